@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/adzimzf/tpot/audit"
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/i18n"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/adzimzf/tpot/ui"
+	"github.com/spf13/cobra"
+)
+
+// hostAction does the picked action other than ssh on the host
+func hostAction(cmd *cobra.Command, proxy *config.Proxy, host string, action ui.Action) error {
+	switch action {
+	case ui.ActionExec:
+		if err := guardReadOnly(proxy, "exec", host); err != nil {
+			return err
+		}
+	case ui.ActionSCP:
+		if err := guardReadOnly(proxy, "scp", host); err != nil {
+			return err
+		}
+	}
+	switch action {
+	case ui.ActionExec:
+		command, err := ui.Input(i18n.Sprintf("Command to run on %s", host))
+		if err != nil {
+			return err
+		}
+		return execOnHosts(cmd, proxy, []string{host}, command, execOptions{})
+	case ui.ActionForward:
+		return forwardAction(cmd, proxy, host)
+	case ui.ActionCopyIP:
+		ip, err := hostIP(&proxy.Node, host)
+		if err != nil {
+			return err
+		}
+		if err := ui.CopyToClipboard(ip); err != nil {
+			return i18n.Errorf("failed to copy %s, error: %v", ip, err)
+		}
+		infof(cmd, "%s of %s is copied to the clipboard\n", ip, host)
+		return nil
+	case ui.ActionInfo:
+		user, err := getUserLogin(cmd, &proxy.Node)
+		if err != nil {
+			return err
+		}
+		return showFacts(cmd, proxy, user, host)
+	case ui.ActionSCP:
+		return scpAction(cmd, proxy, host)
+	}
+	return fmt.Errorf("unsupported action %d", action)
+}
+
+// forwardAction asks the forwarding address & forwards it like the config forwarding
+func forwardAction(cmd *cobra.Command, proxy *config.Proxy, host string) error {
+	addr, err := ui.Input(i18n.T("Forward <local port>:<remote host>:<remote port>"))
+	if err != nil {
+		return err
+	}
+	node, err := config.ParseForward(addr)
+	if err != nil {
+		return err
+	}
+	node.Host = host
+
+	user, err := getUserLogin(cmd, &proxy.Node)
+	if err != nil {
+		return err
+	}
+
+	t := tsh.NewTSH(proxy)
+	if err := openSession(cmd, proxy, t, host); err != nil {
+		return err
+	}
+
+	f := fwd{
+		tsh:         t,
+		env:         proxy.Env,
+		nodeHost:    host,
+		defaultUser: user,
+		list:        []*config.ForwardingNode{node},
+	}
+	auditEvent(audit.Event{Action: audit.ActionForward, Env: proxy.Env, Host: host, User: user, Detail: f.list[0].Address()})
+	return f.Run()
+}
+
+// scpAction asks the direction & the paths then copies the files
+func scpAction(cmd *cobra.Command, proxy *config.Proxy, host string) error {
+	i, err := ui.Select(i18n.Sprintf("Copy files with %s", host), []string{i18n.T("upload"), i18n.T("download")})
+	if err != nil {
+		return err
+	}
+	upload := i == 0
+
+	src, err := ui.Input(i18n.T("Source path"))
+	if err != nil {
+		return err
+	}
+	dst, err := ui.Input(i18n.T("Destination path"))
+	if err != nil {
+		return err
+	}
+
+	user, err := getUserLogin(cmd, &proxy.Node)
+	if err != nil {
+		return err
+	}
+
+	t := tsh.NewTSH(proxy)
+	if err := openSession(cmd, proxy, t, host); err != nil {
+		return err
+	}
+
+	detail := fmt.Sprintf("scp %s into %s:%s", src, host, dst)
+	if !upload {
+		detail = fmt.Sprintf("scp %s:%s into %s", host, src, dst)
+	}
+	auditEvent(audit.Event{Action: audit.ActionExec, Env: proxy.Env, Host: host, User: user, Detail: detail})
+	return t.SCP(user, host, src, dst, upload)
+}
+
+// hostIP returns the IP address of the host without the port
+func hostIP(node *config.Node, host string) (string, error) {
+	item, ok := node.LookUp(host)
+	if !ok {
+		return "", fmt.Errorf("host %s is not found", host)
+	}
+	if item.IsTunnel() {
+		return "", fmt.Errorf("host %s is connected through a tunnel, it has no IP address", host)
+	}
+	return item.IP(), nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/shell"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var aliasCmd = &cobra.Command{
+	Use:   "alias",
+	Short: "List the aliases of the configuration",
+	Long: `Run the user-defined command lines of the aliases configuration by the alias name,
+$1, $2... are replaced by the arguments before the first flag, $@ by all of them,
+the unused arguments & the flags are appended
+
+aliases:
+  deploy-web: prod --filter 'web-*' --exec "sudo systemctl restart app"
+  restart: $1 --filter '$2' --exec "sudo systemctl restart app"`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		names := make([]string, 0, len(cfg.Aliases))
+		for name := range cfg.Aliases {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, name := range names {
+			fmt.Fprintf(w, "%s\t%s\n", name, cfg.Aliases[name])
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(aliasCmd)
+}
+
+// expandAlias expands the alias of the first argument into its command line,
+// the commands & the environments take precedence over the aliases
+func expandAlias(args []string) ([]string, error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return args, nil
+	}
+	if cmd, _, err := rootCmd.Find(args); err != nil || cmd != rootCmd {
+		return args, nil
+	}
+
+	// the command reports the configuration error by itself
+	cfg, err := aliasConfig(args)
+	if err != nil {
+		return args, nil
+	}
+	line, ok := cfg.Aliases[args[0]]
+	if !ok {
+		return args, nil
+	}
+	if _, err := cfg.FindProxy(args[0]); err == nil {
+		return args, nil
+	}
+	return aliasArgs(args[0], line, args[1:])
+}
+
+// aliasConfig loads the configuration before the command is known,
+// only the flags locating the configuration are parsed
+func aliasConfig(args []string) (*config.Config, error) {
+	fs := pflag.NewFlagSet("alias", pflag.ContinueOnError)
+	fs.ParseErrorsWhitelist.UnknownFlags = true
+	fs.SetOutput(ioutil.Discard)
+	isDev := fs.BoolP("developer", "D", false, "")
+	dir := fs.String("config-dir", "", "")
+	profile := fs.String("profile", os.Getenv("TPOT_PROFILE"), "")
+	fs.Parse(args)
+
+	if *dir != "" {
+		config.SetDir(*dir)
+	}
+	if err := config.SetProfile(*profile); err != nil {
+		return nil, err
+	}
+	return config.NewConfig(*isDev)
+}
+
+// aliasParam is the positional parameter of the alias like $1
+var aliasParam = regexp.MustCompile(`\$[1-9][0-9]*`)
+
+// aliasArgs expands the alias command line, $1, $2... are replaced by the
+// arguments before the first flag & $@ by all of them, the unused arguments
+// & the flags are appended
+func aliasArgs(name, line string, args []string) ([]string, error) {
+	words, err := shell.Split(line)
+	if err != nil {
+		return nil, withCode(exitConfig, fmt.Errorf("invalid alias %s, error: %v", name, err))
+	}
+
+	var flags []string
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			args, flags = args[:i], args[i:]
+			break
+		}
+	}
+
+	used := make([]bool, len(args))
+	missing := 0
+	var res []string
+	for _, w := range words {
+		if w == "$@" {
+			res = append(res, args...)
+			for i := range used {
+				used[i] = true
+			}
+			continue
+		}
+		res = append(res, aliasParam.ReplaceAllStringFunc(w, func(p string) string {
+			i, _ := strconv.Atoi(p[1:])
+			if i > len(args) {
+				if i > missing {
+					missing = i
+				}
+				return p
+			}
+			used[i-1] = true
+			return args[i-1]
+		}))
+	}
+	if missing > 0 {
+		return nil, usageErrorf("alias %s needs %d arguments but got %d", name, missing, len(args))
+	}
+
+	for i, arg := range args {
+		if !used[i] {
+			res = append(res, arg)
+		}
+	}
+	return append(res, flags...), nil
+}
@@ -0,0 +1,230 @@
+// Package api serves tpot over a local HTTP API, so the editor extensions,
+// the launchers & the internal portals can drive tpot programmatically
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/filter"
+	"github.com/adzimzf/tpot/metrics"
+)
+
+// ErrNotFound is returned by the Backend when the env or the host is unknown
+var ErrNotFound = errors.New("not found")
+
+// ErrForbidden is returned by the Backend when the policy denies the request
+var ErrForbidden = errors.New("forbidden")
+
+// Env is the environment listed by the API
+type Env struct {
+	Name  string `json:"env"`
+	Badge string `json:"badge,omitempty"`
+	Color string `json:"color,omitempty"`
+	Nodes int    `json:"nodes"`
+}
+
+// Node is the cached node listed by the API
+type Node struct {
+	Hostname string            `json:"hostname"`
+	Address  string            `json:"address"`
+	IP       string            `json:"ip,omitempty"`
+	ID       string            `json:"id,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+}
+
+// Backend does the actual work of the API
+type Backend interface {
+	Envs() ([]Env, error)
+	Nodes(env string) (config.Node, error)
+	Refresh(env string) (config.Node, error)
+	Status(env string) (*config.ProxyStatus, error)
+	ConnectCommand(env, user, host string) (string, error)
+}
+
+// Server is the HTTP handler of the API, every request must have
+// the header Authorization: Bearer <Token>
+type Server struct {
+	Token   string
+	Backend Backend
+
+	// Slack answers the Slack slash commands, nil turns them off,
+	// use SetSlack to replace it while serving
+	Slack *Slack
+	mu    sync.RWMutex
+}
+
+// SetSlack replaces the Slack answering the slash commands while
+// serving, such as the one of the reloaded configuration
+func (s *Server) SetSlack(sl *Slack) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Slack = sl
+}
+
+func (s *Server) slack() *Slack {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Slack
+}
+
+// ServeHTTP implements http.Handler
+//
+//	GET  /v1/envs
+//	GET  /v1/envs/{env}/nodes?filter=web-*, the filter expression of --filter
+//	     without the facts, the fact terms never match
+//	POST /v1/envs/{env}/refresh
+//	GET  /v1/envs/{env}/status
+//	GET  /v1/envs/{env}/connect?user=root&host=web-01
+//	GET  /metrics
+//	GET  /healthz, the only one without the token for the health checks
+//	POST /slack/commands, the Slack slash command signed by Slack instead of the token
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/healthz" {
+		if s.allow(w, r, http.MethodGet) {
+			writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+		}
+		return
+	}
+	if r.URL.Path == "/slack/commands" {
+		s.serveSlack(w, r)
+		return
+	}
+
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, errors.New("invalid token"))
+		return
+	}
+
+	if r.URL.Path == "/metrics" {
+		if s.allow(w, r, http.MethodGet) {
+			w.Header().Set("Content-Type", metrics.ContentType)
+			metrics.Write(w)
+		}
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] != "v1" || parts[1] != "envs" || len(parts) == 3 || len(parts) > 4 {
+		writeError(w, http.StatusNotFound, errors.New("unknown endpoint"))
+		return
+	}
+	if len(parts) == 2 {
+		if s.allow(w, r, http.MethodGet) {
+			envs, err := s.Backend.Envs()
+			writeResult(w, envs, err)
+		}
+		return
+	}
+
+	env := parts[2]
+	switch parts[3] {
+	case "nodes":
+		if s.allow(w, r, http.MethodGet) {
+			s.nodes(w, r, env)
+		}
+	case "refresh":
+		if s.allow(w, r, http.MethodPost) {
+			node, err := s.Backend.Refresh(env)
+			writeResult(w, toNodes(node.Items), err)
+		}
+	case "status":
+		if s.allow(w, r, http.MethodGet) {
+			status, err := s.Backend.Status(env)
+			writeResult(w, status, err)
+		}
+	case "connect":
+		if s.allow(w, r, http.MethodGet) {
+			s.connect(w, r, env)
+		}
+	default:
+		writeError(w, http.StatusNotFound, errors.New("unknown endpoint"))
+	}
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return s.Token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.Token)) == 1
+}
+
+func (s *Server) allow(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	return false
+}
+
+func (s *Server) nodes(w http.ResponseWriter, r *http.Request, env string) {
+	node, err := s.Backend.Nodes(env)
+	if err != nil {
+		writeResult(w, nil, err)
+		return
+	}
+	items := node.Items
+	if expr := r.URL.Query().Get("filter"); expr != "" {
+		f, err := filter.Parse(expr)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid filter, error: %v", err))
+			return
+		}
+		items = node.Select(f, env, nil, nil)
+	}
+	writeResult(w, toNodes(items), nil)
+}
+
+func (s *Server) connect(w http.ResponseWriter, r *http.Request, env string) {
+	user, host := r.URL.Query().Get("user"), r.URL.Query().Get("host")
+	if user == "" || host == "" {
+		writeError(w, http.StatusBadRequest, errors.New("user & host are required"))
+		return
+	}
+	command, err := s.Backend.ConnectCommand(env, user, host)
+	writeResult(w, map[string]string{"command": command}, err)
+}
+
+func toNodes(items []config.Item) []Node {
+	nodes := make([]Node, len(items))
+	for i, item := range items {
+		nodes[i] = Node{
+			Hostname: item.Hostname,
+			Address:  item.Address,
+			IP:       item.IP(),
+			ID:       item.ID,
+			Labels:   item.Labels,
+		}
+	}
+	return nodes
+}
+
+// writeResult writes the result as JSON or the error along with its status
+func writeResult(w http.ResponseWriter, v interface{}, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound) || errors.Is(err, config.ErrEnvNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, ErrForbidden):
+		writeError(w, http.StatusForbidden, err)
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err)
+	default:
+		writeJSON(w, http.StatusOK, v)
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeBackend struct {
+	refreshed string
+}
+
+var fakeNode = config.Node{
+	Status: &config.ProxyStatus{LoginAs: "me", UserLogins: []string{"root"}},
+	Items: []config.Item{
+		{Hostname: "web-01", Address: "10.0.0.1:3022", Labels: map[string]string{"role": "web"}},
+		{Hostname: "db-01", Address: "⟵ Tunnel", ID: "uuid-1"},
+	},
+}
+
+func (f *fakeBackend) Envs() ([]Env, error) {
+	return []Env{{Name: "staging", Nodes: 2}}, nil
+}
+
+func (f *fakeBackend) Nodes(env string) (config.Node, error) {
+	if env != "staging" {
+		return config.Node{}, config.ErrEnvNotFound
+	}
+	return fakeNode, nil
+}
+
+func (f *fakeBackend) Refresh(env string) (config.Node, error) {
+	f.refreshed = env
+	return f.Nodes(env)
+}
+
+func (f *fakeBackend) Status(env string) (*config.ProxyStatus, error) {
+	node, err := f.Nodes(env)
+	return node.Status, err
+}
+
+func (f *fakeBackend) ConnectCommand(env, user, host string) (string, error) {
+	if host == "db-01" {
+		return "", fmt.Errorf("%w, the interactive shell is denied", ErrForbidden)
+	}
+	if host != "web-01" {
+		return "", fmt.Errorf("host %s is %w", host, ErrNotFound)
+	}
+	return "tsh ssh -l " + user + " 10.0.0.1", nil
+}
+
+func TestServer(t *testing.T) {
+	backend := &fakeBackend{}
+	s := &Server{Token: "secret", Backend: backend}
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		token    string
+		wantCode int
+		wantBody string
+	}{
+		{name: "no token", method: "GET", path: "/v1/envs", wantCode: 401},
+		{name: "healthz without token", method: "GET", path: "/healthz", wantCode: 200, wantBody: `{"status":"ok"}`},
+		{name: "wrong token", method: "GET", path: "/v1/envs", token: "guess", wantCode: 401},
+		{name: "envs", method: "GET", path: "/v1/envs", token: "secret", wantCode: 200,
+			wantBody: `[{"env":"staging","nodes":2}]`},
+		{name: "nodes", method: "GET", path: "/v1/envs/staging/nodes?filter=web-*", token: "secret", wantCode: 200,
+			wantBody: `[{"hostname":"web-01","address":"10.0.0.1:3022","ip":"10.0.0.1","labels":{"role":"web"}}]`},
+		{name: "nodes by label", method: "GET", path: "/v1/envs/staging/nodes?filter=label:role%3Dweb+AND+NOT+db", token: "secret", wantCode: 200,
+			wantBody: `[{"hostname":"web-01","address":"10.0.0.1:3022","ip":"10.0.0.1","labels":{"role":"web"}}]`},
+		{name: "nodes invalid filter", method: "GET", path: "/v1/envs/staging/nodes?filter=web+AND", token: "secret", wantCode: 400},
+		{name: "unknown env", method: "GET", path: "/v1/envs/prod/nodes", token: "secret", wantCode: 404},
+		{name: "status", method: "GET", path: "/v1/envs/staging/status", token: "secret", wantCode: 200,
+			wantBody: `{"login_as":"me","roles":null,"user_logins":["root"]}`},
+		{name: "refresh needs POST", method: "GET", path: "/v1/envs/staging/refresh", token: "secret", wantCode: 405},
+		{name: "refresh", method: "POST", path: "/v1/envs/staging/refresh", token: "secret", wantCode: 200},
+		{name: "connect", method: "GET", path: "/v1/envs/staging/connect?user=root&host=web-01", token: "secret", wantCode: 200,
+			wantBody: `{"command":"tsh ssh -l root 10.0.0.1"}`},
+		{name: "connect without host", method: "GET", path: "/v1/envs/staging/connect?user=root", token: "secret", wantCode: 400},
+		{name: "connect denied shell", method: "GET", path: "/v1/envs/staging/connect?user=root&host=db-01", token: "secret", wantCode: 403},
+		{name: "connect unknown host", method: "GET", path: "/v1/envs/staging/connect?user=root&host=x", token: "secret", wantCode: 404},
+		{name: "unknown endpoint", method: "GET", path: "/v1/envs/staging", token: "secret", wantCode: 404},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantCode, w.Code, w.Body.String())
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, w.Body.String())
+			}
+			if tt.wantCode != 200 {
+				var res map[string]string
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				assert.NotEmpty(t, res["error"])
+			}
+		})
+	}
+	assert.Equal(t, "staging", backend.refreshed)
+}
+
+func TestServer_noToken(t *testing.T) {
+	s := &Server{Backend: &fakeBackend{}}
+	r := httptest.NewRequest("GET", "/v1/envs", nil)
+	r.Header.Set("Authorization", "Bearer ")
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "the empty token never authorizes")
+}
+
+func TestServer_metrics(t *testing.T) {
+	s := &Server{Token: "secret", Backend: &fakeBackend{}}
+	r := httptest.NewRequest("GET", "/metrics", nil)
+	r.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/plain")
+
+	r = httptest.NewRequest("GET", "/metrics", nil)
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "the metrics needs the token as well")
+}
@@ -0,0 +1,214 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/filter"
+)
+
+// slackMaxAge is how old the signed Slack request may be, the older
+// one is rejected as it may be replayed
+const slackMaxAge = 5 * time.Minute
+
+// slackMaxHosts is the most hosts listed in a Slack answer
+const slackMaxHosts = 20
+
+// slackUsage is the answer of the unknown slash command
+const slackUsage = "Usage:\n" +
+	"`/tpot find <filter>` finds the cached hosts such as `web-01` or `label:role=web AND env=prod`\n" +
+	"`/tpot envs` lists the environments along with the cached node count"
+
+// Slack answers the Slack slash commands such as /tpot find web-01. It only
+// searches the node caches, it never opens a session nor refreshes a cache
+type Slack struct {
+	// SigningSecret verifies the requests are signed by Slack
+	SigningSecret string
+
+	// Envs is the globs of the environments answered,
+	// empty means every environment
+	Envs []string
+
+	// Now is the current time, it's time.Now when it's nil
+	Now func() time.Time
+}
+
+// slackAnswer is the ephemeral answer only shown to the user typed it
+type slackAnswer struct {
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+}
+
+// serveSlack answers the slash command posted as a form by Slack
+func (s *Server) serveSlack(w http.ResponseWriter, r *http.Request) {
+	sl := s.slack()
+	if sl == nil {
+		writeError(w, http.StatusNotFound, errors.New("unknown endpoint"))
+		return
+	}
+	if !s.allow(w, r, http.MethodPost) {
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := sl.verify(r.Header, body); err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, slackAnswer{ResponseType: "ephemeral", Text: sl.answer(s.Backend, form.Get("text"))})
+}
+
+// verify checks the signature of the request signed by the signing secret
+// https://api.slack.com/authentication/verifying-requests-from-slack
+func (sl *Slack) verify(h http.Header, body []byte) error {
+	if sl.SigningSecret == "" {
+		return errors.New("the Slack signing secret isn't configured")
+	}
+	ts := h.Get("X-Slack-Request-Timestamp")
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return errors.New("invalid Slack request timestamp")
+	}
+	now := time.Now
+	if sl.Now != nil {
+		now = sl.Now
+	}
+	if age := now().Sub(time.Unix(sec, 0)); age > slackMaxAge || age < -slackMaxAge {
+		return errors.New("the Slack request is too old")
+	}
+
+	mac := hmac.New(sha256.New, []byte(sl.SigningSecret))
+	mac.Write([]byte("v0:" + ts + ":"))
+	mac.Write(body)
+	want := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(want), []byte(h.Get("X-Slack-Signature"))) {
+		return errors.New("invalid Slack signature")
+	}
+	return nil
+}
+
+// answer returns the answer of the slash command text such as find web-01
+func (sl *Slack) answer(b Backend, text string) string {
+	command, args := text, ""
+	if i := strings.IndexAny(text, " \t"); i >= 0 {
+		command, args = text[:i], strings.TrimSpace(text[i+1:])
+	}
+	switch strings.TrimSpace(command) {
+	case "find":
+		if args == "" {
+			return "Type what to find, such as `/tpot find web-01`"
+		}
+		return sl.find(b, args)
+	case "envs":
+		return sl.envs(b)
+	}
+	return slackUsage
+}
+
+func (sl *Slack) find(b Backend, expr string) string {
+	f, err := filter.Parse(expr)
+	if err != nil {
+		return fmt.Sprintf("Invalid filter `%s`, error: %v", expr, err)
+	}
+	envs, err := b.Envs()
+	if err != nil {
+		return fmt.Sprintf("Failed to list the environments, error: %v", err)
+	}
+
+	var lines []string
+	total := 0
+	for _, env := range envs {
+		if !sl.answers(env.Name) || !f.MatchEnv(env.Name) {
+			continue
+		}
+		node, err := b.Nodes(env.Name)
+		if err != nil {
+			lines = append(lines, fmt.Sprintf("_failed to read the %s node cache, error: %v_", env.Name, err))
+			continue
+		}
+		for _, item := range node.Select(f, env.Name, nil, nil) {
+			total++
+			if total <= slackMaxHosts {
+				lines = append(lines, slackHost(env.Name, item))
+			}
+		}
+	}
+	if total == 0 {
+		return fmt.Sprintf("No cached host matches `%s`", expr)
+	}
+	if total > slackMaxHosts {
+		lines = append(lines, fmt.Sprintf("_and %d more, narrow down the filter_", total-slackMaxHosts))
+	}
+	return fmt.Sprintf("%d hosts match `%s`\n", total, expr) + strings.Join(lines, "\n")
+}
+
+// slackHost returns the line of the host, such as
+// `web-01` prod 10.0.0.1 role=web
+func slackHost(env string, item config.Item) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "`%s` %s", item.Hostname, env)
+	if item.IsTunnel() {
+		b.WriteString(" tunnel")
+	} else if ip := item.IP(); ip != "" {
+		b.WriteString(" " + ip)
+	}
+	labels := make([]string, 0, len(item.Labels))
+	for k, v := range item.Labels {
+		labels = append(labels, k+"="+v)
+	}
+	sort.Strings(labels)
+	if len(labels) > 0 {
+		b.WriteString(" " + strings.Join(labels, ","))
+	}
+	return b.String()
+}
+
+func (sl *Slack) envs(b Backend) string {
+	envs, err := b.Envs()
+	if err != nil {
+		return fmt.Sprintf("Failed to list the environments, error: %v", err)
+	}
+	var lines []string
+	for _, env := range envs {
+		if sl.answers(env.Name) {
+			lines = append(lines, fmt.Sprintf("`%s` %d nodes", env.Name, env.Nodes))
+		}
+	}
+	if len(lines) == 0 {
+		return "No environment is shared with Slack"
+	}
+	return strings.Join(lines, "\n")
+}
+
+// answers returns true if the env is shared with Slack
+func (sl *Slack) answers(env string) bool {
+	if len(sl.Envs) == 0 {
+		return true
+	}
+	for _, glob := range sl.Envs {
+		if ok, _ := path.Match(glob, env); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func slackRequest(secret string, at time.Time, text string) *http.Request {
+	body := url.Values{"command": {"/tpot"}, "text": {text}}.Encode()
+	ts := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + ts + ":" + body))
+
+	r := httptest.NewRequest(http.MethodPost, "/slack/commands", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set("X-Slack-Request-Timestamp", ts)
+	r.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return r
+}
+
+func TestServer_slack(t *testing.T) {
+	now := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	backend := &fakeBackend{}
+	s := &Server{Token: "secret", Backend: backend,
+		Slack: &Slack{SigningSecret: "slack-secret", Now: func() time.Time { return now }}}
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		code int
+		text string
+	}{
+		{name: "find", req: slackRequest("slack-secret", now, "find web"), code: http.StatusOK,
+			text: "1 hosts match `web`\n`web-01` staging 10.0.0.1 role=web"},
+		{name: "find by label", req: slackRequest("slack-secret", now, "find label:role=web AND env=staging"), code: http.StatusOK,
+			text: "1 hosts match `label:role=web AND env=staging`\n`web-01` staging 10.0.0.1 role=web"},
+		{name: "find tunnel", req: slackRequest("slack-secret", now, "find DB"), code: http.StatusOK,
+			text: "1 hosts match `DB`\n`db-01` staging tunnel"},
+		{name: "find nothing", req: slackRequest("slack-secret", now, "find cache"), code: http.StatusOK,
+			text: "No cached host matches `cache`"},
+		{name: "envs", req: slackRequest("slack-secret", now, "envs"), code: http.StatusOK, text: "`staging` 2 nodes"},
+		{name: "usage", req: slackRequest("slack-secret", now, "ssh web-01"), code: http.StatusOK, text: slackUsage},
+		{name: "wrong secret", req: slackRequest("other", now, "envs"), code: http.StatusUnauthorized},
+		{name: "replayed", req: slackRequest("slack-secret", now.Add(-time.Hour), "envs"), code: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, tt.req)
+			assert.Equal(t, tt.code, w.Code)
+			if tt.code != http.StatusOK {
+				return
+			}
+			var answer slackAnswer
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&answer))
+			assert.Equal(t, "ephemeral", answer.ResponseType)
+			assert.Equal(t, tt.text, answer.Text)
+		})
+	}
+	assert.Empty(t, backend.refreshed)
+
+	// the environments not shared with Slack are never searched
+	s.Slack.Envs = []string{"prod*"}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, slackRequest("slack-secret", now, "find web"))
+	assert.Contains(t, w.Body.String(), "No cached host matches")
+
+	// it's off without the Slack configuration
+	s.SetSlack(nil)
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, slackRequest("slack-secret", now, "envs"))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// the rotated signing secret of the reloaded configuration
+	s.SetSlack(&Slack{SigningSecret: "rotated", Now: func() time.Time { return now }})
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, slackRequest("slack-secret", now, "envs"))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, slackRequest("rotated", now, "envs"))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
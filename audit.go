@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/adzimzf/tpot/audit"
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/spf13/cobra"
+)
+
+// auditLogger is set once the config is loaded with audit enabled
+var auditLogger *audit.Logger
+
+// auditEvent records the event into the audit log, failing to write
+// the audit log must not break the user action, hence only warn
+func auditEvent(e audit.Event) {
+	// nothing is really done on dry run
+	if tsh.DryRun {
+		return
+	}
+	if e.Reason == "" {
+		e.Reason = sessionTicket
+	}
+	if err := auditLogger.Log(e); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING! failed to write the audit log, error: %v\n", err)
+	}
+}
+
+// auditShipTimeout is how long tpot waits the sinks to ship
+// the spooled events before it exits
+const auditShipTimeout = 3 * time.Second
+
+// newAuditLogger creates the audit logger along with the sinks of the
+// configuration & the organization defaults, they're shipped on the background
+func newAuditLogger(c config.Audit, defaults []config.AuditSink) (*audit.Logger, error) {
+	l := audit.NewLogger(c.LogPath(), c.Key())
+	l.OnError(func(err error) {
+		fmt.Fprintf(os.Stderr, "WARNING! %v\n", err)
+	})
+
+	seen := make(map[string]bool)
+	for _, s := range append(append([]config.AuditSink(nil), defaults...), c.Sinks...) {
+		id := s.SpoolID()
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		switch s.Type {
+		case config.AuditSinkSyslog:
+			tag := s.Tag
+			if tag == "" {
+				tag = "tpot"
+			}
+			l.AddSink(id, &audit.SyslogSink{Address: s.Address, Tag: tag}, c.Retries)
+		case config.AuditSinkWebhook:
+			l.AddSink(id, audit.NewWebhookSink(s.URL, s.Headers), c.Retries)
+		case config.AuditSinkFile:
+			l.AddSink(id, &audit.FileSink{Path: s.Path}, c.Retries)
+		default:
+			l.Close(0)
+			return nil, fmt.Errorf("unsupported audit sink %s, use syslog, webhook or file", s.Type)
+		}
+	}
+	return l, nil
+}
+
+const auditExample = `
+tpot audit show                     // Show all the audit events
+tpot audit show -n 20 --verify      // Show the last 20 events & verify the HMAC chain
+tpot audit export -o audit.csv      // Export the audit events as CSV
+tpot audit export --format json     // Export the audit events as JSON lines to stdout
+`
+
+var auditCmd = &cobra.Command{
+	Use:     "audit",
+	Short:   "Show or export the local audit log",
+	Example: auditExample,
+}
+
+var auditShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the audit events",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		events, err := readAuditEvents(cmd)
+		if err != nil {
+			return err
+		}
+
+		limit, _ := cmd.Flags().GetInt("limit")
+		if limit > 0 && limit < len(events) {
+			events = events[len(events)-limit:]
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TIME\tACTOR\tACTION\tENV\tHOST\tUSER\tCOMMAND\tDETAIL\tREASON")
+		for _, e := range events {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				e.Time.Format(time.RFC3339), e.Actor, e.Action, e.Env, e.Host, e.User, e.Command, e.Detail, e.Reason)
+		}
+		return w.Flush()
+	},
+}
+
+var auditExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the audit events as JSON lines or CSV",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		events, err := readAuditEvents(cmd)
+		if err != nil {
+			return err
+		}
+
+		out := io.Writer(os.Stdout)
+		if output, _ := cmd.Flags().GetString("output"); output != "" {
+			f, err := os.OpenFile(output, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+			if err != nil {
+				return fmt.Errorf("failed to create %s, error: %v", output, err)
+			}
+			defer f.Close()
+			out = f
+		}
+
+		format, _ := cmd.Flags().GetString("format")
+		switch format {
+		case "json":
+			err = exportAuditJSON(out, events)
+		case "csv":
+			err = exportAuditCSV(out, events)
+		default:
+			return usageErrorf("unsupported format %s, use json or csv", format)
+		}
+		return err
+	},
+}
+
+func init() {
+	auditCmd.PersistentFlags().Bool("verify", false, "verify the HMAC chain of the audit log")
+	auditShowCmd.Flags().IntP("limit", "n", 0, "only show the last n events")
+	auditExportCmd.Flags().StringP("output", "o", "", "the file to export, default is stdout")
+	auditExportCmd.Flags().String("format", "json", "the export format, json or csv")
+	auditCmd.AddCommand(auditShowCmd, auditExportCmd)
+	rootCmd.AddCommand(auditCmd)
+}
+
+func readAuditEvents(cmd *cobra.Command) ([]audit.Event, error) {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := audit.Read(cfg.Audit.LogPath())
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("audit log is empty, enable it by setting audit.enabled in the configuration")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log, error: %v", err)
+	}
+
+	if verify, _ := cmd.Flags().GetBool("verify"); verify {
+		key := cfg.Audit.Key()
+		if len(key) == 0 {
+			return nil, fmt.Errorf("audit.hmac_key is required to verify the audit log")
+		}
+		if err := audit.Verify(events, key); err != nil {
+			return nil, err
+		}
+		infof(cmd, "%d events are verified\n", len(events))
+	}
+	return events, nil
+}
+
+func exportAuditJSON(w io.Writer, events []audit.Event) error {
+	enc := json.NewEncoder(w)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func exportAuditCSV(w io.Writer, events []audit.Event) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"time", "actor", "action", "env", "host", "user", "command", "detail", "reason", "mac"}); err != nil {
+		return err
+	}
+	for _, e := range events {
+		record := []string{e.Time.Format(time.RFC3339Nano), e.Actor, e.Action, e.Env, e.Host, e.User, e.Command, e.Detail, e.Reason, e.MAC}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,271 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"strings"
+	"sync"
+	"time"
+)
+
+// the list of audited actions
+const (
+	ActionLogin   = "login"
+	ActionConnect = "connect"
+	ActionForward = "forward"
+	ActionExec    = "exec"
+	ActionConfig  = "config"
+	ActionRequest = "request"
+)
+
+// permission is the audit log file permission
+const permission = 0600
+
+// ErrTampered indicates the HMAC chain of the audit log is broken
+var ErrTampered = errors.New("audit log has been tampered")
+
+// Event is a single record of the audit log
+type Event struct {
+	Time    time.Time `json:"time"`
+	Action  string    `json:"action"`
+	Actor   string    `json:"actor"`
+	Env     string    `json:"env,omitempty"`
+	Host    string    `json:"host,omitempty"`
+	User    string    `json:"user,omitempty"`
+	Command string    `json:"command,omitempty"`
+	Detail  string    `json:"detail,omitempty"`
+
+	// Reason is the ticket or the reason given for the session
+	Reason string `json:"reason,omitempty"`
+
+	// Prev is the MAC of the previous event, it chains the events
+	// so removing or editing a line in the middle is detectable
+	Prev string `json:"prev,omitempty"`
+
+	// MAC is the HMAC-SHA256 of the event including Prev
+	MAC string `json:"mac,omitempty"`
+}
+
+// Logger appends the events into JSON lines file
+// a nil Logger is valid and discards all the events
+type Logger struct {
+	path     string
+	key      []byte
+	spoolers []*spooler
+	onError  func(error)
+	mu       sync.Mutex
+
+	// last is the MAC of the last event in the log of the size,
+	// only the events appended by the other processes are read again
+	last string
+	size int64
+}
+
+// NewLogger creates a logger writing into path, the events will be
+// chained using HMAC when the key is not empty
+func NewLogger(path string, key []byte) *Logger {
+	return &Logger{path: path, key: key}
+}
+
+// AddSink ships every logged event into the sink as well on the background,
+// the events are buffered next to the audit log by the spool of the id
+// until they're shipped, the id must be unique per sink & safe as a file name
+func (l *Logger) AddSink(id string, s Sink, retries int) {
+	sp := &spooler{
+		id:      id,
+		sink:    s,
+		path:    l.path + "." + id + ".spool",
+		retries: retries,
+		backoff: 200 * time.Millisecond,
+		onError: l.onError,
+	}
+	sp.start()
+	l.spoolers = append(l.spoolers, sp)
+}
+
+// OnError sets the receiver of the failed shipments to the sinks,
+// it must be set before the sinks are added
+func (l *Logger) OnError(f func(error)) {
+	l.onError = f
+}
+
+// Close waits the sinks to ship the spooled events up to the timeout,
+// the events not shipped in time are shipped by the next run
+func (l *Logger) Close(timeout time.Duration) {
+	if l == nil {
+		return
+	}
+	var wg sync.WaitGroup
+	for _, s := range l.spoolers {
+		wg.Add(1)
+		go func(s *spooler) {
+			defer wg.Done()
+			s.stop(timeout)
+		}(s)
+	}
+	wg.Wait()
+}
+
+// Log appends the event into the audit log
+func (l *Logger) Log(e Event) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.Actor == "" {
+		e.Actor = currentUser()
+	}
+
+	// the log is locked from reading the last MAC until the event is
+	// appended, so the processes logging at the same time don't fork the chain
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_RDWR, permission)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := lockFile(f); err != nil {
+		return err
+	}
+
+	if len(l.key) > 0 {
+		prev, err := l.lastMAC(f)
+		if err != nil {
+			return err
+		}
+		e.Prev = prev
+		e.MAC, err = sign(l.key, e)
+		if err != nil {
+			return err
+		}
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if len(l.key) > 0 {
+		l.last, l.size = e.MAC, l.size+int64(len(line))+1
+	}
+
+	// the event is already stored locally, it's shipped on the background
+	var spoolErrs []string
+	for _, s := range l.spoolers {
+		if err := s.enqueue(e); err != nil {
+			spoolErrs = append(spoolErrs, err.Error())
+		}
+	}
+	if len(spoolErrs) > 0 {
+		return errors.New(strings.Join(spoolErrs, "; "))
+	}
+	return nil
+}
+
+// lastMAC returns the MAC of the last event in the locked log, only the
+// events appended since the previous call are read
+func (l *Logger) lastMAC(f *os.File) (string, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	size := fi.Size()
+	if size == l.size {
+		return l.last, nil
+	}
+	offset := l.size
+	if size < offset {
+		// the log is truncated or rotated, read it from the start
+		offset, l.last = 0, ""
+	}
+	events, err := decode(io.NewSectionReader(f, offset, size-offset))
+	if err != nil {
+		return "", err
+	}
+	if len(events) > 0 {
+		l.last = events[len(events)-1].MAC
+	}
+	l.size = size
+	return l.last, nil
+}
+
+// Read reads all the events from the audit log
+func Read(path string) ([]Event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return decode(f)
+}
+
+func decode(r io.Reader) ([]Event, error) {
+	var events []Event
+	scanner := bufio.NewScanner(r)
+	for i := 1; scanner.Scan(); i++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("invalid event at line %d: %v", i, err)
+		}
+		events = append(events, e)
+	}
+	return events, scanner.Err()
+}
+
+// Verify verifies the HMAC chain of the events
+func Verify(events []Event, key []byte) error {
+	var prev string
+	for i, e := range events {
+		if e.Prev != prev {
+			return fmt.Errorf("%w: event #%d is not chained to the previous event", ErrTampered, i+1)
+		}
+		mac, err := sign(key, e)
+		if err != nil {
+			return err
+		}
+		if !hmac.Equal([]byte(mac), []byte(e.MAC)) {
+			return fmt.Errorf("%w: event #%d has invalid MAC", ErrTampered, i+1)
+		}
+		prev = e.MAC
+	}
+	return nil
+}
+
+// sign computes the MAC of the event excluding its own MAC
+func sign(key []byte, e Event) (string, error) {
+	e.MAC = ""
+	b, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	h := hmac.New(sha256.New, key)
+	h.Write(b)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func currentUser() string {
+	u, err := user.Current()
+	if err != nil {
+		return os.Getenv("USER")
+	}
+	return u.Username
+}
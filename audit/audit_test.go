@@ -0,0 +1,80 @@
+package audit
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger_Chain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	key := []byte("secret")
+	l := NewLogger(path, key)
+
+	assert.NoError(t, l.Log(Event{Action: ActionLogin, Env: "prod"}))
+	assert.NoError(t, l.Log(Event{Action: ActionConnect, Env: "prod", Host: "web-01", User: "root"}))
+	assert.NoError(t, l.Log(Event{Action: ActionExec, Env: "prod", Host: "web-02", Command: "uptime"}))
+
+	events, err := Read(path)
+	assert.NoError(t, err)
+	assert.Len(t, events, 3)
+	assert.Equal(t, events[0].MAC, events[1].Prev)
+	assert.NoError(t, Verify(events, key))
+
+	// wrong key
+	assert.True(t, errors.Is(Verify(events, []byte("other")), ErrTampered))
+
+	// edit the middle event
+	b, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	edited := strings.Replace(string(b), "web-01", "web-99", 1)
+	assert.NoError(t, ioutil.WriteFile(path, []byte(edited), permission))
+	events, err = Read(path)
+	assert.NoError(t, err)
+	assert.True(t, errors.Is(Verify(events, key), ErrTampered))
+
+	// remove the first event
+	assert.True(t, errors.Is(Verify(events[1:], key), ErrTampered))
+}
+
+func TestLogger_concurrentWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	key := []byte("secret")
+	// every logger is a tpot process writing the same log
+	writers := []*Logger{NewLogger(path, key), NewLogger(path, key), NewLogger(path, key), NewLogger(path, key)}
+
+	var wg sync.WaitGroup
+	for _, l := range writers {
+		wg.Add(1)
+		go func(l *Logger) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				assert.NoError(t, l.Log(Event{Action: ActionExec, Env: "prod", Command: "uptime"}))
+			}
+		}(l)
+	}
+	wg.Wait()
+
+	events, err := Read(path)
+	assert.NoError(t, err)
+	assert.Len(t, events, 200)
+	assert.NoError(t, Verify(events, key))
+
+	// the rotated log starts a new chain
+	assert.NoError(t, ioutil.WriteFile(path, nil, permission))
+	assert.NoError(t, writers[0].Log(Event{Action: ActionLogin}))
+	events, err = Read(path)
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.NoError(t, Verify(events, key))
+}
+
+func TestLogger_Nil(t *testing.T) {
+	var l *Logger
+	assert.NoError(t, l.Log(Event{Action: ActionLogin}))
+}
@@ -0,0 +1,12 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package audit
+
+import "os"
+
+// lockFile is not supported on this platform, the processes writing the
+// same audit log at the same time might fork its HMAC chain
+func lockFile(f *os.File) error {
+	return nil
+}
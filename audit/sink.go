@@ -0,0 +1,319 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Sink ships the audit events to a destination other than the local log
+type Sink interface {
+	// Name is the kind of the sink such as webhook
+	Name() string
+
+	// Send ships a single event
+	Send(e Event) error
+}
+
+// FileSink appends the events as JSON lines into a file,
+// such as a file watched by the SIEM agent
+type FileSink struct {
+	Path string
+}
+
+// Name implements Sink
+func (f *FileSink) Name() string {
+	return "file"
+}
+
+// Send implements Sink
+func (f *FileSink) Send(e Event) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, permission)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// WebhookSink posts every event as JSON into an HTTPS endpoint
+type WebhookSink struct {
+	URL     string
+	Headers map[string]string
+	Client  *http.Client
+}
+
+// NewWebhookSink creates a webhook sink with a short timeout,
+// it shouldn't hold the user action for too long
+func NewWebhookSink(url string, headers map[string]string) *WebhookSink {
+	return &WebhookSink{
+		URL:     url,
+		Headers: headers,
+		Client:  &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Name implements Sink
+func (w *WebhookSink) Name() string {
+	return "webhook"
+}
+
+// Send implements Sink
+func (w *WebhookSink) Send(e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("http code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// spooler ships the events into the sink on the background, every event is
+// appended into the spool file first & removed once it's shipped, so the
+// events not shipped yet are retried by the next tpot run. The spool is
+// shared by the tpot processes running at the same time, the spool is
+// locked by path.lock while it's read or written, & path.ship is locked
+// while shipping so only one process ships the spooled events at a time
+type spooler struct {
+	id      string
+	sink    Sink
+	path    string
+	retries int
+	backoff time.Duration
+
+	// mu guards the spool file shared by Log & the worker within the process
+	mu sync.Mutex
+
+	// wake triggers the worker to ship the spool, done stops it
+	wake chan struct{}
+	done chan struct{}
+	wg   sync.WaitGroup
+
+	// onError receives the failed shipments
+	onError func(error)
+}
+
+// start runs the worker shipping the spool until stop is called
+func (s *spooler) start() {
+	s.wake = make(chan struct{}, 1)
+	s.done = make(chan struct{})
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			select {
+			case <-s.wake:
+				s.report(s.ship())
+			case <-s.done:
+				// the last events might be enqueued right before stop
+				s.report(s.ship())
+				return
+			}
+		}
+	}()
+}
+
+// stop waits the worker to ship the remaining spool up to the timeout,
+// the events not shipped in time stay in the spool for the next run
+func (s *spooler) stop(timeout time.Duration) {
+	close(s.done)
+	finished := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(timeout):
+	}
+}
+
+// enqueue appends the event into the spool & wakes the worker up
+func (s *spooler) enqueue(e Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := lockPath(s.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	pending, err := s.read()
+	if err != nil {
+		return err
+	}
+	if err := s.write(append(pending, e)); err != nil {
+		return err
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// ship sends the spooled events in order, once an event is failed
+// the rest are kept in the spool
+func (s *spooler) ship() error {
+	// the other processes only append into the spool while this one ships,
+	// so the sent events are still the first ones of the spool afterwards
+	unlockShip, err := lockPath(s.path + ".ship")
+	if err != nil {
+		return err
+	}
+	defer unlockShip()
+
+	pending, err := s.readLocked()
+	if err != nil || len(pending) == 0 {
+		return err
+	}
+
+	sent := 0
+	var sendErr error
+	for _, p := range pending {
+		if sendErr = s.send(p); sendErr != nil {
+			break
+		}
+		sent++
+	}
+
+	// the events enqueued while sending are appended after the sent ones
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := lockPath(s.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	current, err := s.read()
+	if err != nil {
+		return err
+	}
+	if sent > len(current) {
+		sent = len(current)
+	}
+	if err := s.write(current[sent:]); err != nil {
+		return err
+	}
+	if sendErr != nil {
+		return fmt.Errorf("failed to ship audit event to %s, %d events are spooled: %v", s.id, len(current)-sent, sendErr)
+	}
+	return nil
+}
+
+// readLocked reads the spool while holding its lock
+func (s *spooler) readLocked() ([]Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := lockPath(s.path + ".lock")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	return s.read()
+}
+
+func (s *spooler) report(err error) {
+	if err != nil && s.onError != nil {
+		s.onError(err)
+	}
+}
+
+func (s *spooler) send(e Event) error {
+	var err error
+	for attempt := 0; attempt <= s.retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(s.backoff * time.Duration(attempt))
+		}
+		if err = s.sink.Send(e); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (s *spooler) read() ([]Event, error) {
+	events, err := Read(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return events, err
+}
+
+func (s *spooler) write(events []Event) error {
+	if len(events) == 0 {
+		err := os.Remove(s.path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	// the spool is replaced at once, so the crash in the middle of writing
+	// doesn't leave the truncated spool losing the pending events
+	f, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(permission); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.path)
+}
+
+// lockPath holds the lock of the lock file until unlock is called, the lock
+// file is kept afterwards so every process locks the same file
+func lockPath(path string) (unlock func(), err error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, permission)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	// closing the file releases the lock
+	return func() { f.Close() }, nil
+}
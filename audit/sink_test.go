@@ -0,0 +1,132 @@
+package audit
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type sinkMock struct {
+	mu    sync.Mutex
+	down  bool
+	delay time.Duration
+	sent  []Event
+}
+
+func (s *sinkMock) Name() string {
+	return "mock"
+}
+
+func (s *sinkMock) Send(e Event) error {
+	time.Sleep(s.delay)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.down {
+		return fmt.Errorf("unreachable")
+	}
+	s.sent = append(s.sent, e)
+	return nil
+}
+
+func (s *sinkMock) setDown(down bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.down = down
+}
+
+func (s *sinkMock) actions() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var actions []string
+	for _, e := range s.sent {
+		actions = append(actions, e.Action)
+	}
+	return actions
+}
+
+func TestLogger_AddSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink := &sinkMock{down: true}
+	l := NewLogger(path, nil)
+	l.AddSink("mock-1", sink, 0)
+
+	// the event is still stored locally while the sink is down
+	assert.NoError(t, l.Log(Event{Action: ActionLogin}))
+	assert.NoError(t, l.Log(Event{Action: ActionConnect}))
+	l.Close(time.Second)
+	events, err := Read(path)
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+
+	spooled, err := Read(path + ".mock-1.spool")
+	assert.NoError(t, err)
+	assert.Len(t, spooled, 2)
+	// the spool is replaced by the renamed temporary file
+	tmp, err := filepath.Glob(path + ".mock-1.spool.*.tmp")
+	assert.NoError(t, err)
+	assert.Empty(t, tmp)
+
+	// the spooled events are shipped in order by the next run once the sink is back
+	sink.setDown(false)
+	l = NewLogger(path, nil)
+	l.AddSink("mock-1", sink, 0)
+	assert.NoError(t, l.Log(Event{Action: ActionExec}))
+	l.Close(time.Second)
+	assert.Equal(t, []string{ActionLogin, ActionConnect, ActionExec}, sink.actions())
+	assert.NoFileExists(t, path+".mock-1.spool")
+}
+
+func TestLogger_AddSink_sameType(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	up, down := &sinkMock{}, &sinkMock{down: true}
+	l := NewLogger(path, nil)
+	l.AddSink("webhook-a", up, 0)
+	l.AddSink("webhook-b", down, 0)
+
+	assert.NoError(t, l.Log(Event{Action: ActionLogin}))
+	l.Close(time.Second)
+
+	// every sink of the same type has its own spool
+	assert.Equal(t, []string{ActionLogin}, up.actions())
+	assert.NoFileExists(t, path+".webhook-a.spool")
+	spooled, err := Read(path + ".webhook-b.spool")
+	assert.NoError(t, err)
+	assert.Len(t, spooled, 1)
+}
+
+func TestLogger_AddSink_concurrentProcesses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink := &sinkMock{delay: time.Millisecond}
+
+	// every logger is a tpot process shipping the same spool
+	var wg sync.WaitGroup
+	var want []string
+	for p := 0; p < 2; p++ {
+		l := NewLogger(path, nil)
+		l.AddSink("mock-1", sink, 0)
+		for i := 0; i < 20; i++ {
+			want = append(want, fmt.Sprintf("%d-%d", p, i))
+		}
+		wg.Add(1)
+		go func(p int, l *Logger) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				assert.NoError(t, l.Log(Event{Action: ActionExec, Detail: fmt.Sprintf("%d-%d", p, i)}))
+			}
+			l.Close(5 * time.Second)
+		}(p, l)
+	}
+	wg.Wait()
+
+	// every event is shipped exactly once
+	var got []string
+	for _, e := range sink.sent {
+		got = append(got, e.Detail)
+	}
+	assert.ElementsMatch(t, want, got)
+	assert.NoFileExists(t, path+".mock-1.spool")
+}
@@ -0,0 +1,22 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package audit
+
+import "fmt"
+
+// SyslogSink is not supported on this platform
+type SyslogSink struct {
+	Address string
+	Tag     string
+}
+
+// Name implements Sink
+func (s *SyslogSink) Name() string {
+	return "syslog"
+}
+
+// Send implements Sink
+func (s *SyslogSink) Send(e Event) error {
+	return fmt.Errorf("syslog is not supported on this platform")
+}
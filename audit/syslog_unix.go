@@ -0,0 +1,47 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package audit
+
+import (
+	"encoding/json"
+	"log/syslog"
+	"net/url"
+)
+
+// SyslogSink writes the events as JSON into the syslog
+type SyslogSink struct {
+	// Address is the remote syslog address such as udp://logs.mycomp.com:514,
+	// empty means the local syslog daemon
+	Address string
+	Tag     string
+}
+
+// Name implements Sink
+func (s *SyslogSink) Name() string {
+	return "syslog"
+}
+
+// Send implements Sink
+func (s *SyslogSink) Send(e Event) error {
+	var network, raddr string
+	if s.Address != "" {
+		u, err := url.Parse(s.Address)
+		if err != nil {
+			return err
+		}
+		network, raddr = u.Scheme, u.Host
+	}
+
+	w, err := syslog.Dial(network, raddr, syslog.LOG_INFO|syslog.LOG_AUTH, s.Tag)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return w.Info(string(line))
+}
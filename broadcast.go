@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/adzimzf/tpot/audit"
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/adzimzf/tpot/ui"
+	"github.com/spf13/cobra"
+)
+
+const broadcastExample = `
+tpot broadcast prod --filter 'web-*'        // Open a shell to every production web node & type once for all
+tpot broadcast staging app-01 app-02        // Open a shell to app-01 & app-02 of staging
+`
+
+var broadcastCmd = &cobra.Command{
+	Use:     "broadcast <ENVIRONMENT> [HOST...]",
+	Short:   "Send the typed commands to many nodes at once",
+	Long:    "Open a shell session to every selected node in one UI, every typed line is sent to all the active sessions",
+	Example: broadcastExample,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return usageErrorf("ENVIRONMENT is required")
+		}
+
+		proxy, err := loadProxy(cmd, args[0])
+		if err != nil {
+			return err
+		}
+		node := proxy.Node
+
+		hosts, err := broadcastHosts(cmd, proxy, args[1:])
+		if err != nil {
+			return err
+		}
+
+		if err := guardReadOnly(proxy, "broadcast", hosts...); err != nil {
+			return err
+		}
+		if err := guardShell(proxy, hosts...); err != nil {
+			return err
+		}
+
+		user, err := getUserLogin(cmd, &node)
+		if err != nil {
+			return err
+		}
+
+		t := tsh.NewTSH(proxy)
+		if err := openSession(cmd, proxy, t, fmt.Sprintf("broadcast to %d hosts", len(hosts)), hosts...); err != nil {
+			return err
+		}
+
+		b, err := ui.NewBroadcast(hosts)
+		if err != nil {
+			return err
+		}
+
+		inputs := make(map[string]io.Writer, len(hosts))
+		for _, host := range hosts {
+			auditEvent(audit.Event{Action: audit.ActionConnect, Env: proxy.Env, Host: host, User: user, Detail: "broadcast"})
+			in := newSessionInput()
+			defer in.Close()
+			inputs[host] = in
+
+			out := b.Output(host)
+			go func(host string) {
+				err := t.Shell(user, host, in.reader(), out, out)
+				fmt.Fprintf(out, "\u001B[31;1msession is closed: %v\u001B[0m\n", err)
+			}(host)
+		}
+
+		return b.Run(inputs)
+	},
+}
+
+// broadcastHosts returns the hosts of the arguments & --filter, the reused
+// hostname of the arguments is picked among its instances while every
+// instance matching the filter gets its own session
+func broadcastHosts(cmd *cobra.Command, proxy *config.Proxy, args []string) ([]string, error) {
+	var hosts []string
+	for _, arg := range args {
+		host, err := lookUpHost(proxy, arg)
+		if err != nil {
+			return nil, err
+		}
+		hosts = append(hosts, host)
+	}
+	if filter, _ := cmd.Flags().GetString("filter"); filter != "" {
+		items, err := proxy.FilterNodes(filter)
+		if err != nil {
+			return nil, usageErrorf("invalid --filter, error: %v", err)
+		}
+		filtered := proxy.Node.NamesOf(items)
+		if filtered, err = skipMaintenance(cmd, proxy, filtered); err != nil {
+			return nil, err
+		}
+		hosts = append(hosts, filtered...)
+	}
+	// the host given by both the argument & --filter gets the input once
+	hosts = uniqueHosts(hosts)
+	if len(hosts) == 0 {
+		return nil, usageErrorf("Pick at least one host by the argument or --filter")
+	}
+	return hosts, nil
+}
+
+// uniqueHosts removes the repeated hosts keeping the first order
+func uniqueHosts(hosts []string) []string {
+	seen := make(map[string]bool, len(hosts))
+	res := hosts[:0:0]
+	for _, h := range hosts {
+		if !seen[h] {
+			seen[h] = true
+			res = append(res, h)
+		}
+	}
+	return res
+}
+
+func init() {
+	broadcastCmd.Flags().String("filter", "", "select the nodes match the filter expression")
+	addCIDRFlag(broadcastCmd)
+	broadcastCmd.Flags().StringP("user", "u", "", "user to login to the nodes")
+	addMaintenanceFlag(broadcastCmd)
+	rootCmd.AddCommand(broadcastCmd)
+}
+
+// sessionInput is the stdin of a session, writing never blocks the UI
+// even though the session is slow or already closed
+type sessionInput struct {
+	lines chan string
+	pr    *io.PipeReader
+	pw    *io.PipeWriter
+}
+
+func newSessionInput() *sessionInput {
+	pr, pw := io.Pipe()
+	s := &sessionInput{lines: make(chan string, 64), pr: pr, pw: pw}
+	go func() {
+		for line := range s.lines {
+			if _, err := io.WriteString(pw, line); err != nil {
+				return
+			}
+		}
+		pw.Close()
+	}()
+	return s
+}
+
+func (s *sessionInput) reader() io.Reader {
+	return s.pr
+}
+
+// Write implements io.Writer
+func (s *sessionInput) Write(p []byte) (int, error) {
+	select {
+	case s.lines <- string(p):
+		return len(p), nil
+	default:
+		return 0, fmt.Errorf("session is busy")
+	}
+}
+
+// Close closes the session stdin
+func (s *sessionInput) Close() error {
+	close(s.lines)
+	return nil
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/adzimzf/tpot/ui"
+	"github.com/adzimzf/tpot/wsl"
+	"github.com/spf13/cobra"
+)
+
+// issueURL is the page opening the new GitHub issue of tpot
+const issueURL = "https://github.com/adzimzf/tpot/issues/new"
+
+const bugExample = `
+tpot bug                        // Bundle the bug report & open the prefilled GitHub issue
+tpot bug -o /tmp/tpot-bug.tgz   // Write the bug report into /tmp/tpot-bug.tgz
+tpot bug --no-browser           // Print the issue link instead of opening it
+tpot --trace staging            // Record the debug log of the run bundled by tpot bug
+`
+
+var bugCmd = &cobra.Command{
+	Use:   "bug",
+	Short: "Bundle the bug report & open the prefilled GitHub issue",
+	Long: `Bundle the latest crash report, the versions of tpot & tsh, the configuration with its secrets redacted
+and the debug log of the latest --trace run into a tarball, then open the GitHub issue prefilled by the versions
+& the crash. Nothing is uploaded, review the tarball before attaching it to the issue`,
+	Example: bugExample,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// the broken configuration is likely the bug itself,
+		// the report is bundled without it
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			cmd.PrintErrf("WARNING! failed to load the configuration, error: %v\n", err)
+		}
+
+		out, _ := cmd.Flags().GetString("output")
+		if out == "" {
+			out = "tpot-bug-" + time.Now().Format("20060102-150405") + ".tar.gz"
+		}
+		versions := bugVersions(cmd, cfg)
+		files := []bugFile{{name: "versions.txt", data: []byte(versions)}}
+
+		if b, err := config.SanitizedConfig(); err != nil {
+			cmd.PrintErrf("WARNING! the configuration isn't bundled, error: %v\n", err)
+		} else {
+			files = append(files, bugFile{name: "config.yaml", data: b})
+		}
+		crash, err := latestCrashReport(ui.CrashDir())
+		if err != nil {
+			cmd.PrintErrf("WARNING! the crash report isn't bundled, error: %v\n", err)
+		}
+		if crash != nil {
+			files = append(files, *crash)
+		}
+		if b, err := ioutil.ReadFile(traceLogPath()); err == nil {
+			files = append(files, bugFile{name: "trace.log", data: b})
+		}
+
+		if err := writeBugBundle(out, files); err != nil {
+			return fmt.Errorf("failed to write the bug report, error: %v", err)
+		}
+		infof(cmd, "the bug report is written to %s, review it before attaching it to the issue\n", out)
+
+		link := bugIssueURL(versions, filepath.Base(out), crash)
+		if noBrowser, _ := cmd.Flags().GetBool("no-browser"); !noBrowser {
+			err := tsh.OpenBrowser(bugBrowserCommand(cfg), link, "")
+			if err == nil {
+				return nil
+			}
+			cmd.PrintErrf("WARNING! failed to open the browser, error: %v\n", err)
+		}
+		infof(cmd, "open the link to file the issue\n")
+		fmt.Println(link)
+		return nil
+	},
+}
+
+func init() {
+	bugCmd.Flags().StringP("output", "o", "", "the path of the tarball, default is tpot-bug-<time>.tar.gz in the current directory")
+	bugCmd.Flags().Bool("no-browser", false, "print the issue link instead of opening it")
+	rootCmd.AddCommand(bugCmd)
+}
+
+// bugFile is the file bundled by tpot bug
+type bugFile struct {
+	name string
+	data []byte
+}
+
+// bugVersions returns the versions of tpot, go & tsh along with the directories,
+// tsh is the one of the first environment since it may be set per environment
+func bugVersions(cmd *cobra.Command, cfg *config.Config) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "tpot: %s\n", Version)
+	fmt.Fprintf(&b, "go: %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+
+	proxy := &config.Proxy{}
+	if cfg != nil && len(cfg.Proxies) > 0 {
+		proxy = cfg.Proxies[0]
+	}
+	if v, err := tsh.NewTSH(proxy).Version(); err != nil {
+		// the hint of installing tsh follows the first line
+		fmt.Fprintf(&b, "tsh: unknown, error: %s\n", strings.SplitN(err.Error(), "\n", 2)[0])
+	} else {
+		fmt.Fprintf(&b, "tsh: %s\n", v.Strings())
+	}
+	fmt.Fprintf(&b, "wsl: %t\n", wsl.Detected())
+	if profile, _ := cmd.Flags().GetString("profile"); profile != "" {
+		fmt.Fprintf(&b, "profile: %s\n", profile)
+	}
+	fmt.Fprintf(&b, "config: %s\n", config.Dir)
+	fmt.Fprintf(&b, "cache: %s\n", config.CacheDir)
+	return b.String()
+}
+
+// latestCrashReport returns the newest crash report in the dir,
+// nil when tpot never crashed
+func latestCrashReport(dir string) (*bugFile, error) {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, f := range files {
+		if strings.HasPrefix(f.Name(), "crash-") {
+			names = append(names, f.Name())
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	sort.Strings(names)
+	name := names[len(names)-1]
+	b, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return nil, err
+	}
+	return &bugFile{name: "crash/" + name, data: b}, nil
+}
+
+// writeBugBundle writes the files into the gzipped tarball at path
+func writeBugBundle(path string, files []bugFile) error {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	for _, f := range files {
+		hdr := &tar.Header{Name: "tpot-bug/" + f.name, Mode: 0600, Size: int64(len(f.data)), ModTime: now}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), 0600)
+}
+
+// bugIssueURL returns the link of the new issue prefilled by the versions,
+// the title is the panic of the crash report if any
+func bugIssueURL(versions, bundle string, crash *bugFile) string {
+	title := "Bug report"
+	if crash != nil {
+		if msg := crashPanic(crash.data); msg != "" {
+			title = "Crash: " + msg
+		}
+	}
+	var body strings.Builder
+	body.WriteString("### What happened\n\n\n### Steps to reproduce\n\n\n### Versions\n\n```\n")
+	body.WriteString(versions)
+	body.WriteString("```\n\n")
+	fmt.Fprintf(&body, "Please attach %s bundled by `tpot bug` after reviewing it.\n", bundle)
+
+	q := url.Values{}
+	q.Set("title", title)
+	q.Set("body", body.String())
+	return issueURL + "?" + q.Encode()
+}
+
+// crashPanic returns the panic message of the crash report
+func crashPanic(report []byte) string {
+	s := bufio.NewScanner(bytes.NewReader(report))
+	for s.Scan() {
+		if line := s.Text(); strings.HasPrefix(line, "panic: ") {
+			return strings.TrimPrefix(line, "panic: ")
+		}
+	}
+	return ""
+}
+
+// bugBrowserCommand returns the command opening the issue link,
+// it's the browser_command of the configuration if any
+func bugBrowserCommand(cfg *config.Config) string {
+	if cfg != nil && cfg.BrowserCommand != "" {
+		return cfg.BrowserCommand
+	}
+	if command := wsl.BrowserCommand(); command != "" {
+		return command
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return "open"
+	case "windows":
+		return "rundll32 url.dll,FileProtocolHandler"
+	default:
+		return "xdg-open"
+	}
+}
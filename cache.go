@@ -0,0 +1,303 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/adzimzf/tpot/audit"
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/i18n"
+	"github.com/spf13/cobra"
+)
+
+const cacheExample = `
+tpot cache export prod -o prod-nodes.json                          // Export the production node cache along with its provenance
+tpot cache import prod prod-nodes.json                             // Replace the production node cache by the exported one
+tpot cache import prod prod-nodes.json -a                          // Only append the exported nodes not cached yet
+ssh bastion tpot cache export prod | tpot cache import prod -      // Seed the cache from the bastion
+tpot cache rollback prod --list                                    // List the backups of the production node cache
+tpot cache rollback prod                                           // Roll the production node cache back to the latest backup
+tpot cache rollback prod 3                                         // Roll the production node cache back to the backup version 3
+tpot cache info                                                    // Show the node cache of every environment
+tpot cache clear staging                                           // Remove the staging node cache & facts, it can be rolled back
+tpot cache clear --all                                             // Remove the node cache & facts of every environment
+`
+
+var cacheCmd = &cobra.Command{
+	Use:     "cache",
+	Short:   "Manage the node cache of the environments",
+	Example: cacheExample,
+}
+
+var cacheExportCmd = &cobra.Command{
+	Use:   "export <ENVIRONMENT>",
+	Short: "Export the node cache along with who exported it, when & from which proxy",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return usageErrorf("ENVIRONMENT is required")
+		}
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+		proxy, err := findProxy(cmd, cfg, args[0])
+		if err != nil {
+			return err
+		}
+
+		e, err := proxy.ExportNode(exporterName(), Version)
+		if err != nil {
+			return fmt.Errorf("failed to load the node cache of %s, refresh it by -r first, error: %v", proxy.Env, err)
+		}
+		b, err := json.MarshalIndent(e, "", "  ")
+		if err != nil {
+			return err
+		}
+		b = append(b, '\n')
+
+		output, _ := cmd.Flags().GetString("output")
+		if output == "" {
+			_, err = os.Stdout.Write(b)
+			return err
+		}
+		if err := ioutil.WriteFile(output, b, 0600); err != nil {
+			return fmt.Errorf("failed to write %s, error: %v", output, err)
+		}
+		infof(cmd, "%d nodes of %s are exported into %s\n", len(e.Node.Items), proxy.Env, output)
+		return nil
+	},
+}
+
+var cacheImportCmd = &cobra.Command{
+	Use:   "import <ENVIRONMENT> <FILE>",
+	Short: "Import the exported node cache, FILE - reads the stdin",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return usageErrorf("ENVIRONMENT & FILE are required")
+		}
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+		proxy, err := findProxy(cmd, cfg, args[0])
+		if err != nil {
+			return err
+		}
+
+		var b []byte
+		if args[1] == "-" {
+			b, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			b, err = ioutil.ReadFile(args[1])
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s, error: %v", args[1], err)
+		}
+		e, err := config.ReadCacheExport(b)
+		if err != nil {
+			return err
+		}
+
+		isAppend, _ := cmd.Flags().GetBool("append")
+		force, _ := cmd.Flags().GetBool("force")
+		node, err := proxy.ImportNode(e, isAppend, force)
+		if err != nil {
+			return withCode(exitConfig, fmt.Errorf("%v, pass --force to import it anyway", err))
+		}
+
+		p := e.Provenance
+		auditEvent(audit.Event{Action: audit.ActionConfig, Env: proxy.Env,
+			Detail: fmt.Sprintf("import node cache of %s exported by %s at %s", p.Env, p.ExportedBy, p.ExportedAt.Format(time.RFC3339))})
+		infof(cmd, "the node cache of %s exported by %s at %s from %s is imported, %s has %d nodes now\n",
+			p.Env, p.ExportedBy, p.ExportedAt.Local().Format("2006-01-02 15:04"), p.Proxy, proxy.Env, len(node.Items))
+		return nil
+	},
+}
+
+var cacheRollbackCmd = &cobra.Command{
+	Use:   "rollback <ENVIRONMENT> [VERSION]",
+	Short: "Roll the node cache back to a backup, the latest one by default",
+	Long: "Every refresh backs up the replaced node cache, so a bad refresh such as the scraped empty page can be rolled back. " +
+		"The version 1 is the latest backup, the rolled back cache is backed up as well hence rolling back again undoes it",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return usageErrorf("ENVIRONMENT is required")
+		}
+		version := 1
+		if len(args) > 1 {
+			v, err := strconv.Atoi(args[1])
+			if err != nil {
+				return usageErrorf("invalid VERSION %s, it's the number of tpot cache rollback --list", args[1])
+			}
+			version = v
+		}
+
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+		proxy, err := findProxy(cmd, cfg, args[0])
+		if err != nil {
+			return err
+		}
+
+		if list, _ := cmd.Flags().GetBool("list"); list {
+			return listNodeBackups(proxy)
+		}
+
+		node, err := proxy.RollbackNode(version)
+		if err != nil {
+			return err
+		}
+		auditEvent(audit.Event{Action: audit.ActionConfig, Env: proxy.Env, Detail: fmt.Sprintf("rollback node cache to version %d", version)})
+		infof(cmd, "%s node cache is rolled back to the version %d, it has %d nodes now\n", proxy.Env, version, len(node.Items))
+		return nil
+	},
+}
+
+var cacheInfoCmd = &cobra.Command{
+	Use:   "info [ENVIRONMENT...]",
+	Short: "Show the path, size, nodes, last refresh, source & checksum of the node cache, every environment by default",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+		proxies, err := cacheProxies(cmd, cfg, args, true)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ENV\tNODES\tSIZE\tREFRESHED AT\tSOURCE\tCHECKSUM\tBACKUPS\tPATH")
+		for _, proxy := range proxies {
+			info, err := proxy.CacheInfo()
+			if errors.Is(err, os.ErrNotExist) {
+				// the cleared cache may still be rolled back
+				backups, _ := proxy.NodeBackups()
+				fmt.Fprintf(w, "%s\t-\t-\t-\t-\t-\t%d\t%s\n", proxy.Env, len(backups), i18n.T("not cached"))
+				continue
+			}
+			if err != nil {
+				infof(cmd, "failed to inspect the %s node cache, error: %v\n", proxy.Env, err)
+				continue
+			}
+			source := info.Source
+			if source == "" {
+				source = "-"
+			}
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\t%d\t%s\n", proxy.Env, info.Nodes, formatSize(info.Size),
+				info.RefreshedAt.Local().Format("2006-01-02 15:04:05"), source, info.Checksum, info.Backups, info.Path)
+		}
+		return w.Flush()
+	},
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear <ENVIRONMENT...>",
+	Short: "Remove the node cache & the facts, the cleared cache can be rolled back",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+		all, _ := cmd.Flags().GetBool("all")
+		if len(args) == 0 && !all {
+			return usageErrorf("ENVIRONMENT or --all is required")
+		}
+		proxies, err := cacheProxies(cmd, cfg, args, all)
+		if err != nil {
+			return err
+		}
+
+		for _, proxy := range proxies {
+			if err := proxy.ClearNode(); err != nil {
+				return fmt.Errorf("failed to clear the %s node cache, error: %v", proxy.Env, err)
+			}
+			auditEvent(audit.Event{Action: audit.ActionConfig, Env: proxy.Env, Detail: "clear node cache"})
+			infof(cmd, "%s node cache is cleared, roll it back by tpot cache rollback %s\n", proxy.Env, proxy.Env)
+		}
+		return nil
+	},
+}
+
+func init() {
+	cacheExportCmd.Flags().StringP("output", "o", "", "the file to export, default is stdout")
+	cacheImportCmd.Flags().BoolP("append", "a", false, "only append the nodes not cached yet instead of replacing the cache")
+	cacheImportCmd.Flags().Bool("force", false, "import the node cache exported from another proxy")
+	cacheRollbackCmd.Flags().BoolP("list", "l", false, "list the backups instead of rolling back")
+	cacheClearCmd.Flags().Bool("all", false, "clear the node cache of every environment")
+	cacheCmd.AddCommand(cacheExportCmd, cacheImportCmd, cacheRollbackCmd, cacheInfoCmd, cacheClearCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
+
+// exporterName returns the current user as user@hostname
+func exporterName() string {
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		name += "@" + host
+	}
+	return name
+}
+
+// listNodeBackups prints the backups of the node cache, the latest first
+func listNodeBackups(proxy *config.Proxy) error {
+	backups, err := proxy.NodeBackups()
+	if err != nil {
+		return err
+	}
+	if len(backups) == 0 {
+		return fmt.Errorf("there's no backup of the %s node cache", proxy.Env)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tBACKED UP AT\tNODES")
+	for _, b := range backups {
+		nodes := "-"
+		if n, err := b.Node(); err == nil {
+			nodes = strconv.Itoa(len(n.Items))
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", b.Version, b.Time.Local().Format("2006-01-02 15:04:05"), nodes)
+	}
+	return w.Flush()
+}
+
+// cacheProxies returns the proxies of the environments,
+// every proxy when there's no environment & all is true
+func cacheProxies(cmd *cobra.Command, cfg *config.Config, envs []string, all bool) ([]*config.Proxy, error) {
+	if len(envs) == 0 && all {
+		return cfg.Proxies, nil
+	}
+	proxies := make([]*config.Proxy, 0, len(envs))
+	for _, env := range envs {
+		proxy, err := findProxy(cmd, cfg, env)
+		if err != nil {
+			return nil, err
+		}
+		proxies = append(proxies, proxy)
+	}
+	return proxies, nil
+}
+
+// formatSize returns the size in the binary units such as 1.5 KiB
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
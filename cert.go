@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/adzimzf/tpot/cert"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/adzimzf/tpot/ui"
+	"github.com/spf13/cobra"
+)
+
+var certCmd = &cobra.Command{
+	Use:   "cert <ENVIRONMENT>",
+	Short: "Show the SSH & TLS certificates issued by tsh for the environment",
+	Long: "Show the principals, the extensions, the validity & the CA of the certificates tsh keeps in the Teleport home, " +
+		"to debug the access denied without ssh-keygen -L or openssl",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return usageErrorf("ENVIRONMENT is required")
+		}
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+		proxy, err := findProxy(cmd, cfg, args[0])
+		if err != nil {
+			return err
+		}
+
+		home := tsh.NewTSH(proxy).Home()
+		certs, err := cert.Find(home, proxy.Host())
+		if os.IsNotExist(err) {
+			return fmt.Errorf("there's no certificate of %s in %s, log in first", proxy.Env, home)
+		}
+		if err != nil {
+			return err
+		}
+		for i, c := range certs {
+			if i > 0 {
+				fmt.Println()
+			}
+			printCert(c, time.Now())
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(certCmd)
+}
+
+// certTitles is the title of the certificate kinds
+var certTitles = map[string]string{
+	cert.KindSSH: "SSH certificate",
+	cert.KindTLS: "TLS certificate",
+	cert.KindCA:  "CA certificate",
+}
+
+func printCert(c *cert.Cert, now time.Time) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s %s\n", ui.Colorize(certTitles[c.Kind], "yellow"), c.Path)
+
+	principals, keyID := "Principals", "Key ID"
+	if c.Kind != cert.KindSSH {
+		principals, keyID = "Roles", "Subject"
+	}
+	fmt.Fprintf(w, "  %s\t%s\n", keyID, c.KeyID)
+	if c.Type != "" {
+		fmt.Fprintf(w, "  Type\t%s\n", c.Type)
+	}
+	fmt.Fprintf(w, "  Serial\t%s\n", c.Serial)
+	if c.Kind != cert.KindCA {
+		fmt.Fprintf(w, "  %s\t%s\n", principals, strings.Join(c.Principals, ", "))
+	}
+	fmt.Fprintf(w, "  Valid\t%s\n", certValidity(c, now))
+	if len(c.CriticalOptions) > 0 {
+		fmt.Fprintf(w, "  Critical options\t%s\n", formatOptions(c.CriticalOptions))
+	}
+	if len(c.Extensions) > 0 {
+		fmt.Fprintf(w, "  Extensions\t%s\n", formatOptions(c.Extensions))
+	}
+	fmt.Fprintf(w, "  CA\t%s\n", c.CA)
+	w.Flush()
+}
+
+// certValidity returns the validity along with how long it's still valid
+func certValidity(c *cert.Cert, now time.Time) string {
+	const layout = "2006-01-02 15:04:05"
+	from := c.ValidAfter.Local().Format(layout)
+	if c.ValidBefore.IsZero() {
+		return from + " to forever"
+	}
+	res := from + " to " + c.ValidBefore.Local().Format(layout)
+	switch {
+	case now.Before(c.ValidAfter):
+		return res + " " + ui.Colorize("(not valid yet)", "red")
+	case !c.IsValid(now):
+		return res + " " + ui.Colorize(fmt.Sprintf("(EXPIRED %s ago)", formatDuration(now.Sub(c.ValidBefore))), "red")
+	}
+	return res + " " + ui.Colorize(fmt.Sprintf("(expires in %s)", formatDuration(c.ValidBefore.Sub(now))), "green")
+}
+
+// formatDuration returns the duration in days when it's longer than 2 days,
+// the certificate lifetime is usually hours but the CA's is years
+func formatDuration(d time.Duration) string {
+	if d >= 48*time.Hour {
+		return fmt.Sprintf("%d days", d/(24*time.Hour))
+	}
+	return strings.TrimSuffix(d.Round(time.Minute).String(), "0s")
+}
+
+// formatOptions returns the sorted options, the name only when it has no value
+func formatOptions(opts map[string]string) string {
+	names := make([]string, 0, len(opts))
+	for name := range opts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		if v := opts[name]; v != "" {
+			names[i] = name + "=" + v
+		}
+	}
+	return strings.Join(names, "\n  \t")
+}
@@ -0,0 +1,105 @@
+// Package cert reads the SSH & TLS certificates issued by tsh from the
+// Teleport home directory, it's meant to debug the access denied without
+// ssh-keygen -L or openssl
+package cert
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// the kinds of the certificate
+const (
+	KindSSH = "ssh"
+	KindTLS = "tls"
+	KindCA  = "ca"
+)
+
+// Cert is the parsed certificate
+type Cert struct {
+	Kind string
+	Path string
+
+	// Type is user or host of the SSH certificate
+	Type string
+
+	// KeyID is the key ID of the SSH certificate
+	// or the subject of the TLS certificate
+	KeyID      string
+	Serial     string
+	Principals []string
+
+	// CriticalOptions & Extensions are the SSH certificate options,
+	// the TLS certificate has the extra subject names as the extensions
+	CriticalOptions map[string]string
+	Extensions      map[string]string
+
+	ValidAfter time.Time
+
+	// ValidBefore is zero when the certificate never expires
+	ValidBefore time.Time
+
+	// CA is the SHA256 fingerprint of the SSH signing key
+	// or the issuer of the TLS certificate
+	CA string
+}
+
+// IsValid returns true if the certificate is valid at the time
+func (c *Cert) IsValid(at time.Time) bool {
+	if at.Before(c.ValidAfter) {
+		return false
+	}
+	return c.ValidBefore.IsZero() || at.Before(c.ValidBefore)
+}
+
+// Find reads the certificates of the proxy host in the Teleport home, the
+// SSH certificates are *-cert.pub, the TLS certificates are *-x509.pem & the
+// cluster CAs are in cas/. The missing key directory is os.ErrNotExist
+func Find(home, proxyHost string) ([]*Cert, error) {
+	dir := filepath.Join(home, "keys", proxyHost)
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+
+	var res []*Cert
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		var c *Cert
+		switch name := info.Name(); {
+		case strings.HasSuffix(name, "-cert.pub"):
+			c, err = ReadSSH(path)
+		case strings.HasSuffix(name, "-x509.pem"):
+			c, err = ReadTLS(path, KindTLS)
+		case filepath.Base(filepath.Dir(path)) == "cas" && strings.HasSuffix(name, ".pem"):
+			c, err = ReadTLS(path, KindCA)
+		default:
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		res = append(res, c)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, errors.New("there's no certificate in " + dir)
+	}
+
+	order := map[string]int{KindSSH: 0, KindTLS: 1, KindCA: 2}
+	sort.SliceStable(res, func(i, j int) bool {
+		if order[res[i].Kind] != order[res[j].Kind] {
+			return order[res[i].Kind] < order[res[j].Kind]
+		}
+		return res[i].Path < res[j].Path
+	})
+	return res, nil
+}
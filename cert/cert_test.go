@@ -0,0 +1,92 @@
+package cert
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadSSH(t *testing.T) {
+	c, err := ReadSSH("test/alice-cert.pub")
+	assert.NoError(t, err)
+	assert.Equal(t, KindSSH, c.Kind)
+	assert.Equal(t, "user", c.Type)
+	assert.Equal(t, "alice", c.KeyID)
+	assert.Equal(t, "42", c.Serial)
+	assert.Equal(t, []string{"root", "ubuntu"}, c.Principals)
+	assert.Equal(t, map[string]string{"source-address": "10.0.0.0/8"}, c.CriticalOptions)
+	assert.Equal(t, map[string]string{
+		"permit-X11-forwarding":   "",
+		"permit-agent-forwarding": "",
+		"permit-port-forwarding":  "",
+		"permit-pty":              "",
+		"permit-user-rc":          "",
+		"teleport-roles":          `{"version":"v1","roles":["access"]}`,
+	}, c.Extensions)
+	assert.Equal(t, "SHA256:FjzoB6Ws7ttYj+Jg+pYlb3UcRSFZluqpkhkL0u4D5cI (ssh-ed25519)", c.CA)
+	assert.True(t, c.IsValid(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.False(t, c.IsValid(time.Date(2041, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.False(t, c.IsValid(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestParseSSH_Error(t *testing.T) {
+	b, err := ioutil.ReadFile("test/alice-cert.pub")
+	assert.NoError(t, err)
+
+	for name, s := range map[string]string{
+		"empty":     "",
+		"no base64": "ssh-ed25519-cert-v01@openssh.com",
+		"not key":   "ssh-ed25519-cert-v01@openssh.com !!!",
+		"truncated": string(b[:200]),
+		"plain key": "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIIbm8bLvR0k9s5eXyMhUcNRvXBrYKKHnAHFTm4BYj8Dp",
+	} {
+		_, err := ParseSSH([]byte(s))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestReadTLS(t *testing.T) {
+	c, err := ReadTLS("test/alice-x509.pem", KindTLS)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", c.KeyID)
+	assert.Equal(t, "7", c.Serial)
+	assert.Equal(t, []string{"access", "editor"}, c.Principals)
+	assert.Equal(t, "CN=alice,O=access+O=editor", c.CA)
+	assert.True(t, c.ValidAfter.Before(c.ValidBefore))
+
+	_, err = ParseTLS([]byte("not a PEM"))
+	assert.Error(t, err)
+}
+
+func TestFind(t *testing.T) {
+	home := t.TempDir()
+	_, err := Find(home, "teleport.example.com")
+	assert.True(t, os.IsNotExist(err))
+
+	dir := filepath.Join(home, "keys", "teleport.example.com")
+	for _, f := range []struct{ src, dst string }{
+		{src: "test/alice-cert.pub", dst: "alice-ssh/main-cert.pub"},
+		{src: "test/alice-x509.pem", dst: "alice-x509.pem"},
+		{src: "test/alice-x509.pem", dst: "cas/main.pem"},
+	} {
+		b, err := ioutil.ReadFile(f.src)
+		assert.NoError(t, err)
+		dst := filepath.Join(dir, f.dst)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(dst), 0700))
+		assert.NoError(t, ioutil.WriteFile(dst, b, 0600))
+	}
+	// the private key is skipped
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "alice"), []byte("secret"), 0600))
+
+	certs, err := Find(home, "teleport.example.com")
+	assert.NoError(t, err)
+	var kinds []string
+	for _, c := range certs {
+		kinds = append(kinds, c.Kind)
+	}
+	assert.Equal(t, []string{KindSSH, KindTLS, KindCA}, kinds)
+}
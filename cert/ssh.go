@@ -0,0 +1,169 @@
+package cert
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the number of the public key fields of the certificate type
+// preceding the serial, see PROTOCOL.certkeys of OpenSSH
+var sshKeyFields = map[string]int{
+	"ssh-rsa-cert-v01@openssh.com":                2,
+	"ssh-dss-cert-v01@openssh.com":                4,
+	"ecdsa-sha2-nistp256-cert-v01@openssh.com":    2,
+	"ecdsa-sha2-nistp384-cert-v01@openssh.com":    2,
+	"ecdsa-sha2-nistp521-cert-v01@openssh.com":    2,
+	"ssh-ed25519-cert-v01@openssh.com":            1,
+	"sk-ecdsa-sha2-nistp256-cert-v01@openssh.com": 3,
+	"sk-ssh-ed25519-cert-v01@openssh.com":         2,
+}
+
+var errShortCert = errors.New("the certificate is truncated")
+
+// ReadSSH reads the SSH certificate in the authorized_keys format
+func ReadSSH(path string) (*Cert, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := ParseSSH(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SSH certificate %s, error: %v", path, err)
+	}
+	c.Path = path
+	return c, nil
+}
+
+// ParseSSH parses the SSH certificate in the authorized_keys format
+// such as ssh-ed25519-cert-v01@openssh.com AAAA... comment
+func ParseSSH(b []byte) (*Cert, error) {
+	fields := strings.Fields(string(b))
+	if len(fields) < 2 {
+		return nil, errors.New("the certificate must be <type> <base64>")
+	}
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return nil, err
+	}
+
+	r := &sshReader{b: blob}
+	keyType := string(r.bytes())
+	n, ok := sshKeyFields[keyType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported certificate type %s", keyType)
+	}
+	// the nonce & the public key
+	for i := 0; i < n+1; i++ {
+		r.bytes()
+	}
+
+	c := &Cert{Kind: KindSSH}
+	c.Serial = strconv.FormatUint(r.uint64(), 10)
+	switch r.uint32() {
+	case 1:
+		c.Type = "user"
+	case 2:
+		c.Type = "host"
+	default:
+		c.Type = "unknown"
+	}
+	c.KeyID = string(r.bytes())
+	principals := &sshReader{b: r.bytes()}
+	for len(principals.b) > 0 && principals.err == nil {
+		c.Principals = append(c.Principals, string(principals.bytes()))
+	}
+	c.ValidAfter = sshTime(r.uint64())
+	if before := r.uint64(); before != math.MaxUint64 {
+		c.ValidBefore = sshTime(before)
+	}
+	c.CriticalOptions = sshOptions(r.bytes())
+	c.Extensions = sshOptions(r.bytes())
+	r.bytes() // reserved
+	signer := r.bytes()
+	if r.err != nil || principals.err != nil {
+		return nil, errShortCert
+	}
+	c.CA = fingerprint(signer)
+	return c, nil
+}
+
+// fingerprint returns the OpenSSH SHA256 fingerprint of the public key
+func fingerprint(key []byte) string {
+	sum := sha256.Sum256(key)
+	fp := "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+	if keyType := string((&sshReader{b: key}).bytes()); keyType != "" {
+		fp += " (" + keyType + ")"
+	}
+	return fp
+}
+
+func sshTime(sec uint64) time.Time {
+	if sec > math.MaxInt64 {
+		sec = math.MaxInt64
+	}
+	return time.Unix(int64(sec), 0)
+}
+
+// sshOptions parses the name & the data pairs,
+// the data is the string wrapped in a string
+func sshOptions(b []byte) map[string]string {
+	res := make(map[string]string)
+	r := &sshReader{b: b}
+	for len(r.b) > 0 && r.err == nil {
+		name, data := string(r.bytes()), r.bytes()
+		if len(data) > 0 {
+			inner := &sshReader{b: data}
+			if v := inner.bytes(); inner.err == nil && len(inner.b) == 0 {
+				data = v
+			}
+		}
+		res[name] = string(data)
+	}
+	return res
+}
+
+// sshReader reads the SSH wire format, the first error is kept
+// & the following reads return the zero value
+type sshReader struct {
+	b   []byte
+	err error
+}
+
+func (r *sshReader) bytes() []byte {
+	n := r.uint32()
+	if r.err != nil || uint64(n) > uint64(len(r.b)) {
+		r.err = errShortCert
+		return nil
+	}
+	res := r.b[:n]
+	r.b = r.b[n:]
+	return res
+}
+
+func (r *sshReader) uint32() uint32 {
+	if r.err != nil || len(r.b) < 4 {
+		r.err = errShortCert
+		return 0
+	}
+	v := binary.BigEndian.Uint32(r.b)
+	r.b = r.b[4:]
+	return v
+}
+
+func (r *sshReader) uint64() uint64 {
+	if r.err != nil || len(r.b) < 8 {
+		r.err = errShortCert
+		return 0
+	}
+	v := binary.BigEndian.Uint64(r.b)
+	r.b = r.b[8:]
+	return v
+}
@@ -0,0 +1,76 @@
+package cert
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// ReadTLS reads the first certificate of the PEM file, kind is KindTLS or KindCA
+func ReadTLS(path, kind string) (*Cert, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := ParseTLS(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS certificate %s, error: %v", path, err)
+	}
+	c.Kind, c.Path = kind, path
+	return c, nil
+}
+
+// ParseTLS parses the first certificate of the PEM, the roles of the
+// Teleport identity are the organizations of the subject & the rest of
+// the identity such as the logins are the extra subject names
+func ParseTLS(b []byte) (*Cert, error) {
+	var block *pem.Block
+	for {
+		block, b = pem.Decode(b)
+		if block == nil {
+			return nil, errors.New("there's no PEM certificate")
+		}
+		if block.Type == "CERTIFICATE" {
+			break
+		}
+	}
+	x, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cert{
+		Kind:        KindTLS,
+		KeyID:       x.Subject.CommonName,
+		Serial:      x.SerialNumber.String(),
+		Principals:  x.Subject.Organization,
+		Extensions:  make(map[string]string),
+		ValidAfter:  x.NotBefore,
+		ValidBefore: x.NotAfter,
+		CA:          x.Issuer.String(),
+	}
+	for _, name := range x.Subject.Names {
+		if isStandardName(name.Type.String()) {
+			continue
+		}
+		key := name.Type.String()
+		v := fmt.Sprint(name.Value)
+		if prev, ok := c.Extensions[key]; ok {
+			v = prev + "," + v
+		}
+		c.Extensions[key] = v
+	}
+	if len(x.DNSNames) > 0 {
+		c.Extensions["dns_names"] = strings.Join(x.DNSNames, ",")
+	}
+	return c, nil
+}
+
+// isStandardName reports whether the OID is the attribute of the pkix.Name
+// such as CN & O, they're shown by the other fields
+func isStandardName(oid string) bool {
+	return strings.HasPrefix(oid, "2.5.4.")
+}
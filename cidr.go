@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net"
+	"strings"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/filter"
+	"github.com/adzimzf/tpot/i18n"
+	"github.com/spf13/cobra"
+)
+
+// addCIDRFlag adds --cidr narrowing the nodes of the command
+func addCIDRFlag(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("cidr", nil, "only the nodes inside any of the networks such as 10.12.0.0/16, can be repeated")
+}
+
+// cidrFlag parses the networks of --cidr, it's nil when the flag isn't given
+func cidrFlag(cmd *cobra.Command) ([]*net.IPNet, error) {
+	if cmd == nil || cmd.Flags().Lookup("cidr") == nil {
+		return nil, nil
+	}
+	cidrs, _ := cmd.Flags().GetStringSlice("cidr")
+	var networks []*net.IPNet
+	for _, cidr := range cidrs {
+		network, err := filter.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, usageErrorf("invalid --cidr %s, example: 10.12.0.0/16", cidr)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// narrowCIDR keeps only the nodes of the proxy inside the networks of --cidr
+func narrowCIDR(cmd *cobra.Command, proxy *config.Proxy) error {
+	networks, err := cidrFlag(cmd)
+	if err != nil || len(networks) == 0 {
+		return err
+	}
+	proxy.Node.Items = proxy.Node.FilterCIDR(networks)
+	if len(proxy.Node.Items) == 0 {
+		cidrs, _ := cmd.Flags().GetStringSlice("cidr")
+		return i18n.Errorf("there's no host inside %s", strings.Join(cidrs, ", "))
+	}
+	return nil
+}
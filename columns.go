@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/ui"
+)
+
+// defaultPickerColumns is picker_columns of the configuration,
+// the environment without its own picker_columns shows them
+var defaultPickerColumns []string
+
+// hostColumns returns the picker columns of the proxy hosts along with the
+// width of the hostname, nil when there's no column configured
+func hostColumns(proxy *config.Proxy, defaults []string) ([]ui.Column, int) {
+	columns, err := proxy.Columns(defaults)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING! invalid picker_columns of %s, error: %v\n", proxy.Env, err)
+		return nil, 0
+	}
+	if len(columns) == 0 {
+		return nil, 0
+	}
+
+	var stats map[string]config.HostStats
+	for _, c := range columns {
+		if c.Name == config.ColumnLastUsed || c.Name == config.ColumnLatency {
+			if stats, err = proxy.GetHostStats(); err != nil {
+				fmt.Fprintf(os.Stderr, "WARNING! failed to load the host stats, error: %v\n", err)
+			}
+			break
+		}
+	}
+
+	var meta map[string]config.HostMeta
+	for _, c := range columns {
+		if c.Name == config.ColumnMeta {
+			if meta, err = proxy.GetHostMeta(); err != nil {
+				fmt.Fprintf(os.Stderr, "WARNING! failed to load the host metadata, error: %v\n", err)
+			}
+			break
+		}
+	}
+
+	now := time.Now()
+	names := proxy.Node.Names()
+	res := make([]ui.Column, 0, len(columns)-1)
+	for _, c := range columns[1:] {
+		values := make(map[string]string, len(proxy.Node.Items))
+		for _, item := range proxy.Node.Items {
+			values[names[item.Key()]] = columnValue(c, item, proxy.Node.Source, stats[names[item.Key()]], meta[item.Hostname].Meta, now)
+		}
+		res = append(res, ui.Column{Width: c.Width, Values: values})
+	}
+	return res, columns[0].Width
+}
+
+// columnValue returns the cell of the item in the column, the meta is the enriched metadata of the item
+func columnValue(c config.Column, item config.Item, source string, stats config.HostStats, meta map[string]string, now time.Time) string {
+	switch c.Name {
+	case config.ColumnIP:
+		if item.IsTunnel() {
+			return "tunnel"
+		}
+		return item.IP()
+	case config.ColumnLabels:
+		labels := make([]string, 0, len(item.Labels))
+		for k, v := range item.Labels {
+			labels = append(labels, k+"="+v)
+		}
+		sort.Strings(labels)
+		return strings.Join(labels, ",")
+	case config.ColumnLabel:
+		return item.Labels[c.Label]
+	case config.ColumnMeta:
+		return meta[c.Label]
+	case config.ColumnSource:
+		if item.Source != "" {
+			return item.Source
+		}
+		return source
+	case config.ColumnLastUsed:
+		return formatAgo(stats.LastSuccess, now)
+	case config.ColumnLastSeen:
+		return formatAgo(item.SeenAt(), now)
+	case config.ColumnLatency:
+		if stats.Latency == 0 {
+			return ""
+		}
+		return stats.Latency.Round(time.Millisecond).String()
+	}
+	return ""
+}
+
+// formatAgo returns how long ago the time is such as 5m ago,
+// empty when it's zero
+func formatAgo(t, now time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d/time.Hour))
+	}
+	return fmt.Sprintf("%dd ago", int(d/(24*time.Hour)))
+}
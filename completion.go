@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const completionExample = `
+tpot completion zsh                            // Print the zsh completion script
+tpot completion install                        // Install the completion of the current shell for this user
+tpot completion install fish --system          // Install the fish completion for every user
+tpot completion install bash --dir ./pkg/share // Write the bash completion into the package directory
+`
+
+// completionShells is the shells the completion is generated for
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+var completionCmd = &cobra.Command{
+	Use:       "completion <bash|zsh|fish|powershell>",
+	Short:     "Print the shell completion script",
+	Example:   completionExample,
+	ValidArgs: completionShells,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return usageErrorf("the shell is required, one of %s", strings.Join(completionShells, ", "))
+		}
+		script, err := completionScript(args[0])
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(script)
+		return err
+	},
+}
+
+var completionInstallCmd = &cobra.Command{
+	Use:       "install [bash|zsh|fish]",
+	Short:     "Install the shell completion where the shell loads it, default is the current shell",
+	ValidArgs: completionShells[:3],
+	RunE: func(cmd *cobra.Command, args []string) error {
+		shell := filepath.Base(os.Getenv("SHELL"))
+		if len(args) > 0 {
+			shell = args[0]
+		}
+		script, err := completionScript(shell)
+		if err != nil {
+			return err
+		}
+
+		system, _ := cmd.Flags().GetBool("system")
+		dir, _ := cmd.Flags().GetString("dir")
+		path, err := completionPath(shell, dir, system)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create the completion directory, error: %v", err)
+		}
+		if err := ioutil.WriteFile(path, script, 0644); err != nil {
+			return fmt.Errorf("failed to write the completion, error: %v", err)
+		}
+		infof(cmd, "the %s completion is installed into %s\n", shell, path)
+		if shell == "zsh" && dir == "" && !system {
+			infof(cmd, "add %s to fpath before compinit in ~/.zshrc if it isn't there yet\n", filepath.Dir(path))
+		}
+		return nil
+	},
+}
+
+func init() {
+	completionInstallCmd.Flags().Bool("system", false, "install for every user, it may require root")
+	completionInstallCmd.Flags().String("dir", "", "write the completion into the directory instead, such as the package staging directory")
+	completionCmd.AddCommand(completionInstallCmd)
+	rootCmd.AddCommand(completionCmd)
+}
+
+// completionScript generates the completion script of the shell
+func completionScript(shell string) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	var err error
+	switch shell {
+	case "bash":
+		err = rootCmd.GenBashCompletion(buf)
+	case "zsh":
+		err = rootCmd.GenZshCompletion(buf)
+	case "fish":
+		err = rootCmd.GenFishCompletion(buf, true)
+	case "powershell":
+		err = rootCmd.GenPowerShellCompletion(buf)
+	default:
+		return nil, usageErrorf("unsupported shell %q, it must be one of %s", shell, strings.Join(completionShells, ", "))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate the %s completion, error: %v", shell, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// completionPath returns where the shell loads the completion of tpot,
+// inside the dir when it's given
+func completionPath(shell, dir string, system bool) (string, error) {
+	name := map[string]string{"bash": "tpot", "zsh": "_tpot", "fish": "tpot.fish"}[shell]
+	if name == "" {
+		return "", usageErrorf("installing the %s completion isn't supported, add `tpot completion %s | Out-String | Invoke-Expression` to the profile instead", shell, shell)
+	}
+	if dir != "" {
+		return filepath.Join(dir, name), nil
+	}
+
+	if system {
+		prefix := "/usr"
+		if runtime.GOOS == "darwin" {
+			prefix = homebrewPrefix()
+		}
+		dirs := map[string]string{
+			"bash": prefix + "/share/bash-completion/completions",
+			"zsh":  "/usr/local/share/zsh/site-functions",
+			"fish": prefix + "/share/fish/vendor_completions.d",
+		}
+		if runtime.GOOS == "darwin" {
+			dirs["bash"] = prefix + "/etc/bash_completion.d"
+			dirs["zsh"] = prefix + "/share/zsh/site-functions"
+		}
+		return filepath.Join(dirs[shell], name), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	dirs := map[string]string{
+		"bash": filepath.Join(xdgHome("XDG_DATA_HOME", home, ".local/share"), "bash-completion/completions"),
+		"zsh":  filepath.Join(home, ".zfunc"),
+		"fish": filepath.Join(xdgHome("XDG_CONFIG_HOME", home, ".config"), "fish/completions"),
+	}
+	return filepath.Join(dirs[shell], name), nil
+}
+
+// xdgHome returns the XDG base directory, fallback inside the home
+func xdgHome(env, home, fallback string) string {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(home, fallback)
+}
+
+// homebrewPrefix returns where Homebrew installs, the Apple silicon
+// one is /opt/homebrew unlike /usr/local of the Intel one
+func homebrewPrefix() string {
+	if prefix := os.Getenv("HOMEBREW_PREFIX"); prefix != "" {
+		return prefix
+	}
+	if runtime.GOARCH == "arm64" {
+		return "/opt/homebrew"
+	}
+	return "/usr/local"
+}
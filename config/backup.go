@@ -0,0 +1,148 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultCacheBackups is the number of the previous node caches kept
+// per environment when the configuration doesn't set it
+const DefaultCacheBackups = 5
+
+// cacheBackups is the number of the previous node caches kept
+// per environment, 0 turns the backup off
+var cacheBackups = DefaultCacheBackups
+
+// backupTimeFormat names the backup file, it's sorted by the name
+const backupTimeFormat = "20060102T150405.000000000Z"
+
+// NodeBackup is a previous node cache of an environment
+type NodeBackup struct {
+	// Version is 1 for the latest backup, 2 for the one before it & so on
+	Version int
+	Time    time.Time
+	path    string
+}
+
+// Node reads the node cache of the backup
+func (b NodeBackup) Node() (Node, error) {
+	var n Node
+	raw, err := ioutil.ReadFile(b.path)
+	if err != nil {
+		return n, err
+	}
+	if err := json.Unmarshal(raw, &n); err != nil {
+		return n, fmt.Errorf("invalid backup %s, error: %v", b.path, err)
+	}
+	return n, nil
+}
+
+func backupDir(env string) string {
+	return CacheDir + "backup/" + env + "/"
+}
+
+// backupNode keeps the current node cache of the env before it's replaced
+// by the node, the oldest backups beyond cacheBackups are removed
+func backupNode(env string, n Node) error {
+	if cacheBackups <= 0 {
+		return nil
+	}
+	current, err := store.GetNode(env)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// the empty cache isn't worth to roll back to
+	if len(current.Items) == 0 || reflect.DeepEqual(current, n) {
+		return nil
+	}
+
+	b, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(backupDir(env), 0700); err != nil {
+		return err
+	}
+	name := time.Now().UTC().Format(backupTimeFormat) + ".json"
+	if err := ioutil.WriteFile(backupDir(env)+name, b, permission); err != nil {
+		return err
+	}
+
+	backups, err := nodeBackups(env)
+	if err != nil {
+		return err
+	}
+	if len(backups) <= cacheBackups {
+		return nil
+	}
+	for _, old := range backups[cacheBackups:] {
+		if err := os.Remove(old.path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nodeBackups returns the backups of the env, the latest first
+func nodeBackups(env string) ([]NodeBackup, error) {
+	files, err := ioutil.ReadDir(backupDir(env))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var res []NodeBackup
+	for _, f := range files {
+		t, err := time.Parse(backupTimeFormat, strings.TrimSuffix(f.Name(), ".json"))
+		if err != nil || f.IsDir() {
+			continue
+		}
+		res = append(res, NodeBackup{Time: t, path: backupDir(env) + f.Name()})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Time.After(res[j].Time) })
+	for i := range res {
+		res[i].Version = i + 1
+	}
+	return res, nil
+}
+
+// NodeBackups returns the previous node caches of the proxy, the latest first
+func (p *Proxy) NodeBackups() ([]NodeBackup, error) {
+	return nodeBackups(p.Env)
+}
+
+// RollbackNode replaces the node cache by the backup of the version, the
+// replaced cache is backed up as well hence rolling back again undoes it
+func (p *Proxy) RollbackNode(version int) (Node, error) {
+	backups, err := p.NodeBackups()
+	if err != nil {
+		return Node{}, err
+	}
+	if len(backups) == 0 {
+		return Node{}, fmt.Errorf("there's no backup of the %s node cache", p.Env)
+	}
+	if version < 1 || version > len(backups) {
+		return Node{}, fmt.Errorf("there's no backup version %d of the %s node cache, the versions are 1 to %d", version, p.Env, len(backups))
+	}
+	n, err := backups[version-1].Node()
+	if err != nil {
+		return n, err
+	}
+	if err := p.UpdateNode(n); err != nil {
+		return n, err
+	}
+	p.Node = n
+	return n, nil
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProxy_RollbackNode(t *testing.T) {
+	defer func(s Store, n int) { store, cacheBackups = s, n }(store, cacheBackups)
+	store = fileStore{}
+	tempCacheDir(t)
+	cacheBackups = 2
+
+	p := &Proxy{Env: "prod"}
+	_, err := p.RollbackNode(1)
+	assert.Error(t, err)
+
+	nodes := []Node{
+		{Items: []Item{{Hostname: "web-01"}}},
+		{Items: []Item{{Hostname: "web-01"}, {Hostname: "web-02"}}},
+		{Items: []Item{{Hostname: "web-01"}, {Hostname: "web-02"}, {Hostname: "web-03"}}},
+		// the bad refresh
+		{},
+	}
+	for _, n := range nodes {
+		assert.NoError(t, p.UpdateNode(n))
+	}
+	// the same cache isn't backed up again
+	assert.NoError(t, p.UpdateNode(Node{}))
+
+	backups, err := p.NodeBackups()
+	assert.NoError(t, err)
+	assert.Len(t, backups, 2)
+	assert.Equal(t, 1, backups[0].Version)
+	assert.True(t, backups[0].Time.After(backups[1].Time))
+	n, err := backups[1].Node()
+	assert.NoError(t, err)
+	assert.Equal(t, nodes[1], n)
+
+	_, err = p.RollbackNode(3)
+	assert.Error(t, err)
+	n, err = p.RollbackNode(1)
+	assert.NoError(t, err)
+	assert.Equal(t, nodes[2], n)
+	cached, err := p.GetNode()
+	assert.NoError(t, err)
+	assert.Equal(t, nodes[2], cached)
+
+	// the empty cache rolled back from isn't backed up
+	backups, err = p.NodeBackups()
+	assert.NoError(t, err)
+	assert.Len(t, backups, 2)
+
+	cacheBackups = 0
+	assert.NoError(t, p.UpdateNode(nodes[0]))
+	backups, err = p.NodeBackups()
+	assert.NoError(t, err)
+	assert.Len(t, backups, 2)
+}
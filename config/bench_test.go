@@ -0,0 +1,47 @@
+package config
+
+import (
+	"fmt"
+	"testing"
+)
+
+// benchNode generates n items starting from the offset
+func benchNode(offset, n int) Node {
+	items := make([]Item, n)
+	for i := range items {
+		items[i] = Item{Hostname: fmt.Sprintf("web-%05d", offset+i), Address: fmt.Sprintf("10.0.%d.%d:3022", (offset+i)/256%256, (offset+i)%256)}
+	}
+	return Node{Items: items}
+}
+
+func BenchmarkProxy_AppendNode(b *testing.B) {
+	defer func(s Store) { store = s }(store)
+	for _, n := range []int{1000, 50000} {
+		m := NewMemoryStore()
+		m.nodes["prod"] = benchNode(0, n)
+		store = m
+		// half of the fresh nodes are already cached
+		fresh := benchNode(n/2, n)
+		p := &Proxy{Env: "prod"}
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := p.AppendNode(fresh); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkNode_Filter(b *testing.B) {
+	n := benchNode(0, 50000)
+	for _, pattern := range []string{"web-4", "web-4*"} {
+		b.Run(pattern, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				n.Filter(pattern)
+			}
+		})
+	}
+}
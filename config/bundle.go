@@ -0,0 +1,147 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// BundleSigSuffix is appended to the bundle path or URL to find its signature
+const BundleSigSuffix = ".sig"
+
+// ErrUnverifiedBundle is the bundle synced without verifying the signature
+// because the policy doesn't pin the key, the bundle is still synced
+var ErrUnverifiedBundle = errors.New("the bundle isn't verified")
+
+// Bundle is the configuration distributed by the organization, the
+// proxies & the templates replace the ones of the same name
+type Bundle struct {
+	Proxies   []*Proxy    `yaml:"proxies"`
+	Templates []*Template `yaml:"templates,omitempty"`
+}
+
+// ReadBundle verifies the signature of the bundle by the key pinned in the
+// policy then parses it, the unsigned or tampered bundle is refused when the
+// key is pinned. sig is nil when the bundle isn't signed, the bundle is
+// returned along with ErrUnverifiedBundle when there's no pinned key
+func ReadBundle(b, sig []byte) (*Bundle, error) {
+	verified := false
+	if key := CurrentPolicy().BundleKey; key != "" {
+		if sig == nil {
+			return nil, fmt.Errorf("the bundle isn't signed but the policy %s requires the signed bundles", PolicyPath)
+		}
+		if err := VerifyBundle(b, sig, key); err != nil {
+			return nil, err
+		}
+		verified = true
+	}
+
+	var bundle Bundle
+	if err := yaml.Unmarshal(b, &bundle); err != nil {
+		return nil, fmt.Errorf("invalid bundle, error: %v", err)
+	}
+	if len(bundle.Proxies) == 0 && len(bundle.Templates) == 0 {
+		return nil, fmt.Errorf("the bundle has no proxies nor templates")
+	}
+	for _, p := range bundle.Proxies {
+		if err := p.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid proxy %s of the bundle, %v", p.Env, err)
+		}
+		if err := CurrentPolicy().CheckProxy(p); err != nil {
+			return nil, err
+		}
+	}
+	for _, t := range bundle.Templates {
+		if t.Name == "" {
+			return nil, fmt.Errorf("the template of the bundle has no name")
+		}
+	}
+	if !verified {
+		return &bundle, ErrUnverifiedBundle
+	}
+	return &bundle, nil
+}
+
+// VerifyBundle verifies the base64 ed25519 signature of
+// the bundle by the base64 ed25519 public key
+func VerifyBundle(b, sig []byte, key string) error {
+	pub, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid bundle_key of the policy %s, it must be the base64 ed25519 public key", PolicyPath)
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return fmt.Errorf("invalid signature of the bundle, error: %v", err)
+	}
+	if !ed25519.Verify(pub, b, raw) {
+		return fmt.Errorf("the signature of the bundle doesn't match the key pinned in the policy %s, the bundle may be tampered", PolicyPath)
+	}
+	return nil
+}
+
+// SignBundle returns the base64 ed25519 signature of
+// the bundle by the base64 ed25519 private key
+func SignBundle(b []byte, key string) ([]byte, error) {
+	priv, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+	if err != nil || len(priv) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid private key, it must be the base64 ed25519 private key of tpot config keygen")
+	}
+	sig := ed25519.Sign(priv, b)
+	return []byte(base64.StdEncoding.EncodeToString(sig) + "\n"), nil
+}
+
+// GenerateBundleKey returns the base64 ed25519 key pair to sign the bundles
+func GenerateBundleKey() (public, private string, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return base64.StdEncoding.EncodeToString(pub), base64.StdEncoding.EncodeToString(priv), nil
+}
+
+// SyncBundle replaces the proxies & the templates by the ones of the bundle
+// having the same name, the rest are added. It returns the environments
+// added & updated
+func (c *Config) SyncBundle(b *Bundle) (added, updated []string, err error) {
+	for _, p := range b.Proxies {
+		i := c.proxyIndex(p.Env)
+		if i < 0 {
+			c.Proxies = append(c.Proxies, p)
+			added = append(added, p.Env)
+			continue
+		}
+		c.Proxies[i] = p
+		updated = append(updated, p.Env)
+	}
+	for _, t := range b.Templates {
+		if i := c.templateIndex(t.Name); i >= 0 {
+			c.Templates[i] = t
+		} else {
+			c.Templates = append(c.Templates, t)
+		}
+	}
+	return added, updated, c.save()
+}
+
+func (c *Config) proxyIndex(env string) int {
+	for i, p := range c.Proxies {
+		if p.Env == env {
+			return i
+		}
+	}
+	return -1
+}
+
+func (c *Config) templateIndex(name string) int {
+	for i, t := range c.Templates {
+		if t.Name == name {
+			return i
+		}
+	}
+	return -1
+}
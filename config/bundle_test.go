@@ -0,0 +1,113 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testBundle = `
+proxies:
+- env: prod
+  address: https://teleport.mycomp.com
+  auth_connector: okta
+- env: staging
+  address: https://staging.teleport.mycomp.com
+  auth_connector: okta
+templates:
+- name: cluster
+  address: https://{env}.teleport.mycomp.com
+  auth_connector: okta
+`
+
+func TestReadBundle(t *testing.T) {
+	defer func() { policy = &Policy{} }()
+	public, private, err := GenerateBundleKey()
+	assert.NoError(t, err)
+	sig, err := SignBundle([]byte(testBundle), private)
+	assert.NoError(t, err)
+
+	// there's no pinned key
+	policy = &Policy{}
+	b, err := ReadBundle([]byte(testBundle), nil)
+	assert.True(t, errors.Is(err, ErrUnverifiedBundle))
+	assert.Len(t, b.Proxies, 2)
+
+	policy = &Policy{BundleKey: public}
+	b, err = ReadBundle([]byte(testBundle), sig)
+	assert.NoError(t, err)
+	assert.Len(t, b.Proxies, 2)
+	assert.Equal(t, "cluster", b.Templates[0].Name)
+
+	_, err = ReadBundle([]byte(testBundle), nil)
+	assert.EqualError(t, err, "the bundle isn't signed but the policy "+PolicyPath+" requires the signed bundles")
+
+	// the rogue proxy
+	tampered := []byte(testBundle + "- env: evil\n  address: https://evil.example.com\n  auth_connector: okta\n")
+	_, err = ReadBundle(tampered, sig)
+	assert.Error(t, err)
+
+	// signed by another key
+	_, otherPrivate, err := GenerateBundleKey()
+	assert.NoError(t, err)
+	otherSig, err := SignBundle([]byte(testBundle), otherPrivate)
+	assert.NoError(t, err)
+	_, err = ReadBundle([]byte(testBundle), otherSig)
+	assert.Error(t, err)
+
+	_, err = ReadBundle([]byte(testBundle), []byte("not base64"))
+	assert.Error(t, err)
+
+	invalid := []byte("proxies:\n- env: prod\n  address: teleport\n")
+	invalidSig, err := SignBundle(invalid, private)
+	assert.NoError(t, err)
+	_, err = ReadBundle(invalid, invalidSig)
+	assert.Error(t, err)
+}
+
+func TestConfig_SyncBundle(t *testing.T) {
+	tempConfigDir(t)
+	c := &Config{
+		Proxies: []*Proxy{
+			{Env: "prod", Address: "https://old.mycomp.com", AuthConnector: "okta"},
+			{Env: "local", Address: "https://localhost:3080", UserName: "adzim"},
+		},
+		Templates: []*Template{{Name: "cluster", Proxy: Proxy{Address: "https://old-{env}.mycomp.com"}}},
+	}
+	b, err := ReadBundle([]byte(testBundle), nil)
+	assert.True(t, errors.Is(err, ErrUnverifiedBundle))
+
+	added, updated, err := c.SyncBundle(b)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"staging"}, added)
+	assert.Equal(t, []string{"prod"}, updated)
+
+	saved, err := getConfig()
+	assert.NoError(t, err)
+	assert.Len(t, saved.Proxies, 3)
+	prod, err := saved.FindProxy("prod")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://teleport.mycomp.com", prod.Address)
+	_, err = saved.FindProxy("local")
+	assert.NoError(t, err)
+	assert.Len(t, saved.Templates, 1)
+	assert.Equal(t, "https://{env}.teleport.mycomp.com", saved.Templates[0].Address)
+}
+
+func TestLoadPolicy_BundleKey(t *testing.T) {
+	defer func(path string) { PolicyPath = path }(PolicyPath)
+	PolicyPath = t.TempDir() + "/policy.yaml"
+
+	public, _, err := GenerateBundleKey()
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(PolicyPath, []byte("bundle_key: "+public+"\n"), 0600))
+	p, err := LoadPolicy()
+	assert.NoError(t, err)
+	assert.Equal(t, public, p.BundleKey)
+
+	assert.NoError(t, ioutil.WriteFile(PolicyPath, []byte("bundle_key: c2hvcnQ=\n"), 0600))
+	_, err = LoadPolicy()
+	assert.Error(t, err)
+}
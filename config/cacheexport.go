@@ -0,0 +1,108 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// CacheExport is the node cache of an environment exported to seed the
+// cache of another machine, such as the air-gapped one
+type CacheExport struct {
+	Provenance Provenance `json:"provenance"`
+	Node       Node       `json:"node"`
+}
+
+// Provenance tells who exported the node cache, when & from which proxy
+type Provenance struct {
+	// ExportedBy is the exporter as user@hostname
+	ExportedBy string    `json:"exported_by"`
+	ExportedAt time.Time `json:"exported_at"`
+	Env        string    `json:"env"`
+	Proxy      string    `json:"proxy"`
+
+	// Version is the tpot version of the exporter
+	Version string `json:"tpot_version,omitempty"`
+}
+
+// ExportNode returns the node cache of the proxy along with its provenance
+func (p *Proxy) ExportNode(exportedBy, version string) (*CacheExport, error) {
+	n, err := p.GetNode()
+	if err != nil {
+		return nil, err
+	}
+	return &CacheExport{
+		Provenance: Provenance{
+			ExportedBy: exportedBy,
+			ExportedAt: time.Now().UTC().Truncate(time.Second),
+			Env:        p.Env,
+			Proxy:      p.Address,
+			Version:    version,
+		},
+		Node: n,
+	}, nil
+}
+
+// ReadCacheExport parses the exported node cache, the file without
+// the provenance or the nodes is refused
+func ReadCacheExport(b []byte) (*CacheExport, error) {
+	var e CacheExport
+	if err := json.Unmarshal(b, &e); err != nil {
+		return nil, fmt.Errorf("invalid node cache export, error: %v", err)
+	}
+	if e.Provenance.Proxy == "" || e.Provenance.ExportedAt.IsZero() {
+		return nil, fmt.Errorf("the node cache export has no provenance")
+	}
+	if len(e.Node.Items) == 0 {
+		return nil, fmt.Errorf("the node cache export has no node")
+	}
+	return &e, nil
+}
+
+// ImportNode replaces the node cache by the exported one, or only appends
+// the nodes not cached yet. The export of another proxy is refused unless
+// it's forced since its nodes aren't reachable through this proxy
+func (p *Proxy) ImportNode(e *CacheExport, appendNode, force bool) (Node, error) {
+	if !force && proxyHost(e.Provenance.Proxy) != proxyHost(p.Address) {
+		return Node{}, fmt.Errorf("the node cache is exported from the proxy %s but %s is %s",
+			e.Provenance.Proxy, p.Env, p.Address)
+	}
+
+	n := e.Node
+	if appendNode {
+		var err error
+		if n, err = p.AppendNode(e.Node); err != nil {
+			return n, err
+		}
+		if n.Status == nil {
+			n.Status = e.Node.Status
+		}
+	}
+	n.Source = SourceImport
+	if err := p.UpdateNode(n); err != nil {
+		return n, err
+	}
+	p.Node = n
+	return n, nil
+}
+
+// Host returns the host of the proxy address without the port,
+// tsh keeps the keys of the proxy in the directory of this name
+func (p *Proxy) Host() string {
+	return proxyHost(p.Address)
+}
+
+// proxyHost returns the host of the proxy address to compare
+// the addresses written differently such as along with the port
+func proxyHost(address string) string {
+	if !strings.Contains(address, "://") {
+		address = "https://" + address
+	}
+	u, err := url.Parse(address)
+	if err != nil || u.Hostname() == "" {
+		return strings.ToLower(strings.TrimSuffix(address, "/"))
+	}
+	return strings.ToLower(u.Hostname())
+}
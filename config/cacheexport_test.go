@@ -0,0 +1,72 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProxy_ExportNode(t *testing.T) {
+	defer func(s Store) { store = s }(store)
+	store = NewMemoryStore()
+	tempCacheDir(t)
+
+	p := &Proxy{Env: "prod", Address: "https://teleport.example.com"}
+	_, err := p.ExportNode("me@laptop", "1.0.0")
+	assert.Error(t, err)
+
+	node := Node{Status: &ProxyStatus{LoginAs: "me"}, Items: []Item{{Hostname: "web-01", Address: "10.0.0.1:3022"}}}
+	assert.NoError(t, p.UpdateNode(node))
+	e, err := p.ExportNode("me@laptop", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, node, e.Node)
+	assert.Equal(t, "me@laptop", e.Provenance.ExportedBy)
+	assert.Equal(t, "https://teleport.example.com", e.Provenance.Proxy)
+	assert.Equal(t, "prod", e.Provenance.Env)
+
+	b, err := json.Marshal(e)
+	assert.NoError(t, err)
+	got, err := ReadCacheExport(b)
+	assert.NoError(t, err)
+	assert.Equal(t, e.Provenance.ExportedAt.Unix(), got.Provenance.ExportedAt.Unix())
+	assert.Equal(t, node, got.Node)
+
+	_, err = ReadCacheExport([]byte(`{"node":{"items":[{"hostname":"web-01"}]}}`))
+	assert.Error(t, err)
+	_, err = ReadCacheExport([]byte(`{"provenance":{"proxy":"https://teleport.example.com","exported_at":"2026-10-16T00:00:00Z"},"node":{}}`))
+	assert.Error(t, err)
+	_, err = ReadCacheExport([]byte(`[]`))
+	assert.Error(t, err)
+}
+
+func TestProxy_ImportNode(t *testing.T) {
+	defer func(s Store) { store = s }(store)
+	store = NewMemoryStore()
+	tempCacheDir(t)
+
+	e := &CacheExport{
+		Provenance: Provenance{Proxy: "https://Teleport.example.com:443", Env: "prod"},
+		Node:       Node{Items: []Item{{Hostname: "web-01"}, {Hostname: "web-02"}}},
+	}
+
+	other := &Proxy{Env: "dev", Address: "https://teleport.dev.example.com"}
+	_, err := other.ImportNode(e, false, false)
+	assert.Error(t, err)
+	_, err = other.ImportNode(e, false, true)
+	assert.NoError(t, err)
+
+	p := &Proxy{Env: "staging", Address: "teleport.example.com"}
+	assert.NoError(t, p.UpdateNode(Node{Status: &ProxyStatus{LoginAs: "me"}, Items: []Item{{Hostname: "db-01"}, {Hostname: "web-01"}}}))
+	n, err := p.ImportNode(e, true, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"db-01", "web-01", "web-02"}, n.ListHostname())
+	assert.Equal(t, "me", n.Status.LoginAs)
+
+	n, err = p.ImportNode(e, false, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"web-01", "web-02"}, n.ListHostname())
+	cached, err := p.GetNode()
+	assert.NoError(t, err)
+	assert.Equal(t, n, cached)
+}
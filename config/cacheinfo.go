@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// the sources of the node cache
+const (
+	SourceTSH      = "tsh"
+	SourceScrapper = "scrapper"
+	SourceImport   = "import"
+)
+
+// the checksum states of the node cache
+const (
+	ChecksumValid    = "valid"
+	ChecksumMismatch = "mismatch"
+
+	// ChecksumMissing is the cache written by the older version
+	ChecksumMissing = "missing"
+
+	// ChecksumNone is the backend verifying the integrity by itself
+	ChecksumNone = "n/a"
+)
+
+// CacheInfo describes the node cache of an environment
+type CacheInfo struct {
+	Backend string
+
+	// Path is the file of the cache, the sqlite
+	// database is shared by every environment
+	Path        string
+	Size        int64
+	Nodes       int
+	RefreshedAt time.Time
+
+	// Source is where the nodes are fetched from, it's empty
+	// for the cache written by the older version
+	Source   string
+	Checksum string
+	Backups  int
+}
+
+// CacheInfo describes the node cache of the proxy,
+// the missing cache is reported as os.ErrNotExist
+func (p *Proxy) CacheInfo() (CacheInfo, error) {
+	info, err := store.Info(p.Env)
+	if err != nil {
+		return info, err
+	}
+	backups, err := p.NodeBackups()
+	if err != nil {
+		return info, err
+	}
+	info.Backups = len(backups)
+	return info, nil
+}
+
+// ClearNode removes the node cache & the facts of the proxy, the
+// cleared cache is backed up hence it can be rolled back
+func (p *Proxy) ClearNode() error {
+	if err := backupNode(p.Env, Node{}); err != nil {
+		return fmt.Errorf("failed to back up the %s node cache, error: %v", p.Env, err)
+	}
+	if err := store.DeleteNode(p.Env); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	p.Node = Node{}
+	return nil
+}
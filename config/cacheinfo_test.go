@@ -0,0 +1,72 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProxy_CacheInfo(t *testing.T) {
+	defer func(s Store, n int) { store, cacheBackups = s, n }(store, cacheBackups)
+	store = fileStore{}
+	tempCacheDir(t)
+	cacheBackups = DefaultCacheBackups
+
+	p := &Proxy{Env: "prod"}
+	_, err := p.CacheInfo()
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+
+	assert.NoError(t, p.UpdateNode(Node{Items: []Item{{Hostname: "web-01"}}, Source: SourceTSH}))
+	assert.NoError(t, p.UpdateNode(Node{Items: []Item{{Hostname: "web-01"}, {Hostname: "web-02"}}, Source: SourceScrapper}))
+	info, err := p.CacheInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, StorageFile, info.Backend)
+	assert.Equal(t, CacheDir+"node_prod.json", info.Path)
+	assert.Equal(t, 2, info.Nodes)
+	assert.Equal(t, SourceScrapper, info.Source)
+	assert.Equal(t, ChecksumValid, info.Checksum)
+	assert.Equal(t, 1, info.Backups)
+	assert.NotZero(t, info.Size)
+	assert.False(t, info.RefreshedAt.IsZero())
+
+	// the cache edited by hand
+	assert.NoError(t, ioutil.WriteFile(info.Path, []byte(`{"items":[]}`), permission))
+	info, err = p.CacheInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, ChecksumMismatch, info.Checksum)
+
+	// the cache written by the older version
+	assert.NoError(t, os.Remove(CacheDir+"node_prod.sha256"))
+	info, err = p.CacheInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, ChecksumMissing, info.Checksum)
+}
+
+func TestProxy_ClearNode(t *testing.T) {
+	defer func(s Store, n int) { store, cacheBackups = s, n }(store, cacheBackups)
+	store = fileStore{}
+	tempCacheDir(t)
+	cacheBackups = DefaultCacheBackups
+
+	p := &Proxy{Env: "prod"}
+	assert.NoError(t, p.ClearNode())
+
+	node := Node{Items: []Item{{Hostname: "web-01"}}}
+	assert.NoError(t, p.UpdateNode(node))
+	assert.NoError(t, store.UpdateFacts("prod", "web-01", HostFacts{Facts: map[string]string{"os": "ubuntu"}}))
+
+	assert.NoError(t, p.ClearNode())
+	_, err := p.GetNode()
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+	facts, err := store.GetFacts("prod")
+	assert.NoError(t, err)
+	assert.Empty(t, facts)
+
+	// the cleared cache can be rolled back
+	n, err := p.RollbackNode(1)
+	assert.NoError(t, err)
+	assert.Equal(t, node, n)
+}
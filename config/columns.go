@@ -0,0 +1,108 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// the columns of the picker
+const (
+	ColumnHostname = "hostname"
+	ColumnIP       = "ip"
+	ColumnLabels   = "labels"
+	ColumnSource   = "source"
+	ColumnLastUsed = "last_used"
+	ColumnLastSeen = "last_seen"
+	ColumnLatency  = "latency"
+
+	// ColumnLabel is the prefix of the column of a single label such as label.team
+	ColumnLabel = "label."
+
+	// ColumnMeta is the prefix of the column of an enriched metadata such as meta.team
+	ColumnMeta = "meta."
+)
+
+// columnWidths is the default width of the columns
+var columnWidths = map[string]int{
+	ColumnHostname: 30,
+	ColumnIP:       15,
+	ColumnLabels:   40,
+	ColumnSource:   8,
+	ColumnLastUsed: 10,
+	ColumnLastSeen: 10,
+	ColumnLatency:  8,
+	ColumnLabel:    12,
+	ColumnMeta:     12,
+}
+
+// Column is a column of the picker parsed from picker_columns
+type Column struct {
+	Name string
+
+	// Label is the label key of the ColumnLabel column,
+	// or the metadata key of the ColumnMeta column
+	Label string
+
+	Width int
+}
+
+// ParseColumns parses the picker columns such as ip:15 or label.team,
+// the width after the colon is optional. The hostname is always the first
+// column whether it's listed or not, it's nil when there's no column
+func ParseColumns(specs []string) ([]Column, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	res := []Column{{Name: ColumnHostname, Width: columnWidths[ColumnHostname]}}
+	seen := map[string]bool{}
+	for i, spec := range specs {
+		name, width := strings.TrimSpace(spec), 0
+		if j := strings.LastIndex(name, ":"); j >= 0 {
+			w, err := strconv.Atoi(name[j+1:])
+			if err != nil || w < 1 {
+				return nil, fmt.Errorf("invalid width of the column %s", spec)
+			}
+			name, width = name[:j], w
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("the column %s is listed twice", name)
+		}
+		seen[name] = true
+
+		c := Column{Name: name, Width: width}
+		for _, prefix := range []string{ColumnLabel, ColumnMeta} {
+			if strings.HasPrefix(name, prefix) {
+				c.Name, c.Label = prefix, strings.TrimPrefix(name, prefix)
+				if c.Label == "" {
+					return nil, fmt.Errorf("the key of the column %s is missing", spec)
+				}
+			}
+		}
+		if _, ok := columnWidths[c.Name]; !ok {
+			return nil, fmt.Errorf("unknown column %s, use hostname, ip, labels, label.<key>, meta.<key>, source, last_used, last_seen or latency", name)
+		}
+		if c.Width == 0 {
+			c.Width = columnWidths[c.Name]
+		}
+
+		if c.Name == ColumnHostname {
+			if i > 0 {
+				return nil, fmt.Errorf("hostname must be the first column")
+			}
+			res[0] = c
+			continue
+		}
+		res = append(res, c)
+	}
+	return res, nil
+}
+
+// Columns returns the picker columns of the proxy, the proxy
+// without picker_columns has the columns of the configuration
+func (p *Proxy) Columns(defaults []string) ([]Column, error) {
+	if len(p.PickerColumns) > 0 {
+		return ParseColumns(p.PickerColumns)
+	}
+	return ParseColumns(defaults)
+}
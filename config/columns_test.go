@@ -0,0 +1,69 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseColumns(t *testing.T) {
+	tests := []struct {
+		name  string
+		specs []string
+		want  []Column
+		err   bool
+	}{
+		{name: "no column"},
+		{
+			name:  "hostname first by default",
+			specs: []string{"ip", "label.team:10", "latency"},
+			want: []Column{
+				{Name: ColumnHostname, Width: 30},
+				{Name: ColumnIP, Width: 15},
+				{Name: ColumnLabel, Label: "team", Width: 10},
+				{Name: ColumnLatency, Width: 8},
+			},
+		},
+		{name: "unknown column", specs: []string{"kubernetes.io/role"}, err: true},
+		{
+			name:  "label key with dots",
+			specs: []string{"hostname:20", "label.kubernetes.io/role", "last_used"},
+			want: []Column{
+				{Name: ColumnHostname, Width: 20},
+				{Name: ColumnLabel, Label: "kubernetes.io/role", Width: 12},
+				{Name: ColumnLastUsed, Width: 10},
+			},
+		},
+		{
+			name:  "meta",
+			specs: []string{"meta.team", "meta.rack:6"},
+			want: []Column{
+				{Name: ColumnHostname, Width: 30},
+				{Name: ColumnMeta, Label: "team", Width: 12},
+				{Name: ColumnMeta, Label: "rack", Width: 6},
+			},
+		},
+		{name: "hostname not first", specs: []string{"ip", "hostname"}, err: true},
+		{name: "twice", specs: []string{"ip", "ip:20"}, err: true},
+		{name: "invalid width", specs: []string{"ip:0"}, err: true},
+		{name: "missing label", specs: []string{"label."}, err: true},
+		{name: "missing meta", specs: []string{"meta."}, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseColumns(tt.specs)
+			assert.Equal(t, tt.err, err != nil, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestProxy_Columns(t *testing.T) {
+	got, err := (&Proxy{}).Columns([]string{"ip"})
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+
+	got, err = (&Proxy{PickerColumns: []string{"source", "latency"}}).Columns([]string{"ip"})
+	assert.NoError(t, err)
+	assert.Equal(t, []Column{{Name: ColumnHostname, Width: 30}, {Name: ColumnSource, Width: 8}, {Name: ColumnLatency, Width: 8}}, got)
+}
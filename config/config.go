@@ -1,12 +1,14 @@
 package config
 
 import (
-	"encoding/json"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/adzimzf/tpot/editor"
 	"github.com/manifoldco/promptui"
@@ -14,9 +16,12 @@ import (
 )
 
 var (
-	// Dir is the path where tpot store the configuration & cache
-	// Dir will be overridden by flag -D
-	Dir = os.Getenv("HOME") + "/.tpot/"
+	// Dir is the path where tpot store the configuration
+	// Dir will be overridden by flag -D or --config-dir
+	Dir = xdgDir("XDG_CONFIG_HOME", ".config")
+
+	// CacheDir is the path where tpot store the node cache
+	CacheDir = xdgDir("XDG_CACHE_HOME", ".cache")
 
 	// ErrValidateConfig is an error to indicate config is invalid
 	ErrValidateConfig = errors.New("config is invalid")
@@ -34,8 +39,283 @@ type Config struct {
 
 	// Proxies is list of proxy configuration
 	Proxies []*Proxy `json:"proxies" yaml:"proxies"`
+
+	// Templates is list of named proxy configuration
+	// used to pre-fill a new proxy configuration
+	Templates []*Template `json:"templates,omitempty" yaml:"templates,omitempty"`
+
+	// Audit is the audit log configuration
+	Audit Audit `json:"audit" yaml:"audit,omitempty"`
+
+	// Storage is the node cache backend, file or sqlite
+	// the default is file, the existing file cache is
+	// migrated automatically when it's changed to sqlite
+	Storage string `json:"storage,omitempty" yaml:"storage,omitempty"`
+
+	// CacheBackups is the number of the previous node caches kept per
+	// environment for tpot cache rollback, the default is 5 & 0 turns it off
+	CacheBackups *int `json:"cache_backups,omitempty" yaml:"cache_backups,omitempty"`
+
+	// Flags is the default flags of every command, the flags given
+	// on the command line & the environment flags take precedence
+	Flags Flags `json:"flags,omitempty" yaml:"flags,omitempty"`
+
+	// Aliases is the user-defined command lines run by the alias name,
+	// $1, $2... are replaced by the arguments & $@ by all of them, example
+	//
+	//	deploy-web: prod --filter 'web-*' --exec "sudo systemctl restart app"
+	Aliases map[string]string `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+
+	// Groups is the saved queries of the nodes by the group name, run
+	// by tpot @<name>, example
+	//
+	//	web: label:role=web AND env=prod
+	Groups map[string]string `json:"groups,omitempty" yaml:"groups,omitempty"`
+
+	// Plain uses the numbered prompts instead of the full-screen UI,
+	// it's meant for the screen readers & the minimal terminals
+	Plain bool `json:"plain,omitempty" yaml:"plain,omitempty"`
+
+	// Keybindings customizes the keys of the picker & the console
+	Keybindings Keybindings `json:"keybindings,omitempty" yaml:"keybindings,omitempty"`
+
+	// BrowserCommand opens the SSO page of every environment without
+	// its own browser_command, {url} is replaced by the link, example
+	//
+	//	wslview {url}
+	BrowserCommand string `json:"browser_command,omitempty" yaml:"browser_command,omitempty"`
+
+	// PickerColumns is the columns of the picker of every environment
+	// without its own picker_columns, example
+	//
+	//	[ip:15, label.team, last_used, latency]
+	PickerColumns []string `json:"picker_columns,omitempty" yaml:"picker_columns,omitempty"`
+
+	// SessionHooks is the webhooks fired when the ssh sessions start & end,
+	// such as annotating the incident timeline with the connections
+	SessionHooks []SessionHook `json:"session_hooks,omitempty" yaml:"session_hooks,omitempty"`
+
+	// Slack is the Slack slash command answered by tpot serve
+	Slack Slack `json:"slack,omitempty" yaml:"slack,omitempty"`
+
+	// Enrichment is the inventories the cached nodes are enriched from, such
+	// as the owner team & the rack of Netbox, they're looked up in order
+	Enrichment []Enrichment `json:"enrichment,omitempty" yaml:"enrichment,omitempty"`
+
+	// LiveSearch is how tpot find --live refreshes the environments
+	// missing the host, such as the environments of a tag first
+	LiveSearch LiveSearch `json:"live_search,omitempty" yaml:"live_search,omitempty"`
+
+	// Keepalive is how often tpot serve checks & renews the login
+	// of the environments marked keepalive
+	Keepalive Keepalive `json:"keepalive,omitempty" yaml:"keepalive,omitempty"`
+
+	// ForwardProfiles is the local port forwards added to the matching
+	// hosts once they're connected with --with-forwards
+	ForwardProfiles []ForwardProfile `json:"forward_profiles,omitempty" yaml:"forward_profiles,omitempty"`
+
+	// Bundle is the path or the URL of the configuration bundle
+	// synced by tpot config sync, its signature is <bundle>.sig
+	Bundle string `json:"bundle,omitempty" yaml:"bundle,omitempty"`
+}
+
+// Keybindings is the key map preset along with the keys overriding it
+type Keybindings struct {
+	// Preset is default, emacs or vim
+	Preset string `json:"preset,omitempty" yaml:"preset,omitempty"`
+
+	// Keys replaces the keys of the binding, example
+	//
+	//	exec: [ctrl+x, f5]
+	Keys map[string][]string `json:"keys,omitempty" yaml:"keys,omitempty"`
+}
+
+// Audit configures the local append-only audit log
+type Audit struct {
+	// Enabled enables the audit log of every login,
+	// host connection, exec command & config change
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Path is the audit log location, default is audit.log
+	// inside the config directory
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+
+	// HMACKey chains every event using HMAC-SHA256 when it's not empty,
+	// it can be overridden by the environment variable TPOT_AUDIT_HMAC_KEY
+	HMACKey string `json:"hmac_key,omitempty" yaml:"hmac_key,omitempty"`
+
+	// Sinks ships every event to the remote destinations as well,
+	// so the organization can collect the audit log centrally
+	Sinks []AuditSink `json:"sinks,omitempty" yaml:"sinks,omitempty"`
+
+	// Retries is the number of retries to ship an event before
+	// it's buffered & retried on the next event
+	Retries int `json:"retries,omitempty" yaml:"retries,omitempty"`
+}
+
+// the supported audit sink types
+const (
+	AuditSinkSyslog  = "syslog"
+	AuditSinkWebhook = "webhook"
+	AuditSinkFile    = "file"
+)
+
+// AuditSink is the remote destination of the audit log
+type AuditSink struct {
+	// ID names the spool buffering the events of the sink, default is
+	// derived from the type & the destination so every sink has its own
+	ID string `json:"id,omitempty" yaml:"id,omitempty"`
+
+	// Type is one of syslog, webhook or file
+	Type string `json:"type" yaml:"type"`
+
+	// Address is the syslog address, example udp://logs.mycomp.com:514
+	// empty means the local syslog daemon
+	Address string `json:"address,omitempty" yaml:"address,omitempty"`
+
+	// Tag is the syslog tag, default is tpot
+	Tag string `json:"tag,omitempty" yaml:"tag,omitempty"`
+
+	// URL is the HTTPS webhook endpoint
+	URL string `json:"url,omitempty" yaml:"url,omitempty"`
+
+	// Headers is the additional webhook headers such as Authorization
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+
+	// Path is the file location watched by the SIEM agent
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+}
+
+// SessionHook is the webhook fired when the ssh session starts & ends
+type SessionHook struct {
+	// URL is the HTTPS webhook endpoint
+	URL string `json:"url" yaml:"url"`
+
+	// Headers is the additional webhook headers such as Authorization
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+
+	// Template is the text/template of the request body, empty posts
+	// the session as JSON. The fields are .Event, .Time, .Actor, .Env,
+	// .Host, .User, .Ticket, .Duration & .Error, json quotes them, example
+	//
+	//	{"text": {{json (printf "%s %s %s@%s %s" .Event .Env .User .Host .Ticket)}}}
+	Template string `json:"template,omitempty" yaml:"template,omitempty"`
+
+	// Envs is the globs of the environments the hook is fired on,
+	// empty means every environment
+	Envs []string `json:"envs,omitempty" yaml:"envs,omitempty"`
+}
+
+// Slack configures the Slack slash command such as /tpot find web-01, it
+// only searches the node caches & is off without the signing secret
+type Slack struct {
+	// SigningSecret verifies the requests are signed by Slack,
+	// $TPOT_SLACK_SIGNING_SECRET takes precedence
+	SigningSecret string `json:"signing_secret,omitempty" yaml:"signing_secret,omitempty"`
+
+	// Envs is the globs of the environments searched by the
+	// slash command, empty means every environment
+	Envs []string `json:"envs,omitempty" yaml:"envs,omitempty"`
+}
+
+// the types of the enrichment
+const (
+	EnrichNetbox = "netbox"
+	EnrichHTTP   = "http"
+)
+
+// Enrichment is the inventory the metadata of the hosts is looked up from
+type Enrichment struct {
+	// Type is netbox or http
+	Type string `json:"type" yaml:"type"`
+
+	// URL is the Netbox URL such as https://netbox.example.com, or the URL
+	// of the http JSON API with the {host} placeholder such as
+	// https://cmdb.example.com/api/hosts/{host}
+	URL string `json:"url" yaml:"url"`
+
+	// Token is the Netbox API token, $TPOT_NETBOX_TOKEN takes precedence
+	Token string `json:"token,omitempty" yaml:"token,omitempty"`
+
+	// Headers is the additional http headers such as Authorization
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+
+	// Fields is the metadata keys of the http type mapped to the dotted
+	// path of the value in the JSON response, example
+	//
+	//	team: owner.team
+	//	service: services.0
+	Fields map[string]string `json:"fields,omitempty" yaml:"fields,omitempty"`
+
+	// ServiceField is the Netbox custom field of the service, it's service by default
+	ServiceField string `json:"service_field,omitempty" yaml:"service_field,omitempty"`
+
+	// Envs is the globs of the environments enriched by it,
+	// empty means every environment
+	Envs []string `json:"envs,omitempty" yaml:"envs,omitempty"`
+}
+
+// NetboxToken returns the Netbox API token of the enrichment
+func (e Enrichment) NetboxToken() string {
+	if token := os.Getenv("TPOT_NETBOX_TOKEN"); token != "" {
+		return token
+	}
+	return e.Token
+}
+
+// Secret returns the signing secret of the Slack slash command
+func (s Slack) Secret() string {
+	if secret := os.Getenv("TPOT_SLACK_SIGNING_SECRET"); secret != "" {
+		return secret
+	}
+	return s.SigningSecret
+}
+
+// LogPath returns the audit log location
+func (a Audit) LogPath() string {
+	if a.Path != "" {
+		return a.Path
+	}
+	return Dir + "audit.log"
+}
+
+// Key returns the HMAC key of the audit log
+func (a Audit) Key() []byte {
+	if key := os.Getenv("TPOT_AUDIT_HMAC_KEY"); key != "" {
+		return []byte(key)
+	}
+	return []byte(a.HMACKey)
 }
 
+// SpoolID returns the ID of the sink safe as a file name
+func (s AuditSink) SpoolID() string {
+	if s.ID != "" {
+		return strings.Map(func(r rune) rune {
+			if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+				return r
+			}
+			return '_'
+		}, s.ID)
+	}
+	sum := sha256.Sum256([]byte(s.Type + "\x00" + s.Address + "\x00" + s.URL + "\x00" + s.Path))
+	return s.Type + "-" + hex.EncodeToString(sum[:4])
+}
+
+// Template is a proxy configuration defined once to be used
+// by many similar proxies, the {env} in the address will be
+// replaced by the environment name of the new proxy
+// example https://{env}.teleport.mycomp.com
+type Template struct {
+	Name  string `json:"name" yaml:"name"`
+	Proxy `json:",inline" yaml:",inline"`
+}
+
+// envPlaceholder is replaced by the proxy environment name
+const envPlaceholder = "{env}"
+
+// editText opens the text in the editor, it's replaced by the tests
+var editText = editor.Edit
+
 // NewConfig load config from the file and create it if no exist
 func NewConfig(isDev bool) (*Config, error) {
 	if isDev {
@@ -43,11 +323,26 @@ func NewConfig(isDev bool) (*Config, error) {
 		if err != nil {
 			log.Fatal(err)
 		}
-		Dir = path + "/dev/"
+		SetDir(path + "/dev/")
+	}
+	if err := migrateLegacyDir(); err != nil {
+		// keep using the legacy directory until it can be moved
+		fmt.Fprintf(os.Stderr, "WARNING! failed to move %s, error: %v\n", legacyDir, err)
+		Dir, CacheDir = legacyDir, legacyDir
 	}
+	Dir, CacheDir = profileDir(Dir), profileDir(CacheDir)
 	if err := addConfigDirExist(); err != nil {
 		return nil, err
 	}
+	if err := migrate(); err != nil {
+		return nil, err
+	}
+	p, err := LoadPolicy()
+	if err != nil {
+		return nil, err
+	}
+	policy = p
+
 	config, err := getConfig()
 	if errors.Is(err, os.ErrNotExist) {
 		config = &Config{
@@ -59,32 +354,22 @@ func NewConfig(isDev bool) (*Config, error) {
 	} else if err != nil {
 		return nil, err
 	}
+
+	s, err := OpenStore(config.Storage)
+	if err != nil {
+		return nil, err
+	}
+	UseStore(s)
+
+	cacheBackups = DefaultCacheBackups
+	if config.CacheBackups != nil {
+		cacheBackups = *config.CacheBackups
+	}
 	return config, nil
 }
 
 func getConfig() (*Config, error) {
 	bytes, err := ioutil.ReadFile(Dir + configFileName)
-	if errors.Is(err, os.ErrNotExist) {
-		// we're on migration from JSON file to YAML
-		// to ensure backward compatibility we'll read JSON if exists
-		// then convert the YAML file
-		bytes, err := ioutil.ReadFile(Dir + "config.json")
-		if err != nil {
-			return nil, err
-		}
-
-		var config Config
-		err = json.Unmarshal(bytes, &config)
-		if err != nil {
-			return nil, err
-		}
-
-		if err = config.save(); err != nil {
-			return nil, err
-		}
-
-		return &config, nil
-	}
 	if err != nil {
 		return nil, err
 	}
@@ -102,9 +387,51 @@ func (c *Config) Add() (string, error) {
 	return c.AddPlain(proxyTemplate)
 }
 
+// AddFromTemplate adds a new proxy configuration pre-filled by the template,
+// configPlain is the configuration edited before to continue editing it,
+// empty starts from the template. The {env} in the address is replaced by
+// the environment name of the new proxy
+func (c *Config) AddFromTemplate(name, configPlain string) (string, error) {
+	tmpl, err := c.FindTemplate(name)
+	if err != nil {
+		return "", err
+	}
+
+	if configPlain == "" {
+		proxy := tmpl.Proxy
+		if proxy.Env == "" {
+			proxy.Env = "staging"
+		}
+		if configPlain, err = proxy.ToEditString(); err != nil {
+			return "", err
+		}
+	}
+	return c.add(configPlain, func(p *Proxy) {
+		p.Address = strings.Replace(p.Address, envPlaceholder, p.Env, -1)
+	})
+}
+
+// FindTemplate finds the template by name
+func (c *Config) FindTemplate(name string) (*Template, error) {
+	var names []string
+	for _, t := range c.Templates {
+		if t.Name == name {
+			return t, nil
+		}
+		names = append(names, t.Name)
+	}
+	return nil, fmt.Errorf("template %s is not found, available templates: [%s]", name, strings.Join(names, ", "))
+}
+
 // AddPlain adds a new proxy configuration by plain configuration
 func (c *Config) AddPlain(configPlain string) (string, error) {
-	result, err := editor.Edit(configPlain, "add_proxy*.yaml")
+	return c.add(configPlain, nil)
+}
+
+// add edits the plain configuration then adds its proxy,
+// the fill completes the proxy before it's validated
+func (c *Config) add(configPlain string, fill func(p *Proxy)) (string, error) {
+	result, err := editText(configPlain, "add_proxy*.yaml")
 	if err != nil {
 		return "", err
 	}
@@ -122,6 +449,9 @@ func (c *Config) AddPlain(configPlain string) (string, error) {
 		return result, fmt.Errorf("need one proxy confugration, find %d", l)
 	}
 
+	if fill != nil {
+		fill(tmpConfig.Proxies[0])
+	}
 	if err := tmpConfig.Proxies[0].Validate(); err != nil {
 		return result, fmt.Errorf("failed to validate %v", err)
 	}
@@ -170,7 +500,7 @@ func (c *Config) Edit(envName string) (string, error) {
 
 // EditPlain edit specific proxy configuration by config plain
 func (c *Config) EditPlain(envName, configPlain string) (string, error) {
-	result, err := editor.Edit(configPlain, "edit_proxy*.yaml")
+	result, err := editText(configPlain, "edit_proxy*.yaml")
 	if err != nil {
 		return "", err
 	}
@@ -215,7 +545,7 @@ func (c *Config) EditAll() (string, error) {
 }
 
 func (c *Config) EditAllPlain(configPlain string) (string, error) {
-	result, err := editor.Edit(configPlain, "add_proxy*.yaml")
+	result, err := editText(configPlain, "add_proxy*.yaml")
 	if err != nil {
 		return "", err
 	}
@@ -237,9 +567,9 @@ func (c *Config) EditAllPlain(configPlain string) (string, error) {
 		return result, fmt.Errorf("need one proxy confugration, find %d", l)
 	}
 
-	var tmp2Config = Config{
-		Editor: tmpConfig.Editor,
-	}
+	// keep everything except the proxies, which will be validated one by one
+	var tmp2Config = tmpConfig
+	tmp2Config.Proxies = nil
 	for _, proxy := range tmpConfig.Proxies {
 		if err := proxy.Validate(); err != nil {
 			return result, fmt.Errorf("failed to validate environment %s, error: %v", proxy.Env, err)
@@ -261,6 +591,10 @@ func (c *Config) EditAllPlain(configPlain string) (string, error) {
 func (c *Config) FindProxy(env string) (*Proxy, error) {
 	for _, p := range c.Proxies {
 		if p.Env == env {
+			if err := policy.CheckProxy(p); err != nil {
+				return nil, err
+			}
+			policy.apply(p)
 			return p, nil
 		}
 	}
@@ -278,15 +612,12 @@ func (c *Config) String() (string, error) {
 }
 
 func addConfigDirExist() error {
-	_, err := os.Stat(Dir)
-	if os.IsNotExist(err) {
-		err = os.Mkdir(Dir, os.ModePerm)
-		if err != nil {
+	for _, dir := range []string{Dir, CacheDir} {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
 			return err
 		}
 	}
-	return err
-
+	return nil
 }
 
 func prompt(label string, validate func(string2 string) error) (string, error) {
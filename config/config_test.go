@@ -1,12 +1,19 @@
 package config
 
 import (
+	"net"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
 )
 
 func TestProxy_AppendNode(t *testing.T) {
+	seen := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
 	type fields struct {
 		Address  string
 		UserName string
@@ -57,6 +64,70 @@ func TestProxy_AppendNode(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "reused hostname",
+			fields: fields{
+				Env: "prod",
+			},
+			args: args{
+				n: Node{
+					Items: []Item{
+						{
+							Hostname: "proxy-172.20.1.1",
+							Address:  "172.20.1.9:3022",
+						},
+					},
+				},
+			},
+			want: Node{
+				Items: []Item{
+					{
+						Hostname: "proxy-172.20.1.1",
+						Address:  "172.20.1.1:3022",
+					},
+					{
+						Hostname: "proxy-172.20.1.2",
+						Address:  "172.20.1.2:3022",
+					},
+					{
+						Hostname: "proxy-172.20.1.1",
+						Address:  "172.20.1.9:3022",
+					},
+				},
+			},
+		},
+		{
+			name: "seen again",
+			fields: fields{
+				Env: "prod",
+			},
+			args: args{
+				n: Node{
+					Items: []Item{
+						{
+							Hostname: "proxy-172.20.1.2",
+							Address:  "172.20.1.2:3022",
+							LastSeen: &seen,
+							Source:   SourceTSH,
+						},
+					},
+				},
+			},
+			want: Node{
+				Items: []Item{
+					{
+						Hostname: "proxy-172.20.1.1",
+						Address:  "172.20.1.1:3022",
+					},
+					{
+						Hostname: "proxy-172.20.1.2",
+						Address:  "172.20.1.2:3022",
+						LastSeen: &seen,
+						Source:   SourceTSH,
+					},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -75,7 +146,7 @@ func TestProxy_AppendNode(t *testing.T) {
 				t.Fatal(err)
 			}
 
-			Dir = wd + "/test/"
+			CacheDir = wd + "/test/"
 
 			got, err := p.AppendNode(tt.args.n)
 			if (err != nil) != tt.wantErr {
@@ -88,3 +159,335 @@ func TestProxy_AppendNode(t *testing.T) {
 		})
 	}
 }
+
+func TestNode_Filter(t *testing.T) {
+	n := Node{
+		Items: []Item{
+			{Hostname: "web-01", Address: "10.0.0.1:3022"},
+			{Hostname: "web-02", Address: "10.0.0.2:3022"},
+			{Hostname: "db-web-01", Address: "10.0.0.3:3022"},
+		},
+	}
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{pattern: "web-", want: []string{"web-01", "web-02", "db-web-01"}},
+		{pattern: "web-*", want: []string{"web-01", "web-02"}},
+		{pattern: "*-01", want: []string{"web-01", "db-web-01"}},
+		{pattern: "cache", want: nil},
+		{pattern: "WEB-", want: []string{"web-01", "web-02", "db-web-01"}},
+		{pattern: "=WEB-", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			var got []string
+			for _, item := range n.Filter(tt.pattern) {
+				got = append(got, item.Hostname)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Filter() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNode_FilterCIDR(t *testing.T) {
+	n := Node{
+		Items: []Item{
+			{Hostname: "web-01", Address: "10.12.0.1:3022"},
+			{Hostname: "web-02", Address: "10.13.0.2:3022"},
+			{Hostname: "db-01", Address: "[fd00::1]:3022"},
+			{Hostname: "edge-01", Address: "⟵ Tunnel"},
+		},
+	}
+	_, ipv4, _ := net.ParseCIDR("10.12.0.0/16")
+	_, ipv6, _ := net.ParseCIDR("fd00::/8")
+	tests := []struct {
+		name     string
+		networks []*net.IPNet
+		want     []string
+	}{
+		{name: "ipv4", networks: []*net.IPNet{ipv4}, want: []string{"web-01"}},
+		{name: "ipv6", networks: []*net.IPNet{ipv6}, want: []string{"db-01"}},
+		{name: "any", networks: []*net.IPNet{ipv4, ipv6}, want: []string{"web-01", "db-01"}},
+		{name: "none"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, item := range n.FilterCIDR(tt.networks) {
+				got = append(got, item.Hostname)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FilterCIDR() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNode_NextSibling(t *testing.T) {
+	n := Node{
+		Items: []Item{
+			{Hostname: "web-03"},
+			{Hostname: "web-01"},
+			{Hostname: "web-02"},
+			{Hostname: "web-admin"},
+			{Hostname: "web-10"},
+			{Hostname: "db-web-04"},
+			{Hostname: "bastion"},
+		},
+	}
+	tests := []struct {
+		host string
+		skip map[string]bool
+		want string
+	}{
+		{host: "web-01", want: "web-02"},
+		{host: "web-02", skip: map[string]bool{"web-03": true}, want: "web-10"},
+		{host: "web-10", want: "web-01"},
+		{host: "web-10", skip: map[string]bool{"web-01": true, "web-02": true, "web-03": true}},
+		{host: "db-web-04"},
+		{host: "bastion"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			got, ok := n.NextSibling(tt.host, tt.skip)
+			if got != tt.want || ok != (tt.want != "") {
+				t.Errorf("NextSibling() got = %v, %v, want %v", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortByIP(t *testing.T) {
+	items := []Item{
+		{Hostname: "edge-02", Address: "⟵ Tunnel"},
+		{Hostname: "db-01", Address: "[fd00::1]:3022"},
+		{Hostname: "web-10", Address: "10.0.0.10:3022"},
+		{Hostname: "edge-01"},
+		{Hostname: "web-09", Address: "10.0.0.9:3022"},
+		{Hostname: "web-02", Address: "9.0.0.200"},
+	}
+	SortByIP(items)
+
+	var got []string
+	for _, item := range items {
+		got = append(got, item.Hostname)
+	}
+	want := []string{"web-02", "web-09", "web-10", "db-01", "edge-01", "edge-02"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SortByIP() got = %v, want %v", got, want)
+	}
+}
+
+func TestItem_DialAddresses(t *testing.T) {
+	tests := []struct {
+		item   Item
+		dialBy string
+		ip     string
+		dialed []string
+	}{
+		{item: Item{Hostname: "web-01", Address: "10.0.0.1:3022"}, ip: "10.0.0.1", dialed: []string{"10.0.0.1", "web-01"}},
+		{item: Item{Hostname: "web-01", Address: "10.0.0.1"}, ip: "10.0.0.1", dialed: []string{"10.0.0.1", "web-01"}},
+		{item: Item{Hostname: "web-01", Address: "[fd00::1]:3022"}, ip: "fd00::1", dialed: []string{"web-01"}},
+		{item: Item{Hostname: "web-01", Address: "[fd00::1]"}, ip: "fd00::1", dialed: []string{"web-01"}},
+		{item: Item{Hostname: "web-01", Address: "⟵ Tunnel"}, ip: "", dialed: []string{"web-01"}},
+		{item: Item{ID: "5a8c0f2e", Address: ""}, ip: "", dialed: []string{"5a8c0f2e"}},
+		{item: Item{Hostname: "web-01", ID: "5a8c0f2e", Address: "10.0.0.1:3022"}, dialBy: DialByHostname,
+			ip: "10.0.0.1", dialed: []string{"web-01", "5a8c0f2e"}},
+		{item: Item{Hostname: "web-01", ID: "5a8c0f2e", Address: "10.0.0.1:3022"}, dialBy: DialByUUID,
+			ip: "10.0.0.1", dialed: []string{"5a8c0f2e", "web-01"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.item.Address+tt.dialBy, func(t *testing.T) {
+			if got := tt.item.IP(); got != tt.ip {
+				t.Errorf("IP() got = %v, want %v", got, tt.ip)
+			}
+			if got := tt.item.DialAddresses(tt.dialBy); !reflect.DeepEqual(got, tt.dialed) {
+				t.Errorf("DialAddresses() got = %v, want %v", got, tt.dialed)
+			}
+		})
+	}
+}
+
+func TestProxy_ToEditString(t *testing.T) {
+	p := &Proxy{
+		Env:            "prod",
+		Address:        "https://teleport.mycomp.com",
+		UserName:       "adzim",
+		TeleportHome:   "~/.tsh-prod",
+		ExtraTSHFlags:  []string{"--add-keys-to-agent=no", `--mfa-mode="cross-platform"`},
+		Color:          "red",
+		Badge:          "PROD",
+		ConfirmEnv:     true,
+		ReadOnly:       true,
+		DialBy:         DialByHostname,
+		Browser:        "none",
+		CallbackURL:    "localhost:40123",
+		BindAddr:       "localhost:40123",
+		BrowserCommand: `google-chrome --profile-directory="Profile 2" {url}`,
+		PickerColumns:  []string{"ip:15", "label.team"},
+		Flags:          Flags{"refresh": "true", "exec.parallel": "10"},
+	}
+	str, err := p.ToEditString()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var c Config
+	if err := yaml.Unmarshal([]byte(str), &c); err != nil {
+		t.Fatal(err)
+	}
+	got := c.Proxies[0]
+	if got.TeleportHome != p.TeleportHome || !reflect.DeepEqual(got.ExtraTSHFlags, p.ExtraTSHFlags) ||
+		got.Color != p.Color || got.Badge != p.Badge || got.ConfirmEnv != p.ConfirmEnv ||
+		got.Protected != p.Protected || got.ReadOnly != p.ReadOnly || got.DialBy != p.DialBy ||
+		got.Browser != p.Browser || got.CallbackURL != p.CallbackURL || got.BindAddr != p.BindAddr ||
+		got.BrowserCommand != p.BrowserCommand || !reflect.DeepEqual(got.PickerColumns, p.PickerColumns) ||
+		!reflect.DeepEqual(got.Flags, p.Flags) {
+		t.Errorf("ToEditString() round trip got = %+v, want %+v", got, p)
+	}
+}
+
+func TestFlags_Lookup(t *testing.T) {
+	f := Flags{"append": "true", "exec.append": "false", "parallel": "5"}
+	tests := []struct {
+		command, flag string
+		want          string
+		wantOK        bool
+	}{
+		{command: "tpot", flag: "append", want: "true", wantOK: true},
+		{command: "exec", flag: "append", want: "false", wantOK: true},
+		{command: "exec", flag: "parallel", want: "5", wantOK: true},
+		{command: "exec", flag: "refresh"},
+	}
+	for _, tt := range tests {
+		got, ok := f.Lookup(tt.command, tt.flag)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("Lookup(%s, %s) = %q, %v, want %q, %v", tt.command, tt.flag, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestAuditSink_SpoolID(t *testing.T) {
+	a := AuditSink{Type: AuditSinkWebhook, URL: "https://siem-a.mycomp.com"}
+	b := AuditSink{Type: AuditSinkWebhook, URL: "https://siem-b.mycomp.com"}
+	if a.SpoolID() == b.SpoolID() {
+		t.Errorf("SpoolID() of the webhooks of the distinct URL are both %s", a.SpoolID())
+	}
+	if got := (AuditSink{ID: "siem/a", Type: AuditSinkWebhook}).SpoolID(); got != "siem_a" {
+		t.Errorf("SpoolID() got = %s, want siem_a", got)
+	}
+}
+
+// keepDirs restores the configuration & the cache directories once the test ends
+// withEditor edits the text by the edit instead of the editor until the test ends,
+// the text given to the editor is kept in opened
+func withEditor(t *testing.T, edit func(text string) string) *[]string {
+	var opened []string
+	old := editText
+	t.Cleanup(func() { editText = old })
+	editText = func(text, _ string) (string, error) {
+		opened = append(opened, text)
+		return edit(text), nil
+	}
+	return &opened
+}
+
+func TestConfig_AddFromTemplate(t *testing.T) {
+	tempConfigDir(t)
+	tmpl := &Template{Name: "corporate", Proxy: Proxy{
+		Address:       "https://{env}.teleport.mycomp.com",
+		AuthConnector: "gsuite",
+		TeleportHome:  "~/.tsh-{env}",
+		Tags:          []string{"eu", "k8s"},
+		PickerColumns: []string{"ip:15", "label.team"},
+		Flags:         Flags{"append": "true", "exec.parallel": "10"},
+		ReasonRoles:   []string{"prod-access"},
+		Forwarding: Forwarding{Interval: 60, Nodes: []*ForwardingNode{
+			{Host: "db-01", UserLogin: "root", ListenPort: "5432", RemotePort: "5432", RemoteHost: "localhost"},
+		}},
+	}}
+	c := &Config{Templates: []*Template{tmpl}}
+	opened := withEditor(t, func(text string) string {
+		return strings.Replace(text, "env: staging", "env: prod", 1)
+	})
+
+	_, err := c.AddFromTemplate("other", "")
+	assert.EqualError(t, err, "template other is not found, available templates: [corporate]")
+
+	_, err = c.AddFromTemplate("corporate", "")
+	assert.NoError(t, err)
+	if assert.Len(t, c.Proxies, 1) {
+		got := c.Proxies[0]
+		assert.Equal(t, "prod", got.Env)
+		assert.Equal(t, "https://prod.teleport.mycomp.com", got.Address)
+		// only the address is a pattern of the environment
+		assert.Equal(t, "~/.tsh-{env}", got.TeleportHome)
+		assert.Equal(t, tmpl.AuthConnector, got.AuthConnector)
+		assert.Equal(t, tmpl.Tags, got.Tags)
+		assert.Equal(t, tmpl.PickerColumns, got.PickerColumns)
+		assert.Equal(t, tmpl.Flags, got.Flags)
+		assert.Equal(t, tmpl.ReasonRoles, got.ReasonRoles)
+		assert.Equal(t, tmpl.Forwarding, got.Forwarding)
+	}
+	// the template itself isn't changed by the added proxy
+	assert.Equal(t, "https://{env}.teleport.mycomp.com", tmpl.Address)
+
+	// continue editing the rejected configuration of the template
+	plain, err := c.AddFromTemplate("corporate", "")
+	assert.EqualError(t, err, "environment prod is already exist")
+	_, err = c.AddFromTemplate("corporate", strings.Replace(plain, "env: prod", "env: dev", 1))
+	assert.NoError(t, err)
+	if assert.Len(t, c.Proxies, 2) {
+		assert.Equal(t, "https://dev.teleport.mycomp.com", c.Proxies[1].Address)
+	}
+	// the continued edit opens the rejected configuration instead of the template
+	if assert.Len(t, *opened, 3) {
+		assert.Contains(t, (*opened)[2], "env: dev")
+	}
+
+	saved, err := getConfig()
+	assert.NoError(t, err)
+	assert.Len(t, saved.Proxies, 2)
+}
+
+func TestConfig_AddPlain(t *testing.T) {
+	tempConfigDir(t)
+	c := &Config{}
+	withEditor(t, func(text string) string { return text })
+
+	plain := "proxies:\n- env: prod\n  address: https://teleport.mycomp.com/{env}\n  user_name: adzim\n"
+	_, err := c.AddPlain(plain)
+	assert.NoError(t, err)
+	if assert.Len(t, c.Proxies, 1) {
+		// {env} is only replaced for the templates
+		assert.Equal(t, "https://teleport.mycomp.com/{env}", c.Proxies[0].Address)
+	}
+
+	_, err = c.AddPlain(proxyTemplate)
+	assert.EqualError(t, err, "there's no proxy was added")
+	res, err := c.AddPlain(plain)
+	assert.EqualError(t, err, "environment prod is already exist")
+	assert.Equal(t, plain, res)
+}
+
+func keepDirs(t *testing.T) {
+	dir, cacheDir, legacy := Dir, CacheDir, legacyDir
+	t.Cleanup(func() {
+		Dir, CacheDir, legacyDir = dir, cacheDir, legacy
+	})
+}
+
+// tempCacheDir points the cache directory into a temporary directory during the test
+func tempCacheDir(t *testing.T) {
+	keepDirs(t)
+	CacheDir = t.TempDir() + "/"
+}
+
+// tempConfigDir points the configuration directory into a temporary directory during the test
+func tempConfigDir(t *testing.T) {
+	keepDirs(t)
+	Dir = t.TempDir() + "/"
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// legacyDir is where tpot stored the configuration & cache
+// before following the XDG base directory specification
+var legacyDir = os.Getenv("HOME") + "/.tpot/"
+
+// xdgDir returns the tpot directory inside the XDG base directory,
+// fallback to the default inside HOME when the env is not set
+func xdgDir(env, fallback string) string {
+	base := os.Getenv(env)
+	if base == "" || !filepath.IsAbs(base) {
+		base = filepath.Join(os.Getenv("HOME"), fallback)
+	}
+	return filepath.Join(base, "tpot") + "/"
+}
+
+// profile is the active profile, empty means the default one
+var profile string
+
+// SetProfile namespaces the configuration, cache & tsh directory by the
+// profile name, so the organizations are fully isolated on one machine
+func SetProfile(name string) error {
+	if name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid profile name %s", name)
+	}
+	profile = name
+	return nil
+}
+
+// profileDir returns the profile directory inside dir
+func profileDir(dir string) string {
+	if profile == "" {
+		return dir
+	}
+	suffix := "profiles/" + profile + "/"
+	if strings.HasSuffix(dir, suffix) {
+		return dir
+	}
+	return dir + suffix
+}
+
+// TeleportHome returns the tsh directory of the active profile,
+// empty means the default tsh directory
+func TeleportHome() string {
+	if profile == "" {
+		return ""
+	}
+	return Dir + "tsh/"
+}
+
+// SetDir overrides both the configuration & cache directory
+func SetDir(dir string) {
+	dir = strings.TrimSuffix(dir, "/") + "/"
+	Dir, CacheDir = dir, dir
+	legacyDir = ""
+}
+
+// isCacheFile reports whether the legacy file belongs to the cache directory
+func isCacheFile(name string) bool {
+	return strings.HasPrefix(name, "node_") || strings.HasPrefix(name, "facts_") ||
+		strings.HasPrefix(name, "history_") || strings.HasPrefix(name, "sort_") ||
+		name == "state.json" || name == "tpot.db"
+}
+
+// migrateLegacyDir moves the content of the legacy directory into
+// the XDG directories once, it's skipped when the new configuration
+// directory already exists
+func migrateLegacyDir() error {
+	if legacyDir == "" || legacyDir == Dir {
+		return nil
+	}
+	if _, err := os.Stat(legacyDir); err != nil {
+		return nil
+	}
+	if _, err := os.Stat(Dir); err == nil {
+		return nil
+	}
+
+	files, err := ioutil.ReadDir(legacyDir)
+	if err != nil {
+		return err
+	}
+	for _, dir := range []string{Dir, CacheDir} {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return err
+		}
+	}
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		dst := Dir + f.Name()
+		if isCacheFile(f.Name()) {
+			dst = CacheDir + f.Name()
+		}
+		if err := moveFile(legacyDir+f.Name(), dst); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%s is moved into %s & %s\n", legacyDir, Dir, CacheDir)
+
+	// the directory is kept when there's something else inside
+	os.Remove(legacyDir)
+	return nil
+}
+
+// moveFile renames the file, fallback to copy when it's across devices
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, permission)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_migrateLegacyDir(t *testing.T) {
+	keepDirs(t)
+	home := t.TempDir()
+	legacyDir = home + "/.tpot/"
+	Dir = home + "/.config/tpot/"
+	CacheDir = home + "/.cache/tpot/"
+
+	if err := os.Mkdir(legacyDir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"config.yaml", "schema_version", "node_prod.json", "facts_prod.json"} {
+		if err := ioutil.WriteFile(legacyDir+name, []byte(name), permission); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assert.NoError(t, migrateLegacyDir())
+	for path, content := range map[string]string{
+		Dir + "config.yaml":          "config.yaml",
+		Dir + "schema_version":       "schema_version",
+		CacheDir + "node_prod.json":  "node_prod.json",
+		CacheDir + "facts_prod.json": "facts_prod.json",
+	} {
+		b, err := ioutil.ReadFile(path)
+		assert.NoError(t, err)
+		assert.Equal(t, content, string(b))
+	}
+	_, err := os.Stat(legacyDir)
+	assert.True(t, os.IsNotExist(err))
+
+	// the new directory exists, nothing to move anymore
+	assert.NoError(t, os.Mkdir(legacyDir, os.ModePerm))
+	assert.NoError(t, ioutil.WriteFile(legacyDir+"config.yaml", []byte("old"), permission))
+	assert.NoError(t, migrateLegacyDir())
+	b, err := ioutil.ReadFile(Dir + "config.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "config.yaml", string(b))
+}
+
+func TestSetProfile(t *testing.T) {
+	keepDirs(t)
+	defer func() { profile = "" }()
+
+	assert.Error(t, SetProfile("../work"))
+	assert.Error(t, SetProfile(".."))
+
+	assert.NoError(t, SetProfile(""))
+	assert.Equal(t, "/home/adzim/.config/tpot/", profileDir("/home/adzim/.config/tpot/"))
+	assert.Equal(t, "", TeleportHome())
+
+	assert.NoError(t, SetProfile("work"))
+	dir := profileDir("/home/adzim/.config/tpot/")
+	assert.Equal(t, "/home/adzim/.config/tpot/profiles/work/", dir)
+	assert.Equal(t, dir, profileDir(dir))
+
+	Dir = dir
+	assert.Equal(t, "/home/adzim/.config/tpot/profiles/work/tsh/", TeleportHome())
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Key identifies the item, the instances sharing the hostname have the different keys
+// unless they're listed the same, such as the tunnel nodes without the ID & the labels
+func (i Item) Key() string {
+	labels := make([]string, 0, len(i.Labels))
+	for k, v := range i.Labels {
+		labels = append(labels, k+"="+v)
+	}
+	sort.Strings(labels)
+	return strings.Join(append([]string{i.Hostname, i.Address, i.ID}, labels...), "\x00")
+}
+
+// Names returns the name of every item by its Key as shown by the picker. The hostname
+// listed more than once, such as the reused name of the autoscaling group, is disambiguated
+// like "web-01 (10.0.0.5)" by the IP, otherwise by a label or the ID telling the instances apart
+func (n *Node) Names() map[string]string {
+	groups := make(map[string][]Item, len(n.Items))
+	for _, item := range n.Items {
+		groups[item.Hostname] = append(groups[item.Hostname], item)
+	}
+	res := make(map[string]string, len(n.Items))
+	for host, items := range groups {
+		if len(items) == 1 {
+			res[items[0].Key()] = host
+			continue
+		}
+		for i, item := range items {
+			res[item.Key()] = fmt.Sprintf("%s (%s)", host, instanceSuffix(items, i))
+		}
+	}
+	return res
+}
+
+// instanceSuffix returns what tells the item of the index apart from the other instances
+func instanceSuffix(items []Item, index int) string {
+	unique := func(value func(Item) string) bool {
+		v := value(items[index])
+		if v == "" {
+			return false
+		}
+		for i, item := range items {
+			if i != index && value(item) == v {
+				return false
+			}
+		}
+		return true
+	}
+
+	if unique(Item.IP) {
+		return items[index].IP()
+	}
+	keys := make([]string, 0, len(items[index].Labels))
+	for k := range items[index].Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		label := func(item Item) string {
+			if v, ok := item.Labels[k]; ok {
+				return k + "=" + v
+			}
+			return ""
+		}
+		if unique(label) {
+			return label(items[index])
+		}
+	}
+	if id := items[index].ID; unique(func(item Item) string { return item.ID }) {
+		if len(id) > 8 {
+			id = id[:8]
+		}
+		return id
+	}
+	return fmt.Sprintf("#%d", index+1)
+}
+
+// NamesOf returns the names of the items by Names, such as the hosts matching a filter
+func (n *Node) NamesOf(items []Item) []string {
+	names := n.Names()
+	res := make([]string, len(items))
+	for i, item := range items {
+		res[i] = names[item.Key()]
+	}
+	return res
+}
+
+// Instances returns the items of the hostname, or the item of the name disambiguated by Names
+func (n *Node) Instances(host string) []Item {
+	var res []Item
+	for _, item := range n.Items {
+		if item.Hostname == host {
+			res = append(res, item)
+		}
+	}
+	if len(res) > 0 || !strings.HasSuffix(host, ")") {
+		return res
+	}
+	names := n.Names()
+	for _, item := range n.Items {
+		if names[item.Key()] == host {
+			return []Item{item}
+		}
+	}
+	return nil
+}
+
+// IsDuplicate returns true if the hostname is listed more than once
+func (n *Node) IsDuplicate(hostname string) bool {
+	count := 0
+	for _, item := range n.Items {
+		if item.Hostname == hostname {
+			if count++; count > 1 {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNode_Names(t *testing.T) {
+	n := Node{Items: []Item{
+		{Hostname: "web-01", Address: "10.0.0.1:3022"},
+		{Hostname: "web-01", Address: "10.0.0.9:3022"},
+		{Hostname: "db-01", Address: "10.0.1.1:3022"},
+		{Hostname: "edge-01", Address: "⟵ Tunnel", Labels: map[string]string{"zone": "a", "role": "edge"}},
+		{Hostname: "edge-01", Address: "⟵ Tunnel", Labels: map[string]string{"zone": "b", "role": "edge"}},
+		{Hostname: "job-01", Address: "10.0.2.1:3022", ID: "5a8c0f2e-0b6e-4a4f-9c1f-2f8d3a6c7b1e"},
+		{Hostname: "job-01", Address: "10.0.2.1:3022", ID: "9d1b7c44-7f0e-4f7b-8a55-6a1f2b3c4d5e"},
+		{Hostname: "tmp-01"},
+		{Hostname: "tmp-01", Address: "⟵ Tunnel"},
+	}}
+	assert.Equal(t, []string{
+		"web-01 (10.0.0.1)", "web-01 (10.0.0.9)",
+		"db-01",
+		"edge-01 (zone=a)", "edge-01 (zone=b)",
+		"job-01 (5a8c0f2e)", "job-01 (9d1b7c44)",
+		"tmp-01 (#1)", "tmp-01 (#2)",
+	}, n.ListHostname())
+	assert.Equal(t, []string{"db-01", "web-01 (10.0.0.9)"}, n.NamesOf([]Item{n.Items[2], n.Items[1]}))
+}
+
+func TestNode_LookUp_duplicate(t *testing.T) {
+	n := Node{Items: []Item{
+		{Hostname: "web-01", Address: "10.0.0.1:3022"},
+		{Hostname: "web-02", Address: "10.0.0.2:3022"},
+		{Hostname: "web-01", Address: "10.0.0.9:3022"},
+		{Hostname: "web-01", Address: "⟵ Tunnel", ID: "5a8c0f2e"},
+	}}
+
+	item, ok := n.LookUp("web-01 (10.0.0.9)")
+	assert.True(t, ok)
+	assert.Equal(t, "10.0.0.9:3022", item.Address)
+	item, ok = n.LookUp("web-01")
+	assert.True(t, ok)
+	assert.Equal(t, "10.0.0.1:3022", item.Address)
+	_, ok = n.LookUp("web-01 (10.0.0.5)")
+	assert.False(t, ok)
+
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.9"}, n.LookUpIPAddress("web-01"))
+	assert.Equal(t, []string{"10.0.0.2"}, n.LookUpIPAddress("web-02"))
+	assert.Empty(t, n.LookUpIPAddress("web-01 (5a8c0f2e)"))
+	assert.Empty(t, n.LookUpIPAddress("db-01"))
+
+	assert.True(t, n.IsDuplicate("web-01"))
+	assert.False(t, n.IsDuplicate("web-02"))
+}
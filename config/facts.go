@@ -0,0 +1,21 @@
+package config
+
+import (
+	"time"
+)
+
+// HostFacts is the facts collected from a host
+type HostFacts struct {
+	CollectedAt time.Time         `json:"collected_at"`
+	Facts       map[string]string `json:"facts"`
+}
+
+// GetFacts get the cached facts of all hosts keyed by hostname
+func (p *Proxy) GetFacts() (map[string]HostFacts, error) {
+	return store.GetFacts(p.Env)
+}
+
+// UpdateFacts update the cached facts of the host
+func (p *Proxy) UpdateFacts(host string, facts HostFacts) error {
+	return store.UpdateFacts(p.Env, host, facts)
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"github.com/adzimzf/tpot/filter"
+)
+
+// Target returns the node evaluated by the filter expression, the facts are
+// the cached facts & the meta is the enriched metadata, nil if there's none
+func (i Item) Target(env string, facts, meta map[string]string) filter.Target {
+	return filter.Target{
+		Env:      env,
+		Hostname: i.Hostname,
+		IP:       i.IP(),
+		Labels:   i.Labels,
+		Facts:    facts,
+		Meta:     meta,
+	}
+}
+
+// Select returns the items of the env matching the filter, the facts & the meta
+// are keyed by hostname, the fact & the meta terms never match without them
+func (n *Node) Select(f *filter.Filter, env string, facts map[string]HostFacts, meta map[string]HostMeta) []Item {
+	var res []Item
+	for _, item := range n.Items {
+		if f.Match(item.Target(env, facts[item.Hostname].Facts, meta[item.Hostname].Meta)) {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
+// Select returns the items matching the filter, the cached facts & the
+// metadata are only loaded when the filter has any fact or meta term
+func (p *Proxy) Select(f *filter.Filter, items []Item) ([]Item, error) {
+	var facts map[string]HostFacts
+	var meta map[string]HostMeta
+	var err error
+	if f.UsesFacts() {
+		if facts, err = p.GetFacts(); err != nil {
+			return nil, err
+		}
+	}
+	if f.UsesMeta() {
+		if meta, err = p.GetHostMeta(); err != nil {
+			return nil, err
+		}
+	}
+	return (&Node{Items: items}).Select(f, p.Env, facts, meta), nil
+}
+
+// FilterNodes parses the filter expression & returns the cached nodes matching it
+func (p *Proxy) FilterNodes(expr string) ([]Item, error) {
+	f, err := filter.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	return p.Select(f, p.Node.Items)
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/adzimzf/tpot/filter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProxy_FilterNodes(t *testing.T) {
+	defer func(s Store) { store = s }(store)
+	m := NewMemoryStore()
+	m.UpdateFacts("prod", "web-02", HostFacts{Facts: map[string]string{"os": "CentOS 7"}})
+	store = m
+	tempCacheDir(t)
+
+	p := &Proxy{Env: "prod", Node: Node{Items: []Item{
+		{Hostname: "web-01", Address: "10.12.0.1:3022", Labels: map[string]string{"role": "web"}},
+		{Hostname: "web-02", Address: "10.13.0.2:3022", Labels: map[string]string{"role": "web"}},
+		{Hostname: "db-01", Address: "⟵ Tunnel", Labels: map[string]string{"role": "db"}},
+	}}}
+	assert.NoError(t, p.SetHostMeta(map[string]HostMeta{"db-01": {Meta: map[string]string{"team": "payments"}}}))
+	hostnames := func(items []Item) (res []string) {
+		for _, item := range items {
+			res = append(res, item.Hostname)
+		}
+		return
+	}
+
+	tests := []struct {
+		expr    string
+		want    []string
+		wantErr bool
+	}{
+		{expr: "web-01", want: []string{"web-01"}},
+		{expr: "label:role=web AND ip:10.12.0.0/16", want: []string{"web-01"}},
+		{expr: "NOT ip:10.0.0.0/8", want: []string{"db-01"}},
+		{expr: "fact:os=CentOS*", want: []string{"web-02"}},
+		{expr: "env=prod AND NOT fact:os", want: []string{"web-01", "db-01"}},
+		{expr: "meta:team=pay*", want: []string{"db-01"}},
+		{expr: "label:role=web OR meta:team", want: []string{"web-01", "web-02", "db-01"}},
+		{expr: "env=dev"},
+		{expr: "web OR", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			items, err := p.FilterNodes(tt.expr)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, hostnames(items))
+		})
+	}
+
+	// the fact term never matches without the facts
+	f, err := filter.Parse("fact:os")
+	assert.NoError(t, err)
+	assert.Empty(t, p.Node.Select(f, p.Env, nil, nil))
+}
@@ -0,0 +1,19 @@
+package config
+
+// Flags is the default flags of the commands keyed by the flag name,
+// the key <command>.<flag> only applies to the command & takes
+// precedence over the flag name alone, example
+//
+//	flags:
+//	  append: true
+//	  exec.parallel: 10
+type Flags map[string]string
+
+// Lookup returns the default value of the flag on the command
+func (f Flags) Lookup(command, flag string) (string, bool) {
+	if v, ok := f[command+"."+flag]; ok {
+		return v, true
+	}
+	v, ok := f[flag]
+	return v, ok
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/adzimzf/tpot/filter"
+)
+
+// ForwardProfile is the local port forwards added to the session of the
+// hosts matching the filter once it's connected with --with-forwards
+type ForwardProfile struct {
+	Name string `json:"name" yaml:"name"`
+
+	// Match is the filter expression of the hosts such as *-db-*
+	Match string `json:"match" yaml:"match"`
+
+	// Forwards is the local port forwards formatted as
+	// <local port>:<remote host>:<remote port>, example
+	//
+	//	[5432:localhost:5432]
+	Forwards []string `json:"forwards" yaml:"forwards"`
+}
+
+// Validate returns the error of the profile without a name,
+// the invalid filter or the invalid forward
+func (f ForwardProfile) Validate() error {
+	if f.Name == "" {
+		return fmt.Errorf("the forward profile of %q has no name", f.Match)
+	}
+	if _, err := filter.Parse(f.Match); err != nil {
+		return fmt.Errorf("invalid match of the forward profile %s, error: %v", f.Name, err)
+	}
+	if len(f.Forwards) == 0 {
+		return fmt.Errorf("the forward profile %s has no forwards", f.Name)
+	}
+	for _, fwd := range f.Forwards {
+		if _, err := ParseForward(fwd); err != nil {
+			return fmt.Errorf("invalid forward of the forward profile %s, error: %v", f.Name, err)
+		}
+	}
+	return nil
+}
+
+// ParseForward parses the forward formatted as <local port>:<remote host>:<remote port>
+func ParseForward(s string) (*ForwardingNode, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return nil, fmt.Errorf("invalid forward %q, use <local port>:<remote host>:<remote port>", s)
+	}
+	return &ForwardingNode{
+		ListenPort: parts[0],
+		RemoteHost: parts[1],
+		RemotePort: parts[2],
+	}, nil
+}
+
+// MatchForwardProfiles returns the profiles matching the host in the order
+// they're configured, the host out of the node cache is matched by its name
+func (p *Proxy) MatchForwardProfiles(profiles []ForwardProfile, host string) ([]ForwardProfile, error) {
+	item, _ := p.Node.LookUp(host)
+	item.Hostname = host
+
+	var res []ForwardProfile
+	for _, profile := range profiles {
+		f, err := filter.Parse(profile.Match)
+		if err != nil {
+			return nil, fmt.Errorf("invalid match of the forward profile %s, error: %v", profile.Name, err)
+		}
+		matched, err := p.Select(f, []Item{item})
+		if err != nil {
+			return nil, fmt.Errorf("failed to match the forward profile %s, error: %v", profile.Name, err)
+		}
+		if len(matched) > 0 {
+			res = append(res, profile)
+		}
+	}
+	return res, nil
+}
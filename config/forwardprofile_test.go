@@ -0,0 +1,47 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForwardProfile(t *testing.T) {
+	assert.NoError(t, ForwardProfile{Name: "db", Match: "*-db-*", Forwards: []string{"5432:localhost:5432"}}.Validate())
+	assert.Error(t, ForwardProfile{Match: "*-db-*", Forwards: []string{"5432:localhost:5432"}}.Validate())
+	assert.Error(t, ForwardProfile{Name: "db", Match: "(*-db-*", Forwards: []string{"5432:localhost:5432"}}.Validate())
+	assert.Error(t, ForwardProfile{Name: "db", Match: "*-db-*"}.Validate())
+	assert.Error(t, ForwardProfile{Name: "db", Match: "*-db-*", Forwards: []string{"5432:localhost"}}.Validate())
+
+	fwd, err := ParseForward("15432:10.0.0.1:5432")
+	require.NoError(t, err)
+	assert.Equal(t, "15432:10.0.0.1:5432", fwd.Address())
+	_, err = ParseForward("::5432")
+	assert.Error(t, err)
+}
+
+func TestMatchForwardProfiles(t *testing.T) {
+	p := &Proxy{Env: "prod", Node: Node{Items: []Item{
+		{Hostname: "pay-db-01", Address: "10.12.0.1:3022", Labels: map[string]string{"role": "db"}},
+		{Hostname: "cache-01", Address: "10.12.0.2:3022", Labels: map[string]string{"role": "redis"}},
+	}}}
+	profiles := []ForwardProfile{
+		{Name: "postgres", Match: "*-db-*", Forwards: []string{"5432:localhost:5432"}},
+		{Name: "redis", Match: "label:role=redis", Forwards: []string{"6379:localhost:6379"}},
+		{Name: "metrics", Match: "env=prod", Forwards: []string{"9100:localhost:9100"}},
+	}
+
+	got, err := p.MatchForwardProfiles(profiles, "pay-db-01")
+	require.NoError(t, err)
+	assert.Equal(t, []ForwardProfile{profiles[0], profiles[2]}, got)
+
+	got, err = p.MatchForwardProfiles(profiles, "cache-01")
+	require.NoError(t, err)
+	assert.Equal(t, []ForwardProfile{profiles[1], profiles[2]}, got)
+
+	// the host out of the node cache is matched by its name
+	got, err = p.MatchForwardProfiles(profiles, "new-db-02")
+	require.NoError(t, err)
+	assert.Equal(t, []ForwardProfile{profiles[0], profiles[2]}, got)
+}
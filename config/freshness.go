@@ -0,0 +1,41 @@
+package config
+
+import "time"
+
+// Seen stamps every item as listed by the source of the node at the time
+func (n *Node) Seen(at time.Time) {
+	for i := range n.Items {
+		n.Items[i].LastSeen, n.Items[i].Source = &at, n.Source
+	}
+}
+
+// SeenSince returns the items last listed by a refresh at or after the time,
+// the item never stamped is left out since its age is unknown
+func (n Node) SeenSince(t time.Time) []Item {
+	var res []Item
+	for _, item := range n.Items {
+		if item.LastSeen != nil && !item.LastSeen.Before(t) {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
+// Stale returns the items not listed by the latest refresh of the node, such
+// as the ones appended long ago & gone since. The latest refresh is the newest
+// LastSeen, hence nothing is stale until the items are stamped by a refresh
+func (n Node) Stale() []Item {
+	var latest time.Time
+	for _, item := range n.Items {
+		if item.SeenAt().After(latest) {
+			latest = item.SeenAt()
+		}
+	}
+	var res []Item
+	for _, item := range n.Items {
+		if !latest.IsZero() && item.SeenAt().Before(latest) {
+			res = append(res, item)
+		}
+	}
+	return res
+}
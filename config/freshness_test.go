@@ -0,0 +1,69 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func hostnamesOf(items []Item) []string {
+	var res []string
+	for _, item := range items {
+		res = append(res, item.Hostname)
+	}
+	return res
+}
+
+func TestNode_Seen(t *testing.T) {
+	at := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	n := Node{Items: []Item{{Hostname: "web-01"}, {Hostname: "web-02"}}, Source: SourceTSH}
+	n.Seen(at)
+	for _, item := range n.Items {
+		assert.Equal(t, at, item.SeenAt())
+		assert.Equal(t, SourceTSH, item.Source)
+	}
+}
+
+// seenAt returns the LastSeen of the time
+func seenAt(t time.Time) *time.Time {
+	return &t
+}
+
+func TestNode_SeenSince(t *testing.T) {
+	now := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	n := Node{Items: []Item{
+		{Hostname: "web-01", LastSeen: seenAt(now)},
+		{Hostname: "web-02", LastSeen: seenAt(now.Add(-48 * time.Hour))},
+		{Hostname: "web-03"},
+		{Hostname: "web-04", LastSeen: seenAt(now.Add(-time.Hour))},
+	}}
+	assert.Equal(t, []string{"web-01", "web-04"}, hostnamesOf(n.SeenSince(now.Add(-time.Hour))))
+	assert.Empty(t, n.SeenSince(now.Add(time.Minute)))
+}
+
+func TestNode_Stale(t *testing.T) {
+	now := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		items []Item
+		want  []string
+	}{
+		{name: "never stamped", items: []Item{{Hostname: "web-01"}, {Hostname: "web-02"}}},
+		{name: "all listed", items: []Item{{Hostname: "web-01", LastSeen: seenAt(now)}, {Hostname: "web-02", LastSeen: seenAt(now)}}},
+		{
+			name: "appended long ago",
+			items: []Item{
+				{Hostname: "web-01", LastSeen: seenAt(now)},
+				{Hostname: "web-02", LastSeen: seenAt(now.Add(-72 * time.Hour))},
+				{Hostname: "web-03"},
+			},
+			want: []string{"web-02", "web-03"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, hostnamesOf(Node{Items: tt.items}.Stale()))
+		})
+	}
+}
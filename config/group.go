@@ -0,0 +1,43 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/adzimzf/tpot/filter"
+)
+
+// SaveGroup validates the filter expression of the group & saves it into the configuration
+func (c *Config) SaveGroup(name, query string) error {
+	if name == "" || strings.ContainsAny(name, "@ \t/") {
+		return fmt.Errorf("invalid group name %q", name)
+	}
+	if _, err := filter.Parse(query); err != nil {
+		return err
+	}
+	if c.Groups == nil {
+		c.Groups = map[string]string{}
+	}
+	c.Groups[name] = query
+	return c.save()
+}
+
+// DeleteGroup deletes the group from the configuration
+func (c *Config) DeleteGroup(name string) error {
+	if _, ok := c.Groups[name]; !ok {
+		return fmt.Errorf("group %s is not found", name)
+	}
+	delete(c.Groups, name)
+	return c.save()
+}
+
+// GroupNames returns the sorted names of the groups
+func (c *Config) GroupNames() []string {
+	names := make([]string, 0, len(c.Groups))
+	for name := range c.Groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_SaveGroup(t *testing.T) {
+	tempConfigDir(t)
+	c := &Config{}
+
+	assert.Error(t, c.SaveGroup("@web", "web"))
+	assert.Error(t, c.SaveGroup("web", "web AND"))
+	assert.NoError(t, c.SaveGroup("edge", "(web OR db) AND NOT env=dev"))
+	assert.NoError(t, c.SaveGroup("web", "label:role=web AND env=prod"))
+	assert.NoError(t, c.SaveGroup("db", "db"))
+	assert.Equal(t, []string{"db", "edge", "web"}, c.GroupNames())
+
+	b, err := ioutil.ReadFile(Dir + configFileName)
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), "web: label:role=web AND env=prod")
+
+	assert.NoError(t, c.DeleteGroup("db"))
+	assert.Error(t, c.DeleteGroup("db"))
+	assert.Equal(t, []string{"edge", "web"}, c.GroupNames())
+}
@@ -0,0 +1,34 @@
+package config
+
+import "strings"
+
+// maxSearchHistory is the number of the picker queries kept per env
+const maxSearchHistory = 20
+
+// GetSearchHistory get the picker queries of the env, the latest first
+func (p *Proxy) GetSearchHistory() ([]string, error) {
+	return store.GetSearchHistory(p.Env)
+}
+
+// AddSearchHistory puts the query on top of the picker queries,
+// the same query is moved instead of being duplicated
+func (p *Proxy) AddSearchHistory(query string) error {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil
+	}
+
+	history, err := p.GetSearchHistory()
+	if err != nil {
+		return err
+	}
+
+	res := []string{query}
+	for _, q := range history {
+		if q != query && len(res) < maxSearchHistory {
+			res = append(res, q)
+		}
+	}
+
+	return store.UpdateSearchHistory(p.Env, res)
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProxy_AddSearchHistory(t *testing.T) {
+	tempCacheDir(t)
+	p := &Proxy{Env: "staging"}
+
+	history, err := p.GetSearchHistory()
+	assert.NoError(t, err)
+	assert.Empty(t, history)
+
+	for _, q := range []string{"cassandra-", "web", " ", "cassandra-"} {
+		assert.NoError(t, p.AddSearchHistory(q))
+	}
+	history, err = p.GetSearchHistory()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cassandra-", "web"}, history)
+
+	// the other env has its own history
+	other, err := (&Proxy{Env: "prod"}).GetSearchHistory()
+	assert.NoError(t, err)
+	assert.Empty(t, other)
+
+	for i := 0; i < maxSearchHistory+5; i++ {
+		assert.NoError(t, p.AddSearchHistory(fmt.Sprintf("q%d", i)))
+	}
+	history, err = p.GetSearchHistory()
+	assert.NoError(t, err)
+	assert.Len(t, history, maxSearchHistory)
+	assert.Equal(t, fmt.Sprintf("q%d", maxSearchHistory+4), history[0])
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// HostMeta is the metadata of a host enriched from the inventory such as
+// Netbox, like the owner team, the rack, the site & the service
+type HostMeta struct {
+	Meta map[string]string `json:"meta"`
+
+	// Source is the inventories the metadata is looked up from
+	Source     string    `json:"source,omitempty"`
+	EnrichedAt time.Time `json:"enriched_at"`
+}
+
+// GetHostMeta gets the enriched metadata of the env hosts by the hostname
+func (p *Proxy) GetHostMeta() (map[string]HostMeta, error) {
+	res := map[string]HostMeta{}
+	b, err := ioutil.ReadFile(p.hostMetaPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return res, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, json.Unmarshal(b, &res)
+}
+
+// SetHostMeta replaces the enriched metadata of the env hosts,
+// the hosts no longer known by the inventory are dropped
+func (p *Proxy) SetHostMeta(meta map[string]HostMeta) error {
+	b, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(p.hostMetaPath(), b, permission)
+}
+
+func (p *Proxy) hostMetaPath() string {
+	return CacheDir + "meta_" + p.Env + ".json"
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// HostStats is the connection outcomes of a host
+type HostStats struct {
+	Successes int `json:"successes"`
+	Failures  int `json:"failures"`
+
+	// FailedInRow is the failed connections since the last success
+	FailedInRow int `json:"failed_in_row"`
+
+	LastSuccess time.Time `json:"last_success,omitempty"`
+	LastFailure time.Time `json:"last_failure,omitempty"`
+
+	// LastError is the error of the last failed connection
+	LastError string `json:"last_error,omitempty"`
+
+	// Latency is the connection latency measured by tpot ping at LastProbe
+	Latency   time.Duration `json:"latency,omitempty"`
+	LastProbe time.Time     `json:"last_probe,omitempty"`
+}
+
+// GetHostStats gets the connection outcomes of the env hosts by the hostname
+func (p *Proxy) GetHostStats() (map[string]HostStats, error) {
+	res := map[string]HostStats{}
+	b, err := ioutil.ReadFile(p.hostStatsPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return res, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, json.Unmarshal(b, &res)
+}
+
+// RecordConnection records the connection outcome of the host at the time,
+// the failed connection is the one with the error
+func (p *Proxy) RecordConnection(host string, at time.Time, connErr error) error {
+	stats, err := p.GetHostStats()
+	if err != nil {
+		return err
+	}
+
+	s := stats[host]
+	if connErr == nil {
+		s.Successes++
+		s.FailedInRow = 0
+		s.LastSuccess = at
+	} else {
+		s.Failures++
+		s.FailedInRow++
+		s.LastFailure = at
+		s.LastError = connErr.Error()
+	}
+	stats[host] = s
+	return p.saveHostStats(stats)
+}
+
+// RecordLatencies records the connection latency of the hosts measured at the time
+func (p *Proxy) RecordLatencies(latencies map[string]time.Duration, at time.Time) error {
+	stats, err := p.GetHostStats()
+	if err != nil {
+		return err
+	}
+	for host, latency := range latencies {
+		s := stats[host]
+		s.Latency, s.LastProbe = latency, at
+		stats[host] = s
+	}
+	return p.saveHostStats(stats)
+}
+
+func (p *Proxy) saveHostStats(stats map[string]HostStats) error {
+	b, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(p.hostStatsPath(), b, permission)
+}
+
+func (p *Proxy) hostStatsPath() string {
+	return CacheDir + "stats_" + p.Env + ".json"
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProxy_RecordConnection(t *testing.T) {
+	tempCacheDir(t)
+	p := &Proxy{Env: "staging"}
+
+	stats, err := p.GetHostStats()
+	assert.NoError(t, err)
+	assert.Empty(t, stats)
+
+	now := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	refused := errors.New("ERROR: connection refused")
+	assert.NoError(t, p.RecordConnection("web-01", now, nil))
+	assert.NoError(t, p.RecordConnection("web-01", now.Add(time.Minute), refused))
+	assert.NoError(t, p.RecordConnection("web-01", now.Add(2*time.Minute), refused))
+	assert.NoError(t, p.RecordConnection("web-02", now, nil))
+
+	stats, err = p.GetHostStats()
+	assert.NoError(t, err)
+	assert.Equal(t, HostStats{
+		Successes:   1,
+		Failures:    2,
+		FailedInRow: 2,
+		LastSuccess: now,
+		LastFailure: now.Add(2 * time.Minute),
+		LastError:   "ERROR: connection refused",
+	}, stats["web-01"])
+	assert.Equal(t, HostStats{Successes: 1, LastSuccess: now}, stats["web-02"])
+
+	// the success resets the failures in a row
+	assert.NoError(t, p.RecordConnection("web-01", now.Add(3*time.Minute), nil))
+	stats, err = p.GetHostStats()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, stats["web-01"].FailedInRow)
+	assert.Equal(t, 2, stats["web-01"].Successes)
+
+	// the latency is kept along with the connection outcomes
+	assert.NoError(t, p.RecordLatencies(map[string]time.Duration{"web-01": 120 * time.Millisecond}, now))
+	stats, err = p.GetHostStats()
+	assert.NoError(t, err)
+	assert.Equal(t, 120*time.Millisecond, stats["web-01"].Latency)
+	assert.Equal(t, now, stats["web-01"].LastProbe)
+	assert.Equal(t, 2, stats["web-01"].Successes)
+
+	// the other env has its own stats
+	other, err := (&Proxy{Env: "prod"}).GetHostStats()
+	assert.NoError(t, err)
+	assert.Empty(t, other)
+}
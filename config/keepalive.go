@@ -0,0 +1,51 @@
+package config
+
+import "time"
+
+// the defaults & the limit of Keepalive
+const (
+	DefaultKeepaliveInterval    = 15 * time.Minute
+	MinKeepaliveInterval        = 5 * time.Minute
+	DefaultKeepaliveRenewBefore = time.Hour
+)
+
+// Keepalive is how tpot serve keeps the login of the environments marked keepalive,
+// the login is checked by the local tsh status & renewed by the SSO before it expires
+type Keepalive struct {
+	// IntervalMinutes is how often the login of every environment is checked,
+	// default is 15 & it's at least 5 so the SSO isn't hammered
+	IntervalMinutes int `json:"interval_minutes,omitempty" yaml:"interval_minutes,omitempty"`
+
+	// RenewBeforeMinutes is how long before the login expires it's renewed, default is 60
+	RenewBeforeMinutes int `json:"renew_before_minutes,omitempty" yaml:"renew_before_minutes,omitempty"`
+}
+
+// Interval returns how often the login is checked, it's never below MinKeepaliveInterval
+func (k Keepalive) Interval() time.Duration {
+	if k.IntervalMinutes <= 0 {
+		return DefaultKeepaliveInterval
+	}
+	if d := time.Duration(k.IntervalMinutes) * time.Minute; d > MinKeepaliveInterval {
+		return d
+	}
+	return MinKeepaliveInterval
+}
+
+// RenewBefore returns how long before the login expires it's renewed
+func (k Keepalive) RenewBefore() time.Duration {
+	if k.RenewBeforeMinutes <= 0 {
+		return DefaultKeepaliveRenewBefore
+	}
+	return time.Duration(k.RenewBeforeMinutes) * time.Minute
+}
+
+// KeepaliveProxies returns the environments marked keepalive
+func (c *Config) KeepaliveProxies() []*Proxy {
+	var res []*Proxy
+	for _, p := range c.Proxies {
+		if p.Keepalive {
+			res = append(res, p)
+		}
+	}
+	return res
+}
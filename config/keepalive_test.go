@@ -0,0 +1,22 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeepalive(t *testing.T) {
+	assert.Equal(t, DefaultKeepaliveInterval, Keepalive{}.Interval())
+	assert.Equal(t, MinKeepaliveInterval, Keepalive{IntervalMinutes: 1}.Interval())
+	assert.Equal(t, 30*time.Minute, Keepalive{IntervalMinutes: 30}.Interval())
+	assert.Equal(t, DefaultKeepaliveRenewBefore, Keepalive{}.RenewBefore())
+	assert.Equal(t, 2*time.Hour, Keepalive{RenewBeforeMinutes: 120}.RenewBefore())
+
+	c := &Config{Proxies: []*Proxy{{Env: "prod", Keepalive: true}, {Env: "staging"}}}
+	got := c.KeepaliveProxies()
+	if assert.Len(t, got, 1) {
+		assert.Equal(t, "prod", got[0].Env)
+	}
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"path"
+	"strings"
+)
+
+// DefaultLiveConcurrency is how many environments are refreshed at once by default
+const DefaultLiveConcurrency = 2
+
+// TagPrefix starts the route of the environments of a tag such as tag:eu
+const TagPrefix = "tag:"
+
+// LiveSearch is how tpot find --live refreshes the environments
+// once the host isn't found in the node caches
+type LiveSearch struct {
+	// Order is the routes refreshed first in the order, tag:<tag> is the environments
+	// of the tag & the others are the globs of the environment such as prod-*.
+	// The environments not routed are refreshed last in the configuration order
+	Order []string `json:"order,omitempty" yaml:"order,omitempty"`
+
+	// Concurrency is the most environments refreshed at once so the
+	// clusters aren't refreshed all together, default is 2
+	Concurrency int `json:"concurrency,omitempty" yaml:"concurrency,omitempty"`
+}
+
+// Limit returns how many environments are refreshed at once
+func (l LiveSearch) Limit() int {
+	if l.Concurrency > 0 {
+		return l.Concurrency
+	}
+	return DefaultLiveConcurrency
+}
+
+// Route returns the environments in the order they're refreshed, the
+// environment of the earlier route goes first & the rest keep their order
+func (l LiveSearch) Route(proxies []*Proxy) []*Proxy {
+	rank := func(p *Proxy) int {
+		for i, route := range l.Order {
+			if p.hasRoute(route) {
+				return i
+			}
+		}
+		return len(l.Order)
+	}
+
+	res := make([]*Proxy, 0, len(proxies))
+	for r := 0; r <= len(l.Order); r++ {
+		for _, p := range proxies {
+			if rank(p) == r {
+				res = append(res, p)
+			}
+		}
+	}
+	return res
+}
+
+// hasRoute returns true if the proxy has the tag of the tag:<tag>
+// route, or its environment matches the glob of the route
+func (p *Proxy) hasRoute(route string) bool {
+	if strings.HasPrefix(route, TagPrefix) {
+		tag := strings.TrimPrefix(route, TagPrefix)
+		for _, t := range p.Tags {
+			if t == tag {
+				return true
+			}
+		}
+		return false
+	}
+	ok, _ := path.Match(route, p.Env)
+	return ok
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLiveSearch_Route(t *testing.T) {
+	proxies := []*Proxy{
+		{Env: "prod-us", Tags: []string{"us"}},
+		{Env: "staging"},
+		{Env: "prod-eu", Tags: []string{"eu", "k8s"}},
+		{Env: "dev-eu", Tags: []string{"eu"}},
+	}
+	envs := func(l LiveSearch) (res []string) {
+		for _, p := range l.Route(proxies) {
+			res = append(res, p.Env)
+		}
+		return
+	}
+
+	assert.Equal(t, []string{"prod-us", "staging", "prod-eu", "dev-eu"}, envs(LiveSearch{}))
+	assert.Equal(t, []string{"prod-eu", "dev-eu", "prod-us", "staging"}, envs(LiveSearch{Order: []string{"tag:eu"}}))
+	assert.Equal(t, []string{"prod-eu", "dev-eu", "staging", "prod-us"}, envs(LiveSearch{Order: []string{"tag:k8s", "tag:eu", "stag*"}}))
+	assert.Equal(t, []string{"prod-us", "prod-eu", "staging", "dev-eu"}, envs(LiveSearch{Order: []string{"prod-*", "tag:missing"}}))
+}
+
+func TestLiveSearch_Limit(t *testing.T) {
+	assert.Equal(t, DefaultLiveConcurrency, LiveSearch{}.Limit())
+	assert.Equal(t, 5, LiveSearch{Concurrency: 5}.Limit())
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// Maintenance is the maintenance window of a host, such as a drained node
+type Maintenance struct {
+	Until  time.Time `json:"until"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// GetMaintenance gets the maintenance windows of the env hosts by the
+// hostname, the windows expired by the time are left out
+func (p *Proxy) GetMaintenance(now time.Time) (map[string]Maintenance, error) {
+	res := map[string]Maintenance{}
+	b, err := ioutil.ReadFile(p.maintenancePath())
+	if errors.Is(err, os.ErrNotExist) {
+		return res, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+	for host, m := range res {
+		if !m.Until.After(now) {
+			delete(res, host)
+		}
+	}
+	return res, nil
+}
+
+// SetMaintenance puts the hosts in maintenance until the window expires,
+// the expired windows of the other hosts are dropped
+func (p *Proxy) SetMaintenance(hosts []string, m Maintenance, now time.Time) error {
+	windows, err := p.GetMaintenance(now)
+	if err != nil {
+		return err
+	}
+	for _, host := range hosts {
+		windows[host] = m
+	}
+	return p.saveMaintenance(windows)
+}
+
+// ClearMaintenance ends the maintenance of the hosts, it returns
+// the hosts which were in maintenance
+func (p *Proxy) ClearMaintenance(hosts []string, now time.Time) ([]string, error) {
+	windows, err := p.GetMaintenance(now)
+	if err != nil {
+		return nil, err
+	}
+	var cleared []string
+	for _, host := range hosts {
+		if _, ok := windows[host]; ok {
+			delete(windows, host)
+			cleared = append(cleared, host)
+		}
+	}
+	return cleared, p.saveMaintenance(windows)
+}
+
+func (p *Proxy) saveMaintenance(windows map[string]Maintenance) error {
+	if len(windows) == 0 {
+		if err := os.Remove(p.maintenancePath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	b, err := json.Marshal(windows)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(p.maintenancePath(), b, permission)
+}
+
+func (p *Proxy) maintenancePath() string {
+	return CacheDir + "maint_" + p.Env + ".json"
+}
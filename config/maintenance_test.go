@@ -0,0 +1,46 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProxy_SetMaintenance(t *testing.T) {
+	tempCacheDir(t)
+	p := &Proxy{Env: "staging"}
+	now := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	windows, err := p.GetMaintenance(now)
+	assert.NoError(t, err)
+	assert.Empty(t, windows)
+
+	drained := Maintenance{Until: now.Add(2 * time.Hour), Reason: "kernel upgrade"}
+	assert.NoError(t, p.SetMaintenance([]string{"web-01", "web-02"}, drained, now))
+	assert.NoError(t, p.SetMaintenance([]string{"db-01"}, Maintenance{Until: now.Add(time.Minute)}, now))
+
+	windows, err = p.GetMaintenance(now)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]Maintenance{
+		"web-01": drained,
+		"web-02": drained,
+		"db-01":  {Until: now.Add(time.Minute)},
+	}, windows)
+
+	// the expired window is left out
+	windows, err = p.GetMaintenance(now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]Maintenance{"web-01": drained, "web-02": drained}, windows)
+
+	cleared, err := p.ClearMaintenance([]string{"web-01", "app-01"}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"web-01"}, cleared)
+	windows, err = p.GetMaintenance(now)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]Maintenance{"web-02": drained, "db-01": {Until: now.Add(time.Minute)}}, windows)
+
+	_, err = p.ClearMaintenance([]string{"web-02", "db-01"}, now)
+	assert.NoError(t, err)
+	assert.NoFileExists(t, p.maintenancePath())
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// StorageMemory is the backend of MemoryStore, it's only used by the tests
+const StorageMemory = "memory"
+
+// MemoryStore keeps the node cache, the facts & the search history in memory, it's used by UseStore
+// to run the commands without touching the cache directory such as in the tests
+type MemoryStore struct {
+	mu    sync.Mutex
+	nodes map[string]Node
+	facts map[string]map[string]HostFacts
+
+	history map[string][]string
+}
+
+// NewMemoryStore creates the empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{nodes: map[string]Node{}, facts: map[string]map[string]HostFacts{}, history: map[string][]string{}}
+}
+
+func (m *MemoryStore) GetNode(env string) (Node, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n, ok := m.nodes[env]
+	if !ok {
+		return n, fmt.Errorf("node cache of %s, error: %w", env, os.ErrNotExist)
+	}
+	return n, nil
+}
+
+func (m *MemoryStore) UpdateNode(env string, n Node) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nodes[env] = n
+	return nil
+}
+
+func (m *MemoryStore) GetFacts(env string) (map[string]HostFacts, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	res := make(map[string]HostFacts)
+	for host, f := range m.facts[env] {
+		res[host] = f
+	}
+	return res, nil
+}
+
+func (m *MemoryStore) UpdateFacts(env, host string, facts HostFacts) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.facts[env] == nil {
+		m.facts[env] = map[string]HostFacts{}
+	}
+	m.facts[env][host] = facts
+	return nil
+}
+
+func (m *MemoryStore) GetSearchHistory(env string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.history[env]...), nil
+}
+
+func (m *MemoryStore) UpdateSearchHistory(env string, queries []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.history[env] = append([]string(nil), queries...)
+	return nil
+}
+
+func (m *MemoryStore) Info(env string) (CacheInfo, error) {
+	n, err := m.GetNode(env)
+	return CacheInfo{Backend: StorageMemory, Nodes: len(n.Items), Source: n.Source, Checksum: ChecksumNone}, err
+}
+
+func (m *MemoryStore) DeleteNode(env string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.nodes, env)
+	delete(m.facts, env)
+	return nil
+}
+
+func (m *MemoryStore) Close() error {
+	return nil
+}
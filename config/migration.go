@@ -0,0 +1,143 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// schemaFileName stores the schema version of the config & cache in Dir
+const schemaFileName = "schema_version"
+
+// ErrSchemaTooNew is returned when the config directory is written by
+// a newer tpot, it's never downgraded silently
+var ErrSchemaTooNew = errors.New("config directory is written by a newer tpot")
+
+// migration upgrades the config directory from version-1 to version
+type migration struct {
+	version     int
+	description string
+	up          func() error
+}
+
+// migrations is the list of on-disk format changes ordered by the version,
+// append a new migration here whenever the config or cache format changes
+var migrations = []migration{
+	{version: 1, description: "convert config.json into config.yaml", up: migrateJSONConfig},
+	{version: 2, description: "move the picker sort files into the UI state", up: migratePickerSort},
+}
+
+// SchemaVersion is the latest schema version this tpot understands
+func SchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// migrate runs the pending migrations of the config directory
+func migrate() error {
+	return runMigrations(migrations)
+}
+
+func runMigrations(ms []migration) error {
+	current, err := readSchemaVersion()
+	if err != nil {
+		return err
+	}
+	latest := ms[len(ms)-1].version
+	if current > latest {
+		return fmt.Errorf("%w, the schema version is %d while this tpot only supports up to %d, please upgrade tpot",
+			ErrSchemaTooNew, current, latest)
+	}
+
+	for _, m := range ms {
+		if m.version <= current {
+			continue
+		}
+		if err := m.up(); err != nil {
+			return fmt.Errorf("failed to migrate to schema version %d (%s), error: %v", m.version, m.description, err)
+		}
+		// the version is written after every migration to
+		// resume from the failed one on the next run
+		if err := writeSchemaVersion(m.version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readSchemaVersion() (int, error) {
+	b, err := ioutil.ReadFile(Dir + schemaFileName)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid schema version %q, error: %v", b, err)
+	}
+	return v, nil
+}
+
+func writeSchemaVersion(v int) error {
+	return ioutil.WriteFile(Dir+schemaFileName, []byte(strconv.Itoa(v)+"\n"), permission)
+}
+
+// migrateJSONConfig converts the legacy JSON config into YAML
+func migrateJSONConfig() error {
+	if _, err := os.Stat(Dir + configFileName); err == nil {
+		return nil
+	}
+	bytes, err := ioutil.ReadFile(Dir + "config.json")
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var config Config
+	if err := json.Unmarshal(bytes, &config); err != nil {
+		return err
+	}
+	return config.save()
+}
+
+// migratePickerSort moves the picker sort kept in a sort_<env> file of the
+// cache directory into the UI state, the sort already in the state is kept
+func migratePickerSort() error {
+	files, err := ioutil.ReadDir(CacheDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasPrefix(f.Name(), "sort_") {
+			continue
+		}
+		path := CacheDir + f.Name()
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		sort := strings.TrimSpace(string(b))
+		err = UpdateEnvState(strings.TrimPrefix(f.Name(), "sort_"), func(s *EnvState) {
+			if s.Sort == "" {
+				s.Sort = sort
+			}
+		})
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
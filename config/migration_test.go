@@ -0,0 +1,103 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_runMigrations(t *testing.T) {
+	var ran []int
+	ms := func(failOn int) []migration {
+		var res []migration
+		for v := 1; v <= 3; v++ {
+			v := v
+			res = append(res, migration{version: v, up: func() error {
+				if v == failOn {
+					return fmt.Errorf("failed")
+				}
+				ran = append(ran, v)
+				return nil
+			}})
+		}
+		return res
+	}
+
+	tests := []struct {
+		name        string
+		current     string
+		failOn      int
+		wantRan     []int
+		wantVersion int
+		wantErr     error
+	}{
+		{name: "fresh directory", wantRan: []int{1, 2, 3}, wantVersion: 3},
+		{name: "pending migrations", current: "1\n", wantRan: []int{2, 3}, wantVersion: 3},
+		{name: "up to date", current: "3\n", wantVersion: 3},
+		{name: "resume from the failed migration", current: "1\n", failOn: 3, wantRan: []int{2}, wantVersion: 2},
+		{name: "refuse to downgrade", current: "4\n", wantVersion: 4, wantErr: ErrSchemaTooNew},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempConfigDir(t)
+			if tt.current != "" {
+				if err := ioutil.WriteFile(Dir+schemaFileName, []byte(tt.current), permission); err != nil {
+					t.Fatal(err)
+				}
+			}
+			ran = nil
+
+			err := runMigrations(ms(tt.failOn))
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), err)
+			} else if tt.failOn == 0 {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+			assert.Equal(t, tt.wantRan, ran)
+
+			v, err := readSchemaVersion()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantVersion, v)
+		})
+	}
+}
+
+func Test_migrateJSONConfig(t *testing.T) {
+	tempConfigDir(t)
+	err := ioutil.WriteFile(Dir+"config.json", []byte(`{"editor":"vim","proxies":[{"env":"prod","address":"https://teleport.mycomp.com"}]}`), permission)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NoError(t, migrateJSONConfig())
+	c, err := getConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, "vim", c.Editor)
+	assert.Equal(t, "https://teleport.mycomp.com", c.Proxies[0].Address)
+}
+
+func Test_migratePickerSort(t *testing.T) {
+	tempCacheDir(t)
+	assert.NoError(t, ioutil.WriteFile(CacheDir+"sort_staging", []byte("latency\n"), permission))
+	assert.NoError(t, ioutil.WriteFile(CacheDir+"sort_prod", []byte("ip\n"), permission))
+	// the sort chosen after the legacy file is kept
+	assert.NoError(t, (&Proxy{Env: "prod"}).SetPickerSort("label.team"))
+
+	assert.NoError(t, migratePickerSort())
+	sort, err := (&Proxy{Env: "staging"}).GetPickerSort()
+	assert.NoError(t, err)
+	assert.Equal(t, "latency", sort)
+	sort, err = (&Proxy{Env: "prod"}).GetPickerSort()
+	assert.NoError(t, err)
+	assert.Equal(t, "label.team", sort)
+
+	// the legacy files are gone once the sort is in the UI state
+	for _, name := range []string{"sort_staging", "sort_prod"} {
+		assert.NoFileExists(t, CacheDir+name)
+	}
+}
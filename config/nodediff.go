@@ -0,0 +1,76 @@
+package config
+
+import (
+	"sort"
+	"strings"
+)
+
+// NodeDiff is the differences between the nodes of two sources, such as
+// the Teleport UI & tsh ls, the hostnames are sorted
+type NodeDiff struct {
+	// OnlyA & OnlyB is the hostnames listed by one of the sources only
+	OnlyA []string
+	OnlyB []string
+
+	// Changed is the hostnames listed by both sources with the different IPs
+	Changed []NodeChange
+}
+
+// NodeChange is the IPs of the hostname listed by each source, the tunnel node has no IP
+type NodeChange struct {
+	Hostname string
+	A        []string
+	B        []string
+}
+
+// Empty returns true if both sources list the same nodes
+func (d NodeDiff) Empty() bool {
+	return len(d.OnlyA) == 0 && len(d.OnlyB) == 0 && len(d.Changed) == 0
+}
+
+// Len returns the number of the different hostnames
+func (d NodeDiff) Len() int {
+	return len(d.OnlyA) + len(d.OnlyB) + len(d.Changed)
+}
+
+// CompareNodes compares the nodes of the sources by the hostname & the IP,
+// the port & the labels are ignored since the sources format them differently
+func CompareNodes(a, b Node) NodeDiff {
+	ipsA, ipsB := nodeIPs(a), nodeIPs(b)
+	var d NodeDiff
+	for host, ips := range ipsA {
+		other, ok := ipsB[host]
+		switch {
+		case !ok:
+			d.OnlyA = append(d.OnlyA, host)
+		case strings.Join(ips, ",") != strings.Join(other, ","):
+			d.Changed = append(d.Changed, NodeChange{Hostname: host, A: ips, B: other})
+		}
+	}
+	for host := range ipsB {
+		if _, ok := ipsA[host]; !ok {
+			d.OnlyB = append(d.OnlyB, host)
+		}
+	}
+	sort.Strings(d.OnlyA)
+	sort.Strings(d.OnlyB)
+	sort.Slice(d.Changed, func(i, j int) bool { return d.Changed[i].Hostname < d.Changed[j].Hostname })
+	return d
+}
+
+// nodeIPs returns the sorted IPs of every hostname, the hostname may be
+// listed more than once by the reused names of the autoscaling groups
+func nodeIPs(n Node) map[string][]string {
+	res := make(map[string][]string, len(n.Items))
+	for _, item := range n.Items {
+		ips := res[item.Hostname]
+		if ip := item.IP(); ip != "" {
+			ips = append(ips, ip)
+		}
+		res[item.Hostname] = ips
+	}
+	for _, ips := range res {
+		sort.Strings(ips)
+	}
+	return res
+}
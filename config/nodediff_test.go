@@ -0,0 +1,31 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareNodes(t *testing.T) {
+	web := Node{Items: []Item{
+		{Hostname: "web-01", Address: "10.0.0.1:3022"},
+		{Hostname: "web-02", Address: "10.0.0.2:3022"},
+		{Hostname: "db-01", Address: "10.0.1.1:3022"},
+		{Hostname: "edge-01", Address: ""},
+	}}
+	tsh := Node{Items: []Item{
+		{Hostname: "web-01", Address: "10.0.0.1:3022"},
+		{Hostname: "web-02", Address: "10.0.0.9:3022"},
+		{Hostname: "cache-01", Address: "10.0.2.1:3022"},
+		{Hostname: "edge-01", Address: "⟵ Tunnel"},
+	}}
+
+	d := CompareNodes(web, tsh)
+	assert.Equal(t, []string{"db-01"}, d.OnlyA)
+	assert.Equal(t, []string{"cache-01"}, d.OnlyB)
+	assert.Equal(t, []NodeChange{{Hostname: "web-02", A: []string{"10.0.0.2"}, B: []string{"10.0.0.9"}}}, d.Changed)
+	assert.Equal(t, 3, d.Len())
+	assert.False(t, d.Empty())
+
+	assert.True(t, CompareNodes(web, web).Empty())
+}
@@ -0,0 +1,16 @@
+package config
+
+// GetPickerSort gets the order of the picker chosen last time on the env,
+// it's empty when there's none
+func (p *Proxy) GetPickerSort() (string, error) {
+	s, err := GetState()
+	if err != nil {
+		return "", err
+	}
+	return s.Envs[p.Env].Sort, nil
+}
+
+// SetPickerSort saves the order of the picker chosen on the env
+func (p *Proxy) SetPickerSort(sort string) error {
+	return UpdateEnvState(p.Env, func(s *EnvState) { s.Sort = sort })
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProxy_SetPickerSort(t *testing.T) {
+	tempCacheDir(t)
+	p := &Proxy{Env: "staging"}
+
+	sort, err := p.GetPickerSort()
+	assert.NoError(t, err)
+	assert.Empty(t, sort)
+
+	assert.NoError(t, p.SetPickerSort("label.team"))
+	sort, err = p.GetPickerSort()
+	assert.NoError(t, err)
+	assert.Equal(t, "label.team", sort)
+
+	// the other env has its own sort
+	sort, err = (&Proxy{Env: "prod"}).GetPickerSort()
+	assert.NoError(t, err)
+	assert.Empty(t, sort)
+}
@@ -0,0 +1,346 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/adzimzf/tpot/filter"
+	"github.com/adzimzf/tpot/shell"
+	"gopkg.in/yaml.v2"
+)
+
+// PolicyPath is the machine-wide policy managed by the admin,
+// it can be moved by TPOT_POLICY
+var PolicyPath = policyPath()
+
+func policyPath() string {
+	if p := os.Getenv("TPOT_POLICY"); p != "" {
+		return p
+	}
+	return "/etc/tpot/policy.yaml"
+}
+
+// Policy is the machine-wide rules, it takes precedence over
+// the user configuration & the command flags
+type Policy struct {
+	// MinTSHVersion is the minimum tsh version to login, example 6.2.0
+	MinTSHVersion string `yaml:"min_tsh_version,omitempty"`
+
+	// ForbidInsecure forbids passing --insecure to tsh
+	ForbidInsecure bool `yaml:"forbid_insecure,omitempty"`
+
+	// ForbiddenFlags is the tsh flags the users can't pass,
+	// either by --tsh-arg or extra_tsh_flags
+	ForbiddenFlags []string `yaml:"forbidden_flags,omitempty"`
+
+	// ForbidKeepaliveRenew forbids renewing the login in the background, the
+	// keepalive environments are only checked & the user is told to log in
+	ForbidKeepaliveRenew bool `yaml:"forbid_keepalive_renew,omitempty"`
+
+	// EnforceAudit turns on the audit log regardless of the user configuration
+	EnforceAudit bool `yaml:"enforce_audit,omitempty"`
+
+	// AllowedEnvs is the environment names the users may define,
+	// glob is supported, empty means any environment
+	AllowedEnvs []string `yaml:"allowed_envs,omitempty"`
+
+	// Environments enforces the guards of the environment by name
+	Environments map[string]EnvPolicy `yaml:"environments,omitempty"`
+
+	// Hosts enforces the guards of the hosts matching the filter
+	// expression, whichever the environment they belong to
+	Hosts []HostPolicy `yaml:"hosts,omitempty"`
+
+	// BundleKey is the base64 ed25519 public key the configuration bundles
+	// must be signed by, tpot config sync refuses the unsigned or tampered
+	// bundle when it's set
+	BundleKey string `yaml:"bundle_key,omitempty"`
+
+	// Defaults is the organization-wide settings added to the user configuration
+	Defaults PolicyDefaults `yaml:"defaults,omitempty"`
+}
+
+// PolicyDefaults is the settings every user of the machine gets
+// along with their own configuration
+type PolicyDefaults struct {
+	// AuditSinks is the audit sinks every event is shipped to
+	// besides the sinks of the user configuration
+	AuditSinks []AuditSink `yaml:"audit_sinks,omitempty"`
+}
+
+// EnvPolicy is the guards enforced on an environment,
+// the guard turned on by the user can't be turned off
+type EnvPolicy struct {
+	Critical   bool `yaml:"critical,omitempty"`
+	ConfirmEnv bool `yaml:"confirm_env,omitempty"`
+	Protected  bool `yaml:"protected,omitempty"`
+	ReadOnly   bool `yaml:"read_only,omitempty"`
+
+	// RequireReason asks the ticket or the reason before connecting
+	RequireReason bool `yaml:"require_reason,omitempty"`
+
+	// ExecAllow is the filter expression every command run by exec must match
+	// by the cmd terms, such as cmd:uptime OR cmd:"systemctl status", see CheckExec
+	ExecAllow string `yaml:"exec_allow,omitempty"`
+
+	// ExecDeny is the filter expression of the commands exec refuses to run
+	ExecDeny string `yaml:"exec_deny,omitempty"`
+
+	// DenyShell refuses the interactive shells, only exec is allowed
+	DenyShell bool `yaml:"deny_shell,omitempty"`
+}
+
+// validate parses the exec rules, where is the rule in the policy
+func (e EnvPolicy) validate(where string) error {
+	for _, rule := range []struct{ name, expr string }{{"exec_allow", e.ExecAllow}, {"exec_deny", e.ExecDeny}} {
+		if rule.expr == "" {
+			continue
+		}
+		if _, err := filter.Parse(rule.expr); err != nil {
+			return fmt.Errorf("invalid %s %q of %s of the policy %s, error: %v", rule.name, rule.expr, where, PolicyPath, err)
+		}
+	}
+	return nil
+}
+
+// HostPolicy is the guards enforced on the hosts matching the filter,
+// the environment guards are only turned on while acting on them
+type HostPolicy struct {
+	Filter    string `yaml:"filter"`
+	EnvPolicy `yaml:",inline"`
+}
+
+// policy is the loaded policy, empty when there's no policy file
+var policy = &Policy{}
+
+// CurrentPolicy returns the loaded policy
+func CurrentPolicy() *Policy {
+	return policy
+}
+
+// UsePolicy replaces the loaded policy, such as by the tests of the commands
+func UsePolicy(p *Policy) {
+	policy = p
+}
+
+// LoadPolicy reads the policy file, it's empty when the file doesn't exist
+func LoadPolicy() (*Policy, error) {
+	p := &Policy{}
+	b, err := ioutil.ReadFile(PolicyPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(b, p); err != nil {
+		return nil, fmt.Errorf("invalid policy %s, error: %v", PolicyPath, err)
+	}
+	for env, e := range p.Environments {
+		if err := e.validate("the environment " + env); err != nil {
+			return nil, err
+		}
+	}
+	for _, h := range p.Hosts {
+		if _, err := filter.Parse(h.Filter); err != nil {
+			return nil, fmt.Errorf("invalid hosts filter %q of the policy %s, error: %v", h.Filter, PolicyPath, err)
+		}
+		if err := h.validate(fmt.Sprintf("the hosts %q", h.Filter)); err != nil {
+			return nil, err
+		}
+	}
+	if p.BundleKey != "" {
+		if key, err := base64.StdEncoding.DecodeString(p.BundleKey); err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid bundle_key of the policy %s, it must be the base64 ed25519 public key", PolicyPath)
+		}
+	}
+	return p, nil
+}
+
+// CheckFlags returns an error if any of the tsh flags is forbidden
+func (p *Policy) CheckFlags(flags []string) error {
+	forbidden := p.ForbiddenFlags
+	if p.ForbidInsecure {
+		forbidden = append([]string{"--insecure"}, forbidden...)
+	}
+	for _, flag := range flags {
+		name := strings.SplitN(flag, "=", 2)[0]
+		for _, f := range forbidden {
+			if name == f {
+				return fmt.Errorf("%s is forbidden by the policy %s", f, PolicyPath)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckProxy returns an error if the proxy isn't allowed by the policy
+func (p *Policy) CheckProxy(proxy *Proxy) error {
+	if !p.isEnvAllowed(proxy.Env) {
+		return fmt.Errorf("env %s is not allowed by the policy %s", proxy.Env, PolicyPath)
+	}
+	return p.CheckFlags(proxy.ExtraTSHFlags)
+}
+
+func (p *Policy) isEnvAllowed(env string) bool {
+	if len(p.AllowedEnvs) == 0 {
+		return true
+	}
+	for _, pattern := range p.AllowedEnvs {
+		if ok, _ := path.Match(pattern, env); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// apply turns on the guards of the proxy enforced by the policy
+func (p *Policy) apply(proxy *Proxy) {
+	e, ok := p.Environments[proxy.Env]
+	if !ok {
+		return
+	}
+	proxy.Critical = proxy.Critical || e.Critical
+	proxy.ConfirmEnv = proxy.ConfirmEnv || e.ConfirmEnv
+	proxy.Protected = proxy.Protected || e.Protected
+	proxy.ReadOnly = proxy.ReadOnly || e.ReadOnly
+	proxy.RequireReason = proxy.RequireReason || e.RequireReason
+}
+
+// ApplyHosts returns the copy of the proxy along with the guards of the
+// host rules matching any of the hosts turned on, the proxy itself is
+// returned when there's no matching rule
+func (p *Policy) ApplyHosts(proxy *Proxy, hosts []string) (*Proxy, error) {
+	rules, err := p.hostRules(proxy, hosts)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return proxy, nil
+	}
+
+	res := *proxy
+	for _, h := range rules {
+		res.Critical = res.Critical || h.Critical
+		res.ConfirmEnv = res.ConfirmEnv || h.ConfirmEnv
+		res.Protected = res.Protected || h.Protected
+		res.ReadOnly = res.ReadOnly || h.ReadOnly
+		res.RequireReason = res.RequireReason || h.RequireReason
+	}
+	return &res, nil
+}
+
+// hostRules returns the host rules matching any of the hosts
+func (p *Policy) hostRules(proxy *Proxy, hosts []string) ([]EnvPolicy, error) {
+	if len(p.Hosts) == 0 || len(hosts) == 0 {
+		return nil, nil
+	}
+
+	items := make([]Item, len(hosts))
+	for i, host := range hosts {
+		// the host out of the node cache is still matched by the hostname
+		items[i], _ = proxy.Node.LookUp(host)
+		items[i].Hostname = host
+	}
+
+	var res []EnvPolicy
+	for _, h := range p.Hosts {
+		// the filter is validated by LoadPolicy
+		f, err := filter.Parse(h.Filter)
+		if err != nil {
+			return nil, err
+		}
+		matched, err := proxy.Select(f, items)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply the hosts filter %q of the policy, error: %v", h.Filter, err)
+		}
+		if len(matched) > 0 {
+			res = append(res, h.EnvPolicy)
+		}
+	}
+	return res, nil
+}
+
+// rules returns the rule of the env along with the host rules matching any of the hosts
+func (p *Policy) rules(proxy *Proxy, hosts []string) ([]EnvPolicy, error) {
+	rules, err := p.hostRules(proxy, hosts)
+	if err != nil {
+		return nil, err
+	}
+	if e, ok := p.Environments[proxy.Env]; ok {
+		rules = append([]EnvPolicy{e}, rules...)
+	}
+	return rules, nil
+}
+
+// CheckExec returns an error if any simple command of the command line isn't
+// allowed by the exec rules of the env or of the hosts. Every command must match
+// every exec_allow & none of exec_deny, the command line the shell would run more
+// than its words tell, such as $(...) or the redirection, is refused once any
+// rule applies since it can't be checked
+func (p *Policy) CheckExec(proxy *Proxy, hosts []string, command string) error {
+	rules, err := p.rules(proxy, hosts)
+	if err != nil {
+		return err
+	}
+	var allows, denies []*filter.Filter
+	for _, r := range rules {
+		// the rules are validated by LoadPolicy
+		if r.ExecAllow != "" {
+			f, err := filter.Parse(r.ExecAllow)
+			if err != nil {
+				return err
+			}
+			allows = append(allows, f)
+		}
+		if r.ExecDeny != "" {
+			f, err := filter.Parse(r.ExecDeny)
+			if err != nil {
+				return err
+			}
+			denies = append(denies, f)
+		}
+	}
+	if len(allows) == 0 && len(denies) == 0 {
+		return nil
+	}
+
+	commands, err := shell.Commands(command)
+	if err != nil {
+		return fmt.Errorf("%q can't be run on %s, %v by the exec rules of the policy %s", command, proxy.Env, err, PolicyPath)
+	}
+	for _, words := range commands {
+		t := filter.Target{Env: proxy.Env, Command: strings.Join(words, " ")}
+		for _, f := range denies {
+			if f.Match(t) {
+				return fmt.Errorf("%q is denied on %s by the policy %s", t.Command, proxy.Env, PolicyPath)
+			}
+		}
+		for _, f := range allows {
+			if !f.Match(t) {
+				return fmt.Errorf("%q isn't allowed on %s by the policy %s, only %s", t.Command, proxy.Env, PolicyPath, f)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckShell returns an error if the interactive shell is denied on the env or on any of the hosts
+func (p *Policy) CheckShell(proxy *Proxy, hosts []string) error {
+	rules, err := p.rules(proxy, hosts)
+	if err != nil {
+		return err
+	}
+	for _, r := range rules {
+		if r.DenyShell {
+			return fmt.Errorf("the interactive shell is denied on %s by the policy %s, run the allowed commands by exec", proxy.Env, PolicyPath)
+		}
+	}
+	return nil
+}
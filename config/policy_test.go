@@ -0,0 +1,164 @@
+package config
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadPolicy(t *testing.T) {
+	defer func(path string) { PolicyPath = path }(PolicyPath)
+	PolicyPath = t.TempDir() + "/policy.yaml"
+
+	p, err := LoadPolicy()
+	assert.NoError(t, err)
+	assert.Equal(t, &Policy{}, p)
+
+	content := `
+min_tsh_version: 6.2.0
+forbid_insecure: true
+enforce_audit: true
+allowed_envs: ["staging", "prod-*"]
+environments:
+  prod-eu:
+    read_only: true
+hosts:
+- filter: label:role=db OR db-*
+  protected: true
+`
+	assert.NoError(t, ioutil.WriteFile(PolicyPath, []byte(content), 0600))
+	p, err = LoadPolicy()
+	assert.NoError(t, err)
+	assert.Equal(t, "6.2.0", p.MinTSHVersion)
+	assert.True(t, p.EnforceAudit)
+	assert.True(t, p.Environments["prod-eu"].ReadOnly)
+	assert.Equal(t, []HostPolicy{{Filter: "label:role=db OR db-*", EnvPolicy: EnvPolicy{Protected: true}}}, p.Hosts)
+
+	assert.NoError(t, ioutil.WriteFile(PolicyPath, []byte("hosts:\n- filter: db AND\n  protected: true\n"), 0600))
+	_, err = LoadPolicy()
+	assert.Error(t, err)
+
+	assert.NoError(t, ioutil.WriteFile(PolicyPath, []byte("environments:\n  prod:\n    exec_allow: cmd:uptime OR\n"), 0600))
+	_, err = LoadPolicy()
+	assert.Error(t, err)
+}
+
+func TestPolicy_CheckFlags(t *testing.T) {
+	p := &Policy{ForbidInsecure: true, ForbiddenFlags: []string{"--skip-version-check"}}
+	assert.NoError(t, p.CheckFlags([]string{"--mfa-mode=otp", "-d"}))
+	assert.Error(t, p.CheckFlags([]string{"--insecure"}))
+	assert.Error(t, p.CheckFlags([]string{"--skip-version-check=true"}))
+	assert.NoError(t, (&Policy{}).CheckFlags([]string{"--insecure"}))
+}
+
+func TestPolicy_CheckProxy(t *testing.T) {
+	p := &Policy{AllowedEnvs: []string{"staging", "prod-*"}, ForbidInsecure: true}
+	assert.NoError(t, p.CheckProxy(&Proxy{Env: "staging"}))
+	assert.NoError(t, p.CheckProxy(&Proxy{Env: "prod-eu"}))
+	assert.Error(t, p.CheckProxy(&Proxy{Env: "dev"}))
+	assert.Error(t, p.CheckProxy(&Proxy{Env: "staging", ExtraTSHFlags: []string{"--insecure"}}))
+}
+
+func TestConfig_FindProxy_policy(t *testing.T) {
+	defer func() { policy = &Policy{} }()
+	policy = &Policy{
+		AllowedEnvs:  []string{"prod"},
+		Environments: map[string]EnvPolicy{"prod": {Protected: true}},
+	}
+	c := &Config{Proxies: []*Proxy{{Env: "prod", Critical: true}, {Env: "dev"}}}
+
+	p, err := c.FindProxy("prod")
+	assert.NoError(t, err)
+	assert.True(t, p.Protected)
+	assert.True(t, p.Critical)
+
+	_, err = c.FindProxy("dev")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrEnvNotFound)
+}
+
+func TestPolicy_ApplyHosts(t *testing.T) {
+	defer func(s Store) { store = s }(store)
+	m := NewMemoryStore()
+	m.UpdateFacts("prod", "db-01", HostFacts{Facts: map[string]string{"os": "CentOS 7"}})
+	store = m
+
+	p := &Policy{Hosts: []HostPolicy{
+		{Filter: "label:role=db", EnvPolicy: EnvPolicy{Protected: true}},
+		{Filter: "fact:os=CentOS*", EnvPolicy: EnvPolicy{ReadOnly: true}},
+		{Filter: "pay-*", EnvPolicy: EnvPolicy{ConfirmEnv: true}},
+	}}
+	proxy := &Proxy{Env: "prod", Critical: true, Node: Node{Items: []Item{
+		{Hostname: "web-01", Labels: map[string]string{"role": "web"}},
+		{Hostname: "db-01", Labels: map[string]string{"role": "db"}},
+	}}}
+
+	got, err := p.ApplyHosts(proxy, []string{"web-01"})
+	assert.NoError(t, err)
+	assert.True(t, got == proxy)
+
+	got, err = p.ApplyHosts(proxy, []string{"web-01", "db-01"})
+	assert.NoError(t, err)
+	assert.True(t, got.Critical)
+	assert.True(t, got.Protected)
+	assert.True(t, got.ReadOnly)
+	assert.False(t, got.ConfirmEnv)
+	// the proxy itself is untouched
+	assert.False(t, proxy.Protected)
+
+	// the host out of the node cache is matched by the hostname
+	got, err = p.ApplyHosts(proxy, []string{"pay-01"})
+	assert.NoError(t, err)
+	assert.True(t, got.ConfirmEnv)
+}
+
+func TestPolicy_CheckExec(t *testing.T) {
+	p := &Policy{
+		Environments: map[string]EnvPolicy{
+			"prod":    {ExecAllow: `cmd:uptime OR cmd:"df -h" OR cmd:"systemctl status" OR cmd:"journalctl *"`},
+			"staging": {ExecDeny: `cmd:rm OR cmd:"systemctl stop"`},
+		},
+		Hosts: []HostPolicy{{Filter: "db-*", EnvPolicy: EnvPolicy{ExecDeny: "cmd:journalctl"}}},
+	}
+	prod := &Proxy{Env: "prod"}
+	staging := &Proxy{Env: "staging"}
+	tests := []struct {
+		name    string
+		proxy   *Proxy
+		hosts   []string
+		command string
+		wantErr bool
+	}{
+		{name: "allowed", proxy: prod, hosts: []string{"web-01"}, command: "uptime"},
+		{name: "allowed along with the arguments", proxy: prod, hosts: []string{"web-01"}, command: "systemctl status nginx"},
+		{name: "every command is allowed", proxy: prod, hosts: []string{"web-01"}, command: "uptime && df -h | journalctl -u app"},
+		{name: "not allowed", proxy: prod, hosts: []string{"web-01"}, command: "systemctl restart nginx", wantErr: true},
+		{name: "the second command isn't allowed", proxy: prod, hosts: []string{"web-01"}, command: "uptime; rm -rf /", wantErr: true},
+		{name: "uncheckable", proxy: prod, hosts: []string{"web-01"}, command: "uptime $(rm -rf /)", wantErr: true},
+		{name: "denied by the host rule", proxy: prod, hosts: []string{"web-01", "db-01"}, command: "journalctl -u db", wantErr: true},
+		{name: "not denied", proxy: staging, hosts: []string{"web-01"}, command: "systemctl restart app"},
+		{name: "denied", proxy: staging, hosts: []string{"web-01"}, command: "cd /tmp && rm -rf cache", wantErr: true},
+		{name: "no rule", proxy: &Proxy{Env: "dev"}, hosts: []string{"web-01"}, command: "echo $(id) > /tmp/x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.CheckExec(tt.proxy, tt.hosts, tt.command)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPolicy_CheckShell(t *testing.T) {
+	p := &Policy{
+		Environments: map[string]EnvPolicy{"prod": {DenyShell: true}},
+		Hosts:        []HostPolicy{{Filter: "db-*", EnvPolicy: EnvPolicy{DenyShell: true}}},
+	}
+	assert.Error(t, p.CheckShell(&Proxy{Env: "prod"}, []string{"web-01"}))
+	assert.NoError(t, p.CheckShell(&Proxy{Env: "staging"}, []string{"web-01"}))
+	assert.Error(t, p.CheckShell(&Proxy{Env: "staging"}, []string{"web-01", "db-01"}))
+}
@@ -1,14 +1,19 @@
 package config
 
 import (
-	"encoding/json"
+	"bytes"
 	"errors"
 	"fmt"
-	"io/ioutil"
+	"net"
 	"net/url"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/adzimzf/tpot/fold"
+	"github.com/adzimzf/tpot/wsl"
 )
 
 const permission = 0600
@@ -39,6 +44,109 @@ proxies:
   # default it'll use your OS PATH
   tsh_path: ""
 
+  # isolated tsh directory of this environment passed as TELEPORT_HOME
+  # so the logins of clusters with the same proxy hostname or user never clobber each other
+  # default is the tsh directory of the profile
+  teleport_home: ""
+
+  # the tsh flags appended to every tsh invocation of this environment
+  # example ["--add-keys-to-agent=no", "--mfa-mode=cross-platform"]
+  extra_tsh_flags: []
+
+  # forward the local SSH agent into the nodes like tsh ssh -A
+  forward_agent: false
+
+  # forward the X11 display into the nodes, untrusted like tsh ssh -X or trusted like tsh ssh -Y
+  x11_forwarding: ""
+
+  # the OpenSSH style options of every session passed as tsh ssh -o
+  # example ["ServerAliveInterval=30"]
+  ssh_options: []
+
+  # how tsh login adds the keys into the SSH agent, one of auto, no, yes or only
+  # default is auto
+  add_keys_to_agent: ""
+
+  # how tsh prompts the MFA, one of auto, cross-platform, platform or otp
+  # cross-platform is the hardware key like YubiKey
+  mfa_mode: ""
+
+  # the PIV slot of the hardware key holding the private key, one of 9a, 9c, 9d or 9e
+  # default is the slot of the cluster key policy
+  piv_slot: ""
+
+  # how tsh login opens the SSO page, none prints the link to open it on another machine
+  # such as on the headless server, default is the default browser
+  browser: ""
+
+  # the base URL host:port of the SSO callback link on the headless server, such as the
+  # port forwarded from the laptop, tsh login listens on bind_addr for the callback
+  callback_url: ""
+  bind_addr: ""
+
+  # the command opening the SSO page instead of the default browser, {url} is replaced by the link
+  # & {env} by the environment, the link is appended without {url}. Default is browser_command of the configuration
+  # example google-chrome --profile-directory="Profile 2" {url} or wslview
+  browser_command: ""
+
+  # the SSH agent socket of the tsh processes, none disables the agent
+  # default is SSH_AUTH_SOCK of the shell
+  ssh_auth_sock: ""
+
+  # how tsh dials the node, one of ip, hostname or uuid
+  # the next one is tried when tsh can't reach the node, default is ip
+  dial_by: ""
+
+  # reuse one SSH connection per node through OpenSSH ControlMaster & tsh proxy ssh,
+  # so connecting to the same node again skips the handshake
+  multiplex: false
+
+  # how long the idle multiplexed connection is kept, default is 10m
+  multiplex_persist: ""
+
+  # the color of the environment in the picker & the banner
+  # one of red, green, yellow, blue, magenta, cyan or white
+  color: ""
+
+  # the short label shown along with the environment, example PROD
+  # default is the upper case environment name
+  badge: ""
+
+  # the tags of the environment such as ["eu", "k8s"], tpot find --live
+  # refreshes the environments of the tags in live_search.order first
+  tags: []
+
+  # check the login periodically by tpot serve & renew it by the SSO before it
+  # expires, so the first connection of the day doesn't stall on the SSO
+  keepalive: false
+
+  # the columns of the picker next to the hostname in the order, one of hostname, ip, labels, label.<key>,
+  # meta.<key>, source, last_used, last_seen or latency along with the optional :<width>, example ["ip:15", "label.team", "latency"]
+  # default is picker_columns of the configuration
+  picker_columns: []
+
+  # show a prominent banner before connecting to this environment
+  critical: false
+
+  # type the environment name to confirm before connecting
+  confirm_env: false
+
+  # confirm before ssh & exec on this environment
+  protected: false
+
+  # block exec, scp, broadcast & sync through tpot on this environment
+  read_only: false
+
+  # ask the ticket or the reason before connecting, it's recorded by the audit log & sent to the session hooks
+  require_reason: false
+
+  # the roles requested by the access request along with the reason, the session waits until it's approved
+  reason_roles: []
+
+  # the default flags of the commands on this environment, <command>.<flag> only applies to the command
+  # example {"refresh": "true", "exec.parallel": "10"}
+  flags: {}
+
   # port forwarding configuration
   forwarding:
 	# how ofter the forwarding will reload
@@ -80,6 +188,109 @@ proxies:
   # default it'll use your OS PATH
   tsh_path: %s
 
+  # isolated tsh directory of this environment passed as TELEPORT_HOME
+  # so the logins of clusters with the same proxy hostname or user never clobber each other
+  # default is the tsh directory of the profile
+  teleport_home: "%s"
+
+  # the tsh flags appended to every tsh invocation of this environment
+  # example ["--add-keys-to-agent=no", "--mfa-mode=cross-platform"]
+  extra_tsh_flags: %s
+
+  # forward the local SSH agent into the nodes like tsh ssh -A
+  forward_agent: %s
+
+  # forward the X11 display into the nodes, untrusted like tsh ssh -X or trusted like tsh ssh -Y
+  x11_forwarding: "%s"
+
+  # the OpenSSH style options of every session passed as tsh ssh -o
+  # example ["ServerAliveInterval=30"]
+  ssh_options: %s
+
+  # how tsh login adds the keys into the SSH agent, one of auto, no, yes or only
+  # default is auto
+  add_keys_to_agent: "%s"
+
+  # how tsh prompts the MFA, one of auto, cross-platform, platform or otp
+  # cross-platform is the hardware key like YubiKey
+  mfa_mode: "%s"
+
+  # the PIV slot of the hardware key holding the private key, one of 9a, 9c, 9d or 9e
+  # default is the slot of the cluster key policy
+  piv_slot: "%s"
+
+  # how tsh login opens the SSO page, none prints the link to open it on another machine
+  # such as on the headless server, default is the default browser
+  browser: "%s"
+
+  # the base URL host:port of the SSO callback link on the headless server, such as the
+  # port forwarded from the laptop, tsh login listens on bind_addr for the callback
+  callback_url: "%s"
+  bind_addr: "%s"
+
+  # the command opening the SSO page instead of the default browser, {url} is replaced by the link
+  # & {env} by the environment, the link is appended without {url}. Default is browser_command of the configuration
+  # example google-chrome --profile-directory="Profile 2" {url} or wslview
+  browser_command: %s
+
+  # the SSH agent socket of the tsh processes, none disables the agent
+  # default is SSH_AUTH_SOCK of the shell
+  ssh_auth_sock: "%s"
+
+  # how tsh dials the node, one of ip, hostname or uuid
+  # the next one is tried when tsh can't reach the node, default is ip
+  dial_by: "%s"
+
+  # reuse one SSH connection per node through OpenSSH ControlMaster & tsh proxy ssh,
+  # so connecting to the same node again skips the handshake
+  multiplex: %s
+
+  # how long the idle multiplexed connection is kept, default is 10m
+  multiplex_persist: "%s"
+
+  # the color of the environment in the picker & the banner
+  # one of red, green, yellow, blue, magenta, cyan or white
+  color: "%s"
+
+  # the short label shown along with the environment, example PROD
+  # default is the upper case environment name
+  badge: "%s"
+
+  # the tags of the environment such as ["eu", "k8s"], tpot find --live
+  # refreshes the environments of the tags in live_search.order first
+  tags: %s
+
+  # check the login periodically by tpot serve & renew it by the SSO before it
+  # expires, so the first connection of the day doesn't stall on the SSO
+  keepalive: %s
+
+  # the columns of the picker next to the hostname in the order, one of hostname, ip, labels, label.<key>,
+  # meta.<key>, source, last_used, last_seen or latency along with the optional :<width>, example ["ip:15", "label.team", "latency"]
+  # default is picker_columns of the configuration
+  picker_columns: %s
+
+  # show a prominent banner before connecting to this environment
+  critical: %s
+
+  # type the environment name to confirm before connecting
+  confirm_env: %s
+
+  # confirm before ssh & exec on this environment
+  protected: %s
+
+  # block exec, scp, broadcast & sync through tpot on this environment
+  read_only: %s
+
+  # ask the ticket or the reason before connecting, it's recorded by the audit log & sent to the session hooks
+  require_reason: %s
+
+  # the roles requested by the access request along with the reason, the session waits until it's approved
+  reason_roles: %s
+
+  # the default flags of the commands on this environment, <command>.<flag> only applies to the command
+  # example {"refresh": "true", "exec.parallel": "10"}
+  flags: %s
+
   # port forwarding configuration
   forwarding:
     # how ofter the forwarding will reload in seconds
@@ -92,7 +303,7 @@ proxies:
 const forwardingTemplate = `
       # the server host. example: teleport1-127.34.23.56
       # default will be selected
-      - host:
+      - host: "%s"
         # user to login. example: root
         # default will be selected
         user_login: "%s"
@@ -144,6 +355,106 @@ type Proxy struct {
 	// by default it'll use your PATH location
 	TSHPath string `yaml:"tsh_path"       json:"tsh_path"`
 
+	// TeleportHome is the isolated tsh directory of this proxy,
+	// it's passed as TELEPORT_HOME to every tsh process
+	TeleportHome string `yaml:"teleport_home,omitempty" json:"teleport_home,omitempty"`
+
+	// ExtraTSHFlags is appended verbatim to every tsh invocation,
+	// to use the new tsh options before tpot supports them natively
+	ExtraTSHFlags []string `yaml:"extra_tsh_flags,omitempty" json:"extra_tsh_flags,omitempty"`
+
+	// ForwardAgent forwards the local SSH agent on every session
+	ForwardAgent bool `yaml:"forward_agent,omitempty" json:"forward_agent,omitempty"`
+
+	// X11Forwarding forwards the X11 display on every session,
+	// untrusted is like tsh ssh -X & trusted is like tsh ssh -Y
+	X11Forwarding string `yaml:"x11_forwarding,omitempty" json:"x11_forwarding,omitempty"`
+
+	// SSHOptions is the OpenSSH style options of every session
+	// passed as tsh ssh -o, example ForwardX11Timeout=1h
+	SSHOptions []string `yaml:"ssh_options,omitempty" json:"ssh_options,omitempty"`
+
+	// AddKeysToAgent controls how tsh login adds the keys into
+	// the SSH agent, one of auto, no, yes or only, default is auto
+	AddKeysToAgent string `yaml:"add_keys_to_agent,omitempty" json:"add_keys_to_agent,omitempty"`
+
+	// MFAMode is how tsh prompts the MFA, passed as --mfa-mode
+	MFAMode string `yaml:"mfa_mode,omitempty" json:"mfa_mode,omitempty"`
+
+	// PIVSlot is the PIV slot of the hardware key holding the private key
+	PIVSlot string `yaml:"piv_slot,omitempty" json:"piv_slot,omitempty"`
+
+	// Browser is how tsh login opens the SSO page, none prints
+	// the link to open it on another machine like the headless server
+	Browser string `yaml:"browser,omitempty" json:"browser,omitempty"`
+
+	// CallbackURL is the base URL host:port of the SSO callback link,
+	// tsh login listens on BindAddr for it
+	CallbackURL string `yaml:"callback_url,omitempty" json:"callback_url,omitempty"`
+	BindAddr    string `yaml:"bind_addr,omitempty" json:"bind_addr,omitempty"`
+
+	// BrowserCommand opens the SSO page instead of the default browser,
+	// {url} is replaced by the link & {env} by the environment
+	BrowserCommand string `yaml:"browser_command,omitempty" json:"browser_command,omitempty"`
+
+	// SSHAuthSock overrides SSH_AUTH_SOCK of the tsh processes,
+	// SSHAuthSockNone unsets it to not use the SSH agent at all
+	SSHAuthSock string `yaml:"ssh_auth_sock,omitempty" json:"ssh_auth_sock,omitempty"`
+
+	// DialBy is how tsh dials the node, one of DialByIP, DialByHostname
+	// or DialByUUID, default is DialByIP
+	DialBy string `yaml:"dial_by,omitempty" json:"dial_by,omitempty"`
+
+	// Multiplex reuses one SSH connection per node through
+	// OpenSSH ControlMaster & tsh proxy ssh
+	Multiplex bool `yaml:"multiplex,omitempty" json:"multiplex,omitempty"`
+
+	// MultiplexPersist is how long the idle multiplexed connection
+	// is kept like OpenSSH ControlPersist, default is 10m
+	MultiplexPersist string `yaml:"multiplex_persist,omitempty" json:"multiplex_persist,omitempty"`
+
+	// Color is the color of the environment in the picker & the banner
+	Color string `yaml:"color,omitempty" json:"color,omitempty"`
+
+	// Badge is the short label shown along with the environment,
+	// default is the upper case environment name
+	Badge string `yaml:"badge,omitempty" json:"badge,omitempty"`
+
+	// Tags is the tags of the environment such as eu or k8s,
+	// see LiveSearch
+	Tags []string `yaml:"tags,omitempty" json:"tags,omitempty"`
+
+	// Keepalive checks the login periodically by tpot serve & renews
+	// it before it expires, see Config.KeepaliveInterval
+	Keepalive bool `yaml:"keepalive,omitempty" json:"keepalive,omitempty"`
+
+	// PickerColumns is the columns of the picker next to the hostname
+	// such as ip:15 or label.team, see ParseColumns
+	PickerColumns []string `yaml:"picker_columns,omitempty" json:"picker_columns,omitempty"`
+
+	// Critical shows a prominent banner before connecting
+	Critical bool `yaml:"critical,omitempty" json:"critical,omitempty"`
+
+	// ConfirmEnv asks to type the environment name before connecting
+	ConfirmEnv bool `yaml:"confirm_env,omitempty" json:"confirm_env,omitempty"`
+
+	// Protected asks for the confirmation before ssh & exec
+	Protected bool `yaml:"protected,omitempty" json:"protected,omitempty"`
+
+	// ReadOnly blocks exec, scp, broadcast & sync through tpot
+	ReadOnly bool `yaml:"read_only,omitempty" json:"read_only,omitempty"`
+
+	// RequireReason asks the ticket or the reason before connecting
+	RequireReason bool `yaml:"require_reason,omitempty" json:"require_reason,omitempty"`
+
+	// ReasonRoles is the roles requested by the Teleport access request along
+	// with the reason, the session waits until the request is approved
+	ReasonRoles []string `yaml:"reason_roles,omitempty" json:"reason_roles,omitempty"`
+
+	// Flags is the default flags of the commands on this environment,
+	// it takes precedence over the flags of the configuration
+	Flags Flags `yaml:"flags,omitempty" json:"flags,omitempty"`
+
 	// Node contains the node information from teleport server
 	Node Node `yaml:"node,omitempty" json:"node"`
 
@@ -161,8 +472,60 @@ func (p *Proxy) Validate() error {
 		return fmt.Errorf("auth_connector or user_name must not empty")
 	}
 
+	switch p.X11Forwarding {
+	case "", "untrusted", "trusted":
+	default:
+		return fmt.Errorf("x11_forwarding must be untrusted or trusted")
+	}
+
+	switch p.AddKeysToAgent {
+	case "", "auto", "no", "yes", "only":
+	default:
+		return fmt.Errorf("add_keys_to_agent must be one of auto, no, yes or only")
+	}
+
+	switch p.MFAMode {
+	case "", "auto", "cross-platform", "platform", "otp":
+	default:
+		return fmt.Errorf("mfa_mode must be one of auto, cross-platform, platform or otp")
+	}
+
+	switch p.PIVSlot {
+	case "", "9a", "9c", "9d", "9e":
+	default:
+		return fmt.Errorf("piv_slot must be one of 9a, 9c, 9d or 9e")
+	}
+
+	if p.CallbackURL != "" && p.BindAddr == "" {
+		return fmt.Errorf("callback_url needs bind_addr for tsh login to listen on")
+	}
+
+	switch p.DialBy {
+	case "", DialByIP, DialByHostname, DialByUUID:
+	default:
+		return fmt.Errorf("dial_by must be one of ip, hostname or uuid")
+	}
+
+	if p.MultiplexPersist != "" {
+		if _, err := time.ParseDuration(p.MultiplexPersist); err != nil {
+			return fmt.Errorf("multiplex_persist is invalid, error: %v", err)
+		}
+	}
+
+	if _, err := ParseColumns(p.PickerColumns); err != nil {
+		return fmt.Errorf("picker_columns is invalid, error: %v", err)
+	}
+
+	if p.Color != "" && !isEnvColor(p.Color) {
+		return fmt.Errorf("color must be one of %s", strings.Join(EnvColors, ", "))
+	}
+
+	if err := policy.CheckProxy(p); err != nil {
+		return err
+	}
+
 	// TODO: need to support relative path such as ~/bin
-	_, err = os.Stat(p.TSHPath)
+	_, err = os.Stat(wsl.LinuxPath(p.TSHPath))
 	if err != nil && p.TSHPath != "" {
 		return fmt.Errorf("tsh_path is invalid")
 	}
@@ -177,6 +540,34 @@ func (p *Proxy) ToEditString() (string, error) {
 		p.AuthConnector,
 		strconv.FormatBool(p.TwoFA),
 		p.TSHPath,
+		p.TeleportHome,
+		yamlList(p.ExtraTSHFlags),
+		strconv.FormatBool(p.ForwardAgent),
+		p.X11Forwarding,
+		yamlList(p.SSHOptions),
+		p.AddKeysToAgent,
+		p.MFAMode,
+		p.PIVSlot,
+		p.Browser,
+		p.CallbackURL,
+		p.BindAddr,
+		strconv.Quote(p.BrowserCommand),
+		p.SSHAuthSock,
+		p.DialBy,
+		strconv.FormatBool(p.Multiplex),
+		p.MultiplexPersist,
+		p.Color,
+		p.Badge,
+		yamlList(p.Tags),
+		strconv.FormatBool(p.Keepalive),
+		yamlList(p.PickerColumns),
+		strconv.FormatBool(p.Critical),
+		strconv.FormatBool(p.ConfirmEnv),
+		strconv.FormatBool(p.Protected),
+		strconv.FormatBool(p.ReadOnly),
+		strconv.FormatBool(p.RequireReason),
+		yamlList(p.ReasonRoles),
+		yamlMap(p.Flags),
 		p.Forwarding.Interval,
 	)
 
@@ -184,51 +575,300 @@ func (p *Proxy) ToEditString() (string, error) {
 		return fmt.Sprintf("%s%s", res, forwardingTemplateExample), nil
 	}
 	for _, node := range p.Forwarding.Nodes {
-		nodeStr := fmt.Sprintf(forwardingTemplate, node.UserLogin, node.ListenPort, node.RemotePort, node.RemoteHost)
+		nodeStr := fmt.Sprintf(forwardingTemplate, node.Host, node.UserLogin, node.ListenPort, node.RemotePort, node.RemoteHost)
 		res = fmt.Sprintf("%s%s", res, nodeStr)
 	}
 
 	return res, nil
 }
 
+// SSHAuthSockNone is the ssh_auth_sock to not use the SSH agent
+const SSHAuthSockNone = "none"
+
+// the dial_by of the proxy
+const (
+	DialByIP       = "ip"
+	DialByHostname = "hostname"
+	DialByUUID     = "uuid"
+)
+
+// EnvColors is the supported colors of the environment
+var EnvColors = []string{"red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+func isEnvColor(color string) bool {
+	for _, c := range EnvColors {
+		if c == color {
+			return true
+		}
+	}
+	return false
+}
+
+// EnvBadge returns the badge of the environment
+func (p *Proxy) EnvBadge() string {
+	if p.Badge != "" {
+		return p.Badge
+	}
+	return strings.ToUpper(p.Env)
+}
+
+// yamlMap formats the map as a YAML flow mapping sorted by the key
+func yamlMap(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = strconv.Quote(k) + ": " + strconv.Quote(m[k])
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// yamlList formats the list as a YAML flow sequence
+func yamlList(list []string) string {
+	quoted := make([]string, len(list))
+	for i, s := range list {
+		quoted[i] = strconv.Quote(s)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
 // ProxyStatus contains data about proxy status
 type ProxyStatus struct {
 	// LoginAs is the username logged
 	LoginAs string `json:"login_as"`
 
+	// Cluster is the teleport cluster name
+	Cluster string `json:"cluster,omitempty"`
+
 	// Roles is a list of teleport role
 	Roles []string `json:"roles"`
 
 	// UserLogins is a list of user login
 	UserLogins []string `json:"user_logins"`
+
+	// ActiveRequests is the IDs of the access requests assumed by the login
+	ActiveRequests []string `json:"active_requests,omitempty"`
+}
+
+// HasLogin return true if the user is one of the permitted logins
+func (s *ProxyStatus) HasLogin(user string) bool {
+	for _, login := range s.UserLogins {
+		if login == user {
+			return true
+		}
+	}
+	return false
 }
 
 type Node struct {
 	Status *ProxyStatus `json:"status"`
 	Items  []Item       `json:"items"`
+
+	// Source is where the nodes are fetched from such as tsh
+	Source string `json:"source,omitempty"`
+}
+
+// LookUp returns the item of the host, it's the first instance of the hostname
+// listed more than once unless the host is the name disambiguated by Names
+func (n *Node) LookUp(host string) (Item, bool) {
+	items := n.Instances(host)
+	if len(items) == 0 {
+		return Item{}, false
+	}
+	return items[0], true
 }
 
-// LookUpIPAddress lookup the IP address by host
-func (n *Node) LookUpIPAddress(host string) (string, bool) {
-	for _, i2 := range n.Items {
-		if i2.Hostname == host {
-			return strings.Split(i2.Hostname, ":")[0], true
+// LookUpIPAddress returns the IP addresses of every instance of the host for
+// the user to choose, the instance without a direct address such as the
+// tunnel node is left out. It's empty when the host isn't found
+func (n *Node) LookUpIPAddress(host string) []string {
+	var res []string
+	for _, item := range n.Instances(host) {
+		if ip := item.IP(); ip != "" {
+			res = append(res, ip)
 		}
 	}
-	return "", false
+	return res
 }
 
-// ListHostname return the list of hostname
+// ListHostname returns the name of every item in order, the hostname listed
+// more than once is disambiguated by Names
 func (n *Node) ListHostname() (res []string) {
-	for _, n := range n.Items {
-		res = append(res, n.Hostname)
+	names := n.Names()
+	for _, item := range n.Items {
+		res = append(res, names[item.Key()])
 	}
 	return
 }
 
+// Filter returns the items which the hostname matches the pattern.
+// The pattern is treated as a glob when it has a wildcard (*, ? or [),
+// otherwise any hostname contains the pattern will be matched. The case
+// & the accents are ignored unless the pattern starts with fold.ExactPrefix
+func (n *Node) Filter(pattern string) []Item {
+	var res []Item
+	for _, item := range n.Items {
+		if fold.Match(pattern, item.Hostname) {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
+// FilterCIDR returns the items which the IP is inside any of the networks,
+// the tunnel node has no IP hence it never matches
+func (n *Node) FilterCIDR(networks []*net.IPNet) []Item {
+	var res []Item
+	for _, item := range n.Items {
+		ip := net.ParseIP(item.IP())
+		if ip == nil {
+			continue
+		}
+		for _, network := range networks {
+			if network.Contains(ip) {
+				res = append(res, item)
+				break
+			}
+		}
+	}
+	return res
+}
+
+// NextSibling returns the host after the host sharing its name prefix,
+// the hosts numbered by the trailing digits such as web-01 & web-02.
+// The skipped hosts are left out & it wraps around to the first one
+func (n *Node) NextSibling(host string, skip map[string]bool) (string, bool) {
+	prefix := strings.TrimRight(host, "0123456789")
+	if prefix == host {
+		return "", false
+	}
+	var siblings []string
+	for _, item := range n.Items {
+		h := item.Hostname
+		if h == host || skip[h] || !strings.HasPrefix(h, prefix) {
+			continue
+		}
+		if num := h[len(prefix):]; num == "" || strings.TrimLeft(num, "0123456789") != "" {
+			continue
+		}
+		siblings = append(siblings, h)
+	}
+	if len(siblings) == 0 {
+		return "", false
+	}
+	sort.Strings(siblings)
+	for _, h := range siblings {
+		if h > host {
+			return h, true
+		}
+	}
+	return siblings[0], true
+}
+
+// SortByIP sorts the items by the IP numerically, the IPv4 comes before
+// the IPv6 & the tunnel nodes are the last ones sorted by the hostname
+func SortByIP(items []Item) {
+	key := func(item Item) net.IP {
+		ip := net.ParseIP(item.IP())
+		if ip4 := ip.To4(); ip4 != nil {
+			return ip4
+		}
+		return ip
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := key(items[i]), key(items[j])
+		switch {
+		case a == nil || b == nil:
+			// the node without the IP goes last
+			if (a == nil) != (b == nil) {
+				return b == nil
+			}
+		case len(a) != len(b):
+			return len(a) < len(b)
+		default:
+			if c := bytes.Compare(a, b); c != 0 {
+				return c < 0
+			}
+		}
+		return items[i].Hostname < items[j].Hostname
+	})
+}
+
 type Item struct {
 	Hostname string `json:"hostname"`
 	Address  string `json:"addr"`
+
+	// ID is the node UUID, it's only printed by some tsh versions
+	ID string `json:"id,omitempty"`
+
+	// Labels is the static & dynamic labels of the node
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// LastSeen is when the node was last listed by a refresh & Source is where
+	// it was listed from, they're empty for the node cached by the older version
+	LastSeen *time.Time `json:"last_seen,omitempty"`
+	Source   string     `json:"source,omitempty"`
+}
+
+// SeenAt returns when the node was last listed by a refresh, zero if it's unknown
+func (i Item) SeenAt() time.Time {
+	if i.LastSeen == nil {
+		return time.Time{}
+	}
+	return *i.LastSeen
+}
+
+// IsTunnel returns true if the node is connected through a reverse tunnel,
+// tsh prints it as `⟵ Tunnel` instead of the address
+func (i Item) IsTunnel() bool {
+	return i.Address == "" || strings.Contains(i.Address, "Tunnel")
+}
+
+// IP returns the address of the node without the port & the IPv6 brackets,
+// it's empty for the tunnel node
+func (i Item) IP() string {
+	if i.IsTunnel() {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(i.Address)
+	if err != nil {
+		// the address has no port
+		return strings.TrimSuffix(strings.TrimPrefix(i.Address, "["), "]")
+	}
+	return host
+}
+
+// DialAddresses returns the addresses for tsh to dial the node in order
+// of the dialBy, the others are the fallback. Only the IPv4 is dialed by
+// the IP since tsh can't tell the IPv6 colons apart from the port
+func (i Item) DialAddresses(dialBy string) []string {
+	var ip string
+	if p := net.ParseIP(i.IP()); p != nil && p.To4() != nil {
+		ip = p.String()
+	}
+
+	var order []string
+	switch dialBy {
+	case DialByHostname:
+		order = []string{i.Hostname, i.ID}
+	case DialByUUID:
+		order = []string{i.ID, i.Hostname}
+	default:
+		order = []string{ip, i.Hostname, i.ID}
+	}
+
+	res := make([]string, 0, len(order))
+	seen := make(map[string]bool, len(order))
+	for _, addr := range order {
+		if addr != "" && !seen[addr] {
+			seen[addr] = true
+			res = append(res, addr)
+		}
+	}
+	return res
 }
 
 var ErrEnvNotFound = fmt.Errorf("env not found")
@@ -239,14 +879,24 @@ func (p *Proxy) AppendNode(n Node) (Node, error) {
 	if err != nil && !errors.Is(err, os.ErrNotExist) {
 		return pNode, err
 	}
-	for _, pn := range n.Items {
-		var found bool
-		for _, ni := range pNode.Items {
-			if ni.Hostname == pn.Hostname {
-				found = true
-			}
+	// the instances are told apart by the IP, so the reused hostname of
+	// another instance is appended while the listed one isn't repeated
+	instance := func(i Item) string { return i.Hostname + "\x00" + i.IP() }
+	fresh := make(map[string]Item, len(n.Items))
+	for _, ni := range n.Items {
+		fresh[instance(ni)] = ni
+	}
+	cached := make(map[string]bool, len(pNode.Items))
+	for i, ni := range pNode.Items {
+		cached[instance(ni)] = true
+		// the cached node listed again is seen by this refresh
+		if f, ok := fresh[instance(ni)]; ok && f.LastSeen != nil {
+			pNode.Items[i].LastSeen, pNode.Items[i].Source = f.LastSeen, f.Source
 		}
-		if !found {
+	}
+	for _, pn := range n.Items {
+		if !cached[instance(pn)] {
+			cached[instance(pn)] = true
 			pNode.Items = append(pNode.Items, pn)
 		}
 	}
@@ -255,28 +905,21 @@ func (p *Proxy) AppendNode(n Node) (Node, error) {
 
 // GetNode get the node from proxy cache
 func (p *Proxy) GetNode() (Node, error) {
-	nodeBytes, err := ioutil.ReadFile(Dir + "node_" + p.Env + ".json")
+	n, err := store.GetNode(p.Env)
 	if err != nil {
 		return Node{}, err
 	}
-	err = json.Unmarshal(nodeBytes, &p.Node)
-	if err != nil {
-		return p.Node, err
-	}
+	p.Node = n
 	return p.Node, nil
 }
 
-// UpdateNode update the cache node
+// UpdateNode update the cache node, the replaced
+// cache is backed up to be rolled back
 func (p *Proxy) UpdateNode(n Node) error {
-	bytes, err := json.Marshal(n)
-	if err != nil {
-		return err
+	if err := backupNode(p.Env, n); err != nil {
+		return fmt.Errorf("failed to back up the %s node cache, error: %v", p.Env, err)
 	}
-	return p.save(bytes)
-}
-
-func (p *Proxy) save(date []byte) error {
-	return ioutil.WriteFile(Dir+"node_"+p.Env+".json", date, permission)
+	return store.UpdateNode(p.Env, n)
 }
 
 type Forwarding struct {
@@ -0,0 +1,186 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Runbook is the recorded tpot actions to be run again,
+// it's a plain YAML file to be shared along with the team
+type Runbook struct {
+	Name        string        `yaml:"name"`
+	Description string        `yaml:"description,omitempty"`
+	Steps       []RunbookStep `yaml:"steps"`
+}
+
+// RunbookStep is a single action of the runbook, either exec or forward
+type RunbookStep struct {
+	Env string `yaml:"env"`
+
+	// Filter selects the hosts by the hostname pattern when it's run,
+	// otherwise Hosts is used
+	Filter string   `yaml:"filter,omitempty"`
+	Hosts  []string `yaml:"hosts,omitempty"`
+	User   string   `yaml:"user,omitempty"`
+
+	// Exec is the command run on the hosts, Sudo is the user to run it as
+	Exec string `yaml:"exec,omitempty"`
+	Sudo string `yaml:"sudo,omitempty"`
+
+	// Forward is the list of <local port>:<remote host>:<remote port>
+	Forward []string `yaml:"forward,omitempty"`
+}
+
+// String describes the step in a single line
+func (s RunbookStep) String() string {
+	target := s.Filter
+	if target == "" {
+		target = strings.Join(s.Hosts, ",")
+	}
+	if len(s.Forward) > 0 {
+		return fmt.Sprintf("forward %s through %s %s", strings.Join(s.Forward, ","), s.Env, target)
+	}
+	if s.Sudo != "" {
+		return fmt.Sprintf("exec %q as %s on %s %s", s.Exec, s.Sudo, s.Env, target)
+	}
+	return fmt.Sprintf("exec %q on %s %s", s.Exec, s.Env, target)
+}
+
+// Validate validates the step
+func (s RunbookStep) Validate() error {
+	switch {
+	case s.Env == "":
+		return fmt.Errorf("env is required")
+	case s.Filter == "" && len(s.Hosts) == 0:
+		return fmt.Errorf("filter or hosts is required")
+	case (s.Exec == "") == (len(s.Forward) == 0):
+		return fmt.Errorf("either exec or forward is required")
+	}
+	for _, f := range s.Forward {
+		if len(strings.Split(f, ":")) != 3 {
+			return fmt.Errorf("invalid forward %s, use format <local port>:<remote host>:<remote port>", f)
+		}
+	}
+	return nil
+}
+
+// RunbookDir is where the runbooks are saved
+func RunbookDir() string {
+	return Dir + "runbooks/"
+}
+
+// recordingPath holds the name of the runbook being recorded
+func recordingPath() string {
+	return RunbookDir() + ".recording"
+}
+
+// runbookPath returns the file of the runbook name, the name
+// containing a slash or the .yaml extension is taken as the path
+func runbookPath(name string) string {
+	if strings.ContainsRune(name, '/') || strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml") {
+		return name
+	}
+	return RunbookDir() + name + ".yaml"
+}
+
+// LoadRunbook loads the runbook by the name or the file path
+func LoadRunbook(name string) (*Runbook, error) {
+	b, err := ioutil.ReadFile(runbookPath(name))
+	if err != nil {
+		return nil, err
+	}
+	var r Runbook
+	if err := yaml.Unmarshal(b, &r); err != nil {
+		return nil, fmt.Errorf("invalid runbook %s, error: %v", name, err)
+	}
+	for i, s := range r.Steps {
+		if err := s.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid step %d of the runbook %s, error: %v", i+1, name, err)
+		}
+	}
+	return &r, nil
+}
+
+// Save saves the runbook into the runbook directory
+func (r *Runbook) Save() error {
+	if err := os.MkdirAll(RunbookDir(), 0700); err != nil {
+		return err
+	}
+	b, err := yaml.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(runbookPath(r.Name), b, permission)
+}
+
+// ListRunbooks returns the names of the saved runbooks
+func ListRunbooks() ([]string, error) {
+	files, err := filepath.Glob(RunbookDir() + "*.yaml")
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(files))
+	for i, f := range files {
+		names[i] = strings.TrimSuffix(filepath.Base(f), ".yaml")
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// StartRecording records the next actions into the runbook of the name
+func StartRecording(name, description string) error {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return fmt.Errorf("invalid runbook name %s", name)
+	}
+	if current, ok := Recording(); ok {
+		return fmt.Errorf("runbook %s is being recorded, stop it first", current)
+	}
+	r := &Runbook{Name: name, Description: description}
+	if err := r.Save(); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(recordingPath(), []byte(name), permission)
+}
+
+// Recording returns the name of the runbook being recorded
+func Recording() (string, bool) {
+	b, err := ioutil.ReadFile(recordingPath())
+	if err != nil {
+		return "", false
+	}
+	name := strings.TrimSpace(string(b))
+	return name, name != ""
+}
+
+// StopRecording stops recording & returns the recorded runbook
+func StopRecording() (*Runbook, error) {
+	name, ok := Recording()
+	if !ok {
+		return nil, errors.New("there's no runbook being recorded")
+	}
+	if err := os.Remove(recordingPath()); err != nil {
+		return nil, err
+	}
+	return LoadRunbook(name)
+}
+
+// RecordStep appends the step into the runbook being recorded if any
+func RecordStep(step RunbookStep) error {
+	name, ok := Recording()
+	if !ok {
+		return nil
+	}
+	r, err := LoadRunbook(name)
+	if err != nil {
+		return err
+	}
+	r.Steps = append(r.Steps, step)
+	return r.Save()
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordStep(t *testing.T) {
+	tempConfigDir(t)
+
+	// nothing is recorded until the recording is started
+	assert.NoError(t, RecordStep(RunbookStep{Env: "prod", Hosts: []string{"web-01"}, Exec: "uptime"}))
+	_, err := StopRecording()
+	assert.Error(t, err)
+
+	assert.NoError(t, StartRecording("restart-web", "restart the app"))
+	assert.Error(t, StartRecording("other", ""), "only one runbook is recorded at a time")
+
+	steps := []RunbookStep{
+		{Env: "prod", Filter: "web-*", User: "root", Exec: "sudo systemctl restart app"},
+		{Env: "prod", Hosts: []string{"db-01"}, Forward: []string{"5432:localhost:5432"}},
+	}
+	for _, s := range steps {
+		assert.NoError(t, RecordStep(s))
+	}
+	r, err := StopRecording()
+	assert.NoError(t, err)
+	assert.Equal(t, &Runbook{Name: "restart-web", Description: "restart the app", Steps: steps}, r)
+
+	_, ok := Recording()
+	assert.False(t, ok)
+	names, err := ListRunbooks()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"restart-web"}, names)
+
+	// the runbook is loaded by the file path as well
+	r, err = LoadRunbook(Dir + "runbooks/restart-web.yaml")
+	assert.NoError(t, err)
+	assert.Len(t, r.Steps, 2)
+}
+
+func TestRunbookStep_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		step    RunbookStep
+		wantErr bool
+	}{
+		{name: "exec", step: RunbookStep{Env: "prod", Filter: "web-*", Exec: "uptime"}},
+		{name: "forward", step: RunbookStep{Env: "prod", Hosts: []string{"db-01"}, Forward: []string{"5432:localhost:5432"}}},
+		{name: "no env", step: RunbookStep{Filter: "web-*", Exec: "uptime"}, wantErr: true},
+		{name: "no host", step: RunbookStep{Env: "prod", Exec: "uptime"}, wantErr: true},
+		{name: "exec & forward", step: RunbookStep{Env: "prod", Filter: "web-*", Exec: "uptime", Forward: []string{"1:a:2"}}, wantErr: true},
+		{name: "invalid forward", step: RunbookStep{Env: "prod", Filter: "web-*", Forward: []string{"5432"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantErr, tt.step.Validate() != nil)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"io/ioutil"
+	"net/url"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// redacted replaces the secrets of the sanitized configuration
+const redacted = "REDACTED"
+
+// secretKeys is the keys whose values are redacted, the key containing any of them
+var secretKeys = []string{"secret", "token", "password", "passwd", "hmac_key", "api_key", "private_key"}
+
+// SanitizedConfig returns the configuration file with the secrets redacted,
+// to be shared such as along with the bug report
+func SanitizedConfig() ([]byte, error) {
+	b, err := ioutil.ReadFile(Dir + configFileName)
+	if err != nil {
+		return nil, err
+	}
+	return SanitizeYAML(b)
+}
+
+// SanitizeYAML redacts the secrets of the YAML document: the values of the
+// secret keys & the headers, and the path & the query of the URLs since the
+// webhook URLs carry the secret in them. The comments aren't kept
+func SanitizeYAML(b []byte) ([]byte, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(sanitize(doc, ""))
+}
+
+// sanitize redacts the value of the key, the maps & the lists are walked
+func sanitize(v interface{}, key string) interface{} {
+	key = strings.ToLower(key)
+	switch val := v.(type) {
+	case map[interface{}]interface{}:
+		for k, child := range val {
+			name, _ := k.(string)
+			if strings.ToLower(key) == "headers" {
+				val[k] = redacted
+				continue
+			}
+			val[k] = sanitize(child, name)
+		}
+		return val
+	case []interface{}:
+		for i, child := range val {
+			val[i] = sanitize(child, key)
+		}
+		return val
+	case string:
+		if val == "" {
+			return val
+		}
+		for _, s := range secretKeys {
+			if strings.Contains(key, s) {
+				return redacted
+			}
+		}
+		if key == "url" || strings.HasSuffix(key, "_url") || key == "bundle" {
+			return sanitizeURL(val)
+		}
+	}
+	return v
+}
+
+// sanitizeURL keeps only the scheme & the host of the URL
+func sanitizeURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return s
+	}
+	if u.Path == "" && u.RawQuery == "" && u.User == nil {
+		return s
+	}
+	return u.Scheme + "://" + u.Host + "/" + redacted
+}
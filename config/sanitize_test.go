@@ -0,0 +1,57 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeYAML(t *testing.T) {
+	in := `
+plain: true
+audit:
+  hmac_key: chain-key
+  sinks:
+  - type: webhook
+    url: https://hooks.example.com/services/T0/B0/xyz?token=abc
+    headers:
+      Authorization: Bearer abc
+slack:
+  signing_secret: shh
+enrichment:
+- type: netbox
+  url: https://netbox.example.com
+  token: "0123"
+proxies:
+- env: prod
+  address: teleport.example.com:443
+  callback_url: https://callback.example.com:8443/login?code=1
+  user_name: me
+`
+	want := `audit:
+  hmac_key: REDACTED
+  sinks:
+  - headers:
+      Authorization: REDACTED
+    type: webhook
+    url: https://hooks.example.com/REDACTED
+enrichment:
+- token: REDACTED
+  type: netbox
+  url: https://netbox.example.com
+plain: true
+proxies:
+- address: teleport.example.com:443
+  callback_url: https://callback.example.com:8443/REDACTED
+  env: prod
+  user_name: me
+slack:
+  signing_secret: REDACTED
+`
+	got, err := SanitizeYAML([]byte(in))
+	assert.NoError(t, err)
+	assert.Equal(t, want, string(got))
+
+	_, err = SanitizeYAML([]byte("proxies: ["))
+	assert.Error(t, err)
+}
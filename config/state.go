@@ -0,0 +1,86 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// State is the UI state kept between the runs, such as the environment used
+// last time & the picker sort. It's kept in the cache directory apart from
+// the configuration, hence the UI never rewrites the configuration file.
+// The panes have fixed sizes & the colors aren't themeable yet, so neither
+// is kept until the UI can change them
+type State struct {
+	// LastEnv is the environment of the host picked last time
+	LastEnv string `json:"last_env,omitempty"`
+
+	// HideColumns hides the picker columns as they were hidden last time
+	HideColumns bool `json:"hide_columns,omitempty"`
+
+	// Envs is the state of every environment by the name
+	Envs map[string]EnvState `json:"envs,omitempty"`
+}
+
+// EnvState is the UI state of an environment
+type EnvState struct {
+	// Filter is the query typed last time, it may be empty unlike the search history
+	Filter string `json:"filter,omitempty"`
+
+	// Sort is the order of the picker chosen last time, empty is by name
+	Sort string `json:"sort,omitempty"`
+}
+
+// stateMu serializes UpdateState of the goroutines
+var stateMu sync.Mutex
+
+// GetState gets the UI state, it's empty when nothing is saved yet
+func GetState() (State, error) {
+	var res State
+	b, err := ioutil.ReadFile(statePath())
+	if errors.Is(err, os.ErrNotExist) {
+		return res, nil
+	}
+	if err != nil {
+		return res, err
+	}
+	return res, json.Unmarshal(b, &res)
+}
+
+// UpdateState loads the UI state, changes it by the update & saves it, the
+// unreadable state is replaced as it's only the preferences of the UI
+func UpdateState(update func(s *State)) error {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	s, _ := GetState()
+	if s.Envs == nil {
+		s.Envs = make(map[string]EnvState)
+	}
+	update(&s)
+	for env, e := range s.Envs {
+		if e == (EnvState{}) {
+			delete(s.Envs, env)
+		}
+	}
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(statePath(), b, permission)
+}
+
+// UpdateEnvState changes the UI state of the environment by the update
+func UpdateEnvState(env string, update func(s *EnvState)) error {
+	return UpdateState(func(s *State) {
+		e := s.Envs[env]
+		update(&e)
+		s.Envs[env] = e
+	})
+}
+
+func statePath() string {
+	return CacheDir + "state.json"
+}
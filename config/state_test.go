@@ -0,0 +1,64 @@
+package config
+
+import (
+	"io/ioutil"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateState(t *testing.T) {
+	tempCacheDir(t)
+
+	s, err := GetState()
+	assert.NoError(t, err)
+	assert.Equal(t, State{}, s)
+
+	assert.NoError(t, UpdateState(func(s *State) { s.LastEnv, s.HideColumns = "prod", true }))
+	assert.NoError(t, UpdateEnvState("prod", func(s *EnvState) { s.Filter = "web" }))
+	assert.NoError(t, UpdateEnvState("prod", func(s *EnvState) { s.Sort = "latency" }))
+	assert.NoError(t, UpdateEnvState("staging", func(s *EnvState) { s.Filter = "db" }))
+
+	s, err = GetState()
+	assert.NoError(t, err)
+	assert.Equal(t, State{
+		LastEnv:     "prod",
+		HideColumns: true,
+		Envs: map[string]EnvState{
+			"prod":    {Filter: "web", Sort: "latency"},
+			"staging": {Filter: "db"},
+		},
+	}, s)
+}
+
+func TestUpdateState_concurrent(t *testing.T) {
+	tempCacheDir(t)
+	envs := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+
+	var wg sync.WaitGroup
+	for _, env := range envs {
+		wg.Add(1)
+		go func(env string) {
+			defer wg.Done()
+			assert.NoError(t, UpdateEnvState(env, func(s *EnvState) { s.Filter = env }))
+		}(env)
+	}
+	wg.Wait()
+
+	s, err := GetState()
+	assert.NoError(t, err)
+	assert.Len(t, s.Envs, len(envs))
+}
+
+func TestUpdateState_corrupted(t *testing.T) {
+	tempCacheDir(t)
+	assert.NoError(t, ioutil.WriteFile(statePath(), []byte("{"), permission))
+
+	_, err := GetState()
+	assert.Error(t, err)
+	assert.NoError(t, UpdateState(func(s *State) { s.LastEnv = "prod" }))
+	s, err := GetState()
+	assert.NoError(t, err)
+	assert.Equal(t, "prod", s.LastEnv)
+}
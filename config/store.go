@@ -0,0 +1,257 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// the supported storage backends of the node cache
+const (
+	StorageFile   = "file"
+	StorageSQLite = "sqlite"
+)
+
+// Store persists the node cache, the host facts & the search history of every
+// environment, a missing node cache must be reported as os.ErrNotExist
+type Store interface {
+	GetNode(env string) (Node, error)
+	UpdateNode(env string, n Node) error
+	GetFacts(env string) (map[string]HostFacts, error)
+	UpdateFacts(env, host string, facts HostFacts) error
+
+	// GetSearchHistory returns the picker queries of the env, the latest first,
+	// UpdateSearchHistory replaces them
+	GetSearchHistory(env string) ([]string, error)
+	UpdateSearchHistory(env string, queries []string) error
+
+	// Info describes the node cache of the env
+	Info(env string) (CacheInfo, error)
+
+	// DeleteNode removes the node cache & the facts of the env,
+	// the missing cache isn't an error
+	DeleteNode(env string) error
+	Close() error
+}
+
+// store is the storage used by the proxies, it's replaced once
+// the configuration is loaded
+var store Store = fileStore{}
+
+// OpenStore opens the storage backend, empty means the flat file
+func OpenStore(backend string) (Store, error) {
+	switch backend {
+	case "", StorageFile:
+		return fileStore{}, nil
+	case StorageSQLite:
+		s, err := openSQLiteStore(CacheDir + "tpot.db")
+		if err != nil {
+			return nil, err
+		}
+		// the flat file cache is read on miss to migrate it automatically
+		return &migratingStore{Store: s, legacy: fileStore{}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported storage %s, use file or sqlite", backend)
+	}
+}
+
+// UseStore replaces the storage used by the proxies
+// and closes the previous one
+func UseStore(s Store) {
+	store.Close()
+	store = s
+}
+
+// fileStore stores the cache as a JSON file per environment in CacheDir,
+// the SHA-256 of the node cache is written next to it to detect the
+// cache corrupted or edited by hand
+type fileStore struct{}
+
+func nodePath(env string) string {
+	return CacheDir + "node_" + env + ".json"
+}
+
+func checksumPath(env string) string {
+	return CacheDir + "node_" + env + ".sha256"
+}
+
+func checksum(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func (fileStore) GetNode(env string) (Node, error) {
+	var n Node
+	b, err := ioutil.ReadFile(nodePath(env))
+	if err != nil {
+		return n, err
+	}
+	return n, json.Unmarshal(b, &n)
+}
+
+func (fileStore) UpdateNode(env string, n Node) error {
+	b, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(nodePath(env), b, permission); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(checksumPath(env), []byte(checksum(b)+"\n"), permission)
+}
+
+func (fileStore) Info(env string) (CacheInfo, error) {
+	info := CacheInfo{Backend: StorageFile, Path: nodePath(env)}
+	stat, err := os.Stat(info.Path)
+	if err != nil {
+		return info, err
+	}
+	b, err := ioutil.ReadFile(info.Path)
+	if err != nil {
+		return info, err
+	}
+	info.Size, info.RefreshedAt = stat.Size(), stat.ModTime()
+
+	sum, err := ioutil.ReadFile(checksumPath(env))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		info.Checksum = ChecksumMissing
+	case err != nil:
+		return info, err
+	case strings.TrimSpace(string(sum)) == checksum(b):
+		info.Checksum = ChecksumValid
+	default:
+		info.Checksum = ChecksumMismatch
+	}
+
+	var n Node
+	if err := json.Unmarshal(b, &n); err != nil {
+		return info, fmt.Errorf("invalid node cache %s, error: %v", info.Path, err)
+	}
+	info.Nodes, info.Source = len(n.Items), n.Source
+	return info, nil
+}
+
+func (fileStore) DeleteNode(env string) error {
+	for _, path := range []string{nodePath(env), checksumPath(env), CacheDir + "facts_" + env + ".json"} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (fileStore) GetFacts(env string) (map[string]HostFacts, error) {
+	res := make(map[string]HostFacts)
+	b, err := ioutil.ReadFile(CacheDir + "facts_" + env + ".json")
+	if errors.Is(err, os.ErrNotExist) {
+		return res, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, json.Unmarshal(b, &res)
+}
+
+func (s fileStore) UpdateFacts(env, host string, facts HostFacts) error {
+	all, err := s.GetFacts(env)
+	if err != nil {
+		return err
+	}
+	all[host] = facts
+	b, err := json.Marshal(all)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(CacheDir+"facts_"+env+".json", b, permission)
+}
+
+func historyPath(env string) string {
+	return CacheDir + "history_" + env + ".json"
+}
+
+func (fileStore) GetSearchHistory(env string) ([]string, error) {
+	var res []string
+	b, err := ioutil.ReadFile(historyPath(env))
+	if errors.Is(err, os.ErrNotExist) {
+		return res, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, json.Unmarshal(b, &res)
+}
+
+func (fileStore) UpdateSearchHistory(env string, queries []string) error {
+	b, err := json.Marshal(queries)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(historyPath(env), b, permission)
+}
+
+func (fileStore) Close() error {
+	return nil
+}
+
+// migratingStore imports the legacy cache of an environment
+// into the store the first time it's missing
+type migratingStore struct {
+	Store
+	legacy Store
+}
+
+func (m *migratingStore) GetNode(env string) (Node, error) {
+	n, err := m.Store.GetNode(env)
+	if !errors.Is(err, os.ErrNotExist) {
+		return n, err
+	}
+	n, err = m.legacy.GetNode(env)
+	if err != nil {
+		return n, err
+	}
+	if err := m.Store.UpdateNode(env, n); err != nil {
+		return n, fmt.Errorf("failed to migrate the %s node cache, error: %v", env, err)
+	}
+
+	facts, err := m.legacy.GetFacts(env)
+	if err != nil {
+		return n, err
+	}
+	for host, f := range facts {
+		if err := m.Store.UpdateFacts(env, host, f); err != nil {
+			return n, fmt.Errorf("failed to migrate the %s facts, error: %v", env, err)
+		}
+	}
+	return n, nil
+}
+
+// DeleteNode removes the legacy cache as well,
+// otherwise it's migrated again by the next GetNode
+func (m *migratingStore) DeleteNode(env string) error {
+	if err := m.Store.DeleteNode(env); err != nil {
+		return err
+	}
+	return m.legacy.DeleteNode(env)
+}
+
+// GetSearchHistory imports the legacy history the first time it's missing
+func (m *migratingStore) GetSearchHistory(env string) ([]string, error) {
+	history, err := m.Store.GetSearchHistory(env)
+	if err != nil || len(history) > 0 {
+		return history, err
+	}
+	history, err = m.legacy.GetSearchHistory(env)
+	if err != nil || len(history) == 0 {
+		return history, err
+	}
+	if err := m.Store.UpdateSearchHistory(env, history); err != nil {
+		return history, fmt.Errorf("failed to migrate the %s search history, error: %v", env, err)
+	}
+	return history, nil
+}
@@ -0,0 +1,10 @@
+//go:build !sqlite
+// +build !sqlite
+
+package config
+
+import "fmt"
+
+func openSQLiteStore(path string) (Store, error) {
+	return nil, fmt.Errorf("tpot is built without sqlite storage, rebuild it with -tags sqlite")
+}
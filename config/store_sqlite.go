@@ -0,0 +1,320 @@
+//go:build sqlite
+// +build sqlite
+
+package config
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	// register the sqlite3 driver
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS nodes (
+	env        TEXT PRIMARY KEY,
+	status     TEXT NOT NULL,
+	updated_at INTEGER NOT NULL,
+	source     TEXT NOT NULL DEFAULT ''
+);
+CREATE TABLE IF NOT EXISTS items (
+	env       TEXT NOT NULL,
+	hostname  TEXT NOT NULL,
+	addr      TEXT NOT NULL,
+	id        TEXT NOT NULL DEFAULT '',
+	labels    TEXT NOT NULL DEFAULT '',
+	last_seen INTEGER NOT NULL DEFAULT 0,
+	source    TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (env, hostname, addr, id)
+);
+CREATE TABLE IF NOT EXISTS facts (
+	env          TEXT NOT NULL,
+	hostname     TEXT NOT NULL,
+	collected_at INTEGER NOT NULL,
+	facts        TEXT NOT NULL,
+	PRIMARY KEY (env, hostname)
+);
+CREATE TABLE IF NOT EXISTS search_history (
+	env      TEXT NOT NULL,
+	position INTEGER NOT NULL,
+	query    TEXT NOT NULL,
+	PRIMARY KEY (env, position)
+);
+`
+
+// sqliteMigrations adds the columns missing in the database created
+// by the older version, the duplicate column error is ignored
+var sqliteMigrations = []string{
+	"ALTER TABLE items ADD COLUMN id TEXT NOT NULL DEFAULT ''",
+	"ALTER TABLE items ADD COLUMN labels TEXT NOT NULL DEFAULT ''",
+	"ALTER TABLE nodes ADD COLUMN source TEXT NOT NULL DEFAULT ''",
+	"ALTER TABLE items ADD COLUMN last_seen INTEGER NOT NULL DEFAULT 0",
+	"ALTER TABLE items ADD COLUMN source TEXT NOT NULL DEFAULT ''",
+}
+
+// sqliteItemsKey rebuilds the items table keyed by the hostname only, created by the
+// older version, so the instances sharing the hostname are kept. sqlite can't alter the key
+var sqliteItemsKey = []string{
+	`CREATE TABLE items_by_key (
+	env       TEXT NOT NULL,
+	hostname  TEXT NOT NULL,
+	addr      TEXT NOT NULL,
+	id        TEXT NOT NULL DEFAULT '',
+	labels    TEXT NOT NULL DEFAULT '',
+	last_seen INTEGER NOT NULL DEFAULT 0,
+	source    TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (env, hostname, addr, id)
+)`,
+	"INSERT INTO items_by_key SELECT env, hostname, addr, id, labels, last_seen, source FROM items ORDER BY rowid",
+	"DROP TABLE items",
+	"ALTER TABLE items_by_key RENAME TO items",
+}
+
+// migrateItemsKey runs sqliteItemsKey once the items table is still keyed by the hostname only
+func migrateItemsKey(db *sql.DB) error {
+	var schema string
+	if err := db.QueryRow("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'items'").Scan(&schema); err != nil {
+		return err
+	}
+	if !strings.Contains(schema, "PRIMARY KEY (env, hostname)") {
+		return nil
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, m := range sqliteItemsKey {
+		if _, err := tx.Exec(m); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// sqliteStore stores the cache of all environments in a single database
+type sqliteStore struct {
+	db   *sql.DB
+	path string
+}
+
+func openSQLiteStore(path string) (Store, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create the sqlite schema, error: %v", err)
+	}
+	for _, m := range sqliteMigrations {
+		if _, err := db.Exec(m); err != nil && !strings.Contains(err.Error(), "duplicate column") {
+			db.Close()
+			return nil, fmt.Errorf("failed to migrate the sqlite schema, error: %v", err)
+		}
+	}
+	if err := migrateItemsKey(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate the sqlite schema, error: %v", err)
+	}
+	if err := os.Chmod(path, permission); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &sqliteStore{db: db, path: path}, nil
+}
+
+func (s *sqliteStore) GetNode(env string) (Node, error) {
+	var n Node
+	var status string
+	err := s.db.QueryRow("SELECT status, source FROM nodes WHERE env = ?", env).Scan(&status, &n.Source)
+	if err == sql.ErrNoRows {
+		return n, fmt.Errorf("node cache of %s, error: %w", env, os.ErrNotExist)
+	}
+	if err != nil {
+		return n, err
+	}
+	if err := json.Unmarshal([]byte(status), &n.Status); err != nil {
+		return n, err
+	}
+
+	rows, err := s.db.Query("SELECT hostname, addr, id, labels, last_seen, source FROM items WHERE env = ? ORDER BY rowid", env)
+	if err != nil {
+		return n, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var item Item
+		var labels string
+		var lastSeen int64
+		if err := rows.Scan(&item.Hostname, &item.Address, &item.ID, &labels, &lastSeen, &item.Source); err != nil {
+			return n, err
+		}
+		if lastSeen != 0 {
+			at := time.Unix(lastSeen, 0)
+			item.LastSeen = &at
+		}
+		if labels != "" {
+			if err := json.Unmarshal([]byte(labels), &item.Labels); err != nil {
+				return n, err
+			}
+		}
+		n.Items = append(n.Items, item)
+	}
+	return n, rows.Err()
+}
+
+func (s *sqliteStore) UpdateNode(env string, n Node) error {
+	status, err := json.Marshal(n.Status)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("INSERT OR REPLACE INTO nodes (env, status, updated_at, source) VALUES (?, ?, ?, ?)",
+		env, string(status), time.Now().Unix(), n.Source)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM items WHERE env = ?", env); err != nil {
+		return err
+	}
+	for _, item := range n.Items {
+		var labels []byte
+		if len(item.Labels) > 0 {
+			if labels, err = json.Marshal(item.Labels); err != nil {
+				return err
+			}
+		}
+		var lastSeen int64
+		if item.LastSeen != nil {
+			lastSeen = item.LastSeen.Unix()
+		}
+		_, err := tx.Exec("INSERT OR REPLACE INTO items (env, hostname, addr, id, labels, last_seen, source) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			env, item.Hostname, item.Address, item.ID, string(labels), lastSeen, item.Source)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *sqliteStore) GetFacts(env string) (map[string]HostFacts, error) {
+	rows, err := s.db.Query("SELECT hostname, collected_at, facts FROM facts WHERE env = ?", env)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make(map[string]HostFacts)
+	for rows.Next() {
+		var host, facts string
+		var collectedAt int64
+		if err := rows.Scan(&host, &collectedAt, &facts); err != nil {
+			return nil, err
+		}
+		f := HostFacts{CollectedAt: time.Unix(collectedAt, 0)}
+		if err := json.Unmarshal([]byte(facts), &f.Facts); err != nil {
+			return nil, err
+		}
+		res[host] = f
+	}
+	return res, rows.Err()
+}
+
+func (s *sqliteStore) UpdateFacts(env, host string, facts HostFacts) error {
+	b, err := json.Marshal(facts.Facts)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec("INSERT OR REPLACE INTO facts (env, hostname, collected_at, facts) VALUES (?, ?, ?, ?)",
+		env, host, facts.CollectedAt.Unix(), string(b))
+	return err
+}
+
+func (s *sqliteStore) GetSearchHistory(env string) ([]string, error) {
+	rows, err := s.db.Query("SELECT query FROM search_history WHERE env = ? ORDER BY position", env)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []string
+	for rows.Next() {
+		var q string
+		if err := rows.Scan(&q); err != nil {
+			return nil, err
+		}
+		res = append(res, q)
+	}
+	return res, rows.Err()
+}
+
+func (s *sqliteStore) UpdateSearchHistory(env string, queries []string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("DELETE FROM search_history WHERE env = ?", env); err != nil {
+		return err
+	}
+	for i, q := range queries {
+		if _, err := tx.Exec("INSERT INTO search_history (env, position, query) VALUES (?, ?, ?)", env, i, q); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Info reports the size of the whole database, the integrity
+// is checked by sqlite itself hence there's no checksum
+func (s *sqliteStore) Info(env string) (CacheInfo, error) {
+	info := CacheInfo{Backend: StorageSQLite, Path: s.path, Checksum: ChecksumNone}
+	var updatedAt int64
+	err := s.db.QueryRow("SELECT updated_at, source FROM nodes WHERE env = ?", env).Scan(&updatedAt, &info.Source)
+	if err == sql.ErrNoRows {
+		return info, fmt.Errorf("node cache of %s, error: %w", env, os.ErrNotExist)
+	}
+	if err != nil {
+		return info, err
+	}
+	info.RefreshedAt = time.Unix(updatedAt, 0)
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM items WHERE env = ?", env).Scan(&info.Nodes); err != nil {
+		return info, err
+	}
+	stat, err := os.Stat(s.path)
+	if err != nil {
+		return info, err
+	}
+	info.Size = stat.Size()
+	return info, nil
+}
+
+func (s *sqliteStore) DeleteNode(env string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, table := range []string{"nodes", "items", "facts"} {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE env = ?", env); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,73 @@
+//go:build sqlite
+// +build sqlite
+
+package config
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_sqliteStore(t *testing.T) {
+	s, err := openSQLiteStore(t.TempDir() + "/tpot.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	_, err = s.GetNode("prod")
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+
+	node := Node{
+		Status: &ProxyStatus{LoginAs: "adzim", UserLogins: []string{"root"}},
+		Source: SourceTSH,
+		Items: []Item{
+			{Hostname: "web-02", Address: "10.0.0.2:3022"},
+			{Hostname: "web-01", Address: "10.0.0.1:3022"},
+			// the reused hostname of another instance
+			{Hostname: "web-01", Address: "10.0.0.9:3022"},
+		},
+	}
+	assert.NoError(t, s.UpdateNode("prod", node))
+	got, err := s.GetNode("prod")
+	assert.NoError(t, err)
+	assert.Equal(t, node, got)
+
+	node.Items = node.Items[:1]
+	assert.NoError(t, s.UpdateNode("prod", node))
+	got, err = s.GetNode("prod")
+	assert.NoError(t, err)
+	assert.Equal(t, node, got)
+
+	f := HostFacts{CollectedAt: time.Unix(1688792400, 0), Facts: map[string]string{"os": "ubuntu"}}
+	assert.NoError(t, s.UpdateFacts("prod", "web-01", f))
+	facts, err := s.GetFacts("prod")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]HostFacts{"web-01": f}, facts)
+
+	info, err := s.Info("prod")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, info.Nodes)
+	assert.Equal(t, ChecksumNone, info.Checksum)
+
+	assert.NoError(t, s.DeleteNode("prod"))
+	_, err = s.Info("prod")
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+	facts, err = s.GetFacts("prod")
+	assert.NoError(t, err)
+	assert.Empty(t, facts)
+
+	history, err := s.GetSearchHistory("prod")
+	assert.NoError(t, err)
+	assert.Empty(t, history)
+	assert.NoError(t, s.UpdateSearchHistory("prod", []string{"web", "db"}))
+	assert.NoError(t, s.UpdateSearchHistory("staging", []string{"cassandra-"}))
+	assert.NoError(t, s.UpdateSearchHistory("prod", []string{"db", "web", "api"}))
+	history, err = s.GetSearchHistory("prod")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"db", "web", "api"}, history)
+}
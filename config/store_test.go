@@ -0,0 +1,80 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_fileStore(t *testing.T) {
+	tempCacheDir(t)
+	s := fileStore{}
+
+	_, err := s.GetNode("prod")
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+
+	node := Node{
+		Status: &ProxyStatus{LoginAs: "adzim", UserLogins: []string{"root"}},
+		Items:  []Item{{Hostname: "web-01", Address: "10.0.0.1:3022"}},
+	}
+	assert.NoError(t, s.UpdateNode("prod", node))
+	got, err := s.GetNode("prod")
+	assert.NoError(t, err)
+	assert.Equal(t, node, got)
+
+	facts, err := s.GetFacts("prod")
+	assert.NoError(t, err)
+	assert.Empty(t, facts)
+
+	f := HostFacts{CollectedAt: time.Date(2023, 7, 8, 12, 0, 0, 0, time.UTC), Facts: map[string]string{"os": "ubuntu"}}
+	assert.NoError(t, s.UpdateFacts("prod", "web-01", f))
+	facts, err = s.GetFacts("prod")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]HostFacts{"web-01": f}, facts)
+}
+
+func Test_migratingStore(t *testing.T) {
+	legacy := NewMemoryStore()
+	node := Node{Items: []Item{{Hostname: "web-01", Address: "10.0.0.1:3022"}}}
+	f := HostFacts{Facts: map[string]string{"os": "ubuntu"}}
+	legacy.UpdateNode("prod", node)
+	legacy.UpdateFacts("prod", "web-01", f)
+
+	current := NewMemoryStore()
+	s := &migratingStore{Store: current, legacy: legacy}
+
+	got, err := s.GetNode("prod")
+	assert.NoError(t, err)
+	assert.Equal(t, node, got)
+	assert.Equal(t, node, current.nodes["prod"])
+	assert.Equal(t, f, current.facts["prod"]["web-01"])
+
+	// the migrated cache must not be overridden by the legacy one
+	updated := Node{Items: []Item{{Hostname: "web-02", Address: "10.0.0.2:3022"}}}
+	assert.NoError(t, s.UpdateNode("prod", updated))
+	got, err = s.GetNode("prod")
+	assert.NoError(t, err)
+	assert.Equal(t, updated, got)
+
+	_, err = s.GetNode("staging")
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+
+	legacy.UpdateSearchHistory("prod", []string{"web", "db"})
+	history, err := s.GetSearchHistory("prod")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"web", "db"}, history)
+	assert.Equal(t, history, current.history["prod"])
+	// the migrated history must not be overridden by the legacy one
+	assert.NoError(t, s.UpdateSearchHistory("prod", []string{"api"}))
+	history, err = s.GetSearchHistory("prod")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"api"}, history)
+
+	// the deleted cache must not be migrated again
+	assert.NoError(t, s.DeleteNode("prod"))
+	_, err = s.GetNode("prod")
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// WatchInterval is how often the configuration file is checked for the change
+const WatchInterval = 2 * time.Second
+
+// Reload reads the configuration file again & validates every proxy,
+// the invalid configuration is returned as the error so the caller keeps
+// using the current one
+func Reload() (*Config, error) {
+	cfg, err := getConfig()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(cfg.Proxies))
+	for _, p := range cfg.Proxies {
+		if err := p.Validate(); err != nil {
+			return nil, fmt.Errorf("failed to validate environment %s, error: %v", p.Env, err)
+		}
+		if seen[p.Env] {
+			return nil, fmt.Errorf("environment %s is defined more than once", p.Env)
+		}
+		seen[p.Env] = true
+	}
+	return cfg, nil
+}
+
+// Watch checks the configuration file every interval in the background until
+// the ctx is done, onReload is called with the reloaded configuration once the
+// file is changed & onError with the reason it can't be reloaded, both are
+// called by the watching goroutine
+func Watch(ctx context.Context, interval time.Duration, onReload func(*Config), onError func(error)) {
+	last := fileStamp(Dir + configFileName)
+	go watch(ctx, interval, last, onReload, onError)
+}
+
+func watch(ctx context.Context, interval time.Duration, last string, onReload func(*Config), onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		stamp := fileStamp(Dir + configFileName)
+		if stamp == last {
+			continue
+		}
+		last = stamp
+
+		cfg, err := Reload()
+		if err != nil {
+			onError(err)
+			continue
+		}
+		onReload(cfg)
+	}
+}
+
+// fileStamp identifies the file content by its modification time & size,
+// the editors replacing the file are noticed as well
+func fileStamp(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size())
+}
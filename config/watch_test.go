@@ -0,0 +1,42 @@
+package config
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatch(t *testing.T) {
+	tempConfigDir(t)
+	write := func(content string) {
+		assert.NoError(t, ioutil.WriteFile(Dir+configFileName, []byte(content), 0600))
+	}
+	write("proxies: []\n")
+
+	reloaded := make(chan *Config, 1)
+	errs := make(chan error, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	Watch(ctx, 10*time.Millisecond,
+		func(c *Config) { reloaded <- c },
+		func(err error) { errs <- err })
+
+	write("proxies:\n- env: staging\n  address: https://teleport.mycomp.com\n  user_name: me\n")
+	select {
+	case c := <-reloaded:
+		assert.Equal(t, "staging", c.Proxies[0].Env)
+	case <-time.After(time.Second):
+		t.Fatal("the changed configuration isn't reloaded")
+	}
+
+	write("proxies:\n- env: staging\n  address: not a url\n")
+	select {
+	case err := <-errs:
+		assert.Contains(t, err.Error(), "staging")
+	case <-time.After(time.Second):
+		t.Fatal("the invalid configuration isn't reported")
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/adzimzf/tpot/audit"
+	"github.com/adzimzf/tpot/config"
+	"github.com/spf13/cobra"
+)
+
+const configExample = `
+tpot config sync https://config.mycomp.com/tpot.yaml               // Sync the bundle & remember it for the next sync
+tpot config sync                                                   // Sync the remembered bundle
+tpot config keygen -o bundle.key                                   // Generate the key pair, pin the public key in the policy
+tpot config sign tpot.yaml --key bundle.key                        // Sign the bundle into tpot.yaml.sig
+`
+
+var configCmd = &cobra.Command{
+	Use:     "config",
+	Short:   "Manage the configuration bundle distributed by the organization",
+	Example: configExample,
+}
+
+var configSyncCmd = &cobra.Command{
+	Use:   "sync [BUNDLE]",
+	Short: "Sync the proxies & the templates of the bundle, BUNDLE is a path or an URL",
+	Long: "The proxies & the templates of the bundle replace the ones of the same name, the rest are added. " +
+		"The signature is read from <BUNDLE>.sig, the unsigned or tampered bundle is refused when bundle_key is pinned in the policy",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+		source := cfg.Bundle
+		if len(args) > 0 {
+			source = args[0]
+		}
+		if source == "" {
+			return usageErrorf("BUNDLE is required, it's remembered after the first sync")
+		}
+
+		b, sig, err := fetchBundle(source)
+		if err != nil {
+			return err
+		}
+		bundle, err := config.ReadBundle(b, sig)
+		if errors.Is(err, config.ErrUnverifiedBundle) {
+			infof(cmd, "WARNING! the bundle isn't verified, pin the signing key by bundle_key of the policy %s\n", config.PolicyPath)
+		} else if err != nil {
+			return withCode(exitConfig, err)
+		}
+
+		cfg.Bundle = source
+		added, updated, err := cfg.SyncBundle(bundle)
+		if err != nil {
+			return err
+		}
+		auditEvent(audit.Event{Action: audit.ActionConfig,
+			Detail: fmt.Sprintf("sync bundle %s, added [%s], updated [%s]", source, strings.Join(added, ", "), strings.Join(updated, ", "))})
+		infof(cmd, "%d environments are added & %d are updated from %s\n", len(added), len(updated), source)
+		return nil
+	},
+}
+
+var configSignCmd = &cobra.Command{
+	Use:   "sign <BUNDLE>",
+	Short: "Sign the bundle into <BUNDLE>.sig by the private key of tpot config keygen",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return usageErrorf("BUNDLE is required")
+		}
+		keyPath, _ := cmd.Flags().GetString("key")
+		if keyPath == "" {
+			return usageErrorf("--key is required")
+		}
+		key, err := ioutil.ReadFile(keyPath)
+		if err != nil {
+			return fmt.Errorf("failed to read %s, error: %v", keyPath, err)
+		}
+		b, err := ioutil.ReadFile(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to read %s, error: %v", args[0], err)
+		}
+		sig, err := config.SignBundle(b, string(key))
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(args[0]+config.BundleSigSuffix, sig, 0644); err != nil {
+			return err
+		}
+		infof(cmd, "%s is signed into %s\n", args[0], args[0]+config.BundleSigSuffix)
+		return nil
+	},
+}
+
+var configKeygenCmd = &cobra.Command{
+	Use:   "keygen",
+	Short: "Generate the ed25519 key pair to sign the bundles, the public key is printed",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, _ := cmd.Flags().GetString("output")
+		if output == "" {
+			return usageErrorf("--output is required")
+		}
+		if _, err := os.Stat(output); err == nil {
+			return fmt.Errorf("%s already exists, remove it first or write into another file", output)
+		}
+		public, private, err := config.GenerateBundleKey()
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(output, []byte(private+"\n"), 0600); err != nil {
+			return err
+		}
+		infof(cmd, "the private key is written into %s, keep it secret & pin the public key in the policy\n", output)
+		fmt.Printf("bundle_key: %s\n", public)
+		return nil
+	},
+}
+
+func init() {
+	configSignCmd.Flags().String("key", "", "the private key file of tpot config keygen")
+	configKeygenCmd.Flags().StringP("output", "o", "", "the file to write the private key")
+	configCmd.AddCommand(configSyncCmd, configSignCmd, configKeygenCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+// fetchBundle reads the bundle & its signature from the path or the URL,
+// the signature is nil when the bundle isn't signed
+func fetchBundle(source string) (b, sig []byte, err error) {
+	read := ioutil.ReadFile
+	if strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://") {
+		client := &http.Client{Timeout: 30 * time.Second}
+		read = func(url string) ([]byte, error) {
+			resp, err := client.Get(url)
+			if err != nil {
+				return nil, err
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode == http.StatusNotFound {
+				return nil, os.ErrNotExist
+			}
+			if resp.StatusCode != http.StatusOK {
+				return nil, fmt.Errorf("unexpected status %s", resp.Status)
+			}
+			return ioutil.ReadAll(resp.Body)
+		}
+	}
+
+	if b, err = read(source); err != nil {
+		return nil, nil, fmt.Errorf("failed to read the bundle %s, error: %v", source, err)
+	}
+	sig, err = read(source + config.BundleSigSuffix)
+	if errors.Is(err, os.ErrNotExist) {
+		return b, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read the signature %s, error: %v", source+config.BundleSigSuffix, err)
+	}
+	return b, sig, nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/adzimzf/tpot/ui"
+	"github.com/spf13/cobra"
+)
+
+const consoleExample = `
+tpot ui                // Open the console with every environment
+tpot ui prod -u admin  // Open the console on production & login as admin
+`
+
+var consoleCmd = &cobra.Command{
+	Use:     "ui [ENVIRONMENT]",
+	Short:   "Open the interactive console of every environment",
+	Long:    "Browse the environments & the hosts, see the login status & the search history and do the actions in one persistent window",
+	Example: consoleExample,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		c := &ui.Console{}
+		proxies, envs := consoleEnvs(cmd, cfg)
+		if len(envs) == 0 {
+			return withCode(exitConfig, fmt.Errorf("there's no environment, add one by tpot -c --add"))
+		}
+		c.Envs = envs
+
+		// the console starts where it's left last time
+		state := loadState()
+		env := state.LastEnv
+		if len(args) > 0 {
+			if _, ok := proxies[args[0]]; !ok {
+				return usageErrorf("Env %s not found", args[0])
+			}
+			env = args[0]
+		}
+		if _, ok := proxies[env]; ok {
+			c.SetEnv(env)
+			c.SetQuery(state.Envs[env].Filter)
+		}
+		c.HideColumns = state.HideColumns
+
+		// the environments are changed without closing the console,
+		// the maps are only replaced by the goroutine showing the console
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		config.Watch(ctx, config.WatchInterval, func(cfg *config.Config) {
+			reloaded, envs := consoleEnvs(nil, cfg)
+			keys, err := ui.NewKeyMap(cfg.Keybindings.Preset, cfg.Keybindings.Keys)
+			c.Queue(func() {
+				switch {
+				case err != nil:
+					c.Notice = fmt.Sprintf("invalid keybindings, error: %v", err)
+				case len(envs) == 0:
+					c.Notice = "the reloaded configuration has no environment, keeping the current one"
+				default:
+					proxies = reloaded
+					ui.Keys = keys
+					defaultPickerColumns = cfg.PickerColumns
+					c.SetEnvs(envs)
+					c.Notice = "the configuration is reloaded"
+				}
+			})
+		}, func(err error) {
+			c.Queue(func() { c.Notice = fmt.Sprintf("failed to reload the configuration, error: %v", err) })
+		})
+
+		for {
+			res, ok, err := c.Run()
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return nil
+			}
+
+			proxy := proxies[res.Env]
+			savePicked(res.Env, res.Query, c.HideColumns)
+			if res.Query != "" {
+				if err := proxy.AddSearchHistory(res.Query); err != nil {
+					cmd.PrintErrf("WARNING! failed to save the search history, error: %v\n", err)
+				}
+			}
+
+			errorEnv = proxy.Env
+			err = consoleAction(cmd, proxy, res)
+			if err != nil {
+				printError(cmd, err)
+			}
+			// the output of the action stays until the user goes back
+			if err != nil || (res.Action != ui.ActionSSH && res.Action != ui.ActionRefresh) {
+				waitEnter()
+			}
+			c.UpdateEnv(consoleEnv(proxy, defaultPickerColumns))
+		}
+	},
+}
+
+func init() {
+	consoleCmd.Flags().StringP("user", "u", "", "user to login to the nodes")
+	rootCmd.AddCommand(consoleCmd)
+}
+
+// consoleEnvs loads the proxies along with their node cache,
+// the warnings are only printed if the cmd is given
+func consoleEnvs(cmd *cobra.Command, cfg *config.Config) (map[string]*config.Proxy, []ui.ConsoleEnv) {
+	proxies := make(map[string]*config.Proxy, len(cfg.Proxies))
+	var envs []ui.ConsoleEnv
+	for _, p := range cfg.Proxies {
+		proxy, err := findProxy(nil, cfg, p.Env)
+		if err != nil {
+			if cmd != nil {
+				cmd.PrintErrf("WARNING! skipping %s, error: %v\n", p.Env, err)
+			}
+			continue
+		}
+		proxy.Node, _ = proxy.GetNode()
+		proxies[proxy.Env] = proxy
+		envs = append(envs, consoleEnv(proxy, cfg.PickerColumns))
+	}
+	return proxies, envs
+}
+
+// consoleAction does the action picked in the console
+func consoleAction(cmd *cobra.Command, proxy *config.Proxy, res ui.ConsoleResult) error {
+	switch res.Action {
+	case ui.ActionRefresh:
+		node, err := getLatestNode(proxy, false)
+		if err != nil {
+			return err
+		}
+		proxy.Node = node
+		return nil
+	case ui.ActionSSH:
+		return connect(cmd, proxy, tsh.NewTSH(proxy), res.Host, tsh.SessionOptions{})
+	}
+	return hostAction(cmd, proxy, res.Host, res.Action)
+}
+
+// consoleEnv describes the proxy for the console, the defaults
+// is the picker columns of the environment without its own
+func consoleEnv(proxy *config.Proxy, defaults []string) ui.ConsoleEnv {
+	env := ui.ConsoleEnv{
+		Name:  proxy.Env,
+		Badge: proxy.Badge,
+		Color: proxy.Color,
+		Hosts: proxy.Node.ListHostname(),
+		Notes: hostNotes(proxy),
+	}
+	env.Columns, env.HostWidth = hostColumns(proxy, defaults)
+
+	if s := proxy.Node.Status; s != nil {
+		env.Status = append(env.Status, fmt.Sprintf("Logged in as %s, logins: %s", s.LoginAs, strings.Join(s.UserLogins, ", ")))
+	}
+	if len(proxy.Node.Items) == 0 {
+		env.Status = append(env.Status, "No node is cached, press "+ui.Keys.Label(ui.BindRefresh)+" to refresh")
+	}
+
+	history, err := proxy.GetSearchHistory()
+	if err == nil {
+		env.History = history
+	}
+	return env
+}
+
+// waitEnter waits the user to read the action output
+func waitEnter() {
+	fmt.Fprint(os.Stderr, "\nPress ENTER to go back to the console")
+	bufio.NewReader(os.Stdin).ReadString('\n')
+}
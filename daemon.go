@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/spf13/cobra"
+)
+
+const daemonExample = `
+tpot daemon install                        // Keep tpot serve running across reboots with a random token
+tpot daemon install -l 127.0.0.1:7788      // Serve on another address
+tpot --profile work daemon install         // Serve the work profile
+`
+
+// daemonLabel is the name of the systemd unit & the launchd job
+const daemonLabel = "com.github.adzimzf.tpot"
+
+var daemonCmd = &cobra.Command{
+	Use:     "daemon",
+	Short:   "Run tpot serve as a background service",
+	Example: daemonExample,
+}
+
+var daemonInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install the user-level systemd unit or launchd agent of tpot serve",
+	Long: "Write the user-level systemd unit on Linux or the launchd agent on macOS to keep tpot serve running across reboots, " +
+		"the API token is kept by the unit with the owner only permission",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := loadConfig(cmd); err != nil {
+			return err
+		}
+
+		token, _ := cmd.Flags().GetString("token")
+		if token == "" {
+			var err error
+			if token, err = randomToken(); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "API token: %s\n", token)
+		}
+
+		exe, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to find the tpot binary, error: %v", err)
+		}
+		listen, _ := cmd.Flags().GetString("listen")
+		argv := append([]string{exe}, daemonArgs(cmd)...)
+		argv = append(argv, "serve", "--listen", listen)
+
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+
+		// the files are written in order, the unit is the last
+		var files [][2]string
+		var hint string
+		switch runtime.GOOS {
+		case "linux":
+			unit := filepath.Join(home, ".config", "systemd", "user", "tpot.service")
+			envFile := config.Dir + "serve.env"
+			files = [][2]string{
+				{envFile, "TPOT_API_TOKEN=" + token + "\n"},
+				{unit, systemdUnit(argv, envFile)},
+			}
+			hint = "systemctl --user daemon-reload && systemctl --user enable --now tpot"
+		case "darwin":
+			plist := filepath.Join(home, "Library", "LaunchAgents", daemonLabel+".plist")
+			files = [][2]string{{plist, launchdPlist(argv, token, config.CacheDir+"serve.log")}}
+			hint = "launchctl load -w " + plist
+		default:
+			return fmt.Errorf("daemon install isn't supported on %s, run tpot serve by the service manager instead", runtime.GOOS)
+		}
+
+		for _, f := range files {
+			path, content := f[0], f[1]
+			// nothing is really written on dry run
+			if tsh.DryRun {
+				fmt.Fprintf(tsh.DryRunOutput, "[dry-run] write %s\n%s\n", path, content)
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+				return fmt.Errorf("failed to write %s, error: %v", path, err)
+			}
+			infof(cmd, "%s is written\n", path)
+		}
+		infof(cmd, "start it now & on every login by running\n%s\n", hint)
+		return nil
+	},
+}
+
+func init() {
+	daemonInstallCmd.Flags().StringP("listen", "l", "127.0.0.1:7777", "the address to serve the API")
+	daemonInstallCmd.Flags().String("token", os.Getenv("TPOT_API_TOKEN"), "the bearer token of the API, default is $TPOT_API_TOKEN or a random one")
+	daemonCmd.AddCommand(daemonInstallCmd)
+	rootCmd.AddCommand(daemonCmd)
+}
+
+// daemonArgs returns the global flags the daemon must run with
+// to serve the same configuration as the install
+func daemonArgs(cmd *cobra.Command) []string {
+	var args []string
+	for _, name := range []string{"config-dir", "profile"} {
+		if v, _ := cmd.Flags().GetString(name); v != "" {
+			args = append(args, "--"+name, v)
+		}
+	}
+	if developer, _ := cmd.Flags().GetBool("developer"); developer {
+		args = append(args, "--developer")
+	}
+	return args
+}
+
+// systemdUnit returns the user-level systemd unit running the argv
+func systemdUnit(argv []string, envFile string) string {
+	quoted := make([]string, len(argv))
+	for i, arg := range argv {
+		quoted[i] = arg
+		if strings.ContainsAny(arg, " \t\"'\\") {
+			quoted[i] = strconv.Quote(arg)
+		}
+	}
+	return fmt.Sprintf(`# generated by tpot daemon install
+[Unit]
+Description=tpot local API
+After=network-online.target
+
+[Service]
+ExecStart=%s
+EnvironmentFile=%s
+Restart=on-failure
+RestartSec=5
+
+[Install]
+WantedBy=default.target
+`, strings.Join(quoted, " "), envFile)
+}
+
+// launchdPlist returns the launchd agent running the argv
+func launchdPlist(argv []string, token, logPath string) string {
+	var args bytes.Buffer
+	for _, arg := range argv {
+		fmt.Fprintf(&args, "\n        <string>%s</string>", xmlEscape(arg))
+	}
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+    <key>Label</key>
+    <string>%s</string>
+    <key>ProgramArguments</key>
+    <array>%s
+    </array>
+    <key>EnvironmentVariables</key>
+    <dict>
+        <key>TPOT_API_TOKEN</key>
+        <string>%s</string>
+    </dict>
+    <key>RunAtLoad</key>
+    <true/>
+    <key>KeepAlive</key>
+    <true/>
+    <key>StandardErrorPath</key>
+    <string>%s</string>
+</dict>
+</plist>
+`, daemonLabel, args.String(), xmlEscape(token), xmlEscape(logPath))
+}
+
+func xmlEscape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// servePIDPath is the PID file of tpot serve
+func servePIDPath() string {
+	return config.CacheDir + "serve.pid"
+}
+
+// acquirePIDFile writes the PID file, it fails if another process holding
+// the file is still running, the stale file of the dead one is taken over
+func acquirePIDFile(path string) (func(), error) {
+	if b, err := ioutil.ReadFile(path); err == nil {
+		if pid, err := strconv.Atoi(strings.TrimSpace(string(b))); err == nil && pid != os.Getpid() && processAlive(pid) {
+			return nil, fmt.Errorf("tpot serve is already running by pid %d, remove %s if it isn't", pid, path)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write the PID file %s, error: %v", path, err)
+	}
+	return func() { os.Remove(path) }, nil
+}
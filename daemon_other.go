@@ -0,0 +1,17 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package main
+
+import "os"
+
+// processAlive returns true if the process of the pid is running,
+// FindProcess opens the process handle which fails once it's gone
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
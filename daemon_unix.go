@@ -0,0 +1,20 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// processAlive returns true if the process of the pid is running
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// the signal 0 only checks the process exists
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || err == syscall.EPERM
+}
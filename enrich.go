@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/enrich"
+	"github.com/adzimzf/tpot/trace"
+	"github.com/spf13/cobra"
+)
+
+const enrichExample = `
+tpot enrich prod                                        // Look up the cached production hosts from the inventories
+tpot enrich prod --show                                 // Show the metadata of the production hosts enriched last time
+tpot exec prod "uptime" --filter 'meta:team=payments'   // Run uptime on the hosts owned by the payments team
+`
+
+// enrichSource is the inventory of the environments matching the globs
+type enrichSource struct {
+	enrich.Source
+	envs []string
+}
+
+// enrichSources is the inventories of enrichment set once the config is loaded
+var enrichSources []enrichSource
+
+var enrichCmd = &cobra.Command{
+	Use:   "enrich <ENVIRONMENT>",
+	Short: "Enrich the cached nodes with the metadata of the inventories such as Netbox",
+	Long: `Look up the owner team, the rack, the site & the service of the cached nodes from the inventories of
+the enrichment configuration, the nodes are enriched on every refresh too. The metadata is shown by tpot info
+& the meta.<key> picker columns, and it's matched by the meta:<key>=<pattern> term of the filter expression`,
+	Example: enrichExample,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return usageErrorf("ENVIRONMENT is required")
+		}
+		proxy, err := loadProxy(cmd, args[0])
+		if err != nil {
+			return err
+		}
+		if show, _ := cmd.Flags().GetBool("show"); show {
+			return showHostMeta(proxy)
+		}
+
+		sources := envEnrichSources(proxy.Env)
+		if len(sources) == 0 {
+			return withCode(exitConfig, fmt.Errorf("there's no enrichment of %s in the configuration", proxy.Env))
+		}
+		n, err := enrichNodes(proxy, proxy.Node.Items, sources)
+		if err != nil {
+			return err
+		}
+		infof(cmd, "%d of %d hosts of %s are enriched\n", n, len(proxy.Node.Items), proxy.Env)
+		return nil
+	},
+}
+
+func init() {
+	enrichCmd.Flags().Bool("show", false, "show the metadata enriched last time without looking up the inventories")
+	rootCmd.AddCommand(enrichCmd)
+}
+
+// newEnrichSources creates the inventories of the enrichment configuration
+func newEnrichSources(enrichments []config.Enrichment) ([]enrichSource, error) {
+	res := make([]enrichSource, 0, len(enrichments))
+	for _, e := range enrichments {
+		var s enrich.Source
+		switch e.Type {
+		case config.EnrichNetbox:
+			if e.URL == "" {
+				return nil, fmt.Errorf("the url of the netbox enrichment is missing")
+			}
+			n := enrich.NewNetbox(e.URL, e.NetboxToken())
+			n.ServiceField = e.ServiceField
+			s = n
+		case config.EnrichHTTP:
+			h, err := enrich.NewHTTP(e.URL, e.Headers, e.Fields)
+			if err != nil {
+				return nil, err
+			}
+			s = h
+		default:
+			return nil, fmt.Errorf("unknown enrichment type %q, use netbox or http", e.Type)
+		}
+		res = append(res, enrichSource{Source: s, envs: e.Envs})
+	}
+	return res, nil
+}
+
+// envEnrichSources returns the inventories enriching the env
+func envEnrichSources(env string) []enrich.Source {
+	var res []enrich.Source
+	for _, s := range enrichSources {
+		if enrich.Matches(s.envs, env) {
+			res = append(res, s.Source)
+		}
+	}
+	return res
+}
+
+// enrichNodes looks up the items from the sources & replaces the metadata
+// of the env, it returns how many items are known by the inventories
+func enrichNodes(proxy *config.Proxy, items []config.Item, sources []enrich.Source) (int, error) {
+	hosts := make([]string, len(items))
+	for i, item := range items {
+		hosts[i] = item.Hostname
+	}
+	names := make([]string, len(sources))
+	for i, s := range sources {
+		names[i] = s.Name()
+	}
+
+	step := trace.Start("enrich %d hosts of %s from %s", len(hosts), proxy.Env, strings.Join(names, ", "))
+	meta, err := enrich.Lookup(sources, hosts)
+	step.End(err)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	res := make(map[string]config.HostMeta, len(meta))
+	for host, m := range meta {
+		res[host] = config.HostMeta{Meta: m, Source: strings.Join(names, ","), EnrichedAt: now}
+	}
+	if err := proxy.SetHostMeta(res); err != nil {
+		return 0, fmt.Errorf("failed to save the metadata of %s, error: %v", proxy.Env, err)
+	}
+	return len(res), nil
+}
+
+// enrichRefreshed enriches the refreshed nodes, failing to enrich them
+// must not fail the refresh, hence only warn
+func enrichRefreshed(proxy *config.Proxy, items []config.Item) {
+	sources := envEnrichSources(proxy.Env)
+	if len(sources) == 0 {
+		return
+	}
+	if _, err := enrichNodes(proxy, items, sources); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING! failed to enrich the nodes of %s, error: %v\n", proxy.Env, err)
+	}
+}
+
+// showHostMeta prints the metadata of the enriched hosts, the keys are the columns
+func showHostMeta(proxy *config.Proxy) error {
+	meta, err := proxy.GetHostMeta()
+	if err != nil {
+		return fmt.Errorf("failed to load the metadata of %s, error: %v", proxy.Env, err)
+	}
+	if len(meta) == 0 {
+		return fmt.Errorf("there's no metadata of %s, run tpot enrich %s to look it up", proxy.Env, proxy.Env)
+	}
+
+	hosts := make([]string, 0, len(meta))
+	seen := map[string]bool{}
+	var keys []string
+	for host, m := range meta {
+		hosts = append(hosts, host)
+		for k := range m.Meta {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(hosts)
+	sort.Strings(keys)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "HOST\t%s\n", strings.ToUpper(strings.Join(keys, "\t")))
+	for _, host := range hosts {
+		row := make([]string, len(keys))
+		for i, k := range keys {
+			if row[i] = meta[host].Meta[k]; row[i] == "" {
+				row[i] = "-"
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\n", host, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// printHostMeta prints the enriched metadata of the host under its facts
+func printHostMeta(proxy *config.Proxy, host string) {
+	all, err := proxy.GetHostMeta()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING! failed to load the host metadata, error: %v\n", err)
+		return
+	}
+	meta, ok := all[host]
+	if !ok || len(meta.Meta) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(meta.Meta))
+	for k := range meta.Meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "metadata\t(enriched from %s %s ago)\n", meta.Source, time.Since(meta.EnrichedAt).Round(time.Second))
+	for _, k := range keys {
+		fmt.Fprintf(w, "  %s\t%s\n", k, meta.Meta[k])
+	}
+	w.Flush()
+}
@@ -0,0 +1,118 @@
+// Package enrich looks up the metadata of the hosts from the inventory such
+// as Netbox, like the owner team, the rack, the site & the service
+package enrich
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the metadata keys looked up by every source
+const (
+	KeyTeam    = "team"
+	KeySite    = "site"
+	KeyRack    = "rack"
+	KeyService = "service"
+)
+
+// Source looks up the metadata of the hosts from an inventory
+type Source interface {
+	// Name is the name shown along with the metadata, such as netbox
+	Name() string
+
+	// Lookup returns the metadata of the hosts by the hostname,
+	// the hosts unknown by the inventory are left out
+	Lookup(hosts []string) (map[string]map[string]string, error)
+}
+
+// timeout is how long a request to the inventory may take
+const timeout = 10 * time.Second
+
+// Matches returns true if the env matches any of the globs,
+// no glob means every environment
+func Matches(envs []string, env string) bool {
+	if len(envs) == 0 {
+		return true
+	}
+	for _, glob := range envs {
+		if ok, _ := path.Match(glob, env); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Lookup looks up the hosts from the sources in order, the
+// metadata of the later source overrides the earlier one
+func Lookup(sources []Source, hosts []string) (map[string]map[string]string, error) {
+	res := make(map[string]map[string]string)
+	for _, s := range sources {
+		meta, err := s.Lookup(hosts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up the hosts from %s, error: %v", s.Name(), err)
+		}
+		for host, m := range meta {
+			if res[host] == nil {
+				res[host] = make(map[string]string, len(m))
+			}
+			for k, v := range m {
+				res[host][k] = v
+			}
+		}
+	}
+	return res, nil
+}
+
+// getJSON gets the url & decodes the JSON response into v
+func getJSON(client *http.Client, url string, headers map[string]string, v interface{}) (int, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("http code: %d", resp.StatusCode)
+	}
+	return resp.StatusCode, json.NewDecoder(resp.Body).Decode(v)
+}
+
+// field returns the value of the dotted path in the decoded JSON such as
+// owner.team or tags.0, ok is false when it isn't found or isn't a scalar
+func field(v interface{}, dotted string) (string, bool) {
+	for _, key := range strings.Split(dotted, ".") {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			v = node[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return "", false
+			}
+			v = node[i]
+		default:
+			return "", false
+		}
+	}
+	switch val := v.(type) {
+	case string:
+		return val, val != ""
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(val), true
+	}
+	return "", false
+}
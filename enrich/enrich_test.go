@@ -0,0 +1,151 @@
+package enrich
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetbox_Lookup(t *testing.T) {
+	devices := map[string]interface{}{
+		"web-01": map[string]interface{}{
+			"name":          "web-01",
+			"tenant":        map[string]string{"name": "payments"},
+			"site":          map[string]string{"name": "ams1"},
+			"rack":          map[string]string{"name": "R12"},
+			"device_role":   map[string]string{"name": "web"},
+			"custom_fields": map[string]interface{}{"service": "checkout", "tier": 1},
+		},
+	}
+	vms := map[string]interface{}{
+		"web-01": map[string]interface{}{"name": "web-01", "tenant": map[string]string{"name": "wrong"}},
+		"web-02": map[string]interface{}{
+			"name":    "web-02",
+			"tenant":  map[string]string{"name": "search"},
+			"cluster": map[string]string{"name": "k8s-ams"},
+			"role":    map[string]string{"name": "web"},
+		},
+	}
+	var vmQueries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Token secret", r.Header.Get("Authorization"))
+		objects := devices
+		if strings.HasPrefix(r.URL.Path, "/api/virtualization/") {
+			objects = vms
+			vmQueries = append(vmQueries, r.URL.Query()["name"]...)
+		}
+		var results []interface{}
+		for _, name := range r.URL.Query()["name"] {
+			if o, ok := objects[name]; ok {
+				results = append(results, o)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"results": results})
+	}))
+	defer srv.Close()
+
+	got, err := NewNetbox(srv.URL+"/", "secret").Lookup([]string{"web-01", "web-02", "db-01"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{
+		"web-01": {"team": "payments", "site": "ams1", "rack": "R12", "role": "web", "service": "checkout"},
+		"web-02": {"team": "search", "cluster": "k8s-ams", "role": "web"},
+	}, got)
+	// the devices aren't looked up again as the virtual machines
+	assert.Equal(t, []string{"web-02", "db-01"}, vmQueries)
+}
+
+func TestNetbox_Lookup_pages(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/virtualization/") {
+			_, _ = w.Write([]byte(`{"results": []}`))
+			return
+		}
+		if r.URL.Query().Get("offset") == "" {
+			_, _ = w.Write([]byte(`{"next": "` + srv.URL + `/api/dcim/devices/?offset=1", "results": [{"name": "a", "site": {"name": "s1"}}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"results": [{"name": "b", "site": {"name": "s2"}}]}`))
+	}))
+	defer srv.Close()
+
+	got, err := NewNetbox(srv.URL, "").Lookup([]string{"a", "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{"a": {"site": "s1"}, "b": {"site": "s2"}}, got)
+}
+
+func TestHTTP_Lookup(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer x", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/hosts/web-01":
+			_, _ = w.Write([]byte(`{"owner": {"team": "payments"}, "location": {"rack": 12}, "services": ["checkout", "cart"]}`))
+		case "/hosts/web-02":
+			_, _ = w.Write([]byte(`{"owner": {}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	h, err := NewHTTP(srv.URL+"/hosts/{host}", map[string]string{"Authorization": "Bearer x"},
+		map[string]string{"team": "owner.team", "rack": "location.rack", "service": "services.0"})
+	assert.NoError(t, err)
+	got, err := h.Lookup([]string{"web-01", "web-02", "db-01"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{
+		"web-01": {"team": "payments", "rack": "12", "service": "checkout"},
+	}, got)
+}
+
+func TestHTTP_Lookup_error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	h, err := NewHTTP(srv.URL+"/{host}", nil, map[string]string{"team": "team"})
+	assert.NoError(t, err)
+	_, err = h.Lookup([]string{"web-01"})
+	assert.EqualError(t, err, "web-01: http code: 500")
+}
+
+func TestNewHTTP(t *testing.T) {
+	_, err := NewHTTP("https://cmdb/hosts", nil, map[string]string{"team": "team"})
+	assert.Error(t, err)
+	_, err = NewHTTP("https://cmdb/hosts/{host}", nil, nil)
+	assert.Error(t, err)
+	h, err := NewHTTP("https://cmdb.example.com/hosts/{host}", nil, map[string]string{"team": "team"})
+	assert.NoError(t, err)
+	assert.Equal(t, "cmdb.example.com", h.Name())
+}
+
+type fakeSource struct {
+	meta map[string]map[string]string
+}
+
+func (f fakeSource) Name() string { return "fake" }
+
+func (f fakeSource) Lookup([]string) (map[string]map[string]string, error) { return f.meta, nil }
+
+func TestLookup(t *testing.T) {
+	got, err := Lookup([]Source{
+		fakeSource{meta: map[string]map[string]string{"web-01": {"team": "a", "site": "ams1"}}},
+		fakeSource{meta: map[string]map[string]string{"web-01": {"team": "b"}, "db-01": {"team": "c"}}},
+	}, []string{"web-01", "db-01"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{
+		"web-01": {"team": "b", "site": "ams1"},
+		"db-01":  {"team": "c"},
+	}, got)
+}
+
+func TestMatches(t *testing.T) {
+	assert.True(t, Matches(nil, "prod"))
+	assert.True(t, Matches([]string{"staging", "prod*"}, "prod-eu"))
+	assert.False(t, Matches([]string{"staging"}, "prod"))
+}
@@ -0,0 +1,104 @@
+package enrich
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// hostPlaceholder is replaced by the hostname in the URL of the HTTP source
+const hostPlaceholder = "{host}"
+
+// httpConcurrency is how many hosts the HTTP source looks up at once
+const httpConcurrency = 8
+
+// HTTP looks up every host from a JSON API such as the CMDB, the URL has the
+// {host} placeholder & Fields maps the metadata key to the dotted JSON path
+type HTTP struct {
+	URL     string
+	Headers map[string]string
+	Fields  map[string]string
+	Client  *http.Client
+}
+
+// NewHTTP creates the HTTP source of the URL such as
+// https://cmdb.example.com/api/hosts/{host}
+func NewHTTP(endpoint string, headers, fields map[string]string) (*HTTP, error) {
+	if !strings.Contains(endpoint, hostPlaceholder) {
+		return nil, fmt.Errorf("the url %s of the http enrichment has no %s", endpoint, hostPlaceholder)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("the http enrichment %s has no fields", endpoint)
+	}
+	return &HTTP{
+		URL:     endpoint,
+		Headers: headers,
+		Fields:  fields,
+		Client:  &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Name returns the host of the URL
+func (h *HTTP) Name() string {
+	if u, err := url.Parse(strings.Replace(h.URL, hostPlaceholder, "host", -1)); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return h.URL
+}
+
+// Lookup looks up the hosts concurrently, the host answered by
+// 404 is unknown, any other failure fails the lookup
+func (h *HTTP) Lookup(hosts []string) (map[string]map[string]string, error) {
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs []string
+		sem  = make(chan struct{}, httpConcurrency)
+		res  = make(map[string]map[string]string)
+	)
+	for _, host := range hosts {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(host string) {
+			defer func() { <-sem; wg.Done() }()
+			meta, err := h.lookup(host)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", host, err))
+			} else if len(meta) > 0 {
+				res[host] = meta
+			}
+		}(host)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return nil, errors.New(strings.Join(errs, "; "))
+	}
+	return res, nil
+}
+
+func (h *HTTP) lookup(host string) (map[string]string, error) {
+	var doc interface{}
+	endpoint := strings.Replace(h.URL, hostPlaceholder, url.PathEscape(host), -1)
+	code, err := getJSON(h.Client, endpoint, h.Headers, &doc)
+	if code == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]string, len(h.Fields))
+	for key, dotted := range h.Fields {
+		if v, ok := field(doc, dotted); ok {
+			res[key] = v
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,140 @@
+package enrich
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// netboxBatch is how many hosts are looked up by a Netbox request,
+// the query string stays short enough for the usual proxies
+const netboxBatch = 50
+
+// netboxEndpoints is the Netbox objects the hosts are looked up from,
+// the hosts are either the devices or the virtual machines
+var netboxEndpoints = []string{"/api/dcim/devices/", "/api/virtualization/virtual-machines/"}
+
+// Netbox looks up the hosts by the name of the Netbox devices & virtual
+// machines. The team is the tenant & the service is the custom field
+type Netbox struct {
+	URL   string
+	Token string
+
+	// ServiceField is the custom field of the service, it's service when empty
+	ServiceField string
+
+	Client *http.Client
+}
+
+// NewNetbox creates the Netbox source of the URL such as https://netbox.example.com
+func NewNetbox(endpoint, token string) *Netbox {
+	return &Netbox{
+		URL:    strings.TrimSuffix(endpoint, "/"),
+		Token:  token,
+		Client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Name returns netbox
+func (n *Netbox) Name() string {
+	return "netbox"
+}
+
+// netboxRef is the nested object of a Netbox object such as the site
+type netboxRef struct {
+	Name string `json:"name"`
+}
+
+// netboxObject is the device or the virtual machine, the role is
+// device_role before Netbox 3.6
+type netboxObject struct {
+	Name         string                 `json:"name"`
+	Tenant       *netboxRef             `json:"tenant"`
+	Site         *netboxRef             `json:"site"`
+	Rack         *netboxRef             `json:"rack"`
+	Role         *netboxRef             `json:"role"`
+	DeviceRole   *netboxRef             `json:"device_role"`
+	Platform     *netboxRef             `json:"platform"`
+	Cluster      *netboxRef             `json:"cluster"`
+	CustomFields map[string]interface{} `json:"custom_fields"`
+}
+
+type netboxPage struct {
+	Next    string         `json:"next"`
+	Results []netboxObject `json:"results"`
+}
+
+// Lookup looks up the hosts in batches, the host found as a device
+// isn't looked up as a virtual machine
+func (n *Netbox) Lookup(hosts []string) (map[string]map[string]string, error) {
+	res := make(map[string]map[string]string)
+	for _, endpoint := range netboxEndpoints {
+		var missing []string
+		for _, host := range hosts {
+			if _, ok := res[host]; !ok {
+				missing = append(missing, host)
+			}
+		}
+		for len(missing) > 0 {
+			batch := missing
+			if len(batch) > netboxBatch {
+				batch = batch[:netboxBatch]
+			}
+			missing = missing[len(batch):]
+			if err := n.lookup(endpoint, batch, res); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return res, nil
+}
+
+// lookup looks up the batch of the hosts from the endpoint following the pages
+func (n *Netbox) lookup(endpoint string, hosts []string, res map[string]map[string]string) error {
+	q := url.Values{"name": hosts, "limit": {strconv.Itoa(len(hosts))}}
+	next := n.URL + endpoint + "?" + q.Encode()
+	headers := map[string]string{}
+	if n.Token != "" {
+		headers["Authorization"] = "Token " + n.Token
+	}
+	for next != "" {
+		var page netboxPage
+		if _, err := getJSON(n.Client, next, headers, &page); err != nil {
+			return err
+		}
+		for _, o := range page.Results {
+			if _, ok := res[o.Name]; !ok && o.Name != "" {
+				res[o.Name] = n.meta(o)
+			}
+		}
+		next = page.Next
+	}
+	return nil
+}
+
+// meta returns the metadata of the Netbox object, the empty ones are left out
+func (n *Netbox) meta(o netboxObject) map[string]string {
+	res := make(map[string]string)
+	set := func(key string, ref *netboxRef) {
+		if ref != nil && ref.Name != "" {
+			res[key] = ref.Name
+		}
+	}
+	set(KeyTeam, o.Tenant)
+	set(KeySite, o.Site)
+	set(KeyRack, o.Rack)
+	set("role", o.DeviceRole)
+	set("role", o.Role)
+	set("platform", o.Platform)
+	set("cluster", o.Cluster)
+
+	service := n.ServiceField
+	if service == "" {
+		service = KeyService
+	}
+	if v, ok := field(o.CustomFields, service); ok {
+		res[KeyService] = v
+	}
+	return res
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"errors"
+	"os/exec"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/hint"
+	"github.com/adzimzf/tpot/i18n"
+	"github.com/adzimzf/tpot/trace"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/adzimzf/tpot/ui"
+	"github.com/spf13/cobra"
+)
+
+// the exit codes of tpot. The failed ssh session or remote command on a
+// single node is the exception, it exits with the exit code of the remote
+// command like ssh does, so any of them may come from the node instead
+const (
+	exitOK        = 0
+	exitFailure   = 1
+	exitUsage     = 2
+	exitConfig    = 3
+	exitLogin     = 4
+	exitCrash     = 70
+	exitCancelled = 130
+)
+
+// codeError is the error along with the exit code of tpot
+type codeError struct {
+	code int
+	err  error
+
+	// usage shows the command usage after the error
+	usage bool
+	// silent doesn't print the error since it's already shown,
+	// such as the output of the failed remote command
+	silent bool
+}
+
+func (e *codeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *codeError) Unwrap() error {
+	return e.err
+}
+
+// withCode sets the exit code of the error
+func withCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &codeError{code: code, err: err}
+}
+
+// usageErrorf is the error of the invalid argument or flag, the format
+// is translated & the usage of the command is shown along with the error
+func usageErrorf(format string, a ...interface{}) error {
+	return &codeError{code: exitUsage, err: i18n.Errorf(format, a...), usage: true}
+}
+
+// loginError is the error of logging in to the proxy
+func loginError(err error) error {
+	return withCode(exitLogin, i18n.Errorf("failed to login, error: %w", err))
+}
+
+// errNoHost is returned when the picker is closed without picking a host
+var errNoHost = withCode(exitCancelled, i18n.Error("Pick at least one host to login"))
+
+// sessionError passes through the exit code of the remote session,
+// tsh has already printed the reason into the stderr
+func sessionError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		return &codeError{code: exitErr.ExitCode(), err: err, silent: true}
+	}
+	return err
+}
+
+// exitCode returns the exit code of tpot by the error
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	var e *codeError
+	if errors.As(err, &e) {
+		return e.code
+	}
+	return exitFailure
+}
+
+// errorEnv is the environment of the command, it fills the
+// suggested commands of the hint printed under the error
+var errorEnv string
+
+// printError prints the error returned by the command
+// along with the hint of the common tsh & proxy errors
+func printError(cmd *cobra.Command, err error) {
+	var e *codeError
+	silent := errors.As(err, &e) && e.silent
+	if !silent {
+		cmd.PrintErrln(err)
+	}
+	printHint(cmd, err, silent)
+	if errors.As(err, &e) && e.usage {
+		cmd.PrintErrln()
+		cmd.Help()
+	}
+}
+
+// printHint prints the hint of the error, the silent error only
+// has the ERROR line of tsh since the rest is the remote command output
+func printHint(cmd *cobra.Command, err error, silent bool) {
+	msg := ""
+	if !silent {
+		msg = err.Error()
+	}
+	var tshErr *tsh.Error
+	if errors.As(err, &tshErr) {
+		msg += "\n" + tshErr.Message
+	}
+	h, ok := hint.For(msg, errorEnv)
+	if !ok {
+		return
+	}
+	cmd.PrintErrf("%s %s\n", ui.Colorize(i18n.T("hint:"), "yellow"), i18n.T(h.Message))
+	for _, c := range h.Commands {
+		cmd.PrintErrf("%s  %s\n", ui.Colorize(i18n.T("try:"), "yellow"), c)
+	}
+}
+
+// findProxy finds the proxy of the env, the unknown env is a usage error,
+// the flag defaults of the env are applied into the cmd unless it's nil
+func findProxy(cmd *cobra.Command, cfg *config.Config, env string) (*config.Proxy, error) {
+	proxy, err := cfg.FindProxy(env)
+	if errors.Is(err, config.ErrEnvNotFound) {
+		return nil, usageErrorf("Env %s not found", env)
+	}
+	if err != nil {
+		return nil, withCode(exitConfig, err)
+	}
+	if cmd != nil {
+		errorEnv = proxy.Env
+		if err := applyFlagDefaults(cmd, proxy.Flags); err != nil {
+			return nil, err
+		}
+		if err := readTSHFlags(cmd); err != nil {
+			return nil, err
+		}
+	}
+	return proxy, nil
+}
+
+// loadProxy loads the proxy of the env along with its cached nodes
+func loadProxy(cmd *cobra.Command, env string) (*config.Proxy, error) {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return nil, err
+	}
+	proxy, err := findProxy(cmd, cfg, env)
+	if err != nil {
+		return nil, err
+	}
+	step := trace.Start("read the node cache of %s", proxy.Env)
+	node, err := proxy.GetNode()
+	step.End(err)
+	if err != nil {
+		return nil, i18n.Errorf("failed to load nodes %v,\nyour might need -r to refresh/add the node cache", err)
+	}
+	proxy.Node = node
+	if err := narrowCIDR(cmd, proxy); err != nil {
+		return nil, err
+	}
+	return proxy, nil
+}
@@ -0,0 +1,702 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/adzimzf/tpot/audit"
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/i18n"
+	"github.com/adzimzf/tpot/shell"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/adzimzf/tpot/ui"
+	"github.com/spf13/cobra"
+)
+
+const execExample = `
+tpot exec prod "uptime"                                    // Run uptime on the selected production host
+tpot exec prod "uptime" --filter 'web-*'                   // Run uptime on every production web host
+tpot exec prod "uptime" --filter 'web-*' --failover        // Run uptime on the first healthy production web host
+grep web hosts.txt | tpot exec prod "uptime" --stdin       // Run uptime on the production hosts listed in hosts.txt
+tpot exec prod "df -h" --filter 'web-*' --output-dir out   // Save the output & exit code of every host into out
+tpot exec prod "df -h" --filter 'web-*' --format json      // Print the results as JSON for pipelines
+tpot exec prod "sudo systemctl restart app" --filter 'web-*' --batch-size 2 --batch-delay 30s  // Restart 2 web hosts at a time
+tpot exec prod "uptime" --filter 'web-*' -p 10             // Run uptime on 10 web hosts concurrently
+tpot exec prod "./deploy.sh" --filter 'web-*' --canary 1    // Deploy to a web host first & confirm before the rest
+tpot exec prod "systemctl restart app" --filter 'web-*' --sudo  // Restart the app as root on every web host
+tpot exec prod "cat ~/.env" --sudo=deploy --sudo-password   // Run as deploy through sudo with the password prompted once
+`
+
+var execCmd = &cobra.Command{
+	Use:     "exec <ENVIRONMENT> <COMMAND>",
+	Short:   "Run a command on one or many nodes",
+	Long:    "Run a command on the selected node or on every node match the --filter & report the result of every node",
+	Example: execExample,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return usageErrorf("ENVIRONMENT & COMMAND are required")
+		}
+
+		proxy, err := loadProxy(cmd, args[0])
+		if err != nil {
+			return err
+		}
+
+		var opts execOptions
+		opts.filter, _ = cmd.Flags().GetString("filter")
+		opts.failover, _ = cmd.Flags().GetBool("failover")
+		opts.stdin, _ = cmd.Flags().GetBool("stdin")
+		opts.outputDir, _ = cmd.Flags().GetString("output-dir")
+		opts.format, _ = cmd.Flags().GetString("format")
+		opts.timeout, _ = cmd.Flags().GetDuration("timeout")
+		opts.parallel, _ = cmd.Flags().GetInt("parallel")
+		opts.batchSize, _ = cmd.Flags().GetInt("batch-size")
+		opts.batchDelay, _ = cmd.Flags().GetDuration("batch-delay")
+		opts.canary, _ = cmd.Flags().GetInt("canary")
+		opts.sudo = sudoUser(cmd)
+		opts.sudoPassword, _ = cmd.Flags().GetBool("sudo-password")
+		return execHandler(cmd, proxy, args[1], opts)
+	},
+}
+
+func init() {
+	execCmd.Flags().String("filter", "", "run on every node match the filter expression instead of picking one")
+	addCIDRFlag(execCmd)
+	execCmd.Flags().Bool("failover", false, "retry on the next filtered node until the command succeeds")
+	execCmd.Flags().Bool("stdin", false, "run on the hostnames read from the stdin instead of picking one")
+	execCmd.Flags().String("output-dir", "", "save the stdout, stderr & exit code of every node into the directory")
+	execCmd.Flags().String("format", "text", "the result format, text or json")
+	execCmd.Flags().Duration("timeout", 0, "maximum time to wait for each node, 0 means no timeout")
+	execCmd.Flags().IntP("parallel", "p", 1, "number of nodes to run concurrently")
+	execCmd.Flags().Int("batch-size", 0, "run the nodes in batches of the size one after another, 0 means all at once")
+	execCmd.Flags().Duration("batch-delay", 0, "time to wait between the batches")
+	execCmd.Flags().Int("canary", 0, "run on the first n nodes & ask to continue before running on the rest")
+	execCmd.Flags().StringP("user", "u", "", "user to login to the nodes")
+	addSudoFlags(execCmd)
+	addMaintenanceFlag(execCmd)
+	rootCmd.AddCommand(execCmd)
+}
+
+// execOptions controls how the command is run on the hosts
+type execOptions struct {
+	filter   string
+	failover bool
+
+	// stdin reads the hosts from the stdin instead of the filter or picker
+	stdin bool
+
+	outputDir string
+	format    string
+	timeout   time.Duration
+
+	// parallel is the number of hosts run at the same time
+	parallel int
+	// batchSize splits the hosts into batches run one after another,
+	// 0 means a single batch
+	batchSize  int
+	batchDelay time.Duration
+
+	// canary is the number of hosts run first before
+	// asking to continue to the rest of the hosts
+	canary int
+
+	// sudo is the user to run the command as through sudo,
+	// sudoPassword prompts the sudo password once for all the hosts
+	sudo         string
+	sudoPassword bool
+}
+
+// execHandler runs the command on the picked host or the filtered hosts
+func execHandler(cmd *cobra.Command, proxy *config.Proxy, command string, opts execOptions) error {
+	if opts.format != "" && opts.format != "text" && opts.format != "json" {
+		return usageErrorf("unsupported format %s, use text or json", opts.format)
+	}
+
+	if opts.failover && opts.canary > 0 {
+		return usageErrorf("--canary can't be used along with --failover")
+	}
+
+	if opts.sudoPassword && opts.sudo == "" {
+		return usageErrorf("--sudo-password needs --sudo")
+	}
+
+	if opts.stdin && opts.filter != "" {
+		return usageErrorf("--stdin can't be used along with --filter")
+	}
+
+	var hosts []string
+	if opts.stdin {
+		var err error
+		if hosts, err = readHosts(os.Stdin, proxy); err != nil {
+			return err
+		}
+		if hosts, err = skipMaintenance(cmd, proxy, hosts); err != nil {
+			return err
+		}
+	} else if opts.filter != "" {
+		items, err := proxy.FilterNodes(opts.filter)
+		if err != nil {
+			return usageErrorf("invalid --filter, error: %v", err)
+		}
+		hosts = proxy.Node.NamesOf(items)
+		sort.Strings(hosts)
+		if len(hosts) == 0 {
+			return fmt.Errorf("there's no host match %s", opts.filter)
+		}
+		if hosts, err = skipMaintenance(cmd, proxy, hosts); err != nil {
+			return err
+		}
+	} else {
+		if opts.failover {
+			return usageErrorf("--failover needs --filter or --stdin to know the next hosts")
+		}
+		host, _ := selectHost(proxy, false)
+		if host == "" {
+			return errNoHost
+		}
+		hosts = []string{host}
+	}
+
+	return execOnHosts(cmd, proxy, hosts, command, opts)
+}
+
+// readHosts reads the hostnames separated by the whitespaces, the line
+// starting with # is skipped, all the hosts must be in the node cache
+func readHosts(r io.Reader, proxy *config.Proxy) ([]string, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the hosts, error: %v", err)
+	}
+
+	var hosts, unknown []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(string(b), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		for _, host := range strings.Fields(line) {
+			if seen[host] {
+				continue
+			}
+			seen[host] = true
+			if _, ok := proxy.Node.LookUp(host); !ok {
+				unknown = append(unknown, host)
+				continue
+			}
+			hosts = append(hosts, host)
+		}
+	}
+
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("%d hosts are not found in the %s node cache, refresh it by -r if they're new: %s",
+			len(unknown), proxy.Env, strings.Join(unknown, ", "))
+	}
+	if len(hosts) == 0 {
+		return nil, usageErrorf("there's no host read from the stdin")
+	}
+	return hosts, nil
+}
+
+// execOnHosts runs the command on the hosts
+func execOnHosts(cmd *cobra.Command, proxy *config.Proxy, hosts []string, command string, opts execOptions) error {
+	if err := guardReadOnly(proxy, "exec", hosts...); err != nil {
+		return err
+	}
+	if err := guardExec(proxy, command, hosts...); err != nil {
+		return err
+	}
+
+	if opts.outputDir != "" {
+		if err := os.MkdirAll(opts.outputDir, os.ModePerm); err != nil {
+			return err
+		}
+	}
+
+	user, err := getUserLogin(cmd, &proxy.Node)
+	if err != nil {
+		return err
+	}
+
+	step := config.RunbookStep{Env: proxy.Env, Filter: opts.filter, User: user, Exec: command, Sudo: opts.sudo}
+	if opts.filter == "" {
+		step.Hosts = hosts
+	}
+	recordStep(step)
+
+	r := &execRunner{
+		tsh:     tsh.NewTSH(proxy),
+		env:     proxy.Env,
+		user:    user,
+		command: command,
+		opts:    opts,
+		stdout:  os.Stdout,
+		stderr:  os.Stderr,
+	}
+	target := hosts[0]
+	if len(hosts) > 1 {
+		target = fmt.Sprintf("%d hosts", len(hosts))
+	}
+	if err := openSession(cmd, proxy, r.tsh, target, hosts...); err != nil {
+		return err
+	}
+	if opts.sudoPassword && !tsh.DryRun {
+		if r.sudoPassword, err = ui.Password(i18n.Sprintf("[sudo] password of %s", user)); err != nil {
+			return err
+		}
+	}
+	auditEvent(audit.Event{Action: audit.ActionLogin, Env: proxy.Env})
+
+	var results []execResult
+	var stopped error
+	switch {
+	case opts.failover:
+		results = r.runFailover(hosts)
+	case opts.canary > 0 && opts.canary < len(hosts):
+		results, stopped = r.runCanary(hosts)
+		if stopped != nil && !errors.Is(stopped, errCanaryStopped) {
+			return stopped
+		}
+	default:
+		results = r.runAll(hosts)
+	}
+
+	if opts.format == "json" {
+		if err := printExecJSON(r.stdout, command, results); err != nil {
+			return err
+		}
+		return stopped
+	}
+	if stopped != nil {
+		return stopped
+	}
+	if len(results) > 1 {
+		printExecSummary(r.stdout, results)
+	}
+
+	return execError(results, opts.failover)
+}
+
+// execError returns the error of the results, failover fails only when the
+// command is failed on every host it's failed over to
+func execError(results []execResult, failover bool) error {
+	var failed []string
+	for _, res := range results {
+		if res.Status != execStatusOK {
+			failed = append(failed, res.Error)
+		}
+	}
+	switch {
+	case failover && len(failed) == len(results):
+		return fmt.Errorf("the command is failed on all %d hosts: %s", len(results), strings.Join(failed, "; "))
+	case !failover && len(failed) > 0:
+		err := fmt.Errorf("the command is failed on %d of %d hosts", len(failed), len(results))
+		// a single host exits with the exit code of the remote command
+		if len(results) == 1 && results[0].ExitCode > 0 {
+			return withCode(results[0].ExitCode, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// the status of the command on a host
+const (
+	execStatusOK      = "ok"
+	execStatusFailed  = "failed"
+	execStatusTimeout = "timeout"
+)
+
+// execResult is the result of running the command on a host
+type execResult struct {
+	Host     string        `json:"host"`
+	Status   string        `json:"status"`
+	ExitCode int           `json:"exit_code"`
+	Duration time.Duration `json:"-"`
+	Millis   int64         `json:"duration_ms"`
+	Error    string        `json:"error,omitempty"`
+	Stdout   string        `json:"stdout,omitempty"`
+	Stderr   string        `json:"stderr,omitempty"`
+}
+
+// execRunner runs a command on the hosts
+type execRunner struct {
+	tsh     *tsh.TSH
+	env     string
+	user    string
+	command string
+	opts    execOptions
+
+	// sudoPassword is written into the stdin of sudo -S
+	sudoPassword string
+
+	// stdout & stderr print the output of the hosts & the progress
+	stdout, stderr io.Writer
+}
+
+// runOn runs the command on a single host, the output is written into
+// stdout & stderr if any & saved into the output directory
+func (r *execRunner) runOn(host string, stdout, stderr io.Writer) execResult {
+	command, detail := r.command, ""
+	var stdin io.Reader
+	if r.opts.sudo != "" {
+		command = sudoCommand(r.opts.sudo, r.command, r.opts.sudoPassword)
+		detail = "sudo as " + r.opts.sudo
+		if r.opts.sudoPassword {
+			stdin = strings.NewReader(r.sudoPassword + "\n")
+		}
+	}
+	auditEvent(audit.Event{Action: audit.ActionExec, Env: r.env, Host: host, User: r.user, Command: r.command, Detail: detail})
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	outs, errs := []io.Writer{&stdoutBuf}, []io.Writer{&stderrBuf}
+	if stdout != nil {
+		outs = append(outs, stdout)
+	}
+	if stderr != nil {
+		errs = append(errs, stderr)
+	}
+
+	ctx := context.Background()
+	if r.opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.opts.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := r.tsh.ExecContext(ctx, r.user, host, command, stdin, io.MultiWriter(outs...), io.MultiWriter(errs...))
+	res := execResult{Host: host, Status: execStatusOK, Duration: time.Since(start)}
+	res.Millis = res.Duration.Milliseconds()
+	if err != nil {
+		res.Status, res.ExitCode, res.Error = execStatusFailed, -1, err.Error()
+		// such as exec.ExitError or the one of the replayed tsh fixture
+		var exitErr interface{ ExitCode() int }
+		if errors.As(err, &exitErr) {
+			res.ExitCode = exitErr.ExitCode()
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			res.Status, res.Error = execStatusTimeout, fmt.Sprintf("timeout after %s", r.opts.timeout)
+		}
+	}
+
+	if r.opts.format == "json" {
+		res.Stdout, res.Stderr = stdoutBuf.String(), stderrBuf.String()
+	}
+	if r.opts.outputDir != "" {
+		if err := saveExecOutput(r.opts.outputDir, res, stdoutBuf.Bytes(), stderrBuf.Bytes()); err != nil {
+			fmt.Fprintf(r.stderr, "failed to save the output of %s, error: %v\n", host, err)
+		}
+	}
+	return res
+}
+
+// addSudoFlags adds the flags to run the command through sudo
+func addSudoFlags(cmd *cobra.Command) {
+	cmd.Flags().String("sudo", "", "run the command as the user through sudo, default user is root")
+	cmd.Flags().Lookup("sudo").NoOptDefVal = "root"
+	cmd.Flags().Bool("sudo-password", false, "prompt the sudo password instead of requiring the passwordless sudo")
+}
+
+// sudoUser returns the user of the --sudo flag, --sudo= is the same as
+// --sudo instead of silently not using sudo
+func sudoUser(cmd *cobra.Command) string {
+	user, _ := cmd.Flags().GetString("sudo")
+	if user == "" && cmd.Flags().Changed("sudo") {
+		return "root"
+	}
+	return user
+}
+
+// sudoCommand wraps the command to be run as the user through sudo,
+// the command is run by sh -c to keep the pipes & redirections as is.
+// Without the password sudo fails instead of waiting for the password
+// since there's no terminal to type it. The empty user is root like sudo
+// itself, rather than sudo failing on the unknown empty user
+func sudoCommand(user, command string, password bool) string {
+	if user == "" {
+		user = "root"
+	}
+	flags := []string{"-n"}
+	if password {
+		// the password is read from the stdin without any prompt
+		flags = []string{"-S", "-p", ""}
+	}
+	args := append([]string{"sudo"}, flags...)
+	return shell.Join(append(args, "-u", user, "--", "sh", "-c", command)...)
+}
+
+// runAll runs the command on every host, the hosts are split into
+// batches of batchSize run one after another with batchDelay between them,
+// and at most parallel hosts of a batch are run at the same time
+func (r *execRunner) runAll(hosts []string) []execResult {
+	parallel := r.opts.parallel
+	if parallel < 1 {
+		parallel = 1
+	}
+	batchSize := r.opts.batchSize
+	if batchSize < 1 {
+		batchSize = len(hosts)
+	}
+
+	var mu sync.Mutex
+	results := make([]execResult, len(hosts))
+	for start := 0; start < len(hosts); start += batchSize {
+		if start > 0 && r.opts.batchDelay > 0 {
+			r.progressf("waiting %s before the next batch\n", r.opts.batchDelay)
+			time.Sleep(r.opts.batchDelay)
+		}
+		end := start + batchSize
+		if end > len(hosts) {
+			end = len(hosts)
+		}
+		if batchSize < len(hosts) {
+			r.progressf("batch %d-%d of %d hosts\n", start+1, end, len(hosts))
+		}
+
+		sem := make(chan struct{}, parallel)
+		var wg sync.WaitGroup
+		for i := start; i < end; i++ {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(i int) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				results[i] = r.runHost(hosts[i], len(hosts) > 1, parallel > 1, &mu)
+			}(i)
+		}
+		wg.Wait()
+	}
+	return results
+}
+
+// errCanaryStopped is returned when the user stops after the canary hosts
+var errCanaryStopped = errors.New("stopped after the canary hosts")
+
+// runCanary runs the command on the first canary hosts & asks
+// for the confirmation before running on the rest of the hosts
+func (r *execRunner) runCanary(hosts []string) ([]execResult, error) {
+	results := r.runAll(hosts[:r.opts.canary])
+
+	// the canary results must be seen even though the final result is JSON
+	summary := r.stdout
+	if r.opts.format == "json" {
+		summary = r.stderr
+	}
+	fmt.Fprintf(summary, "\ncanary result of %d hosts", len(results))
+	printExecSummary(summary, results)
+
+	if s := summarizeExec(results); s.Failed+s.Timeout > 0 {
+		fmt.Fprintln(summary, ui.Colorize(i18n.Sprintf("WARNING! the command is failed on %d canary hosts", s.Failed+s.Timeout), "red"))
+	}
+	confirm, err := selector.Confirm(i18n.Sprintf("Do you want to continue to the remaining %d hosts", len(hosts)-len(results)))
+	if err != nil {
+		return nil, err
+	}
+	if !confirm {
+		return results, errCanaryStopped
+	}
+	return append(results, r.runAll(hosts[len(results):])...), nil
+}
+
+// runHost runs the command on the host of runAll, the output of the
+// concurrent hosts is prefixed by the hostname instead of the header
+func (r *execRunner) runHost(host string, many, concurrent bool, mu *sync.Mutex) execResult {
+	if r.opts.format == "json" {
+		return r.runOn(host, nil, nil)
+	}
+	if !concurrent {
+		if many {
+			r.printHeader(host)
+		}
+		res := r.runOn(host, r.stdout, r.stderr)
+		if res.Status != execStatusOK {
+			fmt.Fprintf(r.stderr, "%s: %s\n", host, res.Error)
+		}
+		return res
+	}
+
+	stdout := &prefixWriter{prefix: host + " | ", out: r.stdout, mu: mu}
+	stderr := &prefixWriter{prefix: host + " | ", out: r.stderr, mu: mu}
+	res := r.runOn(host, stdout, stderr)
+	stdout.Flush()
+	stderr.Flush()
+	if res.Status != execStatusOK {
+		mu.Lock()
+		fmt.Fprintf(r.stderr, "%s: %s\n", host, res.Error)
+		mu.Unlock()
+	}
+	return res
+}
+
+// runFailover runs the command on the hosts in order until it succeeds
+func (r *execRunner) runFailover(hosts []string) []execResult {
+	var results []execResult
+	for i, host := range hosts {
+		r.printHeader(host)
+		var stdout, stderr io.Writer
+		if r.opts.format != "json" {
+			stdout, stderr = r.stdout, r.stderr
+		}
+		res := r.runOn(host, stdout, stderr)
+		results = append(results, res)
+		if res.Status == execStatusOK {
+			break
+		}
+		if r.opts.format == "json" {
+			continue
+		}
+		if i < len(hosts)-1 {
+			fmt.Fprintf(r.stderr, "%s: %s, failing over to %s\n", host, res.Error, hosts[i+1])
+		} else {
+			fmt.Fprintf(r.stderr, "%s: %s\n", host, res.Error)
+		}
+	}
+	return results
+}
+
+func (r *execRunner) printHeader(host string) {
+	r.printf("%s\n", ui.Colorize("==> "+host+" <==", "yellow"))
+}
+
+// printf prints the progress unless the result is printed as JSON
+func (r *execRunner) printf(format string, a ...interface{}) {
+	if r.opts.format == "json" {
+		return
+	}
+	fmt.Fprintf(r.stdout, format, a...)
+}
+
+// progressf prints the progress of the batches unless it's quiet
+func (r *execRunner) progressf(format string, a ...interface{}) {
+	if !quiet {
+		r.printf(format, a...)
+	}
+}
+
+// prefixWriter prefixes every line with the prefix, the complete lines
+// are written under the mu to not be mixed with the other writers
+type prefixWriter struct {
+	prefix string
+	out    io.Writer
+	mu     *sync.Mutex
+	buf    []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	i := bytes.LastIndexByte(w.buf, '\n')
+	if i < 0 {
+		return len(p), nil
+	}
+	lines := w.buf[:i+1]
+	w.buf = append([]byte(nil), w.buf[i+1:]...)
+
+	var out bytes.Buffer
+	for _, line := range bytes.SplitAfter(lines, []byte("\n")) {
+		if len(line) > 0 {
+			out.WriteString(w.prefix)
+			out.Write(line)
+		}
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := w.out.Write(out.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush writes the last line without the trailing new line
+func (w *prefixWriter) Flush() {
+	if len(w.buf) == 0 {
+		return
+	}
+	w.Write([]byte("\n"))
+}
+
+// saveExecOutput writes <host>.stdout, <host>.stderr & <host>.exit_code into dir,
+// the path separators of the hostname listed by the cluster are escaped so
+// the files can't be written outside of dir
+func saveExecOutput(dir string, res execResult, stdout, stderr []byte) error {
+	base := filepath.Join(dir, outputFileName(res.Host))
+	if err := ioutil.WriteFile(base+".stdout", stdout, 0644); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(base+".stderr", stderr, 0644); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(base+".exit_code", []byte(strconv.Itoa(res.ExitCode)+"\n"), 0644)
+}
+
+// outputFileName escapes the path separators of the host into _, as well as
+// the host made only of dots which is joined as the parent or the output dir
+func outputFileName(host string) string {
+	if strings.Trim(host, ".") == "" {
+		return strings.Repeat("_", len(host))
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == filepath.Separator {
+			return '_'
+		}
+		return r
+	}, host)
+}
+
+// execSummary counts the results by the status
+type execSummary struct {
+	OK      int `json:"ok"`
+	Failed  int `json:"failed"`
+	Timeout int `json:"timeout"`
+}
+
+func summarizeExec(results []execResult) execSummary {
+	var s execSummary
+	for _, res := range results {
+		switch res.Status {
+		case execStatusOK:
+			s.OK++
+		case execStatusTimeout:
+			s.Timeout++
+		default:
+			s.Failed++
+		}
+	}
+	return s
+}
+
+func printExecSummary(w io.Writer, results []execResult) {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "\nHOSTNAME\tSTATUS\tEXIT CODE\tDURATION")
+	for _, res := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", res.Host, res.Status, res.ExitCode, res.Duration.Round(time.Millisecond))
+	}
+	tw.Flush()
+
+	s := summarizeExec(results)
+	fmt.Fprintf(w, "ok: %d, failed: %d, timeout: %d\n", s.OK, s.Failed, s.Timeout)
+}
+
+func printExecJSON(w io.Writer, command string, results []execResult) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Command string       `json:"command"`
+		Summary execSummary  `json:"summary"`
+		Results []execResult `json:"results"`
+	}{command, summarizeExec(results), results})
+}
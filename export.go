@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/adzimzf/tpot/audit"
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/shell"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/spf13/cobra"
+)
+
+const exportExample = `
+tpot export vscode prod -u root >> ~/.ssh/config      // Add every production host as a VS Code Remote-SSH target
+tpot export vscode prod --filter 'web-*' -o web.conf  // Export the production web hosts only
+tpot export vscode prod -u root --open                // Pick a production host & open VS Code attached to it
+`
+
+var exportCmd = &cobra.Command{
+	Use:     "export",
+	Short:   "Export the nodes for the other tools",
+	Example: exportExample,
+}
+
+var exportVSCodeCmd = &cobra.Command{
+	Use:   "vscode <ENVIRONMENT>",
+	Short: "Export the nodes as the OpenSSH config entries for VS Code Remote-SSH",
+	Long: "Export every node as an OpenSSH Host entry reached by `tsh proxy ssh`, so VS Code Remote-SSH or any OpenSSH client " +
+		"connects to it through the proxy. The host alias is tpot-<env>-<hostname>",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return usageErrorf("ENVIRONMENT is required")
+		}
+
+		proxy, err := loadProxy(cmd, args[0])
+		if err != nil {
+			return err
+		}
+
+		if open, _ := cmd.Flags().GetBool("open"); open {
+			return openVSCode(cmd, proxy)
+		}
+
+		hosts := proxy.Node.ListHostname()
+		if filter, _ := cmd.Flags().GetString("filter"); filter != "" {
+			hosts = hosts[:0]
+			items, err := proxy.FilterNodes(filter)
+			if err != nil {
+				return usageErrorf("invalid --filter, error: %v", err)
+			}
+			for _, item := range items {
+				hosts = append(hosts, item.Hostname)
+			}
+			if len(hosts) == 0 {
+				return fmt.Errorf("there's no host match %s", filter)
+			}
+		}
+		sort.Strings(hosts)
+
+		out := io.Writer(os.Stdout)
+		if output, _ := cmd.Flags().GetString("output"); output != "" {
+			f, err := os.OpenFile(output, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+			if err != nil {
+				return fmt.Errorf("failed to create %s, error: %v", output, err)
+			}
+			defer f.Close()
+			out = f
+		}
+
+		// the user is optional, OpenSSH falls back to the local user
+		user, _ := cmd.Flags().GetString("user")
+		return writeHostConfigs(out, tsh.NewTSH(proxy), proxy.Env, user, hosts)
+	},
+}
+
+func init() {
+	exportVSCodeCmd.Flags().StringP("user", "u", "", "user to login to the nodes")
+	exportVSCodeCmd.Flags().String("filter", "", "only export the nodes match the filter expression")
+	addCIDRFlag(exportVSCodeCmd)
+	exportVSCodeCmd.Flags().StringP("output", "o", "", "the file to export, default is stdout")
+	exportVSCodeCmd.Flags().Bool("open", false, "pick a host & open the editor attached to it")
+	exportVSCodeCmd.Flags().String("editor", "code", "the VS Code binary to open, such as code-insiders or codium")
+	exportVSCodeCmd.Flags().String("folder", "", "the remote folder to open")
+	exportCmd.AddCommand(exportVSCodeCmd)
+	rootCmd.AddCommand(exportCmd)
+}
+
+// hostAlias is the OpenSSH host alias of the node
+func hostAlias(env, host string) string {
+	return "tpot-" + env + "-" + host
+}
+
+// writeHostConfigs writes the OpenSSH config entries of the hosts
+func writeHostConfigs(w io.Writer, t *tsh.TSH, env, user string, hosts []string) error {
+	fmt.Fprintf(w, "# generated by tpot export vscode %s\n", env)
+	for _, host := range hosts {
+		entry, err := t.HostConfig(hostAlias(env, host), user, host)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "\n%s", entry)
+	}
+	return nil
+}
+
+// vscodeConfigDir holds the generated OpenSSH config of every env
+// to be included by ~/.ssh/config, VS Code only reads the latter
+func vscodeConfigDir() string {
+	return config.Dir + "vscode/"
+}
+
+// openVSCode picks a host & opens VS Code attached to it, the entry
+// of the host is written first so the alias is always resolvable
+func openVSCode(cmd *cobra.Command, proxy *config.Proxy) error {
+	host, _ := selector.SelectHost(proxy, false)
+	if host == "" {
+		return errNoHost
+	}
+	user, err := getUserLogin(cmd, &proxy.Node)
+	if err != nil {
+		return err
+	}
+	if err := guardShell(proxy, host); err != nil {
+		return err
+	}
+	t := tsh.NewTSH(proxy)
+	if err := openSession(cmd, proxy, t, host); err != nil {
+		return err
+	}
+
+	args := []string{"--remote", "ssh-remote+" + hostAlias(proxy.Env, host)}
+	if folder, _ := cmd.Flags().GetString("folder"); folder != "" {
+		args = append(args, folder)
+	}
+	editor, _ := cmd.Flags().GetString("editor")
+	// nothing is really written or opened on dry run
+	if tsh.DryRun {
+		fmt.Fprintf(tsh.DryRunOutput, "[dry-run] %s\n", shell.Join(append([]string{editor}, args...)...))
+		return nil
+	}
+
+	var b strings.Builder
+	if err := writeHostConfigs(&b, t, proxy.Env, user, []string{host}); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(vscodeConfigDir(), 0700); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(vscodeConfigDir()+proxy.Env+".conf", []byte(b.String()), 0600); err != nil {
+		return err
+	}
+	warnSSHInclude()
+
+	infof(cmd, "opening %s on %s %s\n", editor, user, host)
+	auditEvent(audit.Event{Action: audit.ActionConnect, Env: proxy.Env, Host: host, User: user, Detail: "vscode"})
+	c := exec.Command(editor, args...)
+	c.Stdout, c.Stderr = os.Stdout, os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("failed to open %s, error: %v", editor, err)
+	}
+	return nil
+}
+
+// warnSSHInclude warns when ~/.ssh/config doesn't include the generated config
+func warnSSHInclude() {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return
+	}
+	b, _ := ioutil.ReadFile(filepath.Join(home, ".ssh", "config"))
+	dir := vscodeConfigDir()
+	if strings.Contains(string(b), dir) || strings.Contains(string(b), strings.Replace(dir, home, "~", 1)) {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "WARNING! add the line below at the top of ~/.ssh/config for VS Code to find the host\nInclude %s*.conf\n", dir)
+}
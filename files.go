@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/adzimzf/tpot/audit"
+	"github.com/adzimzf/tpot/remote"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/adzimzf/tpot/ui"
+	"github.com/spf13/cobra"
+)
+
+const filesExample = `
+tpot files staging                              // Pick a staging node then browse its files next to the local ones
+tpot files prod web-01 --remote-dir /var/log    // Browse /var/log of web-01 next to the current directory
+`
+
+var filesCmd = &cobra.Command{
+	Use:   "files <ENVIRONMENT> [HOST]",
+	Short: "Browse, copy, rename & delete the local & the node files side by side",
+	Long: `Browse the local files & the node files in two panes without typing the scp paths. The selected file or
+directory is copied into the directory of the other pane, uploaded from the local pane & downloaded from the node
+pane. The files can be renamed & deleted on both sides, the node files are listed by its POSIX shell`,
+	Example: filesExample,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return usageErrorf("ENVIRONMENT is required")
+		}
+
+		proxy, err := loadProxy(cmd, args[0])
+		if err != nil {
+			return err
+		}
+		var host string
+		if len(args) > 1 {
+			if host, err = lookUpHost(proxy, args[1]); err != nil {
+				return err
+			}
+		} else {
+			host, _ = selector.SelectHost(proxy, false)
+		}
+		if host == "" {
+			return errNoHost
+		}
+		if err := guardReadOnly(proxy, "files", host); err != nil {
+			return err
+		}
+		user, err := getUserLogin(cmd, &proxy.Node)
+		if err != nil {
+			return err
+		}
+		t := tsh.NewTSH(proxy)
+		if err := openSession(cmd, proxy, t, host); err != nil {
+			return err
+		}
+
+		localDir, _ := cmd.Flags().GetString("local-dir")
+		remoteDir, _ := cmd.Flags().GetString("remote-dir")
+		local := &localFiles{start: localDir}
+		node := &nodeFiles{t: t, user: user, host: host, start: remoteDir, guard: func(command string) error {
+			return guardExec(proxy, command, host)
+		}}
+
+		// the browser is full-screen, only the listing of the node is printed
+		if tsh.DryRun {
+			_, _, err := node.List("")
+			return err
+		}
+
+		audited := func(detail string) {
+			auditEvent(audit.Event{Action: audit.ActionExec, Env: proxy.Env, Host: host, User: user, Detail: detail})
+		}
+		node.audit = audited
+		copier := func(src, dstDir string, upload bool) error {
+			if upload {
+				audited(fmt.Sprintf("scp %s into %s:%s", src, host, dstDir))
+			} else {
+				audited(fmt.Sprintf("scp %s:%s into %s", host, src, dstDir))
+			}
+			var out bytes.Buffer
+			if err := t.CopyFiles(user, host, src, dstDir, upload, &out); err != nil {
+				return outputError(err, out.String())
+			}
+			return nil
+		}
+		return ui.NewFileBrowser(local, node, copier).Run()
+	},
+}
+
+func init() {
+	filesCmd.Flags().String("local-dir", "", "the starting directory of the local pane, default is the current directory")
+	filesCmd.Flags().String("remote-dir", "", "the starting directory of the node pane, default is the home directory")
+	filesCmd.Flags().StringP("user", "u", "", "user to login to the node")
+	rootCmd.AddCommand(filesCmd)
+}
+
+// localFiles is the local pane of the file browser
+type localFiles struct {
+	start string
+}
+
+func (l *localFiles) Name() string {
+	return "local"
+}
+
+func (l *localFiles) List(dir string) (string, []ui.FileEntry, error) {
+	if dir == "" {
+		dir = l.start
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	files := make([]ui.FileEntry, len(infos))
+	for i, info := range infos {
+		files[i] = ui.FileEntry{Name: info.Name(), Dir: info.IsDir(), Size: info.Size()}
+	}
+	return dir, files, nil
+}
+
+func (l *localFiles) Join(dir, name string) string {
+	return filepath.Join(dir, name)
+}
+
+func (l *localFiles) Rename(from, to string) error {
+	return os.Rename(from, to)
+}
+
+func (l *localFiles) Remove(path string) error {
+	return os.RemoveAll(path)
+}
+
+// nodeFiles is the node pane of the file browser, the files are
+// listed & changed by the POSIX shell commands of the node
+type nodeFiles struct {
+	t     *tsh.TSH
+	user  string
+	host  string
+	start string
+
+	// audit records the changes of the node files
+	audit func(detail string)
+
+	// guard checks the command by the exec rules of the policy
+	guard func(command string) error
+}
+
+func (n *nodeFiles) Name() string {
+	return n.host
+}
+
+func (n *nodeFiles) List(dir string) (string, []ui.FileEntry, error) {
+	if dir == "" {
+		dir = n.start
+	}
+	out, err := n.exec(remote.ListDirCommand(dir), "")
+	if err != nil {
+		return "", nil, err
+	}
+	dir, files := remote.ParseDir(out)
+	res := make([]ui.FileEntry, len(files))
+	for i, f := range files {
+		res[i] = ui.FileEntry{Name: f.Name, Dir: f.Dir, Size: f.Size}
+	}
+	return dir, res, nil
+}
+
+func (n *nodeFiles) Join(dir, name string) string {
+	return path.Join(dir, name)
+}
+
+func (n *nodeFiles) Rename(from, to string) error {
+	_, err := n.exec(remote.RenameCommand(from, to), fmt.Sprintf("rename %s into %s", from, to))
+	return err
+}
+
+func (n *nodeFiles) Remove(p string) error {
+	_, err := n.exec(remote.RemoveCommand(p), fmt.Sprintf("delete %s", p))
+	return err
+}
+
+// exec runs the command on the node & returns its output, the change
+// of the detail is audited once the command is allowed by the policy
+func (n *nodeFiles) exec(command, detail string) (string, error) {
+	if n.guard != nil {
+		if err := n.guard(command); err != nil {
+			return "", err
+		}
+	}
+	if detail != "" && n.audit != nil {
+		n.audit(detail)
+	}
+	var stdout, stderr bytes.Buffer
+	if err := n.t.Exec(n.user, n.host, command, &stdout, &stderr); err != nil {
+		return "", outputError(err, stderr.String())
+	}
+	return stdout.String(), nil
+}
+
+// outputError returns the error along with the last line of the output,
+// such as the reason printed by tsh or the shell of the node
+func outputError(err error, output string) error {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if last := strings.TrimSpace(lines[len(lines)-1]); last != "" {
+		return fmt.Errorf("%v, %s", err, last)
+	}
+	return err
+}
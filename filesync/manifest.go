@@ -0,0 +1,167 @@
+package filesync
+
+import (
+	"archive/tar"
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// File is the state of a single file used to decide whether
+// it has been changed since the last sync
+type File struct {
+	Size     int64
+	ModTime  int64
+	Checksum string
+}
+
+// Manifest is the list of files keyed by the slash separated
+// path relative to the synced directory
+type Manifest map[string]File
+
+// Scan builds the manifest of the local directory, the checksum
+// is computed only when it's used to compare the files
+func Scan(dir string, checksum bool) (Manifest, error) {
+	m := make(Manifest)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		f := File{Size: info.Size(), ModTime: info.ModTime().Unix()}
+		if checksum {
+			if f.Checksum, err = sum(path); err != nil {
+				return err
+			}
+		}
+		m[filepath.ToSlash(rel)] = f
+		return nil
+	})
+	return m, err
+}
+
+func sum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// RemoteScanCommand returns the shell command listing the remote files,
+// the output is parsed by ParseRemote. The quotedDir must be shell quoted
+func RemoteScanCommand(quotedDir string, checksum bool) string {
+	if checksum {
+		return "cd " + quotedDir + " 2>/dev/null && find . -type f -exec sha256sum {} + || true"
+	}
+	return "cd " + quotedDir + " 2>/dev/null && find . -type f -printf '%s %T@ %p\\n' || true"
+}
+
+// ParseRemote parses the output of RemoteScanCommand
+func ParseRemote(out string, checksum bool) (Manifest, error) {
+	m := make(Manifest)
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if checksum {
+			// <sha256>  ./path
+			parts := strings.SplitN(line, "  ", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("invalid checksum line: %s", line)
+			}
+			m[cleanRemotePath(parts[1])] = File{Checksum: parts[0]}
+			continue
+		}
+
+		// <size> <mtime.fraction> ./path
+		parts := strings.SplitN(line, " ", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid file line: %s", line)
+		}
+		size, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file size: %s", line)
+		}
+		mtime, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file mtime: %s", line)
+		}
+		m[cleanRemotePath(parts[2])] = File{Size: size, ModTime: int64(mtime)}
+	}
+	return m, scanner.Err()
+}
+
+func cleanRemotePath(p string) string {
+	return strings.TrimPrefix(p, "./")
+}
+
+// Diff returns the sorted local files which are missing or
+// different in the remote
+func Diff(local, remote Manifest, checksum bool) []string {
+	var res []string
+	for path, l := range local {
+		r, ok := remote[path]
+		switch {
+		case !ok:
+		case checksum && l.Checksum == r.Checksum:
+			continue
+		case !checksum && l.Size == r.Size && l.ModTime == r.ModTime:
+			continue
+		}
+		res = append(res, path)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// WriteTar writes the files of the directory as a tar stream,
+// the modification time is kept to make the next mtime diff correct
+func WriteTar(w io.Writer, dir string, files []string) error {
+	tw := tar.NewWriter(w)
+	for _, name := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = name
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(tw, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
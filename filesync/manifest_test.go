@@ -0,0 +1,52 @@
+package filesync
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRemote(t *testing.T) {
+	m, err := ParseRemote("12 1612345678.1234567890 ./main.go\n3 1612345600.0000000000 ./pkg/a b.go\n", false)
+	assert.NoError(t, err)
+	assert.Equal(t, Manifest{
+		"main.go":    {Size: 12, ModTime: 1612345678},
+		"pkg/a b.go": {Size: 3, ModTime: 1612345600},
+	}, m)
+
+	m, err = ParseRemote("abc  ./main.go\n", true)
+	assert.NoError(t, err)
+	assert.Equal(t, Manifest{"main.go": {Checksum: "abc"}}, m)
+
+	_, err = ParseRemote("garbage\n", false)
+	assert.Error(t, err)
+}
+
+func TestScanAndDiff(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "pkg"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte("package main"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "pkg", "a.go"), []byte("package pkg"), 0644))
+	mtime := time.Unix(1612345678, 0)
+	assert.NoError(t, os.Chtimes(filepath.Join(dir, "main.go"), mtime, mtime))
+
+	local, err := Scan(dir, false)
+	assert.NoError(t, err)
+	remote := Manifest{
+		"main.go":  {Size: 12, ModTime: 1612345678},
+		"stale.go": {Size: 1, ModTime: 1},
+	}
+	assert.Equal(t, []string{"pkg/a.go"}, Diff(local, remote, false))
+
+	local, err = Scan(dir, true)
+	assert.NoError(t, err)
+	remote = Manifest{
+		"main.go":  {Checksum: local["main.go"].Checksum},
+		"pkg/a.go": {Checksum: "changed"},
+	}
+	assert.Equal(t, []string{"pkg/a.go"}, Diff(local, remote, true))
+}
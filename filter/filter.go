@@ -0,0 +1,313 @@
+// Package filter parses & evaluates the host filter expressions used by
+// --filter, the groups & the policy, example
+//
+//	web-* AND (label:role=web OR ip:10.12.0.0/16) AND NOT fact:os=centos*
+//
+// The terms are
+//
+//	<pattern>, host:<pattern>   the hostname, the glob or any hostname contains it
+//	label:<key>=<pattern>       the node label, label:<key> only checks the key exists
+//	fact:<key>=<pattern>        the cached fact of tpot info, fact:<key> only checks it exists
+//	meta:<key>=<pattern>        the metadata of tpot enrich such as meta:team=payments
+//	ip:<cidr|ip>                the node IP, the tunnel node has none
+//	env=<pattern>, env:<pattern> the environment
+//	cmd:<pattern>               the command checked by the exec rules of the policy
+//
+// joined by AND, OR & NOT in the order of precedence NOT, AND, OR along
+// with the parentheses. The value with spaces is double quoted such as
+// label:team="my team"
+package filter
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/adzimzf/tpot/fold"
+)
+
+// Target is the node evaluated by the filter
+type Target struct {
+	Env      string
+	Hostname string
+
+	// IP is empty for the tunnel node
+	IP     string
+	Labels map[string]string
+
+	// Facts is nil when the facts aren't collected
+	Facts map[string]string
+
+	// Meta is nil when the node isn't enriched
+	Meta map[string]string
+
+	// Command is the simple command checked by the exec rules, its
+	// words are joined by a space, empty when the node is filtered
+	Command string
+}
+
+// Filter is the parsed expression
+type Filter struct {
+	root expr
+	src  string
+}
+
+// Parse parses the filter expression
+func Parse(s string) (*Filter, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("the filter is empty")
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %s, join the terms by AND or OR", p.tokens[p.pos])
+	}
+	return &Filter{root: root, src: s}, nil
+}
+
+// Match returns true if the target matches the expression
+func (f *Filter) Match(t Target) bool {
+	return f.root.match(t)
+}
+
+// MatchEnv returns false only if no node of the env can match, it's
+// meant to skip loading the nodes of the env excluded by the env terms
+func (f *Filter) MatchEnv(env string) bool {
+	return f.root.matchEnv(env) != no
+}
+
+// UsesFacts returns true if the expression has any fact term, the
+// caller loads the cached facts only when it's needed
+func (f *Filter) UsesFacts() bool {
+	return f.root.usesFacts()
+}
+
+// UsesMeta returns true if the expression has any meta term, the
+// caller loads the enriched metadata only when it's needed
+func (f *Filter) UsesMeta() bool {
+	return f.root.usesMeta()
+}
+
+func (f *Filter) String() string {
+	return f.src
+}
+
+// tri is the result of matching the env without knowing the node
+type tri int
+
+const (
+	no tri = iota
+	maybe
+	yes
+)
+
+type expr interface {
+	match(t Target) bool
+	matchEnv(env string) tri
+	usesFacts() bool
+	usesMeta() bool
+}
+
+type andExpr struct{ left, right expr }
+
+func (e andExpr) match(t Target) bool { return e.left.match(t) && e.right.match(t) }
+func (e andExpr) usesFacts() bool     { return e.left.usesFacts() || e.right.usesFacts() }
+func (e andExpr) usesMeta() bool      { return e.left.usesMeta() || e.right.usesMeta() }
+func (e andExpr) matchEnv(env string) tri {
+	l, r := e.left.matchEnv(env), e.right.matchEnv(env)
+	if l < r {
+		return l
+	}
+	return r
+}
+
+type orExpr struct{ left, right expr }
+
+func (e orExpr) match(t Target) bool { return e.left.match(t) || e.right.match(t) }
+func (e orExpr) usesFacts() bool     { return e.left.usesFacts() || e.right.usesFacts() }
+func (e orExpr) usesMeta() bool      { return e.left.usesMeta() || e.right.usesMeta() }
+func (e orExpr) matchEnv(env string) tri {
+	l, r := e.left.matchEnv(env), e.right.matchEnv(env)
+	if l > r {
+		return l
+	}
+	return r
+}
+
+type notExpr struct{ e expr }
+
+func (e notExpr) match(t Target) bool     { return !e.e.match(t) }
+func (e notExpr) usesFacts() bool         { return e.e.usesFacts() }
+func (e notExpr) usesMeta() bool          { return e.e.usesMeta() }
+func (e notExpr) matchEnv(env string) tri { return yes - e.e.matchEnv(env) }
+
+// term kinds
+const (
+	termHost  = "host"
+	termLabel = "label"
+	termFact  = "fact"
+	termMeta  = "meta"
+	termIP    = "ip"
+	termEnv   = "env"
+	termCmd   = "cmd"
+)
+
+type termExpr struct {
+	kind, key, pattern string
+
+	// exists only checks the key of the label, the fact or the meta
+	exists bool
+	ipNet  *net.IPNet
+	cmd    *regexp.Regexp
+}
+
+func (e termExpr) usesFacts() bool { return e.kind == termFact }
+func (e termExpr) usesMeta() bool  { return e.kind == termMeta }
+
+func (e termExpr) matchEnv(env string) tri {
+	if e.kind != termEnv {
+		return maybe
+	}
+	if ok, _ := path.Match(e.pattern, env); ok {
+		return yes
+	}
+	return no
+}
+
+func (e termExpr) match(t Target) bool {
+	switch e.kind {
+	case termHost:
+		return matchHostname(e.pattern, t.Hostname)
+	case termLabel:
+		return matchKey(t.Labels, e.key, e.pattern, e.exists)
+	case termFact:
+		return matchKey(t.Facts, e.key, e.pattern, e.exists)
+	case termMeta:
+		return matchKey(t.Meta, e.key, e.pattern, e.exists)
+	case termIP:
+		ip := net.ParseIP(t.IP)
+		return ip != nil && e.ipNet.Contains(ip)
+	case termEnv:
+		ok, _ := path.Match(e.pattern, t.Env)
+		return ok
+	case termCmd:
+		return e.cmd.MatchString(t.Command)
+	}
+	return false
+}
+
+// matchHostname matches the glob when the pattern has a wildcard,
+// otherwise any hostname contains the pattern is matched, see fold.Match
+func matchHostname(pattern, hostname string) bool {
+	return fold.Match(pattern, hostname)
+}
+
+// commandRegexp compiles the cmd pattern matching the whole command, * matches
+// anything including the spaces & the slashes. Like sudoers, the pattern
+// without any wildcard matches the command along with any arguments
+func commandRegexp(pattern string) *regexp.Regexp {
+	pattern = strings.Join(strings.Fields(pattern), " ")
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	if !strings.ContainsAny(pattern, "*?") {
+		b.WriteString("( .*)?")
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+func matchKey(m map[string]string, key, pattern string, exists bool) bool {
+	v, ok := m[key]
+	if !ok || exists {
+		return ok
+	}
+	matched, _ := path.Match(pattern, v)
+	return matched
+}
+
+// newTerm parses the term such as label:role=web
+func newTerm(s string) (expr, error) {
+	kind, value := termHost, s
+	if i := strings.IndexAny(s, ":="); i > 0 {
+		switch prefix := s[:i]; {
+		case prefix == termEnv:
+			kind, value = termEnv, s[i+1:]
+		case s[i] == ':' && (prefix == termHost || prefix == termLabel || prefix == termFact || prefix == termMeta || prefix == termIP || prefix == termCmd):
+			kind, value = prefix, s[i+1:]
+		}
+	}
+	value = unquote(value)
+
+	e := termExpr{kind: kind, pattern: value}
+	switch kind {
+	case termLabel, termFact, termMeta:
+		kv := strings.SplitN(value, "=", 2)
+		e.key = unquote(kv[0])
+		if e.key == "" {
+			return nil, fmt.Errorf("invalid term %s, use %s:<key>=<pattern>", s, kind)
+		}
+		if len(kv) == 1 {
+			e.exists = true
+		} else {
+			e.pattern = unquote(kv[1])
+		}
+	case termIP:
+		ipNet, err := ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid term %s, use ip:<cidr> or ip:<ip>", s)
+		}
+		e.ipNet = ipNet
+	case termCmd:
+		e.cmd = commandRegexp(value)
+	}
+	if e.pattern == "" && !e.exists && kind != termIP {
+		return nil, fmt.Errorf("invalid term %s, the pattern is empty", s)
+	}
+	if _, err := path.Match(e.pattern, ""); err != nil && !e.exists && kind != termIP && kind != termCmd {
+		return nil, fmt.Errorf("invalid pattern of %s, error: %v", s, err)
+	}
+	return e, nil
+}
+
+// ParseCIDR parses the network in the CIDR notation,
+// the bare IP is parsed as the network of itself
+func ParseCIDR(s string) (*net.IPNet, error) {
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP %s", s)
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+	}
+	_, ipNet, err := net.ParseCIDR(s)
+	return ipNet, err
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
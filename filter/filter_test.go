@@ -0,0 +1,205 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var targets = []Target{
+	{Env: "prod", Hostname: "web-01", IP: "10.12.0.1", Labels: map[string]string{"role": "web", "team": "my team"}, Facts: map[string]string{"os": "Ubuntu 22.04"}},
+	{Env: "prod", Hostname: "web-02", IP: "10.13.0.2", Labels: map[string]string{"role": "web"}, Facts: map[string]string{"os": "CentOS 7"}},
+	{Env: "prod", Hostname: "db-01", IP: "10.12.5.1", Labels: map[string]string{"role": "db"}, Meta: map[string]string{"team": "payments", "rack": "R12"}},
+	{Env: "staging", Hostname: "web-stg", Labels: map[string]string{"role": "web"}},
+}
+
+func hostnames(f *Filter) []string {
+	var res []string
+	for _, t := range targets {
+		if f.Match(t) {
+			res = append(res, t.Hostname)
+		}
+	}
+	return res
+}
+
+func TestParse_Match(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{expr: "web", want: []string{"web-01", "web-02", "web-stg"}},
+		{expr: "web-0*", want: []string{"web-01", "web-02"}},
+		{expr: "host:db", want: []string{"db-01"}},
+		{expr: "WEB-0*", want: []string{"web-01", "web-02"}},
+		{expr: "host:*-0?", want: []string{"web-01", "web-02", "db-01"}},
+		{expr: "label:role=web", want: []string{"web-01", "web-02", "web-stg"}},
+		{expr: "label:role=w*", want: []string{"web-01", "web-02", "web-stg"}},
+		{expr: "label:team", want: []string{"web-01"}},
+		{expr: `label:team="my team"`, want: []string{"web-01"}},
+		{expr: `label:team="my *"`, want: []string{"web-01"}},
+		{expr: "ip:10.12.0.0/16", want: []string{"web-01", "db-01"}},
+		{expr: "ip:10.13.0.2", want: []string{"web-02"}},
+		{expr: "NOT ip:10.0.0.0/8", want: []string{"web-stg"}},
+		{expr: "fact:os=CentOS*", want: []string{"web-02"}},
+		{expr: "fact:os", want: []string{"web-01", "web-02"}},
+		{expr: "meta:team=payments", want: []string{"db-01"}},
+		{expr: "meta:rack", want: []string{"db-01"}},
+		{expr: "NOT meta:team=pay*", want: []string{"web-01", "web-02", "web-stg"}},
+		{expr: "env=prod", want: []string{"web-01", "web-02", "db-01"}},
+		{expr: "env:stag*", want: []string{"web-stg"}},
+		{expr: "web AND env=prod", want: []string{"web-01", "web-02"}},
+		{expr: "web and not env=prod", want: []string{"web-stg"}},
+		{expr: "db OR web-02", want: []string{"web-02", "db-01"}},
+		{expr: "NOT NOT db", want: []string{"db-01"}},
+		// AND binds tighter than OR
+		{expr: "db OR web AND env=staging", want: []string{"db-01", "web-stg"}},
+		{expr: "(db OR web) AND env=staging", want: []string{"web-stg"}},
+		{expr: "web-* AND (label:role=web OR ip:10.12.0.0/16) AND NOT fact:os=CentOS*", want: []string{"web-01", "web-stg"}},
+		{expr: "(web)AND(NOT web-02)", want: []string{"web-01", "web-stg"}},
+		{expr: "label:role=cache"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			f, err := Parse(tt.expr)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, hostnames(f))
+			assert.Equal(t, tt.expr, f.String())
+		})
+	}
+}
+
+func TestParse_MatchCommand(t *testing.T) {
+	tests := []struct {
+		expr    string
+		command string
+		want    bool
+	}{
+		{expr: "cmd:uptime", command: "uptime", want: true},
+		{expr: "cmd:uptime", command: "uptime -p", want: true},
+		{expr: "cmd:uptime", command: "uptimes", want: false},
+		{expr: `cmd:"systemctl status"`, command: "systemctl status nginx", want: true},
+		{expr: `cmd:"systemctl status"`, command: "systemctl restart nginx", want: false},
+		{expr: `cmd:"cat /var/log/*"`, command: "cat /var/log/nginx/access.log", want: true},
+		{expr: `cmd:"cat /var/log/*"`, command: "cat /etc/shadow", want: false},
+		{expr: `cmd:"df -?"`, command: "df -h", want: true},
+		{expr: `cmd:"df -?"`, command: "df -h /", want: false},
+		{expr: `cmd:"journalctl  -u *"`, command: "journalctl -u app", want: true},
+		{expr: `cmd:uptime OR cmd:"df *"`, command: "df -h", want: true},
+		{expr: `cmd:* AND NOT cmd:rm`, command: "rm -rf /", want: false},
+		{expr: `cmd:ls AND env=prod`, command: "ls", want: true},
+		{expr: `cmd:ls AND env=staging`, command: "ls", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr+" "+tt.command, func(t *testing.T) {
+			f, err := Parse(tt.expr)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, f.Match(Target{Env: "prod", Command: tt.command}))
+		})
+	}
+}
+
+func TestParse_Error(t *testing.T) {
+	tests := []string{
+		"",
+		"   ",
+		"web db",
+		"web AND",
+		"AND web",
+		"web OR OR db",
+		"NOT",
+		"(web",
+		"web)",
+		"()",
+		"label:=web",
+		"fact:",
+		"host:",
+		"env=",
+		"ip:10.0.0.0/33",
+		"ip:web",
+		"web-[",
+		`label:team="my team`,
+	}
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			_, err := Parse(expr)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestFilter_MatchEnv(t *testing.T) {
+	tests := []struct {
+		expr string
+		want map[string]bool
+	}{
+		{expr: "web", want: map[string]bool{"prod": true, "staging": true}},
+		{expr: "env=prod*", want: map[string]bool{"prod": true, "prod-us": true, "staging": false}},
+		{expr: "web AND env=prod", want: map[string]bool{"prod": true, "staging": false}},
+		{expr: "env=prod OR env=staging", want: map[string]bool{"prod": true, "staging": true, "dev": false}},
+		{expr: "env=prod OR web", want: map[string]bool{"prod": true, "dev": true}},
+		{expr: "NOT env=prod", want: map[string]bool{"prod": false, "staging": true}},
+		{expr: "NOT (env=prod AND web)", want: map[string]bool{"prod": true, "staging": true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			f, err := Parse(tt.expr)
+			assert.NoError(t, err)
+			for env, want := range tt.want {
+				assert.Equal(t, want, f.MatchEnv(env), env)
+			}
+		})
+	}
+}
+
+func TestFilter_UsesFacts(t *testing.T) {
+	for expr, want := range map[string]bool{
+		"web":                           false,
+		"label:role=web OR ip:10.0.0.1": false,
+		"web AND NOT fact:os=centos*":   true,
+		"(fact:kernel)":                 true,
+	} {
+		f, err := Parse(expr)
+		assert.NoError(t, err)
+		assert.Equal(t, want, f.UsesFacts(), expr)
+	}
+}
+
+func TestFilter_UsesMeta(t *testing.T) {
+	for expr, want := range map[string]bool{
+		"web":                         false,
+		"web AND NOT fact:os=centos*": false,
+		"db OR meta:team=payments":    true,
+	} {
+		f, err := Parse(expr)
+		assert.NoError(t, err)
+		assert.Equal(t, want, f.UsesMeta(), expr)
+	}
+}
+
+func TestParseCIDR(t *testing.T) {
+	tests := []struct {
+		cidr    string
+		want    string
+		wantErr bool
+	}{
+		{cidr: "10.12.0.0/16", want: "10.12.0.0/16"},
+		{cidr: "10.12.3.4/16", want: "10.12.0.0/16"},
+		{cidr: "10.12.3.4", want: "10.12.3.4/32"},
+		{cidr: "fd00::/8", want: "fd00::/8"},
+		{cidr: "fd00::1", want: "fd00::1/128"},
+		{cidr: "10.12.0.0/33", wantErr: true},
+		{cidr: "web-01", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cidr, func(t *testing.T) {
+			got, err := ParseCIDR(tt.cidr)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
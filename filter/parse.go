@@ -0,0 +1,154 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenTerm tokenKind = iota
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenOpen
+	tokenClose
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func (t token) String() string {
+	return fmt.Sprintf("%q", t.text)
+}
+
+// tokenize splits the expression into the parentheses, the keywords &
+// the terms, the double quoted part of a term may have spaces
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenOpen, text: "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenClose, text: ")"})
+			i++
+		default:
+			start, quoted := i, false
+			for ; i < len(s); i++ {
+				c := s[i]
+				if c == '"' {
+					quoted = !quoted
+					continue
+				}
+				if !quoted && (c == ' ' || c == '\t' || c == '\n' || c == '(' || c == ')') {
+					break
+				}
+			}
+			if quoted {
+				return nil, fmt.Errorf("unterminated quote in %s", s[start:])
+			}
+			word := s[start:i]
+			kind := tokenTerm
+			switch strings.ToUpper(word) {
+			case "AND":
+				kind = tokenAnd
+			case "OR":
+				kind = tokenOr
+			case "NOT":
+				kind = tokenNot
+			}
+			tokens = append(tokens, token{kind: kind, text: word})
+		}
+	}
+	return tokens, nil
+}
+
+// parser is the recursive descent parser of
+//
+//	or   = and { OR and }
+//	and  = not { AND not }
+//	not  = NOT not | "(" or ")" | term
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *parser) accept(kind tokenKind) bool {
+	if t, ok := p.peek(); ok && t.kind == kind {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseOr() (expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(tokenOr) {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(tokenAnd) {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (expr, error) {
+	t, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("the filter ends unexpectedly, a term is missing")
+	}
+	p.pos++
+	switch t.kind {
+	case tokenNot:
+		e, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{e: e}, nil
+	case tokenOpen:
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(tokenClose) {
+			return nil, fmt.Errorf("missing ) of the (")
+		}
+		return e, nil
+	case tokenTerm:
+		return newTerm(t.text)
+	}
+	return nil, fmt.Errorf("unexpected %s, a term is expected", t)
+}
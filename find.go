@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/filter"
+	"github.com/spf13/cobra"
+)
+
+const findExample = `
+tpot find web-01                                // Find web-01 in the node caches of every environment
+tpot find 'label:role=db AND env=prod-*'        // Find the database hosts of the production environments
+tpot find web-01 --live                         // Refresh the environments in the live_search order until web-01 is found
+tpot find web-01 --live --concurrency 1         // Refresh a single environment at once
+`
+
+var findCmd = &cobra.Command{
+	Use:   "find <FILTER>",
+	Short: "Find the hosts in the node caches of every environment",
+	Long: `Find the hosts matching the filter expression in the node caches of every environment, the expression is
+the one of --filter along with the env term. Once no cached host matches, --live refreshes the environments in the
+order of live_search.order, such as the environments of a tag first, until one of them has the host. At most
+live_search.concurrency environments are refreshed at once so the clusters aren't refreshed all together`,
+	Example: findExample,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return usageErrorf("FILTER is required")
+		}
+		expr := strings.Join(args, " ")
+		f, err := filter.Parse(expr)
+		if err != nil {
+			return usageErrorf("invalid filter %s, error: %v", expr, err)
+		}
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+		limit := cfg.LiveSearch.Limit()
+		if cmd.Flags().Changed("concurrency") {
+			if limit, _ = cmd.Flags().GetInt("concurrency"); limit < 1 {
+				return usageErrorf("--concurrency must be at least 1")
+			}
+		}
+
+		var proxies []*config.Proxy
+		for _, p := range cfg.Proxies {
+			if !f.MatchEnv(p.Env) {
+				continue
+			}
+			proxy, err := findProxy(nil, cfg, p.Env)
+			if err != nil {
+				cmd.PrintErrf("WARNING! skipping %s, error: %v\n", p.Env, err)
+				continue
+			}
+			proxies = append(proxies, proxy)
+		}
+
+		var found []foundHost
+		for _, proxy := range proxies {
+			node, err := proxy.GetNode()
+			if err != nil {
+				continue
+			}
+			found = append(found, selectFound(cmd, proxy, node.Items, f)...)
+		}
+		if live, _ := cmd.Flags().GetBool("live"); live && len(found) == 0 {
+			found = searchLive(cmd, cfg.LiveSearch.Route(proxies), f, limit)
+		}
+		if len(found) == 0 {
+			return fmt.Errorf("there's no host match %s", expr)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ENV\tHOST\tIP")
+		for _, h := range found {
+			ip := h.item.IP()
+			if h.item.IsTunnel() {
+				ip = "tunnel"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", h.env, h.item.Hostname, ip)
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	findCmd.Flags().Bool("live", false, "refresh the environments until one of them has the host once no cached host matches")
+	findCmd.Flags().Int("concurrency", config.DefaultLiveConcurrency, "the most environments refreshed at once by --live, default is live_search.concurrency")
+	rootCmd.AddCommand(findCmd)
+}
+
+// foundHost is the host found by tpot find
+type foundHost struct {
+	env  string
+	item config.Item
+}
+
+// selectFound returns the items of the proxy matching the filter
+func selectFound(cmd *cobra.Command, proxy *config.Proxy, items []config.Item, f *filter.Filter) []foundHost {
+	items, err := proxy.Select(f, items)
+	if err != nil {
+		cmd.PrintErrf("WARNING! skipping %s, error: %v\n", proxy.Env, err)
+		return nil
+	}
+	res := make([]foundHost, len(items))
+	for i, item := range items {
+		res[i] = foundHost{env: proxy.Env, item: item}
+	}
+	return res
+}
+
+// searchLive refreshes the proxies in the order until any of them has the hosts
+// matching the filter, at most limit of them are refreshed at once. No more
+// refresh starts once a host is found, the ones already started are waited for
+func searchLive(cmd *cobra.Command, proxies []*config.Proxy, f *filter.Filter, limit int) []foundHost {
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		found []foundHost
+		sem   = make(chan struct{}, limit)
+	)
+	for _, proxy := range proxies {
+		sem <- struct{}{}
+		mu.Lock()
+		done := len(found) > 0
+		mu.Unlock()
+		if done {
+			break
+		}
+
+		wg.Add(1)
+		go func(proxy *config.Proxy) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			infof(cmd, "refreshing the node cache of %s\n", proxy.Env)
+			node, err := getLatestNode(proxy, false)
+			if err != nil {
+				cmd.PrintErrf("WARNING! failed to refresh %s, error: %v\n", proxy.Env, err)
+				return
+			}
+			hosts := selectFound(cmd, proxy, node.Items, f)
+			mu.Lock()
+			found = append(found, hosts...)
+			mu.Unlock()
+		}(proxy)
+	}
+	wg.Wait()
+
+	// the refreshes end in any order, list them in the order of the proxies
+	order := make(map[string]int, len(proxies))
+	for i, proxy := range proxies {
+		order[proxy.Env] = i
+	}
+	sort.SliceStable(found, func(i, j int) bool { return order[found[i].env] < order[found[j].env] })
+	return found
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"os"
+
+	"github.com/adzimzf/tpot/i18n"
+	"github.com/adzimzf/tpot/tsh"
+)
+
+var (
+	// recordFixtures is the directory the tsh invocations are recorded into
+	recordFixtures string
+
+	// replayFixtures is the directory of the fixtures answering the tsh invocations
+	replayFixtures string
+)
+
+// startFixtures records or replays the tsh invocations once the flags are parsed,
+// the replay runs tpot without tsh & a real cluster such as for the demos
+func startFixtures() {
+	var err error
+	switch {
+	case recordFixtures != "" && replayFixtures != "":
+		err = usageErrorf("--record-fixtures & --replay-fixtures can't be used together")
+	case recordFixtures != "":
+		if err = tsh.RecordFixtures(recordFixtures); err != nil {
+			err = withCode(exitUsage, i18n.Errorf("failed to record the fixtures into %s, error: %v", recordFixtures, err))
+		}
+	case replayFixtures != "":
+		if err = tsh.ReplayFixtures(replayFixtures); err != nil {
+			err = withCode(exitUsage, i18n.Errorf("failed to replay the fixtures of %s, error: %v", replayFixtures, err))
+		}
+	}
+	if err != nil {
+		printError(rootCmd, err)
+		os.Exit(exitCode(err))
+	}
+}
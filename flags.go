@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// applyFlagDefaults sets the flags not given on the command line from the
+// configured defaults, the flag isn't marked as changed so the defaults of
+// the environment applied later can still replace the global ones
+func applyFlagDefaults(cmd *cobra.Command, defaults config.Flags) error {
+	if len(defaults) == 0 {
+		return nil
+	}
+
+	var err error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if f.Changed || err != nil {
+			return
+		}
+		v, ok := defaults.Lookup(cmd.Name(), f.Name)
+		if !ok {
+			return
+		}
+		// setting the slice again appends instead of replacing it
+		if s, ok := f.Value.(pflag.SliceValue); ok {
+			err = s.Replace(strings.Split(v, ","))
+		} else {
+			err = f.Value.Set(v)
+		}
+		if err != nil {
+			err = withCode(exitConfig, fmt.Errorf("invalid default %q of the flag %s, error: %v", v, f.Name, err))
+		}
+	})
+	return err
+}
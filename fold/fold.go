@@ -0,0 +1,175 @@
+// Package fold matches the hostnames by a query ignoring the case & the
+// accents, such as WEB-01 matching web-01 or cafe matching café
+package fold
+
+import (
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ExactPrefix starts the query matched as it's typed, such as =WEB-01
+// matching WEB-01 but not web-01
+const ExactPrefix = "="
+
+// letters is the letters the accented ones are folded into
+var letters = map[string]string{
+	"a":  "àáâãäåāăą",
+	"c":  "çćĉċč",
+	"d":  "ďđð",
+	"e":  "èéêëēĕėęě",
+	"g":  "ĝğġģ",
+	"h":  "ĥħ",
+	"i":  "ìíîïĩīĭįı",
+	"j":  "ĵ",
+	"k":  "ķ",
+	"l":  "ĺļľŀł",
+	"n":  "ñńņňŉ",
+	"o":  "òóôõöøōŏő",
+	"r":  "ŕŗř",
+	"s":  "śŝşšș",
+	"t":  "ţťŧț",
+	"u":  "ùúûüũūŭůűų",
+	"w":  "ŵ",
+	"y":  "ýÿŷ",
+	"z":  "źżž",
+	"ss": "ß",
+	"ae": "æ",
+	"oe": "œ",
+	"th": "þ",
+}
+
+// accents is the folded letter of the accented ones
+var accents = map[rune]string{}
+
+func init() {
+	for letter, runes := range letters {
+		for _, r := range runes {
+			accents[r] = letter
+		}
+	}
+}
+
+// foldRune returns the rune lower cased without the accent, the combining
+// marks are dropped & the full-width forms are the ASCII ones
+func foldRune(r rune) string {
+	switch {
+	case r < utf8.RuneSelf:
+		if 'A' <= r && r <= 'Z' {
+			r += 'a' - 'A'
+		}
+		return string(r)
+	case unicode.Is(unicode.Mn, r):
+		return ""
+	case 0xFF01 <= r && r <= 0xFF5E:
+		return foldRune(r - 0xFEE0)
+	}
+	r = unicode.ToLower(r)
+	if letter, ok := accents[r]; ok {
+		return letter
+	}
+	return string(r)
+}
+
+// folded returns true if the string is already folded, it's
+// the usual lower cased ASCII hostname
+func folded(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c >= utf8.RuneSelf || 'A' <= c && c <= 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the string lower cased without the accents
+func String(s string) string {
+	if folded(s) {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		b.WriteString(foldRune(r))
+	}
+	return b.String()
+}
+
+// Matcher matches the hostnames by a query, the query
+// is folded once for the many hostnames
+type Matcher struct {
+	query string
+	exact bool
+}
+
+// New returns the matcher of the query, the query starting
+// with ExactPrefix is matched as it's typed
+func New(query string) Matcher {
+	if strings.HasPrefix(query, ExactPrefix) {
+		return Matcher{query: strings.TrimPrefix(query, ExactPrefix), exact: true}
+	}
+	return Matcher{query: String(query)}
+}
+
+// Contains returns true if the hostname contains the query
+func (m Matcher) Contains(s string) bool {
+	if m.exact {
+		return strings.Contains(s, m.query)
+	}
+	return strings.Contains(String(s), m.query)
+}
+
+// Narrows returns true if every hostname matching m matches the previous
+// matcher too, hence m only needs to match the previous matches
+func (m Matcher) Narrows(prev Matcher) bool {
+	if prev.exact && !m.exact {
+		return false
+	}
+	return strings.Contains(m.query, prev.query)
+}
+
+// Index returns the byte offsets of the query matched in the hostname,
+// ok is false when it doesn't match
+func (m Matcher) Index(s string) (start, end int, ok bool) {
+	if m.exact {
+		i := strings.Index(s, m.query)
+		return i, i + len(m.query), i >= 0
+	}
+
+	// starts & ends are the offsets of the original rune of the folded bytes
+	var b strings.Builder
+	var starts, ends []int
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		f := foldRune(r)
+		for k := 0; k < len(f); k++ {
+			starts = append(starts, i)
+			ends = append(ends, i+size)
+		}
+		b.WriteString(f)
+		i += size
+	}
+	j := strings.Index(b.String(), m.query)
+	if j < 0 {
+		return 0, 0, false
+	}
+	if m.query == "" {
+		return 0, 0, true
+	}
+	return starts[j], ends[j+len(m.query)-1], true
+}
+
+// Match matches the hostname by the pattern, it's a glob when it has
+// a wildcard (*, ? or [), otherwise the hostname contains it
+func Match(pattern, hostname string) bool {
+	m := New(pattern)
+	if !strings.ContainsAny(m.query, "*?[") {
+		return m.Contains(hostname)
+	}
+	if !m.exact {
+		hostname = String(hostname)
+	}
+	ok, _ := path.Match(m.query, hostname)
+	return ok
+}
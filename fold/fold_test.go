@@ -0,0 +1,98 @@
+package fold
+
+import "testing"
+
+func TestString(t *testing.T) {
+	tests := map[string]string{
+		"web-01":     "web-01",
+		"WEB-01":     "web-01",
+		"Café-Ñandú": "cafe-nandu",
+		"cafe\u0301": "cafe",
+		"Straße":     "strasse",
+		"ＷＥＢ－０１":     "web-01",
+	}
+	for s, want := range tests {
+		if got := String(s); got != want {
+			t.Errorf("String(%q) = %q, want %q", s, got, want)
+		}
+	}
+}
+
+func TestMatcher_Contains(t *testing.T) {
+	tests := []struct {
+		query, host string
+		want        bool
+	}{
+		{query: "WEB-01", host: "web-01", want: true},
+		{query: "web", host: "WEB-01", want: true},
+		{query: "cafe", host: "café-01", want: true},
+		{query: "café", host: "CAFE-01", want: true},
+		{query: "=WEB", host: "web-01", want: false},
+		{query: "=WEB", host: "WEB-01", want: true},
+		{query: "=café", host: "cafe-01", want: false},
+		{query: "db", host: "web-01", want: false},
+	}
+	for _, tt := range tests {
+		if got := New(tt.query).Contains(tt.host); got != tt.want {
+			t.Errorf("New(%q).Contains(%q) = %v, want %v", tt.query, tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, host string
+		want          bool
+	}{
+		{pattern: "WEB-*", host: "web-01", want: true},
+		{pattern: "*-0?", host: "Web-01", want: true},
+		{pattern: "=WEB-*", host: "web-01", want: false},
+		{pattern: "=web-*", host: "web-01", want: true},
+		{pattern: "db", host: "web-01", want: false},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.host); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestMatcher_Index(t *testing.T) {
+	tests := []struct {
+		query, host string
+		want        string
+	}{
+		{query: "WEB", host: "db-web-01", want: "web"},
+		{query: "cafe", host: "x-CAFÉ-01", want: "CAFÉ"},
+		{query: "ss", host: "straße", want: "ß"},
+		{query: "=web", host: "WEB-web", want: "web"},
+	}
+	for _, tt := range tests {
+		start, end, ok := New(tt.query).Index(tt.host)
+		if !ok || tt.host[start:end] != tt.want {
+			t.Errorf("New(%q).Index(%q) = %q, %v, want %q", tt.query, tt.host, tt.host[start:end], ok, tt.want)
+		}
+	}
+	if _, _, ok := New("db").Index("web-01"); ok {
+		t.Errorf("Index() of the unmatched query is ok")
+	}
+}
+
+func TestMatcher_Narrows(t *testing.T) {
+	tests := []struct {
+		query, prev string
+		want        bool
+	}{
+		{query: "web-0", prev: "web", want: true},
+		{query: "WEB-0", prev: "web", want: true},
+		{query: "=web-0", prev: "web", want: true},
+		{query: "x=web", prev: "=web", want: false},
+		{query: "db", prev: "web", want: false},
+		{query: "web", prev: "", want: true},
+	}
+	for _, tt := range tests {
+		if got := New(tt.query).Narrows(New(tt.prev)); got != tt.want {
+			t.Errorf("New(%q).Narrows(%q) = %v, want %v", tt.query, tt.prev, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/spf13/cobra"
+)
+
+// forwardProfiles is forward_profiles of the configuration,
+// they're added to the session by --with-forwards
+var forwardProfiles []config.ForwardProfile
+
+// validateForwardProfiles returns the error of the first invalid profile
+func validateForwardProfiles(profiles []config.ForwardProfile) error {
+	for _, p := range profiles {
+		if err := p.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withForwards adds the forwards of the profiles matching the host to the session
+// options once --with-forwards is set, the options are returned as they're otherwise
+func withForwards(cmd *cobra.Command, proxy *config.Proxy, host string, opts tsh.SessionOptions) tsh.SessionOptions {
+	if enabled, _ := cmd.Flags().GetBool("with-forwards"); !enabled {
+		return opts
+	}
+	profiles, err := proxy.MatchForwardProfiles(forwardProfiles, host)
+	if err != nil {
+		cmd.PrintErrf("WARNING! the forward profiles aren't applied, error: %v\n", err)
+		return opts
+	}
+	if len(profiles) == 0 {
+		infof(cmd, "there's no forward profile matching %s\n", host)
+		return opts
+	}
+
+	var forwards []string
+	for _, p := range profiles {
+		forwards = append(forwards, p.Forwards...)
+		infof(cmd, "forwarding %s of the %s profile\n", strings.Join(p.Forwards, ", "), p.Name)
+	}
+	return opts.Merge(tsh.SessionOptions{LocalForwards: forwards})
+}
@@ -3,10 +3,13 @@ module github.com/adzimzf/tpot
 go 1.16
 
 require (
+	github.com/chzyer/readline v0.0.0-20180603132655-2972be24d48e
 	github.com/jroimartin/gocui v0.4.0
 	github.com/manifoldco/promptui v0.8.0
-	github.com/nsf/termbox-go v0.0.0-20210114135735-d04385b850e8 // indirect
+	github.com/mattn/go-sqlite3 v1.14.16
+	github.com/nsf/termbox-go v0.0.0-20210114135735-d04385b850e8
 	github.com/spf13/cobra v1.1.1
+	github.com/spf13/pflag v1.0.5
 	github.com/stretchr/testify v1.7.0
 	gopkg.in/yaml.v2 v2.4.0
 )
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/filter"
+	"github.com/adzimzf/tpot/ui"
+	"github.com/spf13/cobra"
+)
+
+const groupExample = `
+tpot group save web 'label:role=web AND env=prod'                // Save the production web nodes as the web group
+tpot group save db 'env=prod AND db-*'                           // Save the production hosts named db-* as the db group
+tpot group save legacy 'ip:10.12.0.0/16 AND NOT fact:os=Ubuntu*' // Save the non Ubuntu hosts of the subnet as the legacy group
+tpot @web                                                        // Pick a host of the web group
+tpot @web --exec "uptime"                                        // Run uptime on every host of the web group
+tpot @web --exec "uptime" --filter web-0                         // Run uptime on the web group hosts contain web-0
+tpot group rm web                                                // Delete the web group
+`
+
+var groupCmd = &cobra.Command{
+	Use:   "group",
+	Short: "Save the node queries as the groups run by tpot @<group>",
+	Long: `Save the node queries as the groups of the configuration, the query is the filter expression as --filter
+along with the env term, the terms are joined by AND, OR & NOT along with the parentheses
+
+  env=<pattern>             the environment
+  label:<key>=<pattern>     the node label, label:<key> only checks the label exists
+  fact:<key>=<pattern>      the cached fact collected by the node info
+  meta:<key>=<pattern>      the metadata of the inventories enriched by tpot enrich
+  ip:<cidr|ip>              the node IP, the tunnel node has none
+  <pattern>                 the hostname, the glob or any hostname contains it
+
+tpot @<group> opens the picker of the group hosts & --exec runs on every one of them`,
+	Example: groupExample,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return groupLsCmd.RunE(cmd, args)
+	},
+}
+
+var groupSaveCmd = &cobra.Command{
+	Use:   "save <NAME> <QUERY>",
+	Short: "Save the query as the group",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return usageErrorf("NAME & QUERY are required")
+		}
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+		if err := cfg.SaveGroup(args[0], args[1]); err != nil {
+			return withCode(exitConfig, err)
+		}
+		infof(cmd, "group %s is saved, run it by tpot @%s\n", args[0], args[0])
+		return nil
+	},
+}
+
+var groupLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List the groups along with their query",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, name := range cfg.GroupNames() {
+			fmt.Fprintf(w, "@%s\t%s\n", name, cfg.Groups[name])
+		}
+		return w.Flush()
+	},
+}
+
+var groupRmCmd = &cobra.Command{
+	Use:   "rm <NAME>",
+	Short: "Delete the group",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return usageErrorf("NAME is required")
+		}
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+		if err := cfg.DeleteGroup(args[0]); err != nil {
+			return err
+		}
+		infof(cmd, "group %s is deleted\n", args[0])
+		return nil
+	},
+}
+
+func init() {
+	groupCmd.AddCommand(groupSaveCmd, groupLsCmd, groupRmCmd)
+	rootCmd.AddCommand(groupCmd)
+}
+
+// groupHandler runs --exec on every host of the group, otherwise it picks
+// a host of the group, the env is picked first when the group spans many
+func groupHandler(cmd *cobra.Command, cfg *config.Config, name string) error {
+	query, ok := cfg.Groups[name]
+	if !ok {
+		return usageErrorf("group %s not found, save it by tpot group save", name)
+	}
+	f, err := filter.Parse(query)
+	if err != nil {
+		return withCode(exitConfig, fmt.Errorf("invalid filter of the group %s, error: %v", name, err))
+	}
+
+	proxies, err := groupProxies(cmd, cfg, f)
+	if err != nil {
+		return err
+	}
+	if len(proxies) == 0 {
+		return fmt.Errorf("there's no host in the group %s", name)
+	}
+
+	command, _ := cmd.Flags().GetString("exec")
+	if command != "" {
+		opts := execFlags(cmd)
+		// the whole group is run on instead of picking a host
+		if opts.filter == "" && !opts.stdin {
+			opts.filter = "*"
+		}
+		var firstErr error
+		for _, proxy := range proxies {
+			if err := execHandler(cmd, proxy, command, opts); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+	}
+
+	proxy := proxies[0]
+	if len(proxies) > 1 {
+		envs := make([]string, len(proxies))
+		for i, p := range proxies {
+			envs[i] = fmt.Sprintf("%s (%d hosts)", p.Env, len(p.Node.Items))
+		}
+		i, err := ui.Select(fmt.Sprintf("Environment of the group %s", name), envs)
+		if err != nil {
+			return withCode(exitCancelled, err)
+		}
+		proxy = proxies[i]
+	}
+	return nodeHandler(cmd, proxy)
+}
+
+// groupProxies returns the proxies of the envs matching the filter along
+// with only the nodes matching it, the env without any node is skipped
+func groupProxies(cmd *cobra.Command, cfg *config.Config, f *filter.Filter) ([]*config.Proxy, error) {
+	networks, err := cidrFlag(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	var envs []string
+	for _, p := range cfg.Proxies {
+		if f.MatchEnv(p.Env) {
+			envs = append(envs, p.Env)
+		}
+	}
+
+	var proxies []*config.Proxy
+	for _, env := range envs {
+		// the flag defaults only apply when the group is a single env
+		flagCmd := cmd
+		if len(envs) > 1 {
+			flagCmd = nil
+		}
+		proxy, err := findProxy(flagCmd, cfg, env)
+		if err != nil {
+			return nil, err
+		}
+		node, err := handleNode(cmd, proxy)
+		if err != nil && len(envs) > 1 {
+			fmt.Fprintf(os.Stderr, "WARNING! %s is skipped, failed to load the nodes, error: %v\n", env, err)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		// the node may still be saved into the cache, hence the copy
+		filtered := *node
+		if filtered.Items, err = proxy.Select(f, node.Items); err != nil {
+			return nil, err
+		}
+		if len(networks) > 0 {
+			filtered.Items = filtered.FilterCIDR(networks)
+		}
+		if len(filtered.Items) == 0 {
+			continue
+		}
+		proxy.Node = filtered
+		proxies = append(proxies, proxy)
+	}
+	return proxies, nil
+}
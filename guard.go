@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/i18n"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/adzimzf/tpot/ui"
+	"github.com/spf13/cobra"
+)
+
+// guardEnv shows the banner of the critical environment & asks to type
+// the environment name or to confirm the protected environment before
+// connecting to the target, the hosts are the hosts of the target matched
+// by the policy host rules, the target itself is the host when it's empty
+func guardEnv(proxy *config.Proxy, target string, hosts ...string) error {
+	if len(hosts) == 0 {
+		hosts = []string{target}
+	}
+	proxy, err := config.CurrentPolicy().ApplyHosts(proxy, hosts)
+	if err != nil {
+		return err
+	}
+
+	if proxy.Critical && !quiet {
+		ui.Banner(os.Stderr, fmt.Sprintf("%s  %s  %s", proxy.EnvBadge(), proxy.Env, target), proxy.Color)
+	}
+
+	// nothing is really done on dry run
+	if tsh.DryRun {
+		return nil
+	}
+
+	if proxy.ConfirmEnv {
+		ok, err := selector.ConfirmEnv(proxy.Env)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return withCode(exitCancelled, i18n.Errorf("the typed name is not %s, aborted", proxy.Env))
+		}
+	} else if proxy.Protected {
+		ok, err := selector.Confirm(i18n.Sprintf("%s is protected, continue to %s?", proxy.Env, target))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return withCode(exitCancelled, i18n.Error("aborted"))
+		}
+	}
+	return askReason(proxy, target)
+}
+
+// openSession guards the env of the target & the hosts, logs in then requests
+// the reason roles of the env. Every command opening a session on the nodes,
+// either a shell, a command or a tunnel, goes through it before tsh ssh
+func openSession(cmd *cobra.Command, proxy *config.Proxy, t *tsh.TSH, target string, hosts ...string) error {
+	if err := guardEnv(proxy, target, hosts...); err != nil {
+		return err
+	}
+	if err := t.Login(); err != nil {
+		return loginError(err)
+	}
+	return requestReasonRoles(cmd, proxy, t)
+}
+
+// askReason asks the ticket or the reason of the session once the environment
+// or the hosts require it, unless it's given by --ticket. It's recorded by
+// the audit log & sent to the session hooks as the ticket
+func askReason(proxy *config.Proxy, target string) error {
+	if !proxy.RequireReason && len(proxy.ReasonRoles) == 0 || sessionTicket != "" {
+		return nil
+	}
+	reason, err := selector.Input(i18n.Sprintf("Ticket or reason of connecting to %s", target))
+	if err != nil {
+		return withCode(exitCancelled, i18n.Errorf("%s requires the reason, give it by --ticket, error: %v", proxy.Env, err))
+	}
+	sessionTicket = strings.TrimSpace(reason)
+	if sessionTicket == "" {
+		return withCode(exitCancelled, i18n.Errorf("%s requires the reason, give it by --ticket", proxy.Env))
+	}
+	return nil
+}
+
+// guardReadOnly blocks the action on the read only environment
+// or on any of the hosts made read only by the policy
+func guardReadOnly(proxy *config.Proxy, action string, hosts ...string) error {
+	proxy, err := config.CurrentPolicy().ApplyHosts(proxy, hosts)
+	if err != nil {
+		return err
+	}
+	if proxy.ReadOnly {
+		return i18n.Errorf("%s is read only, %s is not allowed through tpot", proxy.Env, action)
+	}
+	return nil
+}
+
+// guardExec blocks the command not allowed by the exec rules of the
+// policy on the environment or on any of the hosts
+func guardExec(proxy *config.Proxy, command string, hosts ...string) error {
+	return config.CurrentPolicy().CheckExec(proxy, hosts, command)
+}
+
+// guardShell blocks the interactive shell denied by the policy
+// on the environment or on any of the hosts
+func guardShell(proxy *config.Proxy, hosts ...string) error {
+	return config.CurrentPolicy().CheckShell(proxy, hosts)
+}
@@ -0,0 +1,87 @@
+// Package hint explains the common tsh & proxy errors by the guidance
+// along with the tpot commands to try, they're printed under the error
+package hint
+
+import "strings"
+
+// EnvPlaceholder is replaced by the environment in the commands
+const EnvPlaceholder = "<ENV>"
+
+// Hint is the guidance of an error
+type Hint struct {
+	// Pattern is matched case-insensitively against the error
+	Pattern string
+	Message string
+
+	// Commands is the tpot commands to try
+	Commands []string
+}
+
+// hints is in the order of precedence, only the first
+// matching hint is shown to not bury the error
+var hints = []Hint{
+	{
+		Pattern: "certificate signed by unknown authority",
+		Message: "the certificate of the proxy isn't signed by a CA this machine trusts, such as the self-signed proxy or the TLS inspection of the corporate network, add the CA into the system trust store",
+	},
+	{
+		Pattern:  "certificate has expired or is not yet valid",
+		Message:  "check the clock of this machine, otherwise the certificate is expired",
+		Commands: []string{"tpot cert <ENV>"},
+	},
+	{
+		Pattern:  "access denied",
+		Message:  "the login or the node isn't allowed by the roles of your certificate, check the principals & the roles or request the elevated roles",
+		Commands: []string{"tpot cert <ENV>", "tpot request <ENV> --roles <ROLE> --wait"},
+	},
+	{
+		Pattern:  "not logged in",
+		Message:  "log in to the proxy again",
+		Commands: []string{"tpot <ENV> -r"},
+	},
+	{
+		Pattern:  "connection refused",
+		Message:  "nothing listens on the address, check the proxy address of the environment & whether the node is up",
+		Commands: []string{"tpot ping <ENV>", "tpot <ENV> --edit"},
+	},
+	{
+		Pattern:  "no such host",
+		Message:  "the address can't be resolved, check the proxy address of the environment or connect to the VPN",
+		Commands: []string{"tpot <ENV> --edit"},
+	},
+	{
+		Pattern:  "i/o timeout",
+		Message:  "the proxy or the node can't be reached in time, check the network or connect to the VPN",
+		Commands: []string{"tpot ping <ENV>"},
+	},
+	{
+		Pattern:  "code: 401",
+		Message:  "the proxy rejected the user name or the password, the proxy requiring the 2FA or the SSO needs two_fa or auth_connector",
+		Commands: []string{"tpot <ENV> --edit"},
+	},
+	{
+		Pattern:  "executable file not found",
+		Message:  "tsh isn't installed or isn't in the PATH, otherwise set tsh_path of the environment",
+		Commands: []string{"tpot tsh install"},
+	},
+}
+
+// For returns the hint of the error message, the placeholder of the
+// commands is replaced by the env unless it's empty
+func For(msg, env string) (Hint, bool) {
+	msg = strings.ToLower(msg)
+	for _, h := range hints {
+		if !strings.Contains(msg, h.Pattern) {
+			continue
+		}
+		if env != "" {
+			commands := make([]string, len(h.Commands))
+			for i, c := range h.Commands {
+				commands[i] = strings.Replace(c, EnvPlaceholder, env, -1)
+			}
+			h.Commands = commands
+		}
+		return h, true
+	}
+	return Hint{}, false
+}
@@ -0,0 +1,59 @@
+package hint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFor(t *testing.T) {
+	tests := []struct {
+		msg      string
+		env      string
+		pattern  string
+		commands []string
+	}{
+		{
+			msg:     `Get "https://teleport.mycomp.com": x509: certificate signed by unknown authority`,
+			pattern: "certificate signed by unknown authority",
+		},
+		{
+			msg:      "ERROR: access denied to root connecting to web-01",
+			env:      "prod",
+			pattern:  "access denied",
+			commands: []string{"tpot cert prod", "tpot request prod --roles <ROLE> --wait"},
+		},
+		{
+			msg:      "ERROR: Access Denied",
+			pattern:  "access denied",
+			commands: []string{"tpot cert <ENV>", "tpot request <ENV> --roles <ROLE> --wait"},
+		},
+		{
+			msg:      "failed to get nodes: dial tcp 10.0.0.1:443: connect: connection refused",
+			env:      "staging",
+			pattern:  "connection refused",
+			commands: []string{"tpot ping staging", "tpot staging --edit"},
+		},
+		{
+			msg:      "failed to get nodes: http code: 401",
+			env:      "prod",
+			pattern:  "code: 401",
+			commands: []string{"tpot prod --edit"},
+		},
+		{msg: "bash: foo: command not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			h, ok := For(tt.msg, tt.env)
+			assert.Equal(t, tt.pattern != "", ok)
+			assert.Equal(t, tt.pattern, h.Pattern)
+			assert.Equal(t, tt.commands, h.Commands)
+		})
+	}
+}
+
+func TestFor_keepsTheHints(t *testing.T) {
+	For("ERROR: access denied", "prod")
+	h, _ := For("ERROR: access denied", "")
+	assert.Equal(t, "tpot cert <ENV>", h.Commands[0])
+}
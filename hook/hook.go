@@ -0,0 +1,156 @@
+// Package hook fires the webhooks of the ssh sessions, such as annotating
+// the incident timeline or the on-call channel with the connections
+package hook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/user"
+	"path"
+	"text/template"
+	"time"
+)
+
+// the session events
+const (
+	EventStart = "session.start"
+	EventEnd   = "session.end"
+)
+
+// Session is the ssh session the hooks are fired on
+type Session struct {
+	Event  string    `json:"event"`
+	Time   time.Time `json:"time"`
+	Actor  string    `json:"actor"`
+	Env    string    `json:"env"`
+	Host   string    `json:"host"`
+	User   string    `json:"user"`
+	Ticket string    `json:"ticket,omitempty"`
+
+	// Duration & Error are the outcome of the ended session
+	Duration time.Duration `json:"duration,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// funcs is the functions of the body template, json quotes the value
+// such as {"text": {{json .Host}}}
+var funcs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// Webhook posts the sessions into the URL
+type Webhook struct {
+	URL     string
+	Headers map[string]string
+	Client  *http.Client
+
+	// envs is the globs of the environments, empty is every environment
+	envs []string
+
+	// body is the template of the request body, nil posts the session as JSON
+	body *template.Template
+}
+
+// New creates the webhook of the environments matching the globs with a
+// short timeout, the session shouldn't wait for it too long. The body is
+// the text/template of the Session, empty posts the session as JSON
+func New(endpoint string, headers map[string]string, body string, envs []string) (*Webhook, error) {
+	if endpoint == "" {
+		return nil, fmt.Errorf("the url of the session hook is missing")
+	}
+	for _, env := range envs {
+		if _, err := path.Match(env, ""); err != nil {
+			return nil, fmt.Errorf("invalid env %s of the session hook, error: %v", env, err)
+		}
+	}
+	w := &Webhook{
+		URL:     endpoint,
+		Headers: headers,
+		Client:  &http.Client{Timeout: 5 * time.Second},
+		envs:    envs,
+	}
+	if body != "" {
+		t, err := template.New("session_hook").Funcs(funcs).Option("missingkey=error").Parse(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template of the session hook %s, error: %v", endpoint, err)
+		}
+		w.body = t
+	}
+	return w, nil
+}
+
+// Matches returns true if the webhook is fired on the sessions of the env
+func (w *Webhook) Matches(env string) bool {
+	if len(w.envs) == 0 {
+		return true
+	}
+	for _, glob := range w.envs {
+		if ok, _ := path.Match(glob, env); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Body returns the request body of the session
+func (w *Webhook) Body(s Session) ([]byte, error) {
+	if w.body == nil {
+		return json.Marshal(s)
+	}
+	var b bytes.Buffer
+	if err := w.body.Execute(&b, s); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Fire posts the session, the actor is the current user when it's empty
+func (w *Webhook) Fire(s Session) error {
+	if s.Actor == "" {
+		s.Actor = currentUser()
+	}
+	body, err := w.Body(s)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("http code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Host returns the host of the URL to tell the webhooks apart in the errors
+func (w *Webhook) Host() string {
+	if u, err := url.Parse(w.URL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return w.URL
+}
+
+func currentUser() string {
+	u, err := user.Current()
+	if err != nil {
+		return os.Getenv("USER")
+	}
+	return u.Username
+}
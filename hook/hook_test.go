@@ -0,0 +1,67 @@
+package hook
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhook_Fire(t *testing.T) {
+	var bodies []string
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		auth = r.Header.Get("Authorization")
+	}))
+	defer srv.Close()
+
+	at := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	s := Session{Event: EventEnd, Time: at, Actor: "adzim", Env: "prod", Host: "web-01", User: "root",
+		Ticket: "INC-42", Duration: time.Minute, Error: "exit status 1"}
+
+	w, err := New(srv.URL, map[string]string{"Authorization": "Bearer secret"}, "", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Fire(s))
+	assert.Equal(t, `{"event":"session.end","time":"2021-03-04T05:06:07Z","actor":"adzim","env":"prod","host":"web-01","user":"root","ticket":"INC-42","duration":60000000000,"error":"exit status 1"}`, bodies[0])
+	assert.Equal(t, "Bearer secret", auth)
+
+	w, err = New(srv.URL, nil, `{"text": {{json (printf "%s@%s %s" .User .Host .Ticket)}}}`, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Fire(s))
+	assert.Equal(t, `{"text": "root@web-01 INC-42"}`, bodies[1])
+}
+
+func TestWebhook_Fire_failed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	w, err := New(srv.URL, nil, "", nil)
+	assert.NoError(t, err)
+	assert.EqualError(t, w.Fire(Session{Event: EventStart}), "http code: 403")
+
+	w, err = New(srv.URL, nil, "{{.Incident}}", nil)
+	assert.NoError(t, err)
+	assert.Error(t, w.Fire(Session{Event: EventStart}))
+}
+
+func TestNew(t *testing.T) {
+	_, err := New("", nil, "", nil)
+	assert.Error(t, err)
+	_, err = New("https://hooks.mycomp.com", nil, "{{.Host", nil)
+	assert.Error(t, err)
+	_, err = New("https://hooks.mycomp.com", nil, "", []string{"[prod"})
+	assert.Error(t, err)
+
+	w, err := New("https://hooks.mycomp.com/incident?team=sre", nil, "", []string{"prod*"})
+	assert.NoError(t, err)
+	assert.True(t, w.Matches("prod-eu"))
+	assert.False(t, w.Matches("staging"))
+	assert.Equal(t, "hooks.mycomp.com", w.Host())
+}
@@ -0,0 +1,114 @@
+// Package i18n translates the user-facing messages of tpot. The message is
+// keyed by its English text, hence the message missing from the catalog of
+// the language falls back to English as is
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// the supported languages
+const (
+	English    = "en"
+	Indonesian = "id"
+)
+
+// catalogs is the translations of every language other than English
+var catalogs = map[string]map[string]string{
+	Indonesian: indonesian,
+}
+
+// lang is the current language, it's set once on start up
+var lang = English
+
+// Languages returns the supported languages
+func Languages() []string {
+	langs := []string{English}
+	for l := range catalogs {
+		langs = append(langs, l)
+	}
+	sort.Strings(langs[1:])
+	return langs
+}
+
+// SetLanguage sets the language of the messages, the locale such as
+// id_ID.UTF-8 is accepted, empty is English
+func SetLanguage(locale string) error {
+	l := parseLocale(locale)
+	if l == "" || l == English {
+		lang = English
+		return nil
+	}
+	if _, ok := catalogs[l]; !ok {
+		return fmt.Errorf("unsupported language %s, use %s", locale, strings.Join(Languages(), " or "))
+	}
+	lang = l
+	return nil
+}
+
+// Language returns the current language
+func Language() string {
+	return lang
+}
+
+// Detect returns the language of the locale environment variables by the
+// POSIX precedence, the unsupported one is English
+func Detect() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		locale := os.Getenv(name)
+		if locale == "" {
+			continue
+		}
+		if l := parseLocale(locale); catalogs[l] != nil {
+			return l
+		}
+		return English
+	}
+	return English
+}
+
+// parseLocale returns the language code of the locale such as id of
+// id_ID.UTF-8, C & POSIX are English
+func parseLocale(locale string) string {
+	l := strings.ToLower(locale)
+	if i := strings.IndexAny(l, "_-.@"); i >= 0 {
+		l = l[:i]
+	}
+	switch l {
+	case "c", "posix":
+		return English
+	case "in":
+		// the deprecated code of Bahasa Indonesia still used by the old systems
+		return Indonesian
+	}
+	return l
+}
+
+// T translates the message into the current language
+func T(msg string) string {
+	if s, ok := catalogs[lang][msg]; ok {
+		return s
+	}
+	return msg
+}
+
+// Sprintf formats the translated format
+func Sprintf(format string, a ...interface{}) string {
+	return fmt.Sprintf(T(format), a...)
+}
+
+// Errorf creates the error of the translated format, %w wraps the error as fmt.Errorf does
+func Errorf(format string, a ...interface{}) error {
+	return fmt.Errorf(T(format), a...)
+}
+
+// Error is the error translated once it's printed, it's meant for the
+// package-level errors created before the language is set
+type Error string
+
+func (e Error) Error() string {
+	return T(string(e))
+}
@@ -0,0 +1,175 @@
+package i18n
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetLanguage(t *testing.T) {
+	defer SetLanguage(English)
+
+	tests := []struct {
+		locale  string
+		want    string
+		wantErr bool
+	}{
+		{locale: "", want: English},
+		{locale: "en_US.UTF-8", want: English},
+		{locale: "id", want: Indonesian},
+		{locale: "id_ID.UTF-8", want: Indonesian},
+		{locale: "in_ID", want: Indonesian},
+		{locale: "C", want: English},
+		{locale: "fr_FR", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			SetLanguage(English)
+			err := SetLanguage(tt.locale)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, Language())
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		defer os.Setenv(name, os.Getenv(name))
+	}
+
+	tests := []struct {
+		name                   string
+		lcAll, lcMessages, env string
+		want                   string
+	}{
+		{name: "LANG", env: "id_ID.UTF-8", want: Indonesian},
+		{name: "LC_ALL takes precedence", lcAll: "en_US.UTF-8", env: "id_ID.UTF-8", want: English},
+		{name: "LC_MESSAGES takes precedence", lcMessages: "id_ID", env: "en_US", want: Indonesian},
+		{name: "unsupported", env: "fr_FR.UTF-8", want: English},
+		{name: "unset", want: English},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("LC_ALL", tt.lcAll)
+			os.Setenv("LC_MESSAGES", tt.lcMessages)
+			os.Setenv("LANG", tt.env)
+			assert.Equal(t, tt.want, Detect())
+		})
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	defer SetLanguage(English)
+
+	assert.Equal(t, "ENVIRONMENT is required", T("ENVIRONMENT is required"))
+	SetLanguage(Indonesian)
+	assert.Equal(t, "ENVIRONMENT wajib diisi", T("ENVIRONMENT is required"))
+	assert.Equal(t, "Cari prod", Sprintf("Search %s", "prod"))
+	assert.Equal(t, "not in the catalog", T("not in the catalog"))
+	assert.Equal(t, "dibatalkan", Error("aborted").Error())
+
+	cause := errors.New("denied")
+	err := Errorf("failed to login, error: %w", cause)
+	assert.Equal(t, "gagal login, galat: denied", err.Error())
+	assert.True(t, errors.Is(err, cause))
+}
+
+// verb is the format verb of the message
+var verb = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+// the translation must keep the format verbs of the English message
+func TestCatalogVerbs(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for msg, translated := range catalog {
+			assert.Equal(t, verb.FindAllString(msg, -1), verb.FindAllString(translated, -1), "%s: %q", lang, msg)
+		}
+	}
+}
+
+// translated is the argument index of the message of the functions
+// translating it, such as i18n.T & the helpers of the main package
+var translated = map[string]int{
+	"i18n.T":       0,
+	"i18n.Sprintf": 0,
+	"i18n.Errorf":  0,
+	"i18n.Error":   0,
+	"usageErrorf":  0,
+	"infof":        1,
+}
+
+// every message literal passed to be translated has its translation
+func TestCatalogComplete(t *testing.T) {
+	fset := token.NewFileSet()
+	err := filepath.Walk("..", func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && strings.HasPrefix(info.Name(), ".") && path != ".." {
+			return filepath.SkipDir
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		f, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			i, ok := translated[funcName(call.Fun)]
+			if !ok || i >= len(call.Args) {
+				return true
+			}
+			lit, ok := call.Args[i].(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return true
+			}
+			msg, err := strconv.Unquote(lit.Value)
+			if err != nil || !hasWord(msg) {
+				return true
+			}
+			for lang, catalog := range catalogs {
+				_, ok := catalog[msg]
+				assert.True(t, ok, "%s: %s: %q", lang, fset.Position(lit.Pos()), msg)
+			}
+			return true
+		})
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func funcName(fun ast.Expr) string {
+	switch f := fun.(type) {
+	case *ast.Ident:
+		return f.Name
+	case *ast.SelectorExpr:
+		if x, ok := f.X.(*ast.Ident); ok {
+			return x.Name + "." + f.Sel.Name
+		}
+	}
+	return ""
+}
+
+// hasWord tells whether the message has anything to translate other than
+// the format verbs such as %v
+func hasWord(msg string) bool {
+	return strings.IndexFunc(verb.ReplaceAllString(msg, ""), unicode.IsLetter) >= 0
+}
@@ -0,0 +1,388 @@
+package i18n
+
+// indonesian is the Bahasa Indonesia catalog, the format verbs must be
+// kept in the same order as the English message
+var indonesian = map[string]string{
+	// the picker, the console & the prompts
+	"Type to Search or Arrow to Navigate": "Ketik untuk Mencari atau Panah untuk Bernavigasi",
+	"Type to Search | Arrow to Navigate":  "Ketik untuk Mencari | Panah untuk Bernavigasi",
+	"Type to broadcast":                   "Ketik untuk disiarkan",
+	"%s/%s history":                       "%s/%s riwayat",
+	"forward":                             "teruskan",
+	"copy IP":                             "salin IP",
+	"refresh":                             "muat ulang",
+	"env":                                 "lingkungan",
+	"quit":                                "keluar",
+	"focus":                               "fokus",
+	"toggle focused":                      "aktifkan yang difokus",
+	"toggle all":                          "aktifkan semua",
+	"Environments":                        "Lingkungan",
+	"Search %s":                           "Cari %s",
+	"Status":                              "Status",
+	"%d of %d hosts\n":                    "%d dari %d host\n",
+	"Recent searches (%s/%s): %s\n":       "Pencarian terakhir (%s/%s): %s\n",
+	"Select user to login":                "Pilih pengguna untuk login",
+	"Yes":                                 "Ya",
+	"Cancel":                              "Batal",
+	"cancel":                              "batal",
+	"Type %s to confirm":                  "Ketik %s untuk mengonfirmasi",
+	"invalid option":                      "pilihan tidak valid",
+	"invalid option, enter y or n":        "pilihan tidak valid, masukkan y atau n",
+	"must not be empty":                   "tidak boleh kosong",
+	"Enter the number, or nothing to %s":  "Masukkan nomornya, atau kosongkan untuk %s",
+	"Enter the number of the host, text to search, or nothing to quit": "Masukkan nomor host, teks untuk mencari, atau kosongkan untuk keluar",
+	"There's no host match %s\n":                                       "Tidak ada host yang cocok dengan %s\n",
+	"%d hosts\n":                                                       "%d host\n",
+	"%d hosts match %s\n":                                              "%d host cocok dengan %s\n",
+	"%d more hosts aren't listed, search to narrow them\n":             "%d host lainnya tidak ditampilkan, cari untuk mempersempitnya\n",
+	"%s isn't a number between 1 & %d\n":                               "%s bukan angka antara 1 & %d\n",
+	"Action on %s":                                                     "Tindakan pada %s",
+	"Press CTRL+C to stop the forwarding":                              "Tekan CTRL+C untuk menghentikan penerusan",
+	"%s listen %s to %s:%s\n":                                          "%s mendengarkan %s ke %s:%s\n",
+	"%s listen %s is %s\n":                                             "%s mendengarkan %s %s\n",
+	"up":                                                               "aktif",
+	"down":                                                             "mati",
+	"the console needs the full-screen terminal, use tpot <ENVIRONMENT> in the plain mode": "konsol membutuhkan terminal layar penuh, gunakan tpot <ENVIRONMENT> pada mode polos",
+	"the broadcast needs the full-screen terminal, use --exec in the plain mode":           "siaran membutuhkan terminal layar penuh, gunakan --exec pada mode polos",
+	"there's no environment to show": "tidak ada lingkungan untuk ditampilkan",
+
+	// the actions
+	"Command to run on %s":                                     "Perintah yang dijalankan di %s",
+	"Forward <local port>:<remote host>:<remote port>":         "Teruskan <port lokal>:<host remote>:<port remote>",
+	"Copy files with %s":                                       "Salin berkas dengan %s",
+	"upload":                                                   "unggah",
+	"download":                                                 "unduh",
+	"Source path":                                              "Path sumber",
+	"Destination path":                                         "Path tujuan",
+	"failed to copy %s, error: %v":                             "gagal menyalin %s, galat: %v",
+	"%s of %s is copied to the clipboard\n":                    "%s dari %s disalin ke clipboard\n",
+	"Listening ports of %s, select one to forward":             "Port yang terbuka di %s, pilih satu untuk diteruskan",
+	"[sudo] password of %s":                                    "[sudo] kata sandi %s",
+	"Do you want to continue to the remaining %d hosts":        "Lanjutkan ke %d host sisanya",
+	"WARNING! the command is failed on %d canary hosts":        "PERINGATAN! perintah gagal di %d host canary",
+	"Access request %s of the %s roles is approved, assume it": "Permintaan akses %s untuk role %s disetujui, gunakan sekarang",
+	"Continue to install?":                                     "Lanjutkan pemasangan?",
+	"Step %d/%d: %s":                                           "Langkah %d/%d: %s",
+	"run":                                                      "jalankan",
+	"skip":                                                     "lewati",
+	"abort":                                                    "batalkan",
+	"runbook %s is aborted at step %d":                         "runbook %s dibatalkan pada langkah %d",
+	"runbook %s is not found":                                  "runbook %s tidak ditemukan",
+
+	// the guards & the logins
+	"%s is protected, continue to %s?":                             "%s dilindungi, lanjutkan ke %s?",
+	"the typed name is not %s, aborted":                            "nama yang diketik bukan %s, dibatalkan",
+	"aborted":                                                      "dibatalkan",
+	"%s is read only, %s is not allowed through tpot":              "%s hanya baca, %s tidak diizinkan melalui tpot",
+	"Pick at least one host to login":                              "Pilih setidaknya satu host untuk login",
+	"Pick at least one host by the argument or --filter":           "Pilih setidaknya satu host melalui argumen atau --filter",
+	"failed to login, error: %w":                                   "gagal login, galat: %w",
+	"login using %s %s\n":                                          "login sebagai %s %s\n",
+	"login as %s is cancelled":                                     "login sebagai %s dibatalkan",
+	"Do you want to continue":                                      "Apakah Anda ingin melanjutkan",
+	"need to run using flag -a or -r to get the latest user login": "jalankan dengan flag -a atau -r untuk mendapatkan pengguna login terbaru",
+	"user login must not be empty":                                 "pengguna login tidak boleh kosong",
+	"WARNING! %s is not in your permitted logins [%s] granted by roles [%s], the login will likely be denied\n": "PERINGATAN! %s tidak termasuk login yang diizinkan [%s] dari role [%s], login kemungkinan akan ditolak\n",
+	"WARNING! minimum tsh version is Teleport v2.6.1 but got %s, the user login list is will be only root\n":    "PERINGATAN! versi tsh minimal adalah Teleport v2.6.1 tetapi didapat %s, daftar pengguna login hanya root\n",
+
+	// the usage errors
+	"ENVIRONMENT is required":                                            "ENVIRONMENT wajib diisi",
+	"ENVIRONMENT & COMMAND are required":                                 "ENVIRONMENT & COMMAND wajib diisi",
+	"ENVIRONMENT & REQUEST ID are required":                              "ENVIRONMENT & REQUEST ID wajib diisi",
+	"ENVIRONMENT, LOCAL DIR & HOST:REMOTE DIR are required":              "ENVIRONMENT, LOCAL DIR & HOST:REMOTE DIR wajib diisi",
+	"NAME is required":                                                   "NAME wajib diisi",
+	"NAME or FILE is required":                                           "NAME atau FILE wajib diisi",
+	"Env %s not found":                                                   "Lingkungan %s tidak ditemukan",
+	"-X & -Y can't be used together":                                     "-X & -Y tidak dapat digunakan bersamaan",
+	"--roles is required":                                                "--roles wajib diisi",
+	"--sudo-password needs --sudo":                                       "--sudo-password membutuhkan --sudo",
+	"--stdin can't be used along with --filter":                          "--stdin tidak dapat digunakan bersama --filter",
+	"--canary can't be used along with --failover":                       "--canary tidak dapat digunakan bersama --failover",
+	"--failover needs --filter or --stdin to know the next hosts":        "--failover membutuhkan --filter atau --stdin untuk mengetahui host berikutnya",
+	"invalid --filter, error: %v":                                        "--filter tidak valid, galat: %v",
+	"invalid --cidr %s, example: 10.12.0.0/16":                           "--cidr %s tidak valid, contoh: 10.12.0.0/16",
+	"invalid --sort %s, use name, ip, last_used, latency or label.<key>": "--sort %s tidak valid, gunakan name, ip, last_used, latency atau label.<key>",
+	"there's no host inside %s":                                          "tidak ada host di dalam %s",
+	"there's no host read from the stdin":                                "tidak ada host yang dibaca dari stdin",
+	"alias %s needs %d arguments but got %d":                             "alias %s membutuhkan %d argumen tetapi didapat %d",
+	"invalid forwarding format for: %s, use format <local port>:<remote address>:<remote port> example: 123:localhost:123": "format penerusan tidak valid untuk: %s, gunakan format <port lokal>:<alamat remote>:<port remote> contoh: 123:localhost:123",
+	"unsupported format %s, use json or csv":                  "format %s tidak didukung, gunakan json atau csv",
+	"unsupported format %s, use text or json":                 "format %s tidak didukung, gunakan text atau json",
+	"unsupported --print %s, use hostname, ip, address or id": "--print %s tidak didukung, gunakan hostname, ip, address atau id",
+	"invalid --listen %s, error: %v":                          "--listen %s tidak valid, galat: %v",
+	"invalid destination %s, use format <host>:<remote dir>":  "tujuan %s tidak valid, gunakan format <host>:<direktori remote>",
+
+	// the configuration & the nodes
+	"%s has updated successfully\n":                                              "%s berhasil diperbarui\n",
+	"Do You want to continue edit":                                               "Apakah Anda ingin melanjutkan pengeditan",
+	"Success to edit proxy\n":                                                    "Proxy berhasil diedit\n",
+	"Success to edit config\n":                                                   "Konfigurasi berhasil diedit\n",
+	"Success to add config\n":                                                    "Konfigurasi berhasil ditambahkan\n",
+	"failed to edit proxy, error: %v\n":                                          "gagal mengedit proxy, galat: %v\n",
+	"failed to edit config, error: %v\n":                                         "gagal mengedit konfigurasi, galat: %v\n",
+	"failed to add config, error: %v\n":                                          "gagal menambahkan konfigurasi, galat: %v\n",
+	"failed to get confirmation, error: %v":                                      "gagal mendapatkan konfirmasi, galat: %v",
+	"failed to get confirmation, error: %v\n":                                    "gagal mendapatkan konfirmasi, galat: %v\n",
+	"failed to get config, error: %v":                                            "gagal memuat konfigurasi, galat: %v",
+	"failed to get config due to %v":                                             "gagal memuat konfigurasi karena %v",
+	"failed to get config string, error:%v":                                      "gagal mendapatkan teks konfigurasi, galat:%v",
+	"failed to get flags edit, error: %v":                                        "gagal mendapatkan flag edit, galat: %v",
+	"failed to get flags template, error: %v":                                    "gagal mendapatkan flag template, galat: %v",
+	"invalid keybindings, error: %v":                                             "keybindings tidak valid, galat: %v",
+	"invalid min_tsh_version of the policy, error: %v":                           "min_tsh_version pada kebijakan tidak valid, galat: %v",
+	"failed to load nodes %v,\nyour might need -r to refresh/add the node cache": "gagal memuat node %v,\nAnda mungkin perlu -r untuk memuat ulang/menambah cache node",
+	"failed to get nodes: %v":                                                    "gagal mendapatkan node: %v",
+	"failed to append nodes, err: %v":                                            "gagal menambahkan node, galat: %v",
+	"there's no nodes found":                                                     "tidak ada node yang ditemukan",
+	"forwarding configuration is empty":                                          "konfigurasi penerusan kosong",
+	"the configuration is reloaded\n":                                            "konfigurasi dimuat ulang\n",
+
+	// the other informational messages
+	"%s is written\n":                                           "%s telah ditulis\n",
+	"opening %s on %s %s\n":                                     "membuka %s di %s %s\n",
+	"pinging %d nodes as %s\n":                                  "melakukan ping ke %d node sebagai %s\n",
+	"serving the API on %s, press CTRL+C to stop\n":             "melayani API di %s, tekan CTRL+C untuk berhenti\n",
+	"watching %s, press CTRL+C to stop\n":                       "memantau %s, tekan CTRL+C untuk berhenti\n",
+	"start it now & on every login by running\n%s\n":            "jalankan sekarang & setiap login dengan\n%s\n",
+	"%d events are verified\n":                                  "%d event terverifikasi\n",
+	"access request %s is approved, the %s roles are assumed\n": "permintaan akses %s disetujui, role %s digunakan\n",
+	"access request %s is assumed\n":                            "permintaan akses %s digunakan\n",
+	"access request %s is created, waiting for the approval, press CTRL+C to stop\n": "permintaan akses %s dibuat, menunggu persetujuan, tekan CTRL+C untuk berhenti\n",
+	"recording the runbook %s, run tpot runbook stop once it's done\n":               "merekam runbook %s, jalankan tpot runbook stop setelah selesai\n",
+	"%d steps are recorded into the runbook %s\n":                                    "%d langkah direkam ke runbook %s\n",
+
+	// the help of the commands
+	"tpot is tsh teleport wrapper":                                          "tpot adalah pembungkus tsh teleport",
+	"Open the interactive console of every environment":                     "Buka konsol interaktif semua lingkungan",
+	"Run a command on one or many nodes":                                    "Jalankan perintah di satu atau banyak node",
+	"Send the typed commands to many nodes at once":                         "Kirim perintah yang diketik ke banyak node sekaligus",
+	"Sync a local directory into a node incrementally":                      "Sinkronkan direktori lokal ke node secara bertahap",
+	"Show the basic facts of a node":                                        "Tampilkan informasi dasar node",
+	"Measure the connection latency to the nodes":                           "Ukur latensi koneksi ke node",
+	"Start a SOCKS proxy through the selected node":                         "Mulai proxy SOCKS melalui node yang dipilih",
+	"Discover the listening ports of a node & forward one of them":          "Temukan port yang terbuka di node & teruskan salah satunya",
+	"Pick a host & print it for the other commands":                         "Pilih host & cetak untuk perintah lain",
+	"Manage the tsh binary used by tpot":                                    "Kelola binary tsh yang digunakan tpot",
+	"Install tsh on this machine":                                           "Pasang tsh di mesin ini",
+	"List the aliases of the configuration":                                 "Tampilkan alias pada konfigurasi",
+	"Record & replay the runbooks of tpot actions":                          "Rekam & putar ulang runbook tindakan tpot",
+	"Record the next exec & forward actions into the runbook":               "Rekam tindakan exec & forward berikutnya ke runbook",
+	"Stop recording the runbook":                                            "Hentikan perekaman runbook",
+	"List the saved runbooks":                                               "Tampilkan runbook yang tersimpan",
+	"Run the steps of the runbook":                                          "Jalankan langkah-langkah runbook",
+	"Show or export the local audit log":                                    "Tampilkan atau ekspor log audit lokal",
+	"Show the audit events":                                                 "Tampilkan event audit",
+	"Export the audit events as JSON lines or CSV":                          "Ekspor event audit sebagai JSON lines atau CSV",
+	"Request the elevated roles by the Teleport access request":             "Minta role yang lebih tinggi melalui access request Teleport",
+	"List the access requests":                                              "Tampilkan permintaan akses",
+	"Show the access request":                                               "Tampilkan permintaan akses",
+	"Run tpot serve as a background service":                                "Jalankan tpot serve sebagai layanan latar belakang",
+	"Install the user-level systemd unit or launchd agent of tpot serve":    "Pasang unit systemd atau agen launchd tingkat pengguna untuk tpot serve",
+	"Serve the node list, status & connect command over a local API":        "Sajikan daftar node, status & perintah koneksi melalui API lokal",
+	"Export the nodes for the other tools":                                  "Ekspor node untuk alat lain",
+	"Export the nodes as the OpenSSH config entries for VS Code Remote-SSH": "Ekspor node sebagai entri konfigurasi OpenSSH untuk VS Code Remote-SSH",
+	"the language of the messages, en or id, default is by $LANG":           "bahasa pesan, en atau id, bawaan mengikuti $LANG",
+	"user to login to the desired host":                                     "pengguna untuk login ke host yang dituju",
+	"show the configuration list":                                           "tampilkan daftar konfigurasi",
+	"Replace the node list from proxy":                                      "Ganti daftar node dari proxy",
+	"Append the fresh node list to the cache":                               "Tambahkan daftar node terbaru ke cache",
+	"run the command on the selected host instead of opening a shell":       "jalankan perintah di host yang dipilih alih-alih membuka shell",
+	"print the tsh commands instead of running them":                        "cetak perintah tsh alih-alih menjalankannya",
+	"only print the errors & the essential result":                          "hanya cetak galat & hasil penting",
+	"add the teleport configuration":                                        "tambahkan konfigurasi teleport",
+	"edit all or specific configuration":                                    "edit semua atau sebagian konfigurasi",
+	"use the directory for both the configuration & cache":                  "gunakan direktori untuk konfigurasi & cache",
+	"use the numbered prompts instead of the full-screen UI for the screen readers, default on the dumb terminal": "gunakan prompt bernomor alih-alih UI layar penuh untuk pembaca layar, bawaan pada terminal dumb",
+	"show the tpot version": "tampilkan versi tpot",
+	"Save the node queries as the groups run by tpot @<group>": "Simpan kueri node sebagai grup yang dijalankan dengan tpot @<group>",
+	"NAME & QUERY are required":                                "NAME & QUERY wajib diisi",
+	"group %s not found, save it by tpot group save":           "grup %s tidak ditemukan, simpan dengan tpot group save",
+	"group %s is saved, run it by tpot @%s\n":                  "grup %s tersimpan, jalankan dengan tpot @%s\n",
+	"group %s is deleted\n":                                    "grup %s dihapus\n",
+
+	// cache
+	"the node cache of %s exported by %s at %s from %s is imported, %s has %d nodes now\n": "cache node %s yang diekspor oleh %s pada %s dari %s telah diimpor, %s sekarang memiliki %d node\n",
+
+	"Manage the node cache of the environments": "Kelola cache node dari lingkungan",
+	"ENVIRONMENT & FILE are required":           "ENVIRONMENT & FILE wajib diisi",
+	"%d nodes of %s are exported into %s\n":     "%d node dari %s diekspor ke %s\n",
+
+	"invalid VERSION %s, it's the number of tpot cache rollback --list":     "VERSION %s tidak valid, gunakan nomor dari tpot cache rollback --list",
+	"%s node cache is rolled back to the version %d, it has %d nodes now\n": "cache node %s dikembalikan ke versi %d, sekarang memiliki %d node\n",
+
+	"ENVIRONMENT or --all is required": "ENVIRONMENT atau --all wajib diisi",
+	"not cached":                       "belum di-cache",
+
+	"failed to inspect the %s node cache, error: %v\n":                   "gagal memeriksa cache node %s, error: %v\n",
+	"%s node cache is cleared, roll it back by tpot cache rollback %s\n": "cache node %s telah dihapus, kembalikan dengan tpot cache rollback %s\n",
+
+	// config
+	"Manage the configuration bundle distributed by the organization": "Kelola bundel konfigurasi yang didistribusikan oleh organisasi",
+	"BUNDLE is required, it's remembered after the first sync":        "BUNDLE wajib diisi, BUNDLE diingat setelah sinkronisasi pertama",
+	"BUNDLE is required":     "BUNDLE wajib diisi",
+	"--key is required":      "--key wajib diisi",
+	"--output is required":   "--output wajib diisi",
+	"%s is signed into %s\n": "%s telah ditandatangani ke %s\n",
+
+	"WARNING! the bundle isn't verified, pin the signing key by bundle_key of the policy %s\n": "PERINGATAN! bundel tidak terverifikasi, sematkan kunci penanda tangan di bundle_key pada kebijakan %s\n",
+	"%d environments are added & %d are updated from %s\n":                                     "%d lingkungan ditambahkan & %d diperbarui dari %s\n",
+	"the private key is written into %s, keep it secret & pin the public key in the policy\n":  "kunci privat ditulis ke %s, rahasiakan & sematkan kunci publik di kebijakan\n",
+
+	// cert
+	"Show the SSH & TLS certificates issued by tsh for the environment": "Tampilkan sertifikat SSH & TLS yang diterbitkan tsh untuk lingkungan",
+	"there's no certificate of %s in %s, log in first":                  "tidak ada sertifikat %s di %s, login terlebih dahulu",
+
+	// hint
+	"hint:": "petunjuk:",
+	"try:":  "coba:",
+
+	"the certificate of the proxy isn't signed by a CA this machine trusts, such as the self-signed proxy or the TLS inspection of the corporate network, add the CA into the system trust store": "sertifikat proxy tidak ditandatangani oleh CA yang dipercaya mesin ini, seperti proxy self-signed atau inspeksi TLS jaringan kantor, tambahkan CA ke trust store sistem",
+	"check the clock of this machine, otherwise the certificate is expired":                                                                "periksa jam mesin ini, jika tidak maka sertifikat telah kedaluwarsa",
+	"the login or the node isn't allowed by the roles of your certificate, check the principals & the roles or request the elevated roles": "login atau node tidak diizinkan oleh role sertifikat Anda, periksa principal & role atau ajukan role yang lebih tinggi",
+	"log in to the proxy again": "login ke proxy kembali",
+	"nothing listens on the address, check the proxy address of the environment & whether the node is up":                     "tidak ada yang mendengarkan di alamat tersebut, periksa alamat proxy dari lingkungan & apakah node menyala",
+	"the address can't be resolved, check the proxy address of the environment or connect to the VPN":                         "alamat tidak dapat di-resolve, periksa alamat proxy dari lingkungan atau sambungkan ke VPN",
+	"the proxy or the node can't be reached in time, check the network or connect to the VPN":                                 "proxy atau node tidak dapat dijangkau tepat waktu, periksa jaringan atau sambungkan ke VPN",
+	"the proxy rejected the user name or the password, the proxy requiring the 2FA or the SSO needs two_fa or auth_connector": "proxy menolak nama pengguna atau kata sandi, proxy yang mewajibkan 2FA atau SSO membutuhkan two_fa atau auth_connector",
+	"tsh isn't installed or isn't in the PATH, otherwise set tsh_path of the environment":                                     "tsh belum terpasang atau tidak ada di PATH, jika tidak atur tsh_path dari lingkungan",
+
+	// stats
+	"Show the statistics recorded by tpot":                                          "Tampilkan statistik yang dicatat oleh tpot",
+	"Show the connection successes & failures of the hosts, the most failing first": "Tampilkan koneksi yang berhasil & gagal dari host, yang paling sering gagal terlebih dahulu",
+	"there's no connection recorded for %s yet\n":                                   "belum ada koneksi yang tercatat untuk %s\n",
+	"failed last attempt":                                                           "gagal pada percobaan terakhir",
+	"failed last %d attempts":                                                       "gagal pada %d percobaan terakhir",
+
+	// auto next
+	"failed to connect to %s, trying %s\n":       "gagal terhubung ke %s, mencoba %s\n",
+	"Failed to connect to %s, pick another host": "Gagal terhubung ke %s, pilih host lain",
+
+	// completion & man
+	"Print the shell completion script":                                                   "Cetak skrip pelengkapan shell",
+	"Install the shell completion where the shell loads it, default is the current shell": "Pasang pelengkapan shell di tempat shell memuatnya, bawaannya adalah shell saat ini",
+	"the %s completion is installed into %s\n":                                            "pelengkapan %s terpasang di %s\n",
+	"add %s to fpath before compinit in ~/.zshrc if it isn't there yet\n":                 "tambahkan %s ke fpath sebelum compinit di ~/.zshrc jika belum ada\n",
+	"Generate & install the man pages of every command":                                   "Buat & pasang halaman man dari setiap perintah",
+	"the shell is required, one of %s":                                                    "shell wajib diisi, salah satu dari %s",
+	"unsupported shell %q, it must be one of %s":                                          "shell %q tidak didukung, harus salah satu dari %s",
+	"installing the %s completion isn't supported, add `tpot completion %s | Out-String | Invoke-Expression` to the profile instead": "pemasangan pelengkapan %s tidak didukung, tambahkan `tpot completion %s | Out-String | Invoke-Expression` ke profil sebagai gantinya",
+	"%d man pages are written into %s\n": "%d halaman man ditulis ke %s\n",
+
+	// picker columns
+	"columns":                           "kolom",
+	"sort":                              "urutan",
+	"invalid picker_columns, error: %v": "picker_columns tidak valid, galat: %v",
+
+	// maintenance
+	"Put the hosts in maintenance, they're left out of the multi-host exec": "Masukkan host ke pemeliharaan, host tersebut tidak diikutkan dalam exec banyak host",
+	"Put the hosts in maintenance until the window expires":                 "Masukkan host ke pemeliharaan hingga jendelanya berakhir",
+	"End the maintenance of the hosts before it expires":                    "Akhiri pemeliharaan host sebelum berakhir",
+	"Show the hosts in maintenance, the earliest to expire first":           "Tampilkan host dalam pemeliharaan, yang paling cepat berakhir terlebih dahulu",
+	"--until must be positive, such as 2h":                                  "--until harus positif, seperti 2h",
+	"%d hosts of %s are in maintenance until %s\n":                          "%d host dari %s dalam pemeliharaan hingga %s\n",
+	"%d hosts of %s are out of maintenance\n":                               "%d host dari %s keluar dari pemeliharaan\n",
+	"there's no host of %s in maintenance\n":                                "tidak ada host dari %s dalam pemeliharaan\n",
+	"skipping %d hosts in maintenance: %s\n":                                "melewati %d host dalam pemeliharaan: %s\n",
+	"in maintenance until %s":                                               "dalam pemeliharaan hingga %s",
+
+	// slack
+	"answering the Slack slash command on %s/slack/commands\n": "menjawab perintah slash Slack di %s/slack/commands\n",
+
+	// enrichment
+	"Enrich the cached nodes with the metadata of the inventories such as Netbox": "Perkaya node dalam cache dengan metadata dari inventaris seperti Netbox",
+	"%d of %d hosts of %s are enriched\n":                                         "%d dari %d host %s diperkaya\n",
+
+	// find
+	"Find the hosts in the node caches of every environment": "Cari host dalam cache node dari semua lingkungan",
+	"FILTER is required":                "FILTER wajib diisi",
+	"invalid filter %s, error: %v":      "filter %s tidak valid, galat: %v",
+	"--concurrency must be at least 1":  "--concurrency minimal 1",
+	"refreshing the node cache of %s\n": "menyegarkan cache node %s\n",
+
+	// crash
+	"tpot crashed: %v\n":                                                 "tpot mengalami crash: %v\n",
+	"failed to write the crash report, error: %v\n":                      "gagal menulis laporan crash, galat: %v\n",
+	"the crash report is written to %s, please attach it to the issue\n": "laporan crash ditulis ke %s, mohon lampirkan pada issue\n",
+
+	// bug
+	"Bundle the bug report & open the prefilled GitHub issue":                       "Bundel laporan bug & buka issue GitHub yang sudah terisi",
+	"the bug report is written to %s, review it before attaching it to the issue\n": "laporan bug ditulis ke %s, periksa sebelum melampirkannya pada issue\n",
+	"open the link to file the issue\n":                                             "buka tautan untuk membuat issue\n",
+
+	// fixtures
+	"--record-fixtures & --replay-fixtures can't be used together": "--record-fixtures & --replay-fixtures tidak dapat digunakan bersamaan",
+	"failed to record the fixtures into %s, error: %v":             "gagal merekam fixture ke %s, galat: %v",
+	"failed to replay the fixtures of %s, error: %v":               "gagal memutar ulang fixture %s, galat: %v",
+
+	// keepalive
+	"the login of %s expires in %s, run tpot %s to log in\n": "login %s berakhir dalam %s, jalankan tpot %s untuk login\n",
+	"%s isn't logged in, run tpot %s to log in\n":            "%s belum login, jalankan tpot %s untuk login\n",
+	"renewing the login of %s\n":                             "memperbarui login %s\n",
+
+	// forward profiles
+	"invalid forward_profiles, error: %v":      "forward_profiles tidak valid, galat: %v",
+	"there's no forward profile matching %s\n": "tidak ada forward profile yang cocok dengan %s\n",
+	"forwarding %s of the %s profile\n":        "meneruskan %s dari profile %s\n",
+
+	// via
+	"Reach the internal address through the selected node":   "Jangkau alamat internal melalui node yang dipilih",
+	"ENVIRONMENT & TARGET are required":                      "ENVIRONMENT & TARGET wajib diisi",
+	"the arguments after -- need --mysql, --psql or --redis": "argumen setelah -- membutuhkan --mysql, --psql atau --redis",
+	"invalid TARGET %s, error: %v":                           "TARGET %s tidak valid, galat: %v",
+	"--%s & --%s can't be used together":                     "--%s & --%s tidak dapat digunakan bersamaan",
+	"opening the tunnel to %s:%s through %s\n":               "membuka tunnel ke %s:%s melalui %s\n",
+
+	// files
+	"Browse, copy, rename & delete the local & the node files side by side":                 "Jelajahi, salin, ganti nama & hapus file lokal & node berdampingan",
+	"the file browser needs the full-screen terminal, use the scp action in the plain mode": "penjelajah file membutuhkan terminal layar penuh, gunakan aksi scp dalam mode plain",
+	"failed to list the files of %s, error: %v":                                             "gagal menampilkan file %s, galat: %v",
+	"open":                               "buka",
+	"switch":                             "pindah",
+	"copy":                               "salin",
+	"rename":                             "ganti nama",
+	"delete":                             "hapus",
+	"Rename %s":                          "Ganti nama %s",
+	"failed to open %s, error: %v":       "gagal membuka %s, galat: %v",
+	"the previous copy is still running": "penyalinan sebelumnya masih berjalan",
+	"copying %s into %s:%s":              "menyalin %s ke %s:%s",
+	"%s is copied into %s:%s":            "%s disalin ke %s:%s",
+	"invalid name %s, it can't be moved into the other directory": "nama %s tidak valid, tidak dapat dipindah ke direktori lain",
+	"failed to rename %s, error: %v":                              "gagal mengganti nama %s, galat: %v",
+	"%s is renamed into %s":                                       "%s diganti nama menjadi %s",
+	"file":                                                        "file",
+	"directory along with its files":                              "direktori beserta file di dalamnya",
+	"delete the %s %s? [y/n]":                                     "hapus %s %s? [y/n]",
+	"failed to delete %s, error: %v":                              "gagal menghapus %s, galat: %v",
+	"%s is deleted":                                               "%s dihapus",
+
+	// tail
+	"Follow the files of many nodes at once with the merged output": "Ikuti file dari banyak node sekaligus dengan keluaran yang digabung",
+	"--lines can't be negative":                                     "--lines tidak boleh negatif",
+
+	// top
+	"Watch the load, memory & disk of the nodes as a live table": "Pantau beban, memori & disk node sebagai tabel langsung",
+	"--interval must be at least %s":                             "--interval minimal %s",
+	"invalid --sort-by %s, use load, mem, disk or host":          "--sort-by %s tidak valid, gunakan load, mem, disk atau host",
+	"sampling the hosts...":                                      "mengambil sampel host...",
+	"updated at %s, sorted by %s":                                "diperbarui pukul %s, diurutkan berdasarkan %s",
+
+	// reason
+	"Ticket or reason of connecting to %s":                   "Tiket atau alasan terhubung ke %s",
+	"%s requires the reason, give it by --ticket":            "%s memerlukan alasan, berikan dengan --ticket",
+	"%s requires the reason, give it by --ticket, error: %v": "%s memerlukan alasan, berikan dengan --ticket, galat: %v",
+
+	// verify
+	"failed to verify the nodes of %s by %s, error: %v":                                           "gagal memverifikasi node %s dengan %s, galat: %v",
+	"the web UI & tsh ls list the same %d nodes of %s\n":                                          "web UI & tsh ls mendaftar %d node %s yang sama\n",
+	"the web UI lists %d nodes & tsh ls lists %d nodes of %s, %d hostnames differ:\n":             "web UI mendaftar %d node & tsh ls mendaftar %d node %s, %d hostname berbeda:\n",
+	"only listed by the web UI":                                                                   "hanya didaftar oleh web UI",
+	"only listed by tsh ls":                                                                       "hanya didaftar oleh tsh ls",
+	"%s by the web UI, %s by tsh ls":                                                              "%s oleh web UI, %s oleh tsh ls",
+	"refresh from both the web UI & tsh ls concurrently & report the nodes they list differently": "refresh dari web UI & tsh ls bersamaan & laporkan node yang didaftar berbeda",
+
+	// freshness
+	"only pick the nodes listed by a refresh within the duration, such as 24h": "hanya pilih node yang didaftar oleh refresh dalam durasi tersebut, seperti 24h",
+	"there's no nodes listed by a refresh within %s, refresh them by -r":       "tidak ada node yang didaftar oleh refresh dalam %s, refresh dengan -r",
+	"not seen by the latest refresh":                                           "tidak terlihat oleh refresh terakhir",
+	"last seen %s":                                                             "terakhir terlihat %s",
+}
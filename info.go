@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/adzimzf/tpot/audit"
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/remote"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/adzimzf/tpot/ui"
+	"github.com/spf13/cobra"
+)
+
+const infoExample = `
+tpot info staging web-01            // Collect & show the facts of web-01
+tpot info staging web-01 --cached   // Show the cached facts of web-01 without connecting
+`
+
+var infoCmd = &cobra.Command{
+	Use:     "info <ENVIRONMENT> [HOST]",
+	Short:   "Show the basic facts of a node",
+	Long:    "Collect the OS, uptime, CPU, memory, disk & cloud metadata of the node in a single tsh round-trip",
+	Example: infoExample,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return usageErrorf("ENVIRONMENT is required")
+		}
+
+		proxy, err := loadProxy(cmd, args[0])
+		if err != nil {
+			return err
+		}
+		node := proxy.Node
+
+		var host string
+		if len(args) > 1 {
+			if host, err = lookUpHost(proxy, args[1]); err != nil {
+				return err
+			}
+		} else {
+			host, _ = selectHost(proxy, false)
+		}
+		if host == "" {
+			return errNoHost
+		}
+
+		if cached, _ := cmd.Flags().GetBool("cached"); cached {
+			all, err := proxy.GetFacts()
+			if err != nil {
+				return fmt.Errorf("failed to load the cached facts, error: %v", err)
+			}
+			facts, ok := all[host]
+			if !ok {
+				return fmt.Errorf("there's no cached facts of %s, run without --cached to collect it", host)
+			}
+			printFacts(host, facts)
+			printHostMeta(proxy, host)
+			return nil
+		}
+
+		user, err := getUserLogin(cmd, &node)
+		if err != nil {
+			return err
+		}
+
+		return showFacts(cmd, proxy, user, host)
+	},
+}
+
+func init() {
+	infoCmd.Flags().Bool("cached", false, "show the cached facts without connecting to the node")
+	infoCmd.Flags().StringP("user", "u", "", "user to login to the node")
+	rootCmd.AddCommand(infoCmd)
+}
+
+// showFacts collects the facts of the host then caches & prints them
+func showFacts(cmd *cobra.Command, proxy *config.Proxy, user, host string) error {
+	if err := guardExec(proxy, remote.FactsCommand, host); err != nil {
+		return err
+	}
+	t := tsh.NewTSH(proxy)
+	if err := openSession(cmd, proxy, t, host); err != nil {
+		return err
+	}
+	facts, err := collectFacts(proxy, t, user, host)
+	if err != nil {
+		return err
+	}
+	if err := proxy.UpdateFacts(host, facts); err != nil {
+		cmd.PrintErrf("WARNING! failed to cache the facts, error: %v\n", err)
+	}
+	printFacts(host, facts)
+	printHostMeta(proxy, host)
+	return nil
+}
+
+func collectFacts(proxy *config.Proxy, t *tsh.TSH, user, host string) (config.HostFacts, error) {
+	// the facts command is a long script, only its purpose is logged
+	auditEvent(audit.Event{Action: audit.ActionExec, Env: proxy.Env, Host: host, User: user, Detail: "collect the facts"})
+	var stdout, stderr bytes.Buffer
+	if err := t.Exec(user, host, remote.FactsCommand, &stdout, &stderr); err != nil {
+		return config.HostFacts{}, fmt.Errorf("failed to collect the facts of %s, error: %v %s", host, err, stderr.String())
+	}
+	return config.HostFacts{
+		CollectedAt: time.Now(),
+		Facts:       remote.ParseFacts(stdout.String()),
+	}, nil
+}
+
+func printFacts(host string, facts config.HostFacts) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\t(collected %s ago)\n", ui.Colorize(host, "yellow"), time.Since(facts.CollectedAt).Round(time.Second))
+	for _, key := range remote.FactKeys {
+		if v, ok := facts.Facts[key]; ok {
+			fmt.Fprintf(w, "  %s\t%s\n", key, v)
+		}
+	}
+	w.Flush()
+}
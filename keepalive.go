@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/adzimzf/tpot/audit"
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/trace"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// keepaliveSpacing is the pause between the environments of a round,
+	// so the keepalive never runs tsh for every environment at once
+	keepaliveSpacing = 10 * time.Second
+
+	// keepaliveRenewLimit is how often the login of an environment may be renewed,
+	// the failed SSO isn't retried on every round
+	keepaliveRenewLimit = time.Hour
+)
+
+// keepalive checks & renews the login of the environments marked keepalive
+type keepalive struct {
+	cmd *cobra.Command
+
+	// renewed is when the login of the env was renewed last time
+	renewed map[string]time.Time
+}
+
+// runKeepalive checks the keepalive environments of the current configuration every
+// interval until the ctx is done, the configuration may be reloaded in the meantime
+func runKeepalive(ctx context.Context, cmd *cobra.Command, current func() *config.Config) {
+	k := &keepalive{cmd: cmd, renewed: map[string]time.Time{}}
+	for {
+		cfg := current()
+		for i, p := range cfg.KeepaliveProxies() {
+			if i > 0 && !sleepCtx(ctx, keepaliveSpacing) {
+				return
+			}
+			proxy, err := findProxy(nil, cfg, p.Env)
+			if err != nil {
+				k.cmd.PrintErrf("WARNING! skipping the keepalive of %s, error: %v\n", p.Env, err)
+				continue
+			}
+			k.check(tsh.NewTSH(proxy), proxy.Env, cfg.Keepalive.RenewBefore())
+		}
+		if !sleepCtx(ctx, cfg.Keepalive.Interval()) {
+			return
+		}
+	}
+}
+
+// check renews the login of the env once it expires within renewBefore, the
+// user is told to log in when it can't be renewed in the background
+func (k *keepalive) check(t *tsh.TSH, env string, renewBefore time.Duration) {
+	step := trace.Start("check the keepalive login of %s", env)
+	until, ok := t.ValidUntil()
+	left := time.Until(until)
+	step.End(nil)
+	if ok && left > renewBefore {
+		return
+	}
+
+	switch {
+	case config.CurrentPolicy().ForbidKeepaliveRenew || !t.CanLoginInBackground():
+		if ok && left > 0 {
+			infof(k.cmd, "the login of %s expires in %s, run tpot %s to log in\n", env, left.Round(time.Minute), env)
+		} else {
+			infof(k.cmd, "%s isn't logged in, run tpot %s to log in\n", env, env)
+		}
+		return
+	case time.Since(k.renewed[env]) < keepaliveRenewLimit:
+		return
+	}
+
+	k.renewed[env] = time.Now()
+	infof(k.cmd, "renewing the login of %s\n", env)
+	if err := t.Renew(os.Stderr); err != nil {
+		k.cmd.PrintErrf("WARNING! failed to renew the login of %s, error: %v\n", env, err)
+		return
+	}
+	auditEvent(audit.Event{Action: audit.ActionLogin, Env: env})
+}
+
+// sleepCtx waits for d, it returns false once the ctx is done
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"github.com/adzimzf/tpot/i18n"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// setLanguage sets the language of the messages by the --lang flag,
+// the locale environment variables are used once it's empty
+func setLanguage(cmd *cobra.Command) error {
+	lang, _ := cmd.Flags().GetString("lang")
+	if lang == "" {
+		lang = i18n.Detect()
+	}
+	if err := i18n.SetLanguage(lang); err != nil {
+		return usageErrorf("%v", err)
+	}
+	return nil
+}
+
+// setHelpLanguage translates the help of every command before it's shown,
+// the help is shown before the command runs hence before loadConfig
+func setHelpLanguage(root *cobra.Command) {
+	i18n.SetLanguage(i18n.Detect())
+	help := root.HelpFunc()
+	root.SetHelpFunc(func(cmd *cobra.Command, args []string) {
+		if lang, _ := cmd.Flags().GetString("lang"); lang != "" {
+			i18n.SetLanguage(lang)
+		}
+		translateHelp(root)
+		help(cmd, args)
+	})
+}
+
+// translateHelp translates the short description & the flag usages of the
+// command & its sub commands, the translated text is kept as is by i18n.T
+func translateHelp(cmd *cobra.Command) {
+	cmd.Short = i18n.T(cmd.Short)
+	translate := func(f *pflag.Flag) { f.Usage = i18n.T(f.Usage) }
+	cmd.LocalFlags().VisitAll(translate)
+	cmd.InheritedFlags().VisitAll(translate)
+	for _, c := range cmd.Commands() {
+		translateHelp(c)
+	}
+}
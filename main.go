@@ -1,16 +1,17 @@
 package main
 
 import (
-	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net"
+	"os"
 	"strings"
 	"time"
 
+	"github.com/adzimzf/tpot/audit"
 	"github.com/adzimzf/tpot/config"
-	"github.com/adzimzf/tpot/scrapper"
+	"github.com/adzimzf/tpot/i18n"
+	"github.com/adzimzf/tpot/trace"
 	"github.com/adzimzf/tpot/tsh"
 	"github.com/adzimzf/tpot/ui"
 	"github.com/spf13/cobra"
@@ -25,24 +26,94 @@ var (
 )
 
 func main() {
+	// a crash mustn't leave the terminal in the full-screen UI
+	ui.HandleCrash(Version, exitCrash)
+	defer ui.Recover()
+
+	setupRootCmd()
+	if code := run(os.Args[1:]); code != exitOK {
+		os.Exit(code)
+	}
+}
+
+// setupRootCmd adds the flags of the root command & sets up how it fails
+func setupRootCmd() {
 	rootCmd.Flags().BoolVarP(&isConfig, "config", "c", false, "show the configuration list")
 	rootCmd.Flags().BoolVarP(&isForward, "forwarding", "L", false, "use ths ssh for port forwarding")
 	rootCmd.Flags().BoolP("refresh", "r", false, "Replace the node list from proxy")
 	rootCmd.Flags().BoolP("append", "a", false, "Append the fresh node list to the cache")
+	rootCmd.Flags().Duration("max-age", 0, "only pick the nodes listed by a refresh within the duration, such as 24h")
+	rootCmd.Flags().Bool("verify", false, "refresh from both the web UI & tsh ls concurrently & report the nodes they list differently")
 	rootCmd.Flags().Bool("add", false, "add the teleport configuration")
+	rootCmd.Flags().String("template", "", "pre-fill the added configuration from the named template")
 	rootCmd.Flags().BoolP("version", "v", false, "show the tpot version")
 	rootCmd.Flags().BoolP("edit", "e", false, "edit all or specific configuration")
 	rootCmd.Flags().StringP("user", "u", "", "user to login to the desired host")
-	rootCmd.Flags().BoolP("developer", "D", false, "used only for developing this application")
+	rootCmd.Flags().BoolP("forward-agent", "A", false, "forward the local SSH agent into the host")
+	rootCmd.Flags().BoolP("x11", "X", false, "forward the X11 display as untrusted")
+	rootCmd.Flags().BoolP("x11-trusted", "Y", false, "forward the X11 display as trusted")
+	rootCmd.Flags().StringArrayP("option", "o", nil, "the OpenSSH style option of the session as Key=Value, can be repeated")
+	rootCmd.Flags().String("exec", "", "run the command on the selected host instead of opening a shell")
+	rootCmd.Flags().String("filter", "", "select the hosts match the filter expression instead of picking one")
+	rootCmd.Flags().Bool("failover", false, "on --exec, retry on the next filtered host until the command succeeds")
+	rootCmd.Flags().Bool("stdin", false, "on --exec, run on the hostnames read from the stdin")
+	rootCmd.Flags().Bool("auto-next", false, "try the next host of the same name prefix once the connection fails quickly, such as web-02 after web-01")
+	rootCmd.Flags().Bool("with-forwards", false, "add the local port forwards of the forward_profiles matching the host to the session")
+	addCIDRFlag(rootCmd)
+	addSudoFlags(rootCmd)
+	rootCmd.PersistentFlags().String("profile", os.Getenv("TPOT_PROFILE"), "isolate the configuration, cache & tsh login by the profile name, default is $TPOT_PROFILE")
+	rootCmd.PersistentFlags().StringArray("tsh-arg", nil, "the flag appended to every tsh invocation, can be repeated")
+	rootCmd.PersistentFlags().Bool("dry-run", false, "print the tsh commands instead of running them")
+	rootCmd.PersistentFlags().String("browser", "", "how tsh login opens the SSO page, none prints the link to open it on another machine such as on the headless server")
+	rootCmd.PersistentFlags().String("callback-url", "", "the base URL host:port of the SSO callback link, tsh login listens on bind_addr of the environment for it")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "only print the errors & the essential result")
+	rootCmd.PersistentFlags().String("lang", "", "the language of the messages, en or id, default is by $LANG")
+	rootCmd.PersistentFlags().Bool("plain", os.Getenv("TERM") == "dumb", "use the numbered prompts instead of the full-screen UI for the screen readers, default on the dumb terminal")
+	rootCmd.PersistentFlags().StringVar(&pickerSort, "sort", sortByName, "the order of the hosts in the picker, one of name, ip, last_used, latency or label.<key>, the one chosen last time is kept without it")
+	rootCmd.PersistentFlags().String("ticket", os.Getenv("TPOT_TICKET"), "the incident or change ticket of the ssh sessions sent to the session hooks, default is $TPOT_TICKET")
+	rootCmd.PersistentFlags().String("config-dir", "", "use the directory for both the configuration & cache")
+	rootCmd.PersistentFlags().BoolP("developer", "D", false, "used only for developing this application")
+	rootCmd.PersistentFlags().BoolVar(&traced, "trace", false, "print the steps such as loading the config & running tsh along with their timings")
+	rootCmd.PersistentFlags().StringVar(&recordFixtures, "record-fixtures", "", "record every tsh invocation into the directory to replay it or to attach it to the issue of the unparsed tsh output")
+	rootCmd.PersistentFlags().StringVar(&replayFixtures, "replay-fixtures", "", "answer the tsh invocations by the fixtures recorded into the directory instead of running tsh")
+	rootCmd.PersistentFlags().StringVar(&pprofAddr, "pprof", "", "serve pprof on the address, example localhost:6060")
+	rootCmd.PersistentFlags().MarkHidden("pprof")
+	cobra.OnInitialize(startTrace, startFixtures, startPprof, setQuiet)
+	setHelpLanguage(rootCmd)
 	rootCmd.Version = Version
-	if err := rootCmd.Execute(); err != nil {
-		log.Fatalf("failed to execute :%v\n", err)
+
+	// the errors are printed once here along with the exit code
+	rootCmd.SilenceErrors = true
+	rootCmd.SilenceUsage = true
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return usageErrorf("%v", err)
+	})
+}
+
+// run runs the root command by the arguments, the error is printed
+// once here & the exit code of tpot is returned
+func run(args []string) int {
+	args, err := expandAlias(args)
+	if err != nil {
+		printError(rootCmd, err)
+		return exitCode(err)
+	}
+	rootCmd.SetArgs(args)
+
+	cmd, err := rootCmd.ExecuteC()
+	saveTrace()
+	auditLogger.Close(auditShipTimeout)
+	if err != nil {
+		printError(cmd, err)
+		return exitCode(err)
 	}
+	return exitOK
 }
 
 const example = `
 tpot -c --add                       // Set up the configuration environment
 tpot -c --edit                      // Edit all the configuration
+tpot -c --add --template corporate  // Set up the configuration environment from the corporate template
 tpot staging                        // Show the node list of staging environment
 tpot staging --edit                 // Edit the staging proxy configuration
 tpot prod -a                        // Get the latest node list then append to the cache for production 
@@ -50,6 +121,17 @@ tpot prod -r                        // Refresh the cache with the latest node fr
 tpot prod -u root                   // Login into production using root user
 tpot prod -L                        // Run the tsh forwarding based on the config list
 tpot prod -L 123:localhost:123      // Run the tsh forwarding based on the list in argument
+tpot prod -A                        // Login to the selected production host with the SSH agent forwarded
+tpot prod --auto-next               // Login to the next production host such as web-02 once web-01 refuses the connection
+tpot prod --with-forwards           // Login to the production host along with the forwards of its forward_profiles
+tpot prod -X -o ServerAliveInterval=30  // Login with the X11 forwarding & an OpenSSH option
+tpot prod --exec "uptime"           // Run uptime on the selected production host
+tpot prod --filter 'web-*' --exec "uptime"             // Run uptime on every production web host
+tpot prod --filter 'web-*' --exec "uptime" --failover  // Run uptime on the first healthy production web host
+cat hosts.txt | tpot prod --stdin --exec "uptime"      // Run uptime on the production hosts listed in hosts.txt
+tpot prod --cidr 10.12.0.0/16 --sort ip                // Pick a production host of the subnet sorted by the IP
+tpot ping prod --filter web-        // Measure the connection latency to the production web nodes
+tpot proxy prod                     // Start a SOCKS proxy through the selected production node
 `
 
 var rootCmd = &cobra.Command{
@@ -57,152 +139,396 @@ var rootCmd = &cobra.Command{
 	Short:   "tpot is tsh teleport wrapper",
 	Long:    `config file is inside ` + config.Dir,
 	Example: example,
-	Run: func(cmd *cobra.Command, args []string) {
-
-		isDev, err := cmd.Flags().GetBool("developer")
-		if err != nil {
-			cmd.PrintErrln("failed to get config due to ", err)
-			return
-		}
-
-		cfg, err := config.NewConfig(isDev)
+	// the first argument is the environment name, not a sub command
+	Args: cobra.ArbitraryArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(cmd)
 		if err != nil {
-			cmd.PrintErrln("failed to get config, error:", err)
-			return
+			return err
 		}
 
 		switch {
 		case isConfig:
-			// the error has already beautify by the handler
-			if err := configHandler(cmd, cfg); err != nil {
-				cmd.PrintErrln(err)
-			}
-			return
+			return configHandler(cmd, cfg)
 		case isForward:
 			if len(args) < 1 {
-				cmd.Help()
-				return
+				return usageErrorf("ENVIRONMENT is required")
 			}
 
-			proxy, err := cfg.FindProxy(args[0])
-			if errors.Is(err, config.ErrEnvNotFound) {
-				cmd.PrintErrf("Env %s not found\n\n", args[0])
-				cmd.Help()
-				return
+			proxy, err := findProxy(cmd, cfg, args[0])
+			if err != nil {
+				return err
 			}
 
 			node, err := handleNode(cmd, proxy)
 			if err != nil {
-				cmd.PrintErrln(err)
-				return
+				return err
 			}
 			proxy.Node = *node
-
-			forwardingNodes := proxy.Forwarding.Nodes
-			if len(args) > 1 {
-				nodesStr := strings.Split(args[1], ",")
-				nodes := []*config.ForwardingNode{}
-				for _, s := range nodesStr {
-					parts := strings.Split(s, ":")
-					if len(parts) != 3 {
-						cmd.PrintErrln(fmt.Sprintf("invalid forwarding format for: %s, use format <local port>:<remote address>:<remote port> example: 123:localhost:123", s))
-						return
-					}
-					nodes = append(nodes, &config.ForwardingNode{
-						ListenPort: parts[0],
-						RemoteHost: parts[1],
-						RemotePort: parts[2],
-					})
-				}
-				// replace the forwarding config nodes
-				if len(nodes) > 0 {
-					forwardingNodes = nodes
-				}
+			if err := narrowCIDR(cmd, proxy); err != nil {
+				return err
 			}
 
-			host := ui.GetSelectedHost(proxy.Node.ListHostname())
-			if host == "" {
-				cmd.PrintErrln("Pick at least one host to login")
-				return
-			}
-
-			user, err := getUserLogin(cmd, &proxy.Node)
-			if err != nil {
-				cmd.PrintErrln(err)
-				return
-			}
-
-			f := fwd{
-				tsh:         tsh.NewTSH(proxy),
-				list:        forwardingNodes,
-				nodeHost:    host,
-				defaultUser: user,
-			}
-			err = f.Run()
-			if err != nil {
-				cmd.PrintErrf("Error: %s\n", err.Error())
-			}
-			return
-
+			return forwardHandler(cmd, proxy, args[1:])
 		}
 
 		if len(args) < 1 {
-			cmd.Help()
-			return
+			return cmd.Help()
 		}
-
-		proxy, err := cfg.FindProxy(args[0])
-		if errors.Is(err, config.ErrEnvNotFound) {
-			cmd.PrintErrf("Env %s not found\n\n", args[0])
-			cmd.Help()
-			return
+		if strings.HasPrefix(args[0], "@") {
+			return groupHandler(cmd, cfg, strings.TrimPrefix(args[0], "@"))
 		}
 
+		proxy, err := findProxy(cmd, cfg, args[0])
 		if err != nil {
-			cmd.PrintErrln("failed to get config due to ", err)
-			return
+			return err
 		}
 
 		isEdit, err := cmd.Flags().GetBool("edit")
 		if err != nil {
-			return
+			return err
 		}
 		if isEdit {
 			if err := proxyEditHandler(cfg, proxy); err != nil {
-				cmd.PrintErrln(err)
-				return
+				return err
 			}
-			cmd.Printf("%s has updated successfully\n", proxy.Env)
-			return
+			cmd.Print(i18n.Sprintf("%s has updated successfully\n", proxy.Env))
+			return nil
 		}
 
 		node, err := handleNode(cmd, proxy)
 		if err != nil {
-			cmd.PrintErrln(err)
-			return
+			return err
 		}
 		proxy.Node = *node
+		if err := narrowCIDR(cmd, proxy); err != nil {
+			return err
+		}
+		return nodeHandler(cmd, proxy)
+	},
+}
 
-		host := ui.GetSelectedHost(node.ListHostname())
-		if host == "" {
-			cmd.PrintErrln("Pick at least one host to login")
-			return
+// nodeHandler runs --exec on the loaded nodes of the proxy,
+// otherwise it picks a host to connect or to do the action
+func nodeHandler(cmd *cobra.Command, proxy *config.Proxy) error {
+	command, err := cmd.Flags().GetString("exec")
+	if err != nil {
+		return err
+	}
+	if command != "" {
+		return execHandler(cmd, proxy, command, execFlags(cmd))
+	}
+
+	opts, err := sessionOptions(cmd)
+	if err != nil {
+		return withCode(exitUsage, err)
+	}
+
+	// the login is checked while the user is still picking the host
+	t := tsh.NewTSH(proxy)
+	t.Prefetch()
+
+	host, action := selector.SelectHost(proxy, true)
+	if host == "" {
+		return errNoHost
+	}
+	if action != ui.ActionSSH {
+		return hostAction(cmd, proxy, host, action)
+	}
+
+	user, err := getUserLogin(cmd, &proxy.Node)
+	if err != nil {
+		return err
+	}
+	autoNext, _ := cmd.Flags().GetBool("auto-next")
+	failed := map[string]bool{}
+	for {
+		start := now()
+		err := connectHost(cmd, proxy, t, host, user, withForwards(cmd, proxy, host, opts))
+		if !tsh.IsConnectionError(err) || now().Sub(start) > quickFailure {
+			return err
 		}
+		failed[host] = true
 
-		user, err := getUserLogin(cmd, node)
-		if err != nil {
-			cmd.PrintErrln(err)
-			return
+		if autoNext {
+			next, ok := proxy.Node.NextSibling(host, failed)
+			if !ok {
+				return err
+			}
+			infof(cmd, "failed to connect to %s, trying %s\n", host, next)
+			host = next
+			continue
 		}
 
-		// print to give user information
-		cmd.Printf("login using %s %s\n", user, host)
+		// the failed host is marked in the picker by its stats
+		pick, cerr := selector.Confirm(i18n.Sprintf("Failed to connect to %s, pick another host", host))
+		if cerr != nil || !pick {
+			return err
+		}
+		if host, action = selector.SelectHost(proxy, true); host == "" {
+			return errNoHost
+		}
+		if action != ui.ActionSSH {
+			return hostAction(cmd, proxy, host, action)
+		}
+	}
+}
+
+// forwardHandler runs the forwarding of the config or of the comma separated
+// forwards of the argument through the picked host of the proxy
+func forwardHandler(cmd *cobra.Command, proxy *config.Proxy, args []string) error {
+	forwardingNodes := proxy.Forwarding.Nodes
+	if len(args) > 0 {
+		nodesStr := strings.Split(args[0], ",")
+		nodes := []*config.ForwardingNode{}
+		for _, s := range nodesStr {
+			node, err := config.ParseForward(s)
+			if err != nil {
+				return usageErrorf("invalid forwarding format for: %s, use format <local port>:<remote address>:<remote port> example: 123:localhost:123", s)
+			}
+			nodes = append(nodes, node)
+		}
+		// replace the forwarding config nodes
+		if len(nodes) > 0 {
+			forwardingNodes = nodes
+		}
+	}
+
+	host, _ := selector.SelectHost(proxy, false)
+	if host == "" {
+		return errNoHost
+	}
+
+	user, err := getUserLogin(cmd, &proxy.Node)
+	if err != nil {
+		return err
+	}
 
-		err = tsh.NewTSH(proxy).SSH(user, host)
+	t := tsh.NewTSH(proxy)
+	if err := openSession(cmd, proxy, t, host); err != nil {
+		return err
+	}
+	auditEvent(audit.Event{Action: audit.ActionForward, Env: proxy.Env, Host: host, User: user})
+	f := fwd{
+		tsh:         t,
+		env:         proxy.Env,
+		list:        forwardingNodes,
+		nodeHost:    host,
+		defaultUser: user,
+	}
+	return f.Run()
+}
+
+// execFlags reads the exec options of the root command
+func execFlags(cmd *cobra.Command) execOptions {
+	var opts execOptions
+	opts.filter, _ = cmd.Flags().GetString("filter")
+	opts.failover, _ = cmd.Flags().GetBool("failover")
+	opts.stdin, _ = cmd.Flags().GetBool("stdin")
+	opts.sudo = sudoUser(cmd)
+	opts.sudoPassword, _ = cmd.Flags().GetBool("sudo-password")
+	return opts
+}
+
+// quickFailure is how long the failed session is still considered as the
+// failed connection instead of the dropped one, such as refused or timed out
+const quickFailure = time.Minute
+
+// connect opens the ssh session into the host once the env is guarded
+func connect(cmd *cobra.Command, proxy *config.Proxy, t *tsh.TSH, host string, opts tsh.SessionOptions) error {
+	user, err := getUserLogin(cmd, &proxy.Node)
+	if err != nil {
+		return err
+	}
+	return connectAs(cmd, proxy, t, host, user, opts)
+}
+
+// connectAs opens the ssh session into the host as the user
+func connectAs(cmd *cobra.Command, proxy *config.Proxy, t *tsh.TSH, host, user string, opts tsh.SessionOptions) error {
+	if err := guardShell(proxy, host); err != nil {
+		return err
+	}
+	if err := openSession(cmd, proxy, t, host); err != nil {
+		return err
+	}
+	assumeApproved(cmd, t)
+
+	// print to give user information
+	infof(cmd, "login using %s %s\n", user, host)
+	auditEvent(audit.Event{Action: audit.ActionConnect, Env: proxy.Env, Host: host, User: user})
+
+	err := sessionHooked(proxy, host, user, func() error { return t.SSH(user, host, opts) })
+	recordConnection(proxy, host, err)
+	return sessionError(err)
+}
+
+// selectHost shows the picker of the proxy nodes along with the search
+// history of the env, the query is remembered once a host is picked
+func selectHost(proxy *config.Proxy, actions bool) (string, ui.Action) {
+	history, err := proxy.GetSearchHistory()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING! failed to load the search history, error: %v\n", err)
+	}
+
+	initial := initialSort(proxy)
+	p := &ui.Picker{Hosts: proxy.Node.ListHostname(), Sorts: pickerSorts(proxy, initial), Sort: initial,
+		History: history, Actions: actions, Notes: hostNotes(proxy)}
+	p.Columns, p.HostWidth = hostColumns(proxy, defaultPickerColumns)
+	p.HideColumns = loadState().HideColumns
+	if proxy.Color != "" || proxy.Badge != "" {
+		p.Header = proxy.EnvBadge() + "  " + proxy.Env
+		p.HeaderColor = proxy.Color
+	}
+	host, action := p.Run()
+	if host == "" {
+		return host, action
+	}
+	saveSort(proxy, p.Sort)
+	savePicked(proxy.Env, p.Query, p.HideColumns)
+	if err := proxy.AddSearchHistory(p.Query); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING! failed to save the search history, error: %v\n", err)
+	}
+	return host, action
+}
+
+// lookUpHost returns the host given by the argument, the user chooses one of the instances
+// once the hostname is listed more than once such as the reused name of the autoscaling group
+func lookUpHost(proxy *config.Proxy, host string) (string, error) {
+	items := proxy.Node.Instances(host)
+	switch len(items) {
+	case 0:
+		return "", fmt.Errorf("host %s is not found in the %s node cache", host, proxy.Env)
+	case 1:
+		return host, nil
+	}
+
+	// the picker only lists the instances disambiguated like the whole node
+	instances := *proxy
+	instances.Node.Items = items
+	picked, _ := selector.SelectHost(&instances, false)
+	if picked == "" {
+		return "", errNoHost
+	}
+	return picked, nil
+}
+
+// sessionOptions builds the session options from the flags
+func sessionOptions(cmd *cobra.Command) (tsh.SessionOptions, error) {
+	var opts tsh.SessionOptions
+	opts.ForwardAgent, _ = cmd.Flags().GetBool("forward-agent")
+	opts.Options, _ = cmd.Flags().GetStringArray("option")
+
+	x11, _ := cmd.Flags().GetBool("x11")
+	x11Trusted, _ := cmd.Flags().GetBool("x11-trusted")
+	switch {
+	case x11 && x11Trusted:
+		return opts, i18n.Errorf("-X & -Y can't be used together")
+	case x11:
+		opts.X11 = tsh.X11Untrusted
+	case x11Trusted:
+		opts.X11 = tsh.X11Trusted
+	}
+	return opts, opts.Validate()
+}
+
+// loadConfig loads the tpot configuration & sets up the audit log
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	isDev, err := cmd.Flags().GetBool("developer")
+	if err != nil {
+		return nil, i18n.Errorf("failed to get config due to %v", err)
+	}
+	if dir, _ := cmd.Flags().GetString("config-dir"); dir != "" {
+		config.SetDir(dir)
+	}
+	profile, _ := cmd.Flags().GetString("profile")
+	if err := config.SetProfile(profile); err != nil {
+		return nil, withCode(exitConfig, err)
+	}
+
+	step := trace.Start("load the configuration of %s", config.Dir)
+	cfg, err := config.NewConfig(isDev)
+	step.End(err)
+	if err != nil {
+		return nil, withCode(exitConfig, i18n.Errorf("failed to get config, error: %v", err))
+	}
+	if err := applyFlagDefaults(cmd, cfg.Flags); err != nil {
+		return nil, err
+	}
+	setQuiet()
+	if err := setLanguage(cmd); err != nil {
+		return nil, err
+	}
+	plain, _ := cmd.Flags().GetBool("plain")
+	ui.Plain = plain || cfg.Plain
+	if !validSort(pickerSort) {
+		return nil, usageErrorf("invalid --sort %s, use name, ip, last_used, latency or label.<key>", pickerSort)
+	}
+	pickerSortSet = cmd.Flags().Changed("sort")
+	if ui.Keys, err = ui.NewKeyMap(cfg.Keybindings.Preset, cfg.Keybindings.Keys); err != nil {
+		return nil, withCode(exitConfig, i18n.Errorf("invalid keybindings, error: %v", err))
+	}
+	if _, err := config.ParseColumns(cfg.PickerColumns); err != nil {
+		return nil, withCode(exitConfig, i18n.Errorf("invalid picker_columns, error: %v", err))
+	}
+	defaultPickerColumns = cfg.PickerColumns
+	if err := validateForwardProfiles(cfg.ForwardProfiles); err != nil {
+		return nil, withCode(exitConfig, i18n.Errorf("invalid forward_profiles, error: %v", err))
+	}
+	forwardProfiles = cfg.ForwardProfiles
+	if err := readTSHFlags(cmd); err != nil {
+		return nil, err
+	}
+	tsh.DefaultBrowserCommand = cfg.BrowserCommand
+
+	policy := config.CurrentPolicy()
+	if policy.MinTSHVersion != "" {
+		if tsh.MinVersion, err = tsh.ParseVersion(policy.MinTSHVersion); err != nil {
+			return nil, withCode(exitConfig, i18n.Errorf("invalid min_tsh_version of the policy, error: %v", err))
+		}
+	}
+
+	// every tsh process of the profile shares its own certificates
+	if home := config.TeleportHome(); home != "" {
+		if err := os.MkdirAll(home, 0700); err != nil {
+			return nil, err
+		}
+		if err := os.Setenv("TELEPORT_HOME", home); err != nil {
+			return nil, err
+		}
+	}
+
+	if sessionHooks, err = newSessionHooks(cfg.SessionHooks); err != nil {
+		return nil, withCode(exitConfig, err)
+	}
+	sessionTicket, _ = cmd.Flags().GetString("ticket")
+	if enrichSources, err = newEnrichSources(cfg.Enrichment); err != nil {
+		return nil, withCode(exitConfig, err)
+	}
+
+	if cfg.Audit.Enabled || policy.EnforceAudit {
+		// the config might be loaded again, such as by the alias
+		auditLogger.Close(auditShipTimeout)
+		auditLogger, err = newAuditLogger(cfg.Audit, policy.Defaults.AuditSinks)
 		if err != nil {
-			cmd.PrintErrln(err)
+			return nil, err
 		}
-	},
+	}
+	return cfg, nil
+}
+
+// readTSHFlags reads the flags passed through to tsh, it's read again once
+// the flag defaults of the environment are applied
+func readTSHFlags(cmd *cobra.Command) error {
+	tsh.DryRun, _ = cmd.Flags().GetBool("dry-run")
+	tsh.ExtraArgs, _ = cmd.Flags().GetStringArray("tsh-arg")
+	tsh.Browser, _ = cmd.Flags().GetString("browser")
+	tsh.CallbackURL, _ = cmd.Flags().GetString("callback-url")
+
+	// the machine-wide policy takes precedence over the user flags & config
+	if err := config.CurrentPolicy().CheckFlags(tsh.ExtraArgs); err != nil {
+		return withCode(exitConfig, err)
+	}
+	return nil
 }
 
 func getUserLogin(cmd *cobra.Command, node *config.Node) (string, error) {
@@ -211,39 +537,60 @@ func getUserLogin(cmd *cobra.Command, node *config.Node) (string, error) {
 		return "", err
 	}
 	if userLogin != "" {
+		if err := ensureUserPermitted(node.Status, userLogin); err != nil {
+			return "", err
+		}
 		return userLogin, nil
 	}
 
 	if node.Status == nil {
-		return "", fmt.Errorf("need to run using flag -a or -r to get the latest user login")
+		return "", i18n.Errorf("need to run using flag -a or -r to get the latest user login")
 	}
 
-	uiUser, err := ui.NewLoginUser(node.Status.UserLogins)
-	if err != nil {
-		return "", err
-	}
-	user, err := uiUser.Run()
+	user, err := selector.SelectUser(node.Status.UserLogins)
 	if err != nil {
 		return "", err
 	}
 	if user == "" {
-		return "", fmt.Errorf("user login must not be empty")
+		return "", i18n.Errorf("user login must not be empty")
 	}
 	return user, nil
 }
 
+// ensureUserPermitted warns the user upfront when the user login is not
+// in the permitted logins of the teleport roles, since tsh will only
+// return a cryptic access denied once the host is selected
+func ensureUserPermitted(status *config.ProxyStatus, user string) error {
+	if status == nil || len(status.UserLogins) == 0 || status.HasLogin(user) {
+		return nil
+	}
+
+	fmt.Print(i18n.Sprintf("WARNING! %s is not in your permitted logins [%s] granted by roles [%s], the login will likely be denied\n",
+		user, strings.Join(status.UserLogins, ", "), strings.Join(status.Roles, ", ")))
+	confirm, err := selector.Confirm(i18n.T("Do you want to continue"))
+	if err != nil {
+		return i18n.Errorf("failed to get confirmation, error: %v", err)
+	}
+	if !confirm {
+		return withCode(exitCancelled, i18n.Errorf("login as %s is cancelled", user))
+	}
+	return nil
+}
+
 func proxyEditHandler(c *config.Config, proxy *config.Proxy) error {
 	res, err := c.Edit(proxy.Env)
 	if err != nil {
-		fmt.Printf("failed to edit proxy, error: %v\n", err)
+		fmt.Print(i18n.Sprintf("failed to edit proxy, error: %v\n", err))
+	} else {
+		auditEvent(audit.Event{Action: audit.ActionConfig, Env: proxy.Env, Detail: "edit proxy"})
 	}
 
 	// if any changes, keep track any last changes until user confirm
 	// don't want to continue edit
 	for res != "" && err != nil {
-		confirm, err := ui.Confirm("Do You want to continue edit")
+		confirm, err := ui.Confirm(i18n.T("Do You want to continue edit"))
 		if err != nil {
-			fmt.Printf("failed to get confirmation, error: %v\n", err)
+			fmt.Print(i18n.Sprintf("failed to get confirmation, error: %v\n", err))
 			break
 		}
 		if !confirm {
@@ -251,10 +598,11 @@ func proxyEditHandler(c *config.Config, proxy *config.Proxy) error {
 		}
 		res, err = c.EditPlain(proxy.Env, res)
 		if err != nil {
-			fmt.Printf("failed to edit proxy, error: %v\n", err)
+			fmt.Print(i18n.Sprintf("failed to edit proxy, error: %v\n", err))
 		}
 		if err == nil {
-			fmt.Printf("Success to edit proxy\n")
+			auditEvent(audit.Event{Action: audit.ActionConfig, Env: proxy.Env, Detail: "edit proxy"})
+			fmt.Print(i18n.T("Success to edit proxy\n"))
 			break
 		}
 	}
@@ -264,21 +612,23 @@ func proxyEditHandler(c *config.Config, proxy *config.Proxy) error {
 func configHandler(cmd *cobra.Command, c *config.Config) error {
 	isEdit, err := cmd.Flags().GetBool("edit")
 	if err != nil {
-		return fmt.Errorf("failed to get flags edit, error: %v", err)
+		return i18n.Errorf("failed to get flags edit, error: %v", err)
 	}
 
 	if isEdit {
 		res, err := c.EditAll()
 		if err != nil {
-			fmt.Printf("failed to edit config, error: %v\n", err)
+			fmt.Print(i18n.Sprintf("failed to edit config, error: %v\n", err))
+		} else {
+			auditEvent(audit.Event{Action: audit.ActionConfig, Detail: "edit all proxies"})
 		}
 
 		// if any changes, keep track any last changes until user confirm
 		// don't want to continue edit
 		for res != "" && err != nil {
-			confirm, err := ui.Confirm("Do You want to continue edit")
+			confirm, err := ui.Confirm(i18n.T("Do You want to continue edit"))
 			if err != nil {
-				fmt.Printf("failed to get confirmation, error: %v\n", err)
+				fmt.Print(i18n.Sprintf("failed to get confirmation, error: %v\n", err))
 				break
 			}
 			if !confirm {
@@ -286,10 +636,11 @@ func configHandler(cmd *cobra.Command, c *config.Config) error {
 			}
 			res, err = c.EditAllPlain(res)
 			if err != nil {
-				fmt.Printf("failed to edit config, error: %v\n", err)
+				fmt.Print(i18n.Sprintf("failed to edit config, error: %v\n", err))
 			}
 			if err == nil {
-				fmt.Printf("Success to edit config\n")
+				auditEvent(audit.Event{Action: audit.ActionConfig, Detail: "edit all proxies"})
+				fmt.Print(i18n.T("Success to edit config\n"))
 				break
 			}
 		}
@@ -298,31 +649,48 @@ func configHandler(cmd *cobra.Command, c *config.Config) error {
 
 	isAdd, err := cmd.Flags().GetBool("add")
 	if err != nil {
-		return fmt.Errorf("failed to get flags edit, error: %v", err)
+		return i18n.Errorf("failed to get flags edit, error: %v", err)
 	}
 	if isAdd {
-		res, err := c.Add()
+		template, err := cmd.Flags().GetString("template")
 		if err != nil {
-			fmt.Printf("failed to add config, error: %v\n", err)
+			return i18n.Errorf("failed to get flags template, error: %v", err)
+		}
+
+		var res string
+		if template != "" {
+			res, err = c.AddFromTemplate(template, "")
+		} else {
+			res, err = c.Add()
+		}
+		if err != nil {
+			fmt.Print(i18n.Sprintf("failed to add config, error: %v\n", err))
+		} else {
+			auditEvent(audit.Event{Action: audit.ActionConfig, Detail: "add proxy"})
 		}
 
 		// if any changes, keep track any last changes until user confirm
 		// don't want to continue edit
 		for res != "" && err != nil {
-			confirm, err := ui.Confirm("Do You want to continue edit")
+			confirm, err := ui.Confirm(i18n.T("Do You want to continue edit"))
 			if err != nil {
-				fmt.Printf("failed to get confirmation, error: %v\n", err)
+				fmt.Print(i18n.Sprintf("failed to get confirmation, error: %v\n", err))
 				break
 			}
 			if !confirm {
 				break
 			}
-			res, err = c.AddPlain(res)
+			if template != "" {
+				res, err = c.AddFromTemplate(template, res)
+			} else {
+				res, err = c.AddPlain(res)
+			}
 			if err != nil {
-				fmt.Printf("failed to add config, error: %v\n", err)
+				fmt.Print(i18n.Sprintf("failed to add config, error: %v\n", err))
 			}
 			if err == nil {
-				fmt.Printf("Success to add config\n")
+				auditEvent(audit.Event{Action: audit.ActionConfig, Detail: "add proxy"})
+				fmt.Print(i18n.T("Success to add config\n"))
 				break
 			}
 		}
@@ -331,7 +699,7 @@ func configHandler(cmd *cobra.Command, c *config.Config) error {
 
 	str, err := c.String()
 	if err != nil {
-		return fmt.Errorf("failed to get config string, error:%v", err)
+		return i18n.Errorf("failed to get config string, error:%v", err)
 	}
 	fmt.Println(str)
 	return nil
@@ -346,77 +714,76 @@ func handleNode(cmd *cobra.Command, proxy *config.Proxy) (*config.Node, error) {
 	if err != nil {
 		return nil, err
 	}
+	isVerify, err := cmd.Flags().GetBool("verify")
+	if err != nil {
+		return nil, err
+	}
 	var nodes config.Node
-	if isRefresh || isAppend {
+	switch {
+	case isVerify:
+		nodes, err = getLatestNodeFrom(newVerifySource(os.Stderr), proxy, isAppend)
+		if err != nil {
+			return nil, err
+		}
+	case isRefresh || isAppend:
 		nodes, err = getLatestNode(proxy, isAppend)
 		if err != nil {
 			return nil, err
 		}
-	} else {
+	default:
+		step := trace.Start("read the node cache of %s", proxy.Env)
 		nodes, err = proxy.GetNode()
+		step.End(err)
 		if err != nil {
-			return nil, fmt.Errorf("failed to load nodes %v,\nyour might need -r to refresh/add the node cache", err)
+			return nil, i18n.Errorf("failed to load nodes %v,\nyour might need -r to refresh/add the node cache", err)
+		}
+	}
+
+	if maxAge, _ := cmd.Flags().GetDuration("max-age"); maxAge > 0 {
+		// the cache is kept whole, only the picked nodes are filtered
+		nodes.Items = nodes.SeenSince(now().Add(-maxAge))
+		if len(nodes.Items) == 0 {
+			return nil, i18n.Errorf("there's no nodes listed by a refresh within %s, refresh them by -r", maxAge)
 		}
 	}
 
 	return &nodes, nil
 }
 
+// getLatestNode fetches the latest nodes of the proxy & replaces the node cache,
+// the cached nodes are kept along with the latest ones on append
 func getLatestNode(proxy *config.Proxy, isAppend bool) (config.Node, error) {
+	return getLatestNodeFrom(fetcher, proxy, isAppend)
+}
 
-	var nodes config.Node
-	var err error
-	t := tsh.NewTSH(proxy)
-	if proxy.AuthConnector == "" {
-		nodes, err = scrapper.NewScrapper(*proxy).GetNodes()
-		if err != nil {
-			return nodes, fmt.Errorf("failed to get nodes: %v", err)
-		}
-	} else {
-		nodes, err = t.ListNodes()
-		if err != nil {
-			return nodes, fmt.Errorf("failed to get nodes: %v", err)
-		}
+// getLatestNodeFrom is getLatestNode fetching the nodes from the src
+func getLatestNodeFrom(src nodeSource, proxy *config.Proxy, isAppend bool) (config.Node, error) {
+	latest, err := src.LatestNodes(proxy)
+	if err != nil {
+		return latest, err
 	}
-
-	if len(nodes.Items) == 0 {
-		return nodes, fmt.Errorf("there's no nodes found")
+	if len(latest.Items) == 0 {
+		return latest, i18n.Errorf("there's no nodes found")
 	}
+	latest.Seen(now())
 
+	res := latest
 	if isAppend {
-		nodes, err = proxy.AppendNode(nodes)
+		res, err = proxy.AppendNode(latest)
 		if err != nil {
-			return nodes, fmt.Errorf("failed to append nodes, err: %v", err)
+			return res, i18n.Errorf("failed to append nodes, err: %v", err)
 		}
 	}
-
-	status, err := t.Status()
-	if err != nil && err != tsh.ErrUnsupportedVersion {
-		return nodes, err
-	}
-
-	// if the tsh version is not supported
-	// just hardcoded the user login to root for now
-	if err == tsh.ErrUnsupportedVersion {
-		version, err := t.Version()
-		if err != nil {
-			return config.Node{}, err
-		}
-
-		fmt.Printf("WARNING! minimum tsh version is Teleport v2.6.1 but got %s, the user login list is will be only root\n", version.Strings())
-		status = &config.ProxyStatus{
-			UserLogins: []string{"root"},
-		}
-	}
-
-	// append the status to node
-	nodes.Status = status
-	go proxy.UpdateNode(nodes)
-	return nodes, nil
+	res.Status = latest.Status
+	res.Source = latest.Source
+	enrichRefreshed(proxy, res.Items)
+	go proxy.UpdateNode(res)
+	return res, nil
 }
 
 type fwd struct {
 	tsh         *tsh.TSH
+	env         string
 	nodeHost    string
 	list        []*config.ForwardingNode
 	defaultUser string
@@ -424,13 +791,20 @@ type fwd struct {
 
 func (f *fwd) Run() error {
 	if len(f.list) == 0 {
-		return fmt.Errorf("forwarding configuration is empty")
+		return i18n.Errorf("forwarding configuration is empty")
+	}
+
+	var addrs []string
+	for _, node := range f.list {
+		addrs = append(addrs, node.Address())
 	}
+	recordStep(config.RunbookStep{Env: f.env, Hosts: []string{f.nodeHost}, User: f.defaultUser, Forward: addrs})
 
 	err := f.tsh.Login()
 	if err != nil {
-		return fmt.Errorf("failed to login, error: %v", err)
+		return loginError(err)
 	}
+	auditEvent(audit.Event{Action: audit.ActionLogin, Env: f.env})
 
 	for _, node := range f.list {
 		go func(node *config.ForwardingNode) {
@@ -496,5 +870,4 @@ func (f *sleepReader) Read(p []byte) (n int, err error) {
 	for {
 		time.Sleep(2 * time.Second)
 	}
-	return 0, io.EOF
 }
@@ -0,0 +1,1211 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adzimzf/tpot/api"
+	"github.com/adzimzf/tpot/audit"
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/remote"
+	"github.com/adzimzf/tpot/shell"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/adzimzf/tpot/ui"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSelector picks the hosts in order & confirms by the answer
+type fakeSelector struct {
+	hosts   []string
+	user    string
+	confirm bool
+	asked   []string
+	input   string
+}
+
+func (f *fakeSelector) SelectHost(*config.Proxy, bool) (string, ui.Action) {
+	if len(f.hosts) == 0 {
+		return "", ui.ActionSSH
+	}
+	host := f.hosts[0]
+	f.hosts = f.hosts[1:]
+	return host, ui.ActionSSH
+}
+
+func (f *fakeSelector) SelectUser([]string) (string, error) {
+	return f.user, nil
+}
+
+func (f *fakeSelector) Confirm(msg string) (bool, error) {
+	f.asked = append(f.asked, msg)
+	return f.confirm, nil
+}
+
+func (f *fakeSelector) ConfirmEnv(env string) (bool, error) {
+	f.asked = append(f.asked, env)
+	return f.input == env, nil
+}
+
+func (f *fakeSelector) Input(label string) (string, error) {
+	f.asked = append(f.asked, label)
+	return f.input, nil
+}
+
+// fakeSource returns the nodes & counts the fetches
+type fakeSource struct {
+	node    config.Node
+	fetched int
+}
+
+func (f *fakeSource) LatestNodes(*config.Proxy) (config.Node, error) {
+	f.fetched++
+	return f.node, nil
+}
+
+// fakeSession fails the connection to the hosts & moves the clock by the duration
+type fakeSession struct {
+	failed    map[string]bool
+	duration  time.Duration
+	clock     time.Time
+	connected []string
+
+	// forwards is the local forwards of the sessions by the host
+	forwards map[string][]string
+}
+
+func (f *fakeSession) connect(_ *cobra.Command, _ *config.Proxy, _ *tsh.TSH, host, user string, opts tsh.SessionOptions) error {
+	f.connected = append(f.connected, user+"@"+host)
+	if len(opts.LocalForwards) > 0 {
+		if f.forwards == nil {
+			f.forwards = map[string][]string{}
+		}
+		f.forwards[host] = opts.LocalForwards
+	}
+	f.clock = f.clock.Add(f.duration)
+	if f.failed[host] {
+		return &tsh.Error{Err: &exec.ExitError{}, Message: "connection refused"}
+	}
+	return nil
+}
+
+// withSeams replaces the seams of the root command until the test ends
+func withSeams(t *testing.T, sel hostSelector, src nodeSource, session *fakeSession) *config.MemoryStore {
+	oldSelector, oldFetcher, oldConnect, oldNow, oldDryRun := selector, fetcher, connectHost, now, tsh.DryRun
+	t.Cleanup(func() {
+		selector, fetcher, connectHost, now, tsh.DryRun = oldSelector, oldFetcher, oldConnect, oldNow, oldDryRun
+		config.UseStore(config.NewMemoryStore())
+	})
+	selector, fetcher, tsh.DryRun = sel, src, true
+	if session != nil {
+		connectHost = session.connect
+		now = func() time.Time { return session.clock }
+	}
+	config.CacheDir = t.TempDir() + "/"
+	store := config.NewMemoryStore()
+	config.UseStore(store)
+	return store
+}
+
+// withPolicy replaces the policy until the test ends
+func withPolicy(t *testing.T, p *config.Policy) {
+	old := config.CurrentPolicy()
+	t.Cleanup(func() { config.UsePolicy(old) })
+	config.UsePolicy(p)
+}
+
+// withAuditLog logs the audit events into a temporary log until the test ends
+func withAuditLog(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	old := auditLogger
+	t.Cleanup(func() { auditLogger = old })
+	auditLogger = audit.NewLogger(path, nil)
+	return path
+}
+
+// newTestCmd returns the command along with the flags of the root command used by the tests
+func newTestCmd(args ...string) *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Flags().BoolP("refresh", "r", false, "")
+	cmd.Flags().BoolP("append", "a", false, "")
+	cmd.Flags().Bool("verify", false, "")
+	cmd.Flags().Duration("max-age", 0, "")
+	cmd.Flags().StringP("user", "u", "", "")
+	cmd.Flags().String("exec", "", "")
+	cmd.Flags().Bool("auto-next", false, "")
+	cmd.Flags().Bool("with-forwards", false, "")
+	cmd.Flags().BoolP("forward-agent", "A", false, "")
+	cmd.Flags().StringArrayP("option", "o", nil, "")
+	cmd.Flags().BoolP("x11", "X", false, "")
+	cmd.Flags().BoolP("x11-trusted", "Y", false, "")
+	_ = cmd.Flags().Parse(args)
+	return cmd
+}
+
+func nodeOf(hosts ...string) config.Node {
+	var n config.Node
+	for _, h := range hosts {
+		n.Items = append(n.Items, config.Item{Hostname: h})
+	}
+	return n
+}
+
+func Test_handleNode(t *testing.T) {
+	status := &config.ProxyStatus{UserLogins: []string{"root"}}
+	latest := nodeOf("web-02")
+	latest.Status, latest.Source = status, config.SourceTSH
+
+	tests := []struct {
+		name      string
+		args      []string
+		wantHosts []string
+		wantFetch int
+	}{
+		{name: "cache", wantHosts: []string{"web-01"}},
+		{name: "refresh", args: []string{"-r"}, wantHosts: []string{"web-02"}, wantFetch: 1},
+		{name: "append", args: []string{"-a"}, wantHosts: []string{"web-01", "web-02"}, wantFetch: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := &fakeSource{node: latest}
+			store := withSeams(t, &fakeSelector{}, src, nil)
+			assert.NoError(t, store.UpdateNode("prod", nodeOf("web-01")))
+			proxy := &config.Proxy{Env: "prod"}
+
+			got, err := handleNode(newTestCmd(tt.args...), proxy)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantHosts, got.ListHostname())
+			assert.Equal(t, tt.wantFetch, src.fetched)
+			if tt.wantFetch == 0 {
+				return
+			}
+			assert.Equal(t, status, got.Status)
+			assert.Equal(t, config.SourceTSH, got.Source)
+			// the cache is replaced in the background
+			assert.Eventually(t, func() bool {
+				n, err := store.GetNode("prod")
+				return err == nil && len(n.Items) == len(tt.wantHosts) && n.Status != nil
+			}, time.Second, time.Millisecond)
+		})
+	}
+}
+
+func Test_verifySource(t *testing.T) {
+	web := config.Node{Items: []config.Item{
+		{Hostname: "web-01", Address: "10.0.0.1:3022"},
+		{Hostname: "web-02", Address: "10.0.0.2:3022"},
+	}}
+	ls := config.Node{Items: []config.Item{
+		{Hostname: "web-01", Address: "10.0.0.1:3022"},
+		{Hostname: "db-01", Address: "10.0.1.1:3022"},
+	}}
+	status := &config.ProxyStatus{UserLogins: []string{"root"}}
+
+	tests := []struct {
+		name       string
+		proxy      *config.Proxy
+		failed     string
+		wantSource string
+		wantOut    []string
+		wantErr    bool
+	}{
+		{
+			name:       "web UI",
+			proxy:      &config.Proxy{Env: "prod"},
+			wantSource: config.SourceScrapper,
+			wantOut:    []string{"2 hostnames differ", "web-02  only listed by the web UI", "db-01   only listed by tsh ls"},
+		},
+		{
+			name:       "auth connector",
+			proxy:      &config.Proxy{Env: "prod", AuthConnector: "okta"},
+			wantSource: config.SourceTSH,
+		},
+		{
+			name:    "failed source",
+			proxy:   &config.Proxy{Env: "prod"},
+			failed:  config.SourceTSH,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			v := verifySource{
+				fetch: func(proxy *config.Proxy, source string) (config.Node, error) {
+					if source == tt.failed {
+						return config.Node{}, errors.New("access denied")
+					}
+					n := web
+					if source == config.SourceTSH {
+						n = ls
+					}
+					n.Source = source
+					return n, nil
+				},
+				status: func(*config.Proxy) (*config.ProxyStatus, error) { return status, nil },
+				out:    &out,
+			}
+
+			got, err := v.LatestNodes(tt.proxy)
+			assert.Equal(t, tt.wantErr, err != nil, "error: %v", err)
+			if tt.wantErr {
+				return
+			}
+			assert.Equal(t, tt.wantSource, got.Source)
+			assert.Equal(t, status, got.Status)
+			for _, s := range tt.wantOut {
+				assert.Contains(t, out.String(), s)
+			}
+		})
+	}
+}
+
+func Test_handleNode_maxAge(t *testing.T) {
+	latest := nodeOf("web-02")
+	latest.Source = config.SourceTSH
+	store := withSeams(t, &fakeSelector{}, &fakeSource{node: latest}, nil)
+	assert.NoError(t, store.UpdateNode("prod", nodeOf("web-01")))
+
+	got, err := handleNode(newTestCmd("-a", "--max-age", "1h"), &config.Proxy{Env: "prod"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"web-02"}, got.ListHostname())
+	assert.Equal(t, config.SourceTSH, got.Items[0].Source)
+
+	// the appended node is still noted as not seen
+	proxy := &config.Proxy{Env: "prod"}
+	assert.Eventually(t, func() bool {
+		n, err := store.GetNode("prod")
+		proxy.Node = n
+		return err == nil && len(n.Items) == 2
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, map[string]string{"web-01": "not seen by the latest refresh"}, hostNotes(proxy))
+
+	// the nodes cached by the older version are never stamped
+	assert.NoError(t, store.UpdateNode("staging", nodeOf("web-01")))
+	_, err = handleNode(newTestCmd("--max-age", "1h"), &config.Proxy{Env: "staging"})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "there's no nodes listed by a refresh within 1h0m0s")
+	}
+}
+
+func Test_handleNode_missingCache(t *testing.T) {
+	withSeams(t, &fakeSelector{}, &fakeSource{}, nil)
+	_, err := handleNode(newTestCmd(), &config.Proxy{Env: "prod"})
+	assert.Error(t, err)
+}
+
+func Test_nodeHandler(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		hosts         []string
+		confirm       bool
+		duration      time.Duration
+		wantConnected []string
+		wantErr       bool
+	}{
+		{
+			name:          "connected",
+			hosts:         []string{"web-01"},
+			wantConnected: []string{"admin@web-01"},
+		},
+		{
+			name:          "auto next",
+			args:          []string{"--auto-next"},
+			hosts:         []string{"db-01"},
+			wantConnected: []string{"admin@db-01", "admin@db-02"},
+		},
+		{
+			name:          "pick another host",
+			hosts:         []string{"db-01", "web-01"},
+			confirm:       true,
+			wantConnected: []string{"admin@db-01", "admin@web-01"},
+		},
+		{
+			name:          "declined",
+			hosts:         []string{"db-01", "web-01"},
+			wantConnected: []string{"admin@db-01"},
+			wantErr:       true,
+		},
+		{
+			name:          "dropped session",
+			args:          []string{"--auto-next"},
+			hosts:         []string{"db-01"},
+			duration:      2 * quickFailure,
+			wantConnected: []string{"admin@db-01"},
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sel := &fakeSelector{hosts: tt.hosts, user: "admin", confirm: tt.confirm}
+			session := &fakeSession{failed: map[string]bool{"db-01": true}, duration: tt.duration}
+			withSeams(t, sel, &fakeSource{}, session)
+			proxy := &config.Proxy{Env: "prod", Node: nodeOf("db-01", "db-02", "web-01")}
+			proxy.Node.Status = &config.ProxyStatus{UserLogins: []string{"admin"}}
+
+			err := nodeHandler(newTestCmd(tt.args...), proxy)
+			assert.Equal(t, tt.wantErr, err != nil, "error: %v", err)
+			assert.Equal(t, tt.wantConnected, session.connected)
+		})
+	}
+}
+
+func Test_askReason(t *testing.T) {
+	tests := []struct {
+		name       string
+		proxy      *config.Proxy
+		ticket     string
+		input      string
+		wantTicket string
+		wantAsked  bool
+		wantErr    bool
+	}{
+		{name: "not required", proxy: &config.Proxy{Env: "staging"}},
+		{
+			name:       "required",
+			proxy:      &config.Proxy{Env: "prod", RequireReason: true},
+			input:      " INC-1234 ",
+			wantTicket: "INC-1234",
+			wantAsked:  true,
+		},
+		{
+			name:       "given by --ticket",
+			proxy:      &config.Proxy{Env: "prod", RequireReason: true},
+			ticket:     "CHG-42",
+			wantTicket: "CHG-42",
+		},
+		{
+			name:       "requested roles",
+			proxy:      &config.Proxy{Env: "prod", ReasonRoles: []string{"dba"}},
+			input:      "INC-1234",
+			wantTicket: "INC-1234",
+			wantAsked:  true,
+		},
+		{
+			name:      "empty reason",
+			proxy:     &config.Proxy{Env: "prod", RequireReason: true},
+			input:     "  ",
+			wantAsked: true,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := sessionTicket
+			t.Cleanup(func() { sessionTicket = old })
+			sessionTicket = tt.ticket
+			sel := &fakeSelector{input: tt.input}
+			withSeams(t, sel, &fakeSource{}, nil)
+
+			err := askReason(tt.proxy, "web-01")
+			assert.Equal(t, tt.wantErr, err != nil, "error: %v", err)
+			assert.Equal(t, tt.wantTicket, sessionTicket)
+			assert.Equal(t, tt.wantAsked, len(sel.asked) > 0)
+		})
+	}
+}
+
+func Test_findProxy_tshFlags(t *testing.T) {
+	oldDryRun, oldArgs, oldBrowser, oldCallback := tsh.DryRun, tsh.ExtraArgs, tsh.Browser, tsh.CallbackURL
+	oldEnv := errorEnv
+	t.Cleanup(func() {
+		tsh.DryRun, tsh.ExtraArgs, tsh.Browser, tsh.CallbackURL = oldDryRun, oldArgs, oldBrowser, oldCallback
+		errorEnv = oldEnv
+	})
+	cmd := &cobra.Command{Use: "ping"}
+	cmd.Flags().StringArray("tsh-arg", nil, "")
+	cmd.Flags().Bool("dry-run", false, "")
+	cmd.Flags().String("browser", "", "")
+	cmd.Flags().String("callback-url", "", "")
+	assert.NoError(t, cmd.Flags().Parse([]string{"--browser=firefox"}))
+	cfg := &config.Config{Proxies: []*config.Proxy{{Env: "prod", Flags: config.Flags{
+		"dry-run": "true", "tsh-arg": "--insecure", "browser": "none",
+	}}}}
+
+	// the tsh flags are read along with the defaults of the env
+	_, err := findProxy(cmd, cfg, "prod")
+	assert.NoError(t, err)
+	assert.True(t, tsh.DryRun)
+	assert.Equal(t, []string{"--insecure"}, tsh.ExtraArgs)
+	assert.Equal(t, "firefox", tsh.Browser, "the given flag is kept over the default")
+}
+
+func Test_lookUpHost(t *testing.T) {
+	proxy := &config.Proxy{Env: "prod", Node: config.Node{Items: []config.Item{
+		{Hostname: "web-01", Address: "10.0.0.1:3022"},
+		{Hostname: "web-01", Address: "10.0.0.9:3022"},
+		{Hostname: "db-01", Address: "10.0.1.1:3022"},
+	}}}
+	tests := []struct {
+		name    string
+		host    string
+		picked  []string
+		want    string
+		wantErr bool
+	}{
+		{name: "unique", host: "db-01", want: "db-01"},
+		{name: "instance", host: "web-01 (10.0.0.9)", want: "web-01 (10.0.0.9)"},
+		{name: "chosen instance", host: "web-01", picked: []string{"web-01 (10.0.0.9)"}, want: "web-01 (10.0.0.9)"},
+		{name: "closed picker", host: "web-01", wantErr: true},
+		{name: "not found", host: "web-03", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withSeams(t, &fakeSelector{hosts: tt.picked}, &fakeSource{}, nil)
+			got, err := lookUpHost(proxy, tt.host)
+			assert.Equal(t, tt.wantErr, err != nil, "error: %v", err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_ping_duplicates(t *testing.T) {
+	withSeams(t, &fakeSelector{}, &fakeSource{}, nil)
+	tsh.DryRun = false
+	proxy := &config.Proxy{Env: "prod", Address: testAddress, Node: config.Node{Items: []config.Item{
+		{Hostname: "web-01", Address: "10.0.0.1:3022"},
+		{Hostname: "web-01", Address: "10.0.0.9:3022"},
+	}}}
+	withFixtures(t, execFixture("10.0.0.1", pingCommand, "", 0), execFixture("10.0.0.9", pingCommand, "", 0))
+
+	items := proxy.Node.Items
+	results := ping(tsh.NewTSH(proxy), "admin", items, proxy.Node.NamesOf(items), 2, time.Minute)
+	var names []string
+	for _, r := range results {
+		assert.NoError(t, r.err, r.name)
+		names = append(names, r.name)
+	}
+	assert.ElementsMatch(t, []string{"web-01 (10.0.0.1)", "web-01 (10.0.0.9)"}, names)
+
+	recordLatencies(proxy, results)
+	stats, err := proxy.GetHostStats()
+	assert.NoError(t, err)
+	assert.Len(t, stats, 2, "every instance has its own latency")
+	assert.Contains(t, stats, "web-01 (10.0.0.1)")
+	assert.Contains(t, stats, "web-01 (10.0.0.9)")
+}
+
+func Test_broadcastHosts(t *testing.T) {
+	proxy := &config.Proxy{Env: "prod", Node: config.Node{Items: []config.Item{
+		{Hostname: "web-01", Address: "10.0.0.1:3022"},
+		{Hostname: "web-01", Address: "10.0.0.9:3022"},
+		{Hostname: "db-01", Address: "10.0.1.1:3022"},
+	}}}
+	tests := []struct {
+		name    string
+		args    []string
+		filter  string
+		picked  []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "filter", filter: "web-", want: []string{"web-01 (10.0.0.1)", "web-01 (10.0.0.9)"}},
+		{name: "argument", args: []string{"web-01", "db-01"}, picked: []string{"web-01 (10.0.0.9)"},
+			want: []string{"web-01 (10.0.0.9)", "db-01"}},
+		{name: "argument & filter", args: []string{"web-01"}, filter: "web-", picked: []string{"web-01 (10.0.0.9)"},
+			want: []string{"web-01 (10.0.0.9)", "web-01 (10.0.0.1)"}},
+		{name: "not found", args: []string{"web-03"}, wantErr: true},
+		{name: "none", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withSeams(t, &fakeSelector{hosts: tt.picked}, &fakeSource{}, nil)
+			cmd := &cobra.Command{}
+			cmd.Flags().String("filter", "", "")
+			cmd.Flags().Bool("include-maintenance", false, "")
+			_ = cmd.Flags().Parse([]string{"--filter=" + tt.filter})
+			got, err := broadcastHosts(cmd, proxy, tt.args)
+			assert.Equal(t, tt.wantErr, err != nil, "error: %v", err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_nodeHandler_closed(t *testing.T) {
+	withSeams(t, &fakeSelector{}, &fakeSource{}, &fakeSession{})
+	err := nodeHandler(newTestCmd(), &config.Proxy{Env: "prod"})
+	assert.True(t, errors.Is(err, errNoHost))
+}
+
+func Test_nodeHandler_withForwards(t *testing.T) {
+	old := forwardProfiles
+	t.Cleanup(func() { forwardProfiles = old })
+	forwardProfiles = []config.ForwardProfile{
+		{Name: "postgres", Match: "*-db-*", Forwards: []string{"5432:localhost:5432"}},
+		{Name: "metrics", Match: "env=prod", Forwards: []string{"9100:localhost:9100"}},
+	}
+
+	tests := []struct {
+		name         string
+		args         []string
+		hosts        []string
+		wantForwards map[string][]string
+	}{
+		{name: "without the flag", hosts: []string{"pay-db-01"}},
+		{
+			name:  "matching host",
+			args:  []string{"--with-forwards"},
+			hosts: []string{"pay-db-01"},
+			wantForwards: map[string][]string{
+				"pay-db-01": {"5432:localhost:5432", "9100:localhost:9100"},
+			},
+		},
+		{
+			name:         "other host",
+			args:         []string{"--with-forwards"},
+			hosts:        []string{"web-01"},
+			wantForwards: map[string][]string{"web-01": {"9100:localhost:9100"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sel := &fakeSelector{hosts: tt.hosts, user: "admin"}
+			session := &fakeSession{}
+			withSeams(t, sel, &fakeSource{}, session)
+			proxy := &config.Proxy{Env: "prod", Node: nodeOf("pay-db-01", "web-01")}
+			proxy.Node.Status = &config.ProxyStatus{UserLogins: []string{"admin"}}
+
+			assert.NoError(t, nodeHandler(newTestCmd(tt.args...), proxy))
+			assert.Equal(t, tt.wantForwards, session.forwards)
+		})
+	}
+}
+
+func Test_parseViaTarget(t *testing.T) {
+	tests := []struct {
+		target      string
+		defaultPort int
+		wantHost    string
+		wantPort    int
+		wantErr     bool
+	}{
+		{target: "10.1.2.3:8080", wantHost: "10.1.2.3", wantPort: 8080},
+		{target: "10.1.2.3:8080", defaultPort: 5432, wantHost: "10.1.2.3", wantPort: 8080},
+		{target: "10.1.2.3", defaultPort: 5432, wantHost: "10.1.2.3", wantPort: 5432},
+		{target: "db.internal", wantErr: true},
+		{target: ":8080", wantErr: true},
+		{target: "10.1.2.3:http", wantErr: true},
+		{target: "10.1.2.3:70000", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			host, port, err := parseViaTarget(tt.target, tt.defaultPort)
+			assert.Equal(t, tt.wantErr, err != nil, "error: %v", err)
+			assert.Equal(t, tt.wantHost, host)
+			assert.Equal(t, tt.wantPort, port)
+		})
+	}
+}
+
+func Test_viaClient_command(t *testing.T) {
+	mysql := viaClients[0]
+	assert.Equal(t, []string{"mysql", "-h", "127.0.0.1", "-P", "13306", "-u", "app", "orders"}, mysql.command(13306, []string{"-u", "app", "orders"}))
+}
+
+func Test_tailer_command(t *testing.T) {
+	tl := &tailer{files: []string{"/var/log/app.log", "/var/log/my app.log"}}
+	assert.Equal(t, "tail -n 10 -F -- /var/log/app.log '/var/log/my app.log'", tl.command(10))
+
+	tl.sudo = "root"
+	assert.Equal(t, sudoCommand("root", "tail -n 0 -F -- /var/log/app.log '/var/log/my app.log'", false), tl.command(0))
+}
+
+func Test_tailer_follow(t *testing.T) {
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantSleep []time.Duration
+	}{
+		{
+			name:      "reconnect the dropped connection from the end of the files",
+			errs:      []error{&tsh.FixtureExitError{Code: 255}, &tsh.FixtureExitError{Code: 255}},
+			wantCalls: 3,
+			wantSleep: []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name:      "give up the quick failure of the command",
+			errs:      []error{&tsh.FixtureExitError{Code: 1}},
+			wantCalls: 1,
+		},
+		{
+			name:      "stop once the tail exits",
+			errs:      []error{nil},
+			wantCalls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var commands []string
+			var slept []time.Duration
+			tl := &tailer{
+				files: []string{"/var/log/app.log"},
+				exec: func(_ context.Context, host, command string, _, _ io.Writer) error {
+					commands = append(commands, command)
+					if len(commands) > len(tt.errs) {
+						cancel()
+						return errors.New("signal: interrupt")
+					}
+					return tt.errs[len(commands)-1]
+				},
+				sleep: func(_ context.Context, d time.Duration) bool {
+					slept = append(slept, d)
+					return true
+				},
+			}
+			tl.follow(ctx, "web-01", 10, io.Discard, io.Discard, &sync.Mutex{})
+
+			assert.Len(t, commands, tt.wantCalls)
+			assert.Equal(t, "tail -n 10 -F -- /var/log/app.log", commands[0])
+			for _, c := range commands[1:] {
+				assert.Equal(t, "tail -n 0 -F -- /var/log/app.log", c)
+			}
+			assert.Equal(t, tt.wantSleep, slept)
+		})
+	}
+}
+
+func Test_topSampler_sample(t *testing.T) {
+	s := &topSampler{
+		parallel: 2,
+		timeout:  50 * time.Millisecond,
+		exec: func(ctx context.Context, host, command string, stdout, stderr io.Writer) error {
+			switch host {
+			case "web-01":
+				io.WriteString(stdout, "load=0.50 0.40 0.30\ncpus=2\nmem_total=4\nmem_available=1\ndisk_total=10\ndisk_used=5\n")
+			case "web-02":
+				io.WriteString(stderr, "ERROR: connection refused\n")
+				return errors.New("exit status 255")
+			case "web-03":
+				io.WriteString(stdout, "load=\n")
+			case "web-04":
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			return nil
+		},
+	}
+	rows := s.sample(context.Background(), []string{"web-01", "web-02", "web-03", "web-04"})
+	assert.Equal(t, []ui.TopRow{
+		{Host: "web-01", Sampled: true, Load: [3]float64{0.5, 0.4, 0.3}, CPUs: 2, MemUsed: 3 * 1024, MemTotal: 4 * 1024, DiskUsed: 5 * 1024, DiskTotal: 10 * 1024},
+		{Host: "web-02", Err: "exit status 255, ERROR: connection refused"},
+		{Host: "web-03", Err: "the load isn't found, the node might not be Linux"},
+		{Host: "web-04", Err: "timeout after 50ms"},
+	}, rows)
+}
+
+func Test_checkListenable(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer l.Close()
+
+	// the port held by another process is refused
+	assert.Error(t, checkListenable(l.Addr().String()))
+	assert.NoError(t, checkListenable("127.0.0.1:0"))
+}
+
+func Test_uniqueHosts(t *testing.T) {
+	assert.Equal(t, []string{"web-01", "web-02", "db-01"}, uniqueHosts([]string{"web-01", "web-02", "web-01", "db-01", "web-02"}))
+	assert.Empty(t, uniqueHosts(nil))
+}
+
+func Test_openSession_guarded(t *testing.T) {
+	forward := func(cmd *cobra.Command, proxy *config.Proxy) error {
+		return forwardHandler(cmd, proxy, []string{"8080:localhost:80"})
+	}
+	socks := func(cmd *cobra.Command, proxy *config.Proxy) error {
+		cmd.Flags().String("bind", "127.0.0.1", "")
+		cmd.Flags().Int("port", 0, "")
+		return socksHandler(cmd, proxy, []string{"web-01"})
+	}
+	tests := []struct {
+		name  string
+		proxy config.Proxy
+		input string
+		run   func(cmd *cobra.Command, proxy *config.Proxy) error
+	}{
+		{name: "protected -L", proxy: config.Proxy{Env: "prod", Protected: true}, run: forward},
+		{name: "protected proxy", proxy: config.Proxy{Env: "prod", Protected: true}, run: socks},
+		{name: "mistyped confirm_env -L", proxy: config.Proxy{Env: "prod", ConfirmEnv: true}, input: "staging", run: forward},
+		{name: "mistyped confirm_env proxy", proxy: config.Proxy{Env: "prod", ConfirmEnv: true}, input: "staging", run: socks},
+		{name: "no reason -L", proxy: config.Proxy{Env: "prod", RequireReason: true}, run: forward},
+		{name: "no reason proxy", proxy: config.Proxy{Env: "prod", RequireReason: true}, run: socks},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := sessionTicket
+			t.Cleanup(func() { sessionTicket = old })
+			sessionTicket = ""
+			sel := &fakeSelector{hosts: []string{"web-01"}, user: "admin", input: tt.input}
+			withSeams(t, sel, &fakeSource{}, nil)
+			// the confirmation is skipped on dry run
+			tsh.DryRun = false
+
+			proxy := tt.proxy
+			proxy.Node = nodeOf("web-01")
+			proxy.Node.Status = &config.ProxyStatus{UserLogins: []string{"admin"}}
+			err := tt.run(newTestCmd(), &proxy)
+			assert.Error(t, err)
+			assert.Equal(t, exitCancelled, exitCode(err), "error: %v", err)
+			assert.NotEmpty(t, sel.asked)
+		})
+	}
+}
+
+func Test_nodeFiles_guarded(t *testing.T) {
+	withPolicy(t, &config.Policy{Environments: map[string]config.EnvPolicy{"prod": {ExecDeny: "cmd:rm OR cmd:mv"}}})
+	proxy := &config.Proxy{Env: "prod"}
+	var audited []string
+	n := &nodeFiles{
+		host:  "web-01",
+		audit: func(detail string) { audited = append(audited, detail) },
+		guard: func(command string) error { return guardExec(proxy, command, "web-01") },
+	}
+
+	assert.Error(t, n.Remove("/var/log/app"))
+	assert.Error(t, n.Rename("/etc/app.conf", "/etc/app.conf.bak"))
+	assert.Empty(t, audited, "the denied changes are not audited")
+}
+
+func Test_openVSCode_denyShell(t *testing.T) {
+	withSeams(t, &fakeSelector{hosts: []string{"web-01"}, user: "admin"}, &fakeSource{}, nil)
+	withPolicy(t, &config.Policy{Environments: map[string]config.EnvPolicy{"prod": {DenyShell: true}}})
+	proxy := &config.Proxy{Env: "prod", Node: nodeOf("web-01")}
+	proxy.Node.Status = &config.ProxyStatus{UserLogins: []string{"admin"}}
+
+	cmd := newTestCmd()
+	cmd.Flags().String("editor", "code", "")
+	cmd.Flags().String("folder", "", "")
+	err := openVSCode(cmd, proxy)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "interactive shell is denied")
+}
+
+func Test_apiBackend_ConnectCommand_denyShell(t *testing.T) {
+	store := withSeams(t, &fakeSelector{}, &fakeSource{}, nil)
+	withPolicy(t, &config.Policy{Environments: map[string]config.EnvPolicy{"prod": {DenyShell: true}}})
+	assert.NoError(t, store.UpdateNode("prod", nodeOf("web-01")))
+	b := &apiBackend{cfg: &config.Config{Proxies: []*config.Proxy{{Env: "prod"}}}}
+
+	_, err := b.ConnectCommand("prod", "admin", "web-01")
+	assert.True(t, errors.Is(err, api.ErrForbidden), "error: %v", err)
+}
+
+func Test_exitCode(t *testing.T) {
+	remote := func(code int) error {
+		cmd := exec.Command("sh", "-c", fmt.Sprintf("exit %d", code))
+		return cmd.Run()
+	}
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "success", want: exitOK},
+		{name: "general failure", err: errors.New("failed"), want: exitFailure},
+		{name: "usage", err: usageErrorf("ENVIRONMENT is required"), want: exitUsage},
+		{name: "config", err: withCode(exitConfig, errors.New("invalid config")), want: exitConfig},
+		{name: "login", err: loginError(errors.New("expired")), want: exitLogin},
+		{name: "cancelled", err: errNoHost, want: exitCancelled},
+		{name: "wrapped", err: fmt.Errorf("wrapped: %w", withCode(exitConfig, errors.New("invalid config"))), want: exitConfig},
+		{name: "nil with code", err: withCode(exitConfig, nil), want: exitOK},
+		{name: "remote session", err: sessionError(remote(42)), want: 42},
+		{name: "remote session colliding", err: sessionError(remote(exitUsage)), want: exitUsage},
+		{name: "not exited session", err: sessionError(errors.New("connection refused")), want: exitFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, exitCode(tt.err))
+		})
+	}
+}
+
+// setupRoot sets up the root command once for the tests running it
+var setupRoot sync.Once
+
+func Test_run(t *testing.T) {
+	setupRoot.Do(setupRootCmd)
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{name: "unknown flag", args: []string{"--no-such-flag"}, want: exitUsage},
+		{name: "unknown env", args: []string{"no-such-env"}, want: exitUsage},
+		{name: "missing env", args: []string{"ping"}, want: exitUsage},
+		{name: "version", args: []string{"--version"}, want: exitOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withSeams(t, &fakeSelector{}, &fakeSource{}, nil)
+			dir, cacheDir := config.Dir, config.CacheDir
+			t.Cleanup(func() { config.Dir, config.CacheDir = dir, cacheDir })
+			rootCmd.SetOut(ioutil.Discard)
+			rootCmd.SetErr(ioutil.Discard)
+
+			assert.Equal(t, tt.want, run(append(tt.args, "--config-dir", t.TempDir())))
+		})
+	}
+}
+
+// testAddress is the proxy address of the exec fixtures
+const testAddress = "https://teleport.example.com:443"
+
+// execFixture is the fixture of running the command on the host by tsh ssh as admin
+func execFixture(host, command, stdout string, exitCode int) tsh.Fixture {
+	return tsh.Fixture{
+		Command:  "tsh",
+		Args:     []string{"ssh", "--proxy=teleport.example.com:443", "--user=", "-l", "admin", host, command},
+		Stdout:   stdout,
+		ExitCode: exitCode,
+	}
+}
+
+// withFixtures answers the tsh invocations by the fixtures until the test ends
+func withFixtures(t *testing.T, fixtures ...tsh.Fixture) {
+	dir := t.TempDir()
+	for i, f := range fixtures {
+		b, err := json.Marshal(f)
+		assert.NoError(t, err)
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("%03d.json", i)), b, 0600))
+	}
+	assert.NoError(t, tsh.ReplayFixtures(dir))
+	t.Cleanup(tsh.StopFixtures)
+}
+
+// newTestRunner returns the runner of the command on the hosts replayed by
+// the fixtures along with its output
+func newTestRunner(t *testing.T, command string, opts execOptions, hosts ...string) (*execRunner, *bytes.Buffer, *bytes.Buffer) {
+	withSeams(t, &fakeSelector{}, &fakeSource{}, nil)
+	tsh.DryRun = false
+	proxy := &config.Proxy{Env: "prod", Address: testAddress, Node: nodeOf(hosts...)}
+	var stdout, stderr bytes.Buffer
+	r := &execRunner{tsh: tsh.NewTSH(proxy), env: "prod", user: "admin", command: command, opts: opts,
+		stdout: &stdout, stderr: &stderr}
+	return r, &stdout, &stderr
+}
+
+func Test_saveExecOutput(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{host: "web-01", want: "web-01"},
+		{host: "web-01 (10.0.0.9)", want: "web-01 (10.0.0.9)"},
+		{host: "../../etc/cron.d/evil", want: ".._.._etc_cron.d_evil"},
+		{host: "/tmp/evil", want: "_tmp_evil"},
+		{host: "..", want: "__"},
+		{host: ".", want: "_"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			dir := t.TempDir()
+			res := execResult{Host: tt.host, Status: execStatusFailed, ExitCode: 3}
+			assert.NoError(t, saveExecOutput(dir, res, []byte("out\n"), []byte("err\n")))
+
+			files, err := filepath.Glob(filepath.Join(dir, "*"))
+			assert.NoError(t, err)
+			assert.Len(t, files, 3, "every file is written inside the output directory")
+			for ext, want := range map[string]string{".stdout": "out\n", ".stderr": "err\n", ".exit_code": "3\n"} {
+				b, err := ioutil.ReadFile(filepath.Join(dir, tt.want+ext))
+				assert.NoError(t, err)
+				assert.Equal(t, want, string(b))
+			}
+		})
+	}
+}
+
+func Test_execRunner_runOn_outputDir(t *testing.T) {
+	dir := t.TempDir()
+	r, stdout, _ := newTestRunner(t, "uptime", execOptions{outputDir: dir}, "web-01")
+	withFixtures(t, execFixture("web-01", "uptime", "up 3 days\n", 2))
+
+	res := r.runOn("web-01", r.stdout, r.stderr)
+	assert.Equal(t, execStatusFailed, res.Status)
+	assert.Equal(t, 2, res.ExitCode)
+	assert.Equal(t, "up 3 days\n", stdout.String())
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "web-01.stdout"))
+	assert.NoError(t, err)
+	assert.Equal(t, "up 3 days\n", string(b))
+	b, err = ioutil.ReadFile(filepath.Join(dir, "web-01.exit_code"))
+	assert.NoError(t, err)
+	assert.Equal(t, "2\n", string(b))
+}
+
+func Test_execRunner_runAll_batches(t *testing.T) {
+	hosts := []string{"web-01", "web-02", "web-03", "web-04", "web-05"}
+	r, stdout, _ := newTestRunner(t, "uptime", execOptions{parallel: 1, batchSize: 2}, hosts...)
+	var fixtures []tsh.Fixture
+	for _, host := range hosts {
+		fixtures = append(fixtures, execFixture(host, "uptime", "up "+host+"\n", 0))
+	}
+	withFixtures(t, fixtures...)
+
+	results := r.runAll(hosts)
+	assert.Len(t, results, len(hosts))
+	for i, res := range results {
+		assert.Equal(t, hosts[i], res.Host)
+		assert.Equal(t, execStatusOK, res.Status)
+	}
+
+	var batches []string
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if strings.HasPrefix(line, "batch ") {
+			batches = append(batches, line)
+		}
+	}
+	assert.Equal(t, []string{"batch 1-2 of 5 hosts", "batch 3-4 of 5 hosts", "batch 5-5 of 5 hosts"}, batches)
+	assert.Less(t, strings.Index(stdout.String(), "up web-02"), strings.Index(stdout.String(), "batch 3-4"),
+		"the next batch waits for the previous one")
+}
+
+func Test_execRunner_runAll_prefixed(t *testing.T) {
+	hosts := []string{"web-01", "web-02", "web-03"}
+	r, stdout, stderr := newTestRunner(t, "tail", execOptions{parallel: 3}, hosts...)
+	var fixtures []tsh.Fixture
+	for _, host := range hosts {
+		fixtures = append(fixtures, execFixture(host, "tail", "first "+host+"\nsecond "+host+"\nlast "+host, 0))
+	}
+	withFixtures(t, fixtures...)
+
+	r.runAll(hosts)
+	assert.Empty(t, stderr.String())
+	lines := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+	assert.Len(t, lines, 9)
+	byHost := map[string][]string{}
+	for _, line := range lines {
+		parts := strings.SplitN(line, " | ", 2)
+		assert.Len(t, parts, 2, "every line is prefixed: %q", line)
+		byHost[parts[0]] = append(byHost[parts[0]], parts[1])
+	}
+	for _, host := range hosts {
+		assert.Equal(t, []string{"first " + host, "second " + host, "last " + host}, byHost[host])
+	}
+}
+
+func Test_prefixWriter_concurrent(t *testing.T) {
+	var out bytes.Buffer
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, host := range []string{"web-01", "web-02", "web-03", "web-04"} {
+		wg.Add(1)
+		go func(host string) {
+			defer wg.Done()
+			w := &prefixWriter{prefix: host + " | ", out: &out, mu: &mu}
+			for i := 0; i < 100; i++ {
+				// the line is split across the writes
+				fmt.Fprintf(w, "line %d ", i)
+				fmt.Fprintf(w, "of %s\n", host)
+			}
+			fmt.Fprint(w, "no newline")
+			w.Flush()
+		}(host)
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	assert.Len(t, lines, 4*101)
+	next := map[string]int{}
+	for _, line := range lines {
+		parts := strings.SplitN(line, " | ", 2)
+		if !assert.Len(t, parts, 2, "line %q", line) {
+			continue
+		}
+		host := parts[0]
+		if parts[1] == "no newline" {
+			assert.Equal(t, 100, next[host])
+			continue
+		}
+		assert.Equal(t, fmt.Sprintf("line %d of %s", next[host], host), parts[1])
+		next[host]++
+	}
+}
+
+func Test_execRunner_runCanary(t *testing.T) {
+	hosts := []string{"web-01", "web-02", "web-03", "web-04"}
+	tests := []struct {
+		name        string
+		canaryCode  int
+		confirm     bool
+		wantHosts   []string
+		wantStopped bool
+	}{
+		{name: "failed canary stops", canaryCode: 1, wantHosts: hosts[:1], wantStopped: true},
+		{name: "failed canary continued", canaryCode: 1, confirm: true, wantHosts: hosts},
+		{name: "healthy canary stopped", wantHosts: hosts[:1], wantStopped: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, stdout, _ := newTestRunner(t, "systemctl restart app", execOptions{canary: 1, parallel: 1}, hosts...)
+			sel := &fakeSelector{confirm: tt.confirm}
+			selector = sel
+			fixtures := []tsh.Fixture{execFixture("web-01", "systemctl restart app", "", tt.canaryCode)}
+			for _, host := range hosts[1:] {
+				fixtures = append(fixtures, execFixture(host, "systemctl restart app", "", 0))
+			}
+			withFixtures(t, fixtures...)
+
+			results, err := r.runCanary(hosts)
+			assert.Equal(t, tt.wantStopped, errors.Is(err, errCanaryStopped), "error: %v", err)
+			var ran []string
+			for _, res := range results {
+				ran = append(ran, res.Host)
+			}
+			assert.Equal(t, tt.wantHosts, ran)
+			assert.Equal(t, []string{"Do you want to continue to the remaining 3 hosts"}, sel.asked)
+			assert.Equal(t, tt.canaryCode != 0, strings.Contains(stdout.String(), "failed on 1 canary hosts"))
+		})
+	}
+}
+
+func Test_execRunner_runFailover(t *testing.T) {
+	hosts := []string{"web-01", "web-02", "web-03"}
+	tests := []struct {
+		name      string
+		codes     []int
+		wantHosts []string
+		wantErr   string
+	}{
+		{name: "stops at the first success", codes: []int{1, 0, 0}, wantHosts: hosts[:2]},
+		{name: "first host succeeds", codes: []int{0, 0, 0}, wantHosts: hosts[:1]},
+		{
+			name: "every host fails", codes: []int{1, 2, 1}, wantHosts: hosts,
+			wantErr: "the command is failed on all 3 hosts: connecting to web-01 as admin: exit status 1; " +
+				"connecting to web-02 as admin: exit status 2; connecting to web-03 as admin: exit status 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _, stderr := newTestRunner(t, "uptime", execOptions{failover: true}, hosts...)
+			var fixtures []tsh.Fixture
+			for i, host := range hosts {
+				fixtures = append(fixtures, execFixture(host, "uptime", "", tt.codes[i]))
+			}
+			withFixtures(t, fixtures...)
+
+			results := r.runFailover(hosts)
+			var ran []string
+			for _, res := range results {
+				ran = append(ran, res.Host)
+			}
+			assert.Equal(t, tt.wantHosts, ran)
+			assert.Equal(t, tt.codes[0] != 0, strings.Contains(stderr.String(), "failing over to web-02"))
+
+			err := execError(results, true)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+			assert.Equal(t, exitFailure, exitCode(err))
+		})
+	}
+}
+
+func Test_execFlags_sudo(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{want: ""},
+		{args: []string{"--sudo"}, want: "root"},
+		{args: []string{"--sudo="}, want: "root"},
+		{args: []string{"--sudo=deploy"}, want: "deploy"},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			cmd := &cobra.Command{}
+			addSudoFlags(cmd)
+			assert.NoError(t, cmd.Flags().Parse(tt.args))
+			assert.Equal(t, tt.want, execFlags(cmd).sudo)
+		})
+	}
+}
+
+func Test_sudoCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     string
+		command  string
+		password bool
+		want     string
+	}{
+		{name: "plain", user: "root", command: "uptime", want: "sudo -n -u root -- sh -c uptime"},
+		{name: "password", user: "deploy", command: "uptime", password: true, want: "sudo -S -p '' -u deploy -- sh -c uptime"},
+		{name: "empty user", command: "uptime", want: "sudo -n -u root -- sh -c uptime"},
+		{name: "quotes", user: "root", command: `echo 'a b' "c"`, want: `sudo -n -u root -- sh -c 'echo '"'"'a b'"'"' "c"'`},
+		{name: "dollar", user: "root", command: "echo $HOME", want: "sudo -n -u root -- sh -c 'echo $HOME'"},
+		{name: "semicolon", user: "root", command: "cd /tmp; ls", want: "sudo -n -u root -- sh -c 'cd /tmp; ls'"},
+		{name: "user with space", user: "a b", command: "id", want: "sudo -n -u 'a b' -- sh -c id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sudoCommand(tt.user, tt.command, tt.password)
+			assert.Equal(t, tt.want, got)
+
+			// the remote shell splits it back into the command run by sh -c
+			args, err := shell.Split(got)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.command, args[len(args)-1])
+		})
+	}
+}
+
+func Test_remoteExec_audited(t *testing.T) {
+	withSeams(t, &fakeSelector{}, &fakeSource{}, nil)
+	tsh.DryRun = false
+	path := withAuditLog(t)
+	proxy := &config.Proxy{Env: "prod", Address: testAddress, Node: nodeOf("web-01")}
+	withFixtures(t,
+		execFixture("web-01", remote.FactsCommand, "os=Ubuntu 22.04\n", 0),
+		execFixture("web-01", remote.ListeningPortsCommand,
+			"LISTEN 0      128    0.0.0.0:22               0.0.0.0:*     users:((\"sshd\",pid=1,fd=3))\n", 0),
+	)
+	t2 := tsh.NewTSH(proxy)
+
+	facts, err := collectFacts(proxy, t2, "admin", "web-01")
+	assert.NoError(t, err)
+	assert.Equal(t, "Ubuntu 22.04", facts.Facts["os"])
+	ports, err := listeningPorts(proxy, t2, "admin", "web-01")
+	assert.NoError(t, err)
+	assert.Len(t, ports, 1)
+
+	events, err := audit.Read(path)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 2) {
+		for _, e := range events {
+			assert.Equal(t, audit.ActionExec, e.Action)
+			assert.Equal(t, "web-01", e.Host)
+			assert.Equal(t, "admin", e.User)
+		}
+		assert.Equal(t, "collect the facts", events[0].Detail)
+		assert.Equal(t, remote.ListeningPortsCommand, events[1].Command)
+	}
+}
+
+func Test_slackOf(t *testing.T) {
+	defer os.Setenv("TPOT_SLACK_SIGNING_SECRET", os.Getenv("TPOT_SLACK_SIGNING_SECRET"))
+	os.Unsetenv("TPOT_SLACK_SIGNING_SECRET")
+
+	assert.Nil(t, slackOf(&config.Config{}))
+	cfg := &config.Config{Slack: config.Slack{SigningSecret: "rotated", Envs: []string{"staging*"}}}
+	assert.Equal(t, &api.Slack{SigningSecret: "rotated", Envs: []string{"staging*"}}, slackOf(cfg))
+}
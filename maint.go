@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/i18n"
+	"github.com/spf13/cobra"
+)
+
+const maintExample = `
+tpot maint set prod web-01 web-02 --until 2h                // Put web-01 & web-02 in maintenance for 2 hours
+tpot maint set prod --filter 'web-*' -m "kernel upgrade"    // Put every web host in maintenance for an hour with the reason
+tpot maint ls prod                                          // Show the production hosts in maintenance
+tpot maint clear prod web-01                                // End the maintenance of web-01 before it expires
+`
+
+var maintCmd = &cobra.Command{
+	Use:     "maint",
+	Short:   "Put the hosts in maintenance, they're left out of the multi-host exec",
+	Long:    "Mark the drained hosts in maintenance until the window expires, the picker notes them & exec, broadcast & the runbooks filtering the hosts leave them out",
+	Example: maintExample,
+}
+
+var maintSetCmd = &cobra.Command{
+	Use:   "set <ENVIRONMENT> [HOST...]",
+	Short: "Put the hosts in maintenance until the window expires",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		proxy, hosts, err := maintHosts(cmd, args)
+		if err != nil {
+			return err
+		}
+		until, _ := cmd.Flags().GetDuration("until")
+		if until <= 0 {
+			return usageErrorf("--until must be positive, such as 2h")
+		}
+		reason, _ := cmd.Flags().GetString("reason")
+
+		now := time.Now()
+		m := config.Maintenance{Until: now.Add(until), Reason: reason}
+		if err := proxy.SetMaintenance(hosts, m, now); err != nil {
+			return fmt.Errorf("failed to save the maintenance of %s, error: %v", proxy.Env, err)
+		}
+		infof(cmd, "%d hosts of %s are in maintenance until %s\n", len(hosts), proxy.Env, formatTime(m.Until))
+		return nil
+	},
+}
+
+var maintClearCmd = &cobra.Command{
+	Use:   "clear <ENVIRONMENT> [HOST...]",
+	Short: "End the maintenance of the hosts before it expires",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		proxy, hosts, err := maintHosts(cmd, args)
+		if err != nil {
+			return err
+		}
+		cleared, err := proxy.ClearMaintenance(hosts, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to save the maintenance of %s, error: %v", proxy.Env, err)
+		}
+		infof(cmd, "%d hosts of %s are out of maintenance\n", len(cleared), proxy.Env)
+		return nil
+	},
+}
+
+var maintLsCmd = &cobra.Command{
+	Use:   "ls <ENVIRONMENT>",
+	Short: "Show the hosts in maintenance, the earliest to expire first",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return usageErrorf("ENVIRONMENT is required")
+		}
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+		proxy, err := findProxy(cmd, cfg, args[0])
+		if err != nil {
+			return err
+		}
+
+		windows, err := proxy.GetMaintenance(time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to load the maintenance of %s, error: %v", proxy.Env, err)
+		}
+		if len(windows) == 0 {
+			infof(cmd, "there's no host of %s in maintenance\n", proxy.Env)
+			return nil
+		}
+		hosts := make([]string, 0, len(windows))
+		for host := range windows {
+			hosts = append(hosts, host)
+		}
+		sort.Slice(hosts, func(i, j int) bool {
+			a, b := windows[hosts[i]].Until, windows[hosts[j]].Until
+			if !a.Equal(b) {
+				return a.Before(b)
+			}
+			return hosts[i] < hosts[j]
+		})
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "HOST\tUNTIL\tREASON")
+		for _, host := range hosts {
+			m := windows[host]
+			reason := m.Reason
+			if reason == "" {
+				reason = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", host, formatTime(m.Until), reason)
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	for _, c := range []*cobra.Command{maintSetCmd, maintClearCmd} {
+		c.Flags().String("filter", "", "the hosts match the filter expression along with the HOST arguments")
+	}
+	maintSetCmd.Flags().Duration("until", time.Hour, "how long the hosts are in maintenance")
+	maintSetCmd.Flags().StringP("reason", "m", "", "why the hosts are in maintenance, shown by tpot maint ls")
+	maintCmd.AddCommand(maintSetCmd, maintClearCmd, maintLsCmd)
+	rootCmd.AddCommand(maintCmd)
+}
+
+// maintHosts returns the proxy of the environment argument along with the
+// hosts of the HOST arguments & --filter, they must be in the node cache
+func maintHosts(cmd *cobra.Command, args []string) (*config.Proxy, []string, error) {
+	if len(args) < 1 {
+		return nil, nil, usageErrorf("ENVIRONMENT is required")
+	}
+	proxy, err := loadProxy(cmd, args[0])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hosts := args[1:]
+	for _, host := range hosts {
+		if _, ok := proxy.Node.LookUp(host); !ok {
+			return nil, nil, fmt.Errorf("host %s is not found in the %s node cache", host, proxy.Env)
+		}
+	}
+	if filter, _ := cmd.Flags().GetString("filter"); filter != "" {
+		items, err := proxy.FilterNodes(filter)
+		if err != nil {
+			return nil, nil, usageErrorf("invalid --filter, error: %v", err)
+		}
+		for _, item := range items {
+			hosts = append(hosts, item.Hostname)
+		}
+	}
+	if len(hosts) == 0 {
+		return nil, nil, usageErrorf("Pick at least one host by the argument or --filter")
+	}
+	return proxy, hosts, nil
+}
+
+// skipMaintenance leaves the hosts in maintenance out of the hosts matched
+// for the multi-host commands, unless --include-maintenance is given
+func skipMaintenance(cmd *cobra.Command, proxy *config.Proxy, hosts []string) ([]string, error) {
+	if include, _ := cmd.Flags().GetBool("include-maintenance"); include {
+		return hosts, nil
+	}
+	windows, err := proxy.GetMaintenance(time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING! failed to load the maintenance, error: %v\n", err)
+		return hosts, nil
+	}
+	if len(windows) == 0 {
+		return hosts, nil
+	}
+
+	res := make([]string, 0, len(hosts))
+	var skipped []string
+	for _, host := range hosts {
+		if _, ok := windows[host]; ok {
+			skipped = append(skipped, host)
+			continue
+		}
+		res = append(res, host)
+	}
+	if len(skipped) == 0 {
+		return hosts, nil
+	}
+	if len(res) == 0 {
+		return nil, fmt.Errorf("all the %d hosts are in maintenance, --include-maintenance runs on them anyway", len(skipped))
+	}
+	infof(cmd, "skipping %d hosts in maintenance: %s\n", len(skipped), strings.Join(skipped, ", "))
+	return res, nil
+}
+
+// addMaintenanceFlag adds the flag running the multi-host command on the hosts in maintenance too
+func addMaintenanceFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("include-maintenance", false, "run on the hosts in maintenance too")
+}
+
+// maintenanceNotes returns the note of the hosts in maintenance to be
+// shown in the picker, nil if there's none
+func maintenanceNotes(proxy *config.Proxy) map[string]string {
+	windows, err := proxy.GetMaintenance(time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING! failed to load the maintenance, error: %v\n", err)
+		return nil
+	}
+	var notes map[string]string
+	for host, m := range windows {
+		if notes == nil {
+			notes = map[string]string{}
+		}
+		notes[host] = i18n.Sprintf("in maintenance until %s", m.Until.Local().Format("Jan 2 15:04"))
+	}
+	return notes
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/adzimzf/tpot/manpage"
+	"github.com/spf13/cobra"
+)
+
+const manExample = `
+tpot man                          // Install the man pages for this user
+tpot man --system                 // Install the man pages for every user
+tpot man --dir ./pkg/share/man1   // Write the man pages into the package directory
+`
+
+var manCmd = &cobra.Command{
+	Use:     "man",
+	Short:   "Generate & install the man pages of every command",
+	Example: manExample,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, _ := cmd.Flags().GetString("dir")
+		if dir == "" {
+			system, _ := cmd.Flags().GetBool("system")
+			var err error
+			if dir, err = manDir(system); err != nil {
+				return err
+			}
+		}
+
+		h := manpage.Header{Source: "tpot " + Version, Manual: "tpot Manual"}
+		files, err := manpage.GenerateTree(rootCmd, h, dir)
+		if err != nil {
+			return fmt.Errorf("failed to generate the man pages, error: %v", err)
+		}
+		infof(cmd, "%d man pages are written into %s\n", len(files), dir)
+		return nil
+	},
+}
+
+func init() {
+	manCmd.Flags().Bool("system", false, "install for every user, it may require root")
+	manCmd.Flags().String("dir", "", "write the man pages into the directory instead, such as the package staging directory")
+	rootCmd.AddCommand(manCmd)
+}
+
+// manDir returns the section 1 directory man searches by default
+func manDir(system bool) (string, error) {
+	if system {
+		prefix := "/usr/local"
+		if runtime.GOOS == "darwin" {
+			prefix = homebrewPrefix()
+		}
+		return filepath.Join(prefix, "share/man/man1"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(xdgHome("XDG_DATA_HOME", home, ".local/share"), "man/man1"), nil
+}
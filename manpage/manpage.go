@@ -0,0 +1,203 @@
+// Package manpage generates the roff man pages of the cobra commands,
+// one page per command named like tpot-stats-hosts.1
+package manpage
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Header is the title line of the pages
+type Header struct {
+	// Section is the manual section, default is 1
+	Section string
+
+	// Source is the program & its version such as tpot 1.2.0
+	Source string
+
+	// Manual is the manual title such as tpot Manual
+	Manual string
+
+	// Date is when the pages are generated, default is now
+	// or $SOURCE_DATE_EPOCH for the reproducible packages
+	Date time.Time
+}
+
+func (h Header) section() string {
+	if h.Section == "" {
+		return "1"
+	}
+	return h.Section
+}
+
+func (h Header) date() time.Time {
+	if !h.Date.IsZero() {
+		return h.Date
+	}
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+		var sec int64
+		if _, err := fmt.Sscan(epoch, &sec); err == nil {
+			return time.Unix(sec, 0).UTC()
+		}
+	}
+	return time.Now()
+}
+
+// GenerateTree writes the pages of the command & its sub commands into the dir,
+// the hidden commands are left out. It returns the written file names
+func GenerateTree(cmd *cobra.Command, h Header, dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	var files []string
+	var generate func(c *cobra.Command) error
+	generate = func(c *cobra.Command) error {
+		for _, sub := range c.Commands() {
+			if !available(sub) {
+				continue
+			}
+			if err := generate(sub); err != nil {
+				return err
+			}
+		}
+		buf := &bytes.Buffer{}
+		if err := Generate(c, h, buf); err != nil {
+			return err
+		}
+		name := filepath.Join(dir, FileName(c, h))
+		if err := ioutil.WriteFile(name, buf.Bytes(), 0644); err != nil {
+			return err
+		}
+		files = append(files, name)
+		return nil
+	}
+	if err := generate(cmd); err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// FileName returns the page file name of the command such as tpot-stats-hosts.1
+func FileName(cmd *cobra.Command, h Header) string {
+	return pageName(cmd) + "." + h.section()
+}
+
+// Generate writes the page of the command into w
+func Generate(cmd *cobra.Command, h Header, w io.Writer) error {
+	cmd.InitDefaultHelpFlag()
+	buf := &bytes.Buffer{}
+	name := pageName(cmd)
+
+	fmt.Fprintf(buf, ".TH %q %q %q %q %q\n", strings.ToUpper(name), h.section(),
+		h.date().Format("Jan 2006"), h.Source, h.Manual)
+	buf.WriteString(".nh\n.ad l\n")
+
+	buf.WriteString(".SH NAME\n")
+	fmt.Fprintf(buf, "%s \\- %s\n", escape(name), escape(cmd.Short))
+
+	buf.WriteString(".SH SYNOPSIS\n")
+	fmt.Fprintf(buf, "\\fB%s\\fP\n", escape(cmd.UseLine()))
+
+	buf.WriteString(".SH DESCRIPTION\n")
+	description := cmd.Long
+	if description == "" {
+		description = cmd.Short
+	}
+	fmt.Fprintf(buf, "%s\n", escapeText(description))
+
+	writeFlags(buf, "OPTIONS", cmd.NonInheritedFlags())
+	writeFlags(buf, "OPTIONS INHERITED FROM PARENT COMMANDS", cmd.InheritedFlags())
+
+	if example := strings.Trim(cmd.Example, "\n"); example != "" {
+		buf.WriteString(".SH EXAMPLE\n.PP\n.RS\n.nf\n")
+		fmt.Fprintf(buf, "%s\n", escapeText(example))
+		buf.WriteString(".fi\n.RE\n")
+	}
+
+	var seeAlso []string
+	if cmd.HasParent() {
+		seeAlso = append(seeAlso, pageName(cmd.Parent()))
+	}
+	for _, sub := range cmd.Commands() {
+		if available(sub) {
+			seeAlso = append(seeAlso, pageName(sub))
+		}
+	}
+	if len(seeAlso) > 0 {
+		buf.WriteString(".SH SEE ALSO\n")
+		for i, page := range seeAlso {
+			seeAlso[i] = fmt.Sprintf("\\fB%s\\fP(%s)", escape(page), h.section())
+		}
+		fmt.Fprintf(buf, "%s\n", strings.Join(seeAlso, ", "))
+	}
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func writeFlags(buf *bytes.Buffer, title string, flags *pflag.FlagSet) {
+	var lines []string
+	flags.VisitAll(func(f *pflag.Flag) {
+		if f.Hidden || f.Deprecated != "" {
+			return
+		}
+		name := fmt.Sprintf("\\fB\\-\\-%s\\fP", escape(f.Name))
+		if f.Shorthand != "" && f.ShorthandDeprecated == "" {
+			name = fmt.Sprintf("\\fB\\-%s\\fP, %s", escape(f.Shorthand), name)
+		}
+		varName, usage := pflag.UnquoteUsage(f)
+		if varName != "" {
+			name += fmt.Sprintf(" \\fI%s\\fP", escape(varName))
+		}
+		if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "[]" {
+			usage += fmt.Sprintf(" (default %s)", f.DefValue)
+		}
+		lines = append(lines, ".TP\n"+name+"\n"+escapeText(usage)+"\n")
+	})
+	if len(lines) == 0 {
+		return
+	}
+	buf.WriteString(".SH " + title + "\n")
+	for _, l := range lines {
+		buf.WriteString(l)
+	}
+}
+
+// available returns true if the command has its own page
+func available(cmd *cobra.Command) bool {
+	return cmd.IsAvailableCommand() || cmd.IsAdditionalHelpTopicCommand()
+}
+
+// pageName returns the command path joined by dashes such as tpot-stats-hosts
+func pageName(cmd *cobra.Command) string {
+	return strings.Replace(cmd.CommandPath(), " ", "-", -1)
+}
+
+// escape escapes the roff special characters of the inline text
+func escape(s string) string {
+	s = strings.Replace(s, `\`, `\e`, -1)
+	return strings.Replace(s, "-", `\-`, -1)
+}
+
+// escapeText escapes the text along with the lines starting by
+// a dot or a quote which roff takes as its requests
+func escapeText(s string) string {
+	lines := strings.Split(escape(strings.TrimRight(s, "\n")), "\n")
+	for i, l := range lines {
+		if strings.HasPrefix(l, ".") || strings.HasPrefix(l, "'") {
+			lines[i] = `\&` + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
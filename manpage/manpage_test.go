@@ -0,0 +1,67 @@
+package manpage
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func testCommands() *cobra.Command {
+	root := &cobra.Command{Use: "tpot <ENVIRONMENT>", Short: "tpot is tsh teleport wrapper", Run: func(*cobra.Command, []string) {}}
+	root.PersistentFlags().String("config-dir", "", "use the directory for both the configuration & cache")
+	stats := &cobra.Command{Use: "stats", Short: "Show the statistics recorded by tpot"}
+	hosts := &cobra.Command{
+		Use:     "hosts <ENVIRONMENT>",
+		Short:   "Show the connection successes & failures of the hosts",
+		Example: "\ntpot stats hosts prod --failing  // Only the failing hosts\n.hidden line\n",
+		Run:     func(*cobra.Command, []string) {},
+	}
+	hosts.Flags().Bool("failing", false, "only show the hosts failed the last connection")
+	hosts.Flags().StringP("sort", "s", "name", "the `order` of the hosts")
+	hidden := &cobra.Command{Use: "secret", Hidden: true, Run: func(*cobra.Command, []string) {}}
+	stats.AddCommand(hosts)
+	root.AddCommand(stats, hidden)
+	return root
+}
+
+func TestGenerate(t *testing.T) {
+	root := testCommands()
+	hosts, _, err := root.Find([]string{"stats", "hosts"})
+	assert.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	h := Header{Source: "tpot 1.2.0", Manual: "tpot Manual", Date: time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC)}
+	assert.NoError(t, Generate(hosts, h, buf))
+	page := buf.String()
+
+	assert.Contains(t, page, `.TH "TPOT-STATS-HOSTS" "1" "Mar 2021" "tpot 1.2.0" "tpot Manual"`)
+	assert.Contains(t, page, "tpot\\-stats\\-hosts \\- Show the connection successes & failures of the hosts\n")
+	assert.Contains(t, page, "\\fBtpot stats hosts <ENVIRONMENT> [flags]\\fP\n")
+	assert.Contains(t, page, ".TP\n\\fB\\-\\-failing\\fP\nonly show the hosts failed the last connection\n")
+	assert.Contains(t, page, ".TP\n\\fB\\-s\\fP, \\fB\\-\\-sort\\fP \\fIorder\\fP\nthe order of the hosts (default name)\n")
+	assert.Contains(t, page, ".SH OPTIONS INHERITED FROM PARENT COMMANDS\n.TP\n\\fB\\-\\-config\\-dir\\fP \\fIstring\\fP\n")
+	assert.Contains(t, page, "tpot stats hosts prod \\-\\-failing  // Only the failing hosts\n\\&.hidden line\n")
+	assert.Contains(t, page, ".SH SEE ALSO\n\\fBtpot\\-stats\\fP(1)\n")
+}
+
+func TestGenerateTree(t *testing.T) {
+	dir := t.TempDir()
+	files, err := GenerateTree(testCommands(), Header{}, dir)
+	assert.NoError(t, err)
+
+	// the hidden command is left out
+	assert.Equal(t, []string{
+		filepath.Join(dir, "tpot-stats-hosts.1"),
+		filepath.Join(dir, "tpot-stats.1"),
+		filepath.Join(dir, "tpot.1"),
+	}, files)
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "tpot.1"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), ".SH SEE ALSO\n\\fBtpot\\-stats\\fP(1)\n")
+}
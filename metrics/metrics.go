@@ -0,0 +1,220 @@
+// Package metrics keeps the counters, gauges & histograms of tpot and
+// writes them in the Prometheus text format, every metric is partitioned
+// by a single label which is enough for the env or the tsh command
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// ContentType is the content type of the Prometheus text format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefBuckets is the default histogram buckets in seconds
+var DefBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// metric is written by Write
+type metric interface {
+	metricName() string
+	write(w io.Writer)
+}
+
+var (
+	registryMu sync.Mutex
+	registry   []metric
+)
+
+func register(m metric) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = append(registry, m)
+}
+
+// Write writes every registered metric sorted by the name
+func Write(w io.Writer) {
+	registryMu.Lock()
+	metrics := append([]metric(nil), registry...)
+	registryMu.Unlock()
+
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].metricName() < metrics[j].metricName() })
+	for _, m := range metrics {
+		m.write(w)
+	}
+}
+
+// Vec is the counter or the gauge partitioned by the label
+type Vec struct {
+	desc
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// NewCounter creates & registers the counter, it only goes up
+func NewCounter(name, help, label string) *Vec {
+	v := &Vec{desc: desc{name: name, help: help, kind: "counter", label: label}, values: map[string]float64{}}
+	register(v)
+	return v
+}
+
+// NewGauge creates & registers the gauge
+func NewGauge(name, help, label string) *Vec {
+	v := &Vec{desc: desc{name: name, help: help, kind: "gauge", label: label}, values: map[string]float64{}}
+	register(v)
+	return v
+}
+
+// Inc increases the value of the label by one
+func (v *Vec) Inc(value string) {
+	v.Add(value, 1)
+}
+
+// Add increases the value of the label by delta
+func (v *Vec) Add(value string, delta float64) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.values[value] += delta
+}
+
+// Set sets the value of the label, it's only meant for the gauge
+func (v *Vec) Set(value string, val float64) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.values[value] = val
+}
+
+func (v *Vec) write(w io.Writer) {
+	v.mu.Lock()
+	values := make(map[string]float64, len(v.values))
+	for k, val := range v.values {
+		values[k] = val
+	}
+	v.mu.Unlock()
+	v.writeValues(w, values)
+}
+
+// GaugeFunc is the gauge whose values are collected on every Write,
+// such as the sizes kept somewhere else
+type GaugeFunc struct {
+	desc
+	collect func() map[string]float64
+}
+
+// NewGaugeFunc creates & registers the gauge collected by the func
+func NewGaugeFunc(name, help, label string, collect func() map[string]float64) *GaugeFunc {
+	g := &GaugeFunc{desc: desc{name: name, help: help, kind: "gauge", label: label}, collect: collect}
+	register(g)
+	return g
+}
+
+func (g *GaugeFunc) write(w io.Writer) {
+	g.writeValues(w, g.collect())
+}
+
+// Histogram counts the observations into the buckets by the label
+type Histogram struct {
+	desc
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewHistogram creates & registers the histogram of the sorted buckets
+func NewHistogram(name, help, label string, buckets []float64) *Histogram {
+	h := &Histogram{
+		desc:    desc{name: name, help: help, kind: "histogram", label: label},
+		buckets: buckets,
+		series:  map[string]*histogramSeries{},
+	}
+	register(h)
+	return h
+}
+
+// Observe adds the observation of the label
+func (h *Histogram) Observe(value string, v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[value]
+	if !ok {
+		s = &histogramSeries{counts: make([]uint64, len(h.buckets))}
+		h.series[value] = s
+	}
+	for i, b := range h.buckets {
+		if v <= b {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	values := make([]string, 0, len(h.series))
+	for value := range h.series {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+
+	h.writeHeader(w)
+	for _, value := range values {
+		s := h.series[value]
+		for i, b := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n", h.name, h.pair(value), formatFloat(b), s.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", h.name, h.pair(value), s.count)
+		fmt.Fprintf(w, "%s_sum{%s} %s\n", h.name, h.pair(value), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count{%s} %d\n", h.name, h.pair(value), s.count)
+	}
+}
+
+// desc is the description shared by every kind of the metric
+type desc struct {
+	name, help, kind, label string
+}
+
+func (d desc) metricName() string {
+	return d.name
+}
+
+func (d desc) writeHeader(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.name, d.help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.name, d.kind)
+}
+
+func (d desc) writeValues(w io.Writer, values map[string]float64) {
+	d.writeHeader(w)
+	for _, value := range sortedKeys(values) {
+		fmt.Fprintf(w, "%s{%s} %s\n", d.name, d.pair(value), formatFloat(values[value]))
+	}
+}
+
+// pair returns the label pair of the value
+func (d desc) pair(value string) string {
+	return d.label + "=" + strconv.Quote(value)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrite(t *testing.T) {
+	defer func(r []metric) { registry = r }(registry)
+	registry = nil
+
+	errs := NewCounter("test_errors_total", "The errors.", "command")
+	errs.Inc("ls")
+	errs.Add("login", 2)
+	NewGaugeFunc("test_nodes", "The nodes.", "env", func() map[string]float64 {
+		return map[string]float64{"staging": 3}
+	})
+	h := NewHistogram("test_duration_seconds", "The duration.", "env", []float64{1, 5})
+	h.Observe("staging", 0.5)
+	h.Observe("staging", 3)
+
+	want := `# HELP test_duration_seconds The duration.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{env="staging",le="1"} 1
+test_duration_seconds_bucket{env="staging",le="5"} 2
+test_duration_seconds_bucket{env="staging",le="+Inf"} 2
+test_duration_seconds_sum{env="staging"} 3.5
+test_duration_seconds_count{env="staging"} 2
+# HELP test_errors_total The errors.
+# TYPE test_errors_total counter
+test_errors_total{command="login"} 2
+test_errors_total{command="ls"} 1
+# HELP test_nodes The nodes.
+# TYPE test_nodes gauge
+test_nodes{env="staging"} 3
+`
+	var b bytes.Buffer
+	Write(&b)
+	assert.Equal(t, want, b.String())
+}
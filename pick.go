@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/spf13/cobra"
+)
+
+const pickExample = `
+tpot pick prod                     // Print the picked production hostname
+ssh $(tpot pick prod --print ip)   // Use the picked production host IP in another command
+tpot pick prod --format json       // Print the picked host along with its labels as JSON
+`
+
+var pickCmd = &cobra.Command{
+	Use:     "pick <ENVIRONMENT>",
+	Short:   "Pick a host & print it for the other commands",
+	Long:    "Show the host picker only & print the picked host into the stdout, the picker is drawn on the terminal so it works inside $(...)",
+	Example: pickExample,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return usageErrorf("ENVIRONMENT is required")
+		}
+
+		proxy, err := loadProxy(cmd, args[0])
+		if err != nil {
+			return err
+		}
+
+		field, _ := cmd.Flags().GetString("print")
+		format, _ := cmd.Flags().GetString("format")
+		switch {
+		case field != "hostname" && field != "ip" && field != "address" && field != "id":
+			return usageErrorf("unsupported --print %s, use hostname, ip, address or id", field)
+		case format != "text" && format != "json":
+			return usageErrorf("unsupported format %s, use text or json", format)
+		}
+
+		host, _ := selectHost(proxy, false)
+		if host == "" {
+			return errNoHost
+		}
+		item, _ := proxy.Node.LookUp(host)
+
+		if format == "json" {
+			return printPickJSON(proxy.Env, item)
+		}
+		switch field {
+		case "ip":
+			if item.IsTunnel() {
+				return fmt.Errorf("host %s is connected through a tunnel, it has no IP address", host)
+			}
+			fmt.Println(item.IP())
+		case "address":
+			fmt.Println(item.Address)
+		case "id":
+			if item.ID == "" {
+				return fmt.Errorf("host %s has no ID, refresh the node cache by -r with the newer tsh", host)
+			}
+			fmt.Println(item.ID)
+		default:
+			fmt.Println(item.Hostname)
+		}
+		return nil
+	},
+}
+
+func init() {
+	pickCmd.Flags().String("print", "hostname", "what to print, hostname, ip, address or id")
+	pickCmd.Flags().String("format", "text", "the output format, text or json")
+	rootCmd.AddCommand(pickCmd)
+}
+
+// pickedHost is the JSON of the picked host
+type pickedHost struct {
+	Env      string            `json:"env"`
+	Hostname string            `json:"hostname"`
+	Address  string            `json:"address"`
+	IP       string            `json:"ip,omitempty"`
+	ID       string            `json:"id,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+}
+
+func printPickJSON(env string, item config.Item) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(pickedHost{
+		Env:      env,
+		Hostname: item.Hostname,
+		Address:  item.Address,
+		IP:       item.IP(),
+		ID:       item.ID,
+		Labels:   item.Labels,
+	})
+}
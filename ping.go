@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/adzimzf/tpot/audit"
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/spf13/cobra"
+)
+
+const pingExample = `
+tpot ping staging                     // Ping all the staging nodes
+tpot ping prod --filter web-          // Ping the production nodes contain web- in the hostname
+tpot ping prod --filter 'web-*' -n 5  // Ping 5 random production nodes match the glob pattern
+`
+
+var pingCmd = &cobra.Command{
+	Use:     "ping <ENVIRONMENT>",
+	Short:   "Measure the connection latency to the nodes",
+	Long:    "Measure the connection establishment latency by running `tsh ssh <node> true` concurrently",
+	Example: pingExample,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return usageErrorf("ENVIRONMENT is required")
+		}
+
+		proxy, err := loadProxy(cmd, args[0])
+		if err != nil {
+			return err
+		}
+		node := proxy.Node
+
+		filter, _ := cmd.Flags().GetString("filter")
+		sample, _ := cmd.Flags().GetInt("sample")
+		parallel, _ := cmd.Flags().GetInt("parallel")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+
+		items := node.Items
+		if filter != "" {
+			if items, err = proxy.FilterNodes(filter); err != nil {
+				return usageErrorf("invalid --filter, error: %v", err)
+			}
+		}
+		if len(items) == 0 {
+			return fmt.Errorf("there's no nodes found")
+		}
+
+		if sample > 0 && sample < len(items) {
+			rand.Seed(time.Now().UnixNano())
+			items = append([]config.Item(nil), items...)
+			rand.Shuffle(len(items), func(i, j int) {
+				items[i], items[j] = items[j], items[i]
+			})
+			items = items[:sample]
+		}
+
+		user, err := getUserLogin(cmd, &node)
+		if err != nil {
+			return err
+		}
+
+		hosts := node.NamesOf(items)
+		if err := guardExec(proxy, pingCommand, hosts...); err != nil {
+			return err
+		}
+		t := tsh.NewTSH(proxy)
+		if err := openSession(cmd, proxy, t, fmt.Sprintf("%d hosts", len(hosts)), hosts...); err != nil {
+			return err
+		}
+
+		auditEvent(audit.Event{Action: audit.ActionLogin, Env: proxy.Env})
+
+		infof(cmd, "pinging %d nodes as %s\n", len(items), user)
+		auditEvent(audit.Event{Action: audit.ActionExec, Env: proxy.Env, User: user, Command: pingCommand,
+			Detail: fmt.Sprintf("ping %d nodes", len(items))})
+		results := ping(t, user, items, hosts, parallel, timeout)
+		printPingResults(results)
+		recordLatencies(proxy, results)
+
+		failed := 0
+		for _, r := range results {
+			if r.err != nil {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d nodes are unreachable", failed, len(results))
+		}
+		return nil
+	},
+}
+
+func init() {
+	pingCmd.Flags().String("filter", "", "only ping the nodes match the filter expression")
+	addCIDRFlag(pingCmd)
+	pingCmd.Flags().IntP("sample", "n", 0, "number of random nodes to ping, 0 means all the nodes")
+	pingCmd.Flags().IntP("parallel", "p", 10, "number of nodes to ping concurrently")
+	pingCmd.Flags().Duration("timeout", 30*time.Second, "maximum time to wait for each node")
+	pingCmd.Flags().StringP("user", "u", "", "user to login to the nodes")
+	rootCmd.AddCommand(pingCmd)
+}
+
+// pingCommand is run on the node to measure the connection latency
+const pingCommand = "true"
+
+// pingResult is the result of pinging a single node
+type pingResult struct {
+	// name is the hostname disambiguated by config.Node.Names,
+	// the instances sharing the hostname are pinged one by one
+	name    string
+	item    config.Item
+	latency time.Duration
+	err     error
+}
+
+// ping measures the latency of every item named by the names with maximum
+// parallel number of tsh process running at the same time
+func ping(t *tsh.TSH, user string, items []config.Item, names []string, parallel int, timeout time.Duration) []pingResult {
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	results := make([]pingResult, len(items))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, item := range items {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, item config.Item) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			results[i] = pingNode(t, user, names[i], item, timeout)
+		}(i, item)
+	}
+	wg.Wait()
+
+	sort.SliceStable(results, func(i, j int) bool {
+		// the failed nodes are always in the bottom
+		if (results[i].err == nil) != (results[j].err == nil) {
+			return results[i].err == nil
+		}
+		return results[i].latency < results[j].latency
+	})
+	return results
+}
+
+func pingNode(t *tsh.TSH, user, name string, item config.Item, timeout time.Duration) pingResult {
+	// the tsh process is killed once it's timed out, so the hung ones don't pile up
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := t.ExecContext(ctx, user, name, pingCommand, nil, ioutil.Discard, ioutil.Discard)
+	if ctx.Err() == context.DeadlineExceeded {
+		return pingResult{name: name, item: item, latency: timeout, err: fmt.Errorf("timeout after %s", timeout)}
+	}
+	return pingResult{name: name, item: item, latency: time.Since(start), err: err}
+}
+
+// recordLatencies records the latency of the reachable nodes
+// to be shown by the latency column of the picker
+func recordLatencies(proxy *config.Proxy, results []pingResult) {
+	if tsh.DryRun {
+		return
+	}
+	latencies := make(map[string]time.Duration, len(results))
+	for _, r := range results {
+		if r.err == nil {
+			latencies[r.name] = r.latency
+		}
+	}
+	if len(latencies) == 0 {
+		return
+	}
+	if err := proxy.RecordLatencies(latencies, time.Now()); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING! failed to save the host stats, error: %v\n", err)
+	}
+}
+
+func printPingResults(results []pingResult) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "HOSTNAME\tADDRESS\tLATENCY\tSTATUS")
+	for _, r := range results {
+		status := "ok"
+		if r.err != nil {
+			status = r.err.Error()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.name, r.item.Address, r.latency.Round(time.Millisecond), status)
+	}
+	w.Flush()
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/adzimzf/tpot/audit"
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/i18n"
+	"github.com/adzimzf/tpot/remote"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/adzimzf/tpot/ui"
+	"github.com/spf13/cobra"
+)
+
+const portsExample = `
+tpot ports staging web-01           // Show the listening ports of web-01 then pick one to forward
+tpot ports staging web-01 --list    // Only show the listening ports of web-01
+`
+
+var portsCmd = &cobra.Command{
+	Use:     "ports <ENVIRONMENT> [HOST]",
+	Short:   "Discover the listening ports of a node & forward one of them",
+	Example: portsExample,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return usageErrorf("ENVIRONMENT is required")
+		}
+
+		proxy, err := loadProxy(cmd, args[0])
+		if err != nil {
+			return err
+		}
+		node := proxy.Node
+
+		var host string
+		if len(args) > 1 {
+			if host, err = lookUpHost(proxy, args[1]); err != nil {
+				return err
+			}
+		} else {
+			host, _ = selectHost(proxy, false)
+		}
+		if host == "" {
+			return errNoHost
+		}
+
+		user, err := getUserLogin(cmd, &node)
+		if err != nil {
+			return err
+		}
+
+		if err := guardExec(proxy, remote.ListeningPortsCommand, host); err != nil {
+			return err
+		}
+		t := tsh.NewTSH(proxy)
+		if err := openSession(cmd, proxy, t, host); err != nil {
+			return err
+		}
+
+		ports, err := listeningPorts(proxy, t, user, host)
+		if err != nil {
+			return err
+		}
+
+		if list, _ := cmd.Flags().GetBool("list"); list {
+			printPorts(ports)
+			return nil
+		}
+
+		items := make([]string, len(ports))
+		for i, p := range ports {
+			items[i] = fmt.Sprintf("%-6d %-16s %s", p.Port, p.Address, p.Process)
+		}
+		i, err := ui.Select(i18n.Sprintf("Listening ports of %s, select one to forward", host), items)
+		if err != nil {
+			return err
+		}
+
+		localPort, _ := cmd.Flags().GetInt("local-port")
+		if localPort == 0 {
+			localPort = ports[i].Port
+		}
+		f := fwd{
+			tsh:         t,
+			env:         proxy.Env,
+			nodeHost:    host,
+			defaultUser: user,
+			list: []*config.ForwardingNode{{
+				Host:       host,
+				ListenPort: strconv.Itoa(localPort),
+				RemoteHost: ports[i].ForwardHost(),
+				RemotePort: strconv.Itoa(ports[i].Port),
+			}},
+		}
+		auditEvent(audit.Event{Action: audit.ActionForward, Env: proxy.Env, Host: host, User: user, Detail: f.list[0].Address()})
+		return f.Run()
+	},
+}
+
+func init() {
+	portsCmd.Flags().Bool("list", false, "only show the listening ports without forwarding")
+	portsCmd.Flags().Int("local-port", 0, "the local port of the forwarding, default is the same as the remote port")
+	portsCmd.Flags().StringP("user", "u", "", "user to login to the node")
+	rootCmd.AddCommand(portsCmd)
+}
+
+// listeningPorts lists the listening ports of the host
+func listeningPorts(proxy *config.Proxy, t *tsh.TSH, user, host string) ([]remote.Port, error) {
+	auditEvent(audit.Event{Action: audit.ActionExec, Env: proxy.Env, Host: host, User: user, Command: remote.ListeningPortsCommand})
+	var stdout, stderr bytes.Buffer
+	if err := t.Exec(user, host, remote.ListeningPortsCommand, &stdout, &stderr); err != nil {
+		return nil, fmt.Errorf("failed to get the listening ports, error: %v %s", err, stderr.String())
+	}
+	ports := remote.ParsePorts(stdout.String())
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("there's no listening port found on %s", host)
+	}
+	return ports, nil
+}
+
+func printPorts(ports []remote.Port) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "PORT\tADDRESS\tPROCESS")
+	for _, p := range ports {
+		fmt.Fprintf(w, "%d\t%s\t%s\n", p.Port, p.Address, p.Process)
+	}
+	w.Flush()
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	_ "net/http/pprof"
+	"os"
+)
+
+// pprofAddr is the address of the pprof server, empty means disabled
+var pprofAddr string
+
+// startPprof serves the pprof endpoints in the background, it's used
+// to profile the picker with the massive inventories
+func startPprof() {
+	if pprofAddr == "" {
+		return
+	}
+	go func() {
+		if err := http.ListenAndServe(pprofAddr, nil); err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING! failed to serve pprof, error: %v\n", err)
+		}
+	}()
+}
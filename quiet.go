@@ -0,0 +1,24 @@
+package main
+
+import (
+	"github.com/adzimzf/tpot/i18n"
+	"github.com/adzimzf/tpot/ui"
+	"github.com/spf13/cobra"
+)
+
+// quiet suppresses the informational output, only the errors & the
+// essential result are printed for the tools capturing the output
+var quiet bool
+
+// setQuiet applies the quiet mode into the prompts as well
+func setQuiet() {
+	ui.Quiet = quiet
+}
+
+// infof prints the translated informational message unless it's quiet
+func infof(cmd *cobra.Command, format string, a ...interface{}) {
+	if quiet {
+		return
+	}
+	cmd.Print(i18n.Sprintf(format, a...))
+}
@@ -0,0 +1,57 @@
+package remote
+
+import (
+	"bufio"
+	"strings"
+)
+
+// FactsCommand collects the basic facts of the node in a single round-trip,
+// every fact is printed as key=value, the failed one is left empty
+const FactsCommand = `
+echo "hostname=$(hostname 2>/dev/null)"
+echo "os=$( (. /etc/os-release 2>/dev/null && echo "$PRETTY_NAME") || uname -s)"
+echo "kernel=$(uname -srm 2>/dev/null)"
+echo "uptime=$(uptime -p 2>/dev/null || uptime 2>/dev/null)"
+echo "load=$(cut -d' ' -f1-3 /proc/loadavg 2>/dev/null)"
+echo "cpus=$(nproc 2>/dev/null || getconf _NPROCESSORS_ONLN 2>/dev/null)"
+echo "memory=$(awk '/MemTotal/{t=$2} /MemAvailable/{a=$2} END{if(t) printf "%.1fGi/%.1fGi used", (t-a)/1048576, t/1048576}' /proc/meminfo 2>/dev/null)"
+echo "disk=$(df -hP / 2>/dev/null | awk 'NR==2{print $3"/"$2" used ("$5")"}')"
+TOKEN=$(curl -s -m 1 -X PUT -H "X-aws-ec2-metadata-token-ttl-seconds: 60" http://169.254.169.254/latest/api/token 2>/dev/null)
+AWS() { curl -s -f -m 1 -H "X-aws-ec2-metadata-token: $TOKEN" "http://169.254.169.254/latest/meta-data/$1" 2>/dev/null; }
+GCP() { curl -s -f -m 1 -H "Metadata-Flavor: Google" "http://metadata.google.internal/computeMetadata/v1/instance/$1" 2>/dev/null; }
+if ID=$(AWS instance-id); then
+  echo "cloud=aws"
+  echo "instance_id=$ID"
+  echo "instance_type=$(AWS instance-type)"
+  echo "zone=$(AWS placement/availability-zone)"
+elif ID=$(GCP id); then
+  echo "cloud=gcp"
+  echo "instance_id=$ID"
+  echo "instance_type=$(GCP machine-type | awk -F/ '{print $NF}')"
+  echo "zone=$(GCP zone | awk -F/ '{print $NF}')"
+fi
+`
+
+// FactKeys is the display order of the facts
+var FactKeys = []string{
+	"hostname", "os", "kernel", "uptime", "load", "cpus", "memory", "disk",
+	"cloud", "instance_id", "instance_type", "zone",
+}
+
+// ParseFacts parses the output of FactsCommand, the empty facts are ignored
+func ParseFacts(out string) map[string]string {
+	facts := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		kv := strings.SplitN(scanner.Text(), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		if key == "" || value == "" {
+			continue
+		}
+		facts[key] = value
+	}
+	return facts
+}
@@ -0,0 +1,23 @@
+package remote
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFacts(t *testing.T) {
+	out := `hostname=web-01
+os=Ubuntu 20.04.2 LTS
+kernel=Linux 5.4.0-1045-aws x86_64
+load=
+memory=1.2Gi/3.8Gi used
+garbage line
+`
+	assert.Equal(t, map[string]string{
+		"hostname": "web-01",
+		"os":       "Ubuntu 20.04.2 LTS",
+		"kernel":   "Linux 5.4.0-1045-aws x86_64",
+		"memory":   "1.2Gi/3.8Gi used",
+	}, ParseFacts(out))
+}
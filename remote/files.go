@@ -0,0 +1,62 @@
+package remote
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/adzimzf/tpot/shell"
+)
+
+// listDirScript prints the absolute directory then every file of it as
+// <d|f>\t<size>\t<name>, the hidden files are included except . & ..
+const listDirScript = ` && pwd && for f in .[!.]* ..?* *; do
+  [ -e "$f" ] || [ -L "$f" ] || continue
+  if [ -d "$f" ]; then
+    printf 'd\t0\t%s\n' "$f"
+  else
+    printf 'f\t%s\t%s\n' "$({ wc -c < "$f"; } 2>/dev/null || echo 0)" "$f"
+  fi
+done`
+
+// File is a file or a directory of the node
+type File struct {
+	Name string
+	Dir  bool
+	Size int64
+}
+
+// ListDirCommand lists the files of the dir by the POSIX shell,
+// the empty dir is the home directory
+func ListDirCommand(dir string) string {
+	if dir == "" {
+		return "cd" + listDirScript
+	}
+	return "cd " + shell.Path(dir) + listDirScript
+}
+
+// ParseDir parses the output of ListDirCommand into the absolute
+// directory along with its files, the malformed lines are skipped
+func ParseDir(out string) (string, []File) {
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	dir := strings.TrimSpace(lines[0])
+	var res []File
+	for _, line := range lines[1:] {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 || (fields[0] != "d" && fields[0] != "f") || fields[2] == "" {
+			continue
+		}
+		size, _ := strconv.ParseInt(strings.TrimSpace(fields[1]), 10, 64)
+		res = append(res, File{Name: fields[2], Dir: fields[0] == "d", Size: size})
+	}
+	return dir, res
+}
+
+// RenameCommand renames the file or the directory
+func RenameCommand(from, to string) string {
+	return "mv -- " + shell.Path(from) + " " + shell.Path(to)
+}
+
+// RemoveCommand removes the file or the directory recursively
+func RemoveCommand(path string) string {
+	return "rm -rf -- " + shell.Path(path)
+}
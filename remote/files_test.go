@@ -0,0 +1,52 @@
+package remote
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDir(t *testing.T) {
+	dir, files := ParseDir("/home/me\nd\t0\t.ssh\nf\t   1024\tmy notes.txt\ngarbage\nf\t12\t\n")
+	assert.Equal(t, "/home/me", dir)
+	assert.Equal(t, []File{
+		{Name: ".ssh", Dir: true},
+		{Name: "my notes.txt", Size: 1024},
+	}, files)
+
+	dir, files = ParseDir("/\n")
+	assert.Equal(t, "/", dir)
+	assert.Empty(t, files)
+}
+
+func TestListDirCommand_shell(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not found")
+	}
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "it's here.txt"), []byte("hello"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, ".hidden"), nil, 0600))
+
+	out, err := exec.Command("sh", "-c", ListDirCommand(dir)).Output()
+	require.NoError(t, err)
+	got, files := ParseDir(string(out))
+	assert.Equal(t, dir, got)
+	assert.ElementsMatch(t, []File{
+		{Name: ".hidden"},
+		{Name: "it's here.txt", Size: 5},
+		{Name: "sub", Dir: true},
+	}, files)
+
+	require.NoError(t, exec.Command("sh", "-c", RenameCommand(dir+"/it's here.txt", dir+"/sub/moved.txt")).Run())
+	require.NoError(t, exec.Command("sh", "-c", RemoveCommand(dir+"/.hidden")).Run())
+	out, err = exec.Command("sh", "-c", ListDirCommand(dir+"/sub")).Output()
+	require.NoError(t, err)
+	_, files = ParseDir(string(out))
+	assert.Equal(t, []File{{Name: "moved.txt", Size: 5}}, files)
+}
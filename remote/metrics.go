@@ -0,0 +1,57 @@
+package remote
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MetricsCommand samples the load, memory & root disk usage of the Linux node in
+// a single round-trip, the metrics are printed as key=value in KiB like FactsCommand
+const MetricsCommand = `
+echo "load=$(cut -d' ' -f1-3 /proc/loadavg 2>/dev/null)"
+echo "cpus=$(nproc 2>/dev/null || getconf _NPROCESSORS_ONLN 2>/dev/null)"
+awk '/^MemTotal:/{print "mem_total="$2} /^MemAvailable:/{print "mem_available="$2}' /proc/meminfo 2>/dev/null
+df -kP / 2>/dev/null | awk 'NR==2{print "disk_total="$2; print "disk_used="$3}'
+`
+
+// Metrics is the sampled usage of the node, the sizes are in bytes
+type Metrics struct {
+	Load      [3]float64
+	CPUs      int
+	MemUsed   int64
+	MemTotal  int64
+	DiskUsed  int64
+	DiskTotal int64
+}
+
+// ParseMetrics parses the output of MetricsCommand, the load is required
+// while the unknown memory or disk is left 0
+func ParseMetrics(out string) (Metrics, error) {
+	var m Metrics
+	facts := ParseFacts(out)
+	load := strings.Fields(facts["load"])
+	if len(load) != 3 {
+		return m, fmt.Errorf("the load isn't found, the node might not be Linux")
+	}
+	for i, l := range load {
+		v, err := strconv.ParseFloat(l, 64)
+		if err != nil {
+			return m, fmt.Errorf("invalid load %s", facts["load"])
+		}
+		m.Load[i] = v
+	}
+	m.CPUs, _ = strconv.Atoi(facts["cpus"])
+
+	kib := func(key string) int64 {
+		v, _ := strconv.ParseInt(facts[key], 10, 64)
+		return v * 1024
+	}
+	// MemAvailable is missing before Linux 3.14, the memory is unknown then
+	if facts["mem_available"] != "" {
+		m.MemTotal = kib("mem_total")
+		m.MemUsed = m.MemTotal - kib("mem_available")
+	}
+	m.DiskTotal, m.DiskUsed = kib("disk_total"), kib("disk_used")
+	return m, nil
+}
@@ -0,0 +1,73 @@
+package remote
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMetrics(t *testing.T) {
+	tests := []struct {
+		name    string
+		out     string
+		want    Metrics
+		wantErr bool
+	}{
+		{
+			name: "all the metrics",
+			out: `load=0.52 1.10 2.00
+cpus=4
+mem_total=8000000
+mem_available=6000000
+disk_total=50000000
+disk_used=12500000
+`,
+			want: Metrics{
+				Load: [3]float64{0.52, 1.1, 2}, CPUs: 4,
+				MemUsed: 2000000 * 1024, MemTotal: 8000000 * 1024,
+				DiskUsed: 12500000 * 1024, DiskTotal: 50000000 * 1024,
+			},
+		},
+		{
+			name: "unknown memory of the old kernel",
+			out:  "load=0.00 0.01 0.05\ncpus=1\nmem_total=1000\n",
+			want: Metrics{Load: [3]float64{0, 0.01, 0.05}, CPUs: 1},
+		},
+		{
+			name:    "not Linux",
+			out:     "load=\ncpus=8\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid load",
+			out:     "load=a b c\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMetrics(tt.out)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMetricsCommand(t *testing.T) {
+	out, err := exec.Command("sh", "-c", MetricsCommand).Output()
+	if err != nil {
+		t.Skipf("sh isn't available, error: %v", err)
+	}
+	m, err := ParseMetrics(string(out))
+	if err != nil {
+		t.Skipf("the metrics aren't available, error: %v", err)
+	}
+	assert.True(t, m.CPUs > 0)
+	assert.True(t, m.MemTotal >= m.MemUsed)
+	assert.True(t, m.DiskTotal >= m.DiskUsed)
+}
@@ -0,0 +1,101 @@
+package remote
+
+import (
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ListeningPortsCommand lists the listening TCP ports using ss,
+// it falls back to netstat for the older distributions
+const ListeningPortsCommand = "ss -tlnp 2>/dev/null || netstat -tlnp 2>/dev/null"
+
+// Port is a listening TCP port of the node
+type Port struct {
+	Address string
+	Port    int
+	Process string
+}
+
+// IsLoopback return true if the port only accepts local connections
+func (p Port) IsLoopback() bool {
+	ip := net.ParseIP(p.Address)
+	return ip != nil && ip.IsLoopback()
+}
+
+// ForwardHost returns the host to be used as the forwarding remote host
+func (p Port) ForwardHost() string {
+	ip := net.ParseIP(p.Address)
+	if ip == nil || ip.IsUnspecified() {
+		return "localhost"
+	}
+	return p.Address
+}
+
+var ssProcessRegex = regexp.MustCompile(`users:\(\("([^"]+)"`)
+
+// ParsePorts parses the output of ss -tlnp or netstat -tlnp,
+// the result is sorted by port then address
+func ParsePorts(out string) []Port {
+	seen := make(map[string]bool)
+	var res []Port
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+
+		var local, process string
+		switch {
+		case fields[0] == "LISTEN":
+			// State Recv-Q Send-Q Local:Port Peer:Port Process
+			local = fields[3]
+			if m := ssProcessRegex.FindStringSubmatch(line); m != nil {
+				process = m[1]
+			}
+		case strings.HasPrefix(fields[0], "tcp"):
+			// Proto Recv-Q Send-Q Local Foreign State PID/Program
+			local = fields[3]
+			if len(fields) > 6 && fields[6] != "-" {
+				if i := strings.Index(fields[6], "/"); i >= 0 {
+					process = fields[6][i+1:]
+				}
+			}
+		default:
+			continue
+		}
+
+		i := strings.LastIndex(local, ":")
+		if i < 0 {
+			continue
+		}
+		port, err := strconv.Atoi(local[i+1:])
+		if err != nil {
+			continue
+		}
+		address := strings.Trim(local[:i], "[]")
+		if j := strings.Index(address, "%"); j >= 0 {
+			address = address[:j]
+		}
+		if address == "*" || address == "::" {
+			address = "0.0.0.0"
+		}
+
+		key := address + ":" + strconv.Itoa(port)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		res = append(res, Port{Address: address, Port: port, Process: process})
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Port != res[j].Port {
+			return res[i].Port < res[j].Port
+		}
+		return res[i].Address < res[j].Address
+	})
+	return res
+}
@@ -0,0 +1,53 @@
+package remote
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePorts(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+		want []Port
+	}{
+		{
+			name: "ss",
+			out: `State  Recv-Q Send-Q Local Address:Port  Peer Address:Port Process
+LISTEN 0      4096   127.0.0.53%lo:53         0.0.0.0:*     users:(("systemd-resolve",pid=612,fd=13))
+LISTEN 0      128    0.0.0.0:22               0.0.0.0:*     users:(("sshd",pid=1,fd=3))
+LISTEN 0      128    [::]:22                  [::]:*        users:(("sshd",pid=1,fd=4))
+LISTEN 0      511    *:8080                   *:*
+`,
+			want: []Port{
+				{Address: "0.0.0.0", Port: 22, Process: "sshd"},
+				{Address: "127.0.0.53", Port: 53, Process: "systemd-resolve"},
+				{Address: "0.0.0.0", Port: 8080},
+			},
+		},
+		{
+			name: "netstat",
+			out: `Active Internet connections (only servers)
+Proto Recv-Q Send-Q Local Address           Foreign Address         State       PID/Program name
+tcp        0      0 127.0.0.1:5432          0.0.0.0:*               LISTEN      900/postgres
+tcp6       0      0 :::22                   :::*                    LISTEN      -
+`,
+			want: []Port{
+				{Address: "0.0.0.0", Port: 22},
+				{Address: "127.0.0.1", Port: 5432, Process: "postgres"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParsePorts(tt.out))
+		})
+	}
+}
+
+func TestPort_ForwardHost(t *testing.T) {
+	assert.Equal(t, "localhost", Port{Address: "0.0.0.0"}.ForwardHost())
+	assert.Equal(t, "127.0.0.53", Port{Address: "127.0.0.53"}.ForwardHost())
+	assert.Equal(t, "10.0.0.5", Port{Address: "10.0.0.5"}.ForwardHost())
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/adzimzf/tpot/audit"
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/i18n"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/adzimzf/tpot/ui"
+	"github.com/spf13/cobra"
+)
+
+const requestExample = `
+tpot request prod --roles dba --reason "incident 1234"          // Request the dba role of production
+tpot request prod --roles dba --reason "incident 1234" --wait   // Wait until it's approved then pick the host with the dba role
+tpot request ls prod                                             // List the access requests of production
+tpot request show prod 9e2b2b2e-1cf7-4b5a-bf1b-6bde0b1e4a3f      // Show the access request
+`
+
+var requestCmd = &cobra.Command{
+	Use:     "request <ENVIRONMENT>",
+	Short:   "Request the elevated roles by the Teleport access request",
+	Long:    "Create the Teleport access request, optionally wait until it's approved then pick the host using the elevated roles",
+	Example: requestExample,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return usageErrorf("ENVIRONMENT is required")
+		}
+		proxy, err := loadProxy(cmd, args[0])
+		if err != nil {
+			return err
+		}
+
+		// the flags may be defaulted by the configuration
+		roles, _ := cmd.Flags().GetStringSlice("roles")
+		if len(roles) == 0 {
+			return usageErrorf("--roles is required")
+		}
+		reason, _ := cmd.Flags().GetString("reason")
+
+		t := tsh.NewTSH(proxy)
+		if err := t.Login(); err != nil {
+			return loginError(err)
+		}
+
+		id, err := t.CreateRequest(roles, reason)
+		if err != nil {
+			return fmt.Errorf("failed to create the access request, error: %v", err)
+		}
+		auditEvent(audit.Event{Action: audit.ActionRequest, Env: proxy.Env,
+			Detail: fmt.Sprintf("request %s roles %s: %s", id, strings.Join(roles, ","), reason)})
+
+		// there's no request to wait on dry run
+		if tsh.DryRun {
+			return nil
+		}
+		if wait, _ := cmd.Flags().GetBool("wait"); !wait {
+			cmd.Printf("%s\n", id)
+			return nil
+		}
+		infof(cmd, "access request %s is created, waiting for the approval, press CTRL+C to stop\n", id)
+
+		interval, _ := cmd.Flags().GetDuration("interval")
+		if err := waitRequest(t, id, interval); err != nil {
+			return err
+		}
+		if err := t.AssumeRequest(id); err != nil {
+			return loginError(err)
+		}
+		infof(cmd, "access request %s is approved, the %s roles are assumed\n", id, strings.Join(roles, ","))
+
+		// the elevated roles may permit more logins
+		if status, err := t.Status(); err == nil {
+			proxy.Node.Status = status
+		}
+
+		host, action := selectHost(proxy, true)
+		if host == "" {
+			return errNoHost
+		}
+		if action != ui.ActionSSH {
+			return hostAction(cmd, proxy, host, action)
+		}
+		return connect(cmd, proxy, t, host, tsh.SessionOptions{})
+	},
+}
+
+var requestLsCmd = &cobra.Command{
+	Use:   "ls <ENVIRONMENT>",
+	Short: "List the access requests",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return usageErrorf("ENVIRONMENT is required")
+		}
+		t, err := requestTSH(cmd, args[0])
+		if err != nil {
+			return err
+		}
+		return t.ListRequests(os.Stdout)
+	},
+}
+
+var requestShowCmd = &cobra.Command{
+	Use:   "show <ENVIRONMENT> <REQUEST ID>",
+	Short: "Show the access request",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return usageErrorf("ENVIRONMENT & REQUEST ID are required")
+		}
+		t, err := requestTSH(cmd, args[0])
+		if err != nil {
+			return err
+		}
+		req, err := t.ShowRequest(args[1])
+		if err != nil {
+			return fmt.Errorf("failed to show the access request, error: %v", err)
+		}
+		if tsh.DryRun {
+			return nil
+		}
+		cmd.Printf("Request ID: %s\nUser:       %s\nRoles:      %s\nReason:     %s\nState:      %s\n",
+			req.ID, req.User, strings.Join(req.Roles, ", "), req.Reason, req.State)
+		return nil
+	},
+}
+
+func init() {
+	requestCmd.Flags().StringSlice("roles", nil, "the roles to request, separated by comma")
+	requestCmd.Flags().String("reason", "", "the reason of the request shown to the reviewers")
+	requestCmd.Flags().BoolP("wait", "w", false, "wait until the request is approved then pick the host")
+	requestCmd.Flags().Duration("interval", 5*time.Second, "how often the request is checked on --wait")
+	requestCmd.Flags().StringP("user", "u", "", "user to login to the node")
+	requestCmd.AddCommand(requestLsCmd, requestShowCmd)
+	rootCmd.AddCommand(requestCmd)
+}
+
+// requestTSH logins to the proxy of the env for the request sub commands
+func requestTSH(cmd *cobra.Command, env string) (*tsh.TSH, error) {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return nil, err
+	}
+	proxy, err := findProxy(cmd, cfg, env)
+	if err != nil {
+		return nil, err
+	}
+	t := tsh.NewTSH(proxy)
+	if err := t.Login(); err != nil {
+		return nil, loginError(err)
+	}
+	return t, nil
+}
+
+// waitRequest polls the access request until it's reviewed
+func waitRequest(t *tsh.TSH, id string, interval time.Duration) error {
+	for {
+		req, err := t.ShowRequest(id)
+		if err != nil {
+			return fmt.Errorf("failed to check the access request, error: %v", err)
+		}
+		switch req.State {
+		case tsh.RequestApproved:
+			return nil
+		case tsh.RequestDenied:
+			return fmt.Errorf("access request %s is denied", id)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// reasonRequested is the envs whose reason_roles are assumed by this run
+var reasonRequested = map[string]bool{}
+
+// requestReasonRoles requests the reason_roles of the env with the reason of
+// the session as the Teleport access request, then waits until it's approved
+// & assumes it, so the reason is recorded by Teleport along with the session
+func requestReasonRoles(cmd *cobra.Command, proxy *config.Proxy, t *tsh.TSH) error {
+	if len(proxy.ReasonRoles) == 0 || sessionTicket == "" || reasonRequested[proxy.Env] {
+		return nil
+	}
+	reasonRequested[proxy.Env] = true
+
+	id, err := t.CreateRequest(proxy.ReasonRoles, sessionTicket)
+	if err != nil {
+		return fmt.Errorf("failed to create the access request, error: %v", err)
+	}
+	auditEvent(audit.Event{Action: audit.ActionRequest, Env: proxy.Env,
+		Detail: fmt.Sprintf("request %s roles %s: %s", id, strings.Join(proxy.ReasonRoles, ","), sessionTicket)})
+	if tsh.DryRun {
+		return nil
+	}
+	infof(cmd, "access request %s is created, waiting for the approval, press CTRL+C to stop\n", id)
+	if err := waitRequest(t, id, 5*time.Second); err != nil {
+		return err
+	}
+	if err := t.AssumeRequest(id); err != nil {
+		return loginError(err)
+	}
+	infof(cmd, "access request %s is approved, the %s roles are assumed\n", id, strings.Join(proxy.ReasonRoles, ","))
+	return nil
+}
+
+// assumeApproved offers to assume the approved access request which isn't
+// assumed by the current login, nothing is shown when the requests can't
+// be listed since most of the clusters don't use the access requests
+func assumeApproved(cmd *cobra.Command, t *tsh.TSH) {
+	if tsh.DryRun {
+		return
+	}
+	requests, err := t.ApprovedRequests()
+	if err != nil {
+		return
+	}
+	for _, req := range requests {
+		ok, err := ui.Confirm(i18n.Sprintf("Access request %s of the %s roles is approved, assume it", req.ID, strings.Join(req.Roles, ",")))
+		if err != nil || !ok {
+			continue
+		}
+		if err := t.AssumeRequest(req.ID); err != nil {
+			cmd.PrintErrf("WARNING! failed to assume the access request %s, error: %v\n", req.ID, err)
+			continue
+		}
+		infof(cmd, "access request %s is assumed\n", req.ID)
+		return
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/i18n"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/adzimzf/tpot/ui"
+	"github.com/spf13/cobra"
+)
+
+const runbookExample = `
+tpot runbook record restart-web -d "restart the app on the web nodes"   // Record the next exec & forward into the restart-web runbook
+tpot exec prod "sudo systemctl restart app" --filter 'web-*'            // Recorded as a step
+tpot runbook stop                                                       // Stop recording
+tpot runbook run restart-web                                            // Run the steps again, confirming every step
+tpot runbook run ./incident-1234.yaml --yes                             // Run the shared runbook file without confirmation
+`
+
+var runbookCmd = &cobra.Command{
+	Use:     "runbook",
+	Short:   "Record & replay the runbooks of tpot actions",
+	Long:    "Record the exec & forward actions into a YAML runbook to be shared & run again with the confirmation of every step",
+	Example: runbookExample,
+}
+
+var runbookRecordCmd = &cobra.Command{
+	Use:   "record <NAME>",
+	Short: "Record the next exec & forward actions into the runbook",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return usageErrorf("NAME is required")
+		}
+		if _, err := loadConfig(cmd); err != nil {
+			return err
+		}
+
+		description, _ := cmd.Flags().GetString("description")
+		if err := config.StartRecording(args[0], description); err != nil {
+			return err
+		}
+		infof(cmd, "recording the runbook %s, run tpot runbook stop once it's done\n", args[0])
+		return nil
+	},
+}
+
+var runbookStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop recording the runbook",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := loadConfig(cmd); err != nil {
+			return err
+		}
+
+		r, err := config.StopRecording()
+		if err != nil {
+			return err
+		}
+		infof(cmd, "%d steps are recorded into the runbook %s\n", len(r.Steps), r.Name)
+		return nil
+	},
+}
+
+var runbookLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List the saved runbooks",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := loadConfig(cmd); err != nil {
+			return err
+		}
+
+		names, err := config.ListRunbooks()
+		if err != nil {
+			return err
+		}
+		recording, _ := config.Recording()
+		for _, name := range names {
+			if name == recording {
+				name += " (recording)"
+			}
+			fmt.Println(name)
+		}
+		return nil
+	},
+}
+
+var runbookRunCmd = &cobra.Command{
+	Use:   "run <NAME|FILE>",
+	Short: "Run the steps of the runbook",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return usageErrorf("NAME or FILE is required")
+		}
+		if _, err := loadConfig(cmd); err != nil {
+			return err
+		}
+
+		r, err := config.LoadRunbook(args[0])
+		if os.IsNotExist(err) {
+			return usageErrorf("runbook %s is not found", args[0])
+		}
+		if err != nil {
+			return withCode(exitConfig, err)
+		}
+		if r.Description != "" {
+			infof(cmd, "%s: %s\n", r.Name, r.Description)
+		}
+
+		// the user of the command line takes precedence over the recorded one
+		userChanged := cmd.Flags().Changed("user")
+		yes, _ := cmd.Flags().GetBool("yes")
+		for i, step := range r.Steps {
+			label := i18n.Sprintf("Step %d/%d: %s", i+1, len(r.Steps), step)
+			if yes {
+				infof(cmd, "%s\n", label)
+			} else {
+				choice, err := ui.Select(label, []string{i18n.T("run"), i18n.T("skip"), i18n.T("abort")})
+				if err != nil {
+					return withCode(exitCancelled, fmt.Errorf("failed to get confirmation, error: %v", err))
+				}
+				if choice == 1 {
+					continue
+				}
+				if choice == 2 {
+					return withCode(exitCancelled, i18n.Errorf("runbook %s is aborted at step %d", r.Name, i+1))
+				}
+			}
+
+			if step.User != "" && !userChanged {
+				cmd.Flags().Set("user", step.User)
+			}
+			if err := runStep(cmd, step); err != nil {
+				return fmt.Errorf("step %d of the runbook %s is failed, error: %w", i+1, r.Name, err)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	runbookRecordCmd.Flags().StringP("description", "d", "", "the description of the runbook")
+	runbookRunCmd.Flags().BoolP("yes", "y", false, "run every step without confirmation")
+	runbookRunCmd.Flags().StringP("user", "u", "", "user to login to the nodes instead of the recorded one")
+	addMaintenanceFlag(runbookRunCmd)
+	runbookCmd.AddCommand(runbookRecordCmd, runbookStopCmd, runbookLsCmd, runbookRunCmd)
+	rootCmd.AddCommand(runbookCmd)
+}
+
+// runStep runs the step of the runbook on the hosts of the step
+func runStep(cmd *cobra.Command, step config.RunbookStep) error {
+	proxy, err := loadProxy(cmd, step.Env)
+	if err != nil {
+		return err
+	}
+
+	var hosts []string
+	if step.Filter != "" {
+		items, err := proxy.FilterNodes(step.Filter)
+		if err != nil {
+			return fmt.Errorf("invalid filter %s of the step, error: %v", step.Filter, err)
+		}
+		hosts = proxy.Node.NamesOf(items)
+		sort.Strings(hosts)
+		if len(hosts) == 0 {
+			return fmt.Errorf("there's no host match %s", step.Filter)
+		}
+		if hosts, err = skipMaintenance(cmd, proxy, hosts); err != nil {
+			return err
+		}
+	} else {
+		for _, host := range step.Hosts {
+			if _, ok := proxy.Node.LookUp(host); !ok {
+				return fmt.Errorf("host %s is not found in the %s node cache", host, proxy.Env)
+			}
+		}
+		hosts = step.Hosts
+	}
+
+	if step.Exec != "" {
+		return execOnHosts(cmd, proxy, hosts, step.Exec, execOptions{filter: step.Filter, sudo: step.Sudo})
+	}
+
+	user, err := getUserLogin(cmd, &proxy.Node)
+	if err != nil {
+		return err
+	}
+	t := tsh.NewTSH(proxy)
+	if err := openSession(cmd, proxy, t, hosts[0]); err != nil {
+		return err
+	}
+	f := fwd{
+		tsh:         t,
+		env:         proxy.Env,
+		nodeHost:    hosts[0],
+		defaultUser: user,
+	}
+	for _, addr := range step.Forward {
+		parts := strings.Split(addr, ":")
+		f.list = append(f.list, &config.ForwardingNode{
+			Host:       hosts[0],
+			ListenPort: parts[0],
+			RemoteHost: parts[1],
+			RemotePort: parts[2],
+		})
+	}
+	return f.Run()
+}
+
+// recordStep records the action into the runbook being recorded,
+// failing to record must not break the action, hence only warn
+func recordStep(step config.RunbookStep) {
+	if err := config.RecordStep(step); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING! failed to record the runbook step, error: %v\n", err)
+	}
+}
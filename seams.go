@@ -0,0 +1,144 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/i18n"
+	"github.com/adzimzf/tpot/scrapper"
+	"github.com/adzimzf/tpot/trace"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/adzimzf/tpot/ui"
+)
+
+// hostSelector is the terminal UI choosing the host & the user login of the root command,
+// the empty host means the picker is closed
+type hostSelector interface {
+	SelectHost(proxy *config.Proxy, actions bool) (string, ui.Action)
+	SelectUser(logins []string) (string, error)
+	Confirm(msg string) (bool, error)
+
+	// ConfirmEnv asks to type the environment name, it's false once the typed name differs
+	ConfirmEnv(env string) (bool, error)
+
+	// Input asks the non-empty text such as the reason of the session
+	Input(label string) (string, error)
+}
+
+// nodeSource fetches the latest nodes of the proxy along with the status of its user logins
+type nodeSource interface {
+	LatestNodes(proxy *config.Proxy) (config.Node, error)
+}
+
+// the seams of the root command, they're replaced by the tests to run the
+// refresh, the append & the connection without a terminal or the network.
+// The node cache is the config.Store, such as the one of config.NewMemoryStore
+var (
+	selector hostSelector = terminalSelector{}
+	fetcher  nodeSource   = teleportSource{}
+
+	// connectHost opens the ssh session into the host as the user
+	connectHost = connectAs
+
+	// now is the clock telling the quick failure of the connection
+	now = time.Now
+)
+
+// terminalSelector asks by the picker & the prompts
+type terminalSelector struct{}
+
+func (terminalSelector) SelectHost(proxy *config.Proxy, actions bool) (string, ui.Action) {
+	return selectHost(proxy, actions)
+}
+
+func (terminalSelector) SelectUser(logins []string) (string, error) {
+	uiUser, err := ui.NewLoginUser(logins)
+	if err != nil {
+		return "", err
+	}
+	return uiUser.Run()
+}
+
+func (terminalSelector) Confirm(msg string) (bool, error) {
+	return ui.Confirm(msg)
+}
+
+func (terminalSelector) ConfirmEnv(env string) (bool, error) {
+	return ui.ConfirmEnv(env)
+}
+
+func (terminalSelector) Input(label string) (string, error) {
+	return ui.Input(label)
+}
+
+// teleportSource fetches the nodes from the Teleport UI of the proxy without
+// the auth connector, otherwise from tsh ls
+type teleportSource struct{}
+
+func (teleportSource) LatestNodes(proxy *config.Proxy) (config.Node, error) {
+	nodes, err := fetchNodes(proxy, primarySource(proxy))
+	if err != nil {
+		return nodes, err
+	}
+	nodes.Status, err = loginStatus(proxy)
+	if err != nil {
+		return config.Node{}, err
+	}
+	return nodes, nil
+}
+
+// primarySource returns the source the nodes are fetched from, the Teleport
+// UI can only be logged in without the auth connector
+func primarySource(proxy *config.Proxy) string {
+	if proxy.AuthConnector == "" {
+		return config.SourceScrapper
+	}
+	return config.SourceTSH
+}
+
+// fetchNodes fetches the nodes of the proxy from the source without the status
+func fetchNodes(proxy *config.Proxy, source string) (config.Node, error) {
+	var nodes config.Node
+	var err error
+	if source == config.SourceScrapper {
+		step := trace.Start("fetch the nodes of %s from %s", proxy.Env, proxy.Address)
+		nodes, err = scrapper.NewScrapper(*proxy).GetNodes()
+		step.End(err)
+	} else {
+		nodes, err = tsh.NewTSH(proxy).ListNodes()
+	}
+	if err != nil {
+		return nodes, i18n.Errorf("failed to get nodes: %v", err)
+	}
+	nodes.Source = source
+	if len(nodes.Items) == 0 {
+		return nodes, i18n.Errorf("there's no nodes found")
+	}
+	return nodes, nil
+}
+
+// loginStatus returns the status of the user logins of the proxy
+func loginStatus(proxy *config.Proxy) (*config.ProxyStatus, error) {
+	t := tsh.NewTSH(proxy)
+	status, err := t.Status()
+	if err != nil && !errors.Is(err, tsh.ErrUnsupportedVersion) {
+		return nil, err
+	}
+
+	// if the tsh version is not supported
+	// just hardcoded the user login to root for now
+	if errors.Is(err, tsh.ErrUnsupportedVersion) {
+		version, err := t.Version()
+		if err != nil {
+			return nil, err
+		}
+
+		fmt.Print(i18n.Sprintf("WARNING! minimum tsh version is Teleport v2.6.1 but got %s, the user login list is will be only root\n", version.Strings()))
+		status = &config.ProxyStatus{
+			UserLogins: []string{"root"},
+		}
+	}
+	return status, nil
+}
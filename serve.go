@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/adzimzf/tpot/api"
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/metrics"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/spf13/cobra"
+)
+
+const serveExample = `
+tpot serve                                   // Serve the API on 127.0.0.1:7777 with a random token
+TPOT_API_TOKEN=secret tpot serve -l :7777    // Serve the API with the token of the environment variable
+curl -H "Authorization: Bearer secret" localhost:7777/v1/envs/prod/nodes?filter=web-*
+curl -H "Authorization: Bearer secret" localhost:7777/metrics   // Scrape the Prometheus metrics
+curl localhost:7777/healthz                                     // Check the API is up, no token is needed
+`
+
+var serveCmd = &cobra.Command{
+	Use:     "serve",
+	Short:   "Serve the node list, status & connect command over a local API",
+	Long:    "Serve a local HTTP API with the token auth, so the editor extensions, the launchers & the portals can drive tpot",
+	Example: serveExample,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		token, _ := cmd.Flags().GetString("token")
+		if token == "" {
+			if token, err = randomToken(); err != nil {
+				return err
+			}
+			// the token is the only thing to be captured by the launcher
+			fmt.Fprintf(os.Stderr, "API token: %s\n", token)
+		}
+
+		listen, _ := cmd.Flags().GetString("listen")
+		if host, _, err := net.SplitHostPort(listen); err != nil {
+			return usageErrorf("invalid --listen %s, error: %v", listen, err)
+		} else if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			fmt.Fprintf(os.Stderr, "WARNING! %s is not a loopback address, the API is reachable from the network\n", listen)
+		}
+
+		backend := &apiBackend{cfg: cfg}
+		metrics.NewGaugeFunc("tpot_cached_nodes", "The cached nodes by the env.", "env", backend.cachedNodes)
+
+		release, err := acquirePIDFile(servePIDPath())
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		// the environments are changed without restarting the API,
+		// the invalid change is only reported to keep serving the current one
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		server := &api.Server{Token: token, Backend: backend, Slack: slackOf(cfg)}
+		if server.Slack != nil {
+			infof(cmd, "answering the Slack slash command on %s/slack/commands\n", listen)
+		}
+		config.Watch(ctx, config.WatchInterval, func(cfg *config.Config) {
+			backend.reload(cfg)
+			// such as the rotated signing secret or the envs shared with Slack
+			server.SetSlack(slackOf(cfg))
+			infof(cmd, "the configuration is reloaded\n")
+		}, func(err error) {
+			fmt.Fprintf(os.Stderr, "WARNING! failed to reload the configuration, error: %v\n", err)
+		})
+		go runKeepalive(ctx, cmd, backend.current)
+
+		infof(cmd, "serving the API on %s, press CTRL+C to stop\n", listen)
+		srv := &http.Server{Addr: listen, Handler: server}
+		return serveUntilSignal(srv)
+	},
+}
+
+func init() {
+	serveCmd.Flags().StringP("listen", "l", "127.0.0.1:7777", "the address to serve the API")
+	serveCmd.Flags().String("token", os.Getenv("TPOT_API_TOKEN"), "the bearer token of the API, default is $TPOT_API_TOKEN or a random one")
+	rootCmd.AddCommand(serveCmd)
+}
+
+// serveShutdownTimeout is how long the in-flight requests are waited on shutdown
+const serveShutdownTimeout = 10 * time.Second
+
+// serveUntilSignal serves until SIGTERM or CTRL+C, then shuts down gracefully
+// so the in-flight refresh isn't cut in the middle of writing the cache
+func serveUntilSignal(srv *http.Server) error {
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.ListenAndServe() }()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGTERM, os.Interrupt)
+	defer signal.Stop(sig)
+
+	select {
+	case err := <-errCh:
+		return err
+	case s := <-sig:
+		fmt.Fprintf(os.Stderr, "%s received, shutting down\n", s)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+	defer cancel()
+	return srv.Shutdown(ctx)
+}
+
+// slackOf returns the Slack answering the slash commands by the
+// configuration, nil when there's no signing secret
+func slackOf(cfg *config.Config) *api.Slack {
+	secret := cfg.Slack.Secret()
+	if secret == "" {
+		return nil
+	}
+	return &api.Slack{SigningSecret: secret, Envs: cfg.Slack.Envs}
+}
+
+// randomToken returns a random API token
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+var (
+	refreshDuration = metrics.NewHistogram("tpot_refresh_duration_seconds", "The duration of refreshing the node cache by the env.", "env", metrics.DefBuckets)
+	refreshErrors   = metrics.NewCounter("tpot_refresh_errors_total", "The failed refreshes of the node cache by the env.", "env")
+	refreshSuccess  = metrics.NewGauge("tpot_refresh_last_success_timestamp_seconds", "The time of the last successful refresh by the env.", "env")
+	sessionsTotal   = metrics.NewCounter("tpot_sessions_total", "The sessions connected through the API by the env.", "env")
+)
+
+// apiBackend serves the API from the configuration
+type apiBackend struct {
+	mu  sync.RWMutex
+	cfg *config.Config
+}
+
+// current returns the configuration, it's replaced once it's reloaded
+func (b *apiBackend) current() *config.Config {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.cfg
+}
+
+func (b *apiBackend) reload(cfg *config.Config) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cfg = cfg
+}
+
+// cachedNodes collects the node count of every env on every scrape,
+// the cache may be refreshed by the other tpot processes as well
+func (b *apiBackend) cachedNodes() map[string]float64 {
+	cfg := b.current()
+	res := make(map[string]float64, len(cfg.Proxies))
+	for _, p := range cfg.Proxies {
+		node, _ := p.GetNode()
+		res[p.Env] = float64(len(node.Items))
+	}
+	return res
+}
+
+// Envs implements api.Backend
+func (b *apiBackend) Envs() ([]api.Env, error) {
+	cfg := b.current()
+	envs := make([]api.Env, 0, len(cfg.Proxies))
+	for _, p := range cfg.Proxies {
+		node, _ := p.GetNode()
+		envs = append(envs, api.Env{Name: p.Env, Badge: p.Badge, Color: p.Color, Nodes: len(node.Items)})
+	}
+	return envs, nil
+}
+
+// Nodes implements api.Backend
+func (b *apiBackend) Nodes(env string) (config.Node, error) {
+	proxy, err := b.current().FindProxy(env)
+	if err != nil {
+		return config.Node{}, err
+	}
+	return proxy.GetNode()
+}
+
+// Refresh implements api.Backend
+func (b *apiBackend) Refresh(env string) (config.Node, error) {
+	proxy, err := b.current().FindProxy(env)
+	if err != nil {
+		return config.Node{}, err
+	}
+
+	start := time.Now()
+	node, err := getLatestNode(proxy, false)
+	refreshDuration.Observe(env, time.Since(start).Seconds())
+	if err != nil {
+		refreshErrors.Inc(env)
+		return node, err
+	}
+	refreshSuccess.Set(env, float64(time.Now().Unix()))
+	return node, nil
+}
+
+// Status implements api.Backend
+func (b *apiBackend) Status(env string) (*config.ProxyStatus, error) {
+	proxy, err := b.current().FindProxy(env)
+	if err != nil {
+		return nil, err
+	}
+	return tsh.NewTSH(proxy).Status()
+}
+
+// ConnectCommand implements api.Backend
+func (b *apiBackend) ConnectCommand(env, user, host string) (string, error) {
+	proxy, err := b.current().FindProxy(env)
+	if err != nil {
+		return "", err
+	}
+	if proxy.Node, err = proxy.GetNode(); err != nil {
+		return "", err
+	}
+	if _, ok := proxy.Node.LookUp(host); !ok {
+		return "", fmt.Errorf("host %s is %w in the %s node cache", host, api.ErrNotFound, env)
+	}
+	if err := guardShell(proxy, host); err != nil {
+		return "", fmt.Errorf("%w, %v", api.ErrForbidden, err)
+	}
+	command, err := tsh.NewTSH(proxy).SSHCommand(user, host)
+	if err == nil {
+		sessionsTotal.Inc(env)
+	}
+	return command, err
+}
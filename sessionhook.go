@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/hook"
+	"github.com/adzimzf/tpot/tsh"
+)
+
+var (
+	// sessionHooks is the webhooks of session_hooks set once the config is loaded
+	sessionHooks []*hook.Webhook
+
+	// sessionTicket is the incident or change ticket sent along with the sessions,
+	// it's asked as the reason by the environment requiring it
+	sessionTicket string
+)
+
+// newSessionHooks creates the webhooks of the configured session hooks
+func newSessionHooks(hooks []config.SessionHook) ([]*hook.Webhook, error) {
+	res := make([]*hook.Webhook, 0, len(hooks))
+	for _, h := range hooks {
+		w, err := hook.New(h.URL, h.Headers, h.Template, h.Envs)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, w)
+	}
+	return res, nil
+}
+
+// fireSessionHooks fires the session hooks of the env at once, failing to
+// fire a hook must not break the session, hence only warn
+func fireSessionHooks(s hook.Session) {
+	// nothing is really done on dry run
+	if tsh.DryRun {
+		return
+	}
+	s.Ticket = sessionTicket
+	var wg sync.WaitGroup
+	for _, w := range sessionHooks {
+		if !w.Matches(s.Env) {
+			continue
+		}
+		wg.Add(1)
+		go func(w *hook.Webhook) {
+			defer wg.Done()
+			if err := w.Fire(s); err != nil {
+				fmt.Fprintf(os.Stderr, "WARNING! failed to fire the session hook of %s, error: %v\n", w.Host(), err)
+			}
+		}(w)
+	}
+	wg.Wait()
+}
+
+// sessionHooked runs the ssh session between the start & the end hooks
+func sessionHooked(proxy *config.Proxy, host, user string, session func() error) error {
+	start := time.Now()
+	fireSessionHooks(hook.Session{Event: hook.EventStart, Time: start, Env: proxy.Env, Host: host, User: user})
+	err := session()
+
+	end := hook.Session{Event: hook.EventEnd, Time: time.Now(), Env: proxy.Env, Host: host, User: user,
+		Duration: time.Since(start).Round(time.Second)}
+	if err != nil {
+		end.Error = err.Error()
+	}
+	fireSessionHooks(end)
+	return err
+}
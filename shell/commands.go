@@ -0,0 +1,90 @@
+package shell
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrUncheckable is returned by Commands when the shell would run more than the
+// words tell, such as $(...), $VAR, the redirection, the subshell, the leading
+// assignment or the compound command like if & for
+var ErrUncheckable = errors.New("the substitution, the expansion, the redirection, the subshell, the assignment & the compound command can't be checked")
+
+// reserved is the words starting the compound commands
+var reserved = map[string]bool{
+	"if": true, "then": true, "else": true, "elif": true, "fi": true, "case": true, "esac": true,
+	"for": true, "select": true, "while": true, "until": true, "do": true, "done": true,
+	"function": true, "{": true, "}": true, "!": true, "[[": true, "]]": true,
+}
+
+// Commands splits the command line into the words of its simple commands run by
+// the POSIX shell, joined by ;, &, |, &&, || or the newline. Such as
+// "cd /tmp && ls -l | wc -l" into [cd /tmp], [ls -l] & [wc -l]
+func Commands(s string) ([][]string, error) {
+	var (
+		res    [][]string
+		start  int
+		quote  rune
+		escape bool
+	)
+	add := func(end int) error {
+		words, err := Split(s[start:end])
+		if err != nil || len(words) == 0 {
+			return err
+		}
+		if reserved[words[0]] || isAssignment(words[0]) {
+			return ErrUncheckable
+		}
+		res = append(res, words)
+		return nil
+	}
+	for i, r := range s {
+		switch {
+		case escape:
+			escape = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			}
+		case r == '\\':
+			escape = true
+		case r == '$' || r == '`':
+			// expanded inside the double quotes too
+			return nil, ErrUncheckable
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case strings.ContainsRune("<>()", r):
+			return nil, ErrUncheckable
+		case strings.ContainsRune(";&|\n", r):
+			if err := add(i); err != nil {
+				return nil, err
+			}
+			start = i + 1
+		}
+	}
+	if quote != 0 || escape {
+		return nil, ErrUnterminated
+	}
+	if err := add(len(s)); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// isAssignment returns true if the word is NAME=value
+func isAssignment(word string) bool {
+	i := strings.IndexByte(word, '=')
+	if i < 1 {
+		return false
+	}
+	for j, r := range word[:i] {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || j > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
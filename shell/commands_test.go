@@ -0,0 +1,42 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommands(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    [][]string
+		wantErr error
+	}{
+		{in: "", want: nil},
+		{in: "uptime", want: [][]string{{"uptime"}}},
+		{in: "cd /tmp && ls -l | wc -l; df -h &", want: [][]string{{"cd", "/tmp"}, {"ls", "-l"}, {"wc", "-l"}, {"df", "-h"}}},
+		{in: "systemctl status 'my app'\njournalctl -u app || true", want: [][]string{{"systemctl", "status", "my app"}, {"journalctl", "-u", "app"}, {"true"}}},
+		{in: `grep 'a;b|c' "x && y" d\;e`, want: [][]string{{"grep", "a;b|c", "x && y", "d;e"}}},
+		{in: `echo '$HOME' "\$PATH"`, want: [][]string{{"echo", "$HOME", "$PATH"}}},
+		{in: "echo $(rm -rf /)", wantErr: ErrUncheckable},
+		{in: `echo "$(id)"`, wantErr: ErrUncheckable},
+		{in: "echo `id`", wantErr: ErrUncheckable},
+		{in: "$CMD -rf /", wantErr: ErrUncheckable},
+		{in: "echo x > /etc/passwd", wantErr: ErrUncheckable},
+		{in: "(rm -rf /)", wantErr: ErrUncheckable},
+		{in: "uptime; if true; then rm -rf /; fi", wantErr: ErrUncheckable},
+		{in: "{ rm -rf /; }", wantErr: ErrUncheckable},
+		{in: "PATH=/tmp uptime", wantErr: ErrUncheckable},
+		{in: "ls --color=auto", want: [][]string{{"ls", "--color=auto"}}},
+		{in: "echo 'open", wantErr: ErrUnterminated},
+	}
+	for _, tt := range tests {
+		got, err := Commands(tt.in)
+		if tt.wantErr != nil {
+			assert.Equal(t, tt.wantErr, err, "Commands(%q)", tt.in)
+			continue
+		}
+		assert.NoError(t, err, "Commands(%q)", tt.in)
+		assert.Equal(t, tt.want, got, "Commands(%q)", tt.in)
+	}
+}
@@ -0,0 +1,28 @@
+//go:build go1.18
+// +build go1.18
+
+package shell
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func FuzzQuote(f *testing.F) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		f.Skip("sh is not found")
+	}
+	for _, s := range tricky {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		// the NUL can't be passed in the arguments
+		if strings.ContainsRune(s, 0) {
+			t.Skip()
+		}
+		if got := shellArgs(t, Join(s, s)); len(got) != 2 || got[0] != s || got[1] != s {
+			t.Errorf("Join(%q, %q) is split into %q", s, s, got)
+		}
+	})
+}
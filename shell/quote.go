@@ -0,0 +1,44 @@
+// Package shell quotes the strings to be passed intact through the POSIX
+// shell of the node, such as the commands run by tsh ssh
+package shell
+
+import "strings"
+
+// Quote quotes s with single quotes to be a single POSIX shell word,
+// nothing inside is expanded including the quotes, globs, variables & newlines
+func Quote(s string) string {
+	return "'" + strings.Replace(s, "'", `'"'"'`, -1) + "'"
+}
+
+// QuoteIfNeeded quotes s like Quote unless it's a plain shell word,
+// to keep the printed command line readable
+func QuoteIfNeeded(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_=+./:@,%", r))
+	}) < 0 {
+		return s
+	}
+	return Quote(s)
+}
+
+// Join quotes every argument & joins them into a single command line,
+// the shell splits it back into the same arguments
+func Join(args ...string) string {
+	words := make([]string, len(args))
+	for i, arg := range args {
+		words[i] = QuoteIfNeeded(arg)
+	}
+	return strings.Join(words, " ")
+}
+
+// Path quotes the remote path to be a single shell word, the leading ~
+// is kept out of the quote to still be expanded into the home directory
+func Path(p string) string {
+	if strings.HasPrefix(p, "~/") {
+		return `"$HOME"/` + Quote(strings.TrimPrefix(p, "~/"))
+	}
+	if p == "~" {
+		return `"$HOME"`
+	}
+	return Quote(p)
+}
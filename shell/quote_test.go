@@ -0,0 +1,85 @@
+package shell
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "", want: "''"},
+		{in: "uptime", want: "'uptime'"},
+		{in: "it's", want: `'it'"'"'s'`},
+		{in: "echo $HOME *", want: "'echo $HOME *'"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Quote(tt.in))
+	}
+}
+
+func TestJoin(t *testing.T) {
+	assert.Equal(t, "sudo -n -u root -- sh -c 'echo '\"'\"'hi'\"'\"' | wc -c'",
+		Join("sudo", "-n", "-u", "root", "--", "sh", "-c", "echo 'hi' | wc -c"))
+	assert.Equal(t, "printf ''", Join("printf", ""))
+}
+
+func TestPath(t *testing.T) {
+	assert.Equal(t, `"$HOME"/'my app'`, Path("~/my app"))
+	assert.Equal(t, `"$HOME"`, Path("~"))
+	assert.Equal(t, `'/opt/~app'`, Path("/opt/~app"))
+}
+
+// tricky are the strings the shell is likely to mangle
+var tricky = []string{
+	"", " ", "'", `"`, `\`, "''", `'\''`, "$HOME", "${HOME:-x}", "$(id)", "`id`",
+	"*", "?", "[a-z]", "~", "~root", "a b\tc", "line1\nline2", "\n", "!", "#comment",
+	"a;b", "a && b", "a | b", "> /tmp/x", "日本語", "-n", "--", "%s",
+}
+
+// shellArgs runs the command line by sh & returns the arguments it got
+func shellArgs(t *testing.T, line string) []string {
+	out, err := exec.Command("sh", "-c", `printf '%s\0' `+line).Output()
+	if err != nil {
+		t.Fatalf("sh -c %s failed, error: %v", line, err)
+	}
+	return strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00")
+}
+
+func TestQuote_shell(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not found")
+	}
+
+	for _, s := range tricky {
+		assert.Equal(t, []string{s}, shellArgs(t, Quote(s)), "Quote(%q)", s)
+		assert.Equal(t, []string{s}, shellArgs(t, QuoteIfNeeded(s)), "QuoteIfNeeded(%q)", s)
+	}
+	assert.Equal(t, tricky, shellArgs(t, Join(tricky...)))
+
+	// the random strings, the NUL can't be passed in the arguments
+	check := func(s string) bool {
+		s = strings.Replace(s, "\x00", "", -1)
+		got := shellArgs(t, Quote(s))
+		return len(got) == 1 && got[0] == s
+	}
+	if err := quick.Check(check, &quick.Config{MaxCount: 200}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPath_shell(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not found")
+	}
+
+	out, err := exec.Command("sh", "-c", `HOME=/home/me; printf '%s' `+Path("~/a b/$x")).Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "/home/me/a b/$x", string(out))
+}
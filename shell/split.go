@@ -0,0 +1,67 @@
+package shell
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrUnterminated is returned by Split when a quote isn't closed
+var ErrUnterminated = errors.New("unterminated quote")
+
+// Split splits the command line into the words like the POSIX shell
+// does with the quotes & the backslashes, nothing is expanded
+func Split(s string) ([]string, error) {
+	var (
+		words []string
+		word  strings.Builder
+		// inWord is true once the word is started even though it's empty like ''
+		inWord bool
+		quote  rune
+		escape bool
+	)
+	for _, r := range s {
+		switch {
+		case escape:
+			// only these are escaped inside the double quotes
+			if quote == '"' && !strings.ContainsRune("\"\\$`\n", r) {
+				word.WriteRune('\\')
+			}
+			if r != '\n' {
+				word.WriteRune(r)
+			}
+			escape = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			escape, inWord = true, true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 || escape {
+		return nil, ErrUnterminated
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
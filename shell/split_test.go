@@ -0,0 +1,40 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []string
+		wantErr bool
+	}{
+		{in: "", want: nil},
+		{in: `prod --filter 'web-*' --exec "sudo systemctl restart app"`,
+			want: []string{"prod", "--filter", "web-*", "--exec", "sudo systemctl restart app"}},
+		{in: `a\ b "c \"d\" \x" '' e`, want: []string{"a b", `c "d" \x`, "", "e"}},
+		{in: "  a\t\tb\n", want: []string{"a", "b"}},
+		{in: `'it'"'"'s'`, want: []string{"it's"}},
+		{in: `'open`, wantErr: true},
+		{in: `"open`, wantErr: true},
+		{in: `trailing\`, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := Split(tt.in)
+		if tt.wantErr {
+			assert.Error(t, err, "Split(%q)", tt.in)
+			continue
+		}
+		assert.NoError(t, err, "Split(%q)", tt.in)
+		assert.Equal(t, tt.want, got, "Split(%q)", tt.in)
+	}
+}
+
+func TestSplit_join(t *testing.T) {
+	got, err := Split(Join(tricky...))
+	assert.NoError(t, err)
+	assert.Equal(t, tricky, got)
+}
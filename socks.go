@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/adzimzf/tpot/audit"
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/spf13/cobra"
+)
+
+const proxyExample = `
+tpot proxy staging                  // Pick a staging node then start a SOCKS proxy on localhost:1080
+tpot proxy prod web-01 -p 8888      // Start a SOCKS proxy on localhost:8888 through the web-01 node
+`
+
+var proxyCmd = &cobra.Command{
+	Use:     "proxy <ENVIRONMENT> [HOST]",
+	Short:   "Start a SOCKS proxy through the selected node",
+	Long:    "Start a SOCKS5 proxy using the tsh dynamic port forwarding, it reconnects automatically whenever the connection drops",
+	Example: proxyExample,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return usageErrorf("ENVIRONMENT is required")
+		}
+
+		proxy, err := loadProxy(cmd, args[0])
+		if err != nil {
+			return err
+		}
+		return socksHandler(cmd, proxy, args[1:])
+	},
+}
+
+func init() {
+	proxyCmd.Flags().IntP("port", "p", 1080, "the local port of the SOCKS proxy")
+	proxyCmd.Flags().String("bind", "127.0.0.1", "the local address of the SOCKS proxy")
+	proxyCmd.Flags().StringP("user", "u", "", "user to login to the desired host")
+	rootCmd.AddCommand(proxyCmd)
+}
+
+// socksHandler starts the SOCKS proxy through the host of the argument, otherwise
+// through the picked host of the proxy
+func socksHandler(cmd *cobra.Command, proxy *config.Proxy, args []string) error {
+	node := proxy.Node
+
+	var host string
+	var err error
+	if len(args) > 0 {
+		if host, err = lookUpHost(proxy, args[0]); err != nil {
+			return err
+		}
+	} else {
+		host, _ = selector.SelectHost(proxy, false)
+	}
+	if host == "" {
+		return errNoHost
+	}
+
+	user, err := getUserLogin(cmd, &node)
+	if err != nil {
+		return err
+	}
+
+	bind, _ := cmd.Flags().GetString("bind")
+	port, _ := cmd.Flags().GetInt("port")
+	listen := net.JoinHostPort(bind, strconv.Itoa(port))
+
+	// the proxy would be announced as ready by whatever holds the port
+	if err := checkListenable(listen); err != nil {
+		return err
+	}
+
+	t := tsh.NewTSH(proxy)
+	if err := openSession(cmd, proxy, t, host); err != nil {
+		return err
+	}
+	auditEvent(audit.Event{Action: audit.ActionForward, Env: proxy.Env, Host: host, User: user, Detail: "socks proxy " + listen})
+
+	go announceSocks(cmd, listen)
+	runSocksProxy(cmd, t, user, host, listen)
+	return nil
+}
+
+// runSocksProxy keeps the dynamic forwarding alive, it reconnects
+// with an exponential backoff whenever the tsh process exits
+func runSocksProxy(cmd *cobra.Command, t *tsh.TSH, user, host, listen string) {
+	const minBackoff, maxBackoff = time.Second, 30 * time.Second
+	backoff := minBackoff
+	for {
+		start := time.Now()
+		err := t.DynamicForward(user, host, listen)
+
+		// the connection was healthy for a while, reconnect immediately
+		if time.Since(start) > time.Minute {
+			backoff = minBackoff
+		}
+		cmd.PrintErrf("SOCKS proxy is disconnected (%v), reconnecting in %s\n", err, backoff)
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// checkListenable ensures nothing is listening on the local address yet
+func checkListenable(listen string) error {
+	l, err := net.Listen("tcp", listen)
+	if err != nil {
+		return fmt.Errorf("can't listen on %s, error: %v", listen, err)
+	}
+	return l.Close()
+}
+
+// announceSocks waits until the SOCKS proxy is listening then prints
+// how to use it
+func announceSocks(cmd *cobra.Command, listen string) {
+	for {
+		conn, err := net.DialTimeout("tcp", listen, time.Second)
+		if err == nil {
+			conn.Close()
+			break
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	if quiet {
+		cmd.Println("socks5://" + listen)
+		return
+	}
+	cmd.Println(fmt.Sprintf(`SOCKS5 proxy is ready at socks5://%[1]s
+  curl --socks5-hostname %[1]s http://internal.service
+  export ALL_PROXY=socks5h://%[1]s
+Press CTRL+C to stop`, listen))
+}
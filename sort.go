@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/ui"
+)
+
+// the orders of the picker by --sort
+const (
+	sortByName     = "name"
+	sortByIP       = "ip"
+	sortByLastUsed = "last_used"
+	sortByLatency  = "latency"
+
+	// sortByLabel is the prefix of the order by a label value such as label.team
+	sortByLabel = "label."
+)
+
+var (
+	// pickerSort is the order of the hosts in the picker
+	pickerSort = sortByName
+
+	// pickerSortSet is true when --sort is given, it takes
+	// precedence over the order chosen last time
+	pickerSortSet bool
+)
+
+// validSort returns true if the picker hosts can be sorted by it
+func validSort(by string) bool {
+	switch by {
+	case sortByName, sortByIP, sortByLastUsed, sortByLatency:
+		return true
+	}
+	return strings.HasPrefix(by, sortByLabel) && by != sortByLabel
+}
+
+// initialSort returns the order the picker of the proxy starts with,
+// it's the one chosen last time unless --sort is given
+func initialSort(proxy *config.Proxy) string {
+	if pickerSortSet {
+		return pickerSort
+	}
+	last, err := proxy.GetPickerSort()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING! failed to load the picker sort, error: %v\n", err)
+	}
+	if validSort(last) {
+		return last
+	}
+	return pickerSort
+}
+
+// saveSort saves the order chosen in the picker for the next time
+func saveSort(proxy *config.Proxy, by string) {
+	if last, _ := proxy.GetPickerSort(); last == by || last == "" && by == sortByName {
+		return
+	}
+	if err := proxy.SetPickerSort(by); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING! failed to save the picker sort, error: %v\n", err)
+	}
+}
+
+// pickerSorts returns the orders the picker switches through, the labels
+// are the ones of the label columns along with the initial order
+func pickerSorts(proxy *config.Proxy, initial string) []ui.Sort {
+	names := []string{sortByName, sortByIP, sortByLastUsed, sortByLatency}
+	columns, _ := proxy.Columns(defaultPickerColumns)
+	for _, c := range columns {
+		if c.Name == config.ColumnLabel {
+			names = append(names, sortByLabel+c.Label)
+		}
+	}
+	found := false
+	for _, name := range names {
+		found = found || name == initial
+	}
+	if !found {
+		names = append(names, initial)
+	}
+
+	stats, err := proxy.GetHostStats()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING! failed to load the host stats, error: %v\n", err)
+	}
+	res := make([]ui.Sort, len(names))
+	for i, name := range names {
+		res[i] = ui.Sort{Name: name, Hosts: sortHosts(proxy.Node.Items, proxy.Node.Names(), name, stats)}
+	}
+	return res
+}
+
+// sortHosts returns the names of the items in the order, nil is by name
+// which the picker sorts by itself. The hosts without the value such as
+// the never used ones are the last ones sorted by name. The names are
+// of config.Node.Names by the item Key
+func sortHosts(items []config.Item, names map[string]string, by string, stats map[string]config.HostStats) []string {
+	if by == sortByName {
+		return nil
+	}
+	items = append([]config.Item(nil), items...)
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Hostname < items[j].Hostname })
+
+	switch {
+	case by == sortByIP:
+		config.SortByIP(items)
+	case by == sortByLastUsed:
+		sort.SliceStable(items, func(i, j int) bool {
+			a, b := stats[names[items[i].Key()]].LastSuccess, stats[names[items[j].Key()]].LastSuccess
+			if a.IsZero() != b.IsZero() {
+				return b.IsZero()
+			}
+			return a.After(b)
+		})
+	case by == sortByLatency:
+		sort.SliceStable(items, func(i, j int) bool {
+			a, b := stats[names[items[i].Key()]].Latency, stats[names[items[j].Key()]].Latency
+			if (a == 0) != (b == 0) {
+				return b == 0
+			}
+			return a < b
+		})
+	case strings.HasPrefix(by, sortByLabel):
+		key := strings.TrimPrefix(by, sortByLabel)
+		sort.SliceStable(items, func(i, j int) bool {
+			a, okA := items[i].Labels[key]
+			b, okB := items[j].Labels[key]
+			if okA != okB {
+				return okA
+			}
+			return a < b
+		})
+	}
+
+	hosts := make([]string, len(items))
+	for i, item := range items {
+		hosts[i] = names[item.Key()]
+	}
+	return hosts
+}
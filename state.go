@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/adzimzf/tpot/config"
+)
+
+// loadState loads the UI state, failing to load it only warns
+// as it's only the preferences of the UI
+func loadState() config.State {
+	s, err := config.GetState()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING! failed to load the UI state, error: %v\n", err)
+	}
+	return s
+}
+
+// savePicked saves the UI state once a host of the env is picked by the query
+func savePicked(env, query string, hideColumns bool) {
+	err := config.UpdateState(func(s *config.State) {
+		s.LastEnv, s.HideColumns = env, hideColumns
+		e := s.Envs[env]
+		e.Filter = query
+		s.Envs[env] = e
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING! failed to save the UI state, error: %v\n", err)
+	}
+}
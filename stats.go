@@ -0,0 +1,168 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/i18n"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/spf13/cobra"
+)
+
+const statsExample = `
+tpot stats hosts prod            // Show the connection successes & failures of the production hosts
+tpot stats hosts prod --failing  // Only show the production hosts failed the last connection
+`
+
+var statsCmd = &cobra.Command{
+	Use:     "stats",
+	Short:   "Show the statistics recorded by tpot",
+	Example: statsExample,
+}
+
+var statsHostsCmd = &cobra.Command{
+	Use:   "hosts <ENVIRONMENT>",
+	Short: "Show the connection successes & failures of the hosts, the most failing first",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return usageErrorf("ENVIRONMENT is required")
+		}
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+		proxy, err := findProxy(cmd, cfg, args[0])
+		if err != nil {
+			return err
+		}
+
+		stats, err := proxy.GetHostStats()
+		if err != nil {
+			return fmt.Errorf("failed to load the host stats of %s, error: %v", proxy.Env, err)
+		}
+		failing, _ := cmd.Flags().GetBool("failing")
+		hosts := make([]string, 0, len(stats))
+		for host, s := range stats {
+			if !failing || s.FailedInRow > 0 {
+				hosts = append(hosts, host)
+			}
+		}
+		if len(hosts) == 0 {
+			infof(cmd, "there's no connection recorded for %s yet\n", proxy.Env)
+			return nil
+		}
+		sort.Slice(hosts, func(i, j int) bool {
+			a, b := stats[hosts[i]], stats[hosts[j]]
+			if a.FailedInRow != b.FailedInRow {
+				return a.FailedInRow > b.FailedInRow
+			}
+			return hosts[i] < hosts[j]
+		})
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "HOST\tSUCCESSES\tFAILURES\tFAILED IN ROW\tLAST SUCCESS\tLAST FAILURE\tLAST ERROR")
+		for _, host := range hosts {
+			s := stats[host]
+			lastError := s.LastError
+			if lastError == "" {
+				lastError = "-"
+			}
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\t%s\t%s\n", host, s.Successes, s.Failures, s.FailedInRow,
+				formatTime(s.LastSuccess), formatTime(s.LastFailure), lastError)
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	statsHostsCmd.Flags().Bool("failing", false, "only show the hosts failed the last connection")
+	statsCmd.AddCommand(statsHostsCmd)
+	rootCmd.AddCommand(statsCmd)
+}
+
+// formatTime returns the local time, - when it's zero
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+// recordConnection records the outcome of the ssh session into the host,
+// the session failed by the remote command is still connected. The error
+// of neither is left out, such as tsh isn't found
+func recordConnection(proxy *config.Proxy, host string, err error) {
+	if tsh.DryRun {
+		return
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case tsh.IsConnectionError(err):
+		var tshErr *tsh.Error
+		if errors.As(err, &tshErr) {
+			err = errors.New(tshErr.Message)
+		}
+	case err == nil || errors.As(err, &exitErr):
+		err = nil
+	default:
+		return
+	}
+	if err := proxy.RecordConnection(host, time.Now(), err); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING! failed to save the host stats, error: %v\n", err)
+	}
+}
+
+// hostNotes returns the note of the hosts in maintenance, not listed by the latest
+// refresh or failed the last connections to be shown in the picker, nil if there's none
+func hostNotes(proxy *config.Proxy) map[string]string {
+	notes := maintenanceNotes(proxy)
+	addNote := func(host, note string) {
+		if notes == nil {
+			notes = map[string]string{}
+		}
+		if notes[host] != "" {
+			note = notes[host] + ", " + note
+		}
+		notes[host] = note
+	}
+
+	// the instances of the hostname listed more than once are noted by their
+	// names, the maintenance of the hostname applies to every instance
+	names := proxy.Node.Names()
+	for _, item := range proxy.Node.Items {
+		if name := names[item.Key()]; name != item.Hostname && notes[item.Hostname] != "" {
+			addNote(name, notes[item.Hostname])
+		}
+	}
+	at := now()
+	for _, item := range proxy.Node.Stale() {
+		if item.LastSeen == nil {
+			addNote(names[item.Key()], i18n.T("not seen by the latest refresh"))
+			continue
+		}
+		addNote(names[item.Key()], i18n.Sprintf("last seen %s", formatAgo(*item.LastSeen, at)))
+	}
+
+	stats, err := proxy.GetHostStats()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING! failed to load the host stats, error: %v\n", err)
+		return notes
+	}
+	for host, s := range stats {
+		if s.FailedInRow == 0 {
+			continue
+		}
+		note := i18n.Sprintf("failed last %d attempts", s.FailedInRow)
+		if s.FailedInRow == 1 {
+			note = i18n.T("failed last attempt")
+		}
+		addNote(host, note)
+	}
+	return notes
+}
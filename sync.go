@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/adzimzf/tpot/audit"
+	"github.com/adzimzf/tpot/filesync"
+	"github.com/adzimzf/tpot/shell"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/spf13/cobra"
+)
+
+const syncExample = `
+tpot sync staging ./app web-01:/opt/app          // Upload the changed files of ./app into /opt/app of web-01
+tpot sync staging ./app web-01:~/app --checksum  // Compare the files by checksum instead of size & mtime
+tpot sync staging ./app web-01:~/app --watch     // Keep syncing whenever the local files change
+`
+
+var syncCmd = &cobra.Command{
+	Use:     "sync <ENVIRONMENT> <LOCAL DIR> <HOST>:<REMOTE DIR>",
+	Short:   "Sync a local directory into a node incrementally",
+	Long:    "Upload only the new or changed files of a local directory into the node through tsh",
+	Example: syncExample,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 3 {
+			return usageErrorf("ENVIRONMENT, LOCAL DIR & HOST:REMOTE DIR are required")
+		}
+
+		hostRemote := strings.SplitN(args[2], ":", 2)
+		if len(hostRemote) != 2 || hostRemote[0] == "" || hostRemote[1] == "" {
+			return usageErrorf("invalid destination %s, use format <host>:<remote dir>", args[2])
+		}
+		if info, err := os.Stat(args[1]); err != nil || !info.IsDir() {
+			return fmt.Errorf("%s is not a directory", args[1])
+		}
+
+		proxy, err := loadProxy(cmd, args[0])
+		if err != nil {
+			return err
+		}
+		node := proxy.Node
+
+		host, err := lookUpHost(proxy, hostRemote[0])
+		if err != nil {
+			return err
+		}
+		if err := guardReadOnly(proxy, "sync", host); err != nil {
+			return err
+		}
+
+		user, err := getUserLogin(cmd, &node)
+		if err != nil {
+			return err
+		}
+
+		checksum, _ := cmd.Flags().GetBool("checksum")
+		s := &syncer{
+			tsh:      tsh.NewTSH(proxy),
+			env:      proxy.Env,
+			user:     user,
+			host:     host,
+			local:    args[1],
+			remote:   hostRemote[1],
+			checksum: checksum,
+		}
+		for _, command := range []string{s.scanCommand(), s.uploadCommand()} {
+			if err := guardExec(proxy, command, s.host); err != nil {
+				return err
+			}
+		}
+		if err := openSession(cmd, proxy, s.tsh, s.host); err != nil {
+			return err
+		}
+		if err := s.sync(cmd); err != nil {
+			return err
+		}
+
+		if watch, _ := cmd.Flags().GetBool("watch"); watch {
+			interval, _ := cmd.Flags().GetDuration("interval")
+			s.watch(cmd, interval)
+		}
+		return nil
+	},
+}
+
+func init() {
+	syncCmd.Flags().Bool("checksum", false, "compare the files by sha256 checksum instead of size & modification time")
+	syncCmd.Flags().BoolP("watch", "w", false, "keep syncing whenever the local files change")
+	syncCmd.Flags().Duration("interval", time.Second, "how often the local files are checked on --watch")
+	syncCmd.Flags().StringP("user", "u", "", "user to login to the node")
+	rootCmd.AddCommand(syncCmd)
+}
+
+type syncer struct {
+	tsh           *tsh.TSH
+	env           string
+	user, host    string
+	local, remote string
+	checksum      bool
+}
+
+// scanCommand lists the remote files to compare with the local ones
+func (s *syncer) scanCommand() string {
+	return filesync.RemoteScanCommand(shell.Path(s.remote), s.checksum)
+}
+
+// uploadCommand extracts the tar of the local files into the remote directory
+func (s *syncer) uploadCommand() string {
+	dir := shell.Path(s.remote)
+	return "mkdir -p " + dir + " && tar -xf - -C " + dir
+}
+
+// sync uploads the local files missing or different in the remote
+func (s *syncer) sync(cmd *cobra.Command) error {
+	local, err := filesync.Scan(s.local, s.checksum)
+	if err != nil {
+		return fmt.Errorf("failed to scan %s, error: %v", s.local, err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	err = s.tsh.Exec(s.user, s.host, s.scanCommand(), &stdout, &stderr)
+	if err != nil {
+		return fmt.Errorf("failed to scan %s:%s, error: %v %s", s.host, s.remote, err, stderr.String())
+	}
+	remote, err := filesync.ParseRemote(stdout.String(), s.checksum)
+	if err != nil {
+		return fmt.Errorf("failed to scan %s:%s, error: %v", s.host, s.remote, err)
+	}
+
+	files := filesync.Diff(local, remote, s.checksum)
+	if len(files) == 0 {
+		cmd.Printf("%s:%s is up to date\n", s.host, s.remote)
+		return nil
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(filesync.WriteTar(pw, s.local, files))
+	}()
+
+	stderr.Reset()
+	err = s.tsh.ExecWithInput(s.user, s.host, s.uploadCommand(), pr, os.Stdout, &stderr)
+	if err != nil {
+		return fmt.Errorf("failed to upload into %s:%s, error: %v %s", s.host, s.remote, err, stderr.String())
+	}
+
+	auditEvent(audit.Event{Action: audit.ActionExec, Env: s.env, Host: s.host, User: s.user,
+		Command: "tar -xf - -C " + s.remote, Detail: fmt.Sprintf("sync %d files from %s", len(files), s.local)})
+	for _, f := range files {
+		infof(cmd, "  %s\n", f)
+	}
+	cmd.Printf("%d files are synced into %s:%s\n", len(files), s.host, s.remote)
+	return nil
+}
+
+// watch polls the local directory & syncs once there's any change
+func (s *syncer) watch(cmd *cobra.Command, interval time.Duration) {
+	infof(cmd, "watching %s, press CTRL+C to stop\n", s.local)
+	last, _ := filesync.Scan(s.local, false)
+	for {
+		time.Sleep(interval)
+		current, err := filesync.Scan(s.local, false)
+		if err != nil {
+			cmd.PrintErrf("failed to scan %s, error: %v\n", s.local, err)
+			continue
+		}
+		if reflect.DeepEqual(last, current) {
+			continue
+		}
+		if err := s.sync(cmd); err != nil {
+			cmd.PrintErrln(err)
+			continue
+		}
+		last = current
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/adzimzf/tpot/audit"
+	"github.com/adzimzf/tpot/shell"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/adzimzf/tpot/ui"
+	"github.com/spf13/cobra"
+)
+
+const tailExample = `
+tpot tail prod -- /var/log/app.log                                // Tail the app log of the selected production host
+tpot tail prod --filter 'web-*' -- /var/log/app.log               // Tail the app log of every production web host at once
+tpot tail prod --filter 'web-*' -n 100 -- /var/log/nginx/*.log    // Start from the last 100 lines of every nginx log
+tpot tail prod --filter 'db-*' --sudo -- /var/log/postgresql.log  // Tail the log readable by root only
+`
+
+// tailColors is the colors of the host prefixes in turn
+var tailColors = []string{"cyan", "green", "yellow", "magenta", "blue", "red"}
+
+// the backoff of reconnecting the dropped tail
+const (
+	tailMinBackoff = time.Second
+	tailMaxBackoff = 30 * time.Second
+)
+
+var tailCmd = &cobra.Command{
+	Use:   "tail <ENVIRONMENT> -- <FILE>...",
+	Short: "Follow the files of many nodes at once with the merged output",
+	Long: `Follow the files of the selected node or of every node match the --filter concurrently, every line is
+prefixed by the colored hostname. The dropped connection is reconnected with the backoff from the end of the file
+so the lines aren't repeated, the lines written while it's disconnected are skipped. Press CTRL+C to stop`,
+	Example: tailExample,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var files []string
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			args, files = args[:dash], args[dash:]
+		}
+		if len(args) != 1 || len(files) == 0 {
+			return usageErrorf("ENVIRONMENT & FILE are required")
+		}
+		lines, _ := cmd.Flags().GetInt("lines")
+		if lines < 0 {
+			return usageErrorf("--lines can't be negative")
+		}
+
+		proxy, err := loadProxy(cmd, args[0])
+		if err != nil {
+			return err
+		}
+		var hosts []string
+		if expr, _ := cmd.Flags().GetString("filter"); expr != "" {
+			items, err := proxy.FilterNodes(expr)
+			if err != nil {
+				return usageErrorf("invalid --filter, error: %v", err)
+			}
+			hosts = proxy.Node.NamesOf(items)
+			if len(hosts) == 0 {
+				return fmt.Errorf("there's no host match %s", expr)
+			}
+			sort.Strings(hosts)
+		} else {
+			host, _ := selector.SelectHost(proxy, false)
+			if host == "" {
+				return errNoHost
+			}
+			hosts = []string{host}
+		}
+
+		target := hosts[0]
+		if len(hosts) > 1 {
+			target = fmt.Sprintf("%d hosts", len(hosts))
+		}
+		user, err := getUserLogin(cmd, &proxy.Node)
+		if err != nil {
+			return err
+		}
+		t := tsh.NewTSH(proxy)
+		tl := &tailer{
+			files: files,
+			sudo:  sudoUser(cmd),
+			exec: func(ctx context.Context, host, command string, stdout, stderr io.Writer) error {
+				return t.ExecContext(ctx, user, host, command, nil, stdout, stderr)
+			},
+		}
+		if err := guardExec(proxy, tl.command(lines), hosts...); err != nil {
+			return err
+		}
+		if err := openSession(cmd, proxy, t, target, hosts...); err != nil {
+			return err
+		}
+		for _, host := range hosts {
+			auditEvent(audit.Event{Action: audit.ActionExec, Env: proxy.Env, Host: host, User: user, Command: tl.command(lines)})
+		}
+
+		// the tail commands are stopped by CTRL+C instead of tpot being killed
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		tl.run(ctx, hosts, lines)
+		return nil
+	},
+}
+
+func init() {
+	tailCmd.Flags().String("filter", "", "tail every node match the filter expression instead of picking one")
+	addCIDRFlag(tailCmd)
+	tailCmd.Flags().IntP("lines", "n", 10, "the number of the last lines printed before following the files")
+	tailCmd.Flags().StringP("user", "u", "", "user to login to the nodes")
+	tailCmd.Flags().String("sudo", "", "read the files as the user through the passwordless sudo, default user is root")
+	tailCmd.Flags().Lookup("sudo").NoOptDefVal = "root"
+	rootCmd.AddCommand(tailCmd)
+}
+
+// tailer follows the files of the hosts concurrently
+type tailer struct {
+	files []string
+
+	// sudo is the user reading the files through sudo, empty means the login user
+	sudo string
+
+	// exec runs the command on the host until the ctx is done
+	exec func(ctx context.Context, host, command string, stdout, stderr io.Writer) error
+
+	// sleep waits for the backoff, it returns false once the ctx is done
+	sleep func(ctx context.Context, d time.Duration) bool
+}
+
+// command returns the command following the files from the last lines,
+// tail -F keeps following the file once it's rotated
+func (tl *tailer) command(lines int) string {
+	args := append([]string{"tail", "-n", strconv.Itoa(lines), "-F", "--"}, tl.files...)
+	command := shell.Join(args...)
+	if tl.sudo != "" {
+		return sudoCommand(tl.sudo, command, false)
+	}
+	return command
+}
+
+// run tails the hosts until the ctx is done, the output of every host
+// is prefixed by the hostname padded to the longest one
+func (tl *tailer) run(ctx context.Context, hosts []string, lines int) {
+	width := 0
+	for _, host := range hosts {
+		if len(host) > width {
+			width = len(host)
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		prefix := ui.Colorize(fmt.Sprintf("%-*s", width, host), tailColors[i%len(tailColors)]) + " | "
+		stdout := &prefixWriter{prefix: prefix, out: os.Stdout, mu: &mu}
+		stderr := &prefixWriter{prefix: prefix, out: os.Stderr, mu: &mu}
+		wg.Add(1)
+		go func(host string) {
+			defer wg.Done()
+			tl.follow(ctx, host, lines, stdout, stderr, &mu)
+			stdout.Flush()
+			stderr.Flush()
+		}(host)
+	}
+	wg.Wait()
+}
+
+// follow tails the host until the ctx is done, it reconnects with the backoff
+// once the connection drops. The host failing quickly without reaching it, such
+// as the missing sudo, is given up instead of being retried forever
+func (tl *tailer) follow(ctx context.Context, host string, lines int, stdout, stderr io.Writer, mu *sync.Mutex) {
+	sleep := tl.sleep
+	if sleep == nil {
+		sleep = sleepCtx
+	}
+	backoff := tailMinBackoff
+	for {
+		start := time.Now()
+		err := tl.exec(ctx, host, tl.command(lines), stdout, stderr)
+		switch {
+		case ctx.Err() != nil || tsh.DryRun || err == nil:
+			return
+		case !tsh.IsConnectionError(err) && time.Since(start) < quickFailure:
+			mu.Lock()
+			fmt.Fprintf(os.Stderr, "%s: %v, giving up\n", host, err)
+			mu.Unlock()
+			return
+		}
+
+		// the connection was healthy for a while, reconnect immediately
+		if time.Since(start) > time.Minute {
+			backoff = tailMinBackoff
+		}
+		mu.Lock()
+		fmt.Fprintf(os.Stderr, "%s: disconnected (%v), reconnecting in %s\n", host, err, backoff)
+		mu.Unlock()
+		if !sleep(ctx, backoff) {
+			return
+		}
+		if backoff *= 2; backoff > tailMaxBackoff {
+			backoff = tailMaxBackoff
+		}
+		// the lines printed before the drop aren't repeated
+		lines = 0
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/adzimzf/tpot/audit"
+	"github.com/adzimzf/tpot/remote"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/adzimzf/tpot/ui"
+	"github.com/spf13/cobra"
+)
+
+const topExample = `
+tpot top staging                               // Watch the load, memory & disk of every staging node
+tpot top prod --filter 'web-*' -i 10s          // Sample the production web nodes every 10 seconds
+tpot top prod --filter 'db-*' --sort-by disk   // Show the fullest database disks first
+tpot top prod --filter 'web-*' --once          // Print the table once for the scripts
+`
+
+// topMinInterval keeps the nodes from being sampled in a busy loop
+const topMinInterval = time.Second
+
+var topCmd = &cobra.Command{
+	Use:   "top <ENVIRONMENT>",
+	Short: "Watch the load, memory & disk of the nodes as a live table",
+	Long: `Sample the load, memory & root disk usage of every node match the --filter every interval by running
+a single command through tsh concurrently, then show them as a live table sorted by the busiest node. The load is
+compared by the 1 minute load of every CPU, the node failing a sample keeps its previous metrics shown in red`,
+	Example: topExample,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return usageErrorf("ENVIRONMENT is required")
+		}
+		interval, _ := cmd.Flags().GetDuration("interval")
+		if interval < topMinInterval {
+			return usageErrorf("--interval must be at least %s", topMinInterval)
+		}
+		sortBy, _ := cmd.Flags().GetString("sort-by")
+		switch sortBy {
+		case ui.TopSortLoad, ui.TopSortMem, ui.TopSortDisk, ui.TopSortHost:
+		default:
+			return usageErrorf("invalid --sort-by %s, use load, mem, disk or host", sortBy)
+		}
+		parallel, _ := cmd.Flags().GetInt("parallel")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		once, _ := cmd.Flags().GetBool("once")
+
+		proxy, err := loadProxy(cmd, args[0])
+		if err != nil {
+			return err
+		}
+		items := proxy.Node.Items
+		if expr, _ := cmd.Flags().GetString("filter"); expr != "" {
+			if items, err = proxy.FilterNodes(expr); err != nil {
+				return usageErrorf("invalid --filter, error: %v", err)
+			}
+		}
+		if len(items) == 0 {
+			return fmt.Errorf("there's no nodes found")
+		}
+		hosts := proxy.Node.NamesOf(items)
+		sort.Strings(hosts)
+
+		user, err := getUserLogin(cmd, &proxy.Node)
+		if err != nil {
+			return err
+		}
+		if err := guardExec(proxy, remote.MetricsCommand, hosts...); err != nil {
+			return err
+		}
+		t := tsh.NewTSH(proxy)
+		if err := openSession(cmd, proxy, t, fmt.Sprintf("%d hosts", len(hosts)), hosts...); err != nil {
+			return err
+		}
+		auditEvent(audit.Event{Action: audit.ActionExec, Env: proxy.Env, User: user,
+			Detail: fmt.Sprintf("top %d nodes", len(hosts))})
+
+		s := &topSampler{
+			parallel: parallel,
+			timeout:  timeout,
+			exec: func(ctx context.Context, host, command string, stdout, stderr io.Writer) error {
+				return t.ExecContext(ctx, user, host, command, nil, stdout, stderr)
+			},
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		// the table is printed once without the metrics of the dry-run
+		if once || tsh.DryRun {
+			rows := s.sample(ctx, hosts)
+			if tsh.DryRun {
+				return nil
+			}
+			ui.SortTopRows(rows, sortBy)
+			ui.WriteTopTable(os.Stdout, rows)
+			failed := 0
+			for _, r := range rows {
+				if !r.Sampled {
+					failed++
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d nodes aren't sampled", failed, len(rows))
+			}
+			return nil
+		}
+		title := fmt.Sprintf("%s: %d hosts every %s", proxy.Env, len(hosts), interval)
+		return ui.NewTopView(title, interval, sortBy, func(ctx context.Context) []ui.TopRow {
+			return s.sample(ctx, hosts)
+		}).Run(ctx)
+	},
+}
+
+func init() {
+	topCmd.Flags().String("filter", "", "only watch the nodes match the filter expression")
+	addCIDRFlag(topCmd)
+	topCmd.Flags().DurationP("interval", "i", 5*time.Second, "time between the samples")
+	topCmd.Flags().IntP("parallel", "p", 10, "number of nodes to sample concurrently")
+	topCmd.Flags().Duration("timeout", 10*time.Second, "maximum time to wait for each node")
+	topCmd.Flags().String("sort-by", ui.TopSortLoad, "the order of the nodes, one of load, mem, disk or host")
+	topCmd.Flags().Bool("once", false, "print the table once instead of watching the nodes")
+	topCmd.Flags().StringP("user", "u", "", "user to login to the nodes")
+	rootCmd.AddCommand(topCmd)
+}
+
+// topSampler samples the metrics of the hosts with maximum parallel
+// number of tsh process running at the same time
+type topSampler struct {
+	parallel int
+	timeout  time.Duration
+
+	// exec runs the command on the host until the ctx is done
+	exec func(ctx context.Context, host, command string, stdout, stderr io.Writer) error
+}
+
+// sample returns the rows of the hosts in order, the failed host has the Err only
+func (s *topSampler) sample(ctx context.Context, hosts []string) []ui.TopRow {
+	parallel := s.parallel
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	rows := make([]ui.TopRow, len(hosts))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, host string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			rows[i] = s.sampleHost(ctx, host)
+		}(i, host)
+	}
+	wg.Wait()
+	return rows
+}
+
+func (s *topSampler) sampleHost(ctx context.Context, host string) ui.TopRow {
+	row := ui.TopRow{Host: host}
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := s.exec(ctx, host, remote.MetricsCommand, &stdout, &stderr); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			row.Err = fmt.Sprintf("timeout after %s", s.timeout)
+			return row
+		}
+		row.Err = outputError(err, stderr.String()).Error()
+		return row
+	}
+	m, err := remote.ParseMetrics(stdout.String())
+	if err != nil {
+		row.Err = err.Error()
+		return row
+	}
+	row.Sampled = true
+	row.Load, row.CPUs = m.Load, m.CPUs
+	row.MemUsed, row.MemTotal = m.MemUsed, m.MemTotal
+	row.DiskUsed, row.DiskTotal = m.DiskUsed, m.DiskTotal
+	return row
+}
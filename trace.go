@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/trace"
+)
+
+// traced prints the steps of tpot along with their timings into the stderr
+var traced bool
+
+// traceLog keeps the steps of the traced run, it's saved as the
+// debug log bundled by tpot bug
+var traceLog bytes.Buffer
+
+// startTrace enables the trace of the steps once the flags are parsed
+func startTrace() {
+	if traced {
+		trace.Enable(io.MultiWriter(os.Stderr, &traceLog))
+	}
+}
+
+// saveTrace saves the steps of the traced run into the cache directory,
+// only the latest traced run is kept
+func saveTrace() {
+	if !traced || traceLog.Len() == 0 {
+		return
+	}
+	_ = ioutil.WriteFile(traceLogPath(), traceLog.Bytes(), 0600)
+}
+
+// traceLogPath returns the path of the debug log of the latest traced run
+func traceLogPath() string {
+	return config.CacheDir + "trace.log"
+}
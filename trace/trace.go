@@ -0,0 +1,132 @@
+// Package trace prints the steps of tpot such as loading the configuration
+// or running tsh along with their timings, it's the middle ground between
+// the silence & the full debug logs to see where the time goes
+package trace
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	mu     sync.Mutex
+	output io.Writer
+	start  time.Time
+
+	// now is replaced by the tests
+	now = time.Now
+)
+
+// Enable prints the steps into w from now on, nil disables it
+func Enable(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	output = w
+	start = now()
+}
+
+// Enabled returns true if the steps are printed
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return output != nil
+}
+
+// Step is a traced step, it's printed once it ends
+type Step struct {
+	name  string
+	begin time.Time
+}
+
+// Start starts the step named by the format, the step of the
+// disabled trace is nil which does nothing once it ends
+func Start(format string, a ...interface{}) *Step {
+	if !Enabled() {
+		return nil
+	}
+	return &Step{name: fmt.Sprintf(format, a...), begin: now()}
+}
+
+// End prints the step along with how long it took & the error if any
+func (s *Step) End(err error) {
+	if s == nil {
+		return
+	}
+	d := now().Sub(s.begin)
+	if err != nil {
+		printf(s.begin, "%s (%s) failed: %v", s.name, round(d), err)
+		return
+	}
+	printf(s.begin, "%s (%s)", s.name, round(d))
+}
+
+// EndWith prints the step along with its result such as the found version
+func (s *Step) EndWith(result string) {
+	if s == nil {
+		return
+	}
+	printf(s.begin, "%s: %s (%s)", s.name, result, round(now().Sub(s.begin)))
+}
+
+// printf prints the line prefixed by when it started since tpot started
+func printf(at time.Time, format string, a ...interface{}) {
+	mu.Lock()
+	defer mu.Unlock()
+	if output == nil {
+		return
+	}
+	fmt.Fprintf(output, "[trace] +%-8s %s\n", round(at.Sub(start)), fmt.Sprintf(format, a...))
+}
+
+func round(d time.Duration) time.Duration {
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond)
+	}
+	return d.Round(time.Millisecond)
+}
+
+// secretFlags is the flag names containing them are redacted by Sanitize
+var secretFlags = []string{"password", "passwd", "token", "secret", "otp"}
+
+// Sanitize redacts the values of the secret flags such as --password=xxx
+// or --token xxx, the args themselves are kept intact
+func Sanitize(args []string) []string {
+	res := make([]string, len(args))
+	redactNext := false
+	for i, arg := range args {
+		if redactNext {
+			res[i], redactNext = "***", false
+			continue
+		}
+		res[i] = arg
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name := strings.ToLower(arg)
+		value := ""
+		if j := strings.Index(arg, "="); j >= 0 {
+			name, value = strings.ToLower(arg[:j]), arg[j+1:]
+		}
+		if !isSecret(name) {
+			continue
+		}
+		if value != "" {
+			res[i] = arg[:len(arg)-len(value)] + "***"
+			continue
+		}
+		redactNext = !strings.Contains(arg, "=")
+	}
+	return res
+}
+
+func isSecret(flag string) bool {
+	for _, s := range secretFlags {
+		if strings.Contains(flag, s) {
+			return true
+		}
+	}
+	return false
+}
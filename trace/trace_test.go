@@ -0,0 +1,69 @@
+package trace
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStep(t *testing.T) {
+	clock := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+
+	// nothing is printed until it's enabled
+	Start("load the configuration").End(nil)
+	assert.False(t, Enabled())
+
+	buf := &bytes.Buffer{}
+	Enable(buf)
+	defer Enable(nil)
+
+	clock = clock.Add(12 * time.Millisecond)
+	step := Start("load the configuration")
+	clock = clock.Add(3 * time.Millisecond)
+	step.End(nil)
+
+	step = Start("run tsh version")
+	clock = clock.Add(1500 * time.Microsecond)
+	step.End(errors.New("exit status 1"))
+
+	step = Start("check the login of prod")
+	clock = clock.Add(250 * time.Millisecond)
+	step.EndWith("logged in true")
+
+	assert.Equal(t, "[trace] +12ms     load the configuration (3ms)\n"+
+		"[trace] +15ms     run tsh version (2ms) failed: exit status 1\n"+
+		"[trace] +17ms     check the login of prod: logged in true (250ms)\n", buf.String())
+}
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{
+			args: []string{"tsh", "ls", "--proxy=teleport.mycomp.com"},
+			want: []string{"tsh", "ls", "--proxy=teleport.mycomp.com"},
+		},
+		{
+			args: []string{"tsh", "login", "--password=s3cret", "--user=me"},
+			want: []string{"tsh", "login", "--password=***", "--user=me"},
+		},
+		{
+			args: []string{"tsh", "login", "--token", "abc", "--Auth-Token=xyz"},
+			want: []string{"tsh", "login", "--token", "***", "--Auth-Token=***"},
+		},
+		{
+			// the remote command isn't a flag
+			args: []string{"tsh", "ssh", "root@web-01", "echo password"},
+			want: []string{"tsh", "ssh", "root@web-01", "echo password"},
+		},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Sanitize(tt.args))
+	}
+}
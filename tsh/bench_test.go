@@ -0,0 +1,30 @@
+package tsh
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// nodesTable generates the `tsh ls` output of n nodes
+func nodesTable(n int) string {
+	var b strings.Builder
+	b.WriteString("Node Name          Address            Labels\n")
+	b.WriteString("------------------ ------------------ ------------------\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "web-%05d          10.0.%d.%d:3022      env=prod,team=web\n", i, i/256%256, i%256)
+	}
+	return b.String()
+}
+
+func BenchmarkParseNodesFromString(b *testing.B) {
+	for _, n := range []int{1000, 50000} {
+		table := nodesTable(n)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				parseNodesFromString(table)
+			}
+		})
+	}
+}
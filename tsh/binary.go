@@ -0,0 +1,178 @@
+package tsh
+
+import (
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/adzimzf/tpot/wsl"
+)
+
+// ErrBinary indicates the tsh binary can't be run on this machine
+var ErrBinary = errors.New("tsh binary is not usable")
+
+// CheckBinary ensures the tsh binary of the proxy exists, is executable
+// & is built for this OS/arch, so the failure is explained before running it
+func (t *TSH) CheckBinary() error {
+	// the replayed fixtures need no tsh
+	if isReplaying() {
+		return nil
+	}
+	return checkBinary(t.tshBinary())
+}
+
+func checkBinary(name string) error {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) || errors.Is(err, os.ErrNotExist) {
+			return binaryError(name, "is not found")
+		}
+		if errors.Is(err, os.ErrPermission) {
+			return binaryError(name, "is not executable")
+		}
+		return binaryError(name, err.Error())
+	}
+
+	osArch, ok := binaryOSArch(path)
+	if !ok {
+		// not a known executable format like a wrapper script
+		return nil
+	}
+	if !canRun(osArch) {
+		return binaryError(path, fmt.Sprintf("is built for %s but this machine is %s/%s", osArch, runtime.GOOS, runtime.GOARCH))
+	}
+	return nil
+}
+
+// canRun returns true if the binary of the os/arch can be run on this machine
+func canRun(osArch string) bool {
+	current := runtime.GOOS + "/" + runtime.GOARCH
+	for _, a := range strings.Split(osArch, ",") {
+		// Rosetta runs the amd64 binary on the Apple silicon
+		if a == current || a == "darwin/amd64" && current == "darwin/arm64" {
+			return true
+		}
+		// WSL runs the Windows binary such as tsh.exe
+		if strings.HasPrefix(a, "windows/") && wsl.Detected() {
+			return true
+		}
+	}
+	return false
+}
+
+// binaryOSArch detects the os/arch of the executable, a universal
+// binary returns all of its archs separated by comma
+func binaryOSArch(path string) (string, bool) {
+	if f, err := elf.Open(path); err == nil {
+		defer f.Close()
+		arch, ok := map[elf.Machine]string{
+			elf.EM_X86_64:  "amd64",
+			elf.EM_AARCH64: "arm64",
+			elf.EM_386:     "386",
+			elf.EM_ARM:     "arm",
+		}[f.Machine]
+		if !ok {
+			arch = strings.ToLower(strings.TrimPrefix(f.Machine.String(), "EM_"))
+		}
+		// ELF is the executable of the unix like other than macOS
+		goos := "linux"
+		if runtime.GOOS != "darwin" && runtime.GOOS != "windows" {
+			goos = runtime.GOOS
+		}
+		return goos + "/" + arch, true
+	}
+	if f, err := macho.Open(path); err == nil {
+		defer f.Close()
+		return "darwin/" + machoArch(f.Cpu), true
+	}
+	if f, err := macho.OpenFat(path); err == nil {
+		defer f.Close()
+		var archs []string
+		for _, a := range f.Arches {
+			archs = append(archs, "darwin/"+machoArch(a.Cpu))
+		}
+		return strings.Join(archs, ","), true
+	}
+	if f, err := pe.Open(path); err == nil {
+		defer f.Close()
+		arch := "386"
+		switch f.Machine {
+		case pe.IMAGE_FILE_MACHINE_AMD64:
+			arch = "amd64"
+		case pe.IMAGE_FILE_MACHINE_ARM64:
+			arch = "arm64"
+		}
+		return "windows/" + arch, true
+	}
+	return "", false
+}
+
+func machoArch(cpu macho.Cpu) string {
+	switch cpu {
+	case macho.CpuAmd64:
+		return "amd64"
+	case macho.CpuArm64:
+		return "arm64"
+	}
+	return strings.ToLower(strings.TrimPrefix(cpu.String(), "Cpu"))
+}
+
+// binaryError explains the tsh binary problem along with how to install it
+func binaryError(path, reason string) error {
+	return fmt.Errorf("%w, %s %s\n%s", ErrBinary, path, reason, InstallHint())
+}
+
+// InstallHint returns how to install tsh on this machine
+func InstallHint() string {
+	var hints []string
+	switch runtime.GOOS {
+	case "darwin":
+		if _, err := exec.LookPath("brew"); err == nil {
+			hints = append(hints, "brew install teleport")
+		}
+		hints = append(hints, "download the tsh package from https://goteleport.com/download")
+	case "linux":
+		hints = append(hints, "curl https://goteleport.com/static/install.sh | bash -s <version>")
+		if _, err := exec.LookPath("apt-get"); err == nil {
+			hints = append(hints, "apt-get install teleport, after adding the Teleport apt repository")
+		}
+		if _, err := exec.LookPath("dnf"); err == nil {
+			hints = append(hints, "dnf install teleport, after adding the Teleport yum repository")
+		}
+	default:
+		hints = append(hints, "download tsh from https://goteleport.com/download")
+	}
+	hints = append(hints, "tpot tsh install --version <version>")
+	return "install tsh by one of:\n  " + strings.Join(hints, "\n  ") +
+		"\nor set tsh_path of the proxy configuration to the tsh binary"
+}
+
+// InstallScript returns the shell script to install tsh on this machine,
+// the version is required unless it's installed by Homebrew
+func InstallScript(version string) (string, error) {
+	if runtime.GOOS == "darwin" && version == "" {
+		if _, err := exec.LookPath("brew"); err == nil {
+			return "brew install teleport", nil
+		}
+	}
+
+	switch runtime.GOOS {
+	case "darwin", "linux":
+	default:
+		return "", fmt.Errorf("installing tsh on %s is not supported, download it from https://goteleport.com/download", runtime.GOOS)
+	}
+	if version == "" {
+		return "", fmt.Errorf("the tsh version to install is required")
+	}
+	v, err := ParseVersion(version)
+	if err != nil {
+		return "", fmt.Errorf("invalid version %s, error: %v", version, err)
+	}
+	return fmt.Sprintf("curl -fsSL https://goteleport.com/static/install.sh | bash -s %d.%d.%d", v.Major, v.Minor, v.Patch), nil
+}
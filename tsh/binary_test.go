@@ -0,0 +1,63 @@
+package tsh
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_checkBinary(t *testing.T) {
+	dir := t.TempDir()
+	script := dir + "/tsh-wrapper"
+	assert.NoError(t, ioutil.WriteFile(script, []byte("#!/bin/sh\nexec tsh \"$@\"\n"), 0755))
+	notExecutable := dir + "/tsh-text"
+	assert.NoError(t, ioutil.WriteFile(notExecutable, []byte("tsh"), 0644))
+	self, err := os.Executable()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{name: "the binary of this machine", path: self},
+		{name: "wrapper script", path: script},
+		{name: "not found", path: dir + "/tsh-missing", wantErr: true},
+		{name: "not found in PATH", path: "tsh-not-installed-anywhere", wantErr: true},
+		{name: "not executable", path: notExecutable, wantErr: runtime.GOOS != "windows"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkBinary(tt.path)
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, ErrBinary), "got %v", err)
+			assert.Contains(t, err.Error(), "tpot tsh install")
+		})
+	}
+}
+
+func Test_canRun(t *testing.T) {
+	current := runtime.GOOS + "/" + runtime.GOARCH
+	assert.True(t, canRun(current))
+	assert.True(t, canRun("plan9/mips,"+current))
+	assert.False(t, canRun("plan9/mips"))
+}
+
+func TestInstallScript(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the install script is only checked on linux")
+	}
+	script, err := InstallScript("v15.4.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "curl -fsSL https://goteleport.com/static/install.sh | bash -s 15.4.0", script)
+
+	_, err = InstallScript("")
+	assert.Error(t, err)
+}
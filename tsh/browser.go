@@ -0,0 +1,190 @@
+package tsh
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/adzimzf/tpot/shell"
+	"github.com/adzimzf/tpot/wsl"
+)
+
+// BrowserNone is the browser to print the SSO link instead of opening it,
+// such as on the headless server the link is opened on another machine
+const BrowserNone = "none"
+
+// BrowserCommand placeholders are replaced by the SSO link & the env
+const (
+	URLPlaceholder = "{url}"
+	EnvPlaceholder = "{env}"
+)
+
+var (
+	// Browser overrides the browser of every proxy such as BrowserNone
+	Browser string
+
+	// CallbackURL overrides the SSO callback URL of every proxy
+	CallbackURL string
+
+	// DefaultBrowserCommand is the browser command of the proxy without one
+	DefaultBrowserCommand string
+)
+
+// browser returns how tsh login opens the SSO page
+func (t *TSH) browser() string {
+	if Browser != "" {
+		return Browser
+	}
+	return t.proxy.Browser
+}
+
+// browserCommand returns the command opening the SSO link, it's
+// only used when tsh login is left to open the browser. Inside WSL
+// the Windows browser is opened since the Linux tsh can't reach it
+func (t *TSH) browserCommand() string {
+	if t.browser() != "" {
+		return ""
+	}
+	if t.proxy.BrowserCommand != "" {
+		return t.proxy.BrowserCommand
+	}
+	if DefaultBrowserCommand != "" {
+		return DefaultBrowserCommand
+	}
+	if wsl.IsWindowsBinary(t.tshBinary()) {
+		// tsh.exe opens the Windows browser by itself
+		return ""
+	}
+	return wsl.BrowserCommand()
+}
+
+// browserFlags returns the tsh login flags of the SSO browser & callback,
+// tsh prints the link instead of opening it once tpot opens it by the command
+func (t *TSH) browserFlags() []string {
+	var args []string
+	if b := t.browser(); b != "" {
+		args = append(args, "--browser="+b)
+	} else if t.browserCommand() != "" {
+		args = append(args, "--browser="+BrowserNone)
+	}
+	callback := CallbackURL
+	if callback == "" {
+		callback = t.proxy.CallbackURL
+	}
+	if callback != "" {
+		args = append(args, "--callback="+callback)
+	}
+	if t.proxy.BindAddr != "" {
+		args = append(args, "--bind-addr="+t.proxy.BindAddr)
+	}
+	return args
+}
+
+// loginOutput returns the writer of the tsh login output, the SSO link is
+// repeated prominently on BrowserNone since it's opened on another machine,
+// or it's opened by the browser command
+func (t *TSH) loginOutput(w io.Writer) io.Writer {
+	if command := t.browserCommand(); command != "" {
+		return &linkWriter{w: w, onLink: func(link string) {
+			if err := OpenBrowser(command, link, t.proxy.Env); err != nil {
+				fmt.Fprintf(w, "failed to open the browser by %s, error: %v\n", command, err)
+				printLink(w, link, t.proxy.Env)
+			}
+		}}
+	}
+	if t.browser() == BrowserNone {
+		return &linkWriter{w: w, onLink: func(link string) {
+			printLink(w, link, t.proxy.Env)
+		}}
+	}
+	return w
+}
+
+// printLink shows the SSO link on its own line to be copied as is
+func printLink(w io.Writer, link, env string) {
+	fmt.Fprintf(w, "\nOpen the link in a browser of any machine to log in to %s, tpot continues once it's done\n\n    %s\n\n", env, link)
+}
+
+// browserArgs returns the browser command along with its placeholders
+// replaced, the link is appended when the command has no {url}
+func browserArgs(command, link, env string) ([]string, error) {
+	words, err := shell.Split(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("the browser command is empty")
+	}
+	hasURL := false
+	for i, w := range words {
+		hasURL = hasURL || strings.Contains(w, URLPlaceholder)
+		w = strings.Replace(w, URLPlaceholder, link, -1)
+		words[i] = strings.Replace(w, EnvPlaceholder, env, -1)
+	}
+	if !hasURL {
+		words = append(words, link)
+	}
+	return words, nil
+}
+
+// OpenBrowser starts the browser command without waiting for it,
+// the browser may keep running after the login is done
+func OpenBrowser(command, link, env string) error {
+	args, err := browserArgs(command, link, env)
+	if err != nil {
+		return err
+	}
+	if DryRun {
+		fmt.Fprintf(DryRunOutput, "[dry-run] %s\n", shell.Join(args...))
+		return nil
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// linkWriter writes through the output & calls onLink
+// once with the first link printed by tsh
+type linkWriter struct {
+	w      io.Writer
+	onLink func(link string)
+	line   bytes.Buffer
+	found  bool
+}
+
+func (l *linkWriter) Write(p []byte) (int, error) {
+	n, err := l.w.Write(p)
+	if err != nil || l.found {
+		return n, err
+	}
+	l.line.Write(p[:n])
+	for {
+		i := bytes.IndexByte(l.line.Bytes(), '\n')
+		if i < 0 {
+			return n, nil
+		}
+		if link := findLink(string(l.line.Next(i + 1))); link != "" {
+			l.found = true
+			l.onLink(link)
+			return n, nil
+		}
+	}
+}
+
+// findLink returns the first http or https link of the line
+func findLink(line string) string {
+	for _, field := range strings.Fields(line) {
+		if strings.HasPrefix(field, "http://") || strings.HasPrefix(field, "https://") {
+			return field
+		}
+	}
+	return ""
+}
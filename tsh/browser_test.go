@@ -0,0 +1,97 @@
+package tsh
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTSH_browserFlags(t *testing.T) {
+	defer func() { Browser, CallbackURL = "", "" }()
+
+	tsh := NewTSH(&config.Proxy{Env: "prod"})
+	assert.Empty(t, tsh.browserFlags())
+
+	tsh = NewTSH(&config.Proxy{Env: "prod", Browser: BrowserNone, CallbackURL: "localhost:40123", BindAddr: "localhost:40123"})
+	assert.Equal(t, []string{"--browser=none", "--callback=localhost:40123", "--bind-addr=localhost:40123"}, tsh.browserFlags())
+
+	// the flags override the proxy
+	Browser, CallbackURL = "chrome", "devbox:8080"
+	assert.Equal(t, []string{"--browser=chrome", "--callback=devbox:8080", "--bind-addr=localhost:40123"}, tsh.browserFlags())
+
+	// tsh only prints the link opened by the browser command
+	Browser, CallbackURL = "", ""
+	tsh = NewTSH(&config.Proxy{Env: "prod", BrowserCommand: "wslview"})
+	assert.Equal(t, []string{"--browser=none"}, tsh.browserFlags())
+}
+
+func TestTSH_browserCommand(t *testing.T) {
+	defer func() { Browser, DefaultBrowserCommand = "", "" }()
+
+	DefaultBrowserCommand = "wslview"
+	assert.Equal(t, "wslview", NewTSH(&config.Proxy{}).browserCommand())
+	assert.Equal(t, "firefox", NewTSH(&config.Proxy{BrowserCommand: "firefox"}).browserCommand())
+
+	// the browser given to tsh takes precedence
+	assert.Empty(t, NewTSH(&config.Proxy{BrowserCommand: "firefox", Browser: BrowserNone}).browserCommand())
+	Browser = BrowserNone
+	assert.Empty(t, NewTSH(&config.Proxy{BrowserCommand: "firefox"}).browserCommand())
+}
+
+func Test_browserArgs(t *testing.T) {
+	const link = "http://127.0.0.1:40123/6e4f1b2a"
+	tests := []struct {
+		command string
+		want    []string
+		err     bool
+	}{
+		{command: "wslview", want: []string{"wslview", link}},
+		{
+			command: `google-chrome --profile-directory="Profile 2" {url}`,
+			want:    []string{"google-chrome", "--profile-directory=Profile 2", link},
+		},
+		{
+			command: "open -na 'Google Chrome' --args --profile-directory={env} --new-window {url}",
+			want:    []string{"open", "-na", "Google Chrome", "--args", "--profile-directory=prod", "--new-window", link},
+		},
+		{command: "firefox 'unterminated", err: true},
+		{command: " ", err: true},
+	}
+	for _, tt := range tests {
+		got, err := browserArgs(tt.command, link, "prod")
+		assert.Equal(t, tt.err, err != nil, tt.command)
+		assert.Equal(t, tt.want, got, tt.command)
+	}
+}
+
+func TestTSH_loginOutput(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tsh := NewTSH(&config.Proxy{Env: "prod"})
+	assert.Equal(t, buf, tsh.loginOutput(buf))
+
+	tsh = NewTSH(&config.Proxy{Env: "prod", Browser: BrowserNone})
+	w := tsh.loginOutput(buf)
+	// the link may be split across the writes
+	fmt.Fprint(w, "If browser window does not open automatically, open it by clicking on the link:\n http://127.0.0.1:401")
+	fmt.Fprint(w, "23/6e4f1b2a\n")
+	fmt.Fprint(w, "Another link http://example.com\n")
+
+	out := buf.String()
+	assert.Contains(t, out, "open it by clicking on the link:\n http://127.0.0.1:40123/6e4f1b2a\n")
+	assert.Contains(t, out, "log in to prod, tpot continues once it's done\n\n    http://127.0.0.1:40123/6e4f1b2a\n\n")
+	assert.Equal(t, 1, bytes.Count(buf.Bytes(), []byte("log in to prod")))
+
+	// the browser command opens the link
+	defer func(dryRun bool, output io.Writer) { DryRun, DryRunOutput = dryRun, output }(DryRun, DryRunOutput)
+	opened := &bytes.Buffer{}
+	DryRun, DryRunOutput = true, opened
+	buf.Reset()
+	tsh = NewTSH(&config.Proxy{Env: "prod", BrowserCommand: "wslview"})
+	fmt.Fprint(tsh.loginOutput(buf), "open it by clicking on the link:\n http://127.0.0.1:40123/6e4f1b2a\n")
+	assert.Equal(t, "[dry-run] wslview http://127.0.0.1:40123/6e4f1b2a\n", opened.String())
+	assert.NotContains(t, buf.String(), "log in to prod")
+}
@@ -0,0 +1,159 @@
+package tsh
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/adzimzf/tpot/config"
+)
+
+const (
+	// versionCacheTTL is how long the tsh version is cached, the binary
+	// is part of the key hence an upgrade is never served the old version
+	versionCacheTTL = 24 * time.Hour
+
+	// statusCacheTTL is how long the proxy status is cached,
+	// it's invalidated earlier once the user logs in
+	statusCacheTTL = 5 * time.Minute
+)
+
+// checkCache is the cached result of tsh version & tsh status, it's kept
+// in the process & on the disk to avoid spawning tsh on every invocation
+type checkCache struct {
+	Versions map[string]versionEntry `json:"versions"`
+	Statuses map[string]statusEntry  `json:"statuses"`
+}
+
+type versionEntry struct {
+	Version  Version   `json:"version"`
+	CachedAt time.Time `json:"cached_at"`
+}
+
+type statusEntry struct {
+	Status   config.ProxyStatus `json:"status"`
+	CachedAt time.Time          `json:"cached_at"`
+}
+
+var (
+	cacheMu sync.Mutex
+
+	// cache is loaded from the disk once it's used
+	cache *checkCache
+)
+
+func cachePath() string {
+	return config.CacheDir + "tsh_cache.json"
+}
+
+// loadCache returns the cache, the caller must hold cacheMu
+func loadCache() *checkCache {
+	if cache != nil {
+		return cache
+	}
+	cache = &checkCache{}
+	if b, err := ioutil.ReadFile(cachePath()); err == nil {
+		// the broken cache is simply rebuilt
+		_ = json.Unmarshal(b, cache)
+	}
+	if cache.Versions == nil {
+		cache.Versions = make(map[string]versionEntry)
+	}
+	if cache.Statuses == nil {
+		cache.Statuses = make(map[string]statusEntry)
+	}
+	return cache
+}
+
+// saveCache writes the cache into the disk, the caller must hold cacheMu.
+// failing to write the cache only makes the next invocation slower
+func saveCache() {
+	b, err := json.Marshal(cache)
+	if err != nil {
+		return
+	}
+	_ = ioutil.WriteFile(cachePath(), b, 0600)
+}
+
+// versionKey identifies the tsh binary by its location, size & modification time
+func (t *TSH) versionKey() (string, bool) {
+	// the replayed fixtures are never cached
+	if isReplaying() {
+		return "", false
+	}
+	path, err := exec.LookPath(t.tshBinary())
+	if err != nil {
+		return "", false
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", false
+	}
+	return fmt.Sprintf("%s|%d|%d", path, info.Size(), info.ModTime().UnixNano()), true
+}
+
+func (t *TSH) cachedVersion() (*Version, bool) {
+	key, ok := t.versionKey()
+	if !ok {
+		return nil, false
+	}
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	e, ok := loadCache().Versions[key]
+	if !ok || t.currentTime().Sub(e.CachedAt) > versionCacheTTL {
+		return nil, false
+	}
+	v := e.Version
+	return &v, true
+}
+
+func (t *TSH) cacheVersion(v *Version) {
+	key, ok := t.versionKey()
+	if !ok {
+		return
+	}
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	loadCache().Versions[key] = versionEntry{Version: *v, CachedAt: t.currentTime()}
+	saveCache()
+}
+
+func (t *TSH) cachedStatus() (*config.ProxyStatus, bool) {
+	if isReplaying() {
+		return nil, false
+	}
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	e, ok := loadCache().Statuses[t.proxy.Env]
+	if !ok || t.currentTime().Sub(e.CachedAt) > statusCacheTTL {
+		return nil, false
+	}
+	s := e.Status
+	return &s, true
+}
+
+func (t *TSH) cacheStatus(s *config.ProxyStatus) {
+	if isReplaying() {
+		return
+	}
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	loadCache().Statuses[t.proxy.Env] = statusEntry{Status: *s, CachedAt: t.currentTime()}
+	saveCache()
+}
+
+// InvalidateStatus drops the cached status of the proxy,
+// it must be called once the login of the proxy is changed
+func (t *TSH) InvalidateStatus() {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if _, ok := loadCache().Statuses[t.proxy.Env]; !ok {
+		return
+	}
+	delete(cache.Statuses, t.proxy.Env)
+	saveCache()
+}
@@ -0,0 +1,75 @@
+package tsh
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTSH_statusCache(t *testing.T) {
+	defer func(dir string) { config.CacheDir = dir }(config.CacheDir)
+	config.CacheDir = t.TempDir() + "/"
+	cache = nil
+
+	now := fixedNow()
+	tsh := NewTSH(&config.Proxy{Env: "staging"})
+	tsh.now = func() time.Time { return now }
+
+	_, ok := tsh.cachedStatus()
+	assert.False(t, ok)
+
+	status := &config.ProxyStatus{LoginAs: "adzim", UserLogins: []string{"root"}}
+	tsh.cacheStatus(status)
+	got, ok := tsh.cachedStatus()
+	assert.True(t, ok)
+	assert.Equal(t, status, got)
+
+	// another process reads the cache from the disk
+	cache = nil
+	got, ok = tsh.cachedStatus()
+	assert.True(t, ok)
+	assert.Equal(t, status, got)
+
+	// the other env is cached separately
+	_, ok = NewTSH(&config.Proxy{Env: "prod"}).cachedStatus()
+	assert.False(t, ok)
+
+	now = now.Add(statusCacheTTL + time.Second)
+	_, ok = tsh.cachedStatus()
+	assert.False(t, ok, "the status must be expired")
+
+	now = fixedNow()
+	tsh.InvalidateStatus()
+	_, ok = tsh.cachedStatus()
+	assert.False(t, ok, "the status must be invalidated")
+}
+
+func TestTSH_versionCache(t *testing.T) {
+	defer func(dir string) { config.CacheDir = dir }(config.CacheDir)
+	config.CacheDir = t.TempDir() + "/"
+	cache = nil
+
+	bin := t.TempDir() + "/tsh"
+	assert.NoError(t, ioutil.WriteFile(bin, []byte("#!/bin/sh\n"), 0755))
+	tsh := NewTSH(&config.Proxy{TSHPath: bin})
+	tsh.now = fixedNow
+
+	v := &Version{Major: 15, Minor: 4}
+	tsh.cacheVersion(v)
+	got, ok := tsh.cachedVersion()
+	assert.True(t, ok)
+	assert.Equal(t, v, got)
+
+	// replacing the binary invalidates its version
+	assert.NoError(t, ioutil.WriteFile(bin, []byte("#!/bin/sh\n# upgraded\n"), 0755))
+	_, ok = tsh.cachedVersion()
+	assert.False(t, ok)
+
+	assert.NoError(t, os.Remove(bin))
+	_, ok = tsh.cachedVersion()
+	assert.False(t, ok)
+}
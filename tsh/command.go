@@ -0,0 +1,227 @@
+package tsh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/metrics"
+	"github.com/adzimzf/tpot/shell"
+	"github.com/adzimzf/tpot/trace"
+)
+
+var (
+	// DryRun prints every tsh command into DryRunOutput instead of running it
+	DryRun bool
+
+	// DryRunOutput is where the tsh commands are printed on DryRun
+	DryRunOutput io.Writer = os.Stderr
+
+	// ExtraArgs is appended to every tsh invocation
+	// along with the extra flags of the proxy
+	ExtraArgs []string
+
+	// MinVersion is the minimum tsh version required to login, nil means any
+	MinVersion *Version
+)
+
+var (
+	commandsTotal = metrics.NewCounter("tpot_tsh_commands_total", "The tsh invocations by the sub command.", "command")
+	errorsTotal   = metrics.NewCounter("tpot_tsh_errors_total", "The failed tsh invocations by the sub command.", "command")
+)
+
+// command creates the tsh command, every tsh process must be created
+// here to share the same binary & environment of the proxy
+func (t *TSH) command(args ...string) *exec.Cmd {
+	return t.commandContext(context.Background(), args...)
+}
+
+// commandContext creates the tsh command which is killed once the ctx is done
+func (t *TSH) commandContext(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, t.tshBinary(), t.withExtraArgs(args)...)
+	cmd.Env = t.environ()
+	return cmd
+}
+
+// withExtraArgs inserts the extra flags right after the tsh sub command,
+// so they're never taken as the positional arguments like the remote command
+func (t *TSH) withExtraArgs(args []string) []string {
+	var extra []string
+	if t.proxy.MFAMode != "" {
+		extra = append(extra, "--mfa-mode="+t.proxy.MFAMode)
+	}
+	extra = append(append(extra, t.proxy.ExtraTSHFlags...), ExtraArgs...)
+	if len(extra) == 0 || len(args) == 0 {
+		return args
+	}
+	res := append([]string{args[0]}, extra...)
+	return append(res, args[1:]...)
+}
+
+// environ returns the environment of the tsh process,
+// nil means the environment of the current process
+func (t *TSH) environ() []string {
+	home := t.teleportHome()
+	sock := t.proxy.SSHAuthSock
+	if home == "" && sock == "" {
+		return nil
+	}
+
+	env := os.Environ()
+	if sock != "" {
+		// the agent of the proxy replaces the one of the shell
+		env = withoutEnv(env, "SSH_AUTH_SOCK")
+		if sock != config.SSHAuthSockNone {
+			env = append(env, "SSH_AUTH_SOCK="+expandHome(sock))
+		}
+	}
+	if home != "" {
+		env = append(env, "TELEPORT_HOME="+home)
+	}
+	return env
+}
+
+// withoutEnv removes the variable from the environment
+func withoutEnv(env []string, name string) []string {
+	var res []string
+	for _, e := range env {
+		if !strings.HasPrefix(e, name+"=") {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// lookupEnv returns the value of the variable of the environment
+func lookupEnv(env []string, name string) (string, bool) {
+	for _, e := range env {
+		if strings.HasPrefix(e, name+"=") {
+			return strings.TrimPrefix(e, name+"="), true
+		}
+	}
+	return "", false
+}
+
+// teleportHome returns the isolated tsh directory of the proxy
+func (t *TSH) teleportHome() string {
+	return expandHome(t.proxy.TeleportHome)
+}
+
+// Home returns the directory tsh keeps the keys & the certificates of the
+// proxy in, the isolated one of the proxy, TELEPORT_HOME or ~/.tsh
+func (t *TSH) Home() string {
+	if home := t.teleportHome(); home != "" {
+		return home
+	}
+	if home := os.Getenv("TELEPORT_HOME"); home != "" {
+		return home
+	}
+	return filepath.Join(os.Getenv("HOME"), ".tsh")
+}
+
+// expandHome expands the leading ~/ of the path into HOME
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		return os.Getenv("HOME") + strings.TrimPrefix(path, "~")
+	}
+	return path
+}
+
+// run runs the tsh command unless it's a dry run, it's answered by the
+// fixture on replay & it's recorded into the fixture on record
+func run(cmd *exec.Cmd) error {
+	if DryRun {
+		printCommand(DryRunOutput, cmd)
+		return nil
+	}
+	if isReplaying() {
+		step := trace.Start("replay %s", tracedCommandLine(cmd))
+		err := replay(cmd)
+		step.End(err)
+		return err
+	}
+	rec := startRecording(cmd)
+	step := trace.Start("run %s", tracedCommandLine(cmd))
+	err := cmd.Run()
+	step.End(err)
+	countCommand(cmd, err)
+	rec.save(err)
+
+	// explain why the tsh binary can't be run instead of the raw exec error
+	var execErr *exec.Error
+	if errors.As(err, &execErr) || errors.Is(err, syscall.ENOEXEC) {
+		if binErr := checkBinary(cmd.Path); binErr != nil {
+			return binErr
+		}
+	}
+	return err
+}
+
+// countCommand counts the tsh invocation by the sub command
+func countCommand(cmd *exec.Cmd, err error) {
+	command := ""
+	if len(cmd.Args) > 1 {
+		command = cmd.Args[1]
+	}
+	commandsTotal.Inc(command)
+	if err != nil {
+		errorsTotal.Inc(command)
+	}
+}
+
+// printCommand prints the command along with its teleport environment
+// as a copyable shell command line
+func printCommand(w io.Writer, cmd *exec.Cmd) {
+	fmt.Fprintf(w, "[dry-run] %s\n", commandLine(cmd))
+}
+
+// commandLine returns the command along with its teleport environment
+// as a shell command line
+func commandLine(cmd *exec.Cmd) string {
+	return formatCommand(cmd.Env, cmd.Args)
+}
+
+// tracedCommandLine returns the command line printed by the trace,
+// the values of the secret flags are redacted
+func tracedCommandLine(cmd *exec.Cmd) string {
+	return formatCommand(cmd.Env, trace.Sanitize(cmd.Args))
+}
+
+// formatCommand returns the args along with the teleport environment
+// as a shell command line, nil env is the one of the current process.
+// The SSH_AUTH_SOCK of the proxy replacing the one of the shell is printed
+// as well, or unset by env -u once the proxy has none
+func formatCommand(env, args []string) string {
+	if env == nil {
+		env = os.Environ()
+	}
+
+	var words []string
+	current, isSet := os.LookupEnv("SSH_AUTH_SOCK")
+	if sock, ok := lookupEnv(env, "SSH_AUTH_SOCK"); ok && (!isSet || sock != current) {
+		words = append(words, "SSH_AUTH_SOCK="+shell.QuoteIfNeeded(sock))
+	} else if !ok && isSet {
+		words = append(words, "env", "-u", "SSH_AUTH_SOCK")
+	}
+	for _, e := range env {
+		if strings.HasPrefix(e, "TELEPORT_") {
+			// the value is quoted, such as the home having a space, to be pasted as is
+			kv := strings.SplitN(e, "=", 2)
+			if len(kv) == 2 {
+				e = kv[0] + "=" + shell.QuoteIfNeeded(kv[1])
+			}
+			words = append(words, e)
+		}
+	}
+	for _, arg := range args {
+		words = append(words, shell.QuoteIfNeeded(arg))
+	}
+	return strings.Join(words, " ")
+}
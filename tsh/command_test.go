@@ -0,0 +1,169 @@
+package tsh
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTSH_command(t *testing.T) {
+	tests := []struct {
+		name         string
+		proxy        *config.Proxy
+		extraArgs    []string
+		wantArgs     []string
+		wantPath     string
+		wantTeleport string
+	}{
+		{
+			name:     "default",
+			proxy:    &config.Proxy{},
+			wantPath: "tsh",
+		},
+		{
+			name:         "isolated teleport home",
+			proxy:        &config.Proxy{TSHPath: "/usr/bin/tsh-2", TeleportHome: "/tmp/tsh-prod"},
+			wantPath:     "/usr/bin/tsh-2",
+			wantTeleport: "TELEPORT_HOME=/tmp/tsh-prod",
+		},
+		{
+			name:         "teleport home inside HOME",
+			proxy:        &config.Proxy{TeleportHome: "~/.tsh-prod"},
+			wantPath:     "tsh",
+			wantTeleport: "TELEPORT_HOME=" + os.Getenv("HOME") + "/.tsh-prod",
+		},
+		{
+			name:      "extra flags",
+			proxy:     &config.Proxy{ExtraTSHFlags: []string{"--add-keys-to-agent=no"}},
+			extraArgs: []string{"--mfa-mode=cross-platform"},
+			wantArgs:  []string{"tsh", "status", "--add-keys-to-agent=no", "--mfa-mode=cross-platform", "--proxy=teleport.mycomp.com"},
+			wantPath:  "tsh",
+		},
+		{
+			name:     "mfa mode",
+			proxy:    &config.Proxy{MFAMode: "cross-platform", ExtraTSHFlags: []string{"-d"}},
+			wantArgs: []string{"tsh", "status", "--mfa-mode=cross-platform", "-d", "--proxy=teleport.mycomp.com"},
+			wantPath: "tsh",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ExtraArgs = tt.extraArgs
+			defer func() { ExtraArgs = nil }()
+
+			cmd := NewTSH(tt.proxy).command("status", "--proxy=teleport.mycomp.com")
+			wantArgs := tt.wantArgs
+			if wantArgs == nil {
+				wantArgs = []string{tt.wantPath, "status", "--proxy=teleport.mycomp.com"}
+			}
+			assert.Equal(t, wantArgs, cmd.Args)
+			if tt.wantTeleport == "" {
+				assert.Nil(t, cmd.Env)
+				return
+			}
+			assert.Equal(t, tt.wantTeleport, cmd.Env[len(cmd.Env)-1])
+		})
+	}
+}
+
+func TestTSH_environ(t *testing.T) {
+	defer os.Setenv("SSH_AUTH_SOCK", os.Getenv("SSH_AUTH_SOCK"))
+	os.Setenv("SSH_AUTH_SOCK", "/tmp/shell-agent.sock")
+
+	sockOf := func(env []string) []string {
+		var res []string
+		for _, e := range env {
+			if strings.HasPrefix(e, "SSH_AUTH_SOCK=") {
+				res = append(res, e)
+			}
+		}
+		return res
+	}
+
+	assert.Nil(t, NewTSH(&config.Proxy{}).environ())
+
+	env := NewTSH(&config.Proxy{SSHAuthSock: "~/.yubikey-agent.sock"}).environ()
+	assert.Equal(t, []string{"SSH_AUTH_SOCK=" + os.Getenv("HOME") + "/.yubikey-agent.sock"}, sockOf(env))
+
+	env = NewTSH(&config.Proxy{SSHAuthSock: config.SSHAuthSockNone, TeleportHome: "/tmp/tsh-prod"}).environ()
+	assert.Empty(t, sockOf(env))
+	assert.Equal(t, "TELEPORT_HOME=/tmp/tsh-prod", env[len(env)-1])
+}
+
+func Test_printCommand(t *testing.T) {
+	cmd := NewTSH(&config.Proxy{TeleportHome: "/tmp/tsh-prod"}).command("ssh", "--proxy=teleport.mycomp.com", "-l", "root", "10.0.0.1", "echo 'hi there'")
+	var b bytes.Buffer
+	printCommand(&b, cmd)
+	assert.Equal(t, `[dry-run] TELEPORT_HOME=/tmp/tsh-prod tsh ssh --proxy=teleport.mycomp.com -l root 10.0.0.1 'echo '"'"'hi there'"'"''`+"\n", b.String())
+}
+
+func Test_formatCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		sock string // SSH_AUTH_SOCK of the shell
+		env  []string
+		want string
+	}{
+		{name: "plain", env: []string{"TELEPORT_HOME=/tmp/tsh-prod"}, want: "TELEPORT_HOME=/tmp/tsh-prod tsh status"},
+		{name: "space", env: []string{"TELEPORT_HOME=/home/adzim/my tsh"}, want: "TELEPORT_HOME='/home/adzim/my tsh' tsh status"},
+		{name: "shell characters", env: []string{"TELEPORT_USER=a;rm -rf ~", "TELEPORT_PROXY=$HOST"},
+			want: "TELEPORT_USER='a;rm -rf ~' TELEPORT_PROXY='$HOST' tsh status"},
+		{name: "empty", env: []string{"TELEPORT_HOME="}, want: "TELEPORT_HOME='' tsh status"},
+		{name: "other env", env: []string{"HOME=/home/adzim"}, want: "tsh status"},
+		{name: "agent of the shell", sock: "/tmp/agent.sock", env: []string{"SSH_AUTH_SOCK=/tmp/agent.sock"}, want: "tsh status"},
+		{name: "agent of the proxy", sock: "/tmp/agent.sock", env: []string{"SSH_AUTH_SOCK=/home/adzim/my agent.sock", "TELEPORT_HOME=/tmp/tsh-prod"},
+			want: "SSH_AUTH_SOCK='/home/adzim/my agent.sock' TELEPORT_HOME=/tmp/tsh-prod tsh status"},
+		{name: "no agent", sock: "/tmp/agent.sock", env: []string{"TELEPORT_HOME=/tmp/tsh-prod"},
+			want: "env -u SSH_AUTH_SOCK TELEPORT_HOME=/tmp/tsh-prod tsh status"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withSSHAuthSock(t, tt.sock)
+			assert.Equal(t, tt.want, formatCommand(tt.env, []string{"tsh", "status"}))
+		})
+	}
+}
+
+// the printed command runs tsh by the same agent as tpot does
+func Test_printCommand_sshAuthSock(t *testing.T) {
+	withSSHAuthSock(t, "/tmp/agent.sock")
+
+	tests := []struct {
+		sock string
+		want string
+	}{
+		{sock: "", want: "[dry-run] tsh status\n"},
+		{sock: "/tmp/agent.sock", want: "[dry-run] tsh status\n"},
+		{sock: "/run/prod agent.sock", want: "[dry-run] SSH_AUTH_SOCK='/run/prod agent.sock' tsh status\n"},
+		{sock: config.SSHAuthSockNone, want: "[dry-run] env -u SSH_AUTH_SOCK tsh status\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sock, func(t *testing.T) {
+			var b bytes.Buffer
+			printCommand(&b, NewTSH(&config.Proxy{SSHAuthSock: tt.sock}).command("status"))
+			assert.Equal(t, tt.want, b.String())
+		})
+	}
+}
+
+// withSSHAuthSock sets the SSH_AUTH_SOCK of the shell until the test ends,
+// empty unsets it
+func withSSHAuthSock(t *testing.T, sock string) {
+	old, ok := os.LookupEnv("SSH_AUTH_SOCK")
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv("SSH_AUTH_SOCK", old)
+		} else {
+			os.Unsetenv("SSH_AUTH_SOCK")
+		}
+	})
+	if sock == "" {
+		os.Unsetenv("SSH_AUTH_SOCK")
+	} else {
+		os.Setenv("SSH_AUTH_SOCK", sock)
+	}
+}
@@ -0,0 +1,110 @@
+package tsh
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// dialErrors is the tsh errors when the node can't be reached by the address,
+// the next address of the node is dialed on them
+var dialErrors = []string{"not found", "no nodes match", "ambiguous host", "failed connecting", "failed to dial"}
+
+// dialAddress returns the first address to dial the host by tsh
+func (t *TSH) dialAddress(host string) (string, error) {
+	addresses, err := t.dialAddresses(host)
+	if err != nil {
+		return "", err
+	}
+	return addresses[0], nil
+}
+
+func (t *TSH) dialAddresses(host string) ([]string, error) {
+	item, ok := t.proxy.Node.LookUp(host)
+	if !ok {
+		return nil, fmt.Errorf("host %s is not found in the node cache", host)
+	}
+	addresses := item.DialAddresses(t.proxy.DialBy)
+	// the hostname listed more than once is ambiguous to tsh,
+	// the instance is only dialed by its IP or its ID
+	if t.proxy.Node.IsDuplicate(item.Hostname) {
+		res := addresses[:0]
+		for _, addr := range addresses {
+			if addr != item.Hostname {
+				res = append(res, addr)
+			}
+		}
+		addresses = res
+	}
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("host %s has no address to dial", host)
+	}
+	return addresses, nil
+}
+
+// dial runs the session by the addresses of the host in order, the next
+// address is only dialed when tsh fails to reach the node by the previous one.
+// The sessions are run again once after logging in again on the expired certificate
+func (t *TSH) dial(host string, stderr io.Writer, session func(address string, stderr io.Writer) error) error {
+	addresses, err := t.dialAddresses(host)
+	if err != nil {
+		return err
+	}
+
+	// nothing is really dialed on dry run to know it fails
+	if DryRun {
+		return session(addresses[0], stderr)
+	}
+
+	return t.withRelogin(stderr, func(stderr io.Writer) error {
+		for i, address := range addresses {
+			if i == len(addresses)-1 {
+				break
+			}
+			tail := &tailBuffer{}
+			err := session(address, io.MultiWriter(stderr, tail))
+			if err == nil || !isDialError(tail.String()) {
+				return err
+			}
+			fmt.Fprintf(stderr, "failed to reach %s by %s, dialing by %s\n", host, address, addresses[i+1])
+		}
+		return session(addresses[len(addresses)-1], stderr)
+	})
+}
+
+// isDialError returns true if tsh printed the error of reaching the node,
+// the output of the remote command isn't prefixed by ERROR
+func isDialError(stderr string) bool {
+	for _, line := range strings.Split(stderr, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "ERROR:") {
+			continue
+		}
+		for _, e := range dialErrors {
+			if strings.Contains(line, e) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// tailBufferSize is enough to keep the tsh error at the end of the output
+const tailBufferSize = 4096
+
+// tailBuffer keeps only the last tailBufferSize bytes written
+type tailBuffer struct {
+	b []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.b = append(t.b, p...)
+	if len(t.b) > tailBufferSize {
+		t.b = t.b[len(t.b)-tailBufferSize:]
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	return string(t.b)
+}
@@ -0,0 +1,88 @@
+package tsh
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTSH_dial(t *testing.T) {
+	proxy := &config.Proxy{Node: config.Node{Items: []config.Item{
+		{Hostname: "web-01", Address: "10.0.0.1:3022", ID: "5a8c0f2e"},
+	}}}
+	tsh := NewTSH(proxy)
+
+	tests := []struct {
+		name   string
+		stderr map[string]string
+		want   []string
+		err    bool
+	}{
+		{
+			name: "dialed by the IP",
+			want: []string{"10.0.0.1"},
+		},
+		{
+			name:   "fallback to the hostname",
+			stderr: map[string]string{"10.0.0.1": "ERROR: failed connecting to node 10.0.0.1\n"},
+			want:   []string{"10.0.0.1", "web-01"},
+		},
+		{
+			name:   "the remote command fails",
+			stderr: map[string]string{"10.0.0.1": "bash: foo: command not found\n"},
+			want:   []string{"10.0.0.1"},
+			err:    true,
+		},
+		{
+			name: "all addresses fail",
+			stderr: map[string]string{
+				"10.0.0.1": "ERROR: failed connecting to node 10.0.0.1\n",
+				"web-01":   "ERROR: not found\n",
+				"5a8c0f2e": "ERROR: not found\n",
+			},
+			want: []string{"10.0.0.1", "web-01", "5a8c0f2e"},
+			err:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dialed []string
+			err := tsh.dial("web-01", &bytes.Buffer{}, func(address string, stderr io.Writer) error {
+				dialed = append(dialed, address)
+				if msg, ok := tt.stderr[address]; ok {
+					fmt.Fprint(stderr, msg)
+					return errors.New("exit status 1")
+				}
+				return nil
+			})
+			assert.Equal(t, tt.err, err != nil)
+			assert.Equal(t, tt.want, dialed)
+		})
+	}
+
+	err := tsh.dial("db-01", &bytes.Buffer{}, func(string, io.Writer) error { return nil })
+	assert.Error(t, err)
+}
+
+func TestTSH_dialAddresses_duplicate(t *testing.T) {
+	proxy := &config.Proxy{DialBy: config.DialByHostname, Node: config.Node{Items: []config.Item{
+		{Hostname: "web-01", Address: "10.0.0.1:3022", ID: "5a8c0f2e"},
+		{Hostname: "web-01", Address: "10.0.0.9:3022", ID: "9d1b7c44"},
+		{Hostname: "web-02", Address: "⟵ Tunnel"},
+		{Hostname: "web-02", Address: "⟵ Tunnel"},
+	}}}
+	tsh := NewTSH(proxy)
+
+	// the ambiguous hostname isn't dialed
+	got, err := tsh.dialAddresses("web-01 (10.0.0.9)")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"9d1b7c44"}, got)
+
+	_, err = tsh.dialAddresses("web-02 (#1)")
+	assert.Error(t, err)
+}
@@ -0,0 +1,89 @@
+package tsh
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Error is the failed tsh command along with the error printed by tsh,
+// it's already shown to the user but kept to explain it such as by the hints
+type Error struct {
+	Err error
+
+	// Message is the last ERROR line printed by tsh
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// withMessage wraps the error along with the last ERROR line of the stderr
+func withMessage(err error, stderr string) error {
+	if err == nil {
+		return nil
+	}
+	lines := strings.Split(stderr, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if line := strings.TrimSpace(lines[i]); strings.HasPrefix(line, "ERROR:") {
+			return &Error{Err: err, Message: line}
+		}
+	}
+	return err
+}
+
+// IsConnectionError returns true if the session failed by tsh or ssh
+// instead of the remote command, tsh printed the error or ssh exited
+// with 255 on the multiplexed session
+func IsConnectionError(err error) bool {
+	var tshErr *Error
+	if errors.As(err, &tshErr) {
+		return true
+	}
+	var fixtureErr *FixtureExitError
+	if errors.As(err, &fixtureErr) {
+		return fixtureErr.Code == 255
+	}
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr) && exitErr.ExitCode() == 255
+}
+
+// PhaseError is the error of a tsh step such as logging in, it tells
+// where it broke since the raw tsh errors look alike
+type PhaseError struct {
+	// Phase is the step such as "logging in to prod"
+	Phase string
+	Err   error
+}
+
+func (e *PhaseError) Error() string {
+	return e.Phase + ": " + e.Err.Error()
+}
+
+func (e *PhaseError) Unwrap() error {
+	return e.Err
+}
+
+// inPhase labels the error by the phase, the nil error stays nil
+func inPhase(err error, format string, a ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	return &PhaseError{Phase: fmt.Sprintf(format, a...), Err: err}
+}
+
+// inSession labels the error of the session on the host, the failed
+// remote command isn't labeled since tsh has connected to the host
+func inSession(err error, userLogin, host string) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && !IsConnectionError(err) {
+		return err
+	}
+	return inPhase(err, "connecting to %s as %s", host, userLogin)
+}
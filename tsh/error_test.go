@@ -0,0 +1,62 @@
+package tsh
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_withMessage(t *testing.T) {
+	assert.NoError(t, withMessage(nil, "ERROR: access denied\n"))
+
+	err := errors.New("exit status 1")
+	assert.Equal(t, err, withMessage(err, "bash: foo: command not found\n"))
+
+	wrapped := withMessage(err, "ERROR: failed connecting\nsome output\nERROR: access denied to root connecting to web-01\n\n")
+	var tshErr *Error
+	assert.True(t, errors.As(wrapped, &tshErr))
+	assert.Equal(t, "ERROR: access denied to root connecting to web-01", tshErr.Message)
+	assert.Equal(t, "exit status 1", wrapped.Error())
+	assert.True(t, errors.Is(wrapped, err))
+}
+
+func TestIsConnectionError(t *testing.T) {
+	assert.False(t, IsConnectionError(nil))
+	assert.False(t, IsConnectionError(errors.New("exit status 1")))
+	assert.True(t, IsConnectionError(withMessage(errors.New("exit status 1"), "ERROR: connection refused\n")))
+
+	for code, want := range map[string]bool{"1": false, "255": true} {
+		err := exec.Command("sh", "-c", "exit "+code).Run()
+		assert.Equal(t, want, IsConnectionError(err), code)
+	}
+}
+
+func Test_inSession(t *testing.T) {
+	assert.NoError(t, inSession(nil, "root", "web-01"))
+
+	// the failed remote command isn't labeled
+	remote := exec.Command("sh", "-c", "exit 3").Run()
+	assert.Equal(t, remote, inSession(remote, "root", "web-01"))
+
+	refused := withMessage(errors.New("exit status 1"), "ERROR: connection refused\n")
+	err := inSession(refused, "root", "web-01")
+	assert.Equal(t, "connecting to web-01 as root: exit status 1", err.Error())
+	var phaseErr *PhaseError
+	assert.True(t, errors.As(err, &phaseErr))
+	assert.Equal(t, "connecting to web-01 as root", phaseErr.Phase)
+	assert.True(t, IsConnectionError(err))
+
+	err = inSession(errors.New("invalid -o"), "root", "web-01")
+	assert.Equal(t, "connecting to web-01 as root: invalid -o", err.Error())
+}
+
+func TestTSH_Login_phase(t *testing.T) {
+	tsh := NewTSH(&config.Proxy{Env: "prod", TSHPath: "/nonexistent/tsh"})
+	err := tsh.Login()
+	var phaseErr *PhaseError
+	assert.True(t, errors.As(err, &phaseErr))
+	assert.Equal(t, "logging in to prod", phaseErr.Phase)
+}
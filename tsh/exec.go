@@ -0,0 +1,111 @@
+package tsh
+
+import (
+	"context"
+	"io"
+)
+
+// Exec runs the command on the host without allocating a terminal,
+// the command output is written into stdout & stderr
+func (t *TSH) Exec(userLogin, host, command string, stdout, stderr io.Writer) error {
+	return t.ExecWithInput(userLogin, host, command, nil, stdout, stderr)
+}
+
+// ExecWithInput runs the command on the host like Exec,
+// the stdin is streamed into the remote command
+func (t *TSH) ExecWithInput(userLogin, host, command string, stdin io.Reader, stdout, stderr io.Writer) error {
+	return t.ExecContext(context.Background(), userLogin, host, command, stdin, stdout, stderr)
+}
+
+// ExecContext runs the command on the host like ExecWithInput,
+// the tsh process is killed once the ctx is done
+func (t *TSH) ExecContext(ctx context.Context, userLogin, host, command string, stdin io.Reader, stdout, stderr io.Writer) (err error) {
+	defer func() { err = inSession(err, userLogin, host) }()
+
+	if t.proxy.Multiplex {
+		cmd, err := t.multiplexCommand(ctx, userLogin, host)
+		if err != nil {
+			return err
+		}
+		cmd.Args = append(cmd.Args, command)
+		cmd.Stdin = stdin
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		return run(cmd)
+	}
+
+	// the stdin might be partly consumed by the failed attempt,
+	// so there's no fallback address to dial once it's streamed
+	if stdin != nil {
+		args, err := t.sshArgs(userLogin, host)
+		if err != nil {
+			return err
+		}
+		cmd := t.commandContext(ctx, append(args, command)...)
+		cmd.Stdin = stdin
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		return run(cmd)
+	}
+
+	args, err := t.tshSSHArgs()
+	if err != nil {
+		return err
+	}
+	return t.dial(host, stderr, func(address string, stderr io.Writer) error {
+		cmd := t.commandContext(ctx, append(args, "-l", userLogin, address, command)...)
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		return run(cmd)
+	})
+}
+
+// Shell runs a login shell on the host without allocating a terminal,
+// every line read from stdin is executed by the remote shell
+func (t *TSH) Shell(userLogin, host string, stdin io.Reader, stdout, stderr io.Writer) (err error) {
+	defer func() { err = inSession(err, userLogin, host) }()
+
+	args, err := t.sshArgs(userLogin, host)
+	if err != nil {
+		return err
+	}
+
+	cmd := t.command(args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return run(cmd)
+}
+
+// sshArgs returns the `tsh ssh` arguments to login into the host
+func (t *TSH) sshArgs(userLogin, host string) ([]string, error) {
+	args, err := t.tshSSHArgs()
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := t.dialAddress(host)
+	if err != nil {
+		return nil, err
+	}
+	return append(args, "-l", userLogin, address), nil
+}
+
+// SSHCommand returns the `tsh ssh` command line to login into the host,
+// such as to be run by the other tools
+func (t *TSH) SSHCommand(userLogin, host string) (string, error) {
+	args, err := t.sshArgs(userLogin, host)
+	if err != nil {
+		return "", err
+	}
+	return commandLine(t.command(args...)), nil
+}
+
+// tshSSHArgs returns the `tsh ssh` arguments without the host
+func (t *TSH) tshSSHArgs() ([]string, error) {
+	args, err := t.getProxyFlags()
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{"ssh"}, append(args, t.authFlags()...)...), nil
+}
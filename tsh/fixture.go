@@ -0,0 +1,285 @@
+package tsh
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adzimzf/tpot/trace"
+)
+
+// Fixture is a recorded tsh invocation, the fixtures are replayed instead of
+// running tsh such as in the tests & the demos without a real cluster
+type Fixture struct {
+	// Command is the name of the binary such as tsh or ssh
+	Command string `json:"command"`
+
+	// Args is the arguments without the binary, the secret flags are redacted
+	Args       []string  `json:"args"`
+	Stdout     string    `json:"stdout,omitempty"`
+	Stderr     string    `json:"stderr,omitempty"`
+	ExitCode   int       `json:"exit_code,omitempty"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// FixtureExitError is the non-zero exit code of the replayed fixture
+type FixtureExitError struct {
+	Code int
+}
+
+func (e *FixtureExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// ExitCode returns the exit code like exec.ExitError
+func (e *FixtureExitError) ExitCode() int {
+	return e.Code
+}
+
+var (
+	fixtureMu sync.Mutex
+
+	// recordDir is where the invocations are recorded, empty means they aren't
+	recordDir string
+	recorded  int
+
+	// replaying is the fixtures answering the invocations, nil means tsh is run
+	replaying []*replayedFixture
+)
+
+type replayedFixture struct {
+	Fixture
+	used bool
+}
+
+// RecordFixtures records every tsh invocation into the dir from now on, the
+// fixtures of the earlier runs in the dir are kept. The output shown on the
+// terminal such as the ssh session isn't recorded
+func RecordFixtures(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	existing, err := LoadFixtures(dir)
+	if err != nil {
+		return err
+	}
+	fixtureMu.Lock()
+	defer fixtureMu.Unlock()
+	recordDir, recorded = dir, len(existing)
+	return nil
+}
+
+// ReplayFixtures answers the tsh invocations by the fixtures of the dir instead of
+// running tsh, the invocation without a fixture fails. The clock of tsh is the time
+// the fixtures were recorded so the recorded login is still valid
+func ReplayFixtures(dir string) error {
+	fixtures, err := LoadFixtures(dir)
+	if err != nil {
+		return err
+	}
+	if len(fixtures) == 0 {
+		return fmt.Errorf("there's no fixture in %s", dir)
+	}
+	fixtureMu.Lock()
+	defer fixtureMu.Unlock()
+	replaying = make([]*replayedFixture, len(fixtures))
+	for i, f := range fixtures {
+		replaying[i] = &replayedFixture{Fixture: f}
+	}
+	return nil
+}
+
+// StopFixtures stops recording & replaying the fixtures
+func StopFixtures() {
+	fixtureMu.Lock()
+	defer fixtureMu.Unlock()
+	recordDir, recorded, replaying = "", 0, nil
+}
+
+// LoadFixtures reads the fixtures of the dir in the order they were recorded
+func LoadFixtures(dir string) ([]Fixture, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	var res []Fixture
+	for _, path := range paths {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var f Fixture
+		if err := json.Unmarshal(b, &f); err != nil {
+			return nil, fmt.Errorf("invalid fixture %s, error: %v", path, err)
+		}
+		res = append(res, f)
+	}
+	return res, nil
+}
+
+// isReplaying returns true if the invocations are answered by the fixtures
+func isReplaying() bool {
+	fixtureMu.Lock()
+	defer fixtureMu.Unlock()
+	return replaying != nil
+}
+
+// fixtureClock returns the time the replayed fixtures were recorded,
+// the current time otherwise
+func fixtureClock() func() time.Time {
+	fixtureMu.Lock()
+	defer fixtureMu.Unlock()
+	if len(replaying) == 0 || replaying[0].RecordedAt.IsZero() {
+		return time.Now
+	}
+	at := replaying[0].RecordedAt
+	return func() time.Time { return at }
+}
+
+// fixtureArgs returns the binary & the redacted arguments of the command, the
+// binary is tsh or ssh so the fixtures don't depend on tsh_path
+func fixtureArgs(cmd *exec.Cmd) (string, []string) {
+	var args []string
+	if len(cmd.Args) > 1 {
+		args = trace.Sanitize(cmd.Args[1:])
+	}
+	command := tshBinary
+	if strings.TrimSuffix(filepath.Base(cmd.Path), ".exe") == sshBinary {
+		command = sshBinary
+	}
+	return command, args
+}
+
+// replay answers the command by the first unused fixture of the same arguments,
+// the last one is reused once they're all used such as the repeated tsh status
+func replay(cmd *exec.Cmd) error {
+	command, args := fixtureArgs(cmd)
+	fixtureMu.Lock()
+	var found *replayedFixture
+	for _, f := range replaying {
+		if f.Command != command || !equalArgs(f.Args, args) {
+			continue
+		}
+		found = f
+		if !f.used {
+			break
+		}
+	}
+	if found != nil {
+		found.used = true
+	}
+	fixtureMu.Unlock()
+	if found == nil {
+		return fmt.Errorf("there's no fixture of %s %s", command, strings.Join(args, " "))
+	}
+
+	if cmd.Stdout != nil {
+		io.WriteString(cmd.Stdout, found.Stdout)
+	}
+	if cmd.Stderr != nil {
+		io.WriteString(cmd.Stderr, found.Stderr)
+	}
+	if found.ExitCode != 0 {
+		return &FixtureExitError{Code: found.ExitCode}
+	}
+	return nil
+}
+
+func equalArgs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// fixtureRecorder captures the output of the command to be recorded once it ends
+type fixtureRecorder struct {
+	fixture        Fixture
+	stdout, stderr bytes.Buffer
+}
+
+// startRecording captures the output of the command if the invocations are
+// recorded, nil otherwise. The output of the terminal isn't captured
+func startRecording(cmd *exec.Cmd) *fixtureRecorder {
+	fixtureMu.Lock()
+	dir := recordDir
+	fixtureMu.Unlock()
+	if dir == "" {
+		return nil
+	}
+	command, args := fixtureArgs(cmd)
+	r := &fixtureRecorder{fixture: Fixture{Command: command, Args: args, RecordedAt: time.Now()}}
+	cmd.Stdout = r.capture(cmd.Stdout, &r.stdout)
+	cmd.Stderr = r.capture(cmd.Stderr, &r.stderr)
+	return r
+}
+
+func (r *fixtureRecorder) capture(w io.Writer, buf *bytes.Buffer) io.Writer {
+	if w == nil {
+		return buf
+	}
+	if _, ok := w.(*os.File); ok {
+		return w
+	}
+	return io.MultiWriter(w, buf)
+}
+
+// save writes the fixture along with the result of the command,
+// failing to record it must not fail the command hence only warn
+func (r *fixtureRecorder) save(err error) {
+	if r == nil {
+		return
+	}
+	r.fixture.Stdout, r.fixture.Stderr = r.stdout.String(), r.stderr.String()
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		r.fixture.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		r.fixture.ExitCode = -1
+	}
+	// the output is kept readable such as the > of tsh status
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r.fixture); err != nil {
+		return
+	}
+
+	fixtureMu.Lock()
+	defer fixtureMu.Unlock()
+	if recordDir == "" {
+		return
+	}
+	recorded++
+	name := fmt.Sprintf("%03d-%s.json", recorded, fixtureName(r.fixture))
+	if err := ioutil.WriteFile(filepath.Join(recordDir, name), b.Bytes(), 0600); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING! failed to record the fixture, error: %v\n", err)
+	}
+}
+
+// fixtureName returns the command along with its sub command such as tsh-ls
+func fixtureName(f Fixture) string {
+	name := f.Command
+	if len(f.Args) > 0 && !strings.HasPrefix(f.Args[0], "-") {
+		name += "-" + f.Args[0]
+	}
+	return name
+}
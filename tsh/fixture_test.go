@@ -0,0 +1,121 @@
+package tsh
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of the fixtures")
+
+// fixtureResult is what tpot parses from the fixtures, it's compared with the golden file
+type fixtureResult struct {
+	Version string              `json:"version"`
+	Status  *config.ProxyStatus `json:"status"`
+	Items   []config.Item       `json:"items"`
+}
+
+// fixtureProxy returns the proxy of the recorded --proxy flag
+func fixtureProxy(t *testing.T, fixtures []Fixture) *config.Proxy {
+	for _, f := range fixtures {
+		for _, arg := range f.Args {
+			if strings.HasPrefix(arg, "--proxy=") {
+				return &config.Proxy{Env: "fixture", AuthConnector: "fixture", Address: "https://" + strings.TrimPrefix(arg, "--proxy=")}
+			}
+		}
+	}
+	t.Fatal("the fixtures have no --proxy flag")
+	return nil
+}
+
+// TestFixtures replays every directory of testdata/fixtures recorded by --record-fixtures,
+// the output tpot fails to parse is contributed as a new directory along with its golden
+// file written by go test ./tsh -run TestFixtures -update
+func TestFixtures(t *testing.T) {
+	defer func(dir string) { config.CacheDir = dir }(config.CacheDir)
+	dirs, err := filepath.Glob("testdata/fixtures/*")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, dirs)
+
+	for _, dir := range dirs {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			continue
+		}
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			config.CacheDir = t.TempDir() + "/"
+			cache = nil
+			assert.NoError(t, ReplayFixtures(dir))
+			defer StopFixtures()
+			fixtures, err := LoadFixtures(dir)
+			assert.NoError(t, err)
+			tsh := NewTSH(fixtureProxy(t, fixtures))
+
+			var res fixtureResult
+			v, err := tsh.Version()
+			assert.NoError(t, err)
+			res.Version = v.Strings()
+			res.Status, err = tsh.Status()
+			assert.NoError(t, err)
+			node, err := tsh.ListNodes()
+			assert.NoError(t, err)
+			res.Items = node.Items
+
+			got, err := json.MarshalIndent(res, "", "  ")
+			assert.NoError(t, err)
+			golden := dir + ".golden.json"
+			if *update {
+				assert.NoError(t, ioutil.WriteFile(golden, append(got, '\n'), 0644))
+			}
+			want, err := ioutil.ReadFile(golden)
+			assert.NoError(t, err)
+			assert.JSONEq(t, string(want), string(got))
+		})
+	}
+}
+
+func TestReplayFixtures(t *testing.T) {
+	dir := t.TempDir()
+	f := `{"command": "tsh", "args": ["ssh", "root@web-01"], "stderr": "ERROR: connection refused\n", "exit_code": 255}`
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "001-tsh-ssh.json"), []byte(f), 0600))
+	assert.NoError(t, ReplayFixtures(dir))
+	defer StopFixtures()
+
+	tsh := NewTSH(&config.Proxy{Env: "prod", TSHPath: "/nonexistent/tsh"})
+	assert.NoError(t, tsh.CheckBinary())
+	err := run(tsh.command("ssh", "root@web-01"))
+	assert.True(t, IsConnectionError(err))
+	err = run(tsh.command("ssh", "root@db-01"))
+	assert.EqualError(t, err, "there's no fixture of tsh ssh root@db-01")
+}
+
+func TestRecordFixtures(t *testing.T) {
+	bin := t.TempDir() + "/tsh"
+	assert.NoError(t, ioutil.WriteFile(bin, []byte("#!/bin/sh\necho \"Teleport v15.4.2 git:v15.4.2 go1.21\"\necho oops >&2\nexit 3\n"), 0755))
+	dir := filepath.Join(t.TempDir(), "fixtures")
+	assert.NoError(t, RecordFixtures(dir))
+	defer StopFixtures()
+
+	tsh := NewTSH(&config.Proxy{TSHPath: bin})
+	cmd := tsh.command("login", "--proxy=teleport.example.com", "--token", "secret")
+	cmd.Stdout = ioutil.Discard
+	assert.Error(t, run(cmd))
+
+	got, err := LoadFixtures(dir)
+	assert.NoError(t, err)
+	if assert.Len(t, got, 1) {
+		assert.Equal(t, "tsh", got[0].Command)
+		assert.Equal(t, []string{"login", "--proxy=teleport.example.com", "--token", "***"}, got[0].Args)
+		assert.Equal(t, "Teleport v15.4.2 git:v15.4.2 go1.21\n", got[0].Stdout)
+		assert.Equal(t, "oops\n", got[0].Stderr)
+		assert.Equal(t, 3, got[0].ExitCode)
+	}
+	_, err = os.Stat(filepath.Join(dir, "001-tsh-login.json"))
+	assert.NoError(t, err)
+}
@@ -1,13 +1,16 @@
 package tsh
 
 import (
+	"context"
 	"fmt"
 	"io"
-	"os/exec"
+	"os"
 )
 
 // Forward run the tsh forwarding
-func (t *TSH) Forward(userLogin, host, forwardAddress string, in io.Reader) error {
+func (t *TSH) Forward(userLogin, host, forwardAddress string, in io.Reader) (err error) {
+	defer func() { err = inSession(err, userLogin, host) }()
+
 	args, err := t.getProxyFlags()
 	if err != nil {
 		return err
@@ -16,7 +19,45 @@ func (t *TSH) Forward(userLogin, host, forwardAddress string, in io.Reader) erro
 	args = append(args, t.authFlags()...)
 	args = append(args, fmt.Sprintf("%s@%s", userLogin, host))
 	args = append([]string{"ssh", "-L", forwardAddress}, args...)
-	cmd := exec.Command(t.tshBinary(), args...)
+	cmd := t.command(args...)
 	cmd.Stdin = in
-	return cmd.Run()
+	return run(cmd)
+}
+
+// DynamicForward runs the tsh dynamic port forwarding, it starts a SOCKS5
+// proxy listening on the listenAddress which routes the traffic through the host
+func (t *TSH) DynamicForward(userLogin, host, listenAddress string) (err error) {
+	defer func() { err = inSession(err, userLogin, host) }()
+
+	args, err := t.getProxyFlags()
+	if err != nil {
+		return err
+	}
+
+	args = append(args, t.authFlags()...)
+
+	return t.dial(host, os.Stderr, func(address string, stderr io.Writer) error {
+		cmd := t.command(append([]string{"ssh", "-N", "-D", listenAddress}, append(args, "-l", userLogin, address)...)...)
+		cmd.Stderr = stderr
+		return run(cmd)
+	})
+}
+
+// Tunnel runs the tsh local port forwarding without a shell until the ctx is
+// done, the forwardAddress is <local port>:<remote host>:<remote port>
+func (t *TSH) Tunnel(ctx context.Context, userLogin, host, forwardAddress string) (err error) {
+	defer func() { err = inSession(err, userLogin, host) }()
+
+	args, err := t.getProxyFlags()
+	if err != nil {
+		return err
+	}
+
+	args = append(args, t.authFlags()...)
+
+	return t.dial(host, os.Stderr, func(address string, stderr io.Writer) error {
+		cmd := t.commandContext(ctx, append([]string{"ssh", "-N", "-L", forwardAddress}, append(args, "-l", userLogin, address)...)...)
+		cmd.Stderr = stderr
+		return run(cmd)
+	})
 }
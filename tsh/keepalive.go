@@ -0,0 +1,33 @@
+package tsh
+
+import (
+	"fmt"
+	"io"
+)
+
+// CanLoginInBackground returns true if tsh login needs no terminal, it's the SSO of
+// the auth connector opened by the browser. The password & the OTP need the terminal
+// & the SSO link of the browser none must be seen to be opened
+func (t *TSH) CanLoginInBackground() bool {
+	return t.proxy.AuthConnector != "" && !t.proxy.TwoFA && t.browser() != BrowserNone
+}
+
+// Renew runs tsh login in the background regardless of the current login,
+// such as before the login expires. The output of tsh login goes to w
+func (t *TSH) Renew(w io.Writer) (err error) {
+	defer func() { err = inPhase(err, "renewing the login of %s", t.proxy.Env) }()
+	if !t.CanLoginInBackground() {
+		return fmt.Errorf("the login of %s needs the terminal", t.proxy.Env)
+	}
+	cmd, err := t.loginCommand()
+	if err != nil {
+		return err
+	}
+	cmd.Stdout = t.loginOutput(w)
+	cmd.Stderr = t.loginOutput(w)
+	if err := run(cmd); err != nil {
+		return err
+	}
+	t.InvalidateStatus()
+	return nil
+}
@@ -0,0 +1,36 @@
+package tsh
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTSH_CanLoginInBackground(t *testing.T) {
+	defer func(b string) { Browser = b }(Browser)
+	Browser = ""
+
+	assert.True(t, NewTSH(&config.Proxy{AuthConnector: "okta"}).CanLoginInBackground())
+	assert.False(t, NewTSH(&config.Proxy{UserName: "me"}).CanLoginInBackground())
+	assert.False(t, NewTSH(&config.Proxy{AuthConnector: "okta", TwoFA: true}).CanLoginInBackground())
+	assert.False(t, NewTSH(&config.Proxy{AuthConnector: "okta", Browser: BrowserNone}).CanLoginInBackground())
+}
+
+func TestTSH_Renew(t *testing.T) {
+	defer func(dryRun bool, w io.Writer) { DryRun, DryRunOutput = dryRun, w }(DryRun, DryRunOutput)
+	defer func(dir string) { config.CacheDir = dir }(config.CacheDir)
+	config.CacheDir = t.TempDir() + "/"
+	var out bytes.Buffer
+	DryRun, DryRunOutput = true, &out
+
+	err := NewTSH(&config.Proxy{Env: "prod", Address: "https://teleport.example.com:443", UserName: "me"}).Renew(ioutil.Discard)
+	assert.EqualError(t, err, "renewing the login of prod: the login of prod needs the terminal")
+
+	tsh := NewTSH(&config.Proxy{Env: "prod", Address: "https://teleport.example.com:443", AuthConnector: "okta", TSHPath: "/opt/tsh"})
+	assert.NoError(t, tsh.Renew(ioutil.Discard))
+	assert.Contains(t, out.String(), "/opt/tsh login --proxy=teleport.example.com:443 --auth=okta")
+}
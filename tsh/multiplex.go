@@ -0,0 +1,123 @@
+package tsh
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/adzimzf/tpot/config"
+)
+
+// sshBinary is the OpenSSH client used by the multiplexed sessions
+const sshBinary = "ssh"
+
+// defaultMultiplexPersist is how long the idle multiplexed connection is kept
+const defaultMultiplexPersist = "10m"
+
+// multiplexCommand creates the OpenSSH command of the multiplexed session,
+// the connection to the node is established once by `tsh proxy ssh` and
+// reused by the next sessions to the same node until it's idle for a while
+func (t *TSH) multiplexCommand(ctx context.Context, userLogin, host string, args ...string) (*exec.Cmd, error) {
+	cfg, err := t.multiplexConfig()
+	if err != nil {
+		return nil, err
+	}
+	cluster, err := t.clusterName()
+	if err != nil {
+		return nil, err
+	}
+	item, ok := t.proxy.Node.LookUp(host)
+	if !ok {
+		return nil, fmt.Errorf("host %s is not found in the node cache", host)
+	}
+
+	// tsh proxy ssh resolves the node by its name under the cluster,
+	// the instance of the hostname listed more than once by its ID
+	name := item.Hostname
+	if t.proxy.Node.IsDuplicate(item.Hostname) {
+		if item.ID == "" {
+			return nil, fmt.Errorf("host %s has no ID to be told apart from the other instances of %s", host, item.Hostname)
+		}
+		name = item.ID
+	}
+	sshArgs := append([]string{"-F", cfg}, args...)
+	sshArgs = append(sshArgs, "-l", userLogin, name+"."+cluster)
+	cmd := exec.CommandContext(ctx, sshBinary, sshArgs...)
+	cmd.Env = t.environ()
+	return cmd, nil
+}
+
+// clusterName returns the teleport cluster name from the tsh status
+func (t *TSH) clusterName() (string, error) {
+	// nothing is really run on dry run to get the status
+	if DryRun {
+		return "<cluster>", nil
+	}
+	status, err := t.Status()
+	if err != nil {
+		return "", err
+	}
+	if status.Cluster == "" {
+		return "", fmt.Errorf("couldn't find the cluster name of %s, multiplex needs tsh status to show it", t.proxy.Env)
+	}
+	return status.Cluster, nil
+}
+
+// multiplexMu prevents the parallel sessions from generating the config at once
+var multiplexMu sync.Mutex
+
+// multiplexConfig returns the OpenSSH configuration of the proxy, it's
+// generated by `tsh config` once along with the ControlMaster options
+func (t *TSH) multiplexConfig() (string, error) {
+	multiplexMu.Lock()
+	defer multiplexMu.Unlock()
+
+	path := config.CacheDir + "ssh_config_" + t.proxy.Env
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	args, err := t.getProxyFlags()
+	if err != nil {
+		return "", err
+	}
+	cmd := t.command(append([]string{"config"}, args...)...)
+	var stdOut, stdErr bytes.Buffer
+	cmd.Stdout = &stdOut
+	cmd.Stderr = &stdErr
+	if err := run(cmd); err != nil {
+		return "", fmt.Errorf("failed to generate the ssh config, error: %v %s", err, stdErr.String())
+	}
+	if DryRun {
+		return path, nil
+	}
+
+	persist := t.proxy.MultiplexPersist
+	if persist == "" {
+		persist = defaultMultiplexPersist
+	}
+	content := multiplexOptions(config.CacheDir, persist) + stdOut.String()
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// multiplexOptions returns the ControlMaster options for all hosts,
+// %C is the hash of the connection to keep the socket path short
+func multiplexOptions(dir, persist string) string {
+	return fmt.Sprintf(`# generated by tpot, remove it to regenerate
+Host *
+    ControlMaster auto
+    ControlPath "%scm-%%C"
+    ControlPersist %s
+
+`, dir, persist)
+}
@@ -0,0 +1,43 @@
+package tsh
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_multiplexOptions(t *testing.T) {
+	want := `# generated by tpot, remove it to regenerate
+Host *
+    ControlMaster auto
+    ControlPath "/home/adzim/.cache/tpot/cm-%C"
+    ControlPersist 10m
+
+`
+	assert.Equal(t, want, multiplexOptions("/home/adzim/.cache/tpot/", "10m"))
+}
+
+func TestTSH_multiplexCommand(t *testing.T) {
+	defer func(dir string) { config.CacheDir = dir }(config.CacheDir)
+	config.CacheDir = t.TempDir() + "/"
+	DryRun = true
+	DryRunOutput = &bytes.Buffer{}
+	defer func() { DryRun, DryRunOutput = false, os.Stderr }()
+
+	proxy := &config.Proxy{
+		Env:     "staging",
+		Address: "https://teleport.mycomp.com",
+		Node:    config.Node{Items: []config.Item{{Hostname: "web-01", Address: "10.0.0.1:3022"}}},
+	}
+	cmd, err := NewTSH(proxy).multiplexCommand(context.Background(), "root", "web-01", "-A")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ssh", "-F", config.CacheDir + "ssh_config_staging", "-A", "-l", "root", "web-01.<cluster>"}, cmd.Args)
+	assert.Contains(t, DryRunOutput.(*bytes.Buffer).String(), "tsh config --proxy=teleport.mycomp.com")
+
+	_, err = NewTSH(proxy).multiplexCommand(context.Background(), "root", "web-02")
+	assert.Error(t, err)
+}
@@ -0,0 +1,72 @@
+package tsh
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// errNotLoggedIn indicates the prefetch couldn't login without the user
+var errNotLoggedIn = errors.New("not logged in")
+
+// Prefetch validates the login in the background while the user is still
+// picking the host, Login waits for it instead of checking the login again.
+// The SSO login is done in the background as well since it only needs the browser
+func (t *TSH) Prefetch() {
+	// nothing is really run on dry run
+	if DryRun {
+		return
+	}
+	done := make(chan error, 1)
+	t.prefetch = done
+	go func() {
+		done <- t.prefetchLogin()
+	}()
+}
+
+func (t *TSH) prefetchLogin() error {
+	if err := t.CheckBinary(); err != nil {
+		return err
+	}
+	if err := t.checkMinVersion(); err != nil {
+		return err
+	}
+	if t.isLogin() {
+		return nil
+	}
+
+	// the password & the OTP need the terminal which is used by the picker
+	if !t.CanLoginInBackground() {
+		return errNotLoggedIn
+	}
+	cmd, err := t.loginCommand()
+	if err != nil {
+		return err
+	}
+	cmd.Stdout = t.loginOutput(&t.prefetchOutput)
+	cmd.Stderr = t.loginOutput(&t.prefetchOutput)
+	if err := run(cmd); err != nil {
+		return fmt.Errorf("%w, %v", errNotLoggedIn, err)
+	}
+	t.InvalidateStatus()
+	return nil
+}
+
+// waitPrefetch waits for the background login, it returns true if the user
+// is already logged in, otherwise Login continues to login interactively
+func (t *TSH) waitPrefetch() (bool, error) {
+	if t.prefetch == nil {
+		return false, nil
+	}
+	err := <-t.prefetch
+	t.prefetch = nil
+	if t.prefetchOutput.Len() > 0 {
+		os.Stdout.Write(t.prefetchOutput.Bytes())
+		t.prefetchOutput = bytes.Buffer{}
+	}
+	if errors.Is(err, errNotLoggedIn) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,37 @@
+package tsh
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTSH_waitPrefetch(t *testing.T) {
+	tests := []struct {
+		name      string
+		prefetch  bool
+		err       error
+		wantLogin bool
+		wantErr   bool
+	}{
+		{name: "not prefetched"},
+		{name: "already logged in", prefetch: true, wantLogin: true},
+		{name: "needs the interactive login", prefetch: true, err: errNotLoggedIn},
+		{name: "background login failed", prefetch: true, err: fmt.Errorf("%w, exit status 1", errNotLoggedIn)},
+		{name: "unusable binary", prefetch: true, err: ErrBinary, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tsh := &TSH{}
+			if tt.prefetch {
+				tsh.prefetch = make(chan error, 1)
+				tsh.prefetch <- tt.err
+			}
+			got, err := tsh.waitPrefetch()
+			assert.Equal(t, tt.wantLogin, got)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Nil(t, tsh.prefetch, "the prefetch must be waited once")
+		})
+	}
+}
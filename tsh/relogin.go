@@ -0,0 +1,87 @@
+package tsh
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// certErrors is the tsh errors of the expired or missing certificate,
+// the user is logged in again & the command is retried once on them
+var certErrors = []string{
+	"not logged in",
+	"cert has expired",
+	"certificate has expired",
+	"profile expired",
+	"please login again",
+	"session has expired",
+}
+
+// isCertError returns true if tsh printed the error of the expired or
+// missing certificate, the output of the remote command isn't prefixed by ERROR
+func isCertError(stderr string) bool {
+	for _, line := range strings.Split(stderr, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "ERROR:") {
+			continue
+		}
+		line = strings.ToLower(line)
+		for _, e := range certErrors {
+			if strings.Contains(line, e) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// withRelogin runs the tsh command, it logs in again & retries the command
+// once when tsh fails by the expired or missing certificate instead of
+// leaving the raw tsh error to the user. The failed command is Error
+func (t *TSH) withRelogin(stderr io.Writer, command func(stderr io.Writer) error) error {
+	// nothing is really run on dry run to know it fails
+	if DryRun {
+		return command(stderr)
+	}
+	tail := &tailBuffer{}
+	err := command(io.MultiWriter(stderr, tail))
+	if err != nil && isCertError(tail.String()) {
+		fmt.Fprintf(os.Stderr, "the certificate of %s is expired or missing, logging in again\n", t.proxy.Env)
+		if err := t.relogin(); err != nil {
+			return fmt.Errorf("failed to log in again, error: %w", err)
+		}
+		tail = &tailBuffer{}
+		err = command(io.MultiWriter(stderr, tail))
+	}
+	return withMessage(err, tail.String())
+}
+
+// relogin runs `tsh login` regardless of the tsh status since the
+// certificate may expire after it's checked. The parallel sessions
+// failed by the same expired certificate only log in once
+func (t *TSH) relogin() error {
+	t.reloginMu.Lock()
+	defer t.reloginMu.Unlock()
+	if t.reloggedIn {
+		return nil
+	}
+	if err := t.login(); err != nil {
+		return err
+	}
+	t.reloggedIn = true
+	t.InvalidateStatus()
+	return nil
+}
+
+// runLogin runs the interactive `tsh login`
+func (t *TSH) runLogin() error {
+	cmd, err := t.loginCommand()
+	if err != nil {
+		return err
+	}
+	cmd.Stdout = t.loginOutput(os.Stdout)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = t.loginOutput(os.Stderr)
+	return run(cmd)
+}
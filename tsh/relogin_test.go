@@ -0,0 +1,123 @@
+package tsh
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_isCertError(t *testing.T) {
+	for stderr, want := range map[string]bool{
+		"ERROR: ssh: cert has expired\n":                                   true,
+		"ERROR: x509: certificate has expired or is not yet valid\n":       true,
+		"ERROR: Not logged in.\n":                                          true,
+		"ERROR: Active profile expired.\n":                                 true,
+		"some output\nERROR: access denied to root connecting to web-01\n": false,
+		"ERROR: failed connecting to node web-01\n":                        false,
+		// the remote command output
+		"bash: cert has expired\n": false,
+	} {
+		assert.Equal(t, want, isCertError(stderr), stderr)
+	}
+}
+
+func TestTSH_withRelogin(t *testing.T) {
+	tests := []struct {
+		name      string
+		stderr    []string
+		wantRuns  int
+		wantLogin int
+		err       bool
+	}{
+		{
+			name:     "succeeded",
+			stderr:   []string{""},
+			wantRuns: 1,
+		},
+		{
+			name:     "the remote command fails",
+			stderr:   []string{"bash: foo: command not found\n"},
+			wantRuns: 1,
+			err:      true,
+		},
+		{
+			name:      "retried after logging in again",
+			stderr:    []string{"ERROR: ssh: cert has expired\n", ""},
+			wantRuns:  2,
+			wantLogin: 1,
+		},
+		{
+			name:      "only retried once",
+			stderr:    []string{"ERROR: ssh: cert has expired\n", "ERROR: ssh: cert has expired\n"},
+			wantRuns:  2,
+			wantLogin: 1,
+			err:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tsh := NewTSH(&config.Proxy{Env: "prod"})
+			logins := 0
+			tsh.login = func() error {
+				logins++
+				return nil
+			}
+
+			runs := 0
+			err := tsh.withRelogin(&bytes.Buffer{}, func(stderr io.Writer) error {
+				msg := tt.stderr[runs]
+				runs++
+				if msg == "" {
+					return nil
+				}
+				fmt.Fprint(stderr, msg)
+				return errors.New("exit status 1")
+			})
+			assert.Equal(t, tt.err, err != nil)
+			assert.Equal(t, tt.wantRuns, runs)
+			assert.Equal(t, tt.wantLogin, logins)
+		})
+	}
+
+	t.Run("the login fails", func(t *testing.T) {
+		tsh := NewTSH(&config.Proxy{Env: "prod"})
+		tsh.login = func() error { return errors.New("cancelled") }
+		runs := 0
+		err := tsh.withRelogin(&bytes.Buffer{}, func(stderr io.Writer) error {
+			runs++
+			fmt.Fprint(stderr, "ERROR: Not logged in.\n")
+			return errors.New("exit status 1")
+		})
+		assert.Error(t, err)
+		assert.Equal(t, 1, runs)
+	})
+}
+
+func TestTSH_relogin_parallel(t *testing.T) {
+	tsh := NewTSH(&config.Proxy{Env: "prod"})
+	var mu sync.Mutex
+	logins := 0
+	tsh.login = func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		logins++
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, tsh.relogin())
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, logins)
+}
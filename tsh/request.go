@@ -0,0 +1,213 @@
+package tsh
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// the states of the access request
+const (
+	RequestPending  = "PENDING"
+	RequestApproved = "APPROVED"
+	RequestDenied   = "DENIED"
+)
+
+// AccessRequest is the Teleport access request to get the elevated roles
+type AccessRequest struct {
+	ID     string
+	User   string
+	Roles  []string
+	Reason string
+	State  string
+}
+
+// CreateRequest runs the `tsh request create` without waiting
+// for the review & returns the request ID, it's empty on dry run
+func (t *TSH) CreateRequest(roles []string, reason string) (_ string, err error) {
+	defer func() { err = inPhase(err, "requesting the roles %s on %s", strings.Join(roles, ","), t.proxy.Env) }()
+
+	args, err := t.getProxyFlags()
+	if err != nil {
+		return "", err
+	}
+	args = append(args, "--roles="+strings.Join(roles, ","), "--nowait")
+	if reason != "" {
+		args = append(args, "--reason="+reason)
+	}
+
+	out, err := t.requestCommand(append([]string{"request", "create"}, args...)...)
+	if err != nil || DryRun {
+		return "", err
+	}
+	req := parseRequest(out)
+	if req.ID == "" {
+		return "", fmt.Errorf("request ID is not found in the tsh output: %s", out)
+	}
+	return req.ID, nil
+}
+
+// ShowRequest runs the `tsh request show` of the request ID
+func (t *TSH) ShowRequest(id string) (_ *AccessRequest, err error) {
+	defer func() { err = inPhase(err, "showing the access request %s", id) }()
+
+	args, err := t.getProxyFlags()
+	if err != nil {
+		return nil, err
+	}
+	out, err := t.requestCommand(append([]string{"request", "show"}, append(args, id)...)...)
+	if err != nil {
+		return nil, err
+	}
+	req := parseRequest(out)
+	if req.ID == "" {
+		req.ID = id
+	}
+	return req, nil
+}
+
+// ListRequests writes the `tsh request ls` output into w
+func (t *TSH) ListRequests(w io.Writer) (err error) {
+	defer func() { err = inPhase(err, "listing the access requests of %s", t.proxy.Env) }()
+
+	args, err := t.getProxyFlags()
+	if err != nil {
+		return err
+	}
+	cmd := t.command(append([]string{"request", "ls"}, args...)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = w
+	cmd.Stderr = os.Stderr
+	return run(cmd)
+}
+
+// Requests runs the `tsh request ls` & parses the listed requests
+func (t *TSH) Requests() (_ []AccessRequest, err error) {
+	defer func() { err = inPhase(err, "listing the access requests of %s", t.proxy.Env) }()
+
+	args, err := t.getProxyFlags()
+	if err != nil {
+		return nil, err
+	}
+	out, err := t.requestCommand(append([]string{"request", "ls"}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	return parseRequests(out), nil
+}
+
+// ApprovedRequests returns the approved requests which aren't assumed
+// by the current login, such as the login is renewed once the elevated
+// certificate is expired
+func (t *TSH) ApprovedRequests() ([]AccessRequest, error) {
+	status, err := t.Status()
+	if err != nil {
+		return nil, err
+	}
+	requests, err := t.Requests()
+	if err != nil {
+		return nil, err
+	}
+
+	active := make(map[string]bool, len(status.ActiveRequests))
+	for _, id := range status.ActiveRequests {
+		active[id] = true
+	}
+	var res []AccessRequest
+	for _, req := range requests {
+		if req.State == RequestApproved && !active[req.ID] {
+			res = append(res, req)
+		}
+	}
+	return res, nil
+}
+
+// AssumeRequest logins again with the approved request,
+// the next sessions have the elevated roles
+func (t *TSH) AssumeRequest(id string) (err error) {
+	defer func() { err = inPhase(err, "assuming the access request %s", id) }()
+
+	cmd, err := t.loginCommand()
+	if err != nil {
+		return err
+	}
+	cmd.Args = append(cmd.Args, "--request-id="+id)
+	cmd.Stdout = t.loginOutput(os.Stdout)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = t.loginOutput(os.Stderr)
+	if err := run(cmd); err != nil {
+		return err
+	}
+
+	// the roles & logins are changed by the request
+	t.InvalidateStatus()
+	return nil
+}
+
+// requestCommand runs the tsh request command & returns its output
+func (t *TSH) requestCommand(args ...string) (string, error) {
+	cmd := t.command(args...)
+	var stdOut, stdErr = &bytes.Buffer{}, &bytes.Buffer{}
+	cmd.Stdout = stdOut
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = stdErr
+	if err := run(cmd); err != nil {
+		if errStr := strings.TrimSpace(stdErr.String()); errStr != "" {
+			return "", errors.New(errStr)
+		}
+		return "", err
+	}
+	return stdOut.String(), nil
+}
+
+// parseRequest parses the `tsh request create|show` output like
+//
+// Request ID: 9e2b2b2e-1cf7-4b5a-bf1b-6bde0b1e4a3f
+// Username:   alice
+// Roles:      dba
+// Reason:     [+] "incident 1234"
+// Status:     PENDING
+func parseRequest(str string) *AccessRequest {
+	req := &AccessRequest{}
+	for _, line := range strings.Split(str, "\n") {
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		value := strings.TrimSpace(kv[1])
+		switch strings.TrimSpace(kv[0]) {
+		case "Request ID":
+			req.ID = value
+		case "Username", "User":
+			req.User = value
+		case "Roles":
+			req.Roles = trimSliceString(strings.Split(value, ","))
+		case "Reason":
+			req.Reason = strings.Trim(strings.TrimSpace(strings.TrimPrefix(value, "[+]")), `"`)
+		case "Status", "State":
+			req.State = strings.ToUpper(value)
+		}
+	}
+	return req
+}
+
+// parseRequests parses the `tsh request ls` table
+func parseRequests(str string) []AccessRequest {
+	var res []AccessRequest
+	for _, row := range parseTable(str) {
+		req := AccessRequest{
+			ID:     row["ID"],
+			User:   row["User"],
+			Roles:  trimSliceString(strings.Split(row["Roles"], ",")),
+			Reason: strings.Trim(firstColumn(row, "Reasons", "Reason"), `"`),
+			State:  strings.ToUpper(firstColumn(row, "Status", "State")),
+		}
+		if req.ID != "" {
+			res = append(res, req)
+		}
+	}
+	return res
+}
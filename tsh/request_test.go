@@ -0,0 +1,74 @@
+package tsh
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		str  string
+		want *AccessRequest
+	}{
+		{
+			name: "created request",
+			str: `Creating request...
+Request ID: 9e2b2b2e-1cf7-4b5a-bf1b-6bde0b1e4a3f
+Username:   alice
+Roles:      dba, auditor
+Reason:     [+] "incident 1234"
+Reviewers:  [none] (suggested)
+Status:     PENDING
+`,
+			want: &AccessRequest{
+				ID:     "9e2b2b2e-1cf7-4b5a-bf1b-6bde0b1e4a3f",
+				User:   "alice",
+				Roles:  []string{"dba", "auditor"},
+				Reason: "incident 1234",
+				State:  RequestPending,
+			},
+		},
+		{
+			name: "approved request",
+			str:  "Request ID: 1234\nStatus:     approved\n",
+			want: &AccessRequest{ID: "1234", State: RequestApproved},
+		},
+		{
+			name: "no request",
+			str:  "ERROR: access denied",
+			want: &AccessRequest{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseRequest(tt.str))
+		})
+	}
+}
+
+func Test_parseRequests(t *testing.T) {
+	out := `ID                                   User  Roles       Created At (UTC)    Status   Reasons
+------------------------------------ ----- ----------- ------------------- -------- ---------------
+9e2b2b2e-1cf7-4b5a-bf1b-6bde0b1e4a3f alice dba,auditor 10 Jan 23 10:00 UTC APPROVED "incident 1234"
+0b6c3c1a-2b1e-4a55-9f61-0d3e1f4a7c2d alice admin       10 Jan 23 09:00 UTC DENIED
+`
+	want := []AccessRequest{
+		{
+			ID:     "9e2b2b2e-1cf7-4b5a-bf1b-6bde0b1e4a3f",
+			User:   "alice",
+			Roles:  []string{"dba", "auditor"},
+			Reason: "incident 1234",
+			State:  RequestApproved,
+		},
+		{
+			ID:    "0b6c3c1a-2b1e-4a55-9f61-0d3e1f4a7c2d",
+			User:  "alice",
+			Roles: []string{"admin"},
+			State: RequestDenied,
+		},
+	}
+	assert.Equal(t, want, parseRequests(out))
+	assert.Nil(t, parseRequests("ERROR: access requests are not supported"))
+}
@@ -3,15 +3,20 @@ package tsh
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/trace"
+	"github.com/adzimzf/tpot/wsl"
 )
 
 type TSH struct {
@@ -22,6 +27,22 @@ type TSH struct {
 	cmdExec func(name string, arg ...string) CmdExecutor
 
 	minVersion Version
+
+	// now returns the current time, abstracted for testing
+	now func() time.Time
+
+	// prefetch is the result of the background login started by Prefetch
+	// & prefetchOutput is its output shown once it's waited
+	prefetch       chan error
+	prefetchOutput bytes.Buffer
+
+	// login runs the interactive login, abstracted for testing
+	login func() error
+
+	// reloginMu guards reloggedIn, the certificate expired
+	// mid-command is only renewed once per TSH
+	reloginMu  sync.Mutex
+	reloggedIn bool
 }
 
 type CmdExecutor interface {
@@ -44,7 +65,7 @@ func (c *cmdType) Run() (cmdResult, error) {
 	var stdOut, stdErr = &bytes.Buffer{}, &bytes.Buffer{}
 	c.Cmd.Stdout = stdOut
 	c.Cmd.Stderr = stdErr
-	err := c.Cmd.Run()
+	err := run(c.Cmd)
 	return cmdResult{stdOut, stdErr}, err
 }
 
@@ -58,30 +79,45 @@ const tshVersion = "v4.1.11"
 var ErrUnsupportedVersion = fmt.Errorf("unsupported version")
 
 // SSH run the `tsh ssh` commands
-func (t *TSH) SSH(username, host string) error {
-	args, err := t.getProxyFlags()
+func (t *TSH) SSH(username, host string, opts SessionOptions) (err error) {
+	defer func() { err = inSession(err, username, host) }()
+
+	opts, err = t.sessionOptions(opts)
 	if err != nil {
 		return err
 	}
 
-	args = append(args, t.authFlags()...)
-
-	ipAddress, ok := t.proxy.Node.LookUpIPAddress(host)
-	if !ok {
-		return fmt.Errorf("couldn't find IP address")
+	if t.proxy.Multiplex {
+		cmd, err := t.multiplexCommand(context.Background(), username, host, opts.args()...)
+		if err != nil {
+			return err
+		}
+		cmd.Stdout = os.Stdout
+		cmd.Stdin = os.Stdin
+		cmd.Stderr = os.Stderr
+		return run(cmd)
 	}
 
-	args = append(args, "-l", username, ipAddress)
+	args, err := t.getProxyFlags()
+	if err != nil {
+		return err
+	}
 
-	cmd := exec.Command(t.tshBinary(), append([]string{"ssh"}, args...)...)
-	cmd.Stdout = os.Stdout
-	cmd.Stdin = os.Stdin
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	args = append(args, t.authFlags()...)
+	args = append(args, opts.args()...)
+
+	return t.dial(host, os.Stderr, func(address string, stderr io.Writer) error {
+		cmd := t.command(append([]string{"ssh"}, append(args, "-l", username, address)...)...)
+		cmd.Stdout = os.Stdout
+		cmd.Stdin = os.Stdin
+		cmd.Stderr = stderr
+		return run(cmd)
+	})
 }
 
 // ListNodes get the list nodes from proxy
-func (t *TSH) ListNodes() (config.Node, error) {
+func (t *TSH) ListNodes() (_ config.Node, err error) {
+	defer func() { err = inPhase(err, "listing the nodes of %s", t.proxy.Env) }()
 
 	if err := t.Login(); err != nil {
 		return config.Node{}, err
@@ -92,12 +128,17 @@ func (t *TSH) ListNodes() (config.Node, error) {
 		return config.Node{}, err
 	}
 
-	cmd := exec.Command(t.tshBinary(), append([]string{"ls"}, args...)...)
 	var stdOut, stdErr = &bytes.Buffer{}, &bytes.Buffer{}
-	cmd.Stdout = stdOut
-	cmd.Stdin = os.Stdin
-	cmd.Stderr = stdErr
-	if err = cmd.Run(); err != nil {
+	err = t.withRelogin(stdErr, func(stderr io.Writer) error {
+		stdOut.Reset()
+		stdErr.Reset()
+		cmd := t.command(append([]string{"ls"}, args...)...)
+		cmd.Stdout = stdOut
+		cmd.Stdin = os.Stdin
+		cmd.Stderr = stderr
+		return run(cmd)
+	})
+	if err != nil {
 		return config.Node{}, err
 	}
 	if errStr := stdErr.String(); errStr != "" {
@@ -111,14 +152,19 @@ func (t *TSH) ListNodes() (config.Node, error) {
 //
 // the tsh Version formatting is like this
 // Teleport v2.4.5.1 git:v2.4.5-19-g4901c48-dirty
-// it'll only return the v2.4.5.1
-func (t *TSH) Version() (*Version, error) {
-	cmd := exec.Command(t.tshBinary(), "version")
+// it'll only return the v2.4.5.1, the Version is cached per tsh binary
+func (t *TSH) Version() (_ *Version, err error) {
+	if v, ok := t.cachedVersion(); ok {
+		return v, nil
+	}
+	defer func() { err = inPhase(err, "getting the version of %s", t.tshBinary()) }()
+
+	cmd := t.command("version")
 	var stdOut, stdErr = &bytes.Buffer{}, &bytes.Buffer{}
 	cmd.Stdout = stdOut
 	cmd.Stdin = os.Stdin
 	cmd.Stderr = stdErr
-	if err := cmd.Run(); err != nil {
+	if err := run(cmd); err != nil {
 		return nil, err
 	}
 	if errStr := stdErr.String(); errStr != "" {
@@ -129,12 +175,22 @@ func (t *TSH) Version() (*Version, error) {
 		return nil, fmt.Errorf("std out is empty")
 	}
 
-	return NewVersion(out)
+	v, err := NewVersion(out)
+	if err != nil {
+		return nil, err
+	}
+	t.cacheVersion(v)
+	return v, nil
 }
 
-// Status return the tsh proxy status
+// Status return the tsh proxy status, it's cached shortly per proxy
 // this method is supported since tsh Version v2.6.1
-func (t *TSH) Status() (*config.ProxyStatus, error) {
+func (t *TSH) Status() (_ *config.ProxyStatus, err error) {
+	if s, ok := t.cachedStatus(); ok {
+		return s, nil
+	}
+	defer func() { err = inPhase(err, "getting the status of %s", t.proxy.Env) }()
+
 	cv, err := t.Version()
 	if err != nil {
 		return nil, err
@@ -148,12 +204,12 @@ func (t *TSH) Status() (*config.ProxyStatus, error) {
 		return nil, err
 	}
 
-	cmd := exec.Command(t.tshBinary(), append([]string{"status"}, proxyFlags...)...)
+	cmd := t.command(append([]string{"status"}, proxyFlags...)...)
 	var stdOut, stdErr = &bytes.Buffer{}, &bytes.Buffer{}
 	cmd.Stdout = stdOut
 	cmd.Stdin = os.Stdin
 	cmd.Stderr = stdErr
-	if err := cmd.Run(); err != nil {
+	if err := run(cmd); err != nil {
 		return nil, err
 	}
 	if errStr := stdErr.String(); errStr != "" {
@@ -164,7 +220,9 @@ func (t *TSH) Status() (*config.ProxyStatus, error) {
 		return nil, fmt.Errorf("std out is empty")
 	}
 
-	return t.parseStringToStatus(out), err
+	status := t.parseStringToStatus(out)
+	t.cacheStatus(status)
+	return status, nil
 }
 
 func (t *TSH) parseStringToStatus(str string) *config.ProxyStatus {
@@ -179,10 +237,14 @@ func (t *TSH) parseStringToStatus(str string) *config.ProxyStatus {
 		switch strings.TrimSpace(kv[0]) {
 		case "Logged in as":
 			res.LoginAs = strings.TrimSpace(kv[1])
+		case "Cluster":
+			res.Cluster = strings.TrimSpace(kv[1])
 		case "Roles":
 			res.Roles = trimSliceString(strings.Split(strings.TrimSpace(kv[1]), ","))
 		case "Logins":
 			res.UserLogins = trimSliceString(strings.Split(strings.TrimSpace(kv[1]), ","))
+		case "Active requests":
+			res.ActiveRequests = trimSliceString(strings.Split(strings.TrimSpace(kv[1]), ","))
 		}
 	}
 	return res
@@ -195,24 +257,79 @@ func trimSliceString(list []string) (res []string) {
 	return
 }
 
-func (t *TSH) Login() error {
+// Login logs in to the proxy unless the certificate is still valid
+func (t *TSH) Login() (err error) {
+	defer func() { err = inPhase(err, "logging in to %s", t.proxy.Env) }()
+
+	if ok, err := t.waitPrefetch(); ok || err != nil {
+		return err
+	}
+
+	// nothing is really run on dry run
+	if !DryRun {
+		if err := t.CheckBinary(); err != nil {
+			return err
+		}
+	}
+	if err := t.checkMinVersion(); err != nil {
+		return err
+	}
 
 	if t.isLogin() {
 		return nil
 	}
 
-	args, err := t.getProxyFlags()
+	cmd, err := t.loginCommand()
 	if err != nil {
 		return err
 	}
+	cmd.Stdout = t.loginOutput(os.Stdout)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = t.loginOutput(os.Stderr)
+	if err := run(cmd); err != nil {
+		return err
+	}
+
+	// the roles & logins may be changed by the new login
+	t.InvalidateStatus()
+	return nil
+}
+
+// loginCommand creates the `tsh login` command of the proxy
+func (t *TSH) loginCommand() (*exec.Cmd, error) {
+	args, err := t.getProxyFlags()
+	if err != nil {
+		return nil, err
+	}
 
 	args = append(args, t.authFlags()...)
+	if t.proxy.AddKeysToAgent != "" {
+		args = append(args, "--add-keys-to-agent="+t.proxy.AddKeysToAgent)
+	}
+	if t.proxy.PIVSlot != "" {
+		args = append(args, "--piv-slot="+t.proxy.PIVSlot)
+	}
+	args = append(args, t.browserFlags()...)
+	return t.command(append([]string{"login"}, args...)...), nil
+}
 
-	cmd := exec.Command(t.tshBinary(), append([]string{"login"}, args...)...)
-	cmd.Stdout = os.Stdout
-	cmd.Stdin = os.Stdin
-	cmd.Stderr = os.Stdin
-	return cmd.Run()
+// checkMinVersion ensures the tsh binary isn't older than MinVersion
+func (t *TSH) checkMinVersion() error {
+	// nothing is really run on dry run to get the version
+	if MinVersion == nil || DryRun {
+		return nil
+	}
+	step := trace.Start("check the tsh version is at least %s", MinVersion.Strings())
+	cv, err := t.Version()
+	if err != nil {
+		step.End(err)
+		return err
+	}
+	step.EndWith(cv.Strings())
+	if !MinVersion.IsSupported(cv) {
+		return fmt.Errorf("%w, the minimum tsh version is %s but got %s", ErrUnsupportedVersion, MinVersion.Strings(), cv.Strings())
+	}
+	return nil
 }
 
 type Profile struct {
@@ -221,15 +338,34 @@ type Profile struct {
 }
 
 // isLogin return true if the user is already login
-func (t *TSH) isLogin() bool {
+func (t *TSH) isLogin() (loggedIn bool) {
+	step := trace.Start("check the login of %s", t.proxy.Env)
+	defer func() { step.EndWith(fmt.Sprintf("logged in %t", loggedIn)) }()
+
+	validUntil, ok := t.ValidUntil()
+	return ok && t.currentTime().Before(validUntil)
+}
+
+// currentTime returns the current time by the clock of the TSH,
+// it's the wall clock once the clock isn't set
+func (t *TSH) currentTime() time.Time {
+	if t.now == nil {
+		return time.Now()
+	}
+	return t.now()
+}
+
+// ValidUntil returns when the login of the proxy expires by the local tsh status,
+// it's false when there's no profile of the proxy. No request is sent to the proxy
+func (t *TSH) ValidUntil() (time.Time, bool) {
 	cmd := t.cmdExec(t.tshBinary(), "status")
 	res, err := cmd.Run()
 	if err != nil {
-		return false
+		return time.Time{}, false
 	}
 
 	if res.stdErr.String() != "" {
-		return false
+		return time.Time{}, false
 	}
 
 	targetProfile := t.proxy.Address
@@ -252,7 +388,8 @@ func (t *TSH) isLogin() bool {
 			line = strings.TrimSpace(line)
 			timeString := strings.Split(strings.TrimPrefix(line, "Valid until:"), "[")[0]
 			timeString = strings.TrimSpace(timeString)
-			layout := "2006-01-02 15:04:05 -0700 WIB"
+			// the zone abbreviation depends on the machine, not only WIB
+			layout := "2006-01-02 15:04:05 -0700 MST"
 			validUntil, _ := time.Parse(layout, timeString)
 			currentProfile.ValidUntil = validUntil
 		}
@@ -263,10 +400,9 @@ func (t *TSH) isLogin() bool {
 
 	target, exists := profileMap[targetProfile]
 	if !exists {
-		return false
+		return time.Time{}, false
 	}
-
-	return time.Now().Before(target.ValidUntil)
+	return target.ValidUntil, true
 }
 
 func (t *TSH) getProxyFlags() ([]string, error) {
@@ -297,47 +433,56 @@ func (t *TSH) cleanAddress() (string, error) {
 	return u.Host, nil
 }
 
-// tshBinary return the location of TSH binary
+// tshBinary return the location of TSH binary, the Windows
+// path of tsh.exe is translated inside WSL
 func (t *TSH) tshBinary() string {
 	if t.proxy.TSHPath != "" {
-		return t.proxy.TSHPath
+		return wsl.LinuxPath(t.proxy.TSHPath)
 	}
 	return tshBinary
 }
 
 func parseNodesFromString(nodeStr string) config.Node {
-	var nodeList []config.Item
-	for _, line := range strings.Split(nodeStr, "\n") {
-
-		// remove the header of node table
-		// for now on the data will get in table formatting,
-		// to support all `tsh` old version
-		// because the JSON formatting is only supported by
-		// newer TSH
-		if strings.HasPrefix(line, "Node") || strings.HasPrefix(line, "---") || strings.HasPrefix(line, " ") {
+	// the table header is recognized by the dashes under it,
+	// otherwise it's the older tsh printing plain columns
+	if rows := parseTable(nodeStr); rows != nil {
+		nodeList := make([]config.Item, 0, len(rows))
+		for _, row := range rows {
+			node := config.Item{
+				Hostname: firstColumn(row, "Node Name", "Hostname"),
+				Address:  row["Address"],
+				ID:       firstColumn(row, "Node ID", "UUID"),
+				Labels:   parseLabels(row["Labels"]),
+			}
+			if node.Hostname != "" {
+				nodeList = append(nodeList, node)
+			}
+		}
+		return config.Node{Items: nodeList}
+	}
+
+	lines := strings.Split(nodeStr, "\n")
+	nodeList := make([]config.Item, 0, len(lines))
+	for _, line := range lines {
+		if strings.HasPrefix(line, "Node") || strings.HasPrefix(line, " ") {
 			continue
 		}
-		lines := strings.Split(line, " ")
 
-		// infoCount indicate that the node information we want to get has already fulfill
-		var infoCount int
+		// only the hostname & the address columns are needed
 		var node config.Item
-		for _, s := range lines {
-			if s == "" {
-				continue
-			}
-			if infoCount == 2 {
-				break
-			}
-			if infoCount == 0 {
-				node.Hostname = s
-			} else {
-				node.Address = s
-			}
-			infoCount++
+		fields := strings.Fields(line)
+		if len(fields) > 0 {
+			node.Hostname = fields[0]
+		}
+		if len(fields) > 1 {
+			node.Address = fields[1]
+		}
+		// the tunnel node is printed as `⟵ Tunnel`
+		if len(fields) > 2 && fields[2] == "Tunnel" {
+			node.Address += " " + fields[2]
 		}
 		// doesn't need to append an empty node
-		if node != (config.Item{}) {
+		if node.Hostname != "" {
 			nodeList = append(nodeList, node)
 		}
 	}
@@ -347,11 +492,22 @@ func parseNodesFromString(nodeStr string) config.Node {
 	}
 }
 
+// firstColumn returns the value of the first column found,
+// the column name is different between the tsh versions
+func firstColumn(row map[string]string, names ...string) string {
+	for _, name := range names {
+		if v, ok := row[name]; ok {
+			return v
+		}
+	}
+	return ""
+}
+
 // NewTSH creates a new TSH
 func NewTSH(p *config.Proxy) *TSH {
-	return &TSH{
-		proxy:   p,
-		cmdExec: Command,
+	t := &TSH{
+		proxy: p,
+		now:   fixtureClock(),
 		// the minimum version for supporting Status is TSH v2.6.1
 		minVersion: Version{
 			Major: 2,
@@ -359,4 +515,11 @@ func NewTSH(p *config.Proxy) *TSH {
 			Patch: 1,
 		},
 	}
+	t.login = t.runLogin
+	t.cmdExec = func(name string, arg ...string) CmdExecutor {
+		cmd := exec.Command(name, t.withExtraArgs(arg)...)
+		cmd.Env = t.environ()
+		return &cmdType{cmd}
+	}
+	return t
 }
@@ -3,6 +3,7 @@ package tsh
 import (
 	"bytes"
 	"testing"
+	"time"
 
 	"github.com/adzimzf/tpot/config"
 	"github.com/stretchr/testify/assert"
@@ -31,10 +32,27 @@ func TestTSH_parseStringToStatus(t1 *testing.T) {
 `,
 			status: &config.ProxyStatus{
 				LoginAs:    "ikhsan@my.com",
+				Cluster:    "main",
 				Roles:      []string{"engineer-frontend-role", "engineer-role*"},
 				UserLogins: []string{"ikhsan@my.com", "root", "readonly"},
 			},
 		},
+		{
+			name: "assumed access request",
+			str: `
+> Profile URL:     https://my.teleport.com
+  Logged in as:    ikhsan@my.com
+  Active requests: 9e2b2b2e-1cf7-4b5a-bf1b-6bde0b1e4a3f
+  Roles:           engineer-role, dba
+  Logins:          root
+`,
+			status: &config.ProxyStatus{
+				LoginAs:        "ikhsan@my.com",
+				Roles:          []string{"engineer-role", "dba"},
+				UserLogins:     []string{"root"},
+				ActiveRequests: []string{"9e2b2b2e-1cf7-4b5a-bf1b-6bde0b1e4a3f"},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t1.Run(tt.name, func(t1 *testing.T) {
@@ -54,6 +72,11 @@ func (c *cmdMock) Run() (cmdResult, error) {
 	return c.cmdResult, c.err
 }
 
+// fixedNow pins the clock to the day the status fixtures were captured
+func fixedNow() time.Time {
+	return time.Date(2023, 7, 8, 12, 0, 0, 0, time.FixedZone("WIB", 7*60*60))
+}
+
 func TestTSH_isLogin(t1 *testing.T) {
 	type fields struct {
 		proxy      *config.Proxy
@@ -155,3 +178,50 @@ func TestTSH_isLogin(t1 *testing.T) {
 		})
 	}
 }
+
+func TestTSH_isLogin_clock(t *testing.T) {
+	status := `
+> Profile URL:        https://staging.teleport.net:3080
+  Logged in as:       youremail@domain.com
+  Valid until:        2023-07-08 21:36:23 +0700 WIB [valid for 11h59m0s]
+`
+	tsh := &TSH{
+		proxy: &config.Proxy{Address: "https://staging.teleport.net:3080"},
+		cmdExec: func(name string, arg ...string) CmdExecutor {
+			return &cmdMock{cmdResult: cmdResult{stdOut: bytes.NewBufferString(status), stdErr: &bytes.Buffer{}}}
+		},
+		now: fixedNow,
+	}
+	assert.True(t, tsh.isLogin())
+
+	tsh.now = func() time.Time { return fixedNow().Add(12 * time.Hour) }
+	assert.False(t, tsh.isLogin())
+}
+
+func TestTSH_ValidUntil_zones(t *testing.T) {
+	tests := []struct {
+		name  string
+		until string
+		want  time.Time
+	}{
+		{"WIB", "2023-07-08 21:36:23 +0700 WIB", time.Date(2023, 7, 8, 14, 36, 23, 0, time.UTC)},
+		{"UTC", "2023-07-08 21:36:23 +0000 UTC", time.Date(2023, 7, 8, 21, 36, 23, 0, time.UTC)},
+		{"CEST", "2023-07-08 21:36:23 +0200 CEST", time.Date(2023, 7, 8, 19, 36, 23, 0, time.UTC)},
+		{"PDT", "2023-07-08 21:36:23 -0700 PDT", time.Date(2023, 7, 9, 4, 36, 23, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := "> Profile URL:        https://staging.teleport.net:3080\n" +
+				"  Valid until:        " + tt.until + " [valid for 11h59m0s]\n"
+			tsh := &TSH{
+				proxy: &config.Proxy{Address: "https://staging.teleport.net:3080"},
+				cmdExec: func(name string, arg ...string) CmdExecutor {
+					return &cmdMock{cmdResult: cmdResult{stdOut: bytes.NewBufferString(status), stdErr: &bytes.Buffer{}}}
+				},
+			}
+			got, ok := tsh.ValidUntil()
+			assert.True(t, ok)
+			assert.True(t, tt.want.Equal(got), "ValidUntil() got = %s, want %s", got, tt.want)
+		})
+	}
+}
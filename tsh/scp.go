@@ -0,0 +1,49 @@
+package tsh
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// SCP copies the files between local & the host recursively,
+// upload copies the local src into the remote dst, otherwise
+// the remote src is downloaded into the local dst
+func (t *TSH) SCP(userLogin, host, src, dst string, upload bool) error {
+	return t.scp(userLogin, host, src, dst, upload, os.Stdin, os.Stdout, os.Stderr)
+}
+
+// CopyFiles copies the files like SCP without the terminal, such as by the file
+// browser, the progress & the errors of tsh are written into the output
+func (t *TSH) CopyFiles(userLogin, host, src, dst string, upload bool, output io.Writer) error {
+	return t.scp(userLogin, host, src, dst, upload, nil, output, output)
+}
+
+func (t *TSH) scp(userLogin, host, src, dst string, upload bool, stdin io.Reader, stdout, stderr io.Writer) (err error) {
+	defer func() { err = inPhase(err, "copying the files with %s as %s", host, userLogin) }()
+
+	args, err := t.getProxyFlags()
+	if err != nil {
+		return err
+	}
+
+	args = append(args, t.authFlags()...)
+
+	return t.dial(host, stderr, func(address string, stderr io.Writer) error {
+		remote := func(p string) string {
+			return fmt.Sprintf("%s@%s:%s", userLogin, address, p)
+		}
+		scpArgs := append([]string{"scp"}, args...)
+		if upload {
+			scpArgs = append(scpArgs, "-r", src, remote(dst))
+		} else {
+			scpArgs = append(scpArgs, "-r", remote(src), dst)
+		}
+
+		cmd := t.command(scpArgs...)
+		cmd.Stdout = stdout
+		cmd.Stdin = stdin
+		cmd.Stderr = stderr
+		return run(cmd)
+	})
+}
@@ -0,0 +1,101 @@
+package tsh
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/adzimzf/tpot/config"
+)
+
+// the X11 forwarding modes of a session
+const (
+	X11Untrusted = "untrusted"
+	X11Trusted   = "trusted"
+)
+
+// SessionOptions is the options of an interactive `tsh ssh` session
+type SessionOptions struct {
+	// ForwardAgent forwards the local SSH agent to the host
+	ForwardAgent bool
+
+	// X11 forwards the X11 display, empty means no forwarding,
+	// X11Untrusted is `tsh ssh -X` & X11Trusted is `tsh ssh -Y`
+	X11 string
+
+	// Options is the OpenSSH style options passed by `-o`,
+	// formatted as Key=Value
+	Options []string
+
+	// LocalForwards is the local port forwards passed by `-L`,
+	// formatted as <local port>:<remote host>:<remote port>
+	LocalForwards []string
+}
+
+// Validate validates the options
+func (o SessionOptions) Validate() error {
+	switch o.X11 {
+	case "", X11Untrusted, X11Trusted:
+	default:
+		return fmt.Errorf("invalid X11 forwarding %s, use %s or %s", o.X11, X11Untrusted, X11Trusted)
+	}
+	for _, opt := range o.Options {
+		kv := strings.SplitN(opt, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return fmt.Errorf("invalid option %q, use Key=Value", opt)
+		}
+	}
+	for _, fwd := range o.LocalForwards {
+		if _, err := config.ParseForward(fwd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Merge returns the options overridden by other, the options of
+// other come first since the first obtained value of OpenSSH wins.
+// The forwards of both are kept
+func (o SessionOptions) Merge(other SessionOptions) SessionOptions {
+	res := SessionOptions{
+		ForwardAgent:  o.ForwardAgent || other.ForwardAgent,
+		X11:           o.X11,
+		Options:       append(append([]string(nil), other.Options...), o.Options...),
+		LocalForwards: append(append([]string(nil), o.LocalForwards...), other.LocalForwards...),
+	}
+	if other.X11 != "" {
+		res.X11 = other.X11
+	}
+	return res
+}
+
+// args returns the `tsh ssh` flags of the options
+func (o SessionOptions) args() []string {
+	var args []string
+	if o.ForwardAgent {
+		args = append(args, "-A")
+	}
+	switch o.X11 {
+	case X11Untrusted:
+		args = append(args, "-X")
+	case X11Trusted:
+		args = append(args, "-Y")
+	}
+	for _, opt := range o.Options {
+		args = append(args, "-o", opt)
+	}
+	for _, fwd := range o.LocalForwards {
+		args = append(args, "-L", fwd)
+	}
+	return args
+}
+
+// sessionOptions returns the session options of the proxy overridden by opts
+func (t *TSH) sessionOptions(opts SessionOptions) (SessionOptions, error) {
+	defaults := SessionOptions{
+		ForwardAgent: t.proxy.ForwardAgent,
+		X11:          t.proxy.X11Forwarding,
+		Options:      t.proxy.SSHOptions,
+	}
+	res := defaults.Merge(opts)
+	return res, res.Validate()
+}
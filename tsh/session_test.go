@@ -0,0 +1,81 @@
+package tsh
+
+import (
+	"testing"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionOptions_args(t *testing.T) {
+	tests := []struct {
+		name string
+		opts SessionOptions
+		want []string
+	}{
+		{name: "default"},
+		{name: "forward agent", opts: SessionOptions{ForwardAgent: true}, want: []string{"-A"}},
+		{name: "untrusted X11", opts: SessionOptions{X11: X11Untrusted}, want: []string{"-X"}},
+		{name: "local forwards", opts: SessionOptions{LocalForwards: []string{"5432:localhost:5432"}}, want: []string{"-L", "5432:localhost:5432"}},
+		{
+			name: "all",
+			opts: SessionOptions{ForwardAgent: true, X11: X11Trusted, Options: []string{"ServerAliveInterval=30", "ForwardX11Timeout=1h"}},
+			want: []string{"-A", "-Y", "-o", "ServerAliveInterval=30", "-o", "ForwardX11Timeout=1h"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.opts.args())
+		})
+	}
+}
+
+func TestTSH_sessionOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxy   *config.Proxy
+		opts    SessionOptions
+		want    SessionOptions
+		wantErr bool
+	}{
+		{
+			name:  "proxy defaults",
+			proxy: &config.Proxy{ForwardAgent: true, X11Forwarding: "untrusted", SSHOptions: []string{"ServerAliveInterval=30"}},
+			want:  SessionOptions{ForwardAgent: true, X11: X11Untrusted, Options: []string{"ServerAliveInterval=30"}},
+		},
+		{
+			name:  "flags override the proxy defaults",
+			proxy: &config.Proxy{X11Forwarding: "untrusted", SSHOptions: []string{"ServerAliveInterval=30"}},
+			opts:  SessionOptions{X11: X11Trusted, Options: []string{"ServerAliveInterval=10"}},
+			want:  SessionOptions{X11: X11Trusted, Options: []string{"ServerAliveInterval=10", "ServerAliveInterval=30"}},
+		},
+		{
+			name:    "invalid option",
+			proxy:   &config.Proxy{},
+			opts:    SessionOptions{Options: []string{"ServerAliveInterval"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid forward",
+			proxy:   &config.Proxy{},
+			opts:    SessionOptions{LocalForwards: []string{"5432:localhost"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid X11",
+			proxy:   &config.Proxy{X11Forwarding: "yes"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewTSH(tt.proxy).sessionOptions(tt.opts)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
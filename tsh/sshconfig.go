@@ -0,0 +1,42 @@
+package tsh
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/adzimzf/tpot/config"
+)
+
+// nodeSSHPort is the port of the teleport node service
+const nodeSSHPort = "3022"
+
+// HostConfig returns the OpenSSH config entry of the host named by the alias,
+// the node is reached by `tsh proxy ssh` so the plain OpenSSH clients like
+// VS Code Remote-SSH connect to it through the proxy
+func (t *TSH) HostConfig(alias, userLogin, host string) (string, error) {
+	address, err := t.dialAddress(host)
+	if err != nil {
+		return "", err
+	}
+	args, err := t.getProxyFlags()
+	if err != nil {
+		return "", err
+	}
+	args = append(append([]string{"proxy", "ssh"}, args...), t.authFlags()...)
+	proxyCommand := commandLine(t.command(append(args, "%r@%h:%p")...))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Host %s\n", alias)
+	fmt.Fprintf(&b, "    HostName %s\n", address)
+	fmt.Fprintf(&b, "    Port %s\n", nodeSSHPort)
+	if userLogin != "" {
+		fmt.Fprintf(&b, "    User %s\n", userLogin)
+	}
+	fmt.Fprintf(&b, "    ProxyCommand %s\n", proxyCommand)
+	if sock := t.proxy.SSHAuthSock; sock == config.SSHAuthSockNone {
+		b.WriteString("    IdentityAgent none\n")
+	} else if sock != "" {
+		fmt.Fprintf(&b, "    IdentityAgent %s\n", expandHome(sock))
+	}
+	return b.String(), nil
+}
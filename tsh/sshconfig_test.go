@@ -0,0 +1,35 @@
+package tsh
+
+import (
+	"testing"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTSH_HostConfig(t *testing.T) {
+	proxy := &config.Proxy{
+		Env:      "staging",
+		Address:  "https://teleport.mycomp.com",
+		UserName: "adzim",
+		Node:     config.Node{Items: []config.Item{{Hostname: "web-01", Address: "10.0.0.1:3022"}}},
+	}
+	want := `Host tpot-staging-web-01
+    HostName 10.0.0.1
+    Port 3022
+    User root
+    ProxyCommand tsh proxy ssh --proxy=teleport.mycomp.com --user=adzim %r@%h:%p
+`
+	got, err := NewTSH(proxy).HostConfig("tpot-staging-web-01", "root", "web-01")
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	proxy.SSHAuthSock = config.SSHAuthSockNone
+	got, err = NewTSH(proxy).HostConfig("tpot-staging-web-01", "", "web-01")
+	assert.NoError(t, err)
+	assert.NotContains(t, got, "User ")
+	assert.Contains(t, got, "IdentityAgent none\n")
+
+	_, err = NewTSH(proxy).HostConfig("tpot-staging-web-02", "root", "web-02")
+	assert.Error(t, err)
+}
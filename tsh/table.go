@@ -0,0 +1,111 @@
+package tsh
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// parseTable parses the table printed by tsh into the rows keyed by the
+// column header. The columns are found by the dashes under the header,
+// since the values like the labels may contain spaces
+func parseTable(out string) []map[string]string {
+	lines := strings.Split(strings.Replace(out, "\r\n", "\n", -1), "\n")
+	for i := 1; i < len(lines); i++ {
+		if !isTableRule(lines[i]) {
+			continue
+		}
+		cols := tableColumns(lines[i-1], lines[i])
+		var rows []map[string]string
+		for _, line := range lines[i+1:] {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			row := make(map[string]string, len(cols))
+			runes := []rune(line)
+			for _, c := range cols {
+				row[c.name] = strings.TrimSpace(sliceRunes(runes, c.start, c.end))
+			}
+			rows = append(rows, row)
+		}
+		return rows
+	}
+	return nil
+}
+
+type tableColumn struct {
+	name string
+
+	// start & end are the rune position of the column in the line,
+	// the end of the last column is -1 to take the rest of the line
+	start, end int
+}
+
+// tableColumns finds the columns by the dashes of the rule,
+// a column spans until the next column starts
+func tableColumns(header, rule string) []tableColumn {
+	var cols []tableColumn
+	start := -1
+	pos := 0
+	for _, r := range rule {
+		if r == '-' && start < 0 {
+			start = pos
+		}
+		if r != '-' && start >= 0 {
+			cols = append(cols, tableColumn{start: start})
+			start = -1
+		}
+		pos++
+	}
+	if start >= 0 {
+		cols = append(cols, tableColumn{start: start})
+	}
+
+	headerRunes := []rune(header)
+	for i := range cols {
+		cols[i].end = -1
+		if i+1 < len(cols) {
+			cols[i].end = cols[i+1].start
+		}
+		cols[i].name = strings.TrimSpace(sliceRunes(headerRunes, cols[i].start, cols[i].end))
+	}
+	return cols
+}
+
+// isTableRule returns true if the line is the dashes under the header
+func isTableRule(line string) bool {
+	line = strings.TrimSpace(line)
+	return line != "" && utf8.RuneCountInString(strings.Trim(line, "- ")) == 0
+}
+
+// sliceRunes slices the runes safely, the end -1 means the rest of the line
+func sliceRunes(runes []rune, start, end int) string {
+	if start >= len(runes) {
+		return ""
+	}
+	if end < 0 || end > len(runes) {
+		end = len(runes)
+	}
+	return string(runes[start:end])
+}
+
+// parseLabels parses the labels column like `env=prod, team=my team`,
+// the part without = belongs to the previous value which contains a comma
+func parseLabels(s string) map[string]string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	labels := make(map[string]string)
+	var last string
+	for _, part := range strings.Split(s, ",") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) == 2 && strings.TrimSpace(kv[0]) != "" {
+			last = strings.TrimSpace(kv[0])
+			labels[last] = strings.TrimSpace(kv[1])
+			continue
+		}
+		if last != "" {
+			labels[last] += "," + part
+		}
+	}
+	return labels
+}
@@ -0,0 +1,79 @@
+package tsh
+
+import (
+	"testing"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseNodesFromString(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+		want []config.Item
+	}{
+		{
+			name: "tsh v4 without labels",
+			out: `Node Name            Node ID                              Address            Labels
+-------------------- ------------------------------------ ------------------ ------
+web-01               5a8c0f2e-5f4d-4a59-9a83-b4e2b2f0a9d1 10.0.0.1:3022
+db-01                6b9d1f3f-6e5e-4b6a-8b94-c5f3c3f1b0e2 10.0.0.2:3022
+`,
+			want: []config.Item{
+				{Hostname: "web-01", Address: "10.0.0.1:3022", ID: "5a8c0f2e-5f4d-4a59-9a83-b4e2b2f0a9d1"},
+				{Hostname: "db-01", Address: "10.0.0.2:3022", ID: "6b9d1f3f-6e5e-4b6a-8b94-c5f3c3f1b0e2"},
+			},
+		},
+		{
+			name: "tsh v6 labels with spaces & commas",
+			out: `Node Name Address        Labels
+--------- -------------- ---------------------------------------------
+web-01    10.0.0.1:3022  env=prod,team=web platform,owners=alice,bob
+db-01     10.0.0.2:3022  env=prod, role=primary db
+`,
+			want: []config.Item{
+				{Hostname: "web-01", Address: "10.0.0.1:3022",
+					Labels: map[string]string{"env": "prod", "team": "web platform", "owners": "alice,bob"}},
+				{Hostname: "db-01", Address: "10.0.0.2:3022",
+					Labels: map[string]string{"env": "prod", "role": "primary db"}},
+			},
+		},
+		{
+			name: "tsh v13 tunnel nodes",
+			out: `Node Name     Address        Labels
+------------- -------------- ----------------------------
+web-01        ⟵ Tunnel       env=staging,region=eu west 1
+my node       10.0.0.2:3022  env=staging
+`,
+			want: []config.Item{
+				{Hostname: "web-01", Address: "⟵ Tunnel",
+					Labels: map[string]string{"env": "staging", "region": "eu west 1"}},
+				{Hostname: "my node", Address: "10.0.0.2:3022",
+					Labels: map[string]string{"env": "staging"}},
+			},
+		},
+		{
+			name: "plain columns without the rule",
+			out: `Node Name Address
+web-01 10.0.0.1:3022
+db-01 10.0.0.2:3022
+`,
+			want: []config.Item{
+				{Hostname: "web-01", Address: "10.0.0.1:3022"},
+				{Hostname: "db-01", Address: "10.0.0.2:3022"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseNodesFromString(tt.out).Items)
+		})
+	}
+}
+
+func Test_parseLabels(t *testing.T) {
+	assert.Nil(t, parseLabels(" "))
+	assert.Equal(t, map[string]string{"a": "1", "b": "x=y"}, parseLabels("a=1,b=x=y"))
+	assert.Equal(t, map[string]string{"a": "1,2"}, parseLabels("a=1,2"))
+}
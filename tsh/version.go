@@ -48,6 +48,11 @@ func NewVersion(s string) (*Version, error) {
 	return v, nil
 }
 
+// ParseVersion creates a Version from the tag like 6.2.0 or v6.2.0
+func ParseVersion(tag string) (*Version, error) {
+	return NewVersion("Teleport v" + strings.TrimPrefix(tag, "v"))
+}
+
 func atoi(s string) (i int) {
 	i, _ = strconv.Atoi(s)
 	return
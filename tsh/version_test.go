@@ -136,3 +136,19 @@ func TestVersion_IsSupported(t *testing.T) {
 		})
 	}
 }
+
+func TestParseVersion(t *testing.T) {
+	for _, tag := range []string{"6.2.1", "v6.2.1"} {
+		v, err := ParseVersion(tag)
+		assert.NoError(t, err)
+		assert.Equal(t, &Version{Major: 6, Minor: 2, Patch: 1}, v)
+	}
+	_, err := ParseVersion("6.2")
+	assert.Error(t, err)
+}
+
+func TestNewVersion(t *testing.T) {
+	got, err := NewVersion("Teleport v2.6.0-rc.1")
+	assert.NoError(t, err)
+	assert.Equal(t, &Version{Major: 2, Minor: 6, Patch: 0}, got)
+}
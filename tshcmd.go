@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/i18n"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/adzimzf/tpot/ui"
+	"github.com/spf13/cobra"
+)
+
+const tshInstallExample = `
+tpot tsh install                    // Install tsh by Homebrew on macOS
+tpot tsh install --version 15.4.0   // Install tsh 15.4.0 by the Teleport install script
+`
+
+var tshCmd = &cobra.Command{
+	Use:   "tsh",
+	Short: "Manage the tsh binary used by tpot",
+}
+
+var tshInstallCmd = &cobra.Command{
+	Use:     "install",
+	Short:   "Install tsh on this machine",
+	Example: tshInstallExample,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := loadConfig(cmd); err != nil {
+			return err
+		}
+
+		version, _ := cmd.Flags().GetString("version")
+		if version == "" {
+			// the policy minimum is surely allowed to login
+			version = config.CurrentPolicy().MinTSHVersion
+		}
+
+		script, err := tsh.InstallScript(version)
+		if err != nil {
+			return err
+		}
+
+		cmd.Printf("tsh will be installed by: %s\n", script)
+		if tsh.DryRun {
+			return nil
+		}
+		if yes, _ := cmd.Flags().GetBool("yes"); !yes {
+			ok, err := ui.Confirm(i18n.T("Continue to install?"))
+			if err != nil {
+				return fmt.Errorf("failed to get confirmation, error: %v", err)
+			}
+			if !ok {
+				return withCode(exitCancelled, fmt.Errorf("tsh install is cancelled"))
+			}
+		}
+
+		c := exec.Command("bash", "-c", script)
+		c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := c.Run(); err != nil {
+			return fmt.Errorf("failed to install tsh, error: %v", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	tshInstallCmd.Flags().String("version", "", "the tsh version to install, example 15.4.0")
+	tshInstallCmd.Flags().BoolP("yes", "y", false, "install without the confirmation")
+	tshCmd.AddCommand(tshInstallCmd)
+	rootCmd.AddCommand(tshCmd)
+}
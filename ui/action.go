@@ -0,0 +1,35 @@
+package ui
+
+import "github.com/adzimzf/tpot/i18n"
+
+// Action is the action to do on the selected host
+type Action int
+
+const (
+	ActionSSH Action = iota
+	ActionExec
+	ActionForward
+	ActionCopyIP
+	ActionInfo
+	ActionSCP
+
+	// ActionRefresh refreshes the nodes of the env, it's only in the console
+	ActionRefresh
+)
+
+// actionBindings maps the picker bindings into the actions, the letters are
+// typed into the search box hence the actions are bound to CTRL or ALT keys
+var actionBindings = map[Binding]Action{
+	BindExec:    ActionExec,
+	BindForward: ActionForward,
+	BindCopyIP:  ActionCopyIP,
+	BindInfo:    ActionInfo,
+	BindSCP:     ActionSCP,
+}
+
+// actionTitle returns the picker title to show the action keys
+func actionTitle() string {
+	return i18n.T("Type to Search | Arrow to Navigate") + " | " + Keys.help(
+		string(BindSelect), "ssh", string(BindExec), "exec", string(BindForward), "forward",
+		string(BindCopyIP), "copy IP", string(BindInfo), "info", string(BindSCP), "scp")
+}
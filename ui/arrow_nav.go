@@ -13,11 +13,12 @@ const (
 	NavRight
 )
 
-var NavKeyMap = map[navDir]gocui.Key{
-	NavUp:    gocui.KeyArrowUp,
-	NavDown:  gocui.KeyArrowDown,
-	NavLeft:  gocui.KeyArrowLeft,
-	NavRight: gocui.KeyArrowRight,
+// navBindings maps the navigation directions into their bindings
+var navBindings = map[navDir]Binding{
+	NavUp:    BindUp,
+	NavDown:  BindDown,
+	NavLeft:  BindLeft,
+	NavRight: BindRight,
 }
 
 type ArrowNav struct {
@@ -27,23 +28,8 @@ type ArrowNav struct {
 
 func (a *ArrowNav) newHandler(dir navDir) func(g *gocui.Gui, v *gocui.View) error {
 	return func(g *gocui.Gui, v *gocui.View) error {
-		resultV, err := g.View(searchResultView)
-		if err != nil {
-			return err
-		}
-		inputV, err := g.View(searchInputView)
-		if err != nil {
-			return err
-		}
-
-		text := cleanText(resultV.Buffer())
-		keyword := inputV.Buffer()
-		pos, data := findArrowPos(text)
-		nextPos := a.nextPos(pos, findMaxXY(text), dir)
-		resultV.Clear()
-
-		_, err = resultV.Write([]byte(formatResult(lookup(keyword, data), keyword, nextPos)))
-		return err
+		a.s.move(dir, a)
+		return a.s.draw(g)
 	}
 }
 
@@ -71,14 +57,14 @@ func (a *ArrowNav) nextPos(cp arrowPos, m maxXY, dir navDir) arrowPos {
 }
 
 func (a *ArrowNav) registerArrowNav() error {
-	for dir, key := range NavKeyMap {
-		if err := a.g.SetKeybinding(searchInputView, key, gocui.ModNone, a.newHandler(dir)); err != nil {
+	for dir, b := range navBindings {
+		if err := Keys.bind(a.g, searchInputView, b, a.newHandler(dir)); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func NewArrowNav(g *gocui.Gui) *ArrowNav {
-	return &ArrowNav{g: g}
+func NewArrowNav(g *gocui.Gui, s *search) *ArrowNav {
+	return &ArrowNav{g: g, s: s}
 }
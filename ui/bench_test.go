@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"fmt"
+	"testing"
+)
+
+func benchHosts(n int) []string {
+	hosts := make([]string, n)
+	for i := range hosts {
+		hosts[i] = fmt.Sprintf("web-%05d", i)
+	}
+	return hosts
+}
+
+func BenchmarkLookup(b *testing.B) {
+	hosts := benchHosts(50000)
+	for _, keyword := range []string{"", "web-4"} {
+		b.Run(fmt.Sprintf("%q", keyword), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				lookup(keyword, hosts)
+			}
+		})
+	}
+}
+
+func BenchmarkFormatResult(b *testing.B) {
+	defer func(x, y int) { maxScreenX, maxScreenY = x, y }(maxScreenX, maxScreenY)
+	maxScreenX, maxScreenY = 200, 50
+	for _, n := range []int{1000, 50000} {
+		result := benchHosts(n)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				formatResult(result, "web", arrowPos{})
+			}
+		})
+	}
+}
+
+func BenchmarkFilterHosts(b *testing.B) {
+	hosts := benchHosts(50000)
+	matches := filterHosts("web-4", hosts)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		filterHosts("web-42", matches)
+	}
+}
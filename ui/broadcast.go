@@ -0,0 +1,225 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/adzimzf/tpot/i18n"
+	"github.com/jroimartin/gocui"
+)
+
+// broadcastInputView is the view to type the broadcast command
+const broadcastInputView = "broadcast_input"
+
+// Broadcast is a multi pane UI, every pane shows the output of a host
+// session & the typed line is sent to all the active sessions
+type Broadcast struct {
+	g     *gocui.Gui
+	panes []*broadcastPane
+
+	// focus is the index of focused pane to be toggled
+	focus int
+}
+
+type broadcastPane struct {
+	host   string
+	active bool
+	input  io.Writer
+}
+
+// NewBroadcast creates the broadcast UI for the hosts
+func NewBroadcast(hosts []string) (*Broadcast, error) {
+	if Plain {
+		return nil, i18n.Error("the broadcast needs the full-screen terminal, use --exec in the plain mode")
+	}
+	g, err := gocui.NewGui(gocui.OutputNormal)
+	if err != nil {
+		return nil, err
+	}
+	g.Cursor = true
+	g.SelFgColor = gocui.ColorGreen
+
+	b := &Broadcast{g: g}
+	for _, host := range hosts {
+		b.panes = append(b.panes, &broadcastPane{host: host, active: true})
+	}
+	g.SetManagerFunc(b.layout)
+	if err := b.registerKeyBind(); err != nil {
+		g.Close()
+		return nil, err
+	}
+	return b, nil
+}
+
+// Output returns the writer of the host pane, it's safe to be used
+// concurrently by the session goroutines
+func (b *Broadcast) Output(host string) io.Writer {
+	return &paneWriter{g: b.g, view: paneViewName(host)}
+}
+
+// Run runs the UI until CTRL+C, inputs is the stdin of every host session
+func (b *Broadcast) Run(inputs map[string]io.Writer) error {
+	defer b.g.Close()
+	for _, p := range b.panes {
+		p.input = inputs[p.host]
+	}
+	err := b.g.MainLoop()
+	if err == gocui.ErrQuit {
+		return nil
+	}
+	return err
+}
+
+func (b *Broadcast) layout(g *gocui.Gui) error {
+	maxX, maxY := g.Size()
+	cols := int(math.Ceil(math.Sqrt(float64(len(b.panes)))))
+	if cols == 0 {
+		cols = 1
+	}
+	rows := int(math.Ceil(float64(len(b.panes)) / float64(cols)))
+	width, height := maxX/cols, (maxY-3)/rows
+
+	for i, p := range b.panes {
+		x0, y0 := (i%cols)*width, (i/cols)*height
+		v, err := g.SetView(paneViewName(p.host), x0, y0, x0+width-1, y0+height-1)
+		if err != nil {
+			if err != gocui.ErrUnknownView {
+				return err
+			}
+			v.Wrap = true
+			v.Autoscroll = true
+		}
+		v.Title = b.paneTitle(i)
+		v.FgColor = gocui.ColorDefault
+		if !p.active {
+			v.FgColor = gocui.ColorRed
+		}
+	}
+
+	if v, err := g.SetView(broadcastInputView, 0, maxY-3, maxX-1, maxY-1); err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		v.Editable = true
+		v.Title = i18n.T("Type to broadcast") + " | " + Keys.help(string(BindNextPane), "focus", string(BindTogglePane), "toggle focused",
+			string(BindToggleAll), "toggle all", string(BindQuit), "quit")
+		if _, err := g.SetCurrentView(broadcastInputView); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Broadcast) paneTitle(i int) string {
+	p := b.panes[i]
+	state := "on"
+	if !p.active {
+		state = "off"
+	}
+	title := fmt.Sprintf("%s [%s]", p.host, state)
+	if i == b.focus {
+		title = "> " + title
+	}
+	return title
+}
+
+func (b *Broadcast) registerKeyBind() error {
+	if err := Keys.bind(b.g, "", BindQuit, quit); err != nil {
+		return err
+	}
+	if err := Keys.bind(b.g, broadcastInputView, BindSelect, b.handleEnter); err != nil {
+		return err
+	}
+	if err := Keys.bind(b.g, broadcastInputView, BindNextPane, func(g *gocui.Gui, v *gocui.View) error {
+		b.focus = (b.focus + 1) % len(b.panes)
+		return nil
+	}); err != nil {
+		return err
+	}
+	if err := Keys.bind(b.g, broadcastInputView, BindTogglePane, func(g *gocui.Gui, v *gocui.View) error {
+		b.panes[b.focus].active = !b.panes[b.focus].active
+		return nil
+	}); err != nil {
+		return err
+	}
+	return Keys.bind(b.g, broadcastInputView, BindToggleAll, func(g *gocui.Gui, v *gocui.View) error {
+		// activate all unless all of them are already active
+		active := false
+		for _, p := range b.panes {
+			if !p.active {
+				active = true
+			}
+		}
+		for _, p := range b.panes {
+			p.active = active
+		}
+		return nil
+	})
+}
+
+// handleEnter sends the typed line into all the active sessions
+func (b *Broadcast) handleEnter(g *gocui.Gui, v *gocui.View) error {
+	line := strings.TrimSpace(v.Buffer())
+	v.Clear()
+	if err := v.SetCursor(0, 0); err != nil {
+		return err
+	}
+
+	for _, p := range b.panes {
+		if !p.active || p.input == nil {
+			continue
+		}
+		pv, err := g.View(paneViewName(p.host))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(pv, "\u001B[33;1m$ %s\u001B[0m\n", line)
+		if _, err := io.WriteString(p.input, line+"\n"); err != nil {
+			fmt.Fprintf(pv, "\u001B[31;1mfailed to send: %v\u001B[0m\n", err)
+		}
+	}
+	return nil
+}
+
+func paneViewName(host string) string {
+	return "broadcast_" + host
+}
+
+// paneWriter writes into a view from outside of the UI goroutine,
+// the output is buffered until the UI flushes it to keep the order
+type paneWriter struct {
+	g    *gocui.Gui
+	view string
+
+	mu        sync.Mutex
+	pending   bytes.Buffer
+	scheduled bool
+}
+
+func (w *paneWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending.Write(p)
+	if !w.scheduled {
+		w.scheduled = true
+		w.g.Update(w.flush)
+	}
+	return len(p), nil
+}
+
+func (w *paneWriter) flush(g *gocui.Gui) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.scheduled = false
+	v, err := g.View(w.view)
+	if err != nil {
+		// the pane is not drawn yet, keep it for the next write
+		return nil
+	}
+	_, err = w.pending.WriteTo(v)
+	return err
+}
@@ -0,0 +1,37 @@
+package ui
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/adzimzf/tpot/wsl"
+)
+
+// clipboardCommands is the clipboard tools tried in order
+var clipboardCommands = [][]string{
+	{"pbcopy"},
+	{"wl-copy"},
+	{"xclip", "-selection", "clipboard"},
+	{"xsel", "--clipboard", "--input"},
+	{"clip.exe"},
+}
+
+// CopyToClipboard copies the text into the system clipboard
+// by the first clipboard tool found in the PATH, clip.exe is
+// tried first inside WSL since there's often no X server
+func CopyToClipboard(text string) error {
+	commands := clipboardCommands
+	if wsl.Detected() {
+		commands = append([][]string{{"clip.exe"}}, clipboardCommands...)
+	}
+	for _, c := range commands {
+		if _, err := exec.LookPath(c[0]); err != nil {
+			continue
+		}
+		cmd := exec.Command(c[0], c[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		return cmd.Run()
+	}
+	return fmt.Errorf("there's no clipboard tool found, install xclip, xsel or wl-copy")
+}
@@ -1 +1,50 @@
 package ui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ansiColors is the ANSI foreground code of the color names
+var ansiColors = map[string]int{
+	"red":     31,
+	"green":   32,
+	"yellow":  33,
+	"blue":    34,
+	"magenta": 35,
+	"cyan":    36,
+	"white":   37,
+}
+
+// Colorize makes the text bold with the color, the unknown color is only bold
+func Colorize(text, color string) string {
+	if Plain {
+		return text
+	}
+	if code, ok := ansiColors[color]; ok {
+		return fmt.Sprintf("\u001B[%d;1m%s\u001B[0m", code, text)
+	}
+	return fmt.Sprintf("\u001B[1m%s\u001B[0m", text)
+}
+
+// Badge renders the text as a reversed label of the color
+func Badge(text, color string) string {
+	if Plain {
+		return text
+	}
+	return Colorize("\u001B[7m "+text+" ", color)
+}
+
+// Banner writes a prominent block of the text to be noticed before
+// doing something on a critical environment
+func Banner(w io.Writer, text, color string) {
+	if Plain {
+		fmt.Fprintln(w, text)
+		return
+	}
+	blank := strings.Repeat(" ", len(text)+4)
+	fmt.Fprintln(w, Badge(blank, color))
+	fmt.Fprintln(w, Badge("  "+text+"  ", color))
+	fmt.Fprintln(w, Badge(blank, color))
+}
@@ -0,0 +1,38 @@
+package ui
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestColorize(t *testing.T) {
+	tests := []struct {
+		name  string
+		color string
+		want  string
+	}{
+		{name: "known color", color: "red", want: "\u001B[31;1mprod\u001B[0m"},
+		{name: "unknown color", color: "pink", want: "\u001B[1mprod\u001B[0m"},
+		{name: "no color", color: "", want: "\u001B[1mprod\u001B[0m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Colorize("prod", tt.color); got != tt.want {
+				t.Errorf("Colorize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBanner(t *testing.T) {
+	var buf bytes.Buffer
+	Banner(&buf, "PROD", "red")
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Banner() got %d lines, want 3", len(lines))
+	}
+	if !strings.Contains(lines[1], "  PROD  ") {
+		t.Errorf("Banner() = %q, want the text in the middle line", lines[1])
+	}
+}
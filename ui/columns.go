@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jroimartin/gocui"
+)
+
+// columnColor starts the columns next to the host, like noteColor
+// the rest of the cell from it isn't the hostname
+const columnColor = "\u001B[36m"
+
+// Column is a column of the picker & the console next to the hostname
+type Column struct {
+	// Width is the width of the column, the longer value is cut
+	Width int
+
+	// Values is the cell of the hosts by the hostname
+	Values map[string]string
+}
+
+var (
+	// pickerColumns is the columns of the picker hosts,
+	// hostWidth is the width of the hostname next to them
+	pickerColumns []Column
+	hostWidth     int
+
+	// showColumns is toggled by BindColumns
+	showColumns bool
+)
+
+// columnsShown returns true if the columns of the picker are shown
+func columnsShown() bool {
+	return showColumns && len(pickerColumns) > 0
+}
+
+// hostDetails returns what's shown after the host, its columns padded by
+// the width along with the note such as the failed connections
+func hostDetails(host string, columns []Column, width int, note string) string {
+	var b strings.Builder
+	if len(columns) > 0 {
+		b.WriteString(strings.Repeat(" ", max(1, width-utf8.RuneCountInString(host))))
+		b.WriteString(columnColor)
+		for i, c := range columns {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(fitColumn(c.Values[host], c.Width))
+		}
+		b.WriteString("\u001B[0m")
+	}
+	if note != "" {
+		b.WriteString(" " + noteColor + note + "\u001B[0m")
+	}
+	return b.String()
+}
+
+// fitColumn pads the value to the width, the longer one is cut by an ellipsis
+func fitColumn(value string, width int) string {
+	n := utf8.RuneCountInString(value)
+	if n > width {
+		return string([]rune(value)[:width-1]) + "…"
+	}
+	return value + strings.Repeat(" ", width-n)
+}
+
+// plainDetails returns the non-empty columns of the host separated by
+// commas for the plain mode, the empty string when there's none
+func plainDetails(host string, columns []Column) string {
+	var values []string
+	for _, c := range columns {
+		if v := strings.TrimSpace(c.Values[host]); v != "" {
+			values = append(values, v)
+		}
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	return ", " + strings.Join(values, ", ")
+}
+
+// registerColumns binds the key toggling the columns, it redraws the hosts
+// matching the query with the arrow back on the first host
+func (s *search) registerColumns() error {
+	if len(pickerColumns) == 0 {
+		return nil
+	}
+	return Keys.bind(s.g, "", BindColumns, func(g *gocui.Gui, v *gocui.View) error {
+		showColumns = !showColumns
+		s.arrow = arrowPos{}
+		return s.draw(g)
+	})
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
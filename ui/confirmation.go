@@ -3,19 +3,37 @@ package ui
 import (
 	"fmt"
 
+	"github.com/adzimzf/tpot/i18n"
 	"github.com/manifoldco/promptui"
 )
 
 // Confirm shows basic popup confirmation Yes or No
 // return false if user select no
 func Confirm(text string) (bool, error) {
+	if Plain {
+		for {
+			s, err := readLine(text + " [y/n]")
+			if err != nil {
+				return false, err
+			}
+			switch s {
+			case "y", "Y":
+				return true, nil
+			case "n", "N":
+				return false, nil
+			}
+			fmt.Fprintln(plainOutput(), i18n.T("invalid option, enter y or n"))
+		}
+	}
 	prompt := promptui.Prompt{
-		Label: text + " [Y/y/N/n]",
+		Label:       text + " [Y/y/N/n]",
+		Stdout:      promptStdout(),
+		HideEntered: Quiet,
 		Validate: func(s string) error {
 			if s == "y" || s == "Y" || s == "N" || s == "n" {
 				return nil
 			}
-			return fmt.Errorf("invalid option")
+			return i18n.Error("invalid option")
 		},
 	}
 	run, err := prompt.Run()
@@ -24,3 +42,22 @@ func Confirm(text string) (bool, error) {
 	}
 	return run == "y" || run == "Y", nil
 }
+
+// ConfirmEnv asks the user to type the environment name to confirm,
+// return false if the typed name is different
+func ConfirmEnv(env string) (bool, error) {
+	if Plain {
+		typed, err := readLine(i18n.Sprintf("Type %s to confirm", env))
+		return typed == env, err
+	}
+	prompt := promptui.Prompt{
+		Label:       i18n.Sprintf("Type %s to confirm", env),
+		Stdout:      promptStdout(),
+		HideEntered: Quiet,
+	}
+	typed, err := prompt.Run()
+	if err != nil {
+		return false, err
+	}
+	return typed == env, nil
+}
@@ -0,0 +1,402 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/adzimzf/tpot/fold"
+	"github.com/adzimzf/tpot/i18n"
+	"github.com/jroimartin/gocui"
+)
+
+// the views of the console
+const (
+	consoleEnvView    = "console_env"
+	consoleSearchView = "console_search"
+	consoleHostView   = "console_host"
+	consoleStatusView = "console_status"
+)
+
+// consoleTitle returns the title of the host list to show the keys
+func consoleTitle() string {
+	return Keys.help(string(BindSelect), "ssh", string(BindExec), "exec", string(BindForward), "forward",
+		string(BindCopyIP), "copy IP", string(BindInfo), "info", string(BindSCP), "scp",
+		string(BindRefresh), "refresh", string(BindNextEnv), "env", string(BindQuit), "quit")
+}
+
+// consoleEnvWidth is the width of the environment list
+const consoleEnvWidth = 24
+
+// ConsoleEnv is an environment shown in the console
+type ConsoleEnv struct {
+	Name  string
+	Badge string
+	Color string
+	Hosts []string
+
+	// Status is the lines describing the login & the node cache
+	Status []string
+
+	// History is the previous queries, the latest first
+	History []string
+
+	// Notes is shown next to the hosts, such as the failed connections
+	Notes map[string]string
+
+	// Columns is shown next to the hosts padded to the HostWidth
+	Columns   []Column
+	HostWidth int
+}
+
+// ConsoleResult is the host & the action picked in the console
+type ConsoleResult struct {
+	Env    string
+	Host   string
+	Action Action
+
+	// Query is the typed query once the host is picked
+	Query string
+}
+
+// Console is the persistent UI combining the environments, the hosts,
+// the status & the search history. It's closed to do the picked action
+// then run again, the selected environment & the query are kept
+type Console struct {
+	Envs []ConsoleEnv
+
+	// Notice is shown on top of the status, such as the configuration is reloaded
+	Notice string
+
+	// HideColumns hides the columns of the hosts, it's toggled by BindColumns
+	HideColumns bool
+
+	env    int
+	query  string
+	cursor int
+
+	// hosts is the hosts of the env match the query
+	hosts []string
+
+	// historyPos is the index of the query shown from the history,
+	// -1 means the typed query
+	historyPos int
+
+	result ConsoleResult
+	picked bool
+
+	// mu guards g & queue, Queue is called by the other goroutines
+	mu    sync.Mutex
+	g     *gocui.Gui
+	queue []func()
+}
+
+// SetEnv selects the environment by the name
+func (c *Console) SetEnv(name string) {
+	for i, e := range c.Envs {
+		if e.Name == name {
+			c.env = i
+		}
+	}
+}
+
+// UpdateEnv replaces the environment of the same name,
+// such as once the nodes are refreshed
+func (c *Console) UpdateEnv(env ConsoleEnv) {
+	for i, e := range c.Envs {
+		if e.Name == env.Name {
+			c.Envs[i] = env
+		}
+	}
+}
+
+// SetEnvs replaces the environments, the selected one is kept by the name,
+// the envs must not be empty
+func (c *Console) SetEnvs(envs []ConsoleEnv) {
+	var name string
+	if c.env < len(c.Envs) {
+		name = c.Envs[c.env].Name
+	}
+	c.Envs = envs
+	c.env = 0
+	c.SetEnv(name)
+	c.historyPos = -1
+	c.filter()
+}
+
+// Queue runs the fn by the goroutine showing the console, right away while
+// it's shown or once it's shown again. It's safe to be called by the other
+// goroutines, such as to reload the environments by SetEnvs
+func (c *Console) Queue(fn func()) {
+	c.mu.Lock()
+	c.queue = append(c.queue, fn)
+	g := c.g
+	c.mu.Unlock()
+	if g != nil {
+		g.Update(func(g *gocui.Gui) error {
+			c.flush()
+			// the key map may be reloaded as well
+			g.DeleteKeybindings("")
+			return c.registerKeyBind(g)
+		})
+	}
+}
+
+// flush runs the queued funcs
+func (c *Console) flush() {
+	c.mu.Lock()
+	queue := c.queue
+	c.queue = nil
+	c.mu.Unlock()
+	for _, fn := range queue {
+		fn()
+	}
+}
+
+// Run shows the console until a host is picked along with the action,
+// it returns false when the user quits without picking
+func (c *Console) Run() (ConsoleResult, bool, error) {
+	if len(c.Envs) == 0 {
+		return ConsoleResult{}, false, i18n.Error("there's no environment to show")
+	}
+	if Plain {
+		return ConsoleResult{}, false, i18n.Error("the console needs the full-screen terminal, use tpot <ENVIRONMENT> in the plain mode")
+	}
+
+	g, err := gocui.NewGui(gocui.OutputNormal)
+	if err != nil {
+		return ConsoleResult{}, false, err
+	}
+	defer g.Close()
+	g.Cursor = true
+
+	c.mu.Lock()
+	c.g = g
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.g = nil
+		c.mu.Unlock()
+	}()
+
+	c.picked = false
+	c.historyPos = -1
+	c.flush()
+	c.filter()
+	g.SetManagerFunc(c.layout)
+	if err := c.registerKeyBind(g); err != nil {
+		return ConsoleResult{}, false, err
+	}
+	if err := g.MainLoop(); err != nil && err != gocui.ErrQuit {
+		return ConsoleResult{}, false, err
+	}
+	return c.result, c.picked, nil
+}
+
+func (c *Console) layout(g *gocui.Gui) error {
+	maxX, maxY := g.Size()
+	statusHeight := 8
+	if maxY < 20 {
+		statusHeight = 4
+	}
+
+	envV, err := g.SetView(consoleEnvView, 0, 0, consoleEnvWidth-1, maxY-1)
+	if err != nil && err != gocui.ErrUnknownView {
+		return err
+	}
+	envV.Title = i18n.T("Environments")
+	envV.Clear()
+	for i, e := range c.Envs {
+		name := e.Name
+		if e.Badge != "" {
+			name = e.Badge + " " + name
+		}
+		if i == c.env {
+			fmt.Fprintf(envV, "%s%s\n", arrowColorized, Colorize(name, e.Color))
+			continue
+		}
+		fmt.Fprintf(envV, "   %s\n", name)
+	}
+
+	searchV, err := g.SetView(consoleSearchView, consoleEnvWidth, 0, maxX-1, 2)
+	if err != nil && err != gocui.ErrUnknownView {
+		return err
+	}
+	searchV.Title = i18n.Sprintf("Search %s", c.Envs[c.env].Name)
+	searchV.Clear()
+	fmt.Fprint(searchV, c.query)
+	if _, err := g.SetCurrentView(consoleSearchView); err != nil {
+		return err
+	}
+	if err := searchV.SetCursor(len(c.query), 0); err != nil {
+		return err
+	}
+
+	hostV, err := g.SetView(consoleHostView, consoleEnvWidth, 3, maxX-1, maxY-statusHeight-1)
+	if err != nil && err != gocui.ErrUnknownView {
+		return err
+	}
+	hostV.Title = consoleTitle()
+	if len(c.Envs[c.env].Columns) > 0 {
+		hostV.Title += " | " + Keys.help(string(BindColumns), "columns")
+	}
+	hostV.Clear()
+	_, height := hostV.Size()
+	start := 0
+	if c.cursor >= height {
+		start = c.cursor - height + 1
+	}
+	env := c.Envs[c.env]
+	columns := env.Columns
+	if c.HideColumns {
+		columns = nil
+	}
+	for i := start; i < len(c.hosts) && i < start+height; i++ {
+		details := hostDetails(c.hosts[i], columns, env.HostWidth, env.Notes[c.hosts[i]])
+		if i == c.cursor {
+			fmt.Fprintf(hostV, "%s\u001B[33;1m%s\u001B[0m%s\n", arrowColorized, c.hosts[i], details)
+			continue
+		}
+		fmt.Fprintf(hostV, "   %s%s\n", colorizeSelectedWord(c.hosts[i], c.query), details)
+	}
+
+	statusV, err := g.SetView(consoleStatusView, consoleEnvWidth, maxY-statusHeight, maxX-1, maxY-1)
+	if err != nil && err != gocui.ErrUnknownView {
+		return err
+	}
+	statusV.Title = i18n.T("Status")
+	statusV.Clear()
+	if c.Notice != "" {
+		fmt.Fprintln(statusV, Colorize(c.Notice, "yellow"))
+	}
+	fmt.Fprint(statusV, i18n.Sprintf("%d of %d hosts\n", len(c.hosts), len(env.Hosts)))
+	for _, line := range env.Status {
+		fmt.Fprintln(statusV, line)
+	}
+	if len(env.History) > 0 {
+		fmt.Fprint(statusV, i18n.Sprintf("Recent searches (%s/%s): %s\n", Keys.Label(BindHistoryPrev), Keys.Label(BindHistoryNext), strings.Join(env.History, ", ")))
+	}
+	return nil
+}
+
+// filter finds the hosts of the env match the query
+func (c *Console) filter() {
+	c.hosts = c.hosts[:0]
+	m := fold.New(strings.TrimSpace(c.query))
+	for _, h := range c.Envs[c.env].Hosts {
+		if h != "" && m.Contains(h) {
+			c.hosts = append(c.hosts, h)
+		}
+	}
+	sort.Strings(c.hosts)
+	if c.cursor >= len(c.hosts) {
+		c.cursor = 0
+	}
+}
+
+func (c *Console) registerKeyBind(g *gocui.Gui) error {
+	for _, r := range autoCompleteChars {
+		r := r
+		if err := g.SetKeybinding("", r, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+			c.SetQuery(c.query + string(r))
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	for _, key := range []gocui.Key{gocui.KeyBackspace, gocui.KeyBackspace2} {
+		if err := g.SetKeybinding("", key, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+			if q := []rune(c.query); len(q) > 0 {
+				c.SetQuery(string(q[:len(q)-1]))
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	// the hosts are a single column, left & right switch the env instead
+	bindings := map[Binding]func(){
+		BindUp: func() {
+			if c.cursor > 0 {
+				c.cursor--
+			}
+		},
+		BindDown: func() {
+			if c.cursor < len(c.hosts)-1 {
+				c.cursor++
+			}
+		},
+		BindNextEnv:     func() { c.switchEnv(1) },
+		BindRight:       func() { c.switchEnv(1) },
+		BindPrevEnv:     func() { c.switchEnv(-1) },
+		BindLeft:        func() { c.switchEnv(-1) },
+		BindHistoryPrev: func() { c.recall(1) },
+		BindHistoryNext: func() { c.recall(-1) },
+		BindColumns:     func() { c.HideColumns = !c.HideColumns },
+	}
+	for b, fn := range bindings {
+		fn := fn
+		if err := Keys.bind(g, "", b, func(g *gocui.Gui, v *gocui.View) error {
+			fn()
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	actions := map[Binding]Action{BindSelect: ActionSSH, BindRefresh: ActionRefresh}
+	for b, action := range actionBindings {
+		actions[b] = action
+	}
+	for b, action := range actions {
+		action := action
+		if err := Keys.bind(g, "", b, func(g *gocui.Gui, v *gocui.View) error {
+			return c.pick(action)
+		}); err != nil {
+			return err
+		}
+	}
+	return Keys.bind(g, "", BindQuit, quit)
+}
+
+// SetQuery types the query of the selected environment
+func (c *Console) SetQuery(query string) {
+	c.query = query
+	c.cursor = 0
+	c.filter()
+}
+
+func (c *Console) switchEnv(step int) {
+	c.env = (c.env + step + len(c.Envs)) % len(c.Envs)
+	c.historyPos = -1
+	c.cursor = 0
+	c.filter()
+}
+
+// recall shows the older or the newer query of the env history
+func (c *Console) recall(step int) {
+	history := c.Envs[c.env].History
+	pos := c.historyPos + step
+	if pos < 0 || pos >= len(history) {
+		return
+	}
+	c.historyPos = pos
+	c.SetQuery(history[pos])
+}
+
+// pick closes the console with the selected host, refreshing
+// the environment doesn't need any host
+func (c *Console) pick(action Action) error {
+	var host string
+	if c.cursor < len(c.hosts) {
+		host = c.hosts[c.cursor]
+	}
+	if host == "" && action != ActionRefresh {
+		return nil
+	}
+	c.result = ConsoleResult{Env: c.Envs[c.env].Name, Host: host, Action: action, Query: strings.TrimSpace(c.query)}
+	c.picked = true
+	return gocui.ErrQuit
+}
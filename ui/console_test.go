@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jroimartin/gocui"
+)
+
+func newTestConsole() *Console {
+	return &Console{
+		Envs: []ConsoleEnv{
+			{Name: "staging", Hosts: []string{"web-02", "db-01", "web-01"}, History: []string{"db", "web"}},
+			{Name: "prod", Hosts: []string{"api-01"}},
+		},
+		historyPos: -1,
+	}
+}
+
+func TestConsole_filter(t *testing.T) {
+	c := newTestConsole()
+	c.SetQuery("web")
+	if want := []string{"web-01", "web-02"}; !reflect.DeepEqual(c.hosts, want) {
+		t.Errorf("filter() = %v, want %v", c.hosts, want)
+	}
+
+	c.switchEnv(1)
+	if len(c.hosts) != 0 {
+		t.Errorf("filter() on prod = %v, want no host", c.hosts)
+	}
+	c.switchEnv(1)
+	if c.Envs[c.env].Name != "staging" {
+		t.Errorf("switchEnv() = %s, want wrapping into staging", c.Envs[c.env].Name)
+	}
+}
+
+func TestConsole_recall(t *testing.T) {
+	c := newTestConsole()
+	c.recall(1)
+	if c.query != "db" {
+		t.Errorf("recall() = %q, want the latest query", c.query)
+	}
+	c.recall(1)
+	c.recall(1)
+	if c.query != "web" {
+		t.Errorf("recall() = %q, want staying on the oldest query", c.query)
+	}
+	c.recall(-1)
+	if c.query != "db" {
+		t.Errorf("recall() = %q, want the newer query", c.query)
+	}
+}
+
+func TestConsole_pick(t *testing.T) {
+	c := newTestConsole()
+	c.SetQuery("web")
+	c.cursor = 1
+	if err := c.pick(ActionExec); err != gocui.ErrQuit {
+		t.Fatalf("pick() error = %v, want ErrQuit", err)
+	}
+	want := ConsoleResult{Env: "staging", Host: "web-02", Action: ActionExec, Query: "web"}
+	if !c.picked || c.result != want {
+		t.Errorf("pick() = %+v, want %+v", c.result, want)
+	}
+
+	c = newTestConsole()
+	c.SetQuery("nothing")
+	if err := c.pick(ActionSSH); err != nil || c.picked {
+		t.Errorf("pick() without host = %v, want no pick", err)
+	}
+	if err := c.pick(ActionRefresh); err != gocui.ErrQuit {
+		t.Errorf("pick() refresh error = %v, want ErrQuit", err)
+	}
+}
+
+func TestConsole_SetEnvs(t *testing.T) {
+	c := newTestConsole()
+	c.switchEnv(1)
+	c.Queue(func() {
+		c.SetEnvs([]ConsoleEnv{{Name: "dev"}, {Name: "prod", Hosts: []string{"api-01", "api-02"}}})
+	})
+	if len(c.Envs) != 2 || c.Envs[0].Name != "staging" {
+		t.Fatalf("Queue() = %v, want waiting the console to be shown", c.Envs)
+	}
+
+	c.flush()
+	if c.Envs[c.env].Name != "prod" {
+		t.Errorf("SetEnvs() selects %s, want keeping prod", c.Envs[c.env].Name)
+	}
+	if want := []string{"api-01", "api-02"}; !reflect.DeepEqual(c.hosts, want) {
+		t.Errorf("SetEnvs() hosts = %v, want %v", c.hosts, want)
+	}
+
+	c.SetEnvs([]ConsoleEnv{{Name: "dev"}})
+	if c.env != 0 {
+		t.Errorf("SetEnvs() selects %d, want the first env once the selected one is removed", c.env)
+	}
+}
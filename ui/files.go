@@ -0,0 +1,424 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/adzimzf/tpot/i18n"
+	"github.com/jroimartin/gocui"
+)
+
+// the views of the file browser
+const (
+	filesPaneView   = "files_pane_"
+	filesStatusView = "files_status"
+	filesPromptView = "files_prompt"
+)
+
+// parentDir is the entry going up to the parent directory
+const parentDir = ".."
+
+// FileEntry is a file or a directory shown by the file browser
+type FileEntry struct {
+	Name string
+	Dir  bool
+	Size int64
+}
+
+// FileSource lists & changes the files of a file browser pane,
+// such as the local disk or the node
+type FileSource interface {
+	// Name is the title of the pane such as local or the host
+	Name() string
+
+	// List returns the absolute dir along with its files,
+	// the empty dir is the starting directory
+	List(dir string) (string, []FileEntry, error)
+
+	// Join returns the path of the name inside the dir
+	Join(dir, name string) string
+
+	Rename(from, to string) error
+	Remove(path string) error
+}
+
+// FileCopier copies the src file or directory into the dir of the other pane,
+// upload is true when the src is the local one
+type FileCopier func(src, dstDir string, upload bool) error
+
+// FileBrowser is the two-pane UI of the local & the node files,
+// the selected file is copied into the directory of the other pane
+type FileBrowser struct {
+	panes [2]*filePane
+	focus int
+	copy  FileCopier
+
+	// status is the result of the latest action
+	status string
+
+	// renaming is the file being renamed by the prompt, empty when there's none
+	renaming string
+
+	// deleting is the file waiting for y to be deleted, empty when there's none
+	deleting string
+
+	// mu guards copying, only one copy runs at once
+	mu      sync.Mutex
+	copying bool
+
+	// update runs the fn by the goroutine showing the UI
+	update func(fn func())
+}
+
+type filePane struct {
+	source FileSource
+	local  bool
+	dir    string
+	files  []FileEntry
+	cursor int
+}
+
+// NewFileBrowser creates the file browser of the local & the remote files,
+// the panes start in their starting directory
+func NewFileBrowser(local, remote FileSource, copier FileCopier) *FileBrowser {
+	return &FileBrowser{
+		panes: [2]*filePane{{source: local, local: true}, {source: remote}},
+		copy:  copier,
+	}
+}
+
+// Run shows the file browser until it's quit
+func (b *FileBrowser) Run() error {
+	if Plain {
+		return i18n.Error("the file browser needs the full-screen terminal, use the scp action in the plain mode")
+	}
+	for _, p := range b.panes {
+		if err := b.load(p, ""); err != nil {
+			return i18n.Errorf("failed to list the files of %s, error: %v", p.source.Name(), err)
+		}
+	}
+
+	g, err := gocui.NewGui(gocui.OutputNormal)
+	if err != nil {
+		return err
+	}
+	defer g.Close()
+	b.update = func(fn func()) {
+		g.Update(func(*gocui.Gui) error {
+			fn()
+			return nil
+		})
+	}
+
+	g.SetManagerFunc(b.layout)
+	if err := b.registerKeyBind(g); err != nil {
+		return err
+	}
+	if err := g.MainLoop(); err != nil && err != gocui.ErrQuit {
+		return err
+	}
+	return nil
+}
+
+func (b *FileBrowser) layout(g *gocui.Gui) error {
+	maxX, maxY := g.Size()
+	width := maxX / 2
+	for i, p := range b.panes {
+		v, err := g.SetView(filesPaneView+fmt.Sprint(i), i*width, 0, i*width+width-1, maxY-4)
+		if err != nil && err != gocui.ErrUnknownView {
+			return err
+		}
+		v.Title = fmt.Sprintf("%s:%s", p.source.Name(), p.dir)
+		if i == b.focus {
+			v.Title = "> " + v.Title
+		}
+		v.Clear()
+		_, height := v.Size()
+		start := 0
+		if p.cursor >= height {
+			start = p.cursor - height + 1
+		}
+		for j := start; j < len(p.files) && j < start+height; j++ {
+			f := p.files[j]
+			name, size := f.Name, fileSize(f.Size)
+			if f.Dir {
+				name, size = name+"/", ""
+			}
+			if j == p.cursor && i == b.focus {
+				fmt.Fprintf(v, "%s\u001B[33;1m%s\u001B[0m  %s\n", arrowColorized, name, size)
+				continue
+			}
+			fmt.Fprintf(v, "   %s  %s\n", name, size)
+		}
+	}
+
+	statusV, err := g.SetView(filesStatusView, 0, maxY-3, maxX-1, maxY-1)
+	if err != nil && err != gocui.ErrUnknownView {
+		return err
+	}
+	statusV.Title = Keys.help(string(BindSelect), "open", string(BindNextPane), "switch", string(BindCopy), "copy",
+		string(BindRename), "rename", string(BindDelete), "delete", string(BindRefresh), "refresh", string(BindQuit), "quit")
+	statusV.Clear()
+	fmt.Fprint(statusV, b.status)
+
+	if b.renaming == "" {
+		if err := g.DeleteView(filesPromptView); err != nil && err != gocui.ErrUnknownView {
+			return err
+		}
+		_, err := g.SetCurrentView(filesPaneView + fmt.Sprint(b.focus))
+		return err
+	}
+	promptV, err := g.SetView(filesPromptView, maxX/6, maxY/2-1, maxX-maxX/6, maxY/2+1)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		promptV.Editable = true
+		promptV.Title = i18n.Sprintf("Rename %s", b.renaming)
+		fmt.Fprint(promptV, b.renaming)
+		if err := promptV.SetCursor(len(b.renaming), 0); err != nil {
+			return err
+		}
+	}
+	_, err = g.SetCurrentView(filesPromptView)
+	return err
+}
+
+// registerKeyBind binds the keys of the panes & the prompt separately,
+// the keys of the panes are typed into the prompt while renaming
+func (b *FileBrowser) registerKeyBind(g *gocui.Gui) error {
+	bindings := map[Binding]func(){
+		BindUp:       func() { b.move(-1) },
+		BindDown:     func() { b.move(1) },
+		BindSelect:   b.open,
+		BindNextPane: func() { b.focus = 1 - b.focus },
+		BindRefresh:  b.refresh,
+		BindCopy:     b.startCopy,
+		BindRename:   b.startRename,
+		BindDelete:   b.startDelete,
+	}
+	for i := range b.panes {
+		view := filesPaneView + fmt.Sprint(i)
+		for binding, fn := range bindings {
+			fn := fn
+			if err := Keys.bind(g, view, binding, func(g *gocui.Gui, v *gocui.View) error {
+				fn()
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+		for _, r := range []rune{'y', 'Y', 'n', 'N'} {
+			confirmed := r == 'y' || r == 'Y'
+			if err := g.SetKeybinding(view, r, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+				b.confirmDelete(confirmed)
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+		if err := Keys.bind(g, view, BindQuit, quit); err != nil {
+			return err
+		}
+	}
+
+	if err := Keys.bind(g, filesPromptView, BindSelect, func(g *gocui.Gui, v *gocui.View) error {
+		b.rename(strings.TrimSpace(v.Buffer()))
+		return nil
+	}); err != nil {
+		return err
+	}
+	return Keys.bind(g, filesPromptView, BindQuit, func(g *gocui.Gui, v *gocui.View) error {
+		b.renaming = ""
+		return nil
+	})
+}
+
+// load lists the dir of the pane, the parent entry comes first
+// followed by the directories then the files by the name
+func (b *FileBrowser) load(p *filePane, dir string) error {
+	dir, files, err := p.source.List(dir)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].Dir != files[j].Dir {
+			return files[i].Dir
+		}
+		return files[i].Name < files[j].Name
+	})
+	p.dir = dir
+	p.files = append([]FileEntry{{Name: parentDir, Dir: true}}, files...)
+	if p.cursor >= len(p.files) {
+		p.cursor = len(p.files) - 1
+	}
+	return nil
+}
+
+// reload lists the pane again, the error is shown by the status
+func (b *FileBrowser) reload(p *filePane) {
+	if err := b.load(p, p.dir); err != nil {
+		b.status = i18n.Sprintf("failed to list the files of %s, error: %v", p.source.Name(), err)
+	}
+}
+
+func (b *FileBrowser) move(step int) {
+	p := b.panes[b.focus]
+	p.cursor += step
+	if p.cursor >= len(p.files) {
+		p.cursor = len(p.files) - 1
+	}
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+	b.deleting = ""
+}
+
+// selected returns the file under the cursor of the focused pane,
+// false for the parent entry
+func (b *FileBrowser) selected() (FileEntry, bool) {
+	p := b.panes[b.focus]
+	if p.cursor >= len(p.files) || p.files[p.cursor].Name == parentDir {
+		return FileEntry{}, false
+	}
+	return p.files[p.cursor], true
+}
+
+// open enters the selected directory
+func (b *FileBrowser) open() {
+	p := b.panes[b.focus]
+	if p.cursor >= len(p.files) || !p.files[p.cursor].Dir {
+		return
+	}
+	cursor := p.cursor
+	p.cursor = 0
+	if err := b.load(p, p.source.Join(p.dir, p.files[cursor].Name)); err != nil {
+		p.cursor = cursor
+		b.status = i18n.Sprintf("failed to open %s, error: %v", p.files[cursor].Name, err)
+		return
+	}
+	b.status = ""
+}
+
+func (b *FileBrowser) refresh() {
+	b.status = ""
+	for _, p := range b.panes {
+		b.reload(p)
+	}
+}
+
+// startCopy copies the selected file into the directory of the other pane
+// in the background, the other pane is listed again once it's copied
+func (b *FileBrowser) startCopy() {
+	f, ok := b.selected()
+	if !ok {
+		return
+	}
+	b.mu.Lock()
+	if b.copying {
+		b.mu.Unlock()
+		b.status = i18n.T("the previous copy is still running")
+		return
+	}
+	b.copying = true
+	b.mu.Unlock()
+
+	from, to := b.panes[b.focus], b.panes[1-b.focus]
+	src := from.source.Join(from.dir, f.Name)
+	dstDir := to.dir
+	b.status = i18n.Sprintf("copying %s into %s:%s", f.Name, to.source.Name(), dstDir)
+	go func() {
+		err := b.copy(src, dstDir, from.local)
+		b.mu.Lock()
+		b.copying = false
+		b.mu.Unlock()
+		b.update(func() {
+			if err != nil {
+				b.status = i18n.Sprintf("failed to copy %s, error: %v", f.Name, err)
+				return
+			}
+			b.status = i18n.Sprintf("%s is copied into %s:%s", f.Name, to.source.Name(), dstDir)
+			if to.dir == dstDir {
+				b.reload(to)
+			}
+		})
+	}()
+}
+
+// startRename shows the prompt of the new name
+func (b *FileBrowser) startRename() {
+	if f, ok := b.selected(); ok {
+		b.renaming = f.Name
+		b.deleting = ""
+	}
+}
+
+// rename renames the file of the prompt into the name inside the same directory
+func (b *FileBrowser) rename(name string) {
+	old := b.renaming
+	b.renaming = ""
+	if name == "" || name == old {
+		return
+	}
+	if strings.ContainsAny(name, `/\`) {
+		b.status = i18n.Sprintf("invalid name %s, it can't be moved into the other directory", name)
+		return
+	}
+	p := b.panes[b.focus]
+	if err := p.source.Rename(p.source.Join(p.dir, old), p.source.Join(p.dir, name)); err != nil {
+		b.status = i18n.Sprintf("failed to rename %s, error: %v", old, err)
+		return
+	}
+	b.status = i18n.Sprintf("%s is renamed into %s", old, name)
+	b.reload(p)
+}
+
+// startDelete asks to confirm deleting the selected file by y
+func (b *FileBrowser) startDelete() {
+	f, ok := b.selected()
+	if !ok {
+		return
+	}
+	b.deleting = f.Name
+	what := i18n.T("file")
+	if f.Dir {
+		what = i18n.T("directory along with its files")
+	}
+	b.status = i18n.Sprintf("delete the %s %s? [y/n]", what, f.Name)
+}
+
+// confirmDelete deletes the file waiting for the confirmation
+func (b *FileBrowser) confirmDelete(confirmed bool) {
+	name := b.deleting
+	if name == "" {
+		return
+	}
+	b.deleting = ""
+	if !confirmed {
+		b.status = ""
+		return
+	}
+	p := b.panes[b.focus]
+	if err := p.source.Remove(p.source.Join(p.dir, name)); err != nil {
+		b.status = i18n.Sprintf("failed to delete %s, error: %v", name, err)
+		return
+	}
+	b.status = i18n.Sprintf("%s is deleted", name)
+	b.reload(p)
+}
+
+// fileSize returns the short size such as 1.5K
+func fileSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%c", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,181 @@
+package ui
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memFiles is the file source of the paths, the directories end with /
+type memFiles struct {
+	name  string
+	home  string
+	paths map[string]int64
+}
+
+func (m *memFiles) Name() string { return m.name }
+
+func (m *memFiles) List(dir string) (string, []FileEntry, error) {
+	if dir == "" {
+		dir = m.home
+	}
+	if _, ok := m.paths[dir+"/"]; !ok && dir != "/" {
+		return "", nil, fmt.Errorf("%s is not found", dir)
+	}
+	var res []FileEntry
+	for p, size := range m.paths {
+		name := strings.TrimSuffix(p, "/")
+		if path.Dir(name) != dir || name == dir {
+			continue
+		}
+		res = append(res, FileEntry{Name: path.Base(name), Dir: strings.HasSuffix(p, "/"), Size: size})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name > res[j].Name })
+	return dir, res, nil
+}
+
+func (m *memFiles) Join(dir, name string) string { return path.Join(dir, name) }
+
+func (m *memFiles) Rename(from, to string) error {
+	size, ok := m.paths[from]
+	if !ok {
+		return fmt.Errorf("%s is not found", from)
+	}
+	delete(m.paths, from)
+	m.paths[to] = size
+	return nil
+}
+
+func (m *memFiles) Remove(p string) error {
+	for k := range m.paths {
+		if k == p || strings.HasPrefix(k, p+"/") {
+			delete(m.paths, k)
+		}
+	}
+	return nil
+}
+
+func newTestBrowser(t *testing.T) (*FileBrowser, *memFiles, *[]string) {
+	local := &memFiles{name: "local", home: "/work", paths: map[string]int64{
+		"/work/": 0, "/work/b.txt": 10, "/work/a.txt": 2048, "/work/logs/": 0, "/work/logs/app.log": 1,
+	}}
+	remote := &memFiles{name: "web-01", home: "/home/me", paths: map[string]int64{
+		"/home/": 0, "/home/me/": 0, "/home/me/notes.txt": 5,
+	}}
+	var copied []string
+	b := NewFileBrowser(local, remote, func(src, dstDir string, upload bool) error {
+		copied = append(copied, fmt.Sprintf("%s -> %s upload=%t", src, dstDir, upload))
+		return nil
+	})
+	for _, p := range b.panes {
+		require.NoError(t, b.load(p, ""))
+	}
+	return b, local, &copied
+}
+
+// waitCopy runs the update of the copy like the UI goroutine
+func waitCopy(t *testing.T, b *FileBrowser) {
+	updates := make(chan func(), 1)
+	b.update = func(fn func()) { updates <- fn }
+	b.startCopy()
+	select {
+	case fn := <-updates:
+		fn()
+	case <-time.After(time.Second):
+		t.Fatal("the copy isn't done")
+	}
+}
+
+func names(files []FileEntry) []string {
+	res := make([]string, len(files))
+	for i, f := range files {
+		res[i] = f.Name
+	}
+	return res
+}
+
+func TestFileBrowser_navigate(t *testing.T) {
+	b, _, _ := newTestBrowser(t)
+	local := b.panes[0]
+	assert.Equal(t, "/work", local.dir)
+	assert.Equal(t, []string{"..", "logs", "a.txt", "b.txt"}, names(local.files))
+
+	b.move(1)
+	b.open()
+	assert.Equal(t, "/work/logs", local.dir)
+	assert.Equal(t, []string{"..", "app.log"}, names(local.files))
+	assert.Equal(t, 0, local.cursor)
+
+	b.open()
+	assert.Equal(t, "/work", local.dir)
+
+	// the file isn't opened
+	b.move(2)
+	b.open()
+	assert.Equal(t, "/work", local.dir)
+
+	b.move(-10)
+	assert.Equal(t, 0, local.cursor)
+}
+
+func TestFileBrowser_copy(t *testing.T) {
+	b, _, copied := newTestBrowser(t)
+
+	// the parent entry isn't copied
+	b.startCopy()
+	assert.Empty(t, *copied)
+
+	b.move(2)
+	waitCopy(t, b)
+	assert.Equal(t, "a.txt is copied into web-01:/home/me", b.status)
+
+	b.focus = 1
+	b.move(1)
+	waitCopy(t, b)
+	assert.Equal(t, []string{
+		"/work/a.txt -> /home/me upload=true",
+		"/home/me/notes.txt -> /work upload=false",
+	}, *copied)
+}
+
+func TestFileBrowser_renameDelete(t *testing.T) {
+	b, local, _ := newTestBrowser(t)
+	b.move(2)
+	b.startRename()
+	assert.Equal(t, "a.txt", b.renaming)
+	b.rename("c.txt")
+	assert.Empty(t, b.renaming)
+	assert.Equal(t, []string{"..", "logs", "b.txt", "c.txt"}, names(b.panes[0].files))
+
+	b.startRename()
+	b.rename("../c.txt")
+	assert.Contains(t, local.paths, "/work/b.txt")
+
+	b.move(-1)
+	b.startDelete()
+	assert.Equal(t, "logs", b.deleting)
+	b.confirmDelete(false)
+	assert.Contains(t, local.paths, "/work/logs/")
+
+	b.startDelete()
+	b.confirmDelete(true)
+	assert.NotContains(t, local.paths, "/work/logs/")
+	assert.NotContains(t, local.paths, "/work/logs/app.log")
+	assert.Equal(t, []string{"..", "b.txt", "c.txt"}, names(b.panes[0].files))
+
+	// y without the pending delete does nothing
+	b.confirmDelete(true)
+	assert.Contains(t, local.paths, "/work/b.txt")
+}
+
+func Test_fileSize(t *testing.T) {
+	assert.Equal(t, "10B", fileSize(10))
+	assert.Equal(t, "1.5K", fileSize(1536))
+	assert.Equal(t, "2.0M", fileSize(2<<20))
+}
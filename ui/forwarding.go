@@ -11,6 +11,10 @@ import (
 )
 
 func NewForwarding(list []*config.ForwardingNode) {
+	if Plain {
+		plainForwarding(list)
+		return
+	}
 	g, err := gocui.NewGui(gocui.OutputNormal)
 	if err != nil {
 		log.Panicln(err)
@@ -62,7 +66,7 @@ func NewForwarding(list []*config.ForwardingNode) {
 		return nil
 	})
 
-	if err := g.SetKeybinding("", gocui.KeyCtrlC, gocui.ModNone, quit); err != nil {
+	if err := Keys.bind(g, "", BindQuit, quit); err != nil {
 		log.Panicln(err)
 	}
 
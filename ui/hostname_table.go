@@ -8,6 +8,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/adzimzf/tpot/fold"
+	"github.com/adzimzf/tpot/i18n"
 	"github.com/jroimartin/gocui"
 )
 
@@ -15,17 +17,91 @@ import (
 // maxScreenY maximum screen high to show table UI
 var maxScreenX, maxScreenY int
 
+// hostOrder is the position of every host of the Ordered picker,
+// nil sorts the hosts by name
+var hostOrder map[string]int
+
+// hostNotes is the notes of the picker hosts by the hostname
+var hostNotes map[string]string
+
 const (
 	// dividerChar is a character to create table
 	dividerChar = '│'
 
 	// arrowColorized is a character ( > ) to indicate the current selected item
 	arrowColorized = "\u001B[33;1m" + " > " + "\u001B[0m"
+
+	// noteColor starts the note next to the host, the rest of
+	// the cell from it isn't the hostname
+	noteColor = "\u001B[31;1m"
 )
 
 // GetSelectedHost will prompt user an table UI, and let the user
 // select node list by typing or moving with an arrow
 func GetSelectedHost(hosts []string) string {
+	host, _ := (&Picker{Hosts: hosts}).Run()
+	return host
+}
+
+// Picker is the table UI to pick a host
+type Picker struct {
+	Hosts []string
+
+	// History is the previous queries, the latest first. The latest
+	// query is typed once the picker shows, CTRL+P & CTRL+N go through it
+	History []string
+
+	// Actions enables the action keys other than ENTER
+	Actions bool
+
+	// Ordered shows the hosts in the order of Hosts, such as sorted
+	// by the IP, instead of sorting them by name
+	Ordered bool
+
+	// Sorts is the orders BindSort switches through, it takes precedence
+	// over Ordered. Sort is the name of the shown one, it's the one
+	// chosen by the user once a host is picked
+	Sorts []Sort
+	Sort  string
+
+	// Notes is shown next to the hosts, such as the failed connections
+	Notes map[string]string
+
+	// Columns is shown next to the hosts padded to the HostWidth,
+	// BindColumns toggles them
+	Columns   []Column
+	HostWidth int
+
+	// HideColumns hides the Columns until BindColumns shows them,
+	// it's the choice of the user once a host is picked
+	HideColumns bool
+
+	// Header is shown on top of the picker as a badge of the HeaderColor
+	Header      string
+	HeaderColor string
+
+	// Query is the typed query once a host is picked
+	Query string
+}
+
+// Run shows the picker until a host is picked along with the action,
+// the host is empty when the user quits without picking
+func (p *Picker) Run() (string, Action) {
+	hostOrder = nil
+	if p.Ordered {
+		hostOrder = make(map[string]int, len(p.Hosts))
+		for i, host := range p.Hosts {
+			hostOrder[host] = i
+		}
+	}
+	if len(p.Sorts) > 0 {
+		p.Sort = p.applySort(p.Sort)
+	}
+	hostNotes = p.Notes
+	pickerColumns, hostWidth, showColumns = p.Columns, p.HostWidth, !p.HideColumns
+	if Plain {
+		return p.runPlain()
+	}
 
 	g, err := gocui.NewGui(gocui.OutputNormal)
 	if err != nil {
@@ -39,43 +115,86 @@ func GetSelectedHost(hosts []string) string {
 	g.Cursor = true
 	g.SelFgColor = gocui.ColorGreen
 
-	if err := newLayout(g).register(hosts); err != nil {
+	var query string
+	if len(p.History) > 0 {
+		query = p.History[0]
+	}
+
+	title := i18n.T("Type to Search or Arrow to Navigate")
+	if p.Actions {
+		title = actionTitle()
+	}
+	if len(p.History) > 0 {
+		title += " | " + i18n.Sprintf("%s/%s history", Keys.Label(BindHistoryPrev), Keys.Label(BindHistoryNext))
+	}
+	if len(p.Columns) > 0 {
+		title += " | " + Keys.help(string(BindColumns), "columns")
+	}
+	s := newSearch(g, p.Hosts)
+	s.apply(query, filterHosts(query, s.hosts))
+	l := newLayout(g, s)
+	l.header = p.Header
+	l.headerColor = p.HeaderColor
+	if err := l.register(p.sortTitle(title), query); err != nil {
 		log.Panicln(err)
 	}
 
-	if err := g.SetKeybinding("", gocui.KeyCtrlC, gocui.ModNone, quit); err != nil {
+	if err := Keys.bind(g, "", BindQuit, quit); err != nil {
 		log.Panicln(err)
 	}
 
-	if err = NewArrowNav(g).registerArrowNav(); err != nil {
+	if err = NewArrowNav(g, s).registerArrowNav(); err != nil {
 		log.Panicln(err)
 	}
 
-	s := newSearch(g, hosts)
 	if err := s.register(); err != nil {
 		log.Panicln(err)
 	}
+	if err := s.registerHistory(p.History); err != nil {
+		log.Panicln(err)
+	}
+	if err := s.registerColumns(); err != nil {
+		log.Panicln(err)
+	}
+	if err := p.registerSort(s, title); err != nil {
+		log.Panicln(err)
+	}
 
 	var result string
-	if err := newKeyEnterBinding(g).register(&result); err != nil {
+	action := ActionSSH
+	k := newKeyEnterBinding(g)
+	if err := k.register(BindSelect, &result, nil); err != nil {
 		log.Panicln(err)
 	}
+	if p.Actions {
+		for b, a := range actionBindings {
+			if err := k.register(b, &result, func(a Action) func() {
+				return func() { action = a }
+			}(a)); err != nil {
+				log.Panicln(err)
+			}
+		}
+	}
 
 	if err := g.MainLoop(); err != nil && err != gocui.ErrQuit {
 		log.Panicln(err)
 	}
-	return result
+	if v, err := g.View(searchInputView); err == nil {
+		p.Query = strings.TrimSpace(v.Buffer())
+	}
+	p.HideColumns = !showColumns
+	return result, action
 
 }
 
 func lookup(keyword string, datum []string) map[string]stringResult {
 	res := make(map[string]stringResult, len(datum))
+	m := fold.New(strings.TrimSpace(keyword))
 	for _, data := range datum {
 		if data == "" {
 			continue
 		}
-		keyword = strings.TrimSpace(keyword)
-		if strings.Contains(data, keyword) {
+		if m.Contains(data) {
 			res[data] = stringResult{
 				FormattedData: data,
 			}
@@ -94,32 +213,58 @@ type stringResult struct {
 }
 
 // formatResult colorize & create table to be shown as a string
-// d is a list of node
+// hosts is the sorted list of node
 // keyword is a keyword to be colorize
 // ap is the current arrow position
-func formatResult(d map[string]stringResult, keyword string, ap arrowPos) string {
-	screenMaxY := maxScreenY - 3
-	var res string
-	var y, x int
-	newList := make([]string, screenMaxY)
-	for _, key := range sortKey(d) {
+// only the page of the table columns around the arrow fitting the
+// screen is built, the massive list costs the same as a few hosts
+func formatResult(hosts []string, keyword string, ap arrowPos) string {
+	screenMaxY := screenRows()
+	var y int
+
+	// the cells are padded by the columns, otherwise by the legacy width
+	var columns []Column
+	width := 60
+	if columnsShown() {
+		columns, width = pickerColumns, 0
+	}
+
+	cols := visibleColumns(len(hosts), screenMaxY)
+	x := ap.X / cols * cols
+	start := x * screenMaxY
+	end := start + cols*screenMaxY
+	if end > len(hosts) || end < 0 {
+		end = len(hosts)
+	}
+	if start > end {
+		start = end
+	}
+
+	// the rows are built along the columns, hence the builders
+	// instead of the string concatenation for the massive list
+	newList := make([]strings.Builder, screenMaxY)
+	for _, key := range hosts[start:end] {
 		prefix := "   "
-		formattedHost := colorizeSelectedWord(d[key].FormattedData, keyword)
+		formattedHost := colorizeSelectedWord(key, keyword)
 		if y == ap.Y && x == ap.X {
 			prefix = arrowColorized
-			formattedHost = fmt.Sprintf("\u001B[33;1m%s\u001B[0m", d[key].FormattedData)
+			formattedHost = fmt.Sprintf("\u001B[33;1m%s\u001B[0m", key)
 		}
-		newList[y] += fmt.Sprintf("%s%-60s%s", prefix, formattedHost, string(dividerChar))
+		formattedHost += hostDetails(key, columns, hostWidth, hostNotes[key])
+		fmt.Fprintf(&newList[y], "%s%-*s%c", prefix, width, formattedHost, dividerChar)
 		y++
 		if y >= screenMaxY {
 			x++
 			y = 0
 		}
 	}
-	for _, s := range newList {
-		res += s + "\n"
+
+	var res strings.Builder
+	for i := range newList {
+		res.WriteString(newList[i].String())
+		res.WriteByte('\n')
 	}
-	return res
+	return res.String()
 }
 
 // arrowPos contain the X and Y of array position in the table list
@@ -127,12 +272,17 @@ type arrowPos struct {
 	X, Y int
 }
 
-// sortKey sort the table item from A-Z to improve readability
+// sortKey sort the table item from A-Z to improve readability,
+// or in the order of the hosts of the Ordered picker
 func sortKey(d map[string]stringResult) []string {
-	var res []string
+	res := make([]string, 0, len(d))
 	for s := range d {
 		res = append(res, s)
 	}
+	if hostOrder != nil {
+		sort.Slice(res, func(i, j int) bool { return hostOrder[res[i]] < hostOrder[res[j]] })
+		return res
+	}
 	sort.Strings(res)
 	return res
 }
@@ -146,36 +296,43 @@ func cleanText(s string) string {
 	return s
 }
 
-func colorizeSelectedWord(text, keyword string) string {
-	key := strings.TrimSpace(keyword)
-	return strings.Replace(text,
-		strings.TrimSpace(key),
-		fmt.Sprintf("\u001B[37;7m%s\u001B[0m", key), 1)
-}
-
-func findArrowPos(res string) (ap arrowPos, data []string) {
-	for i, s := range strings.Split(res, "\n") {
-		for j, q := range strings.Split(s, string(dividerChar)) {
-			if strings.Contains(q, ">") {
-				ap.X = j
-				ap.Y = i
-			}
-			data = append(data, strings.Trim(strings.TrimSpace(q), ">"))
+// cellHost returns the hostname of the cell. The screen buffer has no
+// colors, but the hostname has no space unlike the columns & the note
+// after it, while the cleaned text is cut by their colors instead
+func cellHost(cell string) string {
+	var host string
+	for _, field := range strings.Fields(strings.Replace(cell, ">", "", 1)) {
+		// the arrow is colored apart from the hostname
+		if host = cleanText(field); host != "" {
+			break
+		}
+	}
+	for _, color := range []string{noteColor, columnColor} {
+		if i := strings.Index(host, color); i >= 0 {
+			host = host[:i]
 		}
 	}
-	return
+	return host
 }
 
-type maxXY struct {
-	X, Y int
+// hasArrow returns true if the cell is the selected one
+func hasArrow(cell string) bool {
+	return strings.HasPrefix(cleanText(cell), ">")
 }
 
-func findMaxXY(s string) (m maxXY) {
-	return maxXY{
-		X: len(strings.Split(s, string(dividerChar))),
-		Y: len(strings.Split(strings.Trim(s, "\n"), "\n")),
+// colorizeSelectedWord highlights the part of the text matching the keyword,
+// it's the part as it's in the text whatever the case of the keyword
+func colorizeSelectedWord(text, keyword string) string {
+	key := strings.TrimSpace(keyword)
+	start, end, ok := fold.New(key).Index(text)
+	if !ok || start == end {
+		return text
 	}
+	return text[:start] + "\u001B[37;7m" + text[start:end] + "\u001B[0m" + text[end:]
+}
 
+type maxXY struct {
+	X, Y int
 }
 
 func Debug(i ...interface{}) {
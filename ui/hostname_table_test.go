@@ -1 +1,119 @@
 package ui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_sortKey(t *testing.T) {
+	defer func() { hostOrder = nil }()
+	hosts := []string{"web-10", "db-01", "web-02"}
+	d := lookup("", hosts)
+
+	if got, want := sortKey(d), []string{"db-01", "web-02", "web-10"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sortKey() = %v, want %v", got, want)
+	}
+
+	setPlainInput(t, "\n")
+	Plain = true
+	defer func() { Plain = false }()
+	(&Picker{Hosts: hosts, Ordered: true}).Run()
+	if got := sortKey(d); !reflect.DeepEqual(got, hosts) {
+		t.Errorf("sortKey() of the ordered picker = %v, want %v", got, hosts)
+	}
+}
+
+func Test_formatResult_notes(t *testing.T) {
+	maxScreenY = 10
+	hostNotes = map[string]string{"web-01": "failed last 3 attempts"}
+	defer func() { hostNotes = nil }()
+
+	res := formatResult([]string{"web-01", "web-02"}, "", arrowPos{})
+	if !strings.Contains(res, "failed last 3 attempts") {
+		t.Errorf("formatResult() = %q, want the note of web-01", res)
+	}
+	if got := (&keyEnterBinding{}).findResult(res); got != "web-01" {
+		t.Errorf("findResult() = %q, want web-01", got)
+	}
+}
+
+func Test_formatResult_columns(t *testing.T) {
+	maxScreenY = 10
+	pickerColumns = []Column{
+		{Width: 8, Values: map[string]string{"web-01": "10.0.0.1", "web-02": "10.0.0.2"}},
+		{Width: 6, Values: map[string]string{"web-01": "payments"}},
+	}
+	hostWidth, showColumns = 10, true
+	defer func() { pickerColumns, hostWidth, showColumns = nil, 0, false }()
+
+	res := formatResult([]string{"web-01", "web-02"}, "", arrowPos{})
+	if !strings.Contains(res, "web-02    "+columnColor+"10.0.0.2       ") {
+		t.Errorf("formatResult() = %q, want the columns of web-02 padded to their width", res)
+	}
+	if !strings.Contains(res, "payme…") {
+		t.Errorf("formatResult() = %q, want the long value cut", res)
+	}
+	if got := (&keyEnterBinding{}).findResult(res); got != "web-01" {
+		t.Errorf("findResult() = %q, want web-01", got)
+	}
+
+	// the screen buffer has no colors
+	buffer := strings.NewReplacer(columnColor, "", "\u001B[33;1m", "", "\u001B[0m", "").Replace(res)
+	if got := (&keyEnterBinding{}).findResult(buffer); got != "web-01" {
+		t.Errorf("findResult() of the buffer = %q, want web-01", got)
+	}
+
+	showColumns = false
+	if res := formatResult([]string{"web-01"}, "", arrowPos{}); strings.Contains(res, "10.0.0.1") {
+		t.Errorf("formatResult() = %q, want the columns hidden", res)
+	}
+}
+
+func Test_formatResult_window(t *testing.T) {
+	defer func(x, y int) { maxScreenX, maxScreenY = x, y }(maxScreenX, maxScreenY)
+	maxScreenX, maxScreenY = 140, 13
+	hosts := benchHosts(20000)
+
+	// 2 columns of 10 rows fit the screen
+	res := formatResult(hosts, "", arrowPos{X: 3, Y: 4})
+	if strings.Contains(res, "web-00019") || !strings.Contains(res, "web-00020") || !strings.Contains(res, "web-00039") || strings.Contains(res, "web-00040") {
+		t.Errorf("formatResult() = %q, want only the page of web-00020 to web-00039", res)
+	}
+	if got := (&keyEnterBinding{}).findResult(res); got != "web-00034" {
+		t.Errorf("findResult() = %q, want web-00034", got)
+	}
+}
+
+func Test_search_move(t *testing.T) {
+	defer func(y int) { maxScreenY = y }(maxScreenY)
+	maxScreenY = 5
+	s := newSearch(nil, []string{"web-01", "web-02", "web-03", "db-01"})
+	s.apply("web", filterHosts("web", s.hosts))
+	a := NewArrowNav(nil, s)
+
+	// 2 rows, the second column has web-03 only
+	s.move(NavDown, a)
+	s.move(NavRight, a)
+	if want := (arrowPos{X: 1, Y: 0}); s.arrow != want {
+		t.Errorf("move() right = %v, want the last host %v", s.arrow, want)
+	}
+	s.move(NavDown, a)
+	if want := (arrowPos{X: 1, Y: 0}); s.arrow != want {
+		t.Errorf("move() down = %v, want %v", s.arrow, want)
+	}
+	s.move(NavLeft, a)
+	s.move(NavDown, a)
+	if want := (arrowPos{X: 0, Y: 1}); s.arrow != want {
+		t.Errorf("move() down = %v, want %v", s.arrow, want)
+	}
+}
+
+func Test_colorizeSelectedWord(t *testing.T) {
+	if got, want := colorizeSelectedWord("db-web-01", "WEB"), "db-\u001B[37;7mweb\u001B[0m-01"; got != want {
+		t.Errorf("colorizeSelectedWord() = %q, want %q", got, want)
+	}
+	if got := colorizeSelectedWord("db-web-01", "=WEB"); got != "db-web-01" {
+		t.Errorf("colorizeSelectedWord() of the exact query = %q, want nothing highlighted", got)
+	}
+}
@@ -0,0 +1,250 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/adzimzf/tpot/i18n"
+	"github.com/jroimartin/gocui"
+)
+
+// Binding is the command of the picker, the console, the broadcast, the file browser & the top bound to the keys
+type Binding string
+
+const (
+	BindUp          Binding = "up"
+	BindDown        Binding = "down"
+	BindLeft        Binding = "left"
+	BindRight       Binding = "right"
+	BindSelect      Binding = "select"
+	BindQuit        Binding = "quit"
+	BindHistoryPrev Binding = "history_prev"
+	BindHistoryNext Binding = "history_next"
+	BindExec        Binding = "exec"
+	BindForward     Binding = "forward"
+	BindCopyIP      Binding = "copy_ip"
+	BindInfo        Binding = "info"
+	BindSCP         Binding = "scp"
+	BindColumns     Binding = "columns"
+	BindSort        Binding = "sort"
+
+	// the console only
+	BindRefresh Binding = "refresh"
+	BindNextEnv Binding = "next_env"
+	BindPrevEnv Binding = "prev_env"
+
+	// the broadcast only
+	BindNextPane   Binding = "next_pane"
+	BindTogglePane Binding = "toggle_pane"
+	BindToggleAll  Binding = "toggle_all"
+
+	// the file browser only
+	BindCopy   Binding = "copy"
+	BindRename Binding = "rename"
+	BindDelete Binding = "delete"
+)
+
+// the key map presets
+const (
+	PresetDefault = "default"
+	PresetEmacs   = "emacs"
+	PresetVim     = "vim"
+)
+
+// keyScopes is the bindings shown at once, a key can't be bound twice in a scope
+var keyScopes = map[string][]Binding{
+	"picker": {BindUp, BindDown, BindLeft, BindRight, BindSelect, BindQuit, BindHistoryPrev, BindHistoryNext,
+		BindExec, BindForward, BindCopyIP, BindInfo, BindSCP, BindColumns, BindSort},
+	"console": {BindUp, BindDown, BindLeft, BindRight, BindSelect, BindQuit, BindHistoryPrev, BindHistoryNext,
+		BindExec, BindForward, BindCopyIP, BindInfo, BindSCP, BindColumns, BindRefresh, BindNextEnv, BindPrevEnv},
+	"broadcast": {BindSelect, BindQuit, BindNextPane, BindTogglePane, BindToggleAll},
+	"files":     {BindUp, BindDown, BindSelect, BindQuit, BindNextPane, BindRefresh, BindCopy, BindRename, BindDelete},
+	"top":       {BindUp, BindDown, BindQuit, BindRefresh, BindSort},
+}
+
+// Key is the key along with its modifier, the Key is either gocui.Key or rune
+type Key struct {
+	Key interface{}
+	Mod gocui.Modifier
+}
+
+// KeyMap maps the bindings into their keys
+type KeyMap map[Binding][]Key
+
+// Keys is the key map of every UI, it's replaced by the configuration
+var Keys = mustKeyMap(PresetDefault, nil)
+
+// namedKeys is the keys other than ctrl+<letter> & alt+<char> by the name
+var namedKeys = map[string]gocui.Key{
+	"up": gocui.KeyArrowUp, "down": gocui.KeyArrowDown, "left": gocui.KeyArrowLeft, "right": gocui.KeyArrowRight,
+	"enter": gocui.KeyEnter, "esc": gocui.KeyEsc, "tab": gocui.KeyTab, "ctrl+space": gocui.KeyCtrlSpace,
+	"home": gocui.KeyHome, "end": gocui.KeyEnd, "pgup": gocui.KeyPgup, "pgdn": gocui.KeyPgdn,
+	"insert": gocui.KeyInsert, "delete": gocui.KeyDelete,
+	"f1": gocui.KeyF1, "f2": gocui.KeyF2, "f3": gocui.KeyF3, "f4": gocui.KeyF4, "f5": gocui.KeyF5, "f6": gocui.KeyF6,
+	"f7": gocui.KeyF7, "f8": gocui.KeyF8, "f9": gocui.KeyF9, "f10": gocui.KeyF10, "f11": gocui.KeyF11, "f12": gocui.KeyF12,
+}
+
+// presets is the key names of every preset, emacs & vim only
+// change the navigation of the default one
+var presets = map[string]map[Binding][]string{
+	PresetDefault: {
+		BindUp: {"up"}, BindDown: {"down"}, BindLeft: {"left"}, BindRight: {"right"},
+		BindSelect: {"enter"}, BindQuit: {"ctrl+c", "esc"},
+		BindHistoryPrev: {"ctrl+p"}, BindHistoryNext: {"ctrl+n"},
+		BindExec: {"ctrl+e"}, BindForward: {"ctrl+f"}, BindCopyIP: {"ctrl+y"}, BindInfo: {"ctrl+o"}, BindSCP: {"ctrl+s"},
+		BindColumns: {"ctrl+t"}, BindSort: {"alt+s"},
+		BindRefresh: {"ctrl+r"}, BindNextEnv: {"tab"}, BindPrevEnv: {},
+		BindNextPane: {"tab"}, BindTogglePane: {"ctrl+t"}, BindToggleAll: {"ctrl+a"},
+		BindCopy: {"f5"}, BindRename: {"f2"}, BindDelete: {"f8"},
+	},
+	PresetEmacs: {
+		BindUp: {"up", "ctrl+p"}, BindDown: {"down", "ctrl+n"}, BindLeft: {"left", "ctrl+b"}, BindRight: {"right", "ctrl+f"},
+		BindQuit: {"ctrl+c", "ctrl+g", "esc"}, BindHistoryPrev: {"alt+p"}, BindHistoryNext: {"alt+n"}, BindForward: {"alt+f"},
+	},
+	PresetVim: {
+		BindUp: {"up", "ctrl+k"}, BindDown: {"down", "ctrl+j"}, BindRight: {"right", "ctrl+l"},
+	},
+}
+
+// NewKeyMap creates the key map of the preset, the overrides replace
+// the keys of the binding such as exec: [ctrl+x]
+func NewKeyMap(preset string, overrides map[string][]string) (KeyMap, error) {
+	if preset == "" {
+		preset = PresetDefault
+	}
+	names, ok := presets[preset]
+	if !ok {
+		return nil, fmt.Errorf("unknown preset %s, use default, emacs or vim", preset)
+	}
+
+	m := KeyMap{}
+	for _, keys := range []map[Binding][]string{presets[PresetDefault], names} {
+		for b, list := range keys {
+			if err := m.set(b, list); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for name, list := range overrides {
+		b := Binding(name)
+		if _, ok := presets[PresetDefault][b]; !ok {
+			return nil, fmt.Errorf("unknown binding %s", name)
+		}
+		if err := m.set(b, list); err != nil {
+			return nil, err
+		}
+	}
+	return m, m.validate()
+}
+
+func mustKeyMap(preset string, overrides map[string][]string) KeyMap {
+	m, err := NewKeyMap(preset, overrides)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func (m KeyMap) set(b Binding, names []string) error {
+	keys := make([]Key, 0, len(names))
+	for _, name := range names {
+		k, err := ParseKey(name)
+		if err != nil {
+			return fmt.Errorf("invalid key of %s, error: %v", b, err)
+		}
+		keys = append(keys, k)
+	}
+	m[b] = keys
+	return nil
+}
+
+// validate ensures a key isn't bound twice in the same UI
+func (m KeyMap) validate() error {
+	scopes := make([]string, 0, len(keyScopes))
+	for scope := range keyScopes {
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+
+	for _, scope := range scopes {
+		bound := map[Key]Binding{}
+		for _, b := range keyScopes[scope] {
+			for _, k := range m[b] {
+				if other, ok := bound[k]; ok && other != b {
+					return fmt.Errorf("%s is bound to both %s & %s in the %s", k, other, b, scope)
+				}
+				bound[k] = b
+			}
+		}
+	}
+	return nil
+}
+
+// ParseKey parses the key name such as ctrl+e, alt+p, up, enter or f1
+func ParseKey(name string) (Key, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if k, ok := namedKeys[name]; ok {
+		return Key{Key: k}, nil
+	}
+	if name == "ctrl+h" {
+		// the terminals send ctrl+h as the backspace deleting the query
+		return Key{}, fmt.Errorf("ctrl+h is the backspace, use another key")
+	}
+	if c := strings.TrimPrefix(name, "ctrl+"); c != name && len(c) == 1 && c[0] >= 'a' && c[0] <= 'z' {
+		return Key{Key: gocui.Key(c[0] - 'a' + 1)}, nil
+	}
+	if c := strings.TrimPrefix(name, "alt+"); c != name && utf8.RuneCountInString(c) == 1 {
+		r, _ := utf8.DecodeRuneInString(c)
+		return Key{Key: r, Mod: gocui.ModAlt}, nil
+	}
+	return Key{}, fmt.Errorf("unknown key %q, use ctrl+<letter>, alt+<char> or the named key such as up, enter or f1", name)
+}
+
+// String returns the short label of the key such as ^E
+func (k Key) String() string {
+	if r, ok := k.Key.(rune); ok {
+		return "M-" + string(r)
+	}
+	key, _ := k.Key.(gocui.Key)
+	for name, named := range namedKeys {
+		if named == key {
+			return strings.ToUpper(name)
+		}
+	}
+	if key >= gocui.KeyCtrlA && key <= gocui.KeyCtrlZ {
+		return "^" + string(rune('A'+key-gocui.KeyCtrlA))
+	}
+	return fmt.Sprintf("key %d", key)
+}
+
+// Label returns the label of the first key of the binding, empty if it's unbound
+func (m KeyMap) Label(b Binding) string {
+	if keys := m[b]; len(keys) > 0 {
+		return keys[0].String()
+	}
+	return ""
+}
+
+// bind binds every key of the binding into the handler
+func (m KeyMap) bind(g *gocui.Gui, view string, b Binding, handler func(*gocui.Gui, *gocui.View) error) error {
+	for _, k := range m[b] {
+		if err := g.SetKeybinding(view, k.Key, k.Mod, handler); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// help returns the labels of the bindings along with their translated
+// description, the unbound ones are skipped
+func (m KeyMap) help(pairs ...string) string {
+	var parts []string
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if label := m.Label(Binding(pairs[i])); label != "" {
+			parts = append(parts, label+" "+i18n.T(pairs[i+1]))
+		}
+	}
+	return strings.Join(parts, " | ")
+}
@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jroimartin/gocui"
+)
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Key
+		wantErr bool
+	}{
+		{name: "ctrl+e", want: Key{Key: gocui.KeyCtrlE}},
+		{name: " CTRL+X ", want: Key{Key: gocui.KeyCtrlX}},
+		{name: "alt+p", want: Key{Key: 'p', Mod: gocui.ModAlt}},
+		{name: "up", want: Key{Key: gocui.KeyArrowUp}},
+		{name: "f5", want: Key{Key: gocui.KeyF5}},
+		{name: "ctrl+h", wantErr: true},
+		{name: "x", wantErr: true},
+		{name: "ctrl+1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseKey(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseKey() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewKeyMap(t *testing.T) {
+	for _, preset := range []string{"", PresetDefault, PresetEmacs, PresetVim} {
+		if _, err := NewKeyMap(preset, nil); err != nil {
+			t.Errorf("NewKeyMap(%q) error = %v", preset, err)
+		}
+	}
+
+	m, err := NewKeyMap(PresetEmacs, map[string][]string{"exec": {"ctrl+x", "f5"}})
+	if err != nil {
+		t.Fatalf("NewKeyMap() error = %v", err)
+	}
+	if got := m.Label(BindExec); got != "^X" {
+		t.Errorf("Label(exec) = %s, want the override", got)
+	}
+	if got := m.Label(BindForward); got != "M-f" {
+		t.Errorf("Label(forward) = %s, want the emacs preset", got)
+	}
+	if got := m.Label(BindSCP); got != "^S" {
+		t.Errorf("Label(scp) = %s, want the default", got)
+	}
+
+	errs := map[string]map[string][]string{
+		"conflict":        {"exec": {"ctrl+s"}},
+		"unknown binding": {"launch": {"ctrl+x"}},
+		"invalid key":     {"exec": {"e"}},
+	}
+	for name, overrides := range errs {
+		if _, err := NewKeyMap(PresetDefault, overrides); err == nil {
+			t.Errorf("NewKeyMap() with %s, want error", name)
+		}
+	}
+	if _, err := NewKeyMap("nano", nil); err == nil {
+		t.Error("NewKeyMap() with unknown preset, want error")
+	}
+}
+
+func TestKeyMap_help(t *testing.T) {
+	m, _ := NewKeyMap(PresetDefault, map[string][]string{"info": {}})
+	got := m.help(string(BindSelect), "ssh", string(BindInfo), "info", string(BindRefresh), "refresh")
+	if want := "ENTER ssh | ^R refresh"; got != want {
+		t.Errorf("help() = %s, want %s", got, want)
+	}
+	if strings.Contains(got, "info") {
+		t.Error("help() shows the unbound binding")
+	}
+}
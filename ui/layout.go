@@ -6,12 +6,19 @@ import (
 	"github.com/jroimartin/gocui"
 )
 
+// headerView is the line on top of the picker
+const headerView = "header"
+
 type layout struct {
 	s *search
 	g *gocui.Gui
+
+	header      string
+	headerColor string
 }
 
-func (l *layout) register(data []string) error {
+// register draws the picker, the query is typed in the search box at first
+func (l *layout) register(title, query string) error {
 	l.g.SetManagerFunc(func(gui *gocui.Gui) error {
 		maxX, maxY := l.g.Size()
 		if v, err := l.g.SetView(searchInputView, 0, maxY-3, maxX-1, maxY-1); err != nil {
@@ -19,17 +26,30 @@ func (l *layout) register(data []string) error {
 				return err
 			}
 			v.Editable = true
+			fmt.Fprint(v, query)
+			if err := v.SetCursor(len(query), 0); err != nil {
+				return err
+			}
 			if _, err := l.g.SetCurrentView(searchInputView); err != nil {
 				return err
 			}
 		}
+		if l.header != "" {
+			if v, err := l.g.SetView(headerView, -1, -1, maxX, 1); err != nil {
+				if err != gocui.ErrUnknownView {
+					return err
+				}
+				v.Frame = false
+				fmt.Fprint(v, Badge(l.header, l.headerColor))
+			}
+		}
 		if v, err := l.g.SetView(searchResultView, 0, 1, maxX-1, maxY-3); err != nil {
 			if err != gocui.ErrUnknownView {
 				return err
 			}
-			v.Title = "Type to Search or Arrow to Navigate"
+			v.Title = title
 			v.Editable = true
-			fmt.Fprintln(v, formatResult(lookup("", data), "", arrowPos{}))
+			fmt.Fprintln(v, formatResult(l.s.matches, l.s.keyword, l.s.arrow))
 		}
 		return nil
 	})
@@ -37,8 +57,9 @@ func (l *layout) register(data []string) error {
 
 }
 
-func newLayout(g *gocui.Gui) *layout {
+func newLayout(g *gocui.Gui, s *search) *layout {
 	return &layout{
 		g: g,
+		s: s,
 	}
 }
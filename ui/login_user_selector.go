@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/adzimzf/tpot/i18n"
 	"github.com/jroimartin/gocui"
 )
 
@@ -23,6 +24,9 @@ type loginUser struct {
 
 // NewLoginUser create a new login user UI
 func NewLoginUser(listUser []string) (*loginUser, error) {
+	if Plain {
+		return &loginUser{list: listUser}, nil
+	}
 	g, err := gocui.NewGui(gocui.OutputNormal)
 	if err != nil {
 		return nil, err
@@ -101,22 +105,22 @@ func (l *loginUser) registerView() error {
 }
 
 func (l *loginUser) registerKeyBind() error {
-	if err := l.g.SetKeybinding(l.viewName, gocui.KeyCtrlC, gocui.ModNone, quit); err != nil {
+	if err := Keys.bind(l.g, l.viewName, BindQuit, quit); err != nil {
 		return err
 	}
 
-	if err := l.g.SetKeybinding(l.viewName, gocui.KeyEnter, gocui.ModNone, l.handleEnter); err != nil {
+	if err := Keys.bind(l.g, l.viewName, BindSelect, l.handleEnter); err != nil {
 		return err
 	}
 
-	if err := l.g.SetKeybinding(l.viewName, gocui.KeyArrowUp, gocui.ModNone, l.handleNav(func() {
+	if err := Keys.bind(l.g, l.viewName, BindUp, l.handleNav(func() {
 		if l.pos > 0 {
 			l.pos--
 		}
 	})); err != nil {
 		return err
 	}
-	if err := l.g.SetKeybinding("", gocui.KeyArrowDown, gocui.ModNone, l.handleNav(func() {
+	if err := Keys.bind(l.g, "", BindDown, l.handleNav(func() {
 		if l.pos < len(l.list)-1 {
 			l.pos++
 		}
@@ -145,7 +149,7 @@ func (l *loginUser) write(v *gocui.View) error {
 func (l *loginUser) text(pos int) string {
 	var str bytes.Buffer
 	str.WriteString("\n")
-	str.WriteString("Select user to login")
+	str.WriteString(i18n.T("Select user to login"))
 	str.WriteString("\n\n")
 	for i, s := range l.list {
 		if i == pos {
@@ -155,7 +159,7 @@ func (l *loginUser) text(pos int) string {
 		}
 	}
 	str.WriteString("\n")
-	str.WriteString("Yes [\u001B[32;1mEnter\u001B[0m]   Cancel [\u001B[31;1mCTRL+C\u001B[0m]")
+	fmt.Fprintf(&str, "%s [\u001B[32;1m%s\u001B[0m]   %s [\u001B[31;1m%s\u001B[0m]", i18n.T("Yes"), Keys.Label(BindSelect), i18n.T("Cancel"), Keys.Label(BindQuit))
 	return prependTab(str.String())
 }
 
@@ -175,6 +179,13 @@ func (l *loginUser) handleEnter(g *gocui.Gui, v *gocui.View) error {
 
 // Run runs the UI and returns the selected user login
 func (l *loginUser) Run() (string, error) {
+	if l.g == nil {
+		i, err := plainChoose(i18n.T("Select user to login"), i18n.T("cancel"), l.list)
+		if err != nil || i < 0 {
+			return "", err
+		}
+		return l.list[i], nil
+	}
 	defer l.g.Close()
 	err := l.g.MainLoop()
 	if err == gocui.ErrQuit {
@@ -0,0 +1,197 @@
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/i18n"
+	"github.com/chzyer/readline"
+	"github.com/manifoldco/promptui"
+)
+
+// Plain replaces the full-screen UI with the numbered prompts read line by
+// line, without the cursor positioning & the colors, for the screen readers
+// & the minimal terminals
+var Plain bool
+
+// plainInput is shared by every prompt so the buffered lines aren't lost
+var plainInput = bufio.NewReader(os.Stdin)
+
+// plainMaxItems is the most items listed at once, the rest are narrowed by searching
+const plainMaxItems = 50
+
+// plainActions is the actions of the picker in the order they're listed
+var plainActions = []struct {
+	action Action
+	name   string
+}{
+	{ActionSSH, "ssh"},
+	{ActionExec, "exec"},
+	{ActionForward, "forward"},
+	{ActionCopyIP, "copy IP"},
+	{ActionInfo, "info"},
+	{ActionSCP, "scp"},
+}
+
+// plainOutput returns the output of the plain prompts, it follows the prompts
+func plainOutput() io.Writer {
+	if Quiet {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// readLine prompts the label & returns the typed line, io.EOF once the input is closed
+func readLine(label string) (string, error) {
+	fmt.Fprintf(plainOutput(), "%s: ", label)
+	line, err := plainInput.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		fmt.Fprintln(plainOutput())
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// readPassword prompts the label & returns the typed line without echoing it,
+// the input other than the terminal is read as is
+func readPassword(label string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if plainInput.Buffered() > 0 || !readline.IsTerminal(fd) {
+		return readLine(label)
+	}
+	fmt.Fprintf(plainOutput(), "%s: ", label)
+	b, err := readline.ReadPassword(fd)
+	fmt.Fprintln(plainOutput())
+	return string(b), err
+}
+
+// plainChoose lists the numbered items & returns the index of the entered
+// number, it's -1 when nothing is entered, the empty describes it such as cancel
+func plainChoose(label, empty string, items []string) (int, error) {
+	out := plainOutput()
+	for {
+		fmt.Fprintln(out, label)
+		for i, item := range items {
+			fmt.Fprintf(out, "%d. %s\n", i+1, item)
+		}
+		line, err := readLine(i18n.Sprintf("Enter the number, or nothing to %s", empty))
+		if err != nil {
+			return -1, err
+		}
+		if line == "" {
+			return -1, nil
+		}
+		if n, err := strconv.Atoi(line); err == nil && n >= 1 && n <= len(items) {
+			return n - 1, nil
+		}
+		fmt.Fprint(out, i18n.Sprintf("%s isn't a number between 1 & %d\n", line, len(items)))
+	}
+}
+
+// runPlain lists the numbered hosts until one is picked, the typed text
+// other than the number narrows the list instead
+func (p *Picker) runPlain() (string, Action) {
+	out := plainOutput()
+	if p.Header != "" {
+		fmt.Fprintln(out, p.Header)
+	}
+
+	var query, host string
+	for host == "" {
+		matches := sortKey(lookup(query, p.Hosts))
+		shown := matches
+		if len(shown) > plainMaxItems {
+			shown = shown[:plainMaxItems]
+		}
+		switch {
+		case len(matches) == 0:
+			fmt.Fprint(out, i18n.Sprintf("There's no host match %s\n", query))
+		case query == "":
+			fmt.Fprint(out, i18n.Sprintf("%d hosts\n", len(matches)))
+		default:
+			fmt.Fprint(out, i18n.Sprintf("%d hosts match %s\n", len(matches), query))
+		}
+		for i, h := range shown {
+			line := h + plainDetails(h, p.Columns)
+			if note := p.Notes[h]; note != "" {
+				line += " (" + note + ")"
+			}
+			fmt.Fprintf(out, "%d. %s\n", i+1, line)
+		}
+		if len(matches) > len(shown) {
+			fmt.Fprint(out, i18n.Sprintf("%d more hosts aren't listed, search to narrow them\n", len(matches)-len(shown)))
+		}
+
+		line, err := readLine(i18n.T("Enter the number of the host, text to search, or nothing to quit"))
+		if err != nil || line == "" {
+			return "", ActionSSH
+		}
+		if n, err := strconv.Atoi(line); err == nil && n >= 1 && n <= len(shown) {
+			host = shown[n-1]
+			continue
+		}
+		query = line
+	}
+	p.Query = query
+	if !p.Actions {
+		return host, ActionSSH
+	}
+
+	names := make([]string, len(plainActions))
+	for i, a := range plainActions {
+		names[i] = i18n.T(a.name)
+	}
+	i, err := plainChoose(i18n.Sprintf("Action on %s", host), "ssh", names)
+	if err != nil {
+		return "", ActionSSH
+	}
+	if i < 0 {
+		return host, ActionSSH
+	}
+	return host, plainActions[i].action
+}
+
+// plainSelect is Select of the plain mode
+func plainSelect(label string, items []string) (int, error) {
+	i, err := plainChoose(label, i18n.T("cancel"), items)
+	if err != nil {
+		return -1, err
+	}
+	if i < 0 {
+		return -1, promptui.ErrInterrupt
+	}
+	return i, nil
+}
+
+// plainForwarding prints the forwarding list & every change of the status
+// instead of the forwarding boxes, it runs until the process is interrupted
+func plainForwarding(list []*config.ForwardingNode) {
+	out := plainOutput()
+	status := make(map[*config.ForwardingNode]string, len(list))
+	for _, node := range list {
+		fmt.Fprint(out, i18n.Sprintf("%s listen %s to %s:%s\n", node.Host, node.ListenPort, node.RemoteHost, node.RemotePort))
+	}
+	fmt.Fprintln(out, i18n.T("Press CTRL+C to stop the forwarding"))
+	for {
+		time.Sleep(1 * time.Second)
+		for _, node := range list {
+			s := i18n.T("up")
+			if !node.Status {
+				s = i18n.T("down")
+				if node.Error != "" {
+					s += ", " + node.Error
+				}
+			}
+			if status[node] != s {
+				status[node] = s
+				fmt.Fprint(out, i18n.Sprintf("%s listen %s is %s\n", node.Host, node.ListenPort, s))
+			}
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func setPlainInput(t *testing.T, input string) {
+	old := plainInput
+	plainInput = bufio.NewReader(strings.NewReader(input))
+	t.Cleanup(func() { plainInput = old })
+}
+
+func TestPicker_runPlain(t *testing.T) {
+	hosts := []string{"web-02", "db-01", "web-01"}
+	tests := []struct {
+		name       string
+		input      string
+		actions    bool
+		wantHost   string
+		wantAction Action
+		wantQuery  string
+	}{
+		{name: "pick by number", input: "2\n", wantHost: "web-01"},
+		{name: "search then pick", input: "web\n2\n", wantHost: "web-02", wantQuery: "web"},
+		{name: "out of range is searched", input: "9\n\n", wantQuery: ""},
+		{name: "quit", input: "\n"},
+		{name: "closed input", input: ""},
+		{name: "default action", input: "1\n\n", actions: true, wantHost: "db-01"},
+		{name: "exec action", input: "1\n2\n", actions: true, wantHost: "db-01", wantAction: ActionExec},
+		{name: "invalid action is asked again", input: "1\n7\n6\n", actions: true, wantHost: "db-01", wantAction: ActionSCP},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setPlainInput(t, tt.input)
+			p := &Picker{Hosts: hosts, Actions: tt.actions}
+			host, action := p.runPlain()
+			if host != tt.wantHost || action != tt.wantAction {
+				t.Errorf("runPlain() = %q, %v, want %q, %v", host, action, tt.wantHost, tt.wantAction)
+			}
+			if p.Query != tt.wantQuery {
+				t.Errorf("runPlain() query = %q, want %q", p.Query, tt.wantQuery)
+			}
+		})
+	}
+}
+
+func Test_plainDetails(t *testing.T) {
+	columns := []Column{
+		{Width: 15, Values: map[string]string{"web-01": "10.0.0.1", "web-02": "10.0.0.2"}},
+		{Width: 6, Values: map[string]string{"web-01": "payments"}},
+	}
+	if got := plainDetails("web-01", columns); got != ", 10.0.0.1, payments" {
+		t.Errorf("plainDetails() = %q, want every column", got)
+	}
+	if got := plainDetails("web-02", columns); got != ", 10.0.0.2" {
+		t.Errorf("plainDetails() = %q, want the empty column skipped", got)
+	}
+	if got := plainDetails("web-01", nil); got != "" {
+		t.Errorf("plainDetails() = %q, want nothing without the columns", got)
+	}
+}
+
+func TestPlainPrompts(t *testing.T) {
+	setPlainInput(t, "maybe\nY\n\n  root  \nprod\n")
+	Plain = true
+	defer func() { Plain = false }()
+
+	if ok, err := Confirm("continue"); err != nil || !ok {
+		t.Errorf("Confirm() = %v, %v, want true", ok, err)
+	}
+	if s, err := Input("user"); err != nil || s != "root" {
+		t.Errorf("Input() = %q, %v, want root", s, err)
+	}
+	if ok, err := ConfirmEnv("prod"); err != nil || !ok {
+		t.Errorf("ConfirmEnv() = %v, %v, want true", ok, err)
+	}
+	if _, err := Select("pick", []string{"a"}); err == nil {
+		t.Errorf("Select() on the closed input must fail")
+	}
+	if got := Colorize("prod", "red"); got != "prod" {
+		t.Errorf("Colorize() = %q, want the plain text", got)
+	}
+}
@@ -0,0 +1,27 @@
+package ui
+
+import (
+	"io"
+	"os"
+)
+
+// Quiet writes the prompts into the stderr to keep the stdout for the
+// result, the answered prompt is cleared instead of leaving a line
+var Quiet bool
+
+// promptStdout returns the output of the prompts, nil is the stdout
+func promptStdout() io.WriteCloser {
+	if !Quiet {
+		return nil
+	}
+	return nopCloser{os.Stderr}
+}
+
+// nopCloser keeps the stderr open once the prompt is closed
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
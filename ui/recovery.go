@@ -0,0 +1,122 @@
+package ui
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/i18n"
+	"github.com/chzyer/readline"
+	"github.com/nsf/termbox-go"
+)
+
+// maxCrashReports is the number of the crash reports kept, the older ones are removed
+const maxCrashReports = 10
+
+var (
+	// crashVersion is the tpot version named by the crash report
+	crashVersion string
+
+	// crashCode is the exit code once tpot crashes
+	crashCode = 1
+
+	// termState is the terminal state before the UI changed it,
+	// nil when the stdin isn't a terminal
+	termState *readline.State
+)
+
+// HandleCrash saves the terminal state to restore it once tpot crashes,
+// the crash report names the version & tpot exits with the code
+func HandleCrash(version string, code int) {
+	crashVersion, crashCode = version, code
+	if fd := int(os.Stdin.Fd()); readline.IsTerminal(fd) {
+		termState, _ = readline.GetState(fd)
+	}
+}
+
+// Recover restores the terminal, writes the crash report & exits once the
+// goroutine panics. It's deferred by main & the goroutines of the UI, the
+// panic of the other goroutine can't be recovered by main
+func Recover() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	restoreTerminal()
+
+	path, err := writeCrashReport(CrashDir(), time.Now(), r, debug.Stack())
+	fmt.Fprint(os.Stderr, i18n.Sprintf("tpot crashed: %v\n", r))
+	if err != nil {
+		fmt.Fprint(os.Stderr, i18n.Sprintf("failed to write the crash report, error: %v\n", err))
+	} else {
+		fmt.Fprint(os.Stderr, i18n.Sprintf("the crash report is written to %s, please attach it to the issue\n", path))
+	}
+	os.Exit(crashCode)
+}
+
+// CrashDir returns the directory of the crash reports
+func CrashDir() string {
+	return config.CacheDir + "crash/"
+}
+
+// restoreTerminal leaves the full-screen UI & the raw mode of the prompts
+func restoreTerminal() {
+	if termbox.IsInit {
+		termbox.Close()
+	} else if readline.IsTerminal(int(os.Stdout.Fd())) {
+		// leave the alternate screen, reset the colors & show the cursor
+		// in case they're left by the prompt
+		fmt.Fprint(os.Stdout, "\x1b[?1049l\x1b[0m\x1b[?25h")
+	}
+	if termState != nil {
+		_ = readline.Restore(int(os.Stdin.Fd()), termState)
+	}
+	fmt.Fprintln(os.Stdout)
+}
+
+// writeCrashReport writes the panic along with the stack trace into the dir,
+// it returns the path of the report
+func writeCrashReport(dir string, at time.Time, r interface{}, stack []byte) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "tpot %s crashed at %s\n", crashVersion, at.Format(time.RFC3339))
+	fmt.Fprintf(&b, "go: %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "args: %s\n", strings.Join(os.Args[1:], " "))
+	fmt.Fprintf(&b, "panic: %v\n\n%s", r, stack)
+
+	path := filepath.Join(dir, "crash-"+at.Format("20060102-150405.000")+".txt")
+	if err := ioutil.WriteFile(path, []byte(b.String()), 0600); err != nil {
+		return "", err
+	}
+	pruneCrashReports(dir)
+	return path, nil
+}
+
+// pruneCrashReports removes the oldest reports over maxCrashReports,
+// the names are sorted by the time they crashed
+func pruneCrashReports(dir string) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	var names []string
+	for _, f := range files {
+		if strings.HasPrefix(f.Name(), "crash-") {
+			names = append(names, f.Name())
+		}
+	}
+	sort.Strings(names)
+	for len(names) > maxCrashReports {
+		os.Remove(filepath.Join(dir, names[0]))
+		names = names[1:]
+	}
+}
@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_writeCrashReport(t *testing.T) {
+	dir := t.TempDir() + "/crash/"
+	at := time.Date(2026, 10, 16, 15, 4, 5, 0, time.UTC)
+
+	path, err := writeCrashReport(dir, at, "index out of range", []byte("goroutine 1 [running]:"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := dir + "crash-20261016-150405.000.txt"; path != want {
+		t.Errorf("path = %s, want %s", path, want)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"crashed at 2026-10-16T15:04:05Z", "panic: index out of range", "goroutine 1 [running]:"} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("the report doesn't contain %q\n%s", want, b)
+		}
+	}
+}
+
+func Test_pruneCrashReports(t *testing.T) {
+	dir := t.TempDir() + "/"
+	at := time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC)
+	for i := 0; i < maxCrashReports+2; i++ {
+		if _, err := writeCrashReport(dir, at.Add(time.Duration(i)*time.Second), "boom", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != maxCrashReports {
+		t.Fatalf("got %d reports, want %d", len(files), maxCrashReports)
+	}
+	// the oldest ones are removed
+	if want := "crash-20261016-150002.000.txt"; files[0].Name() != want {
+		t.Errorf("the oldest report = %s, want %s", files[0].Name(), want)
+	}
+}
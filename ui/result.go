@@ -10,24 +10,27 @@ type keyEnterBinding struct {
 	g *gocui.Gui
 }
 
-func (k *keyEnterBinding) register(result *string) error {
-	return k.g.SetKeybinding("", gocui.KeyEnter, gocui.ModNone, func(gui *gocui.Gui, view *gocui.View) error {
+// register picks the selected host into the result once the keys of the binding
+// are pressed, the onPick is called along with it if any
+func (k *keyEnterBinding) register(b Binding, result *string, onPick func()) error {
+	return Keys.bind(k.g, "", b, func(gui *gocui.Gui, view *gocui.View) error {
 		v, err := gui.View(searchResultView)
 		if err != nil {
 			return err
 		}
 		*result = k.findResult(v.Buffer())
+		if onPick != nil {
+			onPick()
+		}
 		return gocui.ErrQuit
 	})
 }
 
 func (k *keyEnterBinding) findResult(s string) string {
 	for _, st := range strings.Split(s, "\n") {
-		if strings.Contains(st, ">") {
-			for _, s2 := range strings.Split(st, string(dividerChar)) {
-				if strings.Contains(s2, ">") {
-					return cleanText(strings.Replace(s2, ">", "", -1))
-				}
+		for _, s2 := range strings.Split(st, string(dividerChar)) {
+			if hasArrow(s2) {
+				return cellHost(s2)
 			}
 		}
 	}
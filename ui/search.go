@@ -3,13 +3,33 @@ package ui
 import (
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/adzimzf/tpot/fold"
 	"github.com/jroimartin/gocui"
 )
 
 type search struct {
-	g     *gocui.Gui
+	g *gocui.Gui
+
+	// hosts is the hosts in the picker order
 	hosts []string
+
+	// history is the previous queries, the latest first
+	// historyPos is the index of the query shown from the history
+	history    []string
+	historyPos int
+
+	// matches is the hosts matching the keyword in the picker order,
+	// the arrow is on one of them
+	matches []string
+	keyword string
+	arrow   arrowPos
+
+	// pending is the filtering waiting for the next key, gen is
+	// the latest one hence the older ones are thrown away
+	pending *time.Timer
+	gen     int
 }
 
 const (
@@ -52,6 +72,38 @@ func (s *search) register() error {
 	return nil
 }
 
+// registerHistory binds the history keys to go to the older & the newer query,
+// the latest query is already typed hence it starts from the first one
+func (s *search) registerHistory(history []string) error {
+	s.history = history
+	if len(history) == 0 {
+		return nil
+	}
+	if err := Keys.bind(s.g, searchInputView, BindHistoryPrev, s.handleHistory(1)); err != nil {
+		return err
+	}
+	return Keys.bind(s.g, searchInputView, BindHistoryNext, s.handleHistory(-1))
+}
+
+func (s *search) handleHistory(step int) func(gui *gocui.Gui, v *gocui.View) error {
+	return func(gui *gocui.Gui, v *gocui.View) error {
+		pos := s.historyPos + step
+		if pos < 0 || pos >= len(s.history) {
+			return nil
+		}
+		s.historyPos = pos
+		text := s.history[pos]
+		v.Clear()
+		if err := v.SetCursor(len(text), 0); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprint(v, text); err != nil {
+			return err
+		}
+		return s.updateResult(text, gui)
+	}
+}
+
 func (s *search) handleType(c rune) func(gui *gocui.Gui, inputV *gocui.View) error {
 	return func(gui *gocui.Gui, v *gocui.View) error {
 		text := v.Buffer()
@@ -67,17 +119,41 @@ func (s *search) handleType(c rune) func(gui *gocui.Gui, inputV *gocui.View) err
 	}
 }
 
+// updateResult shows the hosts matching the keyword. The large inventory is
+// filtered on the background once the typing pauses, from the previous
+// matches when the keyword narrows them down
 func (s *search) updateResult(keyword string, gui *gocui.Gui) error {
-	resultV, err := gui.View(searchResultView)
-	if err != nil {
-		return err
+	keyword = strings.TrimSpace(keyword)
+	base := s.hosts
+	if fold.New(keyword).Narrows(fold.New(s.keyword)) {
+		base = s.matches
+	}
+	if len(base) <= syncFilterHosts {
+		s.gen++
+		s.apply(keyword, filterHosts(keyword, base))
+		return s.draw(gui)
 	}
-	resultV.Overwrite = true
-	resultV.Clear()
-	_, err = fmt.Fprint(resultV, formatResult(lookup(keyword, s.hosts), keyword, arrowPos{}))
-	return err
+
+	s.gen++
+	gen := s.gen
+	if s.pending != nil {
+		s.pending.Stop()
+	}
+	s.pending = time.AfterFunc(filterDelay, func() {
+		defer Recover()
+		matches := filterHosts(keyword, base)
+		gui.Update(func(g *gocui.Gui) error {
+			if gen != s.gen {
+				return nil
+			}
+			s.apply(keyword, matches)
+			return s.draw(g)
+		})
+	})
+	return nil
 }
 
 func newSearch(g *gocui.Gui, hosts []string) *search {
-	return &search{g: g, hosts: hosts}
+	hosts = sortKey(lookup("", hosts))
+	return &search{g: g, hosts: hosts, matches: hosts}
 }
@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/adzimzf/tpot/i18n"
+	"github.com/manifoldco/promptui"
+)
+
+// Select shows a list of items & returns the index of the selected item
+func Select(label string, items []string) (int, error) {
+	if Plain {
+		return plainSelect(label, items)
+	}
+	prompt := promptui.Select{
+		Label: label,
+		Items: items,
+		Size:  15,
+
+		Stdout:       promptStdout(),
+		HideSelected: Quiet,
+	}
+	i, _, err := prompt.Run()
+	return i, err
+}
+
+// Password prompts the label & returns the typed password masked
+func Password(label string) (string, error) {
+	if Plain {
+		return readPassword(label)
+	}
+	prompt := promptui.Prompt{
+		Label:       label,
+		Mask:        '*',
+		Stdout:      promptStdout(),
+		HideEntered: true,
+	}
+	return prompt.Run()
+}
+
+// Input prompts the label & returns the typed text
+func Input(label string) (string, error) {
+	if Plain {
+		for {
+			s, err := readLine(label)
+			if err != nil || s != "" {
+				return s, err
+			}
+			fmt.Fprintln(plainOutput(), i18n.T("must not be empty"))
+		}
+	}
+	prompt := promptui.Prompt{
+		Label:       label,
+		Stdout:      promptStdout(),
+		HideEntered: Quiet,
+		Validate: func(s string) error {
+			if strings.TrimSpace(s) == "" {
+				return i18n.Error("must not be empty")
+			}
+			return nil
+		},
+	}
+	s, err := prompt.Run()
+	return strings.TrimSpace(s), err
+}
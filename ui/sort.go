@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// Sort is an order of the picker hosts
+type Sort struct {
+	Name string
+
+	// Hosts is the hosts in the order, nil sorts them by name
+	Hosts []string
+}
+
+// applySort orders the picker hosts by the sort of the name, the unknown
+// name falls back to the first sort. It returns the name of the applied one
+func (p *Picker) applySort(name string) string {
+	sort := p.Sorts[0]
+	for _, s := range p.Sorts {
+		if s.Name == name {
+			sort = s
+		}
+	}
+	hostOrder = nil
+	if sort.Hosts != nil {
+		hostOrder = make(map[string]int, len(sort.Hosts))
+		for i, host := range sort.Hosts {
+			hostOrder[host] = i
+		}
+	}
+	return sort.Name
+}
+
+// nextSort applies the sort after the current one
+func (p *Picker) nextSort() {
+	for i, s := range p.Sorts {
+		if s.Name == p.Sort {
+			p.Sort = p.applySort(p.Sorts[(i+1)%len(p.Sorts)].Name)
+			return
+		}
+	}
+}
+
+// sortTitle returns the title along with the key switching the sort
+func (p *Picker) sortTitle(title string) string {
+	if len(p.Sorts) < 2 {
+		return title
+	}
+	return title + " | " + Keys.help(string(BindSort), "sort") + ": " + p.Sort
+}
+
+// registerSort binds the key switching the sort, it redraws the hosts
+// matching the query with the arrow back on the first host
+func (p *Picker) registerSort(s *search, title string) error {
+	if len(p.Sorts) < 2 {
+		return nil
+	}
+	return Keys.bind(s.g, "", BindSort, func(g *gocui.Gui, v *gocui.View) error {
+		p.nextSort()
+		s.resort()
+		resultV, err := g.View(searchResultView)
+		if err != nil {
+			return err
+		}
+		resultV.Title = p.sortTitle(title)
+		inputV, err := g.View(searchInputView)
+		if err != nil {
+			return err
+		}
+		return s.updateResult(strings.TrimSpace(inputV.Buffer()), g)
+	})
+}
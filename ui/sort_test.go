@@ -0,0 +1,36 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPicker_nextSort(t *testing.T) {
+	defer func() { hostOrder = nil }()
+	d := lookup("", []string{"web-10", "db-01", "web-02"})
+	p := &Picker{Sorts: []Sort{
+		{Name: "name"},
+		{Name: "ip", Hosts: []string{"web-02", "web-10", "db-01"}},
+		{Name: "latency", Hosts: []string{"web-10", "db-01", "web-02"}},
+	}}
+
+	// the unknown sort falls back to the first one
+	if p.Sort = p.applySort("label.team"); p.Sort != "name" {
+		t.Errorf("applySort() = %q, want name", p.Sort)
+	}
+	if got, want := sortKey(d), []string{"db-01", "web-02", "web-10"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sortKey() by name = %v, want %v", got, want)
+	}
+
+	p.nextSort()
+	if got, want := sortKey(d), []string{"web-02", "web-10", "db-01"}; p.Sort != "ip" || !reflect.DeepEqual(got, want) {
+		t.Errorf("sortKey() by %s = %v, want %v by ip", p.Sort, got, want)
+	}
+
+	// it wraps around
+	p.nextSort()
+	p.nextSort()
+	if p.Sort != "name" || hostOrder != nil {
+		t.Errorf("nextSort() = %q, want back to name", p.Sort)
+	}
+}
@@ -0,0 +1,326 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/adzimzf/tpot/i18n"
+	"github.com/jroimartin/gocui"
+)
+
+// the views of the top
+const (
+	topTableView  = "top_table"
+	topStatusView = "top_status"
+)
+
+// the orders of the top rows, the metrics are sorted from the busiest host
+const (
+	TopSortLoad = "load"
+	TopSortMem  = "mem"
+	TopSortDisk = "disk"
+	TopSortHost = "host"
+)
+
+// topSorts is the orders switched in turn by the sort key
+var topSorts = []string{TopSortLoad, TopSortMem, TopSortDisk, TopSortHost}
+
+// TopRow is the sampled metrics of a host shown by the top, the sizes are in bytes
+type TopRow struct {
+	Host      string
+	Load      [3]float64
+	CPUs      int
+	MemUsed   int64
+	MemTotal  int64
+	DiskUsed  int64
+	DiskTotal int64
+
+	// Err is why the latest sample failed, the metrics are the previous ones
+	Err string
+
+	// Sampled is false until the host is sampled once
+	Sampled bool
+}
+
+// loadPerCPU is the 1 minute load of every CPU, the load is compared
+// between the hosts of the different sizes by it
+func (r TopRow) loadPerCPU() float64 {
+	if r.CPUs < 1 {
+		return r.Load[0]
+	}
+	return r.Load[0] / float64(r.CPUs)
+}
+
+// usage returns the used ratio, 0 when the total is unknown
+func usage(used, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(used) / float64(total)
+}
+
+// SortTopRows sorts the rows by the order, the hosts never sampled are in the bottom
+func SortTopRows(rows []TopRow, by string) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if a.Sampled != b.Sampled {
+			return a.Sampled
+		}
+		var x, y float64
+		switch by {
+		case TopSortLoad:
+			x, y = a.loadPerCPU(), b.loadPerCPU()
+		case TopSortMem:
+			x, y = usage(a.MemUsed, a.MemTotal), usage(b.MemUsed, b.MemTotal)
+		case TopSortDisk:
+			x, y = usage(a.DiskUsed, a.DiskTotal), usage(b.DiskUsed, b.DiskTotal)
+		}
+		if x != y {
+			return x > y
+		}
+		return a.Host < b.Host
+	})
+}
+
+// WriteTopTable writes the rows as the aligned table
+func WriteTopTable(w io.Writer, rows []TopRow) {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "HOST\tLOAD\tCPUS\tMEM\tDISK\tSTATUS")
+	for _, r := range rows {
+		status := "ok"
+		if r.Err != "" {
+			status = r.Err
+		}
+		if !r.Sampled {
+			fmt.Fprintf(tw, "%s\t-\t-\t-\t-\t%s\n", r.Host, status)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%.2f %.2f %.2f\t%d\t%s\t%s\t%s\n", r.Host, r.Load[0], r.Load[1], r.Load[2], r.CPUs,
+			topUsage(r.MemUsed, r.MemTotal), topUsage(r.DiskUsed, r.DiskTotal), status)
+	}
+	tw.Flush()
+}
+
+// topUsage formats the usage as 1.2G/3.8G 32%, - when it's unknown
+func topUsage(used, total int64) string {
+	if total <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%s/%s %.0f%%", fileSize(used), fileSize(total), usage(used, total)*100)
+}
+
+// mergeTopRows returns the next rows along with the previous metrics
+// of the hosts failed this time, so a dropped sample doesn't blank them
+func mergeTopRows(prev, next []TopRow) []TopRow {
+	last := make(map[string]TopRow, len(prev))
+	for _, r := range prev {
+		last[r.Host] = r
+	}
+	res := make([]TopRow, len(next))
+	for i, r := range next {
+		if p, ok := last[r.Host]; ok && r.Err != "" && p.Sampled {
+			p.Err = r.Err
+			r = p
+		}
+		res[i] = r
+	}
+	return res
+}
+
+// TopView shows the metrics of the hosts sampled every interval as the live table
+type TopView struct {
+	title    string
+	interval time.Duration
+	sample   func(ctx context.Context) []TopRow
+
+	sortBy  string
+	rows    []TopRow
+	offset  int
+	updated time.Time
+
+	// refresh samples the hosts without waiting for the interval
+	refresh chan struct{}
+}
+
+// NewTopView creates the top of the hosts sampled by the sample, sortBy is one of the TopSort orders
+func NewTopView(title string, interval time.Duration, sortBy string, sample func(ctx context.Context) []TopRow) *TopView {
+	return &TopView{
+		title:    title,
+		interval: interval,
+		sample:   sample,
+		sortBy:   sortBy,
+		refresh:  make(chan struct{}, 1),
+	}
+}
+
+// Run samples the hosts until it's quit or the ctx is done, the plain
+// mode prints the table after every sample instead of redrawing it
+func (t *TopView) Run(ctx context.Context) error {
+	if Plain {
+		return t.runPlain(ctx)
+	}
+
+	g, err := gocui.NewGui(gocui.OutputNormal)
+	if err != nil {
+		return err
+	}
+	defer g.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		for {
+			rows := t.sample(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			g.Update(func(*gocui.Gui) error {
+				t.apply(rows)
+				return nil
+			})
+			if !t.wait(ctx) {
+				return
+			}
+		}
+	}()
+
+	g.SetManagerFunc(t.layout)
+	if err := t.registerKeyBind(g); err != nil {
+		return err
+	}
+	if err := g.MainLoop(); err != nil && err != gocui.ErrQuit {
+		return err
+	}
+	return nil
+}
+
+func (t *TopView) runPlain(ctx context.Context) error {
+	for {
+		rows := t.sample(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		t.apply(rows)
+		fmt.Fprintln(os.Stdout, t.statusLine())
+		WriteTopTable(os.Stdout, t.rows)
+		fmt.Fprintln(os.Stdout)
+		if !t.wait(ctx) {
+			return nil
+		}
+	}
+}
+
+// apply shows the sampled rows in the current order
+func (t *TopView) apply(rows []TopRow) {
+	t.rows = mergeTopRows(t.rows, rows)
+	SortTopRows(t.rows, t.sortBy)
+	t.updated = time.Now()
+}
+
+// wait waits for the next sample, it returns false once the ctx is done
+func (t *TopView) wait(ctx context.Context) bool {
+	timer := time.NewTimer(t.interval)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.refresh:
+		return true
+	case <-timer.C:
+		return true
+	}
+}
+
+func (t *TopView) statusLine() string {
+	if t.updated.IsZero() {
+		return i18n.Sprintf("sampling the hosts...")
+	}
+	return i18n.Sprintf("updated at %s, sorted by %s", t.updated.Format("15:04:05"), t.sortBy)
+}
+
+func (t *TopView) layout(g *gocui.Gui) error {
+	maxX, maxY := g.Size()
+	v, err := g.SetView(topTableView, 0, 0, maxX-1, maxY-4)
+	if err != nil && err != gocui.ErrUnknownView {
+		return err
+	}
+	v.Title = t.title
+	v.Clear()
+
+	// the widths are of every row, so the columns don't move while scrolling
+	var buf bytes.Buffer
+	WriteTopTable(&buf, t.rows)
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	fmt.Fprintln(v, lines[0])
+	_, height := v.Size()
+	if last := len(t.rows) - height + 1; t.offset > last {
+		t.offset = last
+	}
+	if t.offset < 0 {
+		t.offset = 0
+	}
+	for i := t.offset; i < len(t.rows) && i < t.offset+height-1; i++ {
+		if t.rows[i].Err != "" {
+			fmt.Fprintln(v, Colorize(lines[i+1], "red"))
+			continue
+		}
+		fmt.Fprintln(v, lines[i+1])
+	}
+
+	statusV, err := g.SetView(topStatusView, 0, maxY-3, maxX-1, maxY-1)
+	if err != nil && err != gocui.ErrUnknownView {
+		return err
+	}
+	statusV.Title = Keys.help(string(BindSort), "sort", string(BindRefresh), "refresh", string(BindQuit), "quit")
+	statusV.Clear()
+	fmt.Fprint(statusV, t.statusLine())
+
+	_, err = g.SetCurrentView(topTableView)
+	return err
+}
+
+func (t *TopView) registerKeyBind(g *gocui.Gui) error {
+	bindings := map[Binding]func(){
+		BindUp:      func() { t.offset-- },
+		BindDown:    func() { t.offset++ },
+		BindSort:    t.nextSort,
+		BindRefresh: t.sampleNow,
+	}
+	for binding, fn := range bindings {
+		fn := fn
+		if err := Keys.bind(g, topTableView, binding, func(g *gocui.Gui, v *gocui.View) error {
+			fn()
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return Keys.bind(g, topTableView, BindQuit, quit)
+}
+
+// nextSort sorts the rows by the next order of topSorts
+func (t *TopView) nextSort() {
+	i := 0
+	for j, s := range topSorts {
+		if s == t.sortBy {
+			i = j + 1
+		}
+	}
+	t.sortBy = topSorts[i%len(topSorts)]
+	SortTopRows(t.rows, t.sortBy)
+}
+
+// sampleNow samples the hosts once the current sample is done
+func (t *TopView) sampleNow() {
+	select {
+	case t.refresh <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func topHosts(rows []TopRow) []string {
+	var res []string
+	for _, r := range rows {
+		res = append(res, r.Host)
+	}
+	return res
+}
+
+func TestSortTopRows(t *testing.T) {
+	rows := []TopRow{
+		{Host: "web-03", Err: "timeout"},
+		{Host: "web-01", Sampled: true, Load: [3]float64{2}, CPUs: 8, MemUsed: 1, MemTotal: 4, DiskUsed: 9, DiskTotal: 10},
+		{Host: "web-02", Sampled: true, Load: [3]float64{1}, CPUs: 1, MemUsed: 3, MemTotal: 4, DiskUsed: 1, DiskTotal: 10},
+		{Host: "db-01", Sampled: true, Load: [3]float64{1}, CPUs: 1, MemUsed: 3, MemTotal: 4, DiskUsed: 5, DiskTotal: 10},
+	}
+	tests := []struct {
+		by   string
+		want []string
+	}{
+		{by: TopSortLoad, want: []string{"db-01", "web-02", "web-01", "web-03"}},
+		{by: TopSortMem, want: []string{"db-01", "web-02", "web-01", "web-03"}},
+		{by: TopSortDisk, want: []string{"web-01", "db-01", "web-02", "web-03"}},
+		{by: TopSortHost, want: []string{"db-01", "web-01", "web-02", "web-03"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.by, func(t *testing.T) {
+			SortTopRows(rows, tt.by)
+			assert.Equal(t, tt.want, topHosts(rows))
+		})
+	}
+}
+
+func TestWriteTopTable(t *testing.T) {
+	var buf bytes.Buffer
+	WriteTopTable(&buf, []TopRow{
+		{Host: "web-01", Sampled: true, Load: [3]float64{0.5, 1, 1.25}, CPUs: 4, MemUsed: 1 << 30, MemTotal: 4 << 30, DiskUsed: 10 << 30, DiskTotal: 40 << 30},
+		{Host: "web-02", Err: "connection refused"},
+	})
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.Equal(t, []string{"HOST", "LOAD", "CPUS", "MEM", "DISK", "STATUS"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"web-01", "0.50", "1.00", "1.25", "4", "1.0G/4.0G", "25%", "10.0G/40.0G", "25%", "ok"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"web-02", "-", "-", "-", "-", "connection", "refused"}, strings.Fields(lines[2]))
+}
+
+func TestMergeTopRows(t *testing.T) {
+	prev := []TopRow{
+		{Host: "web-01", Sampled: true, CPUs: 4},
+		{Host: "web-02", Sampled: true, CPUs: 2},
+	}
+	next := []TopRow{
+		{Host: "web-01", Err: "timeout"},
+		{Host: "web-02", Sampled: true, CPUs: 8},
+		{Host: "web-03", Err: "timeout"},
+	}
+	assert.Equal(t, []TopRow{
+		{Host: "web-01", Sampled: true, CPUs: 4, Err: "timeout"},
+		{Host: "web-02", Sampled: true, CPUs: 8},
+		{Host: "web-03", Err: "timeout"},
+	}, mergeTopRows(prev, next))
+}
+
+func TestTopView_nextSort(t *testing.T) {
+	v := NewTopView("prod", 0, TopSortDisk, nil)
+	v.nextSort()
+	assert.Equal(t, TopSortHost, v.sortBy)
+	v.nextSort()
+	assert.Equal(t, TopSortLoad, v.sortBy)
+}
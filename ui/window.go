@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/adzimzf/tpot/fold"
+	"github.com/jroimartin/gocui"
+)
+
+const (
+	// filterDelay is how long the picker waits for the next key before
+	// filtering the large inventory, the typed key is shown right away
+	filterDelay = 30 * time.Millisecond
+
+	// syncFilterHosts is the most hosts filtered on every key, it's
+	// cheaper than a frame hence no need to wait for the next key
+	syncFilterHosts = 2000
+)
+
+// screenRows returns the rows of the table, the hosts are listed along them
+func screenRows() int {
+	return max(1, maxScreenY-3)
+}
+
+// cellWidth returns the width of a table cell along with its divider
+func cellWidth() int {
+	if !columnsShown() {
+		return 3 + 60 + 1
+	}
+	width := 3 + hostWidth + 1
+	for _, c := range pickerColumns {
+		width += c.Width + 1
+	}
+	return width
+}
+
+// visibleColumns returns how many table columns of the hosts fit the screen,
+// all of them when the screen size is unknown
+func visibleColumns(hosts, rows int) int {
+	if maxScreenX <= 0 {
+		return max(1, (hosts+rows-1)/rows)
+	}
+	return max(1, (maxScreenX-2)/cellWidth())
+}
+
+// filterHosts returns the hosts matching the keyword, they stay in
+// the order hence the sorted hosts don't need to be sorted again
+func filterHosts(keyword string, hosts []string) []string {
+	m := fold.New(strings.TrimSpace(keyword))
+	res := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		if host != "" && m.Contains(host) {
+			res = append(res, host)
+		}
+	}
+	return res
+}
+
+// apply shows the hosts matching the keyword with the arrow on the first one
+func (s *search) apply(keyword string, matches []string) {
+	s.keyword, s.matches, s.arrow = strings.TrimSpace(keyword), matches, arrowPos{}
+}
+
+// resort orders the hosts again once the picker order is changed
+func (s *search) resort() {
+	s.gen++
+	s.hosts = sortKey(lookup("", s.hosts))
+	s.apply(s.keyword, filterHosts(s.keyword, s.hosts))
+}
+
+// move moves the arrow to the direction, it stays on the hosts of
+// the last table column shorter than the others
+func (s *search) move(dir navDir, a *ArrowNav) {
+	n := len(s.matches)
+	if n == 0 {
+		return
+	}
+	rows := screenRows()
+	next := a.nextPos(s.arrow, maxXY{X: (n + rows - 1) / rows, Y: rows}, dir)
+	if next.X*rows+next.Y >= n {
+		next = arrowPos{X: (n - 1) / rows, Y: (n - 1) % rows}
+	}
+	s.arrow = next
+}
+
+// draw shows the page of the matches where the arrow is
+func (s *search) draw(gui *gocui.Gui) error {
+	resultV, err := gui.View(searchResultView)
+	if err != nil {
+		return err
+	}
+	resultV.Overwrite = true
+	resultV.Clear()
+	_, err = fmt.Fprint(resultV, formatResult(s.matches, s.keyword, s.arrow))
+	return err
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/i18n"
+)
+
+// sourceNames is the names of the node sources shown by the verification
+var sourceNames = map[string]string{
+	config.SourceScrapper: "the web UI",
+	config.SourceTSH:      "tsh ls",
+}
+
+// verifySource fetches the nodes from both the Teleport UI & tsh ls concurrently
+// and reports their differences, such as the scrapper regression or the roles of
+// the web user differ from the tsh identity. The nodes of the primary source
+// are returned, hence the refresh caches the same nodes as without it
+type verifySource struct {
+	// fetch fetches the nodes of the proxy from the source without the status
+	fetch func(proxy *config.Proxy, source string) (config.Node, error)
+
+	// status returns the status of the user logins of the proxy
+	status func(proxy *config.Proxy) (*config.ProxyStatus, error)
+
+	out io.Writer
+}
+
+func newVerifySource(out io.Writer) verifySource {
+	return verifySource{fetch: fetchNodes, status: loginStatus, out: out}
+}
+
+func (v verifySource) LatestNodes(proxy *config.Proxy) (config.Node, error) {
+	sources := []string{config.SourceScrapper, config.SourceTSH}
+	nodes := make([]config.Node, len(sources))
+	errs := make([]error, len(sources))
+	var wg sync.WaitGroup
+	for i, source := range sources {
+		wg.Add(1)
+		go func(i int, source string) {
+			defer wg.Done()
+			nodes[i], errs[i] = v.fetch(proxy, source)
+		}(i, source)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return config.Node{}, i18n.Errorf("failed to verify the nodes of %s by %s, error: %v", proxy.Env, sourceNames[sources[i]], err)
+		}
+	}
+
+	writeNodeDiff(v.out, proxy.Env, nodes[0], nodes[1])
+	res := nodes[0]
+	if primarySource(proxy) == config.SourceTSH {
+		res = nodes[1]
+	}
+	status, err := v.status(proxy)
+	if err != nil {
+		return config.Node{}, err
+	}
+	res.Status = status
+	return res, nil
+}
+
+// writeNodeDiff reports the differences between the nodes of the web UI & tsh ls
+func writeNodeDiff(w io.Writer, env string, web, ls config.Node) {
+	d := config.CompareNodes(web, ls)
+	if d.Empty() {
+		fmt.Fprint(w, i18n.Sprintf("the web UI & tsh ls list the same %d nodes of %s\n", len(web.Items), env))
+		return
+	}
+	fmt.Fprint(w, i18n.Sprintf("the web UI lists %d nodes & tsh ls lists %d nodes of %s, %d hostnames differ:\n",
+		len(web.Items), len(ls.Items), env, d.Len()))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, host := range d.OnlyA {
+		fmt.Fprintf(tw, "  %s\t%s\n", host, i18n.T("only listed by the web UI"))
+	}
+	for _, host := range d.OnlyB {
+		fmt.Fprintf(tw, "  %s\t%s\n", host, i18n.T("only listed by tsh ls"))
+	}
+	for _, c := range d.Changed {
+		fmt.Fprintf(tw, "  %s\t%s\n", c.Hostname, i18n.Sprintf("%s by the web UI, %s by tsh ls", nodeIPList(c.A), nodeIPList(c.B)))
+	}
+	tw.Flush()
+}
+
+// nodeIPList joins the IPs, the tunnel node has no IP
+func nodeIPList(ips []string) string {
+	if len(ips) == 0 {
+		return "tunnel"
+	}
+	return strings.Join(ips, ",")
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adzimzf/tpot/audit"
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/spf13/cobra"
+)
+
+// viaTunnelTimeout is how long the client waits for the tunnel to listen
+const viaTunnelTimeout = 30 * time.Second
+
+const viaExample = `
+tpot via prod 10.1.2.3:8080                         // Pick a production node then forward localhost:8080 to 10.1.2.3:8080 behind it
+tpot via prod bastion-01 10.1.2.3:5432 --psql       // Open psql on the database behind bastion-01 once the tunnel is up
+tpot via prod 10.1.2.3 --mysql -- -u app orders     // Open mysql on 10.1.2.3:3306 along with the arguments after --
+tpot via prod 10.1.2.3 --redis --local-port 16379   // Open redis-cli through the local port 16379
+`
+
+var viaCmd = &cobra.Command{
+	Use:   "via <ENVIRONMENT> [HOST] <TARGET> [-- CLIENT ARGS]",
+	Short: "Reach the internal address through the selected node",
+	Long: `Forward the local port to the internal address behind the node used as the jumpbox, the TARGET is
+<ip>:<port> & the node is picked when the HOST isn't given. --mysql, --psql & --redis open the local client on
+the tunnel once it's up, the port of the TARGET defaults to the one of the client & the arguments after -- are
+passed to the client. The tunnel is closed once the client exits`,
+	Example: viaExample,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var clientArgs []string
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			args, clientArgs = args[:dash], args[dash:]
+		}
+		if len(args) < 2 || len(args) > 3 {
+			return usageErrorf("ENVIRONMENT & TARGET are required")
+		}
+		client, err := viaClientFlag(cmd)
+		if err != nil {
+			return err
+		}
+		if client == nil && len(clientArgs) > 0 {
+			return usageErrorf("the arguments after -- need --mysql, --psql or --redis")
+		}
+
+		defaultPort := 0
+		if client != nil {
+			defaultPort = client.port
+		}
+		target := args[len(args)-1]
+		remoteHost, remotePort, err := parseViaTarget(target, defaultPort)
+		if err != nil {
+			return usageErrorf("invalid TARGET %s, error: %v", target, err)
+		}
+		localPort, _ := cmd.Flags().GetInt("local-port")
+		if localPort == 0 {
+			localPort = remotePort
+		}
+		forward := &config.ForwardingNode{
+			ListenPort: strconv.Itoa(localPort),
+			RemoteHost: remoteHost,
+			RemotePort: strconv.Itoa(remotePort),
+		}
+
+		proxy, err := loadProxy(cmd, args[0])
+		if err != nil {
+			return err
+		}
+		var host string
+		if len(args) == 3 {
+			if host, err = lookUpHost(proxy, args[1]); err != nil {
+				return err
+			}
+		} else {
+			host, _ = selector.SelectHost(proxy, false)
+		}
+		if host == "" {
+			return errNoHost
+		}
+		forward.Host = host
+
+		user, err := getUserLogin(cmd, &proxy.Node)
+		if err != nil {
+			return err
+		}
+
+		if client != nil {
+			if _, err := exec.LookPath(client.binary); err != nil && !tsh.DryRun {
+				return fmt.Errorf("%s isn't found in the PATH, install it to use --%s", client.binary, client.name)
+			}
+		}
+		t := tsh.NewTSH(proxy)
+		if err := openSession(cmd, proxy, t, host); err != nil {
+			return err
+		}
+		if client == nil {
+			f := fwd{
+				tsh:         t,
+				env:         proxy.Env,
+				nodeHost:    host,
+				defaultUser: user,
+				list:        []*config.ForwardingNode{forward},
+			}
+			auditEvent(audit.Event{Action: audit.ActionForward, Env: proxy.Env, Host: host, User: user, Detail: forward.Address()})
+			return f.Run()
+		}
+
+		auditEvent(audit.Event{Action: audit.ActionForward, Env: proxy.Env, Host: host, User: user, Detail: forward.Address() + " " + client.binary})
+		return runViaClient(cmd, t, user, host, forward, client.command(localPort, clientArgs))
+	},
+}
+
+func init() {
+	viaCmd.Flags().Int("local-port", 0, "the local port of the tunnel, default is the port of the TARGET")
+	viaCmd.Flags().StringP("user", "u", "", "user to login to the node")
+	for _, c := range viaClients {
+		viaCmd.Flags().Bool(c.name, false, fmt.Sprintf("open %s on the tunnel once it's up", c.binary))
+	}
+	rootCmd.AddCommand(viaCmd)
+}
+
+// viaClient is the local client opened on the tunnel by tpot via
+type viaClient struct {
+	name   string
+	binary string
+
+	// port is the default port of the TARGET
+	port int
+
+	// hostFlag & portFlag point the client to the tunnel
+	hostFlag, portFlag string
+}
+
+// the local address is 127.0.0.1 since mysql takes localhost as its socket
+var viaClients = []viaClient{
+	{name: "mysql", binary: "mysql", port: 3306, hostFlag: "-h", portFlag: "-P"},
+	{name: "psql", binary: "psql", port: 5432, hostFlag: "-h", portFlag: "-p"},
+	{name: "redis", binary: "redis-cli", port: 6379, hostFlag: "-h", portFlag: "-p"},
+}
+
+// command returns the command line of the client connecting to the local port
+func (c viaClient) command(localPort int, args []string) []string {
+	res := []string{c.binary, c.hostFlag, "127.0.0.1", c.portFlag, strconv.Itoa(localPort)}
+	return append(res, args...)
+}
+
+// viaClientFlag returns the client of the flags, nil when there's none
+func viaClientFlag(cmd *cobra.Command) (*viaClient, error) {
+	var res *viaClient
+	for i, c := range viaClients {
+		if set, _ := cmd.Flags().GetBool(c.name); !set {
+			continue
+		}
+		if res != nil {
+			return nil, usageErrorf("--%s & --%s can't be used together", res.name, c.name)
+		}
+		res = &viaClients[i]
+	}
+	return res, nil
+}
+
+// parseViaTarget parses the TARGET formatted as <ip>:<port>, the
+// defaultPort is used when the TARGET has no port & it isn't 0
+func parseViaTarget(target string, defaultPort int) (string, int, error) {
+	if !strings.Contains(target, ":") {
+		if defaultPort == 0 {
+			return "", 0, fmt.Errorf("the port is required, use <ip>:<port>")
+		}
+		return target, defaultPort, nil
+	}
+	host, port, err := net.SplitHostPort(target)
+	if err != nil {
+		return "", 0, err
+	}
+	if host == "" {
+		return "", 0, fmt.Errorf("the address is required, use <ip>:<port>")
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 1 || n > 65535 {
+		return "", 0, fmt.Errorf("invalid port %s", port)
+	}
+	return host, n, nil
+}
+
+// runViaClient opens the tunnel & runs the client on it once it's listening,
+// the tunnel is closed once the client exits
+func runViaClient(cmd *cobra.Command, t *tsh.TSH, user, host string, forward *config.ForwardingNode, command []string) error {
+	if tsh.DryRun {
+		if err := t.Tunnel(context.Background(), user, host, forward.Address()); err != nil {
+			return err
+		}
+		fmt.Fprintf(tsh.DryRunOutput, "[dry-run] %s\n", strings.Join(command, " "))
+		return nil
+	}
+
+	listen := net.JoinHostPort("127.0.0.1", forward.ListenPort)
+	// tsh only warns once it can't listen, the client would reach the other process
+	l, err := net.Listen("tcp", listen)
+	if err != nil {
+		return fmt.Errorf("the local port %s is in use, use --local-port, error: %v", forward.ListenPort, err)
+	}
+	l.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tunnel := make(chan error, 1)
+	go func() { tunnel <- t.Tunnel(ctx, user, host, forward.Address()) }()
+
+	infof(cmd, "opening the tunnel to %s:%s through %s\n", forward.RemoteHost, forward.RemotePort, host)
+	if err := waitListening(listen, tunnel, viaTunnelTimeout); err != nil {
+		return err
+	}
+
+	c := exec.Command(command[0], command[1:]...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("%s exited, error: %v", command[0], err)
+	}
+	return nil
+}
+
+// waitListening waits until the address accepts the connection, it fails
+// once the tunnel exits or it isn't listening within the timeout
+func waitListening(address string, tunnel <-chan error, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		conn, err := net.DialTimeout("tcp", address, time.Second)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+		select {
+		case err := <-tunnel:
+			return fmt.Errorf("the tunnel is closed, error: %v", err)
+		case <-time.After(200 * time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("the tunnel isn't listening on %s after %s", address, timeout)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Package wsl detects whether tpot runs inside the Windows Subsystem for Linux
+// & adapts the Windows side of it such as the tsh.exe path, the browser
+// & the clipboard which the Linux tools can't reach
+package wsl
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// osRelease is the kernel release, the WSL one contains microsoft
+const osRelease = "/proc/sys/kernel/osrelease"
+
+// MountRoot is where the Windows drives are mounted by default
+const MountRoot = "/mnt/"
+
+var (
+	once     sync.Once
+	detected bool
+)
+
+// Detected returns true if tpot runs inside WSL
+func Detected() bool {
+	once.Do(func() {
+		if os.Getenv("WSL_DISTRO_NAME") != "" {
+			detected = true
+			return
+		}
+		b, err := ioutil.ReadFile(osRelease)
+		detected = err == nil && isWSLRelease(string(b))
+	})
+	return detected
+}
+
+func isWSLRelease(release string) bool {
+	release = strings.ToLower(release)
+	return strings.Contains(release, "microsoft") || strings.Contains(release, "wsl")
+}
+
+// LinuxPath returns the WSL path of the Windows path such as
+// C:\Program Files\Teleport\tsh.exe inside WSL, otherwise the path as is
+func LinuxPath(path string) string {
+	if !Detected() {
+		return path
+	}
+	return toLinuxPath(path)
+}
+
+func toLinuxPath(path string) string {
+	if len(path) < 3 || path[1] != ':' || path[2] != '\\' && path[2] != '/' {
+		return path
+	}
+	drive := strings.ToLower(path[:1])
+	if drive < "a" || drive > "z" {
+		return path
+	}
+	return MountRoot + drive + "/" + strings.Replace(path[3:], `\`, "/", -1)
+}
+
+// browserCommands is the commands opening the link by the Windows browser,
+// rundll32 is always there unlike wslview of wslu
+var browserCommands = []string{
+	"wslview",
+	"rundll32.exe url.dll,FileProtocolHandler",
+}
+
+// BrowserCommand returns the command opening the link by the Windows
+// browser, it's empty outside WSL
+func BrowserCommand() string {
+	if !Detected() {
+		return ""
+	}
+	for _, c := range browserCommands {
+		if _, err := exec.LookPath(strings.Fields(c)[0]); err == nil {
+			return c
+		}
+	}
+	return ""
+}
+
+// IsWindowsBinary returns true if the binary is a Windows one such as tsh.exe
+func IsWindowsBinary(path string) bool {
+	return strings.HasSuffix(strings.ToLower(path), ".exe")
+}
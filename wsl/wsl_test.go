@@ -0,0 +1,35 @@
+package wsl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_isWSLRelease(t *testing.T) {
+	assert.True(t, isWSLRelease("5.15.133.1-microsoft-standard-WSL2\n"))
+	assert.True(t, isWSLRelease("4.4.0-19041-Microsoft\n"))
+	assert.False(t, isWSLRelease("6.5.0-21-generic\n"))
+}
+
+func Test_toLinuxPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: `C:\Program Files\Teleport\tsh.exe`, want: "/mnt/c/Program Files/Teleport/tsh.exe"},
+		{path: `d:/tools/tsh.exe`, want: "/mnt/d/tools/tsh.exe"},
+		{path: "/usr/local/bin/tsh", want: "/usr/local/bin/tsh"},
+		{path: "tsh", want: "tsh"},
+		{path: `C:tsh.exe`, want: `C:tsh.exe`},
+		{path: `1:\tsh.exe`, want: `1:\tsh.exe`},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, toLinuxPath(tt.path), tt.path)
+	}
+}
+
+func TestIsWindowsBinary(t *testing.T) {
+	assert.True(t, IsWindowsBinary("/mnt/c/Program Files/Teleport/tsh.EXE"))
+	assert.False(t, IsWindowsBinary("/usr/local/bin/tsh"))
+}